@@ -0,0 +1,66 @@
+package sdk_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/hookrunner"
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/logging"
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/protocol"
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/validation"
+)
+
+// The stable API: these assignments stop compiling if an exported
+// signature changes, so a breaking change cannot slip into a minor
+// version.
+var (
+	_ func(io.Reader) (*protocol.HookInput, error)      = protocol.ReadInputFrom
+	_ func() (*protocol.HookInput, error)               = protocol.ReadInput
+	_ func(io.Writer, *protocol.HookOutput) error       = protocol.Encode
+	_ func(string) *protocol.HookOutput                 = protocol.Message
+	_ func(event, reason string) *protocol.HookOutput   = protocol.Deny
+	_ func(event, reason string) *protocol.HookOutput   = protocol.Ask
+	_ func(event, text string) *protocol.HookOutput     = protocol.AdditionalContext
+	_ func(*protocol.HookInput) string                  = (*protocol.HookInput).GetFilePath
+	_ func(*protocol.HookInput) string                  = (*protocol.HookInput).GetCommand
+	_ func(*protocol.HookInput) string                  = (*protocol.HookInput).GetPrompt
+	_ func(*protocol.HookInput) string                  = (*protocol.HookInput).GetToolResult
+	_ func(*protocol.HookInput) (int, bool)             = (*protocol.HookInput).GetExitCode
+	_ func(*protocol.ToolResponse) string               = (*protocol.ToolResponse).Stdout
+	_ func(*protocol.ToolResponse) []string             = (*protocol.ToolResponse).Filenames
+	_ func(path, workDir string) (string, error)        = validation.ValidatePath
+	_ func(string) error                                = validation.ValidateWorkDir
+	_ func(string) error                                = validation.ValidateSessionID
+	_ func(base string, paths ...string) string         = validation.SafeJoin
+	_ func() string                                     = validation.GetWorkDir
+	_ func(hookrunner.Hook)                             = hookrunner.Main
+	_ func(hookrunner.Hook, io.Reader, io.Writer) error = hookrunner.Run
+	_ func(*hookrunner.Context, string) error           = (*hookrunner.Context).Deny
+	_ func(*hookrunner.Context, string) error           = (*hookrunner.Context).Message
+	_ func(*hookrunner.Context, string) error           = (*hookrunner.Context).AddContext
+	_ func(*hookrunner.Context) string                  = (*hookrunner.Context).SessionID
+	_ func(logging.Log, interface{}) error              = logging.Log.Append
+	_ func(logging.Log) ([]logging.Entry, error)        = logging.Log.Entries
+
+	_ = hookrunner.Hook{Name: "", SkipInput: false, LogFile: "", Handler: func(*hookrunner.Context) error { return nil }}
+	_ = hookrunner.Context{WorkDir: "", Input: &protocol.HookInput{}}
+)
+
+// TestAPIConstants pins values plugins may have persisted or compared
+// against.
+func TestAPIConstants(t *testing.T) {
+	for name, tt := range map[string]struct{ got, want interface{} }{
+		"protocol.MaxInputSize":         {protocol.MaxInputSize, 10 * 1024 * 1024},
+		"protocol.Empty":                {protocol.Empty, "{}"},
+		"protocol.PermissionAllow":      {protocol.PermissionAllow, "allow"},
+		"protocol.PermissionDeny":       {protocol.PermissionDeny, "deny"},
+		"protocol.PermissionAsk":        {protocol.PermissionAsk, "ask"},
+		"validation.MaxSessionIDLength": {validation.MaxSessionIDLength, 128},
+		"hookrunner.DefaultSessionID":   {hookrunner.DefaultSessionID, "default"},
+		"logging.DefaultMaxBytes":       {logging.DefaultMaxBytes, 1 << 20},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", name, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package sdk is the shared Go SDK for the hooks of plugins in this
+// marketplace, so a plugin does not reimplement stdin/stdout handling and
+// path validation:
+//
+//   - protocol: the hook input Claude Code sends and the output hooks
+//     write, with accessors for each tool's input and response
+//   - hookrunner: runs a hook handler with a validated project directory
+//     and parsed input, writing exactly one output
+//   - validation: path, working directory, and session ID validation
+//   - logging: a rotating JSON Lines log of hook runs
+//
+// The exported API is stable within a major version: packages gain
+// functions, types, and fields, but existing ones keep their signatures and
+// behavior. The compatibility tests pin the API and the wire format.
+package sdk
+
+// Version is the SDK's version.
+const Version = "1.0.0"
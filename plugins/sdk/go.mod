@@ -0,0 +1,3 @@
+module github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk
+
+go 1.21
@@ -0,0 +1,50 @@
+package hookrunner_test
+
+import (
+	"os"
+	"strings"
+
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/hookrunner"
+)
+
+// A PreToolUse hook that blocks recursive deletes. A plugin's main
+// function calls hookrunner.Main(guard) instead of Run.
+func Example() {
+	guard := hookrunner.Hook{
+		Name: "PreToolUse",
+		Handler: func(c *hookrunner.Context) error {
+			if c.Input.ToolName == "Bash" && strings.Contains(c.Input.GetCommand(), "rm -rf") {
+				return c.Deny("Recursive deletes are blocked")
+			}
+			return nil
+		},
+	}
+
+	dir, _ := os.MkdirTemp("", "example")
+	defer os.RemoveAll(dir)
+	os.Setenv("CLAUDE_WORKING_DIRECTORY", dir)
+	defer os.Unsetenv("CLAUDE_WORKING_DIRECTORY")
+
+	input := `{"session_id": "s1", "tool_name": "Bash", "tool_input": {"command": "rm -rf build"}}`
+	hookrunner.Run(guard, strings.NewReader(input), os.Stdout)
+	// Output: {"systemMessage":"Recursive deletes are blocked","hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"deny","permissionDecisionReason":"Recursive deletes are blocked"}}
+}
+
+// A SessionStart hook that gives the agent context about the project.
+func ExampleContext_AddContext() {
+	brief := hookrunner.Hook{
+		Name:      "SessionStart",
+		SkipInput: true,
+		Handler: func(c *hookrunner.Context) error {
+			return c.AddContext("Run make test before committing.")
+		},
+	}
+
+	dir, _ := os.MkdirTemp("", "example")
+	defer os.RemoveAll(dir)
+	os.Setenv("CLAUDE_WORKING_DIRECTORY", dir)
+	defer os.Unsetenv("CLAUDE_WORKING_DIRECTORY")
+
+	hookrunner.Run(brief, strings.NewReader(""), os.Stdout)
+	// Output: {"hookSpecificOutput":{"hookEventName":"SessionStart","additionalContext":"Run make test before committing."}}
+}
@@ -0,0 +1,166 @@
+// Package hookrunner runs a hook: it resolves and validates the project
+// directory, reads the hook input from stdin, calls the hook's handler,
+// and writes its output, optionally logging each run. A hook never fails
+// the tool call: Main reports errors as a system message and exits 0.
+package hookrunner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/logging"
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/protocol"
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/validation"
+)
+
+// DefaultSessionID is the session ID of input without a valid one.
+const DefaultSessionID = "default"
+
+// Hook describes a hook.
+type Hook struct {
+	// Name is the Claude Code event name, e.g. "PreToolUse"
+	Name string
+	// SkipInput runs the handler without reading stdin
+	SkipInput bool
+	// LogFile, if set, is the JSON Lines log each run is appended to,
+	// relative to the project directory, e.g. ".claude/my-plugin-log.jsonl"
+	LogFile string
+	// Handler does the hook's work. Writing no output lets Claude Code
+	// carry on as usual.
+	Handler func(c *Context) error
+}
+
+// Context is what a handler works with.
+type Context struct {
+	// WorkDir is the validated project directory
+	WorkDir string
+	// Input is the hook input; empty when the hook skips input
+	Input  *protocol.HookInput
+	hook   string
+	stdout io.Writer
+	// output is what the handler wrote; nil until it writes
+	output *protocol.HookOutput
+}
+
+// SessionID returns the input's session ID if it is safe to use in file
+// names, or DefaultSessionID.
+func (c *Context) SessionID() string {
+	if validation.ValidateSessionID(c.Input.SessionID) != nil {
+		return DefaultSessionID
+	}
+	return c.Input.SessionID
+}
+
+// Write writes the hook's output, filling in the event name of
+// hook-specific output. Only the first write is sent; later ones are
+// ignored.
+func (c *Context) Write(output *protocol.HookOutput) error {
+	if c.output != nil {
+		return nil
+	}
+	if output == nil {
+		output = &protocol.HookOutput{}
+	}
+	if specific := output.HookSpecificOutput; specific != nil && specific.HookEventName == "" {
+		specific.HookEventName = c.hook
+	}
+	c.output = output
+	return protocol.Encode(c.stdout, output)
+}
+
+// Message shows message to the user.
+func (c *Context) Message(message string) error {
+	return c.Write(protocol.Message(message))
+}
+
+// Deny blocks a PreToolUse tool call, telling the agent why.
+func (c *Context) Deny(reason string) error {
+	return c.Write(protocol.Deny(c.hook, reason))
+}
+
+// Ask has the user confirm a PreToolUse tool call.
+func (c *Context) Ask(reason string) error {
+	return c.Write(protocol.Ask(c.hook, reason))
+}
+
+// AddContext adds text to the conversation for the agent.
+func (c *Context) AddContext(text string) error {
+	return c.Write(protocol.AdditionalContext(c.hook, text))
+}
+
+// Main runs h as the process's hook and exits 0. An error from a handler
+// that wrote no output is shown to the user as a system message.
+func Main(h Hook) {
+	stdout := &trackingWriter{w: os.Stdout}
+	if err := Run(h, os.Stdin, stdout); err != nil && !stdout.written {
+		protocol.Encode(os.Stdout, protocol.Message(fmt.Sprintf("[%s] Hook error: %v", h.Name, err)))
+	}
+	os.Exit(0)
+}
+
+// trackingWriter records whether anything was written, so Main never
+// writes a second JSON object.
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
+
+// Run calls h's handler with input read from stdin and writes its output
+// to stdout, or protocol.Empty if it wrote none. Without a valid project
+// directory, or with unreadable input, the hook is skipped and writes
+// protocol.Empty.
+func Run(h Hook, stdin io.Reader, stdout io.Writer) error {
+	workDir := validation.GetWorkDir()
+	if validation.ValidateWorkDir(workDir) != nil {
+		return protocol.Encode(stdout, nil)
+	}
+
+	c := &Context{WorkDir: workDir, Input: &protocol.HookInput{}, hook: h.Name, stdout: stdout}
+	if !h.SkipInput {
+		input, err := protocol.ReadInputFrom(stdin)
+		if err != nil {
+			return protocol.Encode(stdout, nil)
+		}
+		c.Input = input
+	}
+
+	start := time.Now()
+	err := h.Handler(c)
+	if err == nil && c.output == nil {
+		err = protocol.Encode(stdout, nil)
+	}
+	if h.LogFile != "" {
+		writeLog(c, h, time.Since(start), err)
+	}
+	return err
+}
+
+// writeLog appends the run to the hook's log. Logging failures are
+// ignored.
+func writeLog(c *Context, h Hook, elapsed time.Duration, runErr error) {
+	path := validation.SafeJoin(c.WorkDir, h.LogFile)
+	if path == "" {
+		return
+	}
+	entry := logging.Entry{
+		Time:       time.Now(),
+		Hook:       h.Name,
+		Tool:       c.Input.ToolName,
+		SessionID:  c.Input.SessionID,
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+	}
+	if c.output != nil {
+		entry.Event, _ = c.output.Metadata["event"].(string)
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	logging.Log{Path: path}.Append(entry)
+}
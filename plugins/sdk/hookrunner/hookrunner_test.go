@@ -0,0 +1,160 @@
+package hookrunner
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/logging"
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/protocol"
+)
+
+// setupProject points the hook at a new project directory.
+func setupProject(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "hookrunner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Setenv("CLAUDE_WORKING_DIRECTORY", dir)
+	return dir
+}
+
+func run(t *testing.T, h Hook, input string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := Run(h, strings.NewReader(input), &out)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	input := `{"session_id": "s1", "tool_name": "Bash", "tool_input": {"command": "rm -rf /"}}`
+	tests := []struct {
+		name    string
+		handler func(c *Context) error
+		want    string
+	}{
+		{"no output", func(c *Context) error { return nil }, `{}`},
+		{
+			"message",
+			func(c *Context) error { return c.Message("ran " + c.Input.GetCommand()) },
+			`{"systemMessage":"ran rm -rf /"}`,
+		},
+		{
+			"deny",
+			func(c *Context) error { return c.Deny("dangerous") },
+			`{"systemMessage":"dangerous","hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"deny","permissionDecisionReason":"dangerous"}}`,
+		},
+		{
+			"ask",
+			func(c *Context) error { return c.Ask("sure?") },
+			`{"systemMessage":"sure?","hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"ask","permissionDecisionReason":"sure?"}}`,
+		},
+		{
+			"add context",
+			func(c *Context) error { return c.AddContext("session " + c.SessionID()) },
+			`{"hookSpecificOutput":{"hookEventName":"PreToolUse","additionalContext":"session s1"}}`,
+		},
+		{
+			"only the first write is sent",
+			func(c *Context) error {
+				c.Message("first")
+				return c.Message("second")
+			},
+			`{"systemMessage":"first"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupProject(t)
+			out, err := run(t, Hook{Name: "PreToolUse", Handler: tt.handler}, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tt.want {
+				t.Errorf("output = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSkips(t *testing.T) {
+	called := false
+	h := Hook{Name: "Stop", Handler: func(c *Context) error {
+		called = true
+		return nil
+	}}
+
+	t.Run("invalid work dir", func(t *testing.T) {
+		t.Setenv("CLAUDE_WORKING_DIRECTORY", "relative/dir")
+		if out, err := run(t, h, "{}"); err != nil || out != protocol.Empty || called {
+			t.Errorf("Run() = %q, %v, want the hook skipped", out, err)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		setupProject(t)
+		if out, err := run(t, h, "{"); err != nil || out != protocol.Empty || called {
+			t.Errorf("Run() = %q, %v, want the hook skipped", out, err)
+		}
+	})
+
+	t.Run("skip input", func(t *testing.T) {
+		setupProject(t)
+		h := h
+		h.SkipInput = true
+		if _, err := run(t, h, "{"); err != nil || !called {
+			t.Errorf("Run() with SkipInput = %v, want the handler called without reading input", err)
+		}
+	})
+}
+
+func TestSessionID(t *testing.T) {
+	for id, want := range map[string]string{"abc-123": "abc-123", "": DefaultSessionID, "../etc": DefaultSessionID} {
+		c := &Context{Input: &protocol.HookInput{SessionID: id}}
+		if got := c.SessionID(); got != want {
+			t.Errorf("SessionID() for %q = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir := setupProject(t)
+	h := Hook{Name: "PostToolUse", LogFile: ".claude/test-log.jsonl", Handler: func(c *Context) error {
+		if c.Input.ToolName == "Bash" {
+			return errors.New("boom")
+		}
+		return c.Write(&protocol.HookOutput{Metadata: protocol.Metadata{"event": "checked"}})
+	}}
+
+	run(t, h, `{"session_id": "s1", "tool_name": "Read"}`)
+	if _, err := run(t, h, `{"session_id": "s1", "tool_name": "Bash"}`); err == nil || err.Error() != "boom" {
+		t.Errorf("Run() = %v, want the handler's error", err)
+	}
+
+	entries, err := logging.Log{Path: filepath.Join(dir, ".claude", "test-log.jsonl")}.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("log has %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e.Hook != "PostToolUse" || e.Tool != "Read" || e.SessionID != "s1" || e.Event != "checked" {
+		t.Errorf("entry = %+v, want the Read run with its event", e)
+	}
+	if e := entries[1]; e.Error != "boom" {
+		t.Errorf("entry = %+v, want the error", e)
+	}
+
+	t.Run("outside the project", func(t *testing.T) {
+		h.LogFile = "../escape.jsonl"
+		run(t, h, `{"tool_name": "Read"}`)
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.jsonl")); err == nil {
+			t.Error("Run() wrote a log outside the project")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Package logging appends hook runs to a JSON Lines log, one Entry per
+// line, rotating the log to a single backup once it reaches a size limit so
+// it never grows without bound.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultMaxBytes is the size at which a log is rotated when no limit is
+// given.
+const DefaultMaxBytes = 1 << 20
+
+// FilePermission is the permission for log files
+const FilePermission = 0600
+
+// DirPermission is the permission for the log directory
+const DirPermission = 0700
+
+// Entry is one hook run.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Hook      string    `json:"hook"`
+	Tool      string    `json:"tool,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	Event     string    `json:"event,omitempty"`
+	DryRun    bool      `json:"dry_run,omitempty"`
+	// Withheld is the advisory kept from a headless session
+	Withheld   string  `json:"withheld,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Log is a JSON Lines file.
+type Log struct {
+	Path string
+	// MaxBytes is the size at which the log is moved to Path + ".1",
+	// replacing an older backup; DefaultMaxBytes if zero
+	MaxBytes int64
+}
+
+// Append writes v as one line, rotating the log first if it is full and
+// creating its directory if needed.
+func (l Log) Append(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	max := l.MaxBytes
+	if max <= 0 {
+		max = DefaultMaxBytes
+	}
+	if info, err := os.Stat(l.Path); err == nil && info.Size() >= max {
+		if err := os.Rename(l.Path, l.Path+".1"); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), DirPermission); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, FilePermission)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Entries reads the log's entries, oldest first. Lines that are not valid
+// entries are skipped; a missing log has none.
+func (l Log) Entries() ([]Entry, error) {
+	data, err := os.ReadFile(l.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e Entry
+		if json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
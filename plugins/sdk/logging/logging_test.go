@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logging-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	l := Log{Path: filepath.Join(tmpDir, ".claude", "hook-log.jsonl"), MaxBytes: 100}
+	if entries, err := l.Entries(); err != nil || entries != nil {
+		t.Errorf("Entries() of a missing log = %v, %v, want none", entries, err)
+	}
+
+	for _, hook := range []string{"PreToolUse", "PostToolUse", "Stop"} {
+		if err := l.Append(Entry{Hook: hook, DurationMS: 1.5}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each entry is over 50 bytes, so the third append rotated the first two
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Hook != "Stop" {
+		t.Errorf("Entries() = %+v, want only the entry after rotation", entries)
+	}
+	backup, err := Log{Path: l.Path + ".1"}.Entries()
+	if err != nil || len(backup) != 2 || backup[0].Hook != "PreToolUse" {
+		t.Errorf("backup Entries() = %+v, %v, want the first two entries", backup, err)
+	}
+
+	info, err := os.Stat(l.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != FilePermission {
+		t.Errorf("log permission = %o, want %o", perm, FilePermission)
+	}
+}
+
+func TestEntriesSkipsInvalidLines(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logging-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "log.jsonl")
+	os.WriteFile(path, []byte("{\"hook\":\"Stop\"}\nnot json\n\n{\"hook\":\"PreCompact\"}"), 0600)
+	entries, err := Log{Path: path}.Entries()
+	if err != nil || len(entries) != 2 || entries[1].Hook != "PreCompact" {
+		t.Errorf("Entries() = %+v, %v, want the two valid entries", entries, err)
+	}
+}
@@ -0,0 +1,143 @@
+package protocol
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// The compatibility tests pin the wire format: inputs in the shape Claude
+// Code sends them, in testdata/, must keep parsing to the same values, and
+// outputs must keep encoding to the same JSON. A failure here means a
+// change would break plugins built on the SDK.
+
+func readFixture(t *testing.T, name string) *HookInput {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	input, err := ReadInputFrom(f)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return input
+}
+
+func TestInputCompatibility(t *testing.T) {
+	t.Run("pre_tool_use_bash", func(t *testing.T) {
+		in := readFixture(t, "pre_tool_use_bash.json")
+		if in.SessionID != "abc123" || in.HookEventName != "PreToolUse" || in.Cwd != "/home/user/project" ||
+			in.ToolName != "Bash" || in.GetCommand() != "go test ./..." || in.GetDescription() != "Run the tests" ||
+			in.PermissionMode != "default" || in.AutoApprovesEdits() || in.ToolResponse != nil {
+			t.Errorf("parsed %+v", in)
+		}
+	})
+
+	t.Run("pre_tool_use_edit", func(t *testing.T) {
+		in := readFixture(t, "pre_tool_use_edit.json")
+		if in.GetFilePath() != "/home/user/project/main.go" || in.GetOldString() != "foo" ||
+			in.GetNewString() != "bar" || !in.GetReplaceAll() || !in.AutoApprovesEdits() {
+			t.Errorf("parsed %+v", in)
+		}
+	})
+
+	t.Run("post_tool_use_bash", func(t *testing.T) {
+		in := readFixture(t, "post_tool_use_bash.json")
+		r := in.ToolResponse
+		code, ok := in.GetExitCode()
+		if r.Stdout() != "--- FAIL: TestX" || r.Stderr() != "exit status 1" || r.Interrupted() ||
+			!ok || code != 1 || in.GetToolResult() != "--- FAIL: TestX\nexit status 1" {
+			t.Errorf("parsed %+v (exit code %d, %v)", r, code, ok)
+		}
+	})
+
+	t.Run("post_tool_use_read", func(t *testing.T) {
+		in := readFixture(t, "post_tool_use_read.json")
+		if got := in.ToolResponse.FileContent(); got != "module demo\n" {
+			t.Errorf("FileContent() = %q", got)
+		}
+	})
+
+	t.Run("post_tool_use_glob", func(t *testing.T) {
+		in := readFixture(t, "post_tool_use_glob.json")
+		want := []string{"/home/user/project/main.go", "/home/user/project/main_test.go"}
+		if got := in.ToolResponse.Filenames(); !reflect.DeepEqual(got, want) || in.GetPattern() != "**/*.go" {
+			t.Errorf("Filenames() = %v, pattern %q", got, in.GetPattern())
+		}
+	})
+
+	t.Run("post_tool_use_string", func(t *testing.T) {
+		in := readFixture(t, "post_tool_use_string.json")
+		code, ok := in.GetExitCode()
+		if in.ToolResponse.Fields != nil || in.GetToolResult() != "Error: Exit code 2\nboom" || !ok || code != 2 {
+			t.Errorf("parsed %+v (exit code %d, %v)", in.ToolResponse, code, ok)
+		}
+	})
+
+	t.Run("user_prompt_submit", func(t *testing.T) {
+		in := readFixture(t, "user_prompt_submit.json")
+		if in.GetPrompt() != "Add a retry to the client" || in.HookEventName != "UserPromptSubmit" {
+			t.Errorf("parsed %+v", in)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		in := readFixture(t, "stop.json")
+		if in.HookEventName != "Stop" || in.ToolName != "" || in.GetStopReason() != "" {
+			t.Errorf("parsed %+v", in)
+		}
+	})
+}
+
+func TestOutputCompatibility(t *testing.T) {
+	tests := []struct {
+		name   string
+		output *HookOutput
+		want   string
+	}{
+		{"empty", nil, `{}`},
+		{"message", Message("Tests are failing"), `{"systemMessage":"Tests are failing"}`},
+		{
+			"deny",
+			Deny("PreToolUse", "Edits to .env are blocked"),
+			`{"systemMessage":"Edits to .env are blocked","hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"deny","permissionDecisionReason":"Edits to .env are blocked"}}`,
+		},
+		{
+			"ask",
+			Ask("PreToolUse", "Push to main?"),
+			`{"systemMessage":"Push to main?","hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"ask","permissionDecisionReason":"Push to main?"}}`,
+		},
+		{
+			"additional context",
+			AdditionalContext("SessionStart", "3 features pending"),
+			`{"hookSpecificOutput":{"hookEventName":"SessionStart","additionalContext":"3 features pending"}}`,
+		},
+		{
+			"updated input with metadata",
+			&HookOutput{
+				HookSpecificOutput: &HookSpecificOutput{
+					HookEventName:      "PreToolUse",
+					PermissionDecision: PermissionAllow,
+					UpdatedInput:       map[string]interface{}{"command": "go test ./..."},
+				},
+				Metadata: Metadata{"event": "input_updated"},
+			},
+			`{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"allow","updatedInput":{"command":"go test ./..."}},"metadata":{"event":"input_updated"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, tt.output); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Encode() = %s\nwant %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
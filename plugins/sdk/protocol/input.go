@@ -0,0 +1,401 @@
+// Package protocol is the JSON Claude Code exchanges with hooks: the input
+// a hook reads from stdin, with accessors for the tool input and response
+// of each tool, and the output it writes to stdout.
+//
+// The field names and accessors are stable: they only gain fields, never
+// change or lose them, and the compatibility tests pin the wire format.
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// MaxInputSize limits stdin to 10MB to prevent DoS attacks
+const MaxInputSize = 10 * 1024 * 1024
+
+// HookInput represents the JSON input from Claude Code to hooks
+type HookInput struct {
+	SessionID string `json:"session_id"`
+	// HookEventName is the event the hook runs for, e.g. "PreToolUse"
+	HookEventName string `json:"hook_event_name,omitempty"`
+	// Cwd is the session's working directory
+	Cwd       string                 `json:"cwd,omitempty"`
+	ToolName  string                 `json:"tool_name"`
+	ToolInput map[string]interface{} `json:"tool_input"`
+	// ToolResponse is the tool's result, a string or an object depending
+	// on the tool; nil if the input has none
+	ToolResponse *ToolResponse `json:"tool_response,omitempty"`
+	// ToolResult is the legacy string form of the result; prefer
+	// GetToolResult, which falls back to ToolResponse
+	ToolResult string `json:"tool_result,omitempty"`
+	// PermissionMode is the session's permission mode, e.g. "default",
+	// "acceptEdits", "plan", or "bypassPermissions"
+	PermissionMode string `json:"permission_mode,omitempty"`
+
+	// UserPromptSubmit-specific fields
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// ToolResponse is a tool's result. Claude Code sends a plain string for some
+// tools and an object for others, such as {"stdout": ..., "stderr": ...,
+// "interrupted": false} for Bash.
+type ToolResponse struct {
+	// Text is the string response, or the readable part of an object
+	// response: stdout and stderr, or its output or content field
+	Text string
+	// Fields holds an object response; nil for string responses
+	Fields map[string]interface{}
+}
+
+// exitCodeKeys are the object fields that may carry a command's exit code
+var exitCodeKeys = []string{"exit_code", "exitCode", "returnCode", "return_code"}
+
+// exitCodePattern finds the exit code Claude Code reports for a failed
+// command in a string response
+var exitCodePattern = regexp.MustCompile(`(?m)^(?:Error: )?Exit code (\d+)\b`)
+
+// UnmarshalJSON accepts a string or an object. Other JSON values are kept
+// as their raw text.
+func (r *ToolResponse) UnmarshalJSON(data []byte) error {
+	*r = ToolResponse{}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")):
+		return nil
+	case trimmed[0] == '"':
+		return json.Unmarshal(trimmed, &r.Text)
+	case trimmed[0] == '{':
+		if err := json.Unmarshal(trimmed, &r.Fields); err != nil {
+			return err
+		}
+		r.Text = r.objectText(string(trimmed))
+		return nil
+	}
+	r.Text = string(trimmed)
+	return nil
+}
+
+// MarshalJSON writes the response back in the form it was read.
+func (r ToolResponse) MarshalJSON() ([]byte, error) {
+	if r.Fields != nil {
+		return json.Marshal(r.Fields)
+	}
+	return json.Marshal(r.Text)
+}
+
+func (r *ToolResponse) objectText(raw string) string {
+	if stdout, stderr := r.Stdout(), r.Stderr(); stdout != "" || stderr != "" {
+		if stdout != "" && stderr != "" {
+			return stdout + "\n" + stderr
+		}
+		return stdout + stderr
+	}
+	for _, key := range []string{"output", "content", "result"} {
+		if text, ok := r.Fields[key].(string); ok {
+			return text
+		}
+	}
+	return raw
+}
+
+func (r *ToolResponse) stringField(key string) string {
+	if r == nil {
+		return ""
+	}
+	s, _ := r.Fields[key].(string)
+	return s
+}
+
+// Stdout returns a Bash response's standard output
+func (r *ToolResponse) Stdout() string {
+	return r.stringField("stdout")
+}
+
+// Stderr returns a Bash response's standard error
+func (r *ToolResponse) Stderr() string {
+	return r.stringField("stderr")
+}
+
+// Interrupted reports whether a Bash command was interrupted
+func (r *ToolResponse) Interrupted() bool {
+	if r == nil {
+		return false
+	}
+	interrupted, _ := r.Fields["interrupted"].(bool)
+	return interrupted
+}
+
+// ExitCode returns a command's exit code, from an exit code field of an
+// object response or an "Exit code N" line in a string response. ok is
+// false if the response does not say.
+func (r *ToolResponse) ExitCode() (code int, ok bool) {
+	if r == nil {
+		return 0, false
+	}
+	for _, key := range exitCodeKeys {
+		if n, isNumber := r.Fields[key].(float64); isNumber {
+			return int(n), true
+		}
+	}
+	if r.Fields == nil {
+		if m := exitCodePattern.FindStringSubmatch(r.Text); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// FileContent returns the file text of a Read response: the content of its
+// file object, or the text of a string response.
+func (r *ToolResponse) FileContent() string {
+	if r == nil {
+		return ""
+	}
+	if file, ok := r.Fields["file"].(map[string]interface{}); ok {
+		content, _ := file["content"].(string)
+		return content
+	}
+	if r.Fields == nil {
+		return r.Text
+	}
+	return ""
+}
+
+// Filenames returns the matched paths of a Grep or Glob response, or nil
+// if the response does not list them.
+func (r *ToolResponse) Filenames() []string {
+	if r == nil {
+		return nil
+	}
+	list, _ := r.Fields["filenames"].([]interface{})
+	var names []string
+	for _, v := range list {
+		if name, ok := v.(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ReadInput reads and parses JSON from stdin with size limiting
+func ReadInput() (*HookInput, error) {
+	return ReadInputFrom(os.Stdin)
+}
+
+// ReadInputFrom reads and parses JSON from r with size limiting
+func ReadInputFrom(r io.Reader) (*HookInput, error) {
+	reader := io.LimitReader(r, MaxInputSize)
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	// Handle empty input gracefully
+	if len(data) == 0 {
+		return &HookInput{}, nil
+	}
+
+	var input HookInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return &input, nil
+}
+
+// AutoApprovesEdits reports whether the session accepts file edits without
+// asking, so allowing a rewritten Edit or Write grants nothing new
+func (h *HookInput) AutoApprovesEdits() bool {
+	return h.PermissionMode == "acceptEdits" || h.PermissionMode == "bypassPermissions"
+}
+
+// GetToolResult returns the tool's result as text, from tool_result or
+// tool_response
+func (h *HookInput) GetToolResult() string {
+	if h.ToolResult != "" {
+		return h.ToolResult
+	}
+	if h.ToolResponse == nil {
+		return ""
+	}
+	return h.ToolResponse.Text
+}
+
+// GetExitCode returns the exit code of a Bash command, if the tool response
+// reports one
+func (h *HookInput) GetExitCode() (int, bool) {
+	if h.ToolResponse != nil {
+		if code, ok := h.ToolResponse.ExitCode(); ok {
+			return code, true
+		}
+	}
+	if h.ToolResult != "" {
+		return (&ToolResponse{Text: h.ToolResult}).ExitCode()
+	}
+	return 0, false
+}
+
+// GetFilePath extracts file_path from tool input, returns empty string if not present
+func (h *HookInput) GetFilePath() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if path, ok := h.ToolInput["file_path"].(string); ok {
+		return path
+	}
+	return ""
+}
+
+// GetCommand extracts command from tool input (for Bash), returns empty string if not present
+func (h *HookInput) GetCommand() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if cmd, ok := h.ToolInput["command"].(string); ok {
+		return cmd
+	}
+	return ""
+}
+
+// GetContent extracts content from tool input (for Write), returns empty string if not present
+func (h *HookInput) GetContent() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if content, ok := h.ToolInput["content"].(string); ok {
+		return content
+	}
+	return ""
+}
+
+// GetOldString extracts old_string from tool input (for Edit), returns empty string if not present
+func (h *HookInput) GetOldString() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if s, ok := h.ToolInput["old_string"].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// GetNewString extracts new_string from tool input (for Edit), returns empty string if not present
+func (h *HookInput) GetNewString() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if s, ok := h.ToolInput["new_string"].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// GetReplaceAll extracts replace_all from tool input (for Edit), returns false if not present
+func (h *HookInput) GetReplaceAll() bool {
+	if h.ToolInput == nil {
+		return false
+	}
+	if r, ok := h.ToolInput["replace_all"].(bool); ok {
+		return r
+	}
+	return false
+}
+
+// GetPattern extracts pattern from tool input (for Grep/Glob), returns empty string if not present
+func (h *HookInput) GetPattern() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if pattern, ok := h.ToolInput["pattern"].(string); ok {
+		return pattern
+	}
+	return ""
+}
+
+// GetPrompt extracts prompt from input (for UserPromptSubmit), returns empty string if not present
+func (h *HookInput) GetPrompt() string {
+	// Check top-level prompt field first (UserPromptSubmit format)
+	if h.Prompt != "" {
+		return h.Prompt
+	}
+	// Fallback to tool_input for backwards compatibility
+	if h.ToolInput == nil {
+		return ""
+	}
+	if prompt, ok := h.ToolInput["prompt"].(string); ok {
+		return prompt
+	}
+	return ""
+}
+
+// GetSubagentType extracts subagent_type from tool input (for SubagentStop), returns empty string if not present
+func (h *HookInput) GetSubagentType() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if t, ok := h.ToolInput["subagent_type"].(string); ok {
+		return t
+	}
+	return ""
+}
+
+// GetDescription extracts description from tool input (for SubagentStop), returns empty string if not present
+func (h *HookInput) GetDescription() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if d, ok := h.ToolInput["description"].(string); ok {
+		return d
+	}
+	return ""
+}
+
+// GetOutput extracts output from tool input (for SubagentStop), returns empty string if not present
+func (h *HookInput) GetOutput() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if o, ok := h.ToolInput["output"].(string); ok {
+		return o
+	}
+	return ""
+}
+
+// GetStopReason extracts stopReason or reason from tool input (for Stop), returns empty string if not present
+func (h *HookInput) GetStopReason() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if r, ok := h.ToolInput["stopReason"].(string); ok {
+		return r
+	}
+	if r, ok := h.ToolInput["reason"].(string); ok {
+		return r
+	}
+	return ""
+}
+
+// GetTranscript extracts transcript or conversation_transcript from tool input
+func (h *HookInput) GetTranscript() string {
+	if h.ToolInput == nil {
+		return ""
+	}
+	if t, ok := h.ToolInput["transcript"].(string); ok {
+		return t
+	}
+	if t, ok := h.ToolInput["conversation_transcript"].(string); ok {
+		return t
+	}
+	if t, ok := h.ToolInput["transcript_path"].(string); ok {
+		return t
+	}
+	return ""
+}
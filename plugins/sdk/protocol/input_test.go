@@ -0,0 +1,484 @@
+package protocol
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHookInputGetters(t *testing.T) {
+	t.Run("GetFilePath", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input HookInput
+			want  string
+		}{
+			{
+				name:  "nil tool input",
+				input: HookInput{ToolInput: nil},
+				want:  "",
+			},
+			{
+				name:  "no file_path key",
+				input: HookInput{ToolInput: map[string]interface{}{"other": "value"}},
+				want:  "",
+			},
+			{
+				name:  "file_path not string",
+				input: HookInput{ToolInput: map[string]interface{}{"file_path": 123}},
+				want:  "",
+			},
+			{
+				name:  "valid file_path",
+				input: HookInput{ToolInput: map[string]interface{}{"file_path": "/path/to/file.txt"}},
+				want:  "/path/to/file.txt",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.input.GetFilePath(); got != tt.want {
+					t.Errorf("GetFilePath() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("GetPattern", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input HookInput
+			want  string
+		}{
+			{
+				name:  "nil tool input",
+				input: HookInput{ToolInput: nil},
+				want:  "",
+			},
+			{
+				name:  "pattern not string",
+				input: HookInput{ToolInput: map[string]interface{}{"pattern": 42}},
+				want:  "",
+			},
+			{
+				name:  "valid pattern",
+				input: HookInput{ToolInput: map[string]interface{}{"pattern": "func main"}},
+				want:  "func main",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.input.GetPattern(); got != tt.want {
+					t.Errorf("GetPattern() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("Edit and Write inputs", func(t *testing.T) {
+		input := HookInput{ToolInput: map[string]interface{}{
+			"content":     "package main",
+			"old_string":  "foo",
+			"new_string":  "bar",
+			"replace_all": true,
+		}}
+
+		if got := input.GetContent(); got != "package main" {
+			t.Errorf("GetContent() = %v, want 'package main'", got)
+		}
+		if got := input.GetOldString(); got != "foo" {
+			t.Errorf("GetOldString() = %v, want 'foo'", got)
+		}
+		if got := input.GetNewString(); got != "bar" {
+			t.Errorf("GetNewString() = %v, want 'bar'", got)
+		}
+		if !input.GetReplaceAll() {
+			t.Error("GetReplaceAll() = false, want true")
+		}
+
+		empty := HookInput{}
+		if empty.GetContent() != "" || empty.GetOldString() != "" || empty.GetNewString() != "" || empty.GetReplaceAll() {
+			t.Error("getters on nil tool input should return zero values")
+		}
+	})
+
+	t.Run("GetCommand", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input HookInput
+			want  string
+		}{
+			{
+				name:  "nil tool input",
+				input: HookInput{ToolInput: nil},
+				want:  "",
+			},
+			{
+				name:  "no command key",
+				input: HookInput{ToolInput: map[string]interface{}{"other": "value"}},
+				want:  "",
+			},
+			{
+				name:  "valid command",
+				input: HookInput{ToolInput: map[string]interface{}{"command": "ls -la"}},
+				want:  "ls -la",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.input.GetCommand(); got != tt.want {
+					t.Errorf("GetCommand() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("GetPrompt", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input HookInput
+			want  string
+		}{
+			{
+				name:  "top-level prompt field",
+				input: HookInput{Prompt: "research this topic"},
+				want:  "research this topic",
+			},
+			{
+				name:  "prompt in tool_input (fallback)",
+				input: HookInput{ToolInput: map[string]interface{}{"prompt": "legacy prompt"}},
+				want:  "legacy prompt",
+			},
+			{
+				name:  "top-level takes precedence",
+				input: HookInput{Prompt: "top-level", ToolInput: map[string]interface{}{"prompt": "in-tool"}},
+				want:  "top-level",
+			},
+			{
+				name:  "no prompt anywhere",
+				input: HookInput{ToolInput: map[string]interface{}{"other": "value"}},
+				want:  "",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.input.GetPrompt(); got != tt.want {
+					t.Errorf("GetPrompt() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("GetSubagentType", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input HookInput
+			want  string
+		}{
+			{
+				name:  "nil tool input",
+				input: HookInput{ToolInput: nil},
+				want:  "",
+			},
+			{
+				name:  "valid subagent_type",
+				input: HookInput{ToolInput: map[string]interface{}{"subagent_type": "fic-researcher"}},
+				want:  "fic-researcher",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.input.GetSubagentType(); got != tt.want {
+					t.Errorf("GetSubagentType() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("GetDescription", func(t *testing.T) {
+		input := HookInput{ToolInput: map[string]interface{}{"description": "task description"}}
+		if got := input.GetDescription(); got != "task description" {
+			t.Errorf("GetDescription() = %v, want 'task description'", got)
+		}
+	})
+
+	t.Run("GetOutput", func(t *testing.T) {
+		input := HookInput{ToolInput: map[string]interface{}{"output": "agent output"}}
+		if got := input.GetOutput(); got != "agent output" {
+			t.Errorf("GetOutput() = %v, want 'agent output'", got)
+		}
+	})
+
+	t.Run("GetStopReason", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input HookInput
+			want  string
+		}{
+			{
+				name:  "stopReason field",
+				input: HookInput{ToolInput: map[string]interface{}{"stopReason": "end_turn"}},
+				want:  "end_turn",
+			},
+			{
+				name:  "reason field fallback",
+				input: HookInput{ToolInput: map[string]interface{}{"reason": "stop_sequence"}},
+				want:  "stop_sequence",
+			},
+			{
+				name:  "stopReason takes precedence",
+				input: HookInput{ToolInput: map[string]interface{}{"stopReason": "end_turn", "reason": "other"}},
+				want:  "end_turn",
+			},
+			{
+				name:  "no stop reason",
+				input: HookInput{ToolInput: map[string]interface{}{}},
+				want:  "",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.input.GetStopReason(); got != tt.want {
+					t.Errorf("GetStopReason() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestHookInputJSONParsing(t *testing.T) {
+	t.Run("parse full input", func(t *testing.T) {
+		jsonData := `{
+			"session_id": "test-session-123",
+			"tool_name": "Edit",
+			"tool_input": {
+				"file_path": "/path/to/file.txt",
+				"old_string": "foo",
+				"new_string": "bar"
+			}
+		}`
+
+		var input HookInput
+		if err := json.Unmarshal([]byte(jsonData), &input); err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+
+		if input.SessionID != "test-session-123" {
+			t.Errorf("SessionID = %v, want 'test-session-123'", input.SessionID)
+		}
+		if input.ToolName != "Edit" {
+			t.Errorf("ToolName = %v, want 'Edit'", input.ToolName)
+		}
+		if input.GetFilePath() != "/path/to/file.txt" {
+			t.Errorf("GetFilePath() = %v, want '/path/to/file.txt'", input.GetFilePath())
+		}
+	})
+
+	t.Run("parse UserPromptSubmit format", func(t *testing.T) {
+		jsonData := `{
+			"session_id": "session-456",
+			"prompt": "What is the architecture of this codebase?"
+		}`
+
+		var input HookInput
+		if err := json.Unmarshal([]byte(jsonData), &input); err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+
+		if input.GetPrompt() != "What is the architecture of this codebase?" {
+			t.Errorf("GetPrompt() = %v, want prompt", input.GetPrompt())
+		}
+	})
+
+	t.Run("parse empty input", func(t *testing.T) {
+		jsonData := `{}`
+
+		var input HookInput
+		if err := json.Unmarshal([]byte(jsonData), &input); err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+
+		if input.SessionID != "" {
+			t.Errorf("SessionID = %v, want empty", input.SessionID)
+		}
+		if input.GetFilePath() != "" {
+			t.Errorf("GetFilePath() = %v, want empty", input.GetFilePath())
+		}
+	})
+}
+
+func TestToolResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		wantText   string
+		wantCode   int
+		wantCodeOK bool
+		wantStderr string
+		wantFields bool
+		wantInterr bool
+	}{
+		{
+			name:     "string",
+			json:     `{"tool_response": "file contents"}`,
+			wantText: "file contents",
+		},
+		{
+			name:       "bash object",
+			json:       `{"tool_response": {"stdout": "ok", "stderr": "warn", "interrupted": false}}`,
+			wantText:   "ok\nwarn",
+			wantStderr: "warn",
+			wantFields: true,
+		},
+		{
+			name:       "exit code field",
+			json:       `{"tool_response": {"stdout": "FAIL", "exit_code": 1}}`,
+			wantText:   "FAIL",
+			wantCode:   1,
+			wantCodeOK: true,
+			wantFields: true,
+		},
+		{
+			name:       "exit code in string",
+			json:       `{"tool_response": "Error: Exit code 2\nboom"}`,
+			wantText:   "Error: Exit code 2\nboom",
+			wantCode:   2,
+			wantCodeOK: true,
+		},
+		{
+			name:       "interrupted",
+			json:       `{"tool_response": {"stdout": "", "interrupted": true}}`,
+			wantText:   `{"stdout": "", "interrupted": true}`,
+			wantFields: true,
+			wantInterr: true,
+		},
+		{
+			name:       "output field",
+			json:       `{"tool_response": {"output": "done", "exitCode": 0}}`,
+			wantText:   "done",
+			wantCodeOK: true,
+			wantFields: true,
+		},
+		{
+			name:       "legacy tool_result",
+			json:       `{"tool_result": "Exit code 3"}`,
+			wantText:   "Exit code 3",
+			wantCode:   3,
+			wantCodeOK: true,
+		},
+		{
+			name: "missing",
+			json: `{"tool_name": "Bash"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input HookInput
+			if err := json.Unmarshal([]byte(tt.json), &input); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got := input.GetToolResult(); got != tt.wantText {
+				t.Errorf("GetToolResult() = %q, want %q", got, tt.wantText)
+			}
+			code, ok := input.GetExitCode()
+			if code != tt.wantCode || ok != tt.wantCodeOK {
+				t.Errorf("GetExitCode() = %d, %v, want %d, %v", code, ok, tt.wantCode, tt.wantCodeOK)
+			}
+			if got := input.ToolResponse.Stderr(); got != tt.wantStderr {
+				t.Errorf("Stderr() = %q, want %q", got, tt.wantStderr)
+			}
+			if got := input.ToolResponse.Interrupted(); got != tt.wantInterr {
+				t.Errorf("Interrupted() = %v, want %v", got, tt.wantInterr)
+			}
+			if hasFields := input.ToolResponse != nil && input.ToolResponse.Fields != nil; hasFields != tt.wantFields {
+				t.Errorf("Fields set = %v, want %v", hasFields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestToolResponseRoundTrip(t *testing.T) {
+	for _, raw := range []string{`"text"`, `{"stdout":"ok"}`} {
+		var r ToolResponse
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", raw, err)
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if string(data) != raw {
+			t.Errorf("Marshal(Unmarshal(%s)) = %s", raw, data)
+		}
+	}
+}
+
+func TestToolResponseFiles(t *testing.T) {
+	var read, grep, text ToolResponse
+	json.Unmarshal([]byte(`{"type": "text", "file": {"filePath": "/p/a.go", "content": "package a"}}`), &read)
+	json.Unmarshal([]byte(`{"mode": "files_with_matches", "filenames": ["/p/a.go", "/p/b.go"], "numFiles": 2}`), &grep)
+	json.Unmarshal([]byte(`"package b"`), &text)
+
+	if got := read.FileContent(); got != "package a" {
+		t.Errorf("FileContent() = %q, want %q", got, "package a")
+	}
+	if got := text.FileContent(); got != "package b" {
+		t.Errorf("FileContent() of string = %q, want %q", got, "package b")
+	}
+	if got := grep.FileContent(); got != "" {
+		t.Errorf("FileContent() of Grep = %q, want empty", got)
+	}
+	if got := grep.Filenames(); len(got) != 2 || got[1] != "/p/b.go" {
+		t.Errorf("Filenames() = %v, want [/p/a.go /p/b.go]", got)
+	}
+	var none *ToolResponse
+	if none.FileContent() != "" || none.Filenames() != nil {
+		t.Error("nil ToolResponse should have no content or filenames")
+	}
+}
+
+func TestMaxInputSize(t *testing.T) {
+	// Verify the constant is set to a reasonable limit
+	if MaxInputSize != 10*1024*1024 {
+		t.Errorf("MaxInputSize = %d, want 10MB (10485760)", MaxInputSize)
+	}
+}
+
+func TestAutoApprovesEdits(t *testing.T) {
+	tests := []struct {
+		mode string
+		want bool
+	}{
+		{"", false},
+		{"default", false},
+		{"plan", false},
+		{"acceptEdits", true},
+		{"bypassPermissions", true},
+	}
+
+	for _, tt := range tests {
+		input := &HookInput{PermissionMode: tt.mode}
+		if got := input.AutoApprovesEdits(); got != tt.want {
+			t.Errorf("AutoApprovesEdits(%q) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestReadInputFrom(t *testing.T) {
+	input, err := ReadInputFrom(strings.NewReader(`{"session_id": "s1", "tool_name": "Read"}`))
+	if err != nil || input.SessionID != "s1" || input.ToolName != "Read" {
+		t.Errorf("ReadInputFrom() = %+v, %v, want session s1 and tool Read", input, err)
+	}
+	if input, err := ReadInputFrom(strings.NewReader("")); err != nil || input == nil {
+		t.Errorf("ReadInputFrom() of empty input = %v, %v, want empty input", input, err)
+	}
+	if _, err := ReadInputFrom(strings.NewReader("{")); err == nil {
+		t.Error("ReadInputFrom() of invalid JSON should fail")
+	}
+}
@@ -0,0 +1,96 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// HookOutput represents the JSON output from hooks to Claude Code
+type HookOutput struct {
+	SystemMessage      string              `json:"systemMessage,omitempty"`
+	HookSpecificOutput *HookSpecificOutput `json:"hookSpecificOutput,omitempty"`
+	Metadata           Metadata            `json:"metadata,omitempty"`
+}
+
+// Metadata is machine-readable detail about a hook's output, so tooling and
+// tests can assert on behavior without parsing the message text. Claude
+// Code ignores it.
+type Metadata map[string]interface{}
+
+// HookSpecificOutput contains hook-specific decisions
+type HookSpecificOutput struct {
+	// HookEventName is the event the output is for, e.g. "PreToolUse"
+	HookEventName      string `json:"hookEventName,omitempty"`
+	PermissionDecision string `json:"permissionDecision,omitempty"` // "allow", "deny", or "ask"
+	// PermissionDecisionReason is shown to the user for "ask" and to the
+	// agent for "deny"
+	PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
+	// UpdatedInput replaces the tool input before the tool runs
+	UpdatedInput map[string]interface{} `json:"updatedInput,omitempty"`
+	// AdditionalContext is added to the conversation for the agent
+	// (UserPromptSubmit, SessionStart, and PostToolUse)
+	AdditionalContext string `json:"additionalContext,omitempty"`
+}
+
+// PermissionDecision constants
+const (
+	PermissionAllow = "allow"
+	PermissionDeny  = "deny"
+	// PermissionAsk prompts the user to confirm the tool call
+	PermissionAsk = "ask"
+)
+
+// Empty is the output of a hook with nothing to say.
+const Empty = "{}"
+
+// Encode writes output to w as JSON, or Empty for a nil output.
+func Encode(w io.Writer, output *HookOutput) error {
+	if output == nil {
+		_, err := io.WriteString(w, Empty)
+		return err
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Message is an informational system message for the user.
+func Message(message string) *HookOutput {
+	return &HookOutput{SystemMessage: message}
+}
+
+// Deny blocks a PreToolUse tool call, telling the agent and the user why.
+func Deny(event, reason string) *HookOutput {
+	return &HookOutput{
+		SystemMessage: reason,
+		HookSpecificOutput: &HookSpecificOutput{
+			HookEventName:            event,
+			PermissionDecision:       PermissionDeny,
+			PermissionDecisionReason: reason,
+		},
+	}
+}
+
+// Ask has the user confirm a PreToolUse tool call, showing reason.
+func Ask(event, reason string) *HookOutput {
+	return &HookOutput{
+		SystemMessage: reason,
+		HookSpecificOutput: &HookSpecificOutput{
+			HookEventName:            event,
+			PermissionDecision:       PermissionAsk,
+			PermissionDecisionReason: reason,
+		},
+	}
+}
+
+// AdditionalContext adds text to the conversation for the agent.
+func AdditionalContext(event, text string) *HookOutput {
+	return &HookOutput{HookSpecificOutput: &HookSpecificOutput{
+		HookEventName:     event,
+		AdditionalContext: text,
+	}}
+}
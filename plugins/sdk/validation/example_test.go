@@ -0,0 +1,23 @@
+package validation_test
+
+import (
+	"fmt"
+
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/validation"
+)
+
+func ExampleSafeJoin() {
+	fmt.Printf("%q\n", validation.SafeJoin("/project", ".claude", "state.json"))
+	fmt.Printf("%q\n", validation.SafeJoin("/project", "../etc/passwd"))
+	// Output:
+	// "/project/.claude/state.json"
+	// ""
+}
+
+func ExampleValidateSessionID() {
+	fmt.Println(validation.ValidateSessionID("abc-123"))
+	fmt.Println(validation.ValidateSessionID("../../etc"))
+	// Output:
+	// <nil>
+	// session ID contains invalid characters
+}
@@ -0,0 +1,13 @@
+// Command api_changes runs "ultraharness api_changes" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "api_changes", Run: cli.APIChanges}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command approve runs "ultraharness approve" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "approve", Run: cli.Approve}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command assumptions runs "ultraharness assumptions" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "assumptions", Run: cli.Assumptions}, os.Args[1:])
+}
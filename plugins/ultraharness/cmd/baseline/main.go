@@ -0,0 +1,13 @@
+// Command baseline runs "ultraharness baseline" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "baseline", Run: cli.Baseline}, os.Args[1:])
+}
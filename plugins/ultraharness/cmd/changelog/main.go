@@ -0,0 +1,13 @@
+// Command changelog runs "ultraharness changelog" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "changelog", Run: cli.Changelog}, os.Args[1:])
+}
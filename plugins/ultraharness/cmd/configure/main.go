@@ -0,0 +1,13 @@
+// Command configure runs "ultraharness configure" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "configure", Run: cli.Configure}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command decision runs "ultraharness decision" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "decision", Run: cli.Decision}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command handoff runs "ultraharness handoff" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "handoff", Run: cli.Handoff}, os.Args[1:])
+}
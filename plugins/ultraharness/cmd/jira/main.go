@@ -0,0 +1,13 @@
+// Command jira runs "ultraharness jira" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "jira", Run: cli.Jira}, os.Args[1:])
+}
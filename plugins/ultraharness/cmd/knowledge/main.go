@@ -0,0 +1,13 @@
+// Command knowledge runs "ultraharness knowledge" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "knowledge", Run: cli.Knowledge}, os.Args[1:])
+}
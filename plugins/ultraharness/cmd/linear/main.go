@@ -0,0 +1,13 @@
+// Command linear runs "ultraharness linear" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "linear", Run: cli.Linear}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command mcp runs "ultraharness mcp" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "mcp", Run: cli.MCP}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command new_plugin runs "ultraharness new_plugin" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "new_plugin", Run: cli.NewPlugin}, os.Args[1:])
+}
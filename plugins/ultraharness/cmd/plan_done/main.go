@@ -0,0 +1,13 @@
+// Command plan_done runs "ultraharness plan_done" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "plan_done", Run: cli.PlanDone}, os.Args[1:])
+}
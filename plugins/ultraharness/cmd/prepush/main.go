@@ -0,0 +1,13 @@
+// Command prepush runs "ultraharness prepush" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "prepush", Run: cli.Prepush}, os.Args[1:])
+}
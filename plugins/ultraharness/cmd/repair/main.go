@@ -0,0 +1,13 @@
+// Command repair runs "ultraharness repair" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "repair", Run: cli.Repair}, os.Args[1:])
+}
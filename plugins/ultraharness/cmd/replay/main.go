@@ -0,0 +1,13 @@
+// Command replay runs "ultraharness replay" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "replay", Run: cli.Replay}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command report runs "ultraharness report" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "report", Run: cli.Report}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command research_done runs "ultraharness research_done" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "research_done", Run: cli.ResearchDone}, os.Args[1:])
+}
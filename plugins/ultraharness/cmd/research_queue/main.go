@@ -0,0 +1,13 @@
+// Command research_queue runs "ultraharness research-queue" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "research_queue", Run: cli.ResearchQueue}, os.Args[1:])
+}
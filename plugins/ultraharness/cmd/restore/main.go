@@ -0,0 +1,13 @@
+// Command restore runs "ultraharness restore" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "restore", Run: cli.Restore}, os.Args[1:])
+}
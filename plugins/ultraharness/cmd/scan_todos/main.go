@@ -0,0 +1,13 @@
+// Command scan_todos runs "ultraharness scan-todos" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "scan_todos", Run: cli.ScanTodos}, os.Args[1:])
+}
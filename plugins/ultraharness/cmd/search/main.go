@@ -0,0 +1,13 @@
+// Command search runs "ultraharness search" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "search", Run: cli.Search}, os.Args[1:])
+}
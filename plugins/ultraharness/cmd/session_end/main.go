@@ -0,0 +1,13 @@
+// Command session_end runs "ultraharness hook session-end" as a standalone
+// binary, for hook registrations that predate the single ultraharness
+// binary.
+package main
+
+import (
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/hooks/sessionend"
+)
+
+func main() {
+	hookrunner.Main(sessionend.Hook)
+}
@@ -0,0 +1,13 @@
+// Command snapshot runs "ultraharness snapshot" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "snapshot", Run: cli.Snapshot}, os.Args[1:])
+}
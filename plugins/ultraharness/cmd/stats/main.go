@@ -0,0 +1,13 @@
+// Command stats runs "ultraharness stats" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "stats", Run: cli.Stats}, os.Args[1:])
+}
@@ -0,0 +1,13 @@
+// Command test_affected runs "ultraharness test_affected" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "test_affected", Run: cli.TestAffected}, os.Args[1:])
+}
@@ -0,0 +1,78 @@
+// Ultraharness runs every hook and command from one binary.
+//
+// Usage:
+//
+//	ultraharness hook NAME      run a hook, reading its input from stdin
+//	ultraharness COMMAND [ARGS] run a command, e.g. report or configure
+//
+// Hook and command names take dashes or underscores (post-tool-use,
+// post_tool_use). Invoked through a symlink named after a hook or command,
+// e.g. bin/linux-amd64/post_tool_use, it runs that hook or command, so
+// existing hook registrations keep working.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ultraharness/internal/cli"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/hooks"
+)
+
+func main() {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	args := os.Args[1:]
+	if name != "ultraharness" {
+		if h, ok := hooks.Lookup(name); ok {
+			hookrunner.Main(h)
+		}
+		if cmd, ok := cli.Lookup(name); ok {
+			cli.Main(cmd, args)
+		}
+	}
+
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage()
+		os.Exit(0)
+	}
+	if args[0] == "hook" {
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "ultraharness: usage: ultraharness hook NAME (one of %s)\n", hookNames())
+			os.Exit(1)
+		}
+		h, ok := hooks.Lookup(args[1])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "ultraharness: unknown hook %q (want one of %s)\n", args[1], hookNames())
+			os.Exit(1)
+		}
+		hookrunner.Main(h)
+	}
+	cmd, ok := cli.Lookup(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ultraharness: unknown command %q (run \"ultraharness help\" for a list)\n", args[0])
+		os.Exit(1)
+	}
+	cli.Main(cmd, args[1:])
+}
+
+// hookNames lists the hooks, dashed as in "ultraharness hook post-tool-use".
+func hookNames() string {
+	return strings.ReplaceAll(strings.Join(hooks.Names, ", "), "_", "-")
+}
+
+func usage() {
+	fmt.Println("Usage: ultraharness hook NAME | ultraharness COMMAND [ARGS]")
+	fmt.Println()
+	fmt.Println("Hooks:")
+	for _, name := range hooks.Names {
+		fmt.Printf("  %s\n", strings.ReplaceAll(name, "_", "-"))
+	}
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, cmd := range cli.Commands {
+		fmt.Printf("  %-16s %s\n", strings.ReplaceAll(cmd.Name, "_", "-"), cmd.Summary)
+	}
+}
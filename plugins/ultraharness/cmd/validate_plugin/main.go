@@ -0,0 +1,13 @@
+// Command validate_plugin runs "ultraharness validate_plugin" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "validate_plugin", Run: cli.ValidatePlugin}, os.Args[1:])
+}
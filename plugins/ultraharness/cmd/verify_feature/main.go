@@ -0,0 +1,13 @@
+// Command verify_feature runs "ultraharness verify-feature" as a standalone binary,
+// for installs that predate the single ultraharness binary.
+package main
+
+import (
+	"os"
+
+	"ultraharness/internal/cli"
+)
+
+func main() {
+	cli.Main(cli.Command{Name: "verify_feature", Run: cli.VerifyFeature}, os.Args[1:])
+}
@@ -0,0 +1,12 @@
+// Package e2e tests the compiled hooks end to end. Each input fixture in
+// testdata/<hook>/ is piped to the hook binary in a fresh workspace for
+// every strictness mode and FIC phase, and the exact JSON output is
+// compared with the fixture's golden file, so changes to what the hooks
+// say or decide show up in review.
+//
+// After an intended change, rewrite the golden files with
+//
+//	go test ./e2e -update
+//
+// and review the diff.
+package e2e
@@ -0,0 +1,225 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/config"
+	"ultraharness/internal/hooks"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// workPlaceholder stands for the workspace directory in fixtures and
+// golden files.
+const workPlaceholder = "$WORK"
+
+// timePlaceholder replaces timestamps, which differ between runs, in
+// golden files.
+const timePlaceholder = "$TIME"
+
+var (
+	timestampPattern     = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:\d{2})?`)
+	tokenEstimatePattern = regexp.MustCompile(`"token_estimate":\d+`)
+)
+
+// strictnesses and phases are the combinations every fixture runs in.
+var (
+	strictnesses = []string{config.StrictnessRelaxed, config.StrictnessStandard, config.StrictnessStrict}
+	phases       = []string{"NEW_SESSION", "RESEARCH", "PLANNING", "IMPLEMENTATION"}
+)
+
+// workspaceFiles are the project files of every workspace.
+var workspaceFiles = map[string]string{
+	"README.md":  "# app\n",
+	"go.mod":     "module example.com/app\n\ngo 1.21\n",
+	"src/app.go": "package app\n\n// Retry calls fn up to three times.\nfunc Retry(fn func() error) error {\n\tfor i := 0; i < 3; i++ {\n\t\tif fn() == nil {\n\t\t\treturn nil\n\t\t}\n\t}\n\treturn nil\n}\n",
+}
+
+// binDir holds the ultraharness binary and a symlink to it per hook, as
+// installed in bin/<platform>/.
+var binDir string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	dir, err := os.MkdirTemp("", "ultraharness-e2e-bin")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	build := exec.Command("go", "build", "-o", filepath.Join(dir, "ultraharness"), "../cmd/ultraharness")
+	if out, err := build.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "building ultraharness: %v\n%s", err, out)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	for _, name := range hooks.Names {
+		if err := os.Symlink("ultraharness", filepath.Join(dir, name)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.RemoveAll(dir)
+			os.Exit(1)
+		}
+	}
+	binDir = dir
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestHooks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the hook binaries")
+	}
+	for _, hook := range hooks.Names {
+		fixtures, err := filepath.Glob(filepath.Join("testdata", hook, "*.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fixtures) == 0 {
+			t.Errorf("no fixtures for %s in testdata/%s", hook, hook)
+		}
+		for _, fixture := range fixtures {
+			name := strings.TrimSuffix(filepath.Base(fixture), ".json")
+			t.Run(hook+"/"+name, func(t *testing.T) {
+				input, err := os.ReadFile(fixture)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got bytes.Buffer
+				for _, strictness := range strictnesses {
+					for _, phase := range phases {
+						out := runHook(t, hook, input, strictness, phase)
+						fmt.Fprintf(&got, "# %s %s\n%s\n\n", strictness, phase, out)
+					}
+				}
+				compareGolden(t, strings.TrimSuffix(fixture, ".json")+".golden", got.Bytes())
+			})
+		}
+	}
+}
+
+// runHook runs hook with input in a new workspace set to strictness and
+// phase, and returns its output with the workspace path replaced by
+// workPlaceholder.
+func runHook(t *testing.T, hook string, input []byte, strictness, phase string) string {
+	t.Helper()
+	work := newWorkspace(t, strictness, phase)
+
+	cmd := exec.Command(filepath.Join(binDir, hook))
+	cmd.Dir = work
+	cmd.Stdin = bytes.NewReader(bytes.ReplaceAll(input, []byte(workPlaceholder), []byte(work)))
+	// A minimal environment, so the developer's or CI's settings do not
+	// change the output
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + t.TempDir(),
+		"USER=e2e",
+		"CLAUDE_WORKING_DIRECTORY=" + work,
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%s (%s, %s): %v\n%s", hook, strictness, phase, err, stderr.String())
+	}
+	if !json.Valid(out) {
+		t.Errorf("%s (%s, %s) wrote invalid JSON: %s", hook, strictness, phase, out)
+	}
+	scrubbed := strings.ReplaceAll(string(out), work, workPlaceholder)
+	scrubbed = timestampPattern.ReplaceAllLiteralString(scrubbed, timePlaceholder)
+	// Token estimates count the workspace path, whose length varies
+	return tokenEstimatePattern.ReplaceAllLiteralString(scrubbed, `"token_estimate":0`)
+}
+
+// newWorkspace creates an initialized project with workspaceFiles, the
+// given strictness, and the artifacts that put it in phase.
+func newWorkspace(t *testing.T, strictness, phase string) string {
+	t.Helper()
+	// A fixed name, since the project tree shows it
+	work := filepath.Join(t.TempDir(), "app")
+	for name, content := range workspaceFiles {
+		path := filepath.Join(work, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	claudeDir := filepath.Join(work, ".claude")
+	if err := os.MkdirAll(claudeDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := json.Marshal(map[string]string{"strictness": strictness})
+	if err := os.WriteFile(filepath.Join(claudeDir, config.ConfigFileName), cfg, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, config.InitMarkerFileName), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	switch phase {
+	case "RESEARCH":
+		err = artifacts.SaveArtifact(work, artifacts.ArtifactResearch, &artifacts.Research{ID: "r1", ConfidenceScore: 0.5})
+	case "PLANNING":
+		err = artifacts.SaveArtifact(work, artifacts.ArtifactPlan, &artifacts.Plan{ID: "p1"})
+	case "IMPLEMENTATION":
+		err = artifacts.SaveArtifact(work, artifacts.ArtifactImplementation, &artifacts.Implementation{ID: "i1", PlanArtifactID: "p1"})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := artifacts.GetCurrentPhase(work); got != phase {
+		t.Fatalf("workspace phase = %s, want %s", got, phase)
+	}
+	return work
+}
+
+// compareGolden compares got with the golden file at path, or rewrites
+// the file with -update.
+func compareGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./e2e -update to create it)", err)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	gotSections := strings.Split(string(got), "\n\n")
+	wantSections := strings.Split(string(want), "\n\n")
+	for i := range gotSections {
+		if i >= len(wantSections) || gotSections[i] != wantSections[i] {
+			want := ""
+			if i < len(wantSections) {
+				want = wantSections[i]
+			}
+			t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, gotSections[i], want)
+		}
+	}
+	if len(wantSections) > len(gotSections) {
+		t.Errorf("output differs from %s: %d sections missing", path, len(wantSections)-len(gotSections))
+	}
+	t.Log("If the change is intended, run go test ./e2e -update and review the diff.")
+}
@@ -1,3 +1,7 @@
 module ultraharness
 
 go 1.21
+
+require github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk v0.0.0
+
+replace github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk => ../sdk
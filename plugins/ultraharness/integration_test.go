@@ -214,15 +214,15 @@ func TestProgressLogIntegration(t *testing.T) {
 
 	t.Run("Log entries across workflow phases", func(t *testing.T) {
 		// Log research activity
-		progress.Append("RESEARCH: Explored codebase structure", workDir)
-		progress.Append("RESEARCH: Identified key files", workDir)
+		progress.Append(progress.SourceAgent, "RESEARCH: Explored codebase structure", workDir)
+		progress.Append(progress.SourceAgent, "RESEARCH: Identified key files", workDir)
 
 		// Log planning activity
-		progress.Append("PLAN: Created implementation strategy", workDir)
+		progress.Append(progress.SourceAgent, "PLAN: Created implementation strategy", workDir)
 
 		// Log implementation activity
-		progress.Append("IMPLEMENT: Created new component", workDir)
-		progress.Append("IMPLEMENT: Added tests", workDir)
+		progress.Append(progress.SourceAgent, "IMPLEMENT: Created new component", workDir)
+		progress.Append(progress.SourceAgent, "IMPLEMENT: Added tests", workDir)
 
 		// Verify entries by reading file
 		content, err := progress.Read(workDir)
@@ -387,7 +387,7 @@ func TestGateMessageFormatting(t *testing.T) {
 
 	t.Run("Warn message includes suggestions", func(t *testing.T) {
 		result := gates.CheckGate(gates.GateAllowEdit, workDir, "standard")
-		msg := gates.FormatGateMessage(result)
+		msg := gates.FormatGateMessage(workDir, result)
 
 		if msg == "" {
 			t.Error("Message should not be empty for warn")
@@ -401,7 +401,7 @@ func TestGateMessageFormatting(t *testing.T) {
 
 	t.Run("Allow message is empty", func(t *testing.T) {
 		result := gates.CheckGate(gates.GateAllowEdit, workDir, "relaxed")
-		msg := gates.FormatGateMessage(result)
+		msg := gates.FormatGateMessage(workDir, result)
 
 		if msg != "" {
 			t.Errorf("Allow message should be empty, got: %s", msg)
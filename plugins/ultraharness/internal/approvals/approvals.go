@@ -0,0 +1,361 @@
+// Package approvals tracks files created by the agent in review mode that
+// must be approved by a human before the agent may modify them further,
+// and dependency additions held for approval in strict mode.
+//
+// The queue is a plain JSON file so reviewers can approve files by editing
+// it directly as well as through the approve command.
+package approvals
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the approval queue file inside .claude.
+const FileName = "approvals.json"
+
+// FilePermission is the permission for the approval queue
+const FilePermission = 0600
+
+// DirPermission is the permission for the queue directory
+const DirPermission = 0700
+
+// Approval statuses.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+)
+
+// Entry is one file in the approval queue.
+type Entry struct {
+	Path        string     `json:"path"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	SessionID   string     `json:"session_id,omitempty"`
+	ApprovedAt  *time.Time `json:"approved_at,omitempty"`
+	ApprovedBy  string     `json:"approved_by,omitempty"`
+}
+
+// DependencyEntry is one dependency addition in the approval queue.
+type DependencyEntry struct {
+	// Name identifies the dependency as ecosystem/name, e.g. "npm/lodash"
+	Name        string     `json:"name"`
+	Change      string     `json:"change"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	SessionID   string     `json:"session_id,omitempty"`
+	ApprovedAt  *time.Time `json:"approved_at,omitempty"`
+	ApprovedBy  string     `json:"approved_by,omitempty"`
+}
+
+// Queue is the set of files and dependencies awaiting or granted approval.
+type Queue struct {
+	Files        []Entry           `json:"files"`
+	Dependencies []DependencyEntry `json:"dependencies,omitempty"`
+}
+
+// GetPath returns the path to the approval queue file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// IsQueueFile reports whether path is the approval queue itself.
+func IsQueueFile(workDir, path string) bool {
+	return Normalize(workDir, path) == ".claude/"+FileName
+}
+
+// commandSeparators split a shell command into simple commands
+var commandSeparators = regexp.MustCompile(`&&|\|\||;|\||\n`)
+
+// outputRedirection matches >, >>, and &> redirections, which need not be
+// separated from their target or the word before them
+var outputRedirection = regexp.MustCompile(`(?:\d*|&)>>?`)
+
+// CommandWrites reports whether a shell command writes the approval
+// queue: redirects output to it, or names it as a file for tee, sed -i,
+// cp, or mv to write. This is a best-effort reading of the command text;
+// writes through variables, scripts, or other programs are not caught.
+func CommandWrites(workDir, command string) bool {
+	for _, target := range writeTargets(command) {
+		target = strings.Trim(target, `"'`)
+		if IsQueueFile(workDir, target) || strings.HasSuffix(filepath.ToSlash(target), "/.claude/"+FileName) {
+			return true
+		}
+	}
+	return false
+}
+
+// approveRunners are the programs that run the approve command as their
+// first argument: the platform launcher and the single binary
+var approveRunners = map[string]bool{"run-hook": true, "ultraharness": true}
+
+// CommandApproves reports whether a shell command runs the approve
+// command, through bin/run-hook, the ultraharness binary, or the approve
+// symlink, also inside sh -c or go run. Approving is for humans, so the
+// agent may not run it in any form; like CommandWrites this reads the
+// command text.
+func CommandApproves(command string) bool {
+	for _, segment := range commandSeparators.Split(command, -1) {
+		fields := strings.Fields(segment)
+		for i, field := range fields {
+			word := strings.Trim(field, `"'`)
+			name := strings.TrimSuffix(filepath.Base(filepath.ToSlash(word)), ".exe")
+			switch {
+			case approveRunners[name] && i+1 < len(fields) && strings.Trim(fields[i+1], `"'`) == "approve":
+				return true
+			case name == "approve" && (i == 0 || strings.Contains(word, "/")):
+				// The approve symlink, or go run ./cmd/approve
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeTargets returns the files a shell command may write.
+func writeTargets(command string) []string {
+	var targets []string
+	for _, segment := range commandSeparators.Split(command, -1) {
+		fields := strings.Fields(outputRedirection.ReplaceAllString(segment, " $0 "))
+		var args []string
+		for i := 0; i < len(fields); i++ {
+			if outputRedirection.FindString(fields[i]) != fields[i] {
+				args = append(args, fields[i])
+			} else if i+1 < len(fields) {
+				// Duplications such as 2>&1 give targets like "&1",
+				// which are never the queue
+				i++
+				targets = append(targets, fields[i])
+			}
+		}
+		for len(args) > 0 && (strings.Contains(args[0], "=") || args[0] == "sudo") {
+			args = args[1:] // environment assignments
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		var files []string
+		inPlace := false
+		for _, arg := range args[1:] {
+			if strings.HasPrefix(arg, "-") {
+				inPlace = inPlace || strings.HasPrefix(arg, "-i") || strings.HasPrefix(arg, "--in-place")
+				continue
+			}
+			files = append(files, arg)
+		}
+		switch filepath.Base(args[0]) {
+		case "tee":
+			targets = append(targets, files...)
+		case "sed", "perl":
+			if inPlace {
+				targets = append(targets, files...)
+			}
+		case "cp", "mv", "install":
+			if len(files) > 1 {
+				targets = append(targets, files[len(files)-1])
+			}
+		}
+	}
+	return targets
+}
+
+// Load reads the approval queue. A missing file is an empty queue.
+func Load(workDir string) (*Queue, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Queue{}, nil
+		}
+		return nil, err
+	}
+
+	var q Queue
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// Save writes the approval queue.
+func (q *Queue) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+
+	sort.Slice(q.Files, func(i, j int) bool { return q.Files[i].Path < q.Files[j].Path })
+	sort.Slice(q.Dependencies, func(i, j int) bool { return q.Dependencies[i].Name < q.Dependencies[j].Name })
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Normalize returns path relative to workDir with forward slashes.
+func Normalize(workDir, path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(workDir, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// Find returns the entry for path, or nil if the file is not queued.
+func (q *Queue) Find(workDir, path string) *Entry {
+	path = Normalize(workDir, path)
+	for i := range q.Files {
+		if q.Files[i].Path == path {
+			return &q.Files[i]
+		}
+	}
+	return nil
+}
+
+// IsPending reports whether path is waiting for approval.
+func (q *Queue) IsPending(workDir, path string) bool {
+	e := q.Find(workDir, path)
+	return e != nil && e.Status != StatusApproved
+}
+
+// Request adds path to the queue as pending. Files already queued are left
+// unchanged. Returns true if an entry was added.
+func (q *Queue) Request(workDir, path, sessionID string) bool {
+	if q.Find(workDir, path) != nil {
+		return false
+	}
+	q.Files = append(q.Files, Entry{
+		Path:        Normalize(workDir, path),
+		Status:      StatusPending,
+		RequestedAt: time.Now(),
+		SessionID:   sessionID,
+	})
+	return true
+}
+
+// Approve marks the given paths approved. Returns the paths that were
+// pending and are now approved.
+func (q *Queue) Approve(workDir string, paths ...string) []string {
+	var approved []string
+	for _, path := range paths {
+		e := q.Find(workDir, path)
+		if e == nil || e.Status == StatusApproved {
+			continue
+		}
+		now := time.Now()
+		e.Status = StatusApproved
+		e.ApprovedAt = &now
+		e.ApprovedBy = approver()
+		approved = append(approved, e.Path)
+	}
+	return approved
+}
+
+// Pending returns the entries waiting for approval.
+func (q *Queue) Pending() []Entry {
+	var pending []Entry
+	for _, e := range q.Files {
+		if e.Status != StatusApproved {
+			pending = append(pending, e)
+		}
+	}
+	return pending
+}
+
+// PendingPaths returns the paths waiting for approval.
+func (q *Queue) PendingPaths() []string {
+	var paths []string
+	for _, e := range q.Pending() {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+// FindDependency returns the entry for the named dependency, or nil if it
+// is not queued.
+func (q *Queue) FindDependency(name string) *DependencyEntry {
+	for i := range q.Dependencies {
+		if q.Dependencies[i].Name == name {
+			return &q.Dependencies[i]
+		}
+	}
+	return nil
+}
+
+// IsDependencyApproved reports whether the named dependency was approved.
+func (q *Queue) IsDependencyApproved(name string) bool {
+	e := q.FindDependency(name)
+	return e != nil && e.Status == StatusApproved
+}
+
+// RequestDependency adds the named dependency to the queue as pending.
+// A pending entry has its change description refreshed; approved entries
+// are left unchanged. Returns true if an entry was added.
+func (q *Queue) RequestDependency(name, change, sessionID string) bool {
+	if e := q.FindDependency(name); e != nil {
+		if e.Status != StatusApproved {
+			e.Change = change
+		}
+		return false
+	}
+	q.Dependencies = append(q.Dependencies, DependencyEntry{
+		Name:        name,
+		Change:      change,
+		Status:      StatusPending,
+		RequestedAt: time.Now(),
+		SessionID:   sessionID,
+	})
+	return true
+}
+
+// ApproveDependencies marks the named dependencies approved. Returns the
+// names that were pending and are now approved.
+func (q *Queue) ApproveDependencies(names ...string) []string {
+	var approved []string
+	for _, name := range names {
+		e := q.FindDependency(name)
+		if e == nil || e.Status == StatusApproved {
+			continue
+		}
+		now := time.Now()
+		e.Status = StatusApproved
+		e.ApprovedAt = &now
+		e.ApprovedBy = approver()
+		approved = append(approved, e.Name)
+	}
+	return approved
+}
+
+// PendingDependencies returns the dependency entries waiting for approval.
+func (q *Queue) PendingDependencies() []DependencyEntry {
+	var pending []DependencyEntry
+	for _, e := range q.Dependencies {
+		if e.Status != StatusApproved {
+			pending = append(pending, e)
+		}
+	}
+	return pending
+}
+
+// PendingDependencyNames returns the dependency names waiting for approval.
+func (q *Queue) PendingDependencyNames() []string {
+	var names []string
+	for _, e := range q.PendingDependencies() {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func approver() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return strings.TrimSpace(os.Getenv("USER"))
+}
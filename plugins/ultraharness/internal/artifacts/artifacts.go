@@ -2,12 +2,19 @@
 package artifacts
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"os"
+	"fmt"
+	"path"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
+
+	"ultraharness/internal/schema"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/tags"
+	"ultraharness/internal/text"
 )
 
 // ArtifactType represents different FIC artifact types.
@@ -30,19 +37,35 @@ const DirPermission = 0700
 
 // Research represents a research artifact.
 type Research struct {
-	ID               string         `json:"id"`
-	FeatureOrTask    string         `json:"feature_or_task"`
-	ConfidenceScore  float64        `json:"confidence_score"`
-	Discoveries      []Discovery    `json:"discoveries,omitempty"`
-	OpenQuestions    []OpenQuestion `json:"open_questions,omitempty"`
-	ResearchSessions int            `json:"research_sessions"`
-	UpdatedAt        string         `json:"updated_at"`
+	SchemaVersion    int             `json:"schema_version"`
+	ID               string          `json:"id"`
+	FeatureOrTask    string          `json:"feature_or_task"`
+	// InitialContext is what was known before research began, such as
+	// the description of the task's tracker issue
+	InitialContext   string          `json:"initial_context,omitempty"`
+	ConfidenceScore  float64         `json:"confidence_score"`
+	Discoveries      []Discovery     `json:"discoveries,omitempty"`
+	RelevantFiles    []FileReference `json:"relevant_files,omitempty"`
+	OpenQuestions    []OpenQuestion  `json:"open_questions,omitempty"`
+	Assumptions      []Assumption    `json:"assumptions,omitempty"`
+	ResearchSessions int             `json:"research_sessions"`
+	UpdatedAt        string          `json:"updated_at"`
 }
 
 // Discovery represents a research discovery.
 type Discovery struct {
-	Summary  string `json:"summary"`
-	Critical bool   `json:"critical,omitempty"`
+	Summary  string   `json:"summary"`
+	Critical bool     `json:"critical,omitempty"`
+	// Tags label the discovery, e.g. "auth"; hashtags in the summary
+	// count too
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// FileReference is a file research found relevant.
+type FileReference struct {
+	Path      string  `json:"path"`
+	Purpose   string  `json:"purpose,omitempty"`
+	Relevance float64 `json:"relevance,omitempty"`
 }
 
 // OpenQuestion represents an open research question.
@@ -51,13 +74,31 @@ type OpenQuestion struct {
 	Blocking bool   `json:"blocking,omitempty"`
 }
 
+// Assumption is something research took to be true without confirming
+// it, and what it rests on.
+type Assumption struct {
+	Statement string `json:"statement"`
+	// File is the project file the assumption depends on, e.g.
+	// "internal/auth/refresh.go"; a ":line" suffix is allowed
+	File      string `json:"file,omitempty"`
+	// Fact is what the assumption depends on when it is not a file
+	Fact      string `json:"fact,omitempty"`
+	// Hash is the SHA-256 of File's content when the assumption was pinned
+	Hash      string `json:"hash,omitempty"`
+}
+
+// CompleteConfidence is the research confidence needed to move on to
+// planning.
+const CompleteConfidence = 0.7
+
 // IsComplete returns true if research confidence is >= 70%.
 func (r *Research) IsComplete() bool {
-	return r.ConfidenceScore >= 0.7
+	return r.ConfidenceScore >= CompleteConfidence
 }
 
 // Plan represents a plan artifact.
 type Plan struct {
+	SchemaVersion    int              `json:"schema_version"`
 	ID               string           `json:"id"`
 	Goal             string           `json:"goal"`
 	Steps            []PlanStep       `json:"steps,omitempty"`
@@ -68,9 +109,11 @@ type Plan struct {
 
 // PlanStep represents a step in a plan.
 type PlanStep struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	Completed   bool   `json:"completed,omitempty"`
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Completed   bool     `json:"completed,omitempty"`
+	// Tags label the step; hashtags in the description count too
+	Tags        []string `json:"tags,omitempty"`
 }
 
 // ValidationResult represents plan validation outcome.
@@ -86,6 +129,7 @@ func (p *Plan) IsActionable() bool {
 
 // Implementation represents an implementation artifact.
 type Implementation struct {
+	SchemaVersion   int      `json:"schema_version"`
 	ID              string   `json:"id"`
 	PlanArtifactID  string   `json:"plan_artifact_id"`
 	StepsCompleted  []string `json:"steps_completed,omitempty"`
@@ -94,6 +138,12 @@ type Implementation struct {
 	UpdatedAt       string   `json:"updated_at"`
 }
 
+// Schema is the artifact file format, shared by all artifact types.
+var Schema = &schema.Format{
+	Name:       "FIC artifact",
+	Migrations: []schema.Migration{schema.Unversioned},
+}
+
 // GetArtifactDir returns the directory for a given artifact type.
 func GetArtifactDir(workDir string, artifactType ArtifactType) string {
 	return filepath.Join(workDir, ArtifactsDir, string(artifactType))
@@ -101,34 +151,25 @@ func GetArtifactDir(workDir string, artifactType ArtifactType) string {
 
 // GetLatestArtifact returns the most recent artifact of the given type.
 func GetLatestArtifact(workDir string, artifactType ArtifactType) (interface{}, error) {
-	dir := GetArtifactDir(workDir, artifactType)
-
-	entries, err := os.ReadDir(dir)
+	backend, err := storage.Open(workDir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	// Filter JSON files and sort by name (which includes timestamp)
-	var jsonFiles []string
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
-			jsonFiles = append(jsonFiles, entry.Name())
-		}
+	latest, err := latestKey(backend, artifactType)
+	if err != nil {
+		return nil, err
 	}
-
-	if len(jsonFiles) == 0 {
+	if latest == "" {
 		return nil, nil
 	}
 
-	// Sort descending to get latest first
-	sort.Sort(sort.Reverse(sort.StringSlice(jsonFiles)))
+	return Get(backend, latest, artifactType)
+}
 
-	// Load the latest artifact
-	latestPath := filepath.Join(dir, jsonFiles[0])
-	data, err := os.ReadFile(latestPath)
+// Get returns the artifact of the given type stored under key.
+func Get(backend storage.Backend, key string, artifactType ArtifactType) (interface{}, error) {
+	data, err := backend.Get(key)
 	if err != nil {
 		return nil, err
 	}
@@ -136,21 +177,21 @@ func GetLatestArtifact(workDir string, artifactType ArtifactType) (interface{},
 	switch artifactType {
 	case ArtifactResearch:
 		var research Research
-		if err := json.Unmarshal(data, &research); err != nil {
+		if err := Schema.Unmarshal(data, &research); err != nil {
 			return nil, err
 		}
 		return &research, nil
 
 	case ArtifactPlan:
 		var plan Plan
-		if err := json.Unmarshal(data, &plan); err != nil {
+		if err := Schema.Unmarshal(data, &plan); err != nil {
 			return nil, err
 		}
 		return &plan, nil
 
 	case ArtifactImplementation:
 		var impl Implementation
-		if err := json.Unmarshal(data, &impl); err != nil {
+		if err := Schema.Unmarshal(data, &impl); err != nil {
 			return nil, err
 		}
 		return &impl, nil
@@ -159,23 +200,164 @@ func GetLatestArtifact(workDir string, artifactType ArtifactType) (interface{},
 	return nil, nil
 }
 
+// latestKey returns the storage key of the most recent artifact of the
+// given type, or "" if there is none.
+func latestKey(backend storage.Backend, artifactType ArtifactType) (string, error) {
+	keys, err := Keys(backend, artifactType)
+	if err != nil || len(keys) == 0 {
+		return "", err
+	}
+	return keys[len(keys)-1], nil
+}
+
+// Keys returns the storage keys of the saved artifacts of the given type,
+// oldest first. Artifacts put aside in subdirectories are not included.
+func Keys(backend storage.Backend, artifactType ArtifactType) ([]string, error) {
+	prefix := artifactKeyPrefix(artifactType)
+	all, err := backend.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep direct JSON children; keys are sorted and names include a timestamp
+	var keys []string
+	for _, key := range all {
+		name := strings.TrimPrefix(key, prefix)
+		if !strings.Contains(name, "/") && strings.HasSuffix(name, ".json") {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// PutAside stages moving the artifact stored under key into dir, a
+// subdirectory of its type's directory such as "orphaned", in tx. An
+// artifact put aside is kept for reference but is never the latest.
+func PutAside(tx storage.Tx, backend storage.Backend, key, dir string) error {
+	data, err := backend.Get(key)
+	if err != nil {
+		return err
+	}
+	tx.Put(path.Dir(key)+"/"+dir+"/"+path.Base(key), data)
+	tx.Delete(key)
+	return nil
+}
+
+// LatestVersion identifies the most recent artifact of the given type by
+// its key and content, so saving a new artifact or rewriting the latest one
+// changes it. It returns "" if there is none.
+func LatestVersion(workDir string, artifactType ArtifactType) string {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return ""
+	}
+	latest, err := latestKey(backend, artifactType)
+	if err != nil || latest == "" {
+		return ""
+	}
+	data, err := backend.Get(latest)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return latest + "@" + hex.EncodeToString(sum[:8])
+}
+
 // SaveArtifact saves an artifact to disk.
 func SaveArtifact(workDir string, artifactType ArtifactType, artifact interface{}) error {
-	dir := GetArtifactDir(workDir, artifactType)
-	if err := os.MkdirAll(dir, DirPermission); err != nil {
+	backend, err := storage.Open(workDir)
+	if err != nil {
 		return err
 	}
 
 	// Generate filename with timestamp
-	timestamp := time.Now().Format("20060102-150405")
-	filename := filepath.Join(dir, timestamp+".json")
+	timestamp := time.Now().Format(timestampFormat)
 
-	data, err := json.MarshalIndent(artifact, "", "  ")
+	data, err := encode(artifact)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filename, data, FilePermission)
+	return backend.Put(artifactKeyPrefix(artifactType)+timestamp+".json", data)
+}
+
+// PutLatest stages a rewrite of the most recent artifact of the given type
+// in tx, keeping its key, so it is saved together with other state. It
+// fails if there is no artifact of that type.
+func PutLatest(tx storage.Tx, backend storage.Backend, artifactType ArtifactType, artifact interface{}) error {
+	latest, err := latestKey(backend, artifactType)
+	if err != nil {
+		return err
+	}
+	if latest == "" {
+		return fmt.Errorf("no %s artifact to update", artifactType)
+	}
+
+	data, err := encode(artifact)
+	if err != nil {
+		return err
+	}
+	tx.Put(latest, data)
+	return nil
+}
+
+// encode stamps an artifact with the current schema version and encodes it.
+func encode(artifact interface{}) ([]byte, error) {
+	switch a := artifact.(type) {
+	case *Research:
+		a.SchemaVersion = Schema.Version()
+	case *Plan:
+		a.SchemaVersion = Schema.Version()
+	case *Implementation:
+		a.SchemaVersion = Schema.Version()
+	}
+
+	return json.MarshalIndent(artifact, "", "  ")
+}
+
+// timestampFormat names artifact files by the time they were saved
+const timestampFormat = "20060102-150405"
+
+// SavedTimes returns when each artifact of the given type was saved,
+// oldest first, from the timestamps in their names.
+func SavedTimes(workDir string, artifactType ArtifactType) []time.Time {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil
+	}
+	prefix := artifactKeyPrefix(artifactType)
+	keys, err := backend.List(prefix)
+	if err != nil {
+		return nil
+	}
+
+	var times []time.Time
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, prefix)
+		if strings.Contains(name, "/") {
+			continue
+		}
+		if t, ok := SavedAt(key); ok {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+// SavedAt returns when the artifact stored under key was first saved, from
+// the timestamp in its name.
+func SavedAt(key string) (time.Time, bool) {
+	name := path.Base(key)
+	if !strings.HasSuffix(name, ".json") {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(timestampFormat, strings.TrimSuffix(name, ".json"), time.Local)
+	return t, err == nil
+}
+
+// artifactKeyPrefix returns the storage key prefix for an artifact type.
+func artifactKeyPrefix(artifactType ArtifactType) string {
+	return strings.TrimPrefix(ArtifactsDir, ".claude/") + "/" + string(artifactType) + "/"
 }
 
 // GetCurrentPhase determines the current FIC workflow phase.
@@ -204,6 +386,32 @@ func GetCurrentPhase(workDir string) string {
 	return "NEW_SESSION"
 }
 
+// CurrentTags returns the tags of the task being worked on: those of the
+// latest plan's goal and unfinished steps, then those of the latest
+// research's subject and discoveries.
+func CurrentTags(workDir string) []string {
+	var lists [][]string
+	if plan, _ := GetLatestArtifact(workDir, ArtifactPlan); plan != nil {
+		if p, ok := plan.(*Plan); ok {
+			lists = append(lists, tags.Parse(p.Goal))
+			for _, step := range p.Steps {
+				if !step.Completed {
+					lists = append(lists, tags.Of(step.Tags, step.Description))
+				}
+			}
+		}
+	}
+	if research, _ := GetLatestArtifact(workDir, ArtifactResearch); research != nil {
+		if r, ok := research.(*Research); ok {
+			lists = append(lists, tags.Parse(r.FeatureOrTask))
+			for _, d := range r.Discoveries {
+				lists = append(lists, tags.Of(d.Tags, d.Summary))
+			}
+		}
+	}
+	return tags.Merge(lists...)
+}
+
 // GetPhaseInfo returns phase and details for context preservation.
 func GetPhaseInfo(workDir string) map[string]interface{} {
 	phase := GetCurrentPhase(workDir)
@@ -229,11 +437,7 @@ func GetPhaseInfo(workDir string) map[string]interface{} {
 		if plan, _ := GetLatestArtifact(workDir, ArtifactPlan); plan != nil {
 			if p, ok := plan.(*Plan); ok {
 				details["plan_id"] = p.ID
-				if len(p.Goal) > 100 {
-					details["goal"] = p.Goal[:100]
-				} else {
-					details["goal"] = p.Goal
-				}
+				details["goal"] = text.Prefix(p.Goal, 100)
 				details["total_steps"] = len(p.Steps)
 				details["is_validated"] = p.ValidationResult != nil
 			}
@@ -3,7 +3,11 @@ package artifacts
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"ultraharness/internal/storage"
 )
 
 func TestResearchIsComplete(t *testing.T) {
@@ -249,6 +253,25 @@ func TestSaveArtifact(t *testing.T) {
 		}
 	})
 
+	t.Run("records the schema version", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "artifacts-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if err := SaveArtifact(tmpDir, ArtifactPlan, &Plan{ID: "plan-1"}); err != nil {
+			t.Fatalf("SaveArtifact() error = %v", err)
+		}
+		latest, err := GetLatestArtifact(tmpDir, ArtifactPlan)
+		if err != nil {
+			t.Fatalf("GetLatestArtifact() error = %v", err)
+		}
+		if plan := latest.(*Plan); plan.SchemaVersion != Schema.Version() || plan.ID != "plan-1" {
+			t.Errorf("GetLatestArtifact() = %+v, want plan-1 at schema version %d", plan, Schema.Version())
+		}
+	})
+
 	t.Run("file has correct permissions", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "artifacts-test")
 		if err != nil {
@@ -278,6 +301,105 @@ func TestSaveArtifact(t *testing.T) {
 	})
 }
 
+func TestPutLatest(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "artifacts-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	backend, err := storage.Open(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := func(plan *Plan) error {
+		return backend.Update(func(tx storage.Tx) error {
+			return PutLatest(tx, backend, ArtifactPlan, plan)
+		})
+	}
+
+	if err := update(&Plan{ID: "plan-1"}); err == nil {
+		t.Error("PutLatest() without a plan succeeded, want an error")
+	}
+
+	if err := SaveArtifact(tmpDir, ArtifactPlan, &Plan{ID: "plan-1", Goal: "old"}); err != nil {
+		t.Fatalf("SaveArtifact() error = %v", err)
+	}
+	if err := update(&Plan{ID: "plan-1", Goal: "new"}); err != nil {
+		t.Fatalf("PutLatest() error = %v", err)
+	}
+	latest, _ := GetLatestArtifact(tmpDir, ArtifactPlan)
+	if plan := latest.(*Plan); plan.Goal != "new" || plan.SchemaVersion != Schema.Version() {
+		t.Errorf("GetLatestArtifact() = %+v, want the rewritten plan", plan)
+	}
+	if times := SavedTimes(tmpDir, ArtifactPlan); len(times) != 1 {
+		t.Errorf("%d plan artifacts, want the latest rewritten in place", len(times))
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "artifacts-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if got := LatestVersion(tmpDir, ArtifactPlan); got != "" {
+		t.Errorf("LatestVersion() without plans = %q, want empty", got)
+	}
+
+	if err := SaveArtifact(tmpDir, ArtifactPlan, &Plan{ID: "plan-1"}); err != nil {
+		t.Fatalf("SaveArtifact() error = %v", err)
+	}
+	first := LatestVersion(tmpDir, ArtifactPlan)
+	if first == "" {
+		t.Fatal("LatestVersion() = empty after saving a plan")
+	}
+
+	// Rewriting the latest plan in place is a new version
+	entries, _ := os.ReadDir(GetArtifactDir(tmpDir, ArtifactPlan))
+	path := filepath.Join(GetArtifactDir(tmpDir, ArtifactPlan), entries[0].Name())
+	os.WriteFile(path, []byte(`{"schema_version": 1, "id": "plan-1", "goal": "revised"}`), FilePermission)
+	if got := LatestVersion(tmpDir, ArtifactPlan); got == first || got == "" {
+		t.Errorf("LatestVersion() after a rewrite = %q, want a new version", got)
+	}
+}
+
+func TestSavedTimes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "artifacts-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dir := GetArtifactDir(tmpDir, ArtifactResearch)
+	os.MkdirAll(filepath.Join(dir, "archive"), DirPermission)
+	for _, name := range []string{"20250102-090000.json", "20250101-120000.json", "notes.json", "archive/20240101-000000.json"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(`{}`), FilePermission)
+	}
+
+	times := SavedTimes(tmpDir, ArtifactResearch)
+	want := []time.Time{
+		time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local),
+		time.Date(2025, 1, 2, 9, 0, 0, 0, time.Local),
+	}
+	if len(times) != len(want) || !times[0].Equal(want[0]) || !times[1].Equal(want[1]) {
+		t.Errorf("SavedTimes() = %v, want %v", times, want)
+	}
+}
+
+func TestSavedAt(t *testing.T) {
+	got, ok := SavedAt(".claude/fic-artifacts/plan/20250102-090000.json")
+	if !ok || !got.Equal(time.Date(2025, 1, 2, 9, 0, 0, 0, time.Local)) {
+		t.Errorf("SavedAt() = %v, %v, want 2025-01-02 09:00", got, ok)
+	}
+	for _, key := range []string{".claude/fic-artifacts/plan/notes.json", ".claude/fic-artifacts/plan/20250102-090000.txt"} {
+		if _, ok := SavedAt(key); ok {
+			t.Errorf("SavedAt(%q) parsed a key without a timestamp", key)
+		}
+	}
+}
+
 func TestGetCurrentPhase(t *testing.T) {
 	t.Run("new session", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "phase-test")
@@ -376,6 +498,31 @@ func TestGetCurrentPhase(t *testing.T) {
 	})
 }
 
+func TestCurrentTags(t *testing.T) {
+	tmpDir := t.TempDir()
+	if got := CurrentTags(tmpDir); len(got) != 0 {
+		t.Errorf("CurrentTags() without artifacts = %v", got)
+	}
+
+	SaveArtifact(tmpDir, ArtifactResearch, &Research{
+		ID:            "r1",
+		FeatureOrTask: "Token refresh #auth",
+		Discoveries:   []Discovery{{Summary: "pool is too small", Tags: []string{"#Perf"}}},
+	})
+	SaveArtifact(tmpDir, ArtifactPlan, &Plan{
+		ID:   "p1",
+		Goal: "Refresh tokens early #auth",
+		Steps: []PlanStep{
+			{ID: "1", Description: "Drop the old cache #tech-debt", Completed: true},
+			{ID: "2", Description: "Add a refresh job", Tags: []string{"jobs"}},
+		},
+	})
+
+	if got := strings.Join(CurrentTags(tmpDir), " "); got != "auth jobs perf" {
+		t.Errorf("CurrentTags() = %q, want the plan's open steps and the research's tags", got)
+	}
+}
+
 func TestGetPhaseInfo(t *testing.T) {
 	t.Run("returns phase and details", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "phase-test")
@@ -0,0 +1,229 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// AssumptionState is whether what an assumption rests on has changed since
+// it was pinned.
+type AssumptionState string
+
+const (
+	// AssumptionHolds means the file is unchanged since it was pinned
+	AssumptionHolds AssumptionState = "holds"
+	// AssumptionChanged means the file changed since it was pinned
+	AssumptionChanged AssumptionState = "changed"
+	// AssumptionMissing means the file was removed since it was pinned
+	AssumptionMissing AssumptionState = "missing"
+	// AssumptionUnpinned means the file's content was never recorded,
+	// for example because it did not exist
+	AssumptionUnpinned AssumptionState = "unpinned"
+	// AssumptionUnchecked means the assumption rests on a fact, which
+	// cannot be checked
+	AssumptionUnchecked AssumptionState = "unchecked"
+)
+
+// AssumptionMarker starts an assumption in subagent output.
+const AssumptionMarker = "ASSUMPTION:"
+
+// maxExtractedAssumptions limits the assumptions taken from one output.
+const maxExtractedAssumptions = 10
+
+// Path returns the file the assumption depends on, without a ":line"
+// suffix, or "" if it rests on a fact.
+func (a Assumption) Path() string {
+	file := strings.TrimSpace(a.File)
+	if i := strings.LastIndex(file, ":"); i > 0 && strings.Trim(file[i+1:], "0123456789-") == "" {
+		file = file[:i]
+	}
+	return file
+}
+
+// Invalidated reports whether a state means the assumption may no longer
+// hold.
+func (s AssumptionState) Invalidated() bool {
+	return s == AssumptionChanged || s == AssumptionMissing
+}
+
+// CheckAssumption compares the file an assumption depends on with the
+// content it was pinned to.
+func CheckAssumption(workDir string, a Assumption) AssumptionState {
+	file := a.Path()
+	switch {
+	case file == "":
+		return AssumptionUnchecked
+	case a.Hash == "":
+		return AssumptionUnpinned
+	}
+	hash, err := hashFile(workDir, file)
+	switch {
+	case os.IsNotExist(err):
+		return AssumptionMissing
+	case err != nil:
+		return AssumptionUnchecked
+	case hash != a.Hash:
+		return AssumptionChanged
+	}
+	return AssumptionHolds
+}
+
+// PinAssumptions records the current content of the files the research's
+// unpinned assumptions depend on, and returns how many were pinned.
+// Assumptions whose file does not exist stay unpinned.
+func (r *Research) PinAssumptions(workDir string) int {
+	pinned := 0
+	for i := range r.Assumptions {
+		a := &r.Assumptions[i]
+		if a.Hash != "" || a.Path() == "" {
+			continue
+		}
+		if hash, err := hashFile(workDir, a.Path()); err == nil {
+			a.Hash = hash
+			pinned++
+		}
+	}
+	return pinned
+}
+
+// RepinAssumption records the current content of the file the i-th
+// assumption depends on, once it has been confirmed to still hold.
+func (r *Research) RepinAssumption(workDir string, i int) error {
+	hash, err := hashFile(workDir, r.Assumptions[i].Path())
+	if err != nil {
+		return err
+	}
+	r.Assumptions[i].Hash = hash
+	return nil
+}
+
+// InvalidatedAssumptions returns the research's assumptions whose file
+// changed or was removed since they were pinned.
+func (r *Research) InvalidatedAssumptions(workDir string) []Assumption {
+	var invalidated []Assumption
+	for _, a := range r.Assumptions {
+		if CheckAssumption(workDir, a).Invalidated() {
+			invalidated = append(invalidated, a)
+		}
+	}
+	return invalidated
+}
+
+// AddAssumption adds a, unless an assumption with the same statement,
+// ignoring case and spacing, is already recorded. Returns whether it was
+// added.
+func (r *Research) AddAssumption(a Assumption) bool {
+	a.Statement = strings.TrimSpace(a.Statement)
+	key := strings.ToLower(strings.Join(strings.Fields(a.Statement), " "))
+	if key == "" {
+		return false
+	}
+	for _, existing := range r.Assumptions {
+		if strings.ToLower(strings.Join(strings.Fields(existing.Statement), " ")) == key {
+			return false
+		}
+	}
+	r.Assumptions = append(r.Assumptions, a)
+	return true
+}
+
+// ExtractAssumptions returns the assumptions marked in subagent output. An
+// assumption is a line "ASSUMPTION: <statement>", in capitals and
+// optionally as a list item or in bold, that may be followed by a
+// "Depends on: <file or fact>" line. A dependency that looks like a path,
+// such as "internal/auth/refresh.go:42", is taken as the file; anything
+// else as a fact.
+func ExtractAssumptions(output string) []Assumption {
+	var found []Assumption
+	var current *Assumption
+
+	for _, raw := range strings.Split(output, "\n") {
+		item := strings.TrimSpace(raw)
+		for _, bullet := range []string{"- ", "* "} {
+			item = strings.TrimPrefix(item, bullet)
+		}
+		item = strings.TrimSpace(strings.ReplaceAll(item, "**", ""))
+
+		if rest, ok := strings.CutPrefix(item, AssumptionMarker); ok {
+			if len(found) == maxExtractedAssumptions {
+				break
+			}
+			statement, dependsOn := cutDependsOn(rest)
+			found = append(found, Assumption{Statement: strings.TrimSpace(statement)})
+			current = &found[len(found)-1]
+			current.setDependency(dependsOn)
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if _, dependsOn := cutDependsOn(item); dependsOn != "" && current.File == "" && current.Fact == "" {
+			current.setDependency(dependsOn)
+		}
+		current = nil
+	}
+
+	kept := found[:0]
+	for _, a := range found {
+		if a.Statement != "" {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// cutDependsOn splits "<statement> depends on: <dependency>", also in
+// parentheses or after a dash, ignoring the label's case.
+func cutDependsOn(s string) (statement, dependsOn string) {
+	const label = "depends on:"
+	// Not ToLower, which can change byte offsets
+	for i := 0; i+len(label) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(label)], label) {
+			statement = strings.TrimRight(strings.TrimSpace(s[:i]), "(-–—, ")
+			dependsOn = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s[i+len(label):]), ")"))
+			return statement, strings.Trim(dependsOn, "`")
+		}
+	}
+	return s, ""
+}
+
+// setDependency records what an assumption depends on, as its file if it
+// looks like a path.
+func (a *Assumption) setDependency(dependsOn string) {
+	if dependsOn == "" {
+		return
+	}
+	if looksLikePath(dependsOn) {
+		a.File = dependsOn
+	} else {
+		a.Fact = dependsOn
+	}
+}
+
+// looksLikePath reports whether s is a single word naming a file, such as
+// "go.mod" or "internal/auth/refresh.go:42".
+func looksLikePath(s string) bool {
+	if strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+		return false
+	}
+	base := filepath.Base(Assumption{File: s}.Path())
+	return strings.Contains(s, "/") || strings.Contains(strings.TrimPrefix(base, "."), ".")
+}
+
+// hashFile returns the SHA-256 of a file's content, relative to workDir
+// unless absolute.
+func hashFile(workDir, file string) (string, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(workDir, file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,103 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckAssumption(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "refresh.go")
+	os.WriteFile(file, []byte("package auth\n"), 0644)
+
+	r := &Research{Assumptions: []Assumption{
+		{Statement: "Tokens refresh in middleware", File: "refresh.go:12-20"},
+		{Statement: "Config is read once", File: "config.go"},
+		{Statement: "The gateway caches tokens", Fact: "gateway docs"},
+	}}
+	if got := CheckAssumption(tmpDir, r.Assumptions[0]); got != AssumptionUnpinned {
+		t.Errorf("CheckAssumption() before pinning = %q", got)
+	}
+	if n := r.PinAssumptions(tmpDir); n != 1 || r.Assumptions[1].Hash != "" {
+		t.Fatalf("PinAssumptions() = %d, %+v, want only the existing file pinned", n, r.Assumptions)
+	}
+
+	tests := []struct {
+		a    Assumption
+		want AssumptionState
+	}{
+		{r.Assumptions[0], AssumptionHolds},
+		{r.Assumptions[1], AssumptionUnpinned},
+		{r.Assumptions[2], AssumptionUnchecked},
+	}
+	for _, tt := range tests {
+		if got := CheckAssumption(tmpDir, tt.a); got != tt.want {
+			t.Errorf("CheckAssumption(%q) = %q, want %q", tt.a.Statement, got, tt.want)
+		}
+	}
+	if got := r.InvalidatedAssumptions(tmpDir); len(got) != 0 {
+		t.Errorf("InvalidatedAssumptions() = %+v, want none", got)
+	}
+
+	os.WriteFile(file, []byte("package auth\n\nfunc Refresh() {}\n"), 0644)
+	if got := CheckAssumption(tmpDir, r.Assumptions[0]); got != AssumptionChanged {
+		t.Errorf("CheckAssumption() after an edit = %q", got)
+	}
+	if got := r.InvalidatedAssumptions(tmpDir); len(got) != 1 || got[0].Statement != "Tokens refresh in middleware" {
+		t.Errorf("InvalidatedAssumptions() = %+v", got)
+	}
+	if err := r.RepinAssumption(tmpDir, 0); err != nil || CheckAssumption(tmpDir, r.Assumptions[0]) != AssumptionHolds {
+		t.Errorf("RepinAssumption() = %v, want the assumption to hold again", err)
+	}
+
+	os.Remove(file)
+	if got := CheckAssumption(tmpDir, r.Assumptions[0]); got != AssumptionMissing {
+		t.Errorf("CheckAssumption() after removal = %q", got)
+	}
+}
+
+func TestAddAssumption(t *testing.T) {
+	r := &Research{}
+	if !r.AddAssumption(Assumption{Statement: " Tokens refresh  in middleware "}) {
+		t.Fatal("AddAssumption() did not add")
+	}
+	if r.AddAssumption(Assumption{Statement: "tokens refresh in middleware"}) || r.AddAssumption(Assumption{Statement: " "}) {
+		t.Error("AddAssumption() added a duplicate or empty statement")
+	}
+	if r.Assumptions[0].Statement != "Tokens refresh  in middleware" {
+		t.Errorf("Statement = %q", r.Assumptions[0].Statement)
+	}
+}
+
+func TestExtractAssumptions(t *testing.T) {
+	output := `## RESEARCH FINDINGS
+
+- ASSUMPTION: Tokens are refreshed only in middleware (depends on: internal/auth/refresh.go:42)
+- **ASSUMPTION:** The gateway caches tokens for 60 seconds
+  Depends on: gateway team's runbook
+ASSUMPTION: Config is read once at startup
+Depends on: ` + "`config.go`" + `
+ASSUMPTION: Sessions expire after an hour - depends on: Redis TTL settings
+Assumption: lowercase prose is not a marker
+ASSUMPTION:
+`
+	got := ExtractAssumptions(output)
+	want := []Assumption{
+		{Statement: "Tokens are refreshed only in middleware", File: "internal/auth/refresh.go:42"},
+		{Statement: "The gateway caches tokens for 60 seconds", Fact: "gateway team's runbook"},
+		{Statement: "Config is read once at startup", File: "config.go"},
+		{Statement: "Sessions expire after an hour", Fact: "Redis TTL settings"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ExtractAssumptions() = %+v, want %d assumptions", got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ExtractAssumptions()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if got := ExtractAssumptions("no assumptions here"); len(got) != 0 {
+		t.Errorf("ExtractAssumptions() = %+v, want none", got)
+	}
+}
@@ -0,0 +1,106 @@
+package artifacts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ultraharness/internal/git"
+)
+
+// ConfidenceDecay is how far a research artifact's confidence has eroded
+// as the codebase changed underneath it.
+type ConfidenceDecay struct {
+	// Written is when the research was last updated
+	Written time.Time
+	// Days is how many whole days have passed since
+	Days int
+	// Commits is how many commits since changed the research's files
+	Commits int
+	// Confidence is the effective confidence after decay
+	Confidence float64
+}
+
+// Decayed reports whether the decay took confidence below what planning
+// needs, when it was enough before.
+func (d ConfidenceDecay) Decayed(r *Research) bool {
+	return r.IsComplete() && d.Confidence < CompleteConfidence
+}
+
+// String explains the decay, e.g. "52% after 40 days and 12 commits to
+// its files".
+func (d ConfidenceDecay) String() string {
+	var causes []string
+	if d.Days > 0 {
+		causes = append(causes, plural(d.Days, "day"))
+	}
+	if d.Commits > 0 {
+		causes = append(causes, plural(d.Commits, "commit")+" to its files")
+	}
+	if len(causes) == 0 {
+		return fmt.Sprintf("%.0f%%", d.Confidence*100)
+	}
+	return fmt.Sprintf("%.0f%% after %s", d.Confidence*100, strings.Join(causes, " and "))
+}
+
+// WrittenAt returns when the research was last updated, from UpdatedAt.
+func (r *Research) WrittenAt() (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, r.UpdatedAt, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Files returns the files the research rests on: its relevant files and
+// the files its assumptions depend on.
+func (r *Research) Files() []string {
+	seen := map[string]bool{}
+	var files []string
+	add := func(file string) {
+		if file = strings.TrimSpace(file); file != "" && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, f := range r.RelevantFiles {
+		add(Assumption{File: f.Path}.Path())
+	}
+	for _, a := range r.Assumptions {
+		add(a.Path())
+	}
+	return files
+}
+
+// Decay returns the research's effective confidence at now: its
+// confidence less perWeek for every week since it was written and
+// perCommit for every commit since that changed its files, never below 0.
+// Research without a parseable UpdatedAt does not decay.
+func (r *Research) Decay(workDir string, perWeek, perCommit float64, now time.Time) ConfidenceDecay {
+	d := ConfidenceDecay{Confidence: r.ConfidenceScore}
+	written, ok := r.WrittenAt()
+	if !ok || !now.After(written) {
+		return d
+	}
+	d.Written = written
+	elapsed := now.Sub(written)
+	d.Days = int(elapsed.Hours() / 24)
+	if perCommit > 0 {
+		d.Commits = git.CommitCountSince(workDir, written, r.Files()...)
+	}
+
+	d.Confidence -= perWeek*elapsed.Hours()/(24*7) + perCommit*float64(d.Commits)
+	if d.Confidence < 0 {
+		d.Confidence = 0
+	}
+	return d
+}
+
+// plural returns "1 day" or "3 days".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,48 @@
+package artifacts
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecay(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	r := &Research{
+		ConfidenceScore: 0.85,
+		UpdatedAt:       now.AddDate(0, 0, -14).Format(time.RFC3339),
+		RelevantFiles:   []FileReference{{Path: "auth/refresh.go:10"}, {Path: "auth/middleware.go"}},
+		Assumptions:     []Assumption{{Statement: "Tokens refresh in middleware", File: "auth/middleware.go:42"}},
+	}
+	if got := strings.Join(r.Files(), " "); got != "auth/refresh.go auth/middleware.go" {
+		t.Errorf("Files() = %q", got)
+	}
+
+	// Outside a git repository only time counts
+	d := r.Decay(t.TempDir(), 0.05, 0.02, now)
+	if d.Days != 14 || d.Commits != 0 || d.Confidence < 0.749 || d.Confidence > 0.751 || d.Decayed(r) {
+		t.Errorf("Decay() after two weeks = %+v", d)
+	}
+	if got := d.String(); got != "75% after 14 days" {
+		t.Errorf("String() = %q", got)
+	}
+
+	d = r.Decay(t.TempDir(), 0.05, 0.02, now.AddDate(0, 0, 14))
+	if !d.Decayed(r) || d.Confidence > 0.66 {
+		t.Errorf("Decay() after four weeks = %+v, want below the planning threshold", d)
+	}
+	if d = r.Decay(t.TempDir(), 0.5, 0, now.AddDate(1, 0, 0)); d.Confidence != 0 {
+		t.Errorf("Decay() after a year = %v, want 0", d.Confidence)
+	}
+
+	for _, updated := range []string{"", "last week"} {
+		r.UpdatedAt = updated
+		if d := r.Decay(t.TempDir(), 0.05, 0.02, now); d.Confidence != 0.85 || !d.Written.IsZero() {
+			t.Errorf("Decay() with updated_at %q = %+v, want no decay", updated, d)
+		}
+	}
+	r.UpdatedAt = "2026-10-01"
+	if d := r.Decay(t.TempDir(), 0.05, 0.02, now); d.Days != 14 {
+		t.Errorf("Decay() with a date-only updated_at = %+v", d)
+	}
+}
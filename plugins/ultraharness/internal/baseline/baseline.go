@@ -0,0 +1,301 @@
+// Package baseline runs the tests that establish a session's starting
+// state.
+//
+// Running the whole suite at every session start is slow, and most of it
+// covers code that has not changed since the last time it passed. The
+// commit of the last fully green run is kept in .claude/baseline.json, and
+// the baseline then runs only the tests affected by the changes since that
+// commit: Go packages with their dependents, and JavaScript, TypeScript,
+// and Python test files found by following imports. A full run is made
+// instead when there is no green commit to compare with, when the last full
+// run is older than baseline_full_run_hours (nightly by default), when a
+// changed code file or dependency manifest cannot be mapped to tests, or on
+// demand.
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/deps"
+	"ultraharness/internal/git"
+	"ultraharness/internal/testimpact"
+	"ultraharness/internal/testrunner"
+)
+
+// FileName is the baseline state file inside .claude.
+const FileName = "baseline.json"
+
+// FilePermission is the permission for the state file
+const FilePermission = 0600
+
+// DirPermission is the permission for the state directory
+const DirPermission = 0700
+
+// MaxTargets is the number of affected test targets above which a full
+// run is made instead, as it would take about as long
+const MaxTargets = 200
+
+// Modes of a baseline run.
+const (
+	// ModeFull ran the whole suite
+	ModeFull = "full"
+	// ModeIncremental ran only the tests affected since the last green run
+	ModeIncremental = "incremental"
+	// ModeUnchanged ran nothing, as no code changed since the last green
+	// run
+	ModeUnchanged = "unchanged"
+)
+
+// State records the last green baseline.
+type State struct {
+	// Commit is HEAD at the last run that passed
+	Commit  string    `json:"commit,omitempty"`
+	GreenAt time.Time `json:"green_at"`
+	// FullAt is the time of the last full run that passed
+	FullAt time.Time `json:"full_at"`
+}
+
+// GetPath returns the path to the baseline state file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the baseline state. A missing file is an empty state.
+func Load(workDir string) (*State, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save writes the baseline state.
+func (s *State) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Result is the outcome of a baseline run.
+type Result struct {
+	// Mode is ModeFull, ModeIncremental, or ModeUnchanged
+	Mode string
+	// Reason explains a full run, e.g. "no green run recorded"
+	Reason string
+	// Since is the commit of the last green run the changes were taken
+	// from, for incremental and unchanged runs
+	Since string
+	// Targets are the Go package directories and test files run
+	// incrementally
+	Targets []string
+	Summary *testrunner.Summary
+}
+
+// Run runs the baseline tests in workDir: only those affected since the
+// last green run, or all of them when full is set or an incremental run is
+// not possible. A run that passes is recorded as the new green baseline.
+func Run(workDir string, cfg *config.Config, full bool) *Result {
+	state, err := Load(workDir)
+	if err != nil {
+		state = &State{}
+	}
+	head := git.Head(workDir)
+	now := time.Now()
+
+	result := plan(workDir, cfg, state, head, full, now)
+	switch result.Mode {
+	case ModeUnchanged:
+		return result
+	case ModeIncremental:
+		result.Summary = runTargets(workDir, cfg, result.Targets)
+		if result.Summary == nil {
+			result.Mode, result.Reason = ModeFull, "the test runner cannot select test files"
+			result.Summary = testrunner.Run(workDir, testrunner.DefaultTimeout)
+		}
+	default:
+		result.Summary = testrunner.Run(workDir, testrunner.DefaultTimeout)
+	}
+
+	if result.Summary.Result == testrunner.Passed && head != "" {
+		state.Commit = head
+		state.GreenAt = now
+		if result.Mode == ModeFull {
+			state.FullAt = now
+		}
+		state.Save(workDir)
+	}
+	return result
+}
+
+// plan decides between a full, incremental, or no run, listing the
+// targets of an incremental one.
+func plan(workDir string, cfg *config.Config, state *State, head string, full bool, now time.Time) *Result {
+	fullRun := func(reason string) *Result {
+		return &Result{Mode: ModeFull, Reason: reason}
+	}
+	switch {
+	case full:
+		return fullRun("requested")
+	case !cfg.BaselineIncremental:
+		return fullRun("incremental baseline disabled")
+	case head == "":
+		return fullRun("not a git repository")
+	case state.Commit == "":
+		return fullRun("no green run recorded")
+	case !git.IsCommit(workDir, state.Commit):
+		return fullRun("last green commit " + short(state.Commit) + " no longer exists")
+	case now.Sub(state.FullAt) > time.Duration(cfg.GetBaselineFullRunHours())*time.Hour:
+		return fullRun(fmt.Sprintf("no full run in the last %d hours", cfg.GetBaselineFullRunHours()))
+	}
+
+	changed := append(git.ChangedFilesSince(workDir, state.Commit), git.ModifiedFiles(workDir)...)
+	sort.Strings(changed)
+
+	codeFiles := cfg.GetCodeFiles()
+	var goFiles, scriptFiles []string
+	for i, f := range changed {
+		if i > 0 && f == changed[i-1] {
+			continue
+		}
+		base := filepath.Base(f)
+		switch {
+		case base == "go.mod" || base == "go.sum" || filepath.Ext(f) == ".go":
+			goFiles = append(goFiles, f)
+		case deps.IsManifest(f):
+			return fullRun(f + " changed")
+		case !git.IsCodeFile(f, codeFiles.Extensions, codeFiles.Exclude):
+		case !testimpact.Supported(f):
+			return fullRun("cannot map " + f + " to its tests")
+		case !exists(filepath.Join(workDir, f)):
+			// The importers of a deleted file can no longer be found
+			if !testrunner.IsTestFile(f) {
+				return fullRun(f + " was deleted")
+			}
+		default:
+			scriptFiles = append(scriptFiles, f)
+		}
+	}
+
+	result := &Result{Mode: ModeIncremental, Since: state.Commit}
+	if len(goFiles) == 0 && len(scriptFiles) == 0 {
+		result.Mode = ModeUnchanged
+		return result
+	}
+
+	timeout := time.Duration(cfg.GetTestPackageTimeoutSeconds()) * time.Second
+	if len(goFiles) > 0 {
+		result.Targets = testimpact.GoPackages(workDir, goFiles, false, timeout)
+	}
+	seen := map[string]bool{}
+	for _, f := range scriptFiles {
+		for _, t := range testimpact.Find(workDir, f, MaxTargets, timeout).Files {
+			if !seen[t] {
+				seen[t] = true
+				result.Targets = append(result.Targets, t)
+			}
+		}
+	}
+	if len(result.Targets) > MaxTargets {
+		return fullRun(fmt.Sprintf("%d test targets affected", len(result.Targets)))
+	}
+	if len(result.Targets) == 0 {
+		result.Mode = ModeUnchanged
+	}
+	return result
+}
+
+// runTargets runs the affected Go packages in parallel and the affected
+// test files with the project's runner, combining their results. Returns
+// nil if the runner cannot run single test files.
+func runTargets(workDir string, cfg *config.Config, targets []string) *testrunner.Summary {
+	var pkgs, files []string
+	for _, t := range targets {
+		if strings.HasPrefix(t, "./") || strings.HasPrefix(t, "../") || t == "." {
+			pkgs = append(pkgs, t)
+		} else {
+			files = append(files, t)
+		}
+	}
+
+	var runs []*testrunner.Summary
+	if len(pkgs) > 0 {
+		timeout := time.Duration(cfg.GetTestPackageTimeoutSeconds()) * time.Second
+		runs = append(runs, testrunner.RunGoPackages(workDir, pkgs, cfg.GetTestWorkers(), timeout))
+	}
+	if len(files) > 0 {
+		run := testrunner.RunFiles(workDir, files, testrunner.DefaultTimeout)
+		if run.Result == testrunner.NotRun {
+			return nil
+		}
+		runs = append(runs, run)
+	}
+	return combine(runs)
+}
+
+// combine merges summaries; the result is the worst of them.
+func combine(runs []*testrunner.Summary) *testrunner.Summary {
+	if len(runs) == 1 {
+		return runs[0]
+	}
+	summary := &testrunner.Summary{Result: testrunner.Passed}
+	var output []string
+	for _, r := range runs {
+		if r.Result > summary.Result {
+			summary.Result = r.Result
+		}
+		summary.Passed += r.Passed
+		summary.Failed += r.Failed
+		summary.Skipped += r.Skipped
+		summary.Total += r.Total
+		summary.Duration += r.Duration
+		summary.Packages = append(summary.Packages, r.Packages...)
+		output = append(output, strings.TrimRight(r.RawOutput, "\n"))
+	}
+	summary.RawOutput = strings.Join(output, "\n\n") + "\n"
+	return summary
+}
+
+// Describe explains what the run covered, e.g. "3 affected target(s)
+// since a1b2c3d" or "full run: no green run recorded".
+func (r *Result) Describe() string {
+	switch r.Mode {
+	case ModeUnchanged:
+		return "no tests affected since the last green run at " + short(r.Since)
+	case ModeIncremental:
+		return fmt.Sprintf("%d affected target(s) since %s", len(r.Targets), short(r.Since))
+	}
+	return "full run: " + r.Reason
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func short(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
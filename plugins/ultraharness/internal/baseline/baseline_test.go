@@ -0,0 +1,112 @@
+package baseline
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/testrunner"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	state, err := Load(tmpDir)
+	if err != nil || state.Commit != "" {
+		t.Fatalf("Load() without a file = %+v, %v, want empty state", state, err)
+	}
+
+	state.Commit = "a1b2c3d4e5f6"
+	state.FullAt = time.Now().Truncate(time.Second)
+	if err := state.Save(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(GetPath(tmpDir))
+	if err != nil || info.Mode().Perm() != FilePermission {
+		t.Errorf("state file mode = %v, %v, want %v", info.Mode().Perm(), err, os.FileMode(FilePermission))
+	}
+	loaded, err := Load(tmpDir)
+	if err != nil || loaded.Commit != state.Commit || !loaded.FullAt.Equal(state.FullAt) {
+		t.Errorf("Load() = %+v, %v, want %+v", loaded, err, state)
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, tool := range []string{"git", "go"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skip(tool + " not available")
+		}
+	}
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...).Run()
+	}
+	write := func(name, content string) {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("go.mod", "module example.com/m\n\ngo 1.21\n")
+	write("a/a.go", "package a\n\nfunc A() int { return 1 }\n")
+	write("a/a_test.go", "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n")
+	write("b/b.go", "package b\n\nimport \"example.com/m/a\"\n\nfunc B() int { return a.A() }\n")
+	write("b/b_test.go", "package b\n\nimport \"testing\"\n\nfunc TestB(t *testing.T) {}\n")
+	write("c/c.go", "package c\n")
+	write("c/c_test.go", "package c\n\nimport \"testing\"\n\nfunc TestC(t *testing.T) {}\n")
+	write("README.md", "# m\n")
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+	cfg := config.DefaultConfig()
+
+	run := Run(tmpDir, cfg, false)
+	if run.Mode != ModeFull || run.Reason != "no green run recorded" || run.Summary.Result != testrunner.Passed {
+		t.Fatalf("first Run() = %+v, want a passing full run", run)
+	}
+	if state, _ := Load(tmpDir); state.Commit == "" || state.FullAt.IsZero() {
+		t.Errorf("state after a green full run = %+v, want the commit recorded", state)
+	}
+
+	write("README.md", "# m\n\nDocs only.\n")
+	if run := Run(tmpDir, cfg, false); run.Mode != ModeUnchanged {
+		t.Errorf("Run() after a docs change = %+v, want unchanged", run)
+	}
+
+	write("a/a.go", "package a\n\nfunc A() int { return 2 }\n")
+	git("commit", "-q", "-am", "change a")
+	run = Run(tmpDir, cfg, false)
+	if run.Mode != ModeIncremental || !reflect.DeepEqual(run.Targets, []string{"./a", "./b"}) || run.Summary.Result != testrunner.Passed {
+		t.Errorf("Run() after changing a = %+v, want ./a and ./b passing", run)
+	}
+	if run := Run(tmpDir, cfg, false); run.Mode != ModeUnchanged {
+		t.Errorf("Run() after a green incremental run = %+v, want unchanged", run)
+	}
+
+	if run := Run(tmpDir, cfg, true); run.Mode != ModeFull || run.Reason != "requested" {
+		t.Errorf("Run(full) = %+v, want a requested full run", run)
+	}
+
+	state, _ := Load(tmpDir)
+	state.FullAt = time.Now().Add(-25 * time.Hour)
+	state.Save(tmpDir)
+	if got := plan(tmpDir, cfg, state, state.Commit, false, time.Now()); got.Mode != ModeFull || got.Reason != "no full run in the last 24 hours" {
+		t.Errorf("plan() a day after the last full run = %+v, want a full run", got)
+	}
+
+	state.FullAt = time.Now()
+	write("lib/parse.rs", "fn main() {}\n")
+	if got := plan(tmpDir, cfg, state, state.Commit, false, time.Now()); got.Mode != ModeFull || got.Reason != "cannot map lib/parse.rs to its tests" {
+		t.Errorf("plan() with a Rust change = %+v, want a full run", got)
+	}
+
+	state.Commit = "0123456789abcdef0123456789abcdef01234567"
+	if got := plan(tmpDir, cfg, state, state.Commit, false, time.Now()); got.Mode != ModeFull || got.Reason != "last green commit 0123456 no longer exists" {
+		t.Errorf("plan() with a lost commit = %+v, want a full run", got)
+	}
+}
@@ -0,0 +1,193 @@
+// Package blockers keeps the errors that stopped recent Bash commands:
+// compiler errors, panics, tracebacks, and uncaught exceptions, each with
+// the file and line it points at. Unresolved blockers are carried across
+// compactions and sessions so the agent does not lose track of what is
+// still broken.
+//
+// Blockers are stored in .claude/blockers.json. PostToolUse records them
+// from failing Bash output and resolves them when the command that
+// reported them runs again without them.
+package blockers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the blockers file inside .claude.
+const FileName = "blockers.json"
+
+// FilePermission is the permission for the blockers file
+const FilePermission = 0600
+
+// DirPermission is the permission for the blockers directory
+const DirPermission = 0700
+
+// MaxBlockers caps the list; resolved blockers are dropped first, then the
+// oldest.
+const MaxBlockers = 50
+
+// Blocker is an error reported by a Bash command.
+type Blocker struct {
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	// Kind is "error", "panic", or "exception"
+	Kind string `json:"kind"`
+	// Command is the normalized command that reported the blocker
+	Command   string    `json:"command"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Resolved  bool      `json:"resolved"`
+	// ResolvedAt is when a run of Command no longer reported the blocker
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Location returns file:line, or the file alone without a line.
+func (b Blocker) Location() string {
+	if b.Line > 0 {
+		return fmt.Sprintf("%s:%d", b.File, b.Line)
+	}
+	return b.File
+}
+
+// String renders the blocker as location: message.
+func (b Blocker) String() string {
+	if b.File == "" {
+		return b.Message
+	}
+	return b.Location() + ": " + b.Message
+}
+
+// key identifies a blocker across runs. The line is left out since edits
+// above an error move it.
+func (b Blocker) key() string {
+	return b.File + "\x00" + b.Message
+}
+
+// List is the project's blockers, oldest first.
+type List struct {
+	Blockers []Blocker `json:"blockers"`
+}
+
+// GetPath returns the path to the blockers file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the blockers. A missing file is an empty list.
+func Load(workDir string) (*List, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &List{}, nil
+		}
+		return nil, err
+	}
+
+	var l List
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Save writes the blockers.
+func (l *List) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Record updates the list with the blockers found in a run of command.
+// Known blockers are counted again and reopened if they were resolved;
+// unresolved blockers command reported before but not this time are
+// resolved. Returns the number of new blockers and of resolved ones.
+func (l *List) Record(command string, found []Blocker) (added, resolved int) {
+	command = NormalizeCommand(command)
+	now := time.Now()
+
+	seen := map[string]bool{}
+	for _, f := range found {
+		k := f.key()
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		if i := l.find(k); i >= 0 {
+			b := &l.Blockers[i]
+			b.Line, b.Command, b.LastSeen = f.Line, command, now
+			b.Count++
+			b.Resolved, b.ResolvedAt = false, nil
+			continue
+		}
+		f.Command, f.Count, f.FirstSeen, f.LastSeen = command, 1, now, now
+		l.Blockers = append(l.Blockers, f)
+		added++
+	}
+
+	for i := range l.Blockers {
+		b := &l.Blockers[i]
+		if !b.Resolved && b.Command == command && !seen[b.key()] {
+			b.Resolved, b.ResolvedAt = true, &now
+			resolved++
+		}
+	}
+	l.prune()
+	return added, resolved
+}
+
+func (l *List) find(key string) int {
+	for i, b := range l.Blockers {
+		if b.key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// prune drops resolved blockers, then the oldest, until the list fits
+// MaxBlockers.
+func (l *List) prune() {
+	excess := len(l.Blockers) - MaxBlockers
+	if excess <= 0 {
+		return
+	}
+	kept := l.Blockers[:0]
+	for _, b := range l.Blockers {
+		if b.Resolved && excess > 0 {
+			excess--
+			continue
+		}
+		kept = append(kept, b)
+	}
+	l.Blockers = kept[excess:]
+}
+
+// Unresolved returns the blockers not yet resolved, oldest first.
+func (l *List) Unresolved() []Blocker {
+	var open []Blocker
+	for _, b := range l.Blockers {
+		if !b.Resolved {
+			open = append(open, b)
+		}
+	}
+	return open
+}
+
+// NormalizeCommand collapses the whitespace in a command, so reruns match
+// however they were typed.
+func NormalizeCommand(command string) string {
+	return strings.Join(strings.Fields(command), " ")
+}
@@ -0,0 +1,169 @@
+package blockers
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+		kind   string
+	}{
+		{
+			name:   "go build",
+			output: "# example.com/app\n./main.go:12:5: undefined: handler\n./main.go:14:2: declared and not used: x\n",
+			want:   []string{"main.go:12: undefined: handler", "main.go:14: declared and not used: x"},
+			kind:   KindError,
+		},
+		{
+			name:   "go test failure",
+			output: "--- FAIL: TestParse (0.00s)\n    parse_test.go:23: Parse() = 1, want 2\nFAIL\n",
+			want:   []string{"parse_test.go:23: Parse() = 1, want 2"},
+			kind:   KindError,
+		},
+		{
+			name: "go panic",
+			output: "panic: runtime error: index out of range [3] with length 3\n\n" +
+				"goroutine 1 [running]:\nruntime.panicIndex()\n\t/usr/local/go/src/runtime/panic.go:114 +0x1d\n" +
+				"main.parse(...)\n\t/work/app/parse.go:9 +0x25\nmain.main()\n\t/work/app/main.go:5 +0x1a\nexit status 2\n",
+			want: []string{"parse.go:9: panic: runtime error: index out of range [3] with length 3"},
+			kind: KindPanic,
+		},
+		{
+			name: "python traceback",
+			output: "Traceback (most recent call last):\n  File \"/work/app/app/views.py\", line 12, in index\n    total(cart)\n" +
+				"  File \"/usr/lib/python3.11/decimal.py\", line 40, in total\n    raise ValueError(\"bad\")\nValueError: bad cart\n",
+			want: []string{"app/views.py:12: ValueError: bad cart"},
+			kind: KindException,
+		},
+		{
+			name: "node exception",
+			output: "TypeError: Cannot read properties of undefined (reading 'id')\n" +
+				"    at getUser (/work/app/src/users.js:8:15)\n    at Module._compile (node:internal/modules/cjs/loader:1101:14)\n",
+			want: []string{"src/users.js:8: TypeError: Cannot read properties of undefined (reading 'id')"},
+			kind: KindException,
+		},
+		{
+			name:   "tsc",
+			output: "src/app.ts(12,5): error TS2304: Cannot find name 'x'.\n",
+			want:   []string{"src/app.ts:12: TS2304: Cannot find name 'x'."},
+			kind:   KindError,
+		},
+		{
+			name:   "rustc",
+			output: "error[E0425]: cannot find value `x` in this scope\n --> src/main.rs:4:5\n  |\n",
+			want:   []string{"src/main.rs:4: [E0425] cannot find value `x` in this scope"},
+			kind:   KindError,
+		},
+		{
+			name:   "gcc warnings skipped",
+			output: "main.c:3:1: warning: unused variable\nmain.c:7:9: error: expected ';'\n",
+			want:   []string{"main.c:7: expected ';'"},
+			kind:   KindError,
+		},
+		{
+			name:   "no errors",
+			output: "ok  \texample.com/app\t0.01s\nError: see above\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := Extract("/work/app", tt.output)
+			if len(found) != len(tt.want) {
+				t.Fatalf("Extract() = %v, want %v", found, tt.want)
+			}
+			for i, b := range found {
+				if b.String() != tt.want[i] || b.Kind != tt.kind {
+					t.Errorf("Extract()[%d] = %q (%s), want %q (%s)", i, b, b.Kind, tt.want[i], tt.kind)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractLimit(t *testing.T) {
+	var output string
+	for i := 1; i <= MaxPerRun+5; i++ {
+		output += fmt.Sprintf("main.go:%d:1: undefined: v%d\n", i, i)
+	}
+	if found := Extract("", output); len(found) != MaxPerRun {
+		t.Errorf("Extract() found %d blockers, want %d", len(found), MaxPerRun)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	list := &List{}
+	undefined := Blocker{Kind: KindError, File: "main.go", Line: 12, Message: "undefined: handler"}
+	unused := Blocker{Kind: KindError, File: "main.go", Line: 14, Message: "declared and not used: x"}
+
+	if added, resolved := list.Record("go  build ./...", []Blocker{undefined, unused}); added != 2 || resolved != 0 {
+		t.Fatalf("Record() = %d, %d, want 2 added", added, resolved)
+	}
+
+	// The line moved after an edit; the unused variable is fixed
+	undefined.Line = 13
+	if added, resolved := list.Record("go build ./...", []Blocker{undefined}); added != 0 || resolved != 1 {
+		t.Fatalf("Record() = %d, %d, want 1 resolved", added, resolved)
+	}
+	open := list.Unresolved()
+	if len(open) != 1 || open[0].Line != 13 || open[0].Count != 2 {
+		t.Fatalf("Unresolved() = %+v, want undefined seen twice at line 13", open)
+	}
+
+	// Other commands leave it alone
+	if _, resolved := list.Record("go vet ./...", nil); resolved != 0 {
+		t.Errorf("Record() from another command resolved %d, want 0", resolved)
+	}
+	if _, resolved := list.Record("go build ./...", nil); resolved != 1 || len(list.Unresolved()) != 0 {
+		t.Errorf("Record() of a clean run resolved %d, want 1 and none left", resolved)
+	}
+
+	// A recurring blocker is reopened
+	list.Record("go build ./...", []Blocker{unused})
+	if open := list.Unresolved(); len(open) != 1 || open[0].Message != unused.Message || open[0].Count != 2 {
+		t.Errorf("Unresolved() = %+v, want the unused variable reopened", open)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	list := &List{}
+	for i := 0; i < MaxBlockers; i++ {
+		list.Record("make", []Blocker{{File: "a.c", Message: fmt.Sprintf("error %d", i)}})
+	}
+	list.Record("make test", []Blocker{{File: "t.c", Message: "failed"}})
+	if len(list.Blockers) != MaxBlockers {
+		t.Fatalf("len(Blockers) = %d, want %d", len(list.Blockers), MaxBlockers)
+	}
+	if open := list.Unresolved(); len(open) != 2 || open[1].Message != "failed" {
+		t.Errorf("Unresolved() = %+v, want the last make error and the test failure", open)
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "blockers-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	list, err := Load(tmpDir)
+	if err != nil || len(list.Blockers) != 0 {
+		t.Fatalf("Load() without a file = %+v, %v, want an empty list", list, err)
+	}
+	list.Record("go test ./...", []Blocker{{Kind: KindPanic, File: "a.go", Line: 3, Message: "panic: boom"}})
+	if err := list.Save(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if open := loaded.Unresolved(); len(open) != 1 || open[0].String() != "a.go:3: panic: boom" || open[0].Command != "go test ./..." {
+		t.Errorf("Load() = %+v, want the saved panic", open)
+	}
+}
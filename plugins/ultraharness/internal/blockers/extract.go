@@ -0,0 +1,188 @@
+package blockers
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ultraharness/internal/text"
+)
+
+// Kinds of blockers
+const (
+	KindError     = "error"
+	KindPanic     = "panic"
+	KindException = "exception"
+)
+
+// Limits on extraction, so a flood of errors does not bury the first ones
+const (
+	MaxPerRun  = 10
+	maxMessage = 200
+)
+
+var (
+	// main.go:12:5: undefined: x, src/a.c:3:1: error: ..., and the
+	// indented failures of go test
+	locatedPattern = regexp.MustCompile(`^\s*([\w./\\-]+\.[A-Za-z]\w*):(\d+)(?::\d+)?:\s+(.+)$`)
+	// src/app.ts(12,5): error TS2304: Cannot find name 'x'.
+	tscPattern = regexp.MustCompile(`^([\w./\\-]+\.\w+)\((\d+),\d+\):\s+error\s+(.+)$`)
+	// error[E0425]: cannot find value `x` in this scope
+	rustErrorPattern = regexp.MustCompile(`^error(\[E\d+\])?:\s+(.+)$`)
+	// --> src/main.rs:4:5
+	rustLocationPattern = regexp.MustCompile(`^\s*-->\s+(\S+?):(\d+):\d+`)
+	// panic: runtime error: index out of range
+	panicPattern = regexp.MustCompile(`^panic:\s+(.+)$`)
+	// \t/home/u/app/main.go:12 +0x1d
+	goFramePattern = regexp.MustCompile(`^\s+(\S+\.go):(\d+)`)
+	// File "app/views.py", line 12, in index
+	pyFramePattern = regexp.MustCompile(`^\s*File "([^"]+)", line (\d+)`)
+	// ValueError: bad input, or TypeError: x is not a function
+	exceptionPattern = regexp.MustCompile(`^(?:Uncaught )?([\w.]*(?:Error|Exception)|KeyboardInterrupt|AssertionError):?\s*(.*)$`)
+	// at fn (src/app.js:12:5), or at src/app.js:12:5
+	jsFramePattern = regexp.MustCompile(`^\s+at (?:.*\()?([^()\s]+?):(\d+):\d+\)?$`)
+)
+
+// Extract returns the blockers in the output of a failing command, up to
+// MaxPerRun. Files under workDir are made relative to it.
+func Extract(workDir, output string) []Blocker {
+	lines := strings.Split(output, "\n")
+	var found []Blocker
+	add := func(b Blocker) {
+		if len(found) == MaxPerRun || b.Message == "" {
+			return
+		}
+		b.Message = text.Truncate(strings.TrimSpace(b.Message), maxMessage)
+		b.File = relFile(workDir, b.File)
+		found = append(found, b)
+	}
+
+	for i := 0; i < len(lines) && len(found) < MaxPerRun; i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		switch {
+		case panicPattern.MatchString(line):
+			b := Blocker{Kind: KindPanic, Message: "panic: " + panicPattern.FindStringSubmatch(line)[1]}
+			i = goFrame(lines, i+1, &b)
+			add(b)
+
+		case strings.HasPrefix(line, "Traceback (most recent call last)"):
+			b := Blocker{Kind: KindException}
+			i = pyTraceback(lines, i+1, &b)
+			add(b)
+
+		case rustErrorPattern.MatchString(line):
+			m := rustErrorPattern.FindStringSubmatch(line)
+			b := Blocker{Kind: KindError, Message: strings.TrimPrefix(m[1]+" ", " ") + m[2]}
+			if i+1 < len(lines) {
+				if loc := rustLocationPattern.FindStringSubmatch(lines[i+1]); loc != nil {
+					b.File, b.Line = loc[1], atoi(loc[2])
+					i++
+				}
+			}
+			add(b)
+
+		case tscPattern.MatchString(line):
+			m := tscPattern.FindStringSubmatch(line)
+			add(Blocker{Kind: KindError, File: m[1], Line: atoi(m[2]), Message: m[3]})
+
+		case locatedPattern.MatchString(line):
+			m := locatedPattern.FindStringSubmatch(line)
+			msg := strings.TrimPrefix(m[3], "error: ")
+			if strings.HasPrefix(msg, "warning") || strings.HasPrefix(msg, "note:") {
+				continue
+			}
+			add(Blocker{Kind: KindError, File: m[1], Line: atoi(m[2]), Message: msg})
+
+		case exceptionPattern.MatchString(line) && i+1 < len(lines) && jsFramePattern.MatchString(lines[i+1]):
+			m := exceptionPattern.FindStringSubmatch(line)
+			b := Blocker{Kind: KindException, Message: strings.TrimSuffix(m[1]+": "+m[2], ": ")}
+			i = jsFrames(lines, i+1, &b)
+			add(b)
+		}
+	}
+	return found
+}
+
+// goFrame locates a panic at the first frame of the panicking goroutine
+// outside the Go runtime and testing packages. Returns the index of the
+// last line of the trace.
+func goFrame(lines []string, i int, b *Blocker) int {
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" && b.File != "" {
+			return i
+		}
+		m := goFramePattern.FindStringSubmatch(line)
+		if m == nil || b.File != "" || isGoLibrary(m[1]) {
+			continue
+		}
+		b.File, b.Line = m[1], atoi(m[2])
+	}
+	return i
+}
+
+func isGoLibrary(file string) bool {
+	file = filepath.ToSlash(file)
+	return strings.Contains(file, "/src/runtime/") || strings.Contains(file, "/src/testing/") ||
+		strings.Contains(file, "/pkg/mod/")
+}
+
+// pyTraceback locates an exception at the innermost frame outside
+// installed packages and takes the message from the line after the
+// frames. Returns the index of that line.
+func pyTraceback(lines []string, i int, b *Blocker) int {
+	for ; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if m := pyFramePattern.FindStringSubmatch(line); m != nil {
+			if !isPythonLibrary(m[1]) {
+				b.File, b.Line = m[1], atoi(m[2])
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		b.Message = line
+		return i
+	}
+	return i
+}
+
+func isPythonLibrary(file string) bool {
+	file = filepath.ToSlash(file)
+	return strings.Contains(file, "site-packages/") || strings.Contains(file, "/lib/python") ||
+		strings.HasPrefix(file, "<")
+}
+
+// jsFrames locates an exception at the first stack frame outside
+// node_modules and Node internals. Returns the index of the last frame.
+func jsFrames(lines []string, i int, b *Blocker) int {
+	for ; i < len(lines); i++ {
+		m := jsFramePattern.FindStringSubmatch(strings.TrimRight(lines[i], "\r"))
+		if m == nil {
+			return i - 1
+		}
+		file := strings.TrimPrefix(m[1], "file://")
+		if b.File == "" && !strings.HasPrefix(file, "node:") && !strings.Contains(file, "node_modules/") {
+			b.File, b.Line = file, atoi(m[2])
+		}
+	}
+	return i
+}
+
+// relFile makes an absolute file under workDir relative to it and drops a
+// leading ./ so the same file is named the same way by every tool.
+func relFile(workDir, file string) string {
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(workDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(file), "./")
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
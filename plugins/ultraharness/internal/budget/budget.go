@@ -0,0 +1,167 @@
+// Package budget enforces per-session limits on tool calls, wall time, files
+// modified, lines changed, and subagents.
+// Budgets are opt-in: a zero limit means unlimited.
+package budget
+
+import (
+	"fmt"
+	"strings"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/session"
+)
+
+// Status contains the outcome of a budget check
+type Status struct {
+	Warnings []string // limits at or above the warning ratio, up to the limit itself
+	Exceeded []string // limits that usage has gone past
+}
+
+// IsExceeded returns true if usage has gone past any budget limit
+func (s *Status) IsExceeded() bool {
+	return len(s.Exceeded) > 0
+}
+
+// HasWarnings returns true if any budget limit is approaching
+func (s *Status) HasWarnings() bool {
+	return len(s.Warnings) > 0
+}
+
+// Check compares session usage against the configured budget. A limit is
+// only exceeded once usage goes past it: a session may use all N of N.
+func Check(state *session.State, budget config.BudgetConfig) *Status {
+	return check(state, budget, len(state.FilesModified))
+}
+
+// CheckEdit is Check before an edit of path. A file the session has not
+// modified yet counts toward files modified, so at N/N an edit that adds
+// a new file is over the limit while edits to the N files are not.
+func CheckEdit(state *session.State, budget config.BudgetConfig, path string) *Status {
+	files := len(state.FilesModified)
+	if path != "" {
+		files++
+		for _, f := range state.FilesModified {
+			if f == path {
+				files--
+				break
+			}
+		}
+	}
+	return check(state, budget, files)
+}
+
+func check(state *session.State, budget config.BudgetConfig, filesModified int) *Status {
+	status := &Status{}
+
+	status.check("tool calls", state.ToolCalls, budget.MaxToolCalls, budget.WarnRatio)
+	status.check("minutes", int(state.Elapsed().Minutes()), budget.MaxWallMinutes, budget.WarnRatio)
+	status.check("files modified", filesModified, budget.MaxFilesModified, budget.WarnRatio)
+	status.check("lines changed", state.LinesChanged, budget.MaxLinesChanged, budget.WarnRatio)
+
+	return status
+}
+
+func (s *Status) check(name string, used, limit int, warnRatio float64) {
+	if limit <= 0 {
+		return
+	}
+
+	usage := fmt.Sprintf("%d/%d %s", used, limit, name)
+	if used > limit {
+		s.Exceeded = append(s.Exceeded, usage)
+	} else if float64(used) >= float64(limit)*warnRatio {
+		s.Warnings = append(s.Warnings, usage)
+	}
+}
+
+// FormatStatus formats the budget status as a user-friendly message
+func FormatStatus(status *Status) string {
+	if status.IsExceeded() {
+		msg := "[Harness] Session budget exceeded: " + strings.Join(status.Exceeded, ", ")
+		msg += "\nCheckpoint your work and wrap up, or start a new session."
+		return msg
+	}
+	if status.HasWarnings() {
+		return "[Harness] Session budget nearly used: " + strings.Join(status.Warnings, ", ")
+	}
+	return ""
+}
+
+// LinesChanged estimates the lines added plus removed when before is
+// replaced by after. Lines are matched regardless of position, so moved
+// lines are not counted.
+func LinesChanged(before, after string) int {
+	counts := make(map[string]int)
+	for _, line := range splitLines(before) {
+		counts[line]++
+	}
+
+	added := 0
+	for _, line := range splitLines(after) {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added++
+		}
+	}
+
+	removed := 0
+	for _, n := range counts {
+		removed += n
+	}
+	return added + removed
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// ProjectDiff checks a change of the given size against the line-change
+// budget before it is made. It returns a message when the change would
+// exceed the budget or cross the warning ratio, and whether it exceeds it.
+func ProjectDiff(state *session.State, budget config.BudgetConfig, lines int) (string, bool) {
+	limit := budget.MaxLinesChanged
+	if limit <= 0 || lines <= 0 {
+		return "", false
+	}
+
+	total := state.LinesChanged + lines
+	if total > limit {
+		msg := fmt.Sprintf("[Harness] Diff budget exceeded: this change (~%d lines) would bring the session to %d/%d lines changed.", lines, total, limit)
+		msg += "\nCheckpoint (commit) what you have and split the remaining work into smaller steps, or start a new session."
+		return msg, true
+	}
+	if float64(total) >= float64(limit)*budget.WarnRatio {
+		return fmt.Sprintf("[Harness] Diff budget nearly used: %d/%d lines changed after this change.", total, limit), false
+	}
+	return "", false
+}
+
+// ProjectSubagent checks starting one more Task subagent against the
+// subagent budgets. It returns a message when the start would exceed a
+// limit or cross the warning ratio, and whether it exceeds one.
+func ProjectSubagent(state *session.State, budget config.BudgetConfig) (string, bool) {
+	if running, limit := state.SubagentsRunning(), budget.MaxConcurrentSubagents; limit > 0 && running >= limit {
+		msg := fmt.Sprintf("[Harness] Subagent limit reached: %d/%d subagents already running.", running, limit)
+		msg += "\nWait for a running subagent to finish, or do this work directly."
+		return msg, true
+	}
+
+	limit := budget.MaxSubagents
+	if limit <= 0 {
+		return "", false
+	}
+	total := state.SubagentsStarted + 1
+	if total > limit {
+		msg := fmt.Sprintf("[Harness] Subagent budget exceeded: %d/%d subagents already used this session.", state.SubagentsStarted, limit)
+		msg += "\nDo the remaining work directly, or start a new session."
+		return msg, true
+	}
+	if float64(total) >= float64(limit)*budget.WarnRatio {
+		return fmt.Sprintf("[Harness] Subagent budget nearly used: %d/%d subagents after this one.", total, limit), false
+	}
+	return "", false
+}
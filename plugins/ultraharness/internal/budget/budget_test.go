@@ -0,0 +1,187 @@
+package budget
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/session"
+)
+
+func TestCheck(t *testing.T) {
+	t.Run("zero limits are unlimited", func(t *testing.T) {
+		state := &session.State{ToolCalls: 1000}
+		status := Check(state, config.BudgetConfig{WarnRatio: 0.8})
+
+		if status.IsExceeded() || status.HasWarnings() {
+			t.Errorf("Check() = %+v, want no warnings or overruns", status)
+		}
+	})
+
+	t.Run("warns when approaching limit", func(t *testing.T) {
+		state := &session.State{ToolCalls: 85}
+		status := Check(state, config.BudgetConfig{MaxToolCalls: 100, WarnRatio: 0.8})
+
+		if status.IsExceeded() {
+			t.Error("Check() should not be exceeded at 85/100")
+		}
+		if !status.HasWarnings() {
+			t.Error("Check() should warn at 85/100")
+		}
+	})
+
+	t.Run("warns at limit", func(t *testing.T) {
+		state := &session.State{ToolCalls: 100, FilesModified: []string{"a.go", "b.go"}}
+		status := Check(state, config.BudgetConfig{MaxToolCalls: 100, MaxFilesModified: 2, WarnRatio: 0.8})
+
+		if status.IsExceeded() {
+			t.Errorf("Exceeded = %v, want none at 100/100 and 2/2", status.Exceeded)
+		}
+		if len(status.Warnings) != 2 {
+			t.Errorf("Warnings = %v, want 2 entries", status.Warnings)
+		}
+	})
+
+	t.Run("exceeded past limit", func(t *testing.T) {
+		state := &session.State{
+			StartedAt:     time.Now().Add(-61 * time.Minute),
+			FilesModified: []string{"a.go", "b.go", "c.go"},
+		}
+		status := Check(state, config.BudgetConfig{MaxWallMinutes: 60, MaxFilesModified: 2, WarnRatio: 0.8})
+
+		if len(status.Exceeded) != 2 {
+			t.Errorf("Exceeded = %v, want 2 entries", status.Exceeded)
+		}
+	})
+}
+
+func TestCheckEdit(t *testing.T) {
+	state := &session.State{FilesModified: []string{"a.go", "b.go"}}
+	limits := config.BudgetConfig{MaxFilesModified: 2, WarnRatio: 0.8}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a.go", false},
+		{"b.go", false},
+		{"c.go", true},
+	}
+	for _, tt := range tests {
+		if got := CheckEdit(state, limits, tt.path).IsExceeded(); got != tt.want {
+			t.Errorf("CheckEdit(2/2 files, %q).IsExceeded() = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if CheckEdit(&session.State{FilesModified: []string{"a.go"}}, limits, "c.go").IsExceeded() {
+		t.Error("CheckEdit(1/2 files, new file) should not be exceeded")
+	}
+}
+
+func TestFormatStatus(t *testing.T) {
+	if msg := FormatStatus(&Status{}); msg != "" {
+		t.Errorf("FormatStatus() = %v, want empty", msg)
+	}
+
+	msg := FormatStatus(&Status{Warnings: []string{"85/100 tool calls"}})
+	if !strings.Contains(msg, "nearly used") || !strings.Contains(msg, "85/100 tool calls") {
+		t.Errorf("FormatStatus() warning = %v", msg)
+	}
+
+	msg = FormatStatus(&Status{Exceeded: []string{"101/100 tool calls"}})
+	if !strings.Contains(msg, "exceeded") {
+		t.Errorf("FormatStatus() exceeded = %v", msg)
+	}
+}
+
+func TestLinesChanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   int
+	}{
+		{"new file", "", "a\nb\nc\n", 3},
+		{"deleted content", "a\nb\n", "", 2},
+		{"one line modified", "a\nb\nc\n", "a\nB\nc\n", 2},
+		{"unchanged", "a\nb\n", "a\nb\n", 0},
+		{"moved line", "a\nb\nc", "c\na\nb", 0},
+		{"duplicate lines", "x\nx\n", "x\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LinesChanged(tt.before, tt.after); got != tt.want {
+				t.Errorf("LinesChanged() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectDiff(t *testing.T) {
+	budget := config.BudgetConfig{MaxLinesChanged: 100, WarnRatio: 0.8}
+
+	if msg, exceeded := ProjectDiff(&session.State{LinesChanged: 10}, config.BudgetConfig{WarnRatio: 0.8}, 500); msg != "" || exceeded {
+		t.Errorf("ProjectDiff() without a limit = %q, %v, want no message", msg, exceeded)
+	}
+
+	if msg, exceeded := ProjectDiff(&session.State{LinesChanged: 10}, budget, 20); msg != "" || exceeded {
+		t.Errorf("ProjectDiff() within budget = %q, %v, want no message", msg, exceeded)
+	}
+
+	msg, exceeded := ProjectDiff(&session.State{LinesChanged: 70}, budget, 15)
+	if exceeded || !strings.Contains(msg, "85/100") {
+		t.Errorf("ProjectDiff() near limit = %q, %v, want warning with 85/100", msg, exceeded)
+	}
+
+	msg, exceeded = ProjectDiff(&session.State{LinesChanged: 70}, budget, 40)
+	if !exceeded || !strings.Contains(msg, "110/100") {
+		t.Errorf("ProjectDiff() over limit = %q, %v, want exceeded with 110/100", msg, exceeded)
+	}
+
+	if status := Check(&session.State{LinesChanged: 100}, budget); status.IsExceeded() {
+		t.Error("Check() should not be exceeded at 100/100 lines changed")
+	}
+	if status := Check(&session.State{LinesChanged: 101}, budget); !status.IsExceeded() {
+		t.Error("Check() should be exceeded at 101/100 lines changed")
+	}
+}
+
+func TestProjectSubagent(t *testing.T) {
+	budget := config.BudgetConfig{MaxSubagents: 10, MaxConcurrentSubagents: 2, WarnRatio: 0.8}
+	running := func(n int) []time.Time {
+		starts := make([]time.Time, n)
+		for i := range starts {
+			starts[i] = time.Now()
+		}
+		return starts
+	}
+
+	if msg, exceeded := ProjectSubagent(&session.State{SubagentsStarted: 50, SubagentStarts: running(9)}, config.BudgetConfig{WarnRatio: 0.8}); msg != "" || exceeded {
+		t.Errorf("ProjectSubagent() without limits = %q, %v, want no message", msg, exceeded)
+	}
+
+	if msg, exceeded := ProjectSubagent(&session.State{SubagentsStarted: 3, SubagentStarts: running(1)}, budget); msg != "" || exceeded {
+		t.Errorf("ProjectSubagent() within budget = %q, %v, want no message", msg, exceeded)
+	}
+
+	msg, exceeded := ProjectSubagent(&session.State{SubagentsStarted: 3, SubagentStarts: running(2)}, budget)
+	if !exceeded || !strings.Contains(msg, "2/2 subagents already running") {
+		t.Errorf("ProjectSubagent() at concurrency limit = %q, %v, want exceeded with 2/2 running", msg, exceeded)
+	}
+
+	stale := []time.Time{time.Now().Add(-2 * session.SubagentTimeout), time.Now().Add(-session.SubagentTimeout)}
+	if msg, exceeded := ProjectSubagent(&session.State{SubagentsStarted: 3, SubagentStarts: stale}, budget); msg != "" || exceeded {
+		t.Errorf("ProjectSubagent() with stale subagents = %q, %v, want them expired", msg, exceeded)
+	}
+
+	msg, exceeded = ProjectSubagent(&session.State{SubagentsStarted: 7}, budget)
+	if exceeded || !strings.Contains(msg, "8/10") {
+		t.Errorf("ProjectSubagent() near limit = %q, %v, want warning with 8/10", msg, exceeded)
+	}
+
+	msg, exceeded = ProjectSubagent(&session.State{SubagentsStarted: 10}, budget)
+	if !exceeded || !strings.Contains(msg, "10/10") {
+		t.Errorf("ProjectSubagent() over limit = %q, %v, want exceeded with 10/10", msg, exceeded)
+	}
+}
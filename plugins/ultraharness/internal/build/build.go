@@ -0,0 +1,150 @@
+// Package build runs the project build and caches the result against the
+// working tree state, so unchanged code is not rebuilt.
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ultraharness/internal/git"
+	"ultraharness/internal/project"
+	"ultraharness/internal/text"
+)
+
+// CacheFileName is the name of the build result cache.
+const CacheFileName = "fic-build-cache.json"
+
+// DefaultTimeout is the default build timeout.
+const DefaultTimeout = 90 * time.Second
+
+// MaxOutputLength limits the stored build output.
+const MaxOutputLength = 500
+
+// FilePermission is the permission for the cache file
+const FilePermission = 0600
+
+// DirPermission is the permission for the cache directory
+const DirPermission = 0700
+
+// Result is the outcome of a build.
+type Result struct {
+	Command     string    `json:"command"`
+	Fingerprint string    `json:"fingerprint"`
+	Success     bool      `json:"success"`
+	Output      string    `json:"output,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	BuiltAt     time.Time `json:"built_at"`
+
+	// Cached is set when the result was reused for an unchanged tree
+	Cached bool `json:"-"`
+}
+
+// DetectCommand returns the configured build command, or the detected one
+// if none is configured. Returns nil if the project has no build command.
+func DetectCommand(workDir string, configured []string) []string {
+	if len(configured) > 0 {
+		return configured
+	}
+	return project.Detect(workDir).BuildCommand
+}
+
+// GetCachePath returns the path to the build cache file.
+func GetCachePath(workDir string) string {
+	return filepath.Join(workDir, ".claude", CacheFileName)
+}
+
+// LoadCached returns the last build result, or nil if there is none.
+func LoadCached(workDir string) *Result {
+	data, err := os.ReadFile(GetCachePath(workDir))
+	if err != nil {
+		return nil
+	}
+
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+	return &result
+}
+
+// Verify builds the project unless the cached result matches the current
+// working tree and build command. Returns nil if command is empty.
+func Verify(workDir string, command []string, timeout time.Duration) *Result {
+	if len(command) == 0 {
+		return nil
+	}
+
+	fingerprint := git.StateFingerprint(workDir)
+	commandStr := strings.Join(command, " ")
+
+	if cached := LoadCached(workDir); cached != nil &&
+		cached.Fingerprint == fingerprint && cached.Command == commandStr {
+		cached.Cached = true
+		return cached
+	}
+
+	result := Run(workDir, command, timeout)
+	result.Fingerprint = fingerprint
+	save(workDir, result)
+	return result
+}
+
+// Run executes the build command.
+func Run(workDir string, command []string, timeout time.Duration) *Result {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	result := &Result{Command: strings.Join(command, " "), BuiltAt: time.Now()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = workDir
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Error = "build timed out after " + timeout.String()
+		return result
+	}
+
+	// Keep the end of the output, where compilers report errors
+	outputStr := strings.TrimSpace(string(output))
+	if len(outputStr) > MaxOutputLength {
+		outputStr = "...[truncated]" + text.Suffix(outputStr, MaxOutputLength)
+	}
+	result.Output = outputStr
+
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.Error = "build failed (exit " + strconv.Itoa(exitErr.ExitCode()) + ")"
+		} else {
+			result.Error = "build failed: " + err.Error()
+		}
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+func save(workDir string, result *Result) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetCachePath(workDir), data, FilePermission)
+}
@@ -0,0 +1,87 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func createTestRepo(t *testing.T) string {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "build-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec.Command("git", "-C", tmpDir, "init", "-q").Run()
+	return tmpDir
+}
+
+func TestVerify(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	counter := filepath.Join(tmpDir, ".claude", "builds")
+	os.MkdirAll(filepath.Dir(counter), 0700)
+	command := []string{"sh", "-c", "echo build >> " + counter}
+	builds := func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "build")
+	}
+
+	first := Verify(tmpDir, command, 0)
+	if first == nil || !first.Success || first.Cached {
+		t.Fatalf("Verify() = %+v, want fresh success", first)
+	}
+
+	second := Verify(tmpDir, command, 0)
+	if !second.Cached || builds() != 1 {
+		t.Errorf("Verify() on unchanged tree: Cached = %v, builds = %d, want cached with 1 build", second.Cached, builds())
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644)
+	third := Verify(tmpDir, command, 0)
+	if third.Cached || builds() != 2 {
+		t.Errorf("Verify() after change: Cached = %v, builds = %d, want rebuild", third.Cached, builds())
+	}
+}
+
+func TestVerifyFailure(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	result := Verify(tmpDir, []string{"sh", "-c", "echo 'main.go:3: syntax error'; exit 2"}, 0)
+	if result.Success {
+		t.Fatal("Success = true, want false")
+	}
+	if result.Error != "build failed (exit 2)" {
+		t.Errorf("Error = %q, want %q", result.Error, "build failed (exit 2)")
+	}
+	if !strings.Contains(result.Output, "syntax error") {
+		t.Errorf("Output = %q, want compiler output", result.Output)
+	}
+}
+
+func TestVerifyNoCommand(t *testing.T) {
+	if result := Verify(os.TempDir(), nil, 0); result != nil {
+		t.Errorf("Verify() with no command = %+v, want nil", result)
+	}
+}
+
+func TestDetectCommand(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "build-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module x\n"), 0644)
+
+	if got := strings.Join(DetectCommand(tmpDir, nil), " "); got != "go build ./..." {
+		t.Errorf("DetectCommand() = %q, want %q", got, "go build ./...")
+	}
+	if got := strings.Join(DetectCommand(tmpDir, []string{"make"}), " "); got != "make" {
+		t.Errorf("DetectCommand() with config = %q, want %q", got, "make")
+	}
+}
@@ -0,0 +1,223 @@
+// Package changelog stages agent-driven changes in the Unreleased section
+// of a Keep a Changelog style CHANGELOG.md and rolls them into releases.
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/progress"
+)
+
+// ChangelogFile is the name of the changelog file.
+const ChangelogFile = "CHANGELOG.md"
+
+// UnreleasedHeading marks the staging section.
+const UnreleasedHeading = "## [Unreleased]"
+
+// Keep a Changelog section names, in output order.
+const (
+	SectionAdded    = "Added"
+	SectionChanged  = "Changed"
+	SectionRemoved  = "Removed"
+	SectionFixed    = "Fixed"
+	SectionSecurity = "Security"
+)
+
+var sectionOrder = []string{SectionAdded, SectionChanged, SectionRemoved, SectionFixed, SectionSecurity}
+
+// ErrNothingToRelease is returned when the Unreleased section is empty.
+var ErrNothingToRelease = errors.New("no unreleased changes")
+
+// conventionalTypes maps conventional commit types to sections. Types not
+// listed (docs, test, chore, ci, build, style) are not user-facing.
+var conventionalTypes = map[string]string{
+	"feat":     SectionAdded,
+	"fix":      SectionFixed,
+	"perf":     SectionChanged,
+	"refactor": SectionChanged,
+	"revert":   SectionRemoved,
+	"security": SectionSecurity,
+}
+
+var conventionalPattern = regexp.MustCompile(`^(\w+)(?:\(([^)]+)\))?!?:\s*(.+)$`)
+
+var header = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// Entry is a single changelog line.
+type Entry struct {
+	Section     string
+	Scope       string
+	Description string
+}
+
+// Line renders the entry as a Markdown list item.
+func (e Entry) Line() string {
+	if e.Scope != "" {
+		return fmt.Sprintf("- **%s:** %s", e.Scope, e.Description)
+	}
+	return "- " + e.Description
+}
+
+// ParseConventional parses a conventional commit subject such as
+// "feat(auth): add login". Returns false for non-conventional or
+// non-user-facing types.
+func ParseConventional(subject string) (Entry, bool) {
+	m := conventionalPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return Entry{}, false
+	}
+
+	section, ok := conventionalTypes[strings.ToLower(m[1])]
+	if !ok {
+		return Entry{}, false
+	}
+	return Entry{Section: section, Scope: m[2], Description: m[3]}, true
+}
+
+// FromPlanStep derives an entry from a completed plan step, classifying it
+// by its leading verb.
+func FromPlanStep(step artifacts.PlanStep) Entry {
+	desc := strings.TrimSpace(step.Description)
+	lower := strings.ToLower(desc)
+
+	section := SectionChanged
+	switch {
+	case hasAnyPrefix(lower, "add", "implement", "create", "introduce", "support"):
+		section = SectionAdded
+	case hasAnyPrefix(lower, "fix", "resolve", "correct"):
+		section = SectionFixed
+	case hasAnyPrefix(lower, "remove", "delete", "drop"):
+		section = SectionRemoved
+	}
+	return Entry{Section: section, Description: desc}
+}
+
+// FromProgress derives entries from progress log lines written in
+// conventional form, e.g. "[2024-01-02 10:00:00] [agent] fix: handle empty
+// input".
+func FromProgress(content string) []Entry {
+	var entries []Entry
+	for _, p := range progress.Entries(content) {
+		if e, ok := ParseConventional(p.Message); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Collect gathers entries for a commit: the commit subject, completed steps
+// of the latest plan, and conventional progress entries.
+func Collect(workDir, commitSubject, progressContent string) []Entry {
+	var entries []Entry
+	if e, ok := ParseConventional(commitSubject); ok {
+		entries = append(entries, e)
+	}
+
+	if latest, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan); latest != nil {
+		if plan, ok := latest.(*artifacts.Plan); ok {
+			for _, step := range plan.Steps {
+				if step.Completed && strings.TrimSpace(step.Description) != "" {
+					entries = append(entries, FromPlanStep(step))
+				}
+			}
+		}
+	}
+
+	return append(entries, FromProgress(progressContent)...)
+}
+
+// GetPath returns the path to the changelog file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ChangelogFile)
+}
+
+// Stage adds entries to the Unreleased section, creating the changelog if
+// needed. Entries already present anywhere in the changelog are skipped.
+// Returns the number of entries added.
+func Stage(workDir string, entries []Entry) (int, error) {
+	content, err := read(workDir)
+	if err != nil {
+		return 0, err
+	}
+
+	doc := parse(content)
+	added := 0
+	for _, e := range entries {
+		line := e.Line()
+		if strings.Contains(content, line) || doc.unreleased.has(line) {
+			continue
+		}
+		doc.unreleased.add(e.Section, line)
+		added++
+	}
+
+	if added == 0 {
+		return 0, nil
+	}
+	return added, os.WriteFile(GetPath(workDir), []byte(doc.render()), 0644)
+}
+
+// Unreleased returns the rendered Unreleased section body.
+func Unreleased(workDir string) (string, error) {
+	content, err := read(workDir)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(parse(content).unreleased.render()), nil
+}
+
+// Release moves the Unreleased entries into a new version section dated
+// date (today if zero) and leaves an empty Unreleased section.
+func Release(workDir, version string, date time.Time) error {
+	content, err := read(workDir)
+	if err != nil {
+		return err
+	}
+
+	doc := parse(content)
+	if doc.unreleased.empty() {
+		return ErrNothingToRelease
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	version = strings.TrimPrefix(version, "v")
+	release := fmt.Sprintf("## [%s] - %s\n\n%s", version, date.Format("2006-01-02"), doc.unreleased.render())
+	doc.rest = release + "\n" + doc.rest
+	doc.unreleased = newSection()
+
+	return os.WriteFile(GetPath(workDir), []byte(doc.render()), 0644)
+}
+
+func read(workDir string) (string, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return header, nil
+		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
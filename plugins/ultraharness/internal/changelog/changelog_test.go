@@ -0,0 +1,146 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/artifacts"
+)
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		subject   string
+		wantOK    bool
+		wantEntry Entry
+	}{
+		{"feat(auth): add login", true, Entry{SectionAdded, "auth", "add login"}},
+		{"fix: handle empty input", true, Entry{SectionFixed, "", "handle empty input"}},
+		{"refactor!: split parser", true, Entry{SectionChanged, "", "split parser"}},
+		{"chore: bump deps", false, Entry{}},
+		{"Add login page", false, Entry{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			got, ok := ParseConventional(tt.subject)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseConventional() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.wantEntry {
+				t.Errorf("ParseConventional() = %+v, want %+v", got, tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestFromPlanStep(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{"Implement token refresh", SectionAdded},
+		{"Fix race in cache", SectionFixed},
+		{"Remove legacy endpoint", SectionRemoved},
+		{"Update error messages", SectionChanged},
+	}
+
+	for _, tt := range tests {
+		if got := FromPlanStep(artifacts.PlanStep{Description: tt.desc}).Section; got != tt.want {
+			t.Errorf("FromPlanStep(%q).Section = %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestFromProgress(t *testing.T) {
+	content := "[2024-01-02 10:00:00] AUTO: Created main.go (new file created)\n" +
+		"[2024-01-02 10:05:00] fix(db): close rows\n" +
+		"feat: add export\n" +
+		"[2024-01-02 10:06:00] [agent] perf: cache lookups\n"
+
+	entries := FromProgress(content)
+	if len(entries) != 3 {
+		t.Fatalf("len(FromProgress()) = %d, want 3", len(entries))
+	}
+	if entries[2].Line() != "- cache lookups" {
+		t.Errorf("entries[2].Line() = %q, want the attributed entry without its source", entries[2].Line())
+	}
+	if entries[0].Line() != "- **db:** close rows" {
+		t.Errorf("entries[0].Line() = %q", entries[0].Line())
+	}
+}
+
+func TestStageAndRelease(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "changelog-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	entries := []Entry{
+		{Section: SectionFixed, Description: "close rows"},
+		{Section: SectionAdded, Scope: "auth", Description: "add login"},
+	}
+
+	added, err := Stage(tmpDir, entries)
+	if err != nil || added != 2 {
+		t.Fatalf("Stage() = %d, %v, want 2, nil", added, err)
+	}
+
+	// Staging the same entries again is a no-op
+	if added, _ := Stage(tmpDir, entries); added != 0 {
+		t.Errorf("Stage() repeat = %d, want 0", added)
+	}
+
+	unreleased, _ := Unreleased(tmpDir)
+	if !strings.HasPrefix(unreleased, "### Added") || !strings.Contains(unreleased, "### Fixed\n\n- close rows") {
+		t.Errorf("Unreleased() = %q, want Added before Fixed", unreleased)
+	}
+
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := Release(tmpDir, "v1.2.0", date); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := Release(tmpDir, "1.3.0", date); err != ErrNothingToRelease {
+		t.Errorf("Release() with empty Unreleased = %v, want ErrNothingToRelease", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, ChangelogFile))
+	content := string(data)
+	unreleasedAt := strings.Index(content, UnreleasedHeading)
+	releaseAt := strings.Index(content, "## [1.2.0] - 2024-03-01")
+	if unreleasedAt < 0 || releaseAt < unreleasedAt {
+		t.Errorf("changelog missing Unreleased above release section:\n%s", content)
+	}
+	if !strings.Contains(content[releaseAt:], "- **auth:** add login") {
+		t.Errorf("release section missing entries:\n%s", content)
+	}
+
+	// Released entries are not staged again
+	if added, _ := Stage(tmpDir, entries); added != 0 {
+		t.Errorf("Stage() after release = %d, want 0", added)
+	}
+}
+
+func TestStagePreservesExistingChangelog(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "changelog-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	existing := "# Changelog\n\n## [1.0.0] - 2023-01-01\n\n### Added\n\n- initial release\n"
+	os.WriteFile(filepath.Join(tmpDir, ChangelogFile), []byte(existing), 0644)
+
+	if _, err := Stage(tmpDir, []Entry{{Section: SectionFixed, Description: "crash on start"}}); err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, ChangelogFile))
+	want := "# Changelog\n\n## [Unreleased]\n\n### Fixed\n\n- crash on start\n\n## [1.0.0] - 2023-01-01\n\n### Added\n\n- initial release\n"
+	if string(data) != want {
+		t.Errorf("changelog =\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,116 @@
+package changelog
+
+import "strings"
+
+// document splits a changelog into the text before the Unreleased section,
+// the Unreleased section itself, and everything after it.
+type document struct {
+	preamble   string
+	unreleased *section
+	rest       string
+}
+
+// section holds list items grouped by subsection heading.
+type section struct {
+	items map[string][]string
+	// extra keeps subsections with unknown headings, in order
+	extra []string
+}
+
+func newSection() *section {
+	return &section{items: make(map[string][]string)}
+}
+
+func (s *section) add(name, line string) {
+	if !containsString(sectionOrder, name) && !containsString(s.extra, name) {
+		s.extra = append(s.extra, name)
+	}
+	s.items[name] = append(s.items[name], line)
+}
+
+func (s *section) has(line string) bool {
+	for _, lines := range s.items {
+		if containsString(lines, line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *section) empty() bool {
+	for _, lines := range s.items {
+		if len(lines) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *section) render() string {
+	var b strings.Builder
+	for _, name := range append(append([]string{}, sectionOrder...), s.extra...) {
+		lines := s.items[name]
+		if len(lines) == 0 {
+			continue
+		}
+		b.WriteString("### " + name + "\n\n")
+		b.WriteString(strings.Join(lines, "\n"))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+func parse(content string) *document {
+	doc := &document{unreleased: newSection()}
+
+	start := strings.Index(content, UnreleasedHeading)
+	if start < 0 {
+		// Insert Unreleased before the first release heading, if any
+		if i := strings.Index(content, "\n## "); i >= 0 {
+			doc.preamble = content[:i+1]
+			doc.rest = content[i+1:]
+		} else {
+			doc.preamble = content
+		}
+		return doc
+	}
+
+	doc.preamble = content[:start]
+	body := content[start+len(UnreleasedHeading):]
+	if i := strings.Index(body, "\n## "); i >= 0 {
+		doc.rest = body[i+1:]
+		body = body[:i]
+	}
+
+	current := SectionChanged
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "### "):
+			current = strings.TrimSpace(strings.TrimPrefix(trimmed, "### "))
+		case trimmed != "":
+			doc.unreleased.add(current, line)
+		}
+	}
+	return doc
+}
+
+func (d *document) render() string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(d.preamble, "\n"))
+	b.WriteString("\n\n" + UnreleasedHeading + "\n\n")
+	b.WriteString(d.unreleased.render())
+	if d.rest != "" {
+		b.WriteString(strings.TrimLeft(d.rest, "\n"))
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
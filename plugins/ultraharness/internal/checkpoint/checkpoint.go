@@ -0,0 +1,191 @@
+// Package checkpoint links git commits to the harness state recorded
+// alongside them. The first time HEAD moves forward to a commit, the
+// harness state is saved as the snapshot "checkpoint-<hash>". When HEAD
+// later moves back to an earlier commit, by git reset or checkout, FIC
+// research, plans, and progress may describe work that is no longer in the
+// tree; Observe reports the move with the snapshot that rolls the state
+// back to match.
+//
+// The last HEAD seen is kept in .claude/checkpoint.json. Since that file is
+// part of every snapshot, restoring a checkpoint also restores it, and the
+// restored state sees the commit it was recorded at.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/git"
+	"ultraharness/internal/snapshot"
+)
+
+// FileName is the checkpoint state file inside .claude.
+const FileName = "checkpoint.json"
+
+// FilePermission is the permission for the checkpoint state file
+const FilePermission = 0600
+
+// DirPermission is the permission for the checkpoint state directory
+const DirPermission = 0700
+
+// SnapshotPrefix starts the name of every checkpoint snapshot.
+const SnapshotPrefix = "checkpoint-"
+
+// MaxSnapshots is the number of checkpoint snapshots kept; older ones are
+// removed as new checkpoints are recorded. Other snapshots are never
+// removed.
+const MaxSnapshots = 20
+
+// shortHash is the length of the commit hash in snapshot names and
+// messages.
+const shortHash = 12
+
+// State is the last HEAD seen.
+type State struct {
+	Head   string    `json:"head"`
+	SeenAt time.Time `json:"seen_at"`
+}
+
+// Move is HEAD moving back to an earlier commit.
+type Move struct {
+	// From and To are the commit hashes before and after the move
+	From string
+	To   string
+	// Snapshot is the checkpoint snapshot recorded at To, or "" if there
+	// is none
+	Snapshot string
+}
+
+// GetPath returns the path to the checkpoint state file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// SnapshotName returns the name of the checkpoint snapshot for a commit.
+func SnapshotName(commit string) string {
+	return SnapshotPrefix + short(commit)
+}
+
+func short(commit string) string {
+	if len(commit) > shortHash {
+		return commit[:shortHash]
+	}
+	return commit
+}
+
+// Load reads the checkpoint state. A missing file is an empty state.
+func Load(workDir string) (*State, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Save writes the checkpoint state.
+func (s *State) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Dir(GetPath(workDir)), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Observe compares HEAD with the last HEAD seen and records it. A move
+// forward records a checkpoint snapshot for the new commit, unless one was
+// recorded when it was first reached; a move back returns the Move. Other
+// moves, such as switching to an unrelated branch, are only recorded.
+// Outside a repository or before the first commit, Observe does nothing.
+func Observe(workDir string) (*Move, error) {
+	head := git.Head(workDir)
+	if head == "" {
+		return nil, nil
+	}
+	state, err := Load(workDir)
+	if err != nil {
+		return nil, err
+	}
+	last := state.Head
+	if last == head {
+		return nil, nil
+	}
+
+	state.Head, state.SeenAt = head, time.Now()
+	if err := state.Save(workDir); err != nil {
+		return nil, err
+	}
+	if last == "" {
+		return nil, nil
+	}
+
+	if git.IsAncestor(workDir, head, last) {
+		move := &Move{From: last, To: head}
+		if exists(workDir, SnapshotName(head)) {
+			move.Snapshot = SnapshotName(head)
+		}
+		return move, nil
+	}
+	if git.IsAncestor(workDir, last, head) && !exists(workDir, SnapshotName(head)) {
+		if _, err := snapshot.Create(workDir, SnapshotName(head)); err != nil {
+			return nil, err
+		}
+		return nil, prune(workDir)
+	}
+	return nil, nil
+}
+
+// exists reports whether a snapshot is stored under name.
+func exists(workDir, name string) bool {
+	_, err := os.Stat(snapshot.GetPath(workDir, name))
+	return err == nil
+}
+
+// prune removes the oldest checkpoint snapshots beyond MaxSnapshots.
+func prune(workDir string) error {
+	all, err := snapshot.List(workDir)
+	if err != nil {
+		return err
+	}
+	// Newest first
+	var checkpoints []snapshot.Info
+	for _, s := range all {
+		if strings.HasPrefix(s.Name, SnapshotPrefix) {
+			checkpoints = append(checkpoints, s)
+		}
+	}
+	if len(checkpoints) <= MaxSnapshots {
+		return nil
+	}
+	for _, s := range checkpoints[MaxSnapshots:] {
+		if err := snapshot.Remove(workDir, s.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Advisory is the warning shown for the move.
+func (m *Move) Advisory() string {
+	msg := fmt.Sprintf("[Harness] HEAD moved back from %s to %s. FIC research, plans, and progress recorded since may describe work that is no longer in the tree.",
+		short(m.From), short(m.To))
+	if m.Snapshot == "" {
+		return msg + "\nNo harness snapshot was recorded at that commit; review the active research and plan before continuing."
+	}
+	return msg + fmt.Sprintf("\nThe harness state recorded at that commit is saved as snapshot %s. "+
+		"Offer to roll back to it with /ultraharness:snapshot (restore %s); the current state is kept as a pre-restore snapshot.",
+		m.Snapshot, m.Snapshot)
+}
@@ -0,0 +1,177 @@
+package checkpoint
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/snapshot"
+)
+
+func newRepo(t *testing.T) (string, func(args ...string) string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	return tmpDir, git
+}
+
+func commit(t *testing.T, workDir string, git func(...string) string, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(workDir, "app.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "app.go")
+	git("commit", "-qm", content)
+	return git("rev-parse", "HEAD")
+}
+
+func writeProgress(t *testing.T, workDir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(workDir, "claude-progress.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func observe(t *testing.T, workDir string) *Move {
+	t.Helper()
+	move, err := Observe(workDir)
+	if err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+	return move
+}
+
+func TestObserve(t *testing.T) {
+	workDir, git := newRepo(t)
+
+	if move := observe(t, workDir); move != nil {
+		t.Errorf("Observe() before the first commit = %+v, want nil", move)
+	}
+
+	commit(t, workDir, git, "one")
+	if move := observe(t, workDir); move != nil {
+		t.Errorf("Observe() on first sight = %+v, want nil", move)
+	}
+	if list, _ := snapshot.List(workDir); len(list) != 0 {
+		t.Errorf("first sight recorded snapshots %v, want none", list)
+	}
+
+	writeProgress(t, workDir, "two done\n")
+	two := commit(t, workDir, git, "two")
+	if move := observe(t, workDir); move != nil {
+		t.Errorf("Observe() after a commit = %+v, want nil", move)
+	}
+	if _, err := os.Stat(snapshot.GetPath(workDir, SnapshotName(two))); err != nil {
+		t.Fatalf("no checkpoint snapshot for the new commit: %v", err)
+	}
+
+	writeProgress(t, workDir, "two done\nthree done\n")
+	commit(t, workDir, git, "three")
+	observe(t, workDir)
+	if move := observe(t, workDir); move != nil {
+		t.Errorf("Observe() with HEAD unchanged = %+v, want nil", move)
+	}
+
+	git("reset", "-q", "--hard", "HEAD~1")
+	move := observe(t, workDir)
+	if move == nil || move.To != two || move.Snapshot != SnapshotName(two) {
+		t.Fatalf("Observe() after a reset = %+v, want a move back to %s with its snapshot", move, two)
+	}
+	if advisory := move.Advisory(); !strings.Contains(advisory, "restore "+SnapshotName(two)) {
+		t.Errorf("Advisory() = %q, want the restore command", advisory)
+	}
+	if move := observe(t, workDir); move != nil {
+		t.Errorf("Observe() repeated the warning: %+v", move)
+	}
+
+	// Restoring the checkpoint restores the state recorded with it, which
+	// has already seen the commit
+	if _, err := snapshot.Restore(workDir, SnapshotName(two)); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "claude-progress.txt")); string(data) != "two done\n" {
+		t.Errorf("restored progress = %q, want the state at the checkpoint", data)
+	}
+	if state, err := Load(workDir); err != nil || state.Head != two {
+		t.Errorf("restored checkpoint state = %+v, %v, want head %s", state, err, two)
+	}
+	if move := observe(t, workDir); move != nil {
+		t.Errorf("Observe() after restoring = %+v, want nil", move)
+	}
+}
+
+func TestObserveWithoutSnapshot(t *testing.T) {
+	workDir, git := newRepo(t)
+	one := commit(t, workDir, git, "one")
+	commit(t, workDir, git, "two")
+	observe(t, workDir)
+
+	git("checkout", "-q", one)
+	move := observe(t, workDir)
+	if move == nil || move.Snapshot != "" {
+		t.Fatalf("Observe() = %+v, want a move back without a snapshot", move)
+	}
+	if advisory := move.Advisory(); !strings.Contains(advisory, "No harness snapshot") {
+		t.Errorf("Advisory() = %q, want a note that there is no snapshot", advisory)
+	}
+}
+
+func TestObserveBranchSwitch(t *testing.T) {
+	workDir, git := newRepo(t)
+	commit(t, workDir, git, "base")
+	git("branch", "-M", "main")
+	git("checkout", "-q", "-b", "topic")
+	commit(t, workDir, git, "topic")
+	observe(t, workDir)
+
+	git("checkout", "-q", "main")
+	main := commit(t, workDir, git, "main")
+	if move := observe(t, workDir); move != nil {
+		t.Errorf("Observe() after switching branches = %+v, want nil", move)
+	}
+	if _, err := os.Stat(snapshot.GetPath(workDir, SnapshotName(main))); err == nil {
+		t.Error("switching branches recorded a checkpoint snapshot")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	workDir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < MaxSnapshots+2; i++ {
+		path, err := snapshot.Create(workDir, fmt.Sprintf("%s%02d", SnapshotPrefix, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(path, at, at)
+	}
+	if _, err := snapshot.Create(workDir, "before-refactor"); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(snapshot.GetPath(workDir, "before-refactor"), old, old)
+
+	if err := prune(workDir); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := snapshot.List(workDir)
+	if len(list) != MaxSnapshots+1 {
+		t.Fatalf("kept %d snapshots, want %d", len(list), MaxSnapshots+1)
+	}
+	for _, s := range list {
+		if s.Name == SnapshotPrefix+"00" || s.Name == SnapshotPrefix+"01" {
+			t.Errorf("kept the oldest checkpoint %s", s.Name)
+		}
+	}
+}
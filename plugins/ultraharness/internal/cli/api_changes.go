@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/contract"
+	"ultraharness/internal/git"
+	"ultraharness/internal/validation"
+)
+
+// APIChanges prints the API contract changes on the branch as a Markdown
+// section for the pull request description.
+//
+// Usage: api_changes [-workdir DIR] [-base REV]
+//
+// The OpenAPI specs and .proto files that differ from the base revision
+// (the branch's upstream, or HEAD if it has none) are diffed semantically,
+// and breaking changes are called out.
+func APIChanges(args []string) error {
+	flags := flag.NewFlagSet("api_changes", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	base := flags.String("base", "", "revision to compare with (default: the upstream branch, or HEAD)")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+	if !git.IsRepo(dir) {
+		return errors.New("not a git repository")
+	}
+
+	rev := *base
+	if rev == "" {
+		rev = git.Upstream(dir)
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	files := contract.Since(dir, rev)
+	if len(files) == 0 {
+		fmt.Printf("No API contract changes since %s.\n", rev)
+		return nil
+	}
+	fmt.Print(contract.Markdown(files))
+	return nil
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/approvals"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/validation"
+)
+
+// Approve grants human approval for files created in review mode
+// and for dependency additions held in strict mode.
+//
+// Usage: approve [-workdir DIR] [-all] [PATH|DEPENDENCY...]
+//
+// Without arguments it lists everything awaiting approval. Approved files
+// may be edited by the agent again; dependencies are named ecosystem/name,
+// e.g. npm/lodash.
+func Approve(args []string) error {
+	flags := flag.NewFlagSet("approve", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	all := flags.Bool("all", false, "approve every pending file and dependency")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	queue, err := approvals.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", approvals.FileName, err)
+	}
+
+	paths := flags.Args()
+	names := flags.Args()
+	if *all {
+		paths = queue.PendingPaths()
+		names = queue.PendingDependencyNames()
+	}
+
+	if len(paths) == 0 && len(names) == 0 {
+		listPendingApprovals(queue)
+		return nil
+	}
+
+	// Arguments naming a queued dependency approve it; the rest are files
+	approved := queue.ApproveDependencies(names...)
+	approved = append(approved, queue.Approve(dir, paths...)...)
+	if len(approved) == 0 {
+		return fmt.Errorf("none of the given files or dependencies are awaiting approval")
+	}
+	if err := queue.Save(dir); err != nil {
+		return err
+	}
+
+	for _, name := range approved {
+		fmt.Printf("Approved %s\n", name)
+		progress.Append(progress.CommandSource(), fmt.Sprintf("APPROVED: %s", name), dir)
+	}
+	if remaining := len(queue.Pending()) + len(queue.PendingDependencies()); remaining > 0 {
+		fmt.Printf("%d item(s) still awaiting approval.\n", remaining)
+	}
+	return nil
+}
+
+func listPendingApprovals(queue *approvals.Queue) {
+	files := queue.Pending()
+	dependencies := queue.PendingDependencies()
+	if len(files) == 0 && len(dependencies) == 0 {
+		fmt.Println("Nothing awaiting approval.")
+		return
+	}
+
+	if len(files) > 0 {
+		fmt.Printf("%d file(s) awaiting approval:\n", len(files))
+		for _, e := range files {
+			fmt.Printf("  %s (created %s)\n", e.Path, e.RequestedAt.Format("2006-01-02 15:04"))
+		}
+	}
+	if len(dependencies) > 0 {
+		fmt.Printf("%d dependency addition(s) awaiting approval:\n", len(dependencies))
+		for _, e := range dependencies {
+			fmt.Printf("  %s: %s (requested %s)\n", e.Name, e.Change, e.RequestedAt.Format("2006-01-02 15:04"))
+		}
+	}
+}
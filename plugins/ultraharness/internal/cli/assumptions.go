@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/validation"
+)
+
+// Assumptions lists the assumptions recorded by the latest research and
+// whether the files they depend on changed since, and confirms the ones
+// that still hold.
+//
+// Usage: assumptions [-workdir DIR] [N...]
+//
+// Without arguments it lists the assumptions. Numbers confirm those
+// assumptions still hold, pinning them to the current content of their
+// files so they are no longer flagged at session start.
+func Assumptions(args []string) error {
+	flags := flag.NewFlagSet("assumptions", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	var numbers []int
+	for _, arg := range flags.Args() {
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			return fmt.Errorf("invalid assumption %q (want a number such as 2)", arg)
+		}
+		numbers = append(numbers, n)
+	}
+
+	a, err := artifacts.GetLatestArtifact(dir, artifacts.ArtifactResearch)
+	if err != nil {
+		return err
+	}
+	research, ok := a.(*artifacts.Research)
+	if !ok || len(research.Assumptions) == 0 {
+		if len(numbers) > 0 {
+			return errors.New("the latest research records no assumptions")
+		}
+		fmt.Println("No assumptions recorded.")
+		return nil
+	}
+
+	if len(numbers) == 0 {
+		listAssumptions(dir, research)
+		return nil
+	}
+
+	for _, n := range numbers {
+		if n < 1 || n > len(research.Assumptions) {
+			return fmt.Errorf("no assumption %d (the research records %d)", n, len(research.Assumptions))
+		}
+		assumption := research.Assumptions[n-1]
+		if assumption.Path() == "" {
+			return fmt.Errorf("assumption %d depends on a fact, not a file, so there is nothing to confirm", n)
+		}
+		if err := research.RepinAssumption(dir, n-1); err != nil {
+			return fmt.Errorf("cannot confirm assumption %d: %w", n, err)
+		}
+	}
+	research.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	backend, err := storage.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = backend.Update(func(tx storage.Tx) error {
+		return artifacts.PutLatest(tx, backend, artifacts.ArtifactResearch, research)
+	})
+	if err != nil {
+		return err
+	}
+	for _, n := range numbers {
+		fmt.Printf("Confirmed %d: %s\n", n, research.Assumptions[n-1].Statement)
+	}
+	return nil
+}
+
+func listAssumptions(workDir string, research *artifacts.Research) {
+	fmt.Printf("%d assumption(s) in the research for %q:\n", len(research.Assumptions), research.FeatureOrTask)
+	invalidated := 0
+	for i, a := range research.Assumptions {
+		state := artifacts.CheckAssumption(workDir, a)
+		if state.Invalidated() {
+			invalidated++
+		}
+		dependsOn := a.File
+		if dependsOn == "" {
+			dependsOn = a.Fact
+		}
+		if dependsOn == "" {
+			dependsOn = "nothing recorded"
+		}
+		fmt.Printf("  %d. [%s] %s\n     depends on: %s\n", i+1, strings.ToUpper(string(state)), a.Statement, dependsOn)
+	}
+	if invalidated > 0 {
+		fmt.Printf("%d may no longer hold. Re-check them, then confirm the ones that do with: assumptions N...\n", invalidated)
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"ultraharness/internal/baseline"
+	"ultraharness/internal/config"
+	"ultraharness/internal/testrunner"
+	"ultraharness/internal/validation"
+)
+
+// Baseline runs the baseline tests: those affected by the changes since
+// the last green baseline, or the whole suite.
+//
+// Usage: baseline [-workdir DIR] [-full]
+//
+// The commit of the last baseline that passed is kept in
+// .claude/baseline.json. Changed Go files select their packages and the
+// packages importing them; changed JavaScript, TypeScript, and Python files
+// select the test files that import them. The whole suite runs instead with
+// -full, when no green baseline is recorded, when the last full run is
+// older than baseline_full_run_hours, or when a change cannot be mapped to
+// tests. ErrFailed is returned if the tests fail.
+func Baseline(args []string) error {
+	flags := flag.NewFlagSet("baseline", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	full := flags.Bool("full", false, "run every test, not only the affected ones")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	run := baseline.Run(dir, cfg, *full)
+	fmt.Printf("Baseline: %s\n", run.Describe())
+	if run.Mode == baseline.ModeIncremental {
+		for _, t := range run.Targets {
+			fmt.Printf("  %s\n", t)
+		}
+	}
+	if run.Mode == baseline.ModeUnchanged {
+		return nil
+	}
+
+	summary := run.Summary
+	switch summary.Result {
+	case testrunner.NotRun:
+		fmt.Println("No test command detected.")
+		return nil
+	case testrunner.Passed:
+		fmt.Printf("PASSED: %s\n", testrunner.GetSummaryString(summary))
+		return nil
+	}
+	fmt.Printf("FAILED: %s\n\n%s\n", testrunner.GetSummaryString(summary), strings.TrimRight(summary.RawOutput, "\n"))
+	return ErrFailed
+}
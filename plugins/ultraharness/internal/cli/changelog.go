@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"ultraharness/internal/changelog"
+	"ultraharness/internal/validation"
+)
+
+// Changelog shows staged changelog entries or rolls them into a
+// release section.
+//
+// Usage: changelog [-workdir DIR] [-release VERSION] [-date YYYY-MM-DD]
+//
+// Without -release, prints the Unreleased section of CHANGELOG.md. With
+// -release, moves the Unreleased entries into a "## [VERSION] - DATE"
+// section and leaves an empty Unreleased section for future work.
+func Changelog(args []string) error {
+	flags := flag.NewFlagSet("changelog", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	version := flags.String("release", "", "version to release the Unreleased entries as")
+	dateStr := flags.String("date", "", "release date, YYYY-MM-DD (default: today)")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	if *version == "" {
+		unreleased, err := changelog.Unreleased(dir)
+		if err != nil {
+			return err
+		}
+		if unreleased == "" {
+			fmt.Println("No unreleased changes.")
+			return nil
+		}
+		fmt.Println(unreleased)
+		return nil
+	}
+
+	var date time.Time
+	if *dateStr != "" {
+		d, err := time.Parse("2006-01-02", *dateStr)
+		if err != nil {
+			return fmt.Errorf("invalid -date %q: %w", *dateStr, err)
+		}
+		date = d
+	}
+
+	if err := changelog.Release(dir, *version, date); err != nil {
+		if errors.Is(err, changelog.ErrNothingToRelease) {
+			return fmt.Errorf("nothing to release: the Unreleased section is empty")
+		}
+		return err
+	}
+
+	fmt.Printf("Released %s in %s\n", *version, changelog.ChangelogFile)
+	return nil
+}
@@ -0,0 +1,92 @@
+// Package cli implements the harness commands behind the slash commands:
+// report, approve, configure, and the rest. Each command takes its
+// arguments without the command name and prints to stdout; the ultraharness
+// binary and the per-command wrapper binaries both run them through Main.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/testrunner"
+)
+
+// ErrFailed reports a command failure the command has already described,
+// such as a failing verification; Main exits 1 without printing it.
+var ErrFailed = errors.New("command failed")
+
+// Command is a harness command.
+type Command struct {
+	// Name is the command's name with underscores, as the wrapper binaries
+	// are named, e.g. "verify_feature"
+	Name string
+	// Summary is a one-line description for the command list
+	Summary string
+	Run     func(args []string) error
+}
+
+// Commands lists every command in alphabetical order.
+var Commands = []Command{
+	{"api_changes", "Show the branch's API contract changes for the PR description", APIChanges},
+	{"approve", "Approve files and dependencies awaiting human approval", Approve},
+	{"assumptions", "List research assumptions and confirm the ones that still hold", Assumptions},
+	{"baseline", "Run the tests affected since the last green baseline", Baseline},
+	{"changelog", "Show staged changelog entries or roll them into a release", Changelog},
+	{"configure", "Show or change harness settings", Configure},
+	{"decision", "Record, list, or export architecture decisions", Decision},
+	{"handoff", "Export or import the current task state", Handoff},
+	{"jira", "Sync feature checklist entries with their Jira issues", Jira},
+	{"knowledge", "Search the project knowledge base", Knowledge},
+	{"linear", "Bind the task to a Linear issue that follows the FIC phase", Linear},
+	{"mcp", "Serve harness state and actions over the Model Context Protocol", MCP},
+	{"new_plugin", "Scaffold a new plugin in the marketplace", NewPlugin},
+	{"plan_done", "Mark the plan validated so implementation can begin", PlanDone},
+	{"prepush", "Check the branch is ready to push: tests, build, merge conflicts, features", Prepush},
+	{"repair", "Diagnose and fix inconsistent harness state", Repair},
+	{"replay", "Re-run recorded hook calls against a scratch project", Replay},
+	{"report", "Summarize the current session", Report},
+	{"research_done", "Mark research complete so planning can begin", ResearchDone},
+	{"research_queue", "List or resolve deferred research questions", ResearchQueue},
+	{"restore", "Roll the harness state back to a snapshot", Restore},
+	{"scan_todos", "List untracked TODO comments", ScanTodos},
+	{"search", "Search artifacts, knowledge, progress, and preserved context", Search},
+	{"snapshot", "Save the harness state to a named snapshot", Snapshot},
+	{"stats", "Summarize sessions across all projects", Stats},
+	{"test_affected", "Test the Go packages affected by the changes, in parallel", TestAffected},
+	{"validate_plugin", "Check plugin and marketplace manifests, hooks, commands, and agents", ValidatePlugin},
+	{"verify_feature", "Run a feature's acceptance criteria", VerifyFeature},
+}
+
+// Lookup returns the command called name, accepting dashes for
+// underscores ("verify-feature").
+func Lookup(name string) (Command, bool) {
+	name = strings.ReplaceAll(name, "-", "_")
+	i := sort.Search(len(Commands), func(i int) bool { return Commands[i].Name >= name })
+	if i < len(Commands) && Commands[i].Name == name {
+		return Commands[i], true
+	}
+	return Command{}, false
+}
+
+// Main runs cmd with args and exits: 0 on success, or 1 after printing
+// the error to stderr. Notices raised while it ran, such as a corrupt state
+// file being reset, go to stderr first.
+func Main(cmd Command, args []string) {
+	// Long test runs report that they are still going
+	testrunner.Heartbeats = os.Stderr
+	err := cmd.Run(args)
+	for _, notice := range protocol.TakeNotices() {
+		fmt.Fprintln(os.Stderr, notice)
+	}
+	if err == nil {
+		os.Exit(0)
+	}
+	if !errors.Is(err, ErrFailed) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.Name, err)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/progress"
+)
+
+func TestCommandsSorted(t *testing.T) {
+	if !sort.SliceIsSorted(Commands, func(i, j int) bool { return Commands[i].Name < Commands[j].Name }) {
+		t.Error("Commands must be sorted by name for Lookup")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report", "report"},
+		{"verify_feature", "verify_feature"},
+		{"verify-feature", "verify_feature"},
+		{"research-queue", "research_queue"},
+		{"approve", "approve"},
+		{"stats", "stats"},
+		{"doctor", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		cmd, ok := Lookup(tt.name)
+		if ok != (tt.want != "") || cmd.Name != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.name, cmd.Name, ok, tt.want)
+		}
+	}
+}
+
+func TestProgressSummary(t *testing.T) {
+	dir := t.TempDir()
+	old := "[2020-01-01 09:00:00] [agent] Finished an older task\n"
+	os.WriteFile(progress.GetProgressPath(dir), []byte(old), 0600)
+	start := time.Now()
+	progress.Append(progress.SourceAgent, "Implemented the parser", dir)
+	progress.Append(progress.SourceAuto, "AUTO: Modified parse.go (source file edited)", dir)
+	progress.Append(progress.SourceAuto, "AUTO: Ran 'go test' (tests run)", dir)
+
+	got := strings.Join(progressSummary(dir, start, "", nil), "\n")
+	for _, want := range []string{"Agent claimed: 1 | Hooks observed: 2 | Human: 0 | Unattributed: 0", "Implemented the parser", "AUTO: Ran 'go test'"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("progressSummary() = %q, want %q", got, want)
+		}
+	}
+	if strings.Contains(got, "older task") {
+		t.Errorf("progressSummary() = %q, want only this session's entries", got)
+	}
+
+	got = strings.Join(progressSummary(dir, start, progress.SourceAgent, nil), "\n")
+	if strings.Contains(got, "AUTO:") || !strings.Contains(got, "Agent claimed: 1") {
+		t.Errorf("progressSummary(agent) = %q, want only the agent's entries", got)
+	}
+
+	progress.Append(progress.SourceHuman, "Rotate the signing key #auth", dir)
+	got = strings.Join(progressSummary(dir, start, "", []string{"auth"}), "\n")
+	if !strings.Contains(got, "Agent claimed: 0 | Hooks observed: 0 | Human: 1") || !strings.Contains(got, "signing key") {
+		t.Errorf("progressSummary(#auth) = %q, want only the tagged entry", got)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, arg := range []string{"/usr/local/bin/ultraharness", "/Users/o'brien/bin/ultra harness", `/tmp/$HOME/a"b\c` + "`x`"} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(arg)).Output()
+		if err != nil {
+			t.Fatalf("sh -c with %s: %v", shellQuote(arg), err)
+		}
+		if string(out) != arg {
+			t.Errorf("sh read %s as %q, want %q", shellQuote(arg), out, arg)
+		}
+	}
+}
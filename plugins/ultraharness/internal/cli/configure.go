@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/validation"
+)
+
+// featureToggles maps the names accepted by -enable and -disable to their
+// settings.
+func featureToggles(cfg *config.Config) map[string]*bool {
+	return map[string]*bool{
+		"fic":                  &cfg.FICEnabled,
+		"context-tracking":     &cfg.FICContextTracking,
+		"auto-log":             &cfg.AutoProgressLogging,
+		"checkpoint":           &cfg.AutoCheckpointSuggestions,
+		"checkpoint-snapshots": &cfg.CheckpointSnapshots,
+		"feature-enforcement":  &cfg.FeatureEnforcement,
+		"init-script":          &cfg.InitScriptExecution,
+		"baseline-tests":       &cfg.BaselineTestsOnStartup,
+		"incremental-baseline": &cfg.BaselineIncremental,
+		"todo-scan":            &cfg.TodoScanOnStartup,
+		"build-verification":   &cfg.BuildVerification,
+		"changelog":            &cfg.ChangelogStaging,
+		"write-guard":          &cfg.WriteGuard,
+		"dependency-gate":      &cfg.DependencyGate,
+		"infra-gate":           &cfg.InfraGate,
+		"migration-gate":       &cfg.MigrationGate,
+		"contract-check":       &cfg.ContractCheck,
+		"syntax-check":         &cfg.SyntaxCheck,
+		"auto-format":          &cfg.AutoFormat,
+		"import-check":         &cfg.ImportCheck,
+		"churn-advisory":       &cfg.ChurnAdvisory,
+		"risk-scoring":         &cfg.RiskScoring,
+		"test-impact":          &cfg.TestImpact,
+		"loop-watchdog":        &cfg.LoopWatchdog,
+		"blocker-tracking":     &cfg.BlockerTracking,
+		"drift-detection":      &cfg.DriftDetection,
+		"retrospectives":       &cfg.Retrospectives,
+		"daily-log":            &cfg.DailyLog,
+		"knowledge-base":       &cfg.KnowledgeBase,
+		"codebase-map":         &cfg.CodebaseMap,
+		"project-tree":         &cfg.ProjectTree,
+	}
+}
+
+// Configure tunes the harness settings in .claude/claude-harness.json
+// without hand-editing JSON.
+//
+// Usage: configure [-workdir DIR] [-strictness LEVEL] [-verbosity LEVEL] [-headless MODE]
+//
+//	[-locale LOCALE]
+//	[-auto-compact-threshold N] [-compaction-tool-threshold N]
+//	[-research-confidence-threshold N] [-max-open-questions N]
+//	[-checkpoint-interval MINUTES] [-enable FEATURE,...] [-disable FEATURE,...]
+//
+// Values are validated with the config setters; an invalid value is an
+// error and nothing is written. Without flags it prints the current
+// settings.
+func Configure(args []string) error {
+	flags := flag.NewFlagSet("configure", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	strictness := flags.String("strictness", "", "relaxed, standard, strict, or review")
+	verbosity := flags.String("verbosity", "", "quiet, normal, or verbose")
+	headless := flags.String("headless", "", "auto (on in CI), on, or off")
+	locale := flags.String("locale", "", "language of injected guidance, e.g. en or es")
+	autoCompact := flags.Float64("auto-compact-threshold", 0, "context utilization (0-1] that triggers compaction")
+	compactionTools := flags.Int("compaction-tool-threshold", 0, "tool calls that trigger compaction")
+	confidence := flags.Float64("research-confidence-threshold", 0, "research confidence [0-1] required to leave the research phase")
+	openQuestions := flags.Int("max-open-questions", 0, "open questions allowed when leaving the research phase")
+	checkpointInterval := flags.Int("checkpoint-interval", 0, "minutes between checkpoint suggestions")
+	enable := flags.String("enable", "", "comma-separated features to turn on")
+	disable := flags.String("disable", "", "comma-separated features to turn off")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	delete(set, "workdir")
+	if len(set) == 0 {
+		printSettings(cfg)
+		return nil
+	}
+
+	// The setters ignore or replace invalid values, so a value that did not
+	// stick was invalid
+	if set["strictness"] {
+		if cfg.SetStrictness(*strictness); cfg.Strictness != *strictness {
+			return fmt.Errorf("invalid strictness %q (want relaxed, standard, strict, or review)", *strictness)
+		}
+	}
+	if set["verbosity"] {
+		if cfg.SetOutputVerbosity(*verbosity); cfg.OutputVerbosity != *verbosity {
+			return fmt.Errorf("invalid verbosity %q (want quiet, normal, or verbose)", *verbosity)
+		}
+	}
+	if set["headless"] {
+		if cfg.SetHeadless(*headless); cfg.Headless != *headless {
+			return fmt.Errorf("invalid headless mode %q (want auto, on, or off)", *headless)
+		}
+	}
+	if set["locale"] {
+		if cfg.SetLocale(*locale); cfg.Locale != *locale {
+			return fmt.Errorf("invalid locale %q (want a language tag such as en, es, or pt-BR)", *locale)
+		}
+	}
+	if set["auto-compact-threshold"] {
+		if cfg.SetAutoCompactThreshold(*autoCompact); cfg.FICConfig.AutoCompactThreshold != *autoCompact {
+			return fmt.Errorf("invalid auto-compact threshold %v (want a fraction in (0, 1])", *autoCompact)
+		}
+	}
+	if set["compaction-tool-threshold"] {
+		if cfg.SetCompactionToolThreshold(*compactionTools); cfg.FICConfig.CompactionToolThreshold != *compactionTools {
+			return fmt.Errorf("invalid compaction tool threshold %d (want a positive count)", *compactionTools)
+		}
+	}
+	if set["research-confidence-threshold"] {
+		if cfg.SetResearchConfidenceThreshold(*confidence); cfg.FICConfig.ResearchConfidenceThreshold != *confidence {
+			return fmt.Errorf("invalid research confidence threshold %v (want a fraction in [0, 1])", *confidence)
+		}
+	}
+	if set["max-open-questions"] {
+		if cfg.SetMaxOpenQuestions(*openQuestions); cfg.FICConfig.MaxOpenQuestions != *openQuestions {
+			return fmt.Errorf("invalid max open questions %d (want 0 or more)", *openQuestions)
+		}
+	}
+	if set["checkpoint-interval"] {
+		if *checkpointInterval <= 0 {
+			return fmt.Errorf("invalid checkpoint interval %d (want a positive number of minutes)", *checkpointInterval)
+		}
+		cfg.CheckpointIntervalMinutes = *checkpointInterval
+	}
+
+	toggles := featureToggles(cfg)
+	for _, change := range []struct {
+		names string
+		value bool
+	}{{*enable, true}, {*disable, false}} {
+		for _, name := range strings.Split(change.names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			setting, ok := toggles[name]
+			if !ok {
+				return fmt.Errorf("unknown feature %q (want one of %s)", name, strings.Join(featureNames(toggles), ", "))
+			}
+			*setting = change.value
+		}
+	}
+
+	if err := cfg.Save(dir); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf("Updated .claude/%s\n\n", config.ConfigFileName)
+	printSettings(cfg)
+	return nil
+}
+
+func featureNames(toggles map[string]*bool) []string {
+	names := make([]string, 0, len(toggles))
+	for name := range toggles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printSettings(cfg *config.Config) {
+	fmt.Printf("Strictness: %s\n", cfg.Strictness)
+	fmt.Printf("Output verbosity: %s\n", cfg.GetOutputVerbosity())
+	fmt.Printf("Headless: %s\n", cfg.GetHeadless())
+	fmt.Printf("Locale: %s (bundled: %s)\n", cfg.GetLocale(), strings.Join(templates.Locales(), ", "))
+	fmt.Printf("Auto-compact threshold: %.2f\n", cfg.GetAutoCompactThreshold())
+	fmt.Printf("Compaction tool threshold: %d\n", cfg.GetCompactionToolThreshold())
+	fmt.Printf("Research confidence threshold: %.2f\n", cfg.GetResearchConfidenceThreshold())
+	fmt.Printf("Max open questions: %d\n", cfg.GetMaxOpenQuestions())
+	fmt.Printf("Checkpoint interval: %d minutes\n", cfg.CheckpointIntervalMinutes)
+
+	toggles := featureToggles(cfg)
+	var enabled, disabled []string
+	for _, name := range featureNames(toggles) {
+		if *toggles[name] {
+			enabled = append(enabled, name)
+		} else {
+			disabled = append(disabled, name)
+		}
+	}
+	if len(enabled) > 0 {
+		fmt.Printf("Enabled: %s\n", strings.Join(enabled, ", "))
+	}
+	if len(disabled) > 0 {
+		fmt.Printf("Disabled: %s\n", strings.Join(disabled, ", "))
+	}
+}
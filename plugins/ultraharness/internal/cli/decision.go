@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/decisions"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/tags"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/text"
+	"ultraharness/internal/validation"
+)
+
+// Decision records a decision in the project's decision log, or lists,
+// shows, or exports the recorded ones.
+//
+// Usage: decision [-workdir DIR] [-show ID]
+//
+//	decision [-workdir DIR] -decision TEXT [-title TITLE] [-context TEXT] [-options "A; B"] [-consequences TEXT] [-tag LIST]
+//	decision [-workdir DIR] -export [-dir DIR]
+//
+// Without flags it lists the recorded decisions. -show prints one as a
+// markdown record; -export renders every decision into DIR (default
+// docs/adr) as NNNN-title.md files, rewriting those already there.
+// Recording a decision also notes it in the progress log.
+func Decision(args []string) error {
+	flags := flag.NewFlagSet("decision", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	decision := flags.String("decision", "", "what was decided")
+	title := flags.String("title", "", "short title (default: the decision's first sentence)")
+	context := flags.String("context", "", "the situation that called for a decision")
+	options := flags.String("options", "", `alternatives considered, separated by ";"`)
+	consequences := flags.String("consequences", "", "what follows from the decision")
+	tagList := flags.String("tag", "", "comma-separated tags, e.g. auth,perf")
+	show := flags.String("show", "", "print the decision with this ID as a markdown record")
+	export := flags.Bool("export", false, "render every decision as a markdown record")
+	exportDir := flags.String("dir", decisions.DefaultDir, "with -export, directory for the records")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errors.New(`usage: decision -decision TEXT [-title TITLE] [-context TEXT] [-options "A; B"] [-consequences TEXT] [-tag LIST]`)
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	templates.SetLocale(cfg.GetLocale())
+
+	log, err := decisions.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", decisions.FileName, err)
+	}
+
+	switch {
+	case *decision != "":
+		d, added := log.Add(decisions.Decision{
+			Title:        *title,
+			Context:      strings.TrimSpace(*context),
+			Options:      decisions.SplitOptions(*options),
+			Decision:     *decision,
+			Consequences: strings.TrimSpace(*consequences),
+			Source:       string(progress.CommandSource()),
+			Tags:         tags.Split(*tagList),
+		})
+		if d == nil {
+			return errors.New("the decision is empty")
+		}
+		if !added {
+			fmt.Printf("Already recorded as %s.\n", d)
+			return nil
+		}
+		if err := log.Save(dir); err != nil {
+			return err
+		}
+		progress.Append(progress.CommandSource(), "DECISION: "+d.String(), dir)
+		fmt.Printf("Recorded %s.\n", d)
+		return nil
+
+	case *show != "":
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(*show), "ADR-"))
+		if err != nil {
+			return fmt.Errorf("invalid decision %q (want a number such as 3)", *show)
+		}
+		d := log.Find(id)
+		if d == nil {
+			return fmt.Errorf("no decision ADR-%d", id)
+		}
+		fmt.Println(decisions.Render(dir, *d))
+		return nil
+
+	case *export:
+		if len(log.Decisions) == 0 {
+			fmt.Println("No decisions recorded.")
+			return nil
+		}
+		paths, err := decisions.Export(dir, *exportDir, log)
+		fmt.Printf("Wrote %d record(s) to %s.\n", len(paths), *exportDir)
+		return err
+	}
+
+	if len(log.Decisions) == 0 {
+		fmt.Println("No decisions recorded. Record one with: decision -decision TEXT")
+		return nil
+	}
+	fmt.Printf("%d decision(s):\n", len(log.Decisions))
+	for _, d := range log.Decisions {
+		fmt.Printf("  %s (%s, %s)\n    %s\n", d, d.Source, d.RecordedAt.Format("2006-01-02"), text.Truncate(d.Decision, 200))
+	}
+	return nil
+}
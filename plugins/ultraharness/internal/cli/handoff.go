@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/handoff"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/validation"
+)
+
+// Handoff exports the current task state for a teammate, or
+// imports a bundle exported by one.
+//
+// Usage: handoff [-workdir DIR] [-o FILE | -import FILE]
+//
+// The export is a Markdown file with the phase, preserved context, active
+// plan, open questions, hot files, and progress tail; it defaults to
+// .claude/handoffs/handoff-<timestamp>.md. Import saves the current state
+// as a "pre-handoff-<timestamp>" snapshot before installing the bundle.
+func Handoff(args []string) error {
+	flags := flag.NewFlagSet("handoff", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	output := flags.String("o", "", "file to write the bundle to (default: .claude/handoffs/handoff-<timestamp>.md)")
+	importPath := flags.String("import", "", "install the bundle in FILE as the current task state")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := storage.Configure(cfg); err != nil {
+		return err
+	}
+
+	if *importPath != "" {
+		content, err := os.ReadFile(*importPath)
+		if err != nil {
+			return err
+		}
+		bundle, err := handoff.Parse(content)
+		if err != nil {
+			if errors.Is(err, handoff.ErrNotBundle) {
+				return fmt.Errorf("%s is not a handoff bundle", *importPath)
+			}
+			return err
+		}
+
+		backup, err := handoff.Import(dir, bundle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported handoff from %s (%s). Previous state saved as snapshot %s.\n",
+			bundle.CreatedBy, bundle.Phase, backup)
+		return nil
+	}
+
+	path, err := handoff.Write(dir, *output)
+	if err != nil {
+		return err
+	}
+	progress.Append(progress.CommandSource(), fmt.Sprintf("HANDOFF EXPORTED: %s", path), dir)
+	fmt.Printf("Wrote handoff bundle to %s\n", path)
+	return nil
+}
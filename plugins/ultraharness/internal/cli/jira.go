@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/jira"
+	"ultraharness/internal/validation"
+)
+
+// Jira syncs the feature checklist with the Jira issues its features map
+// to.
+//
+// Usage: jira [-workdir DIR] [-pull] [-push] [-dry-run]
+//
+// -pull copies the mapped issue fields and statuses into
+// claude-features.json; -push posts the progress milestones logged since
+// the last push as comments on the issues they mention. Without either
+// flag, both run. -dry-run lists the changes and comments without making
+// them.
+func Jira(args []string) error {
+	flags := flag.NewFlagSet("jira", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	pull := flags.Bool("pull", false, "update features from their issues")
+	push := flags.Bool("push", false, "post progress milestones as issue comments")
+	dryRun := flags.Bool("dry-run", false, "list changes and comments without making them")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+	if !*pull && !*push {
+		*pull, *push = true, true
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return err
+	}
+	syncer, err := jira.New(dir, cfg)
+	if err != nil {
+		return err
+	}
+	if syncer == nil {
+		return errors.New(`jira sync is disabled; set "jira": {"enabled": true} in .claude/claude-harness.json`)
+	}
+
+	if *pull {
+		changes, err := syncer.Pull(*dryRun)
+		if err != nil {
+			return fmt.Errorf("pull failed: %w", err)
+		}
+		if len(changes) == 0 {
+			fmt.Println("Features are up to date with Jira.")
+		} else {
+			verb := "Updated"
+			if *dryRun {
+				verb = "Would update"
+			}
+			fmt.Printf("%s %d feature field(s):\n", verb, len(changes))
+			for _, c := range changes {
+				fmt.Println("  " + c.String())
+			}
+		}
+	}
+
+	if *push {
+		comments, err := syncer.Push(*dryRun)
+		if err != nil {
+			return fmt.Errorf("push failed after %d comment(s): %w", len(comments), err)
+		}
+		if len(comments) == 0 {
+			fmt.Println("No new milestones to post.")
+		} else {
+			verb := "Posted"
+			if *dryRun {
+				verb = "Would post"
+			}
+			fmt.Printf("%s %d milestone comment(s):\n", verb, len(comments))
+			for _, c := range comments {
+				fmt.Printf("  %s: %s\n", c.Issue, c.Entry.Message)
+			}
+		}
+	}
+	return nil
+}
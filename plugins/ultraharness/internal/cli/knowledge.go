@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/tags"
+	"ultraharness/internal/validation"
+)
+
+// Knowledge searches the project knowledge base of discoveries
+// accumulated across tasks.
+//
+// Usage: knowledge [-workdir DIR] [-limit N] [-tag LIST] [QUERY...]
+//
+// With a query it lists the facts sharing the most words with it; without
+// one it lists the most recent facts. -tag considers only the facts
+// carrying any of a comma-separated list of tags.
+func Knowledge(args []string) error {
+	flags := flag.NewFlagSet("knowledge", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	limit := flags.Int("limit", 10, "maximum number of facts to list")
+	tagList := flags.String("tag", "", "only list facts carrying one of these comma-separated tags")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("invalid limit %d (want a positive count)", *limit)
+	}
+
+	base, err := knowledge.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", knowledge.FileName, err)
+	}
+	if len(base.Facts) == 0 {
+		fmt.Println("The knowledge base is empty. Discoveries are added at compaction and when research subagents finish.")
+		return nil
+	}
+
+	if labels := tags.Split(*tagList); len(labels) > 0 {
+		kept := base.Facts[:0]
+		for _, f := range base.Facts {
+			if tags.Any(f.Tags, labels) {
+				kept = append(kept, f)
+			}
+		}
+		base.Facts = kept
+		if len(base.Facts) == 0 {
+			fmt.Printf("No facts are tagged #%s.\n", strings.Join(labels, ", #"))
+			return nil
+		}
+	}
+
+	query := strings.Join(flags.Args(), " ")
+	var facts []knowledge.Fact
+	if query == "" {
+		facts = base.Recent(*limit)
+		fmt.Printf("%d most recent of %d fact(s):\n", len(facts), len(base.Facts))
+	} else {
+		facts = base.Search(query, *limit)
+		if len(facts) == 0 {
+			fmt.Printf("No facts match %q.\n", query)
+			return nil
+		}
+		fmt.Printf("%d fact(s) matching %q:\n", len(facts), query)
+	}
+
+	for _, f := range facts {
+		marker := ""
+		if f.Critical {
+			marker = "[CRITICAL] "
+		}
+		labels := ""
+		if len(f.Tags) > 0 {
+			labels = ", #" + strings.Join(f.Tags, " #")
+		}
+		fmt.Printf("  %s%s\n    %s, %s%s\n", marker, f.Text, f.Source, f.AddedAt.Format("2006-01-02"), labels)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/linear"
+	"ultraharness/internal/validation"
+)
+
+// Linear binds the current task to a Linear issue, whose workflow state
+// then follows the FIC phase.
+//
+// Usage: linear [-workdir DIR] [-bind ISSUE | -unbind]
+//
+// -bind ENG-123 records the binding, adds the issue's description to the
+// research artifact as initial context, and moves the issue to the state
+// mapped to the current phase. Without flags, shows the bound issue.
+func Linear(args []string) error {
+	flags := flag.NewFlagSet("linear", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	bind := flags.String("bind", "", "issue identifier to bind the task to, e.g. ENG-123")
+	unbind := flags.Bool("unbind", false, "remove the task's issue binding")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	if *unbind {
+		binding, err := linear.Unbind(dir)
+		if errors.Is(err, linear.ErrNotBound) {
+			fmt.Println("No Linear issue is bound to the task.")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Unbound %s. Its state is no longer updated.\n", binding.Issue)
+		return nil
+	}
+
+	if *bind == "" {
+		binding, err := linear.LoadBinding(dir)
+		if errors.Is(err, linear.ErrNotBound) {
+			fmt.Println("No Linear issue is bound to the task. Bind one with: linear -bind ENG-123")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", binding.Issue, binding.Title)
+		fmt.Printf("  State: %s\n", binding.State)
+		if binding.URL != "" {
+			fmt.Printf("  URL: %s\n", binding.URL)
+		}
+		fmt.Printf("  Bound: %s\n", binding.BoundAt.Local().Format("2006-01-02 15:04"))
+		return nil
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return err
+	}
+	linker, err := linear.New(dir, cfg)
+	if err != nil {
+		return err
+	}
+	if linker == nil {
+		return errors.New(`linear sync is disabled; set "linear": {"enabled": true} in .claude/claude-harness.json`)
+	}
+
+	binding, move, err := linker.Bind(*bind)
+	if binding != nil {
+		fmt.Printf("Bound the task to %s: %s\n", binding.Issue, binding.Title)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("The issue description is in the research artifact as initial context.")
+	if move != nil {
+		fmt.Println(move.String())
+	}
+	return nil
+}
+
+// followLinear moves the task's Linear issue to the state mapped to
+// phase and reports the move. A failure is reported without failing the
+// command: the phase has advanced either way.
+func followLinear(dir, phase string) {
+	move, err := linear.Follow(dir, phase)
+	if err != nil {
+		fmt.Printf("WARNING: Could not update the Linear issue: %v\n", err)
+		return
+	}
+	if move != nil {
+		fmt.Println(move.String())
+	}
+}
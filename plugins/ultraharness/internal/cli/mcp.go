@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"ultraharness/internal/mcp"
+	"ultraharness/internal/validation"
+)
+
+// MCP serves the harness state to Claude over the Model Context Protocol
+// on stdin and stdout, until stdin closes. The plugin's .mcp.json starts
+// it with each session.
+//
+// Usage: mcp [-workdir DIR]
+func MCP(args []string) error {
+	flags := flag.NewFlagSet("mcp", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	server := &mcp.Server{WorkDir: dir}
+	return server.Serve(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ultraharness/internal/plugincheck"
+	"ultraharness/internal/scaffold"
+	"ultraharness/internal/validation"
+)
+
+// NewPlugin scaffolds a new plugin in the marketplace.
+//
+// Usage: new_plugin [-description TEXT] [-author NAME] [-hooks EVENTS] [-dir DIR] NAME
+//
+// It must run inside the marketplace, whose plugins/sdk directory holds the
+// hooks SDK the plugin's go.mod points at. The plugin is written to
+// DIR/NAME, where DIR defaults to the marketplace's plugins directory.
+// EVENTS is a comma-separated list of hook
+// events, e.g. "PreToolUse,Stop" (default: PreToolUse, PostToolUse, and
+// SessionStart). The new plugin is checked with plugincheck, and the
+// marketplace entry to add is printed.
+func NewPlugin(args []string) error {
+	flags := flag.NewFlagSet("new_plugin", flag.ExitOnError)
+	description := flags.String("description", "", "what the plugin does (required)")
+	author := flags.String("author", "", "author name for plugin.json")
+	hooks := flags.String("hooks", "", "comma-separated hook events (default: "+strings.Join(scaffold.DefaultEvents, ",")+")")
+	parent := flags.String("dir", "", "directory to create the plugin in (default: the marketplace's plugins directory)")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: new_plugin [-description TEXT] [-author NAME] [-hooks EVENTS] [-dir DIR] NAME")
+	}
+	workDir := validation.GetWorkDir()
+	if err := validation.ValidateWorkDir(workDir); err != nil {
+		return err
+	}
+	root := marketplaceRoot(workDir)
+	if root == "" {
+		return fmt.Errorf("%s is not inside a marketplace (no %s above it)", workDir, plugincheck.MarketplaceFile)
+	}
+	sdkDir := filepath.Join(root, "plugins", "sdk")
+	if _, err := os.Stat(filepath.Join(sdkDir, "go.mod")); err != nil {
+		return fmt.Errorf("the marketplace has no hooks SDK at %s", sdkDir)
+	}
+
+	dir := *parent
+	switch {
+	case dir == "":
+		dir = filepath.Join(root, "plugins")
+	case !filepath.IsAbs(dir):
+		dir = filepath.Join(workDir, dir)
+	}
+	dir = filepath.Join(dir, flags.Arg(0))
+
+	opts := scaffold.Options{Name: flags.Arg(0), Description: *description, Author: *author, SDKPath: sdkDir}
+	if rel, err := filepath.Rel(dir, sdkDir); err == nil {
+		opts.SDKPath = filepath.ToSlash(rel)
+	}
+	for _, e := range strings.Split(*hooks, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			opts.Events = append(opts.Events, e)
+		}
+	}
+
+	paths, err := scaffold.Generate(dir, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %s:\n", dir)
+	for _, p := range paths {
+		fmt.Printf("  %s\n", filepath.ToSlash(p))
+	}
+
+	report := plugincheck.Plugin(dir)
+	for _, p := range report.Problems {
+		fmt.Println(p)
+	}
+
+	fmt.Println()
+	fmt.Println("Next steps:")
+	fmt.Printf("  cd %s && make && make test\n", dir)
+	if source, err := filepath.Rel(root, dir); err == nil && !strings.HasPrefix(source, "..") {
+		fmt.Printf("  Add to the plugins in %s:\n", filepath.Join(root, plugincheck.MarketplaceFile))
+		fmt.Printf("    {\"name\": %q, \"source\": %q, \"description\": %q}\n",
+			opts.Name, "./"+filepath.ToSlash(source), opts.Description)
+	}
+	if report.Errors() > 0 {
+		return ErrFailed
+	}
+	return nil
+}
+
+// marketplaceRoot returns the closest directory at or above dir holding a
+// marketplace manifest, or "".
+func marketplaceRoot(dir string) string {
+	for {
+		if plugincheck.IsMarketplace(dir) {
+			return dir
+		}
+		up := filepath.Dir(dir)
+		if up == dir {
+			return ""
+		}
+		dir = up
+	}
+}
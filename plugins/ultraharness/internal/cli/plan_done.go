@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/gates"
+	"ultraharness/internal/validation"
+)
+
+// PlanDone marks the plan validated, opening the plan gate so
+// implementation can begin.
+//
+// Usage: plan_done [-workdir DIR] [-force]
+//
+// Research must be complete, and the latest plan artifact must have steps
+// and not have been sent back by plan validation; -force marks the plan
+// validated regardless. A plan that was never validated is recorded as
+// validated along with the state.
+func PlanDone(args []string) error {
+	flags := flag.NewFlagSet("plan_done", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	force := flags.Bool("force", false, "mark the plan validated even if its prerequisites are not met")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	c, err := gates.CompletePlan(dir, *force)
+	if errors.Is(err, gates.ErrNotReady) {
+		fmt.Printf("The plan is not ready to implement: %v\n", err)
+		fmt.Println("Finish the plan, or run plan_done -force to mark it validated anyway.")
+		return ErrFailed
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Plan marked validated.")
+	if p := c.Plan; p != nil {
+		fmt.Printf("  Goal: %s\n", p.Goal)
+		fmt.Printf("  Steps: %d\n", len(p.Steps))
+		if v := p.ValidationResult; v != nil {
+			fmt.Printf("  Validation: %s\n", v.Recommendation)
+		}
+	}
+	printOverridden(c.Overridden)
+	fmt.Printf("Phase: %s. Edits are no longer gated.\n", c.State.Phase)
+	followLinear(dir, c.State.Phase)
+	return nil
+}
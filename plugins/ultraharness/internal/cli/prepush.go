@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ultraharness/internal/build"
+	"ultraharness/internal/config"
+	"ultraharness/internal/git"
+	"ultraharness/internal/linear"
+	"ultraharness/internal/readiness"
+	"ultraharness/internal/testrunner"
+	"ultraharness/internal/validation"
+)
+
+// Prepush checks that the branch is ready to push, for use as a git
+// pre-push hook.
+//
+// Usage: prepush [-workdir DIR] [-timeout DURATION]
+//
+//	prepush -install [-force]
+//
+// It runs the Stop hook's readiness checks: the tests pass (reusing the
+// last result for an unchanged tree), the project builds, no merge
+// conflicts are left, and no feature is marked passing without
+// verification. Each check is reported as PASS, FAIL, or SKIP, and
+// ErrFailed is returned if any failed, which makes git abort the push.
+//
+// -install writes .git/hooks/pre-push to run this command; -force replaces
+// an existing hook.
+func Prepush(args []string) error {
+	flags := flag.NewFlagSet("prepush", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	timeout := flags.Duration("timeout", testrunner.DefaultTimeout, "test timeout")
+	install := flags.Bool("install", false, "install as the repository's pre-push hook")
+	force := flags.Bool("force", false, "with -install, replace an existing pre-push hook")
+	// git passes the remote name and URL; they do not affect the checks
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	if *install {
+		return installPrepush(dir, *force)
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	report := func(result, name, detail string) {
+		if result == "FAIL" {
+			failed++
+		}
+		fmt.Printf("[%s] %s", result, name)
+		if detail != "" {
+			fmt.Printf(": %s", strings.ReplaceAll(detail, "\n", "\n       "))
+		}
+		fmt.Println()
+	}
+
+	tests, cached := testrunner.RunCached(dir, *timeout)
+	name := "tests"
+	if cached {
+		name += " (cached)"
+	}
+	switch tests.Result {
+	case testrunner.Passed:
+		report("PASS", name, testCounts(tests))
+	case testrunner.Failed:
+		report("FAIL", name, testCounts(tests)+"\n"+strings.TrimSpace(tests.RawOutput))
+	case testrunner.Error:
+		report("FAIL", name, strings.TrimSpace(tests.RawOutput))
+	default:
+		report("SKIP", name, "no test command detected")
+	}
+
+	switch {
+	case !cfg.BuildVerification:
+		report("SKIP", "build", "build_verification is off")
+	case len(build.DetectCommand(dir, cfg.BuildCommand)) == 0:
+		report("SKIP", "build", "no build command detected")
+	default:
+		failure := readiness.BuildFailure(dir, cfg)
+		report(status(failure), "build", failure)
+	}
+
+	conflicts := readiness.Conflicts(dir)
+	if len(conflicts) > 0 {
+		report("FAIL", "merge conflicts", strings.Join(conflicts, ", "))
+	} else {
+		report("PASS", "merge conflicts", "")
+	}
+
+	unverified := readiness.UnverifiedFeatures(dir)
+	report(status(unverified), "features", unverified)
+
+	if failed > 0 {
+		fmt.Printf("\nPush blocked: %d check(s) failed. Fix them, or push with --no-verify to skip these checks.\n", failed)
+		return ErrFailed
+	}
+	// A branch ready to push is ready for review
+	if linear.CurrentPhase(dir) == "implementation" {
+		followLinear(dir, linear.PhaseReview)
+	}
+	return nil
+}
+
+// status returns "FAIL" for a check that found a problem, or "PASS".
+func status(problem string) string {
+	if problem != "" {
+		return "FAIL"
+	}
+	return "PASS"
+}
+
+// testCounts describes the parsed test counts, or "" if none were parsed.
+func testCounts(s *testrunner.Summary) string {
+	if s.Total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", s.Passed, s.Failed, s.Skipped)
+}
+
+// installPrepush writes a pre-push hook that runs this binary.
+func installPrepush(dir string, force bool) error {
+	hooksDir := git.HooksDir(dir)
+	if hooksDir == "" {
+		return errors.New("not a git repository")
+	}
+
+	path := filepath.Join(hooksDir, "pre-push")
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists; use -force to replace it", path)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the harness binary: %w", err)
+	}
+	command := shellQuote(executable)
+	// The standalone prepush binary runs the command; the single binary
+	// needs its name
+	if strings.TrimSuffix(filepath.Base(executable), ".exe") != "prepush" {
+		command += " prepush"
+	}
+
+	script := "#!/bin/sh\n# Installed by ultraharness prepush -install\nexec " + command + " \"$@\"\n"
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return err
+	}
+	fmt.Printf("Installed pre-push hook at %s\n", path)
+	return nil
+}
+
+// shellQuote quotes s as a single sh word: in single quotes, which keep
+// $, `, and \ literal, closing and reopening them around an escaped quote
+// for each single quote in s.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/repair"
+	"ultraharness/internal/validation"
+)
+
+// Repair diagnoses harness state that disagrees with itself and fixes it.
+//
+// Usage: repair [-workdir DIR] [-dry-run] [-resync] [-clear-plan] [-force-phase PHASE]
+//
+// With no fix flags it only lists the problems it finds. -resync brings
+// fic-state.json in line with the artifacts, puts implementation artifacts
+// whose plan is gone aside, and clears stale context tracking. -clear-plan
+// puts the plan and implementation artifacts aside so planning can start
+// over. -force-phase sets the phase and its gates outright. With -dry-run
+// it prints what the fixes would change without writing anything.
+func Repair(args []string) error {
+	flags := flag.NewFlagSet("repair", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	dryRun := flags.Bool("dry-run", false, "print the changes without making them")
+	resync := flags.Bool("resync", false, "bring the state in line with the artifacts")
+	clearPlan := flags.Bool("clear-plan", false, "put the plan and implementation artifacts aside")
+	forcePhase := flags.String("force-phase", "", "set the phase (research, planning, or implementation) and its gates")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	s, err := repair.Load(dir)
+	if err != nil {
+		return err
+	}
+	issues := s.Diagnose()
+	opts := repair.Options{ForcePhase: *forcePhase, ClearPlan: *clearPlan, Resync: *resync}
+
+	if opts == (repair.Options{}) {
+		if len(issues) == 0 {
+			fmt.Println("No problems found.")
+			return nil
+		}
+		fmt.Printf("Found %d problem(s):\n", len(issues))
+		for _, issue := range issues {
+			fmt.Printf("  - %s\n", issue)
+		}
+		fmt.Println("Run repair -resync -dry-run to preview the fixes.")
+		return ErrFailed
+	}
+
+	changes, err := s.Repair(opts, *dryRun)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("Nothing to change.")
+		return nil
+	}
+	if *dryRun {
+		fmt.Println("Dry run; the fixes would:")
+	} else {
+		fmt.Println("Repaired:")
+	}
+	for _, change := range changes {
+		fmt.Printf("  - %s\n", change)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/hooks"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/text"
+	"ultraharness/internal/validation"
+)
+
+// Replay re-runs the hook calls recorded in a hook log against a scratch
+// project and prints each decision, to reproduce reported misbehavior.
+//
+// Usage: replay [-workdir DIR] [-config PATH] [-session ID] [-keep] [LOG]
+//
+// LOG defaults to the project's .claude/hook-log.jsonl; only runs logged
+// with hook_log_inputs on can be replayed. The scratch project starts with
+// the project's config, or the one at -config, and no other state. Paths
+// under the recorded project are moved into the scratch project, so the
+// replay neither reads nor changes the project's files and state. -keep
+// leaves the scratch project in place to inspect its state. ErrFailed is
+// returned if a decision differs from the recorded one.
+func Replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	configPath := flags.String("config", "", "config to replay with (default: the project's)")
+	sessionID := flags.String("session", "", "only replay runs from this session")
+	keep := flags.Bool("keep", false, "keep the scratch project after the replay")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+	logPath := filepath.Join(dir, ".claude", hookrunner.LogFile)
+	if flags.NArg() > 0 {
+		logPath = flags.Arg(0)
+	}
+
+	all, err := hookrunner.LoadRecords(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", logPath, err)
+	}
+	var records []hookrunner.Record
+	skipped := 0
+	for _, r := range all {
+		if *sessionID != "" && r.SessionID != *sessionID {
+			continue
+		}
+		if r.Input == nil {
+			skipped++
+			continue
+		}
+		records = append(records, r)
+	}
+	if len(records) == 0 {
+		fmt.Printf("No recorded hook inputs in %s.\n", logPath)
+		fmt.Println(`Record them with "hook_log": true and "hook_log_inputs": true in .claude/claude-harness.json.`)
+		return nil
+	}
+
+	if *configPath == "" {
+		*configPath = filepath.Join(dir, ".claude", config.ConfigFileName)
+	}
+	scratch, err := newScratchProject(*configPath)
+	if err != nil {
+		return err
+	}
+	if *keep {
+		fmt.Printf("Scratch project: %s\n", scratch)
+	} else {
+		defer os.RemoveAll(scratch)
+	}
+
+	changed := 0
+	for i, r := range records {
+		output, err := replayRecord(scratch, r)
+		if err != nil {
+			fmt.Printf("%3d  %s: %v\n", i+1, r.Hook, err)
+			changed++
+			continue
+		}
+		replayed, message := describeOutput(output)
+		recorded, _ := describeOutput(r.Output)
+		fmt.Printf("%3d  %s %s\n", i+1, r.Hook, describeCall(r.Input))
+		fmt.Printf("     %s\n", replayed)
+		if message != "" {
+			fmt.Printf("       %s\n", message)
+		}
+		if replayed != recorded {
+			fmt.Printf("     recorded: %s\n", recorded)
+			changed++
+		}
+	}
+
+	fmt.Printf("\n%d run(s) replayed", len(records))
+	if skipped > 0 {
+		fmt.Printf(", %d without a recorded input skipped", skipped)
+	}
+	fmt.Printf("; %d differ from the recording.\n", changed)
+	if changed > 0 {
+		return ErrFailed
+	}
+	return nil
+}
+
+// newScratchProject creates an initialized project using the config at
+// configPath, or the default config if there is none.
+func newScratchProject(configPath string) (string, error) {
+	scratch, err := os.MkdirTemp("", "ultraharness-replay-")
+	if err != nil {
+		return "", err
+	}
+	claudeDir := filepath.Join(scratch, ".claude")
+	if err := os.MkdirAll(claudeDir, 0700); err != nil {
+		return "", err
+	}
+	if data, err := os.ReadFile(configPath); err == nil {
+		if err := os.WriteFile(filepath.Join(claudeDir, config.ConfigFileName), data, 0600); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, config.InitMarkerFileName), nil, 0600); err != nil {
+		return "", err
+	}
+	return scratch, nil
+}
+
+// replayRecord runs the hook of r with its input in the scratch project
+// and returns the hook's output.
+func replayRecord(scratch string, r hookrunner.Record) ([]byte, error) {
+	h, ok := hooks.Lookup(r.Hook)
+	if !ok {
+		return nil, fmt.Errorf("unknown hook %q", r.Hook)
+	}
+	input, err := json.Marshal(r.Input)
+	if err != nil {
+		return nil, err
+	}
+	if r.WorkDir != "" {
+		// Paths are JSON-escaped in the input, so move them escaped too
+		from, _ := json.Marshal(r.WorkDir)
+		to, _ := json.Marshal(scratch)
+		input = bytes.ReplaceAll(input, bytes.Trim(from, `"`), bytes.Trim(to, `"`))
+	}
+
+	os.Setenv("CLAUDE_WORKING_DIRECTORY", scratch)
+	var out bytes.Buffer
+	protocol.SetHook(h.Name)
+	protocol.SetOutput(&out)
+	defer protocol.SetOutput(os.Stdout)
+	if err := hookrunner.Run(h, bytes.NewReader(input)); err != nil {
+		protocol.WriteError("%v", err)
+	}
+	return out.Bytes(), nil
+}
+
+// describeCall names the tool call or prompt a hook input is for.
+func describeCall(input *protocol.HookInput) string {
+	var target string
+	switch {
+	case input.GetFilePath() != "":
+		target = input.GetFilePath()
+	case input.GetCommand() != "":
+		target = input.GetCommand()
+	case input.GetPattern() != "":
+		target = input.GetPattern()
+	case input.Prompt != "":
+		target = input.Prompt
+	}
+	return strings.TrimSpace(input.ToolName + " " + text.Truncate(firstLine(target), 57))
+}
+
+// describeOutput summarizes a hook's output as its decision (the output
+// event and the check that made it) and the first line of its message.
+func describeOutput(output []byte) (decision, message string) {
+	var out protocol.HookOutput
+	if len(bytes.TrimSpace(output)) == 0 || json.Unmarshal(output, &out) != nil {
+		return "no output", ""
+	}
+	decision = "allowed"
+	if event, _ := out.Metadata["event"].(string); event != "" {
+		decision = event
+	}
+	if check, _ := out.Metadata["check"].(string); check != "" {
+		decision += " (" + check + ")"
+	}
+
+	message = out.SystemMessage
+	if specific := out.HookSpecificOutput; specific != nil {
+		switch {
+		case specific.PermissionDecisionReason != "":
+			message = specific.PermissionDecisionReason
+		case specific.AdditionalContext != "" && message == "":
+			message = specific.AdditionalContext
+		}
+	}
+	return decision, firstLine(message)
+}
+
+// firstLine returns the first non-empty line of s, trimmed.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/budget"
+	"ultraharness/internal/config"
+	"ultraharness/internal/context"
+	"ultraharness/internal/cost"
+	"ultraharness/internal/digest"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/risk"
+	"ultraharness/internal/session"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/tags"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/validation"
+)
+
+// Report prints a summary of the current harness session.
+//
+// Usage: report [-workdir DIR] [-source agent|auto|human] [-tag LIST]
+//
+//	report -digest [-workdir DIR] [-days N]
+//
+// The report covers session activity, edit risk, the session's progress
+// entries by who wrote them, context utilization, budget usage, and
+// estimated cost. -source lists only the progress entries from that
+// source, and -tag only those carrying any of a comma-separated list of
+// hashtags, e.g. auth,perf. -digest instead prints a non-technical summary of the last N
+// days (default 7) for stakeholders, from the digest template. It is
+// intended to back the /ultraharness:report slash command and reads state
+// only; nothing is modified.
+func Report(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	source := flags.String("source", "", "only list progress entries from this source: agent, auto, or human")
+	tagList := flags.String("tag", "", "only list progress entries carrying one of these comma-separated tags")
+	digestMode := flags.Bool("digest", false, "print a stakeholder digest instead of the session report")
+	days := flags.Int("days", 7, "with -digest, number of days the digest covers")
+	flags.Parse(args)
+
+	if *source != "" && !validSource(progress.Source(*source)) {
+		return fmt.Errorf("unknown source %q: want agent, auto, or human", *source)
+	}
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := storage.Configure(cfg); err != nil {
+		return err
+	}
+
+	if *digestMode {
+		if *days <= 0 {
+			return fmt.Errorf("invalid days %d (want a positive count)", *days)
+		}
+		templates.SetLocale(cfg.GetLocale())
+		fmt.Println(digest.Render(dir, digest.Build(dir, *days, time.Now())))
+		return nil
+	}
+
+	fmt.Print(buildReport(dir, cfg, progress.Source(*source), tags.Split(*tagList)))
+	return nil
+}
+
+func validSource(source progress.Source) bool {
+	for _, s := range progress.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func buildReport(workDir string, cfg *config.Config, source progress.Source, labels []string) string {
+	var lines []string
+
+	lines = append(lines, "=== ULTRAHARNESS SESSION REPORT ===")
+	lines = append(lines, "")
+
+	state, err := session.LoadLatest(workDir)
+	if err != nil {
+		lines = append(lines, fmt.Sprintf("Session state unreadable: %v", err))
+	}
+	if state == nil {
+		lines = append(lines, "No session activity recorded yet.")
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	lines = append(lines, "--- SESSION ---")
+	lines = append(lines, fmt.Sprintf("Session: %s", state.SessionID))
+	lines = append(lines, fmt.Sprintf("Started: %s", state.StartedAt.Format(time.RFC3339)))
+	lines = append(lines, fmt.Sprintf("Duration: %s", state.Elapsed().Round(time.Minute)))
+	lines = append(lines, fmt.Sprintf("Tool calls: %d", state.ToolCalls))
+	lines = append(lines, fmt.Sprintf("Files modified: %d", len(state.FilesModified)))
+	for i, f := range state.FilesModified {
+		if i >= 10 {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(state.FilesModified)-10))
+			break
+		}
+		lines = append(lines, "  - "+f)
+	}
+	lines = append(lines, fmt.Sprintf("Lines changed: %d", state.LinesChanged))
+	lines = append(lines, "")
+
+	if len(state.EditRisks) > 0 {
+		lines = append(lines, "--- EDIT RISK ---")
+		lines = append(lines, riskSummary(state.EditRisks)...)
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, "--- PROGRESS ---")
+	lines = append(lines, progressSummary(workDir, state.StartedAt, source, labels)...)
+	lines = append(lines, "")
+
+	if ctxState, err := context.LoadContextState(state.SessionID, workDir); err == nil {
+		lines = append(lines, "--- CONTEXT ---")
+		lines = append(lines, ctxState.GetSummary())
+		compactions := fmt.Sprintf("Compactions: %d", ctxState.CompactionCount)
+		if last := ctxState.LastCompaction; last != nil {
+			compactions += " (last " + last.String() + ")"
+		}
+		lines = append(lines, compactions)
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, "--- BUDGET ---")
+	status := budget.Check(state, cfg.GetBudget())
+	if msg := budget.FormatStatus(status); msg != "" {
+		lines = append(lines, msg)
+	} else if cfg.Budget == nil {
+		lines = append(lines, "No budget configured")
+	} else {
+		lines = append(lines, "Within budget")
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, "--- ESTIMATED COST ---")
+	lines = append(lines, fmt.Sprintf("Model: %s", cfg.GetCostModel()))
+	lines = append(lines, fmt.Sprintf("Input tokens: ~%dk | Output tokens: ~%dk",
+		state.InputTokens/1000, state.OutputTokens/1000))
+	lines = append(lines, fmt.Sprintf("Estimated cost: %s", cost.Format(cost.ForSession(state, cfg))))
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// maxProgressEntries limits the progress entries listed per source.
+const maxProgressEntries = 10
+
+// sourceLabels name the sources in the report; "" is entries written
+// without one, by hand or by older versions.
+var sourceLabels = map[progress.Source]string{
+	progress.SourceAgent: "Agent claimed",
+	progress.SourceAuto:  "Hooks observed",
+	progress.SourceHuman: "Human",
+	"":                   "Unattributed",
+}
+
+// progressSummary counts the progress entries written since the session
+// started by source and lists the latest from each, so what the agent
+// claimed can be told from what the hooks observed. With source set only
+// its entries are listed, and with labels only those carrying one of them.
+func progressSummary(workDir string, since time.Time, source progress.Source, labels []string) []string {
+	var only []progress.Source
+	if source != "" {
+		only = append(only, source)
+	}
+	entries, err := progress.ReadEntries(workDir, only...)
+	if err != nil {
+		return []string{fmt.Sprintf("Progress log unreadable: %v", err)}
+	}
+	// Entry timestamps have whole seconds
+	since = since.Truncate(time.Second)
+	bySource := map[progress.Source][]progress.Entry{}
+	for _, e := range entries {
+		if !e.Time.Before(since) && (len(labels) == 0 || tags.Any(e.Tags(), labels)) {
+			bySource[e.Source] = append(bySource[e.Source], e)
+		}
+	}
+
+	order := append(append([]progress.Source{}, progress.Sources...), "")
+	if source != "" {
+		order = only
+	}
+	var counts []string
+	for _, s := range order {
+		counts = append(counts, fmt.Sprintf("%s: %d", sourceLabels[s], len(bySource[s])))
+	}
+	lines := []string{strings.Join(counts, " | ")}
+	for _, s := range order {
+		list := bySource[s]
+		if len(list) == 0 {
+			continue
+		}
+		lines = append(lines, sourceLabels[s]+":")
+		if len(list) > maxProgressEntries {
+			lines = append(lines, fmt.Sprintf("  ... %d earlier", len(list)-maxProgressEntries))
+			list = list[len(list)-maxProgressEntries:]
+		}
+		for _, e := range list {
+			lines = append(lines, fmt.Sprintf("  - %s %s", e.Time.Format("15:04"), e.Message))
+		}
+	}
+	return lines
+}
+
+// riskSummary counts the edited files at each risk level and lists the
+// MEDIUM and HIGH risk ones, riskiest first.
+func riskSummary(scores map[string]int) []string {
+	files := make([]string, 0, len(scores))
+	counts := map[string]int{}
+	for f, score := range scores {
+		files = append(files, f)
+		counts[risk.Level(score)]++
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if scores[files[i]] != scores[files[j]] {
+			return scores[files[i]] > scores[files[j]]
+		}
+		return files[i] < files[j]
+	})
+
+	lines := []string{fmt.Sprintf("HIGH: %d | MEDIUM: %d | LOW: %d", counts[risk.High], counts[risk.Medium], counts[risk.Low])}
+	for _, f := range files {
+		if level := risk.Level(scores[f]); level != risk.Low {
+			lines = append(lines, fmt.Sprintf("  - %s %s (score %d)", level, f, scores[f]))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/gates"
+	"ultraharness/internal/validation"
+)
+
+// ResearchDone marks the research phase complete, opening the research
+// gate so planning can begin.
+//
+// Usage: research_done [-workdir DIR] [-force]
+//
+// The latest research artifact must be at least 70% confident and have
+// no blocking open questions; -force marks research complete regardless.
+func ResearchDone(args []string) error {
+	flags := flag.NewFlagSet("research_done", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	force := flags.Bool("force", false, "mark research complete even if its prerequisites are not met")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	c, err := gates.CompleteResearch(dir, *force)
+	if errors.Is(err, gates.ErrNotReady) {
+		fmt.Printf("Research is not ready for planning: %v\n", err)
+		fmt.Println("Keep researching, or run research_done -force to mark it complete anyway.")
+		return ErrFailed
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Research marked complete.")
+	if r := c.Research; r != nil {
+		blocking := 0
+		for _, q := range r.OpenQuestions {
+			if q.Blocking {
+				blocking++
+			}
+		}
+		fmt.Printf("  Task: %s\n", r.FeatureOrTask)
+		fmt.Printf("  Confidence: %.0f%%\n", r.ConfidenceScore*100)
+		fmt.Printf("  Discoveries: %d, open questions: %d (%d blocking)\n", len(r.Discoveries), len(r.OpenQuestions), blocking)
+		if len(r.Assumptions) > 0 {
+			fmt.Printf("  Assumptions: %d, pinned to the files they depend on (see /ultraharness:assumptions)\n", len(r.Assumptions))
+		}
+	}
+	printOverridden(c.Overridden)
+	fmt.Printf("Phase: %s. Edits stay gated until the plan is done (/fic-plan-done).\n", c.State.Phase)
+	followLinear(dir, c.State.Phase)
+	return nil
+}
+
+// printOverridden lists the prerequisites a forced completion skipped.
+func printOverridden(problems []string) {
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Println("Forced past:")
+	for _, p := range problems {
+		fmt.Printf("  - %s\n", p)
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ultraharness/internal/researchqueue"
+	"ultraharness/internal/validation"
+)
+
+// ResearchQueue lists research questions deferred during
+// implementation and marks them done once researched.
+//
+// Usage: research_queue [-workdir DIR] [-prune] [ID...]
+//
+// Without IDs it lists the pending questions. IDs mark those questions
+// done; -prune removes done questions from the queue.
+func ResearchQueue(args []string) error {
+	flags := flag.NewFlagSet("research_queue", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	prune := flags.Bool("prune", false, "remove questions already marked done")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	queue, err := researchqueue.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", researchqueue.FileName, err)
+	}
+
+	var ids []int
+	for _, arg := range flags.Args() {
+		id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			return fmt.Errorf("invalid research item %q (want a number such as 3)", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 && !*prune {
+		listPendingResearch(queue)
+		return nil
+	}
+
+	if len(ids) > 0 {
+		resolved := queue.Resolve(ids...)
+		if len(resolved) == 0 {
+			return fmt.Errorf("none of the given items are pending")
+		}
+		for _, id := range resolved {
+			fmt.Printf("Marked #%d done\n", id)
+		}
+	}
+	if *prune {
+		fmt.Printf("Removed %d done item(s)\n", queue.Prune())
+	}
+	if err := queue.Save(dir); err != nil {
+		return err
+	}
+	if remaining := len(queue.Pending()); remaining > 0 {
+		fmt.Printf("%d item(s) still pending.\n", remaining)
+	}
+	return nil
+}
+
+func listPendingResearch(queue *researchqueue.Queue) {
+	pending := queue.Pending()
+	if len(pending) == 0 {
+		fmt.Println("No deferred research.")
+		return
+	}
+
+	fmt.Printf("%d deferred research item(s):\n", len(pending))
+	for _, item := range pending {
+		fmt.Printf("  #%d %s (queued %s)\n", item.ID, strings.Join(strings.Fields(item.Prompt), " "), item.QueuedAt.Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"ultraharness/internal/snapshot"
+	"ultraharness/internal/validation"
+)
+
+// Restore rolls the harness state back to a snapshot.
+//
+// Usage: restore [-workdir DIR] NAME
+//
+// The current state is saved as a "pre-restore-<timestamp>" snapshot first,
+// so a restore can itself be undone.
+func Restore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	name := flags.Arg(0)
+	if name == "" {
+		return fmt.Errorf("usage: restore NAME (see: snapshot -list)")
+	}
+
+	backup, err := snapshot.Restore(dir, name)
+	if err != nil {
+		if errors.Is(err, snapshot.ErrNotFound) {
+			return fmt.Errorf("snapshot %q not found (see: snapshot -list)", name)
+		}
+		return err
+	}
+
+	fmt.Printf("Restored snapshot %s. Previous state saved as %s.\n", name, backup)
+	return nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/todos"
+	"ultraharness/internal/validation"
+)
+
+// ScanTodos lists TODO/FIXME/HACK comments not yet tracked in the
+// feature checklist, and optionally adds them as pending features.
+//
+// Usage: scan_todos [-workdir DIR] [-add]
+//
+// Only tracked code files are scanned. Comments whose text already appears
+// in a feature's name or description are skipped.
+func ScanTodos(args []string) error {
+	flags := flag.NewFlagSet("scan_todos", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	add := flags.Bool("add", false, "add untracked items to claude-features.json as pending features")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return err
+	}
+	codeFiles := cfg.GetCodeFiles()
+
+	data, err := features.Load(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to load features: %w", err)
+		}
+		data = &features.FeaturesData{}
+	}
+
+	items := todos.Scan(dir, func(path string) bool {
+		return git.IsCodeFile(path, codeFiles.Extensions, codeFiles.Exclude)
+	})
+	untracked := todos.Untracked(items, data)
+	if len(untracked) == 0 {
+		fmt.Println("No untracked TODO/FIXME/HACK comments found.")
+		return nil
+	}
+
+	if !*add {
+		fmt.Printf("Untracked code debt (%d):\n", len(untracked))
+		for _, item := range untracked {
+			fmt.Printf("  %s %s: %s\n", item.Tag, item.Location(), item.Text)
+		}
+		return nil
+	}
+
+	added := todos.AddAsFeatures(untracked, data)
+	if err := features.Save(dir, data); err != nil {
+		return fmt.Errorf("failed to save features: %w", err)
+	}
+	progress.Append(progress.CommandSource(), fmt.Sprintf("Added %d code debt items to feature checklist", len(added)), dir)
+
+	fmt.Printf("Added %d pending features:\n", len(added))
+	for _, f := range added {
+		fmt.Printf("  %s: %s\n", f.ID, f.Description)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"ultraharness/internal/search"
+	"ultraharness/internal/tags"
+	"ultraharness/internal/validation"
+)
+
+// Search full-text searches the FIC artifacts, the decision log, the
+// knowledge base, the progress log, and the preserved context.
+//
+// Usage: search [-workdir DIR] [-limit N] [-source LIST] [-tag LIST] [QUERY...]
+//
+// Results are ranked by how many of the query's words they share, rarer
+// words counting more, with newer text first among equals. Each shows
+// where it was recorded, when, and a snippet around the first match.
+// -source restricts the search to a comma-separated list of sources, and
+// -tag to text carrying any of a comma-separated list of tags; with -tag
+// the query may be left out to list the newest tagged text.
+func Search(args []string) error {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	limit := flags.Int("limit", 10, "maximum number of results to list")
+	sources := flags.String("source", "", "comma-separated sources to search: "+strings.Join(search.Sources, ", ")+" (default: all)")
+	tagList := flags.String("tag", "", "comma-separated tags, e.g. auth,perf; only text carrying one is searched")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("invalid limit %d (want a positive count)", *limit)
+	}
+	query := strings.Join(flags.Args(), " ")
+	labels := tags.Split(*tagList)
+	if strings.TrimSpace(query) == "" && len(labels) == 0 {
+		return errors.New("usage: search [-workdir DIR] [-limit N] [-source LIST] [-tag LIST] [QUERY...]")
+	}
+
+	known := map[string]bool{}
+	for _, s := range search.Sources {
+		known[s] = true
+	}
+	wanted := map[string]bool{}
+	for _, s := range strings.Split(*sources, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		if !known[s] {
+			return fmt.Errorf("unknown source %q (want one of %s)", s, strings.Join(search.Sources, ", "))
+		}
+		wanted[s] = true
+	}
+
+	var docs []search.Document
+	for _, d := range search.Collect(dir) {
+		if len(wanted) == 0 || wanted[d.Source] {
+			docs = append(docs, d)
+		}
+	}
+	subject := fmt.Sprintf("%q", query)
+	if len(labels) > 0 {
+		docs = search.Tagged(docs, labels)
+		subject = "#" + strings.Join(labels, ", #")
+		if strings.TrimSpace(query) != "" {
+			subject = fmt.Sprintf("%q tagged %s", query, subject)
+		}
+	}
+
+	results := search.Search(docs, query, *limit)
+	if len(results) == 0 {
+		fmt.Printf("Nothing matches %s.\n", subject)
+		return nil
+	}
+	fmt.Printf("%d result(s) for %s:\n", len(results), subject)
+	for _, r := range results {
+		where := r.Source
+		if r.ID != "" {
+			where += " " + r.ID
+		}
+		when := "undated"
+		if !r.Time.IsZero() {
+			when = r.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  [%s] %s, %s\n    %s\n", where, r.Field, when, r.Snippet)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"ultraharness/internal/snapshot"
+	"ultraharness/internal/validation"
+)
+
+// Snapshot saves the complete harness state to a named snapshot.
+//
+// Usage: snapshot [-workdir DIR] [-list] [NAME]
+//
+// The snapshot captures .claude (config, artifacts, context state) along
+// with claude-features.json and claude-progress.txt. NAME defaults to a
+// timestamp. Use the restore command to roll back to a snapshot.
+func Snapshot(args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	list := flags.Bool("list", false, "list existing snapshots")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	if *list {
+		snapshots, err := snapshot.List(dir)
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots.")
+			return nil
+		}
+		for _, s := range snapshots {
+			fmt.Printf("%-32s %s  %d KB\n", s.Name, s.CreatedAt.Format(time.RFC3339), (s.Size+1023)/1024)
+		}
+		return nil
+	}
+
+	name := flags.Arg(0)
+	if name == "" {
+		name = snapshot.DefaultName()
+	}
+
+	path, err := snapshot.Create(dir, name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved snapshot %s (%s)\n", name, path)
+	return nil
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"ultraharness/internal/stats"
+)
+
+// Stats summarizes harness behavior across all projects from the
+// user-level stats store written when global_stats is enabled.
+//
+// Usage: stats [-file PATH] [-days N] [-project SUBSTRING] [-top N]
+//
+// It reports session counts and averages overall, the projects that
+// trigger the most compactions, and the most used tools.
+func Stats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	file := flags.String("file", "", "stats store (default: ~/.ultraharness/stats.jsonl)")
+	days := flags.Int("days", 0, "only include sessions from the last N days (0: all)")
+	project := flags.String("project", "", "only include projects whose path contains SUBSTRING")
+	top := flags.Int("top", 10, "number of projects and tools to list")
+	flags.Parse(args)
+
+	path := *file
+	if path == "" {
+		var err error
+		if path, err = stats.DefaultPath(); err != nil {
+			return err
+		}
+	}
+
+	records, err := stats.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var since time.Time
+	if *days > 0 {
+		since = time.Now().AddDate(0, 0, -*days)
+	}
+	records = stats.Filter(records, since, *project)
+	if len(records) == 0 {
+		fmt.Printf("No sessions recorded in %s.\n", path)
+		fmt.Println(`Enable collection with "global_stats": true in each project's .claude/claude-harness.json.`)
+		return nil
+	}
+
+	summary := stats.Summarize(records)
+	fmt.Printf("All projects (%d):\n", len(summary.Projects))
+	printTotals(summary.Overall, "  ")
+
+	fmt.Println()
+	fmt.Println("Projects by compactions:")
+	for i, p := range summary.Projects {
+		if i >= *top {
+			fmt.Printf("  ... and %d more\n", len(summary.Projects)-i)
+			break
+		}
+		fmt.Printf("  %s (%s)\n", filepath.Base(p.Project), p.Project)
+		printTotals(p.Totals, "    ")
+	}
+
+	if len(summary.Tools) > 0 {
+		fmt.Println()
+		fmt.Println("Most used tools:")
+		for i, t := range summary.Tools {
+			if i >= *top {
+				break
+			}
+			fmt.Printf("  %-12s %d\n", t.Tool, t.Calls)
+		}
+	}
+	return nil
+}
+
+func printTotals(t stats.Totals, indent string) {
+	fmt.Printf("%sSessions: %d, average length %s, %.0f tool calls\n",
+		indent, t.Sessions, t.AverageDuration().Round(time.Minute), t.AverageToolCalls())
+	compactions := fmt.Sprintf("%d (%.1f per session", t.Compactions, float64(t.Compactions)/float64(t.Sessions))
+	if freed := t.AverageTokensFreed(); freed >= 0 {
+		compactions += fmt.Sprintf(", ~%dk tokens freed each", freed/1000)
+	}
+	fmt.Printf("%sCompactions: %s), gate blocks: %d\n", indent, compactions, t.GateBlocks)
+	if rate := t.TestPassRate(); rate >= 0 {
+		fmt.Printf("%sTest runs: %d, %.0f%% passed\n", indent, t.TestRuns, rate*100)
+	} else {
+		fmt.Printf("%sTest runs: none\n", indent)
+	}
+}
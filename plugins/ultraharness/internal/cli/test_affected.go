@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/git"
+	"ultraharness/internal/testimpact"
+	"ultraharness/internal/testrunner"
+	"ultraharness/internal/validation"
+)
+
+// TestAffected runs the tests of the Go packages affected by the changes
+// in the working tree, several packages at a time.
+//
+// Usage: test_affected [-workdir DIR] [-base REV] [-all] [-workers N] [-timeout DURATION]
+//
+// The affected packages are those containing changed .go files and every
+// package importing them, or every package of a module whose go.mod
+// changed. Changes are the uncommitted ones plus, with -base, those
+// committed since REV; -all tests every package. Each package runs in its
+// own go test process with its own timeout, and is reported as ok or FAIL,
+// followed by the output of the failures. ErrFailed is returned if any
+// package failed or timed out.
+func TestAffected(args []string) error {
+	flags := flag.NewFlagSet("test_affected", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	base := flags.String("base", "", "also include changes committed since this revision")
+	all := flags.Bool("all", false, "test every package, not only the affected ones")
+	workers := flags.Int("workers", 0, "packages tested at once (default: test_workers, or one per CPU)")
+	timeout := flags.Duration("timeout", 0, "timeout for each package (default: test_package_timeout_seconds, or 2m)")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return err
+	}
+	if *workers <= 0 {
+		*workers = cfg.GetTestWorkers()
+	}
+	if *timeout <= 0 {
+		*timeout = time.Duration(cfg.GetTestPackageTimeoutSeconds()) * time.Second
+	}
+
+	var changed []string
+	if !*all {
+		changed = git.ModifiedFiles(dir)
+		if *base != "" {
+			changed = append(changed, git.ChangedFilesSince(dir, *base)...)
+		}
+	}
+	pkgs := testimpact.GoPackages(dir, changed, *all, *timeout)
+	if len(pkgs) == 0 {
+		if *all {
+			fmt.Println("No Go packages with tests found.")
+		} else {
+			fmt.Println("No Go packages with tests are affected by the changes.")
+		}
+		return nil
+	}
+
+	fmt.Printf("Testing %d package(s), %d at a time:\n", len(pkgs), *workers)
+	summary := testrunner.RunGoPackages(dir, pkgs, *workers, *timeout)
+	fmt.Print(summary.RawOutput)
+	fmt.Printf("\n%d passed, %d failed in %s\n", summary.Passed, summary.Failed, summary.Duration.Round(time.Second/10))
+	if summary.Result != testrunner.Passed {
+		return ErrFailed
+	}
+	return nil
+}
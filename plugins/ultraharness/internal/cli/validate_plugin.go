@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"ultraharness/internal/plugincheck"
+	"ultraharness/internal/validation"
+)
+
+// ValidatePlugin checks plugins and marketplaces before they are published.
+//
+// Usage: validate_plugin [-strict] [DIR...]
+//
+// Each DIR (default: the current directory) is a plugin, or a marketplace
+// root containing .claude-plugin/marketplace.json, in which case every
+// plugin it lists with a local source is checked too. It checks the
+// manifests, that hook registrations use known events and run scripts that
+// exist and are executable, command frontmatter, and agent definitions.
+// Problems are printed as ERROR or WARNING with the file they are in, and
+// ErrFailed is returned if there are errors, or warnings with -strict.
+func ValidatePlugin(args []string) error {
+	flags := flag.NewFlagSet("validate_plugin", flag.ExitOnError)
+	strict := flags.Bool("strict", false, "fail on warnings as well as errors")
+	flags.Parse(args)
+
+	dirs := flags.Args()
+	if len(dirs) == 0 {
+		dirs = []string{validation.GetWorkDir()}
+	}
+
+	failed := false
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if err := validation.ValidateWorkDir(abs); err != nil {
+			return fmt.Errorf("%s: %w", dir, err)
+		}
+
+		kind, report := "plugin", plugincheck.Plugin
+		if plugincheck.IsMarketplace(abs) {
+			kind, report = "marketplace", plugincheck.Marketplace
+		}
+		r := report(abs)
+
+		if len(dirs) > 1 {
+			fmt.Printf("%s:\n", dir)
+		}
+		for _, p := range r.Problems {
+			fmt.Println(p)
+		}
+		errs, warns := r.Errors(), r.Warnings()
+		if errs == 0 && warns == 0 {
+			fmt.Printf("%s OK\n", kind)
+		} else {
+			fmt.Printf("%s: %d errors, %d warnings\n", kind, errs, warns)
+		}
+		if errs > 0 || (*strict && warns > 0) {
+			failed = true
+		}
+	}
+	if failed {
+		return ErrFailed
+	}
+	return nil
+}
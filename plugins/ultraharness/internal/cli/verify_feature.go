@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"ultraharness/internal/features"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/validation"
+)
+
+// VerifyFeature runs a feature's acceptance criteria.
+//
+// Usage: verify_feature [-workdir DIR] [-all] [-timeout DURATION] FEATURE_ID...
+//
+// Each acceptance criterion is either a shell command that must exit 0 or
+// a test filter passed to the project's test command. The feature's status
+// is flipped to passing or failing and the result is recorded in
+// claude-features.json, so the Stop hook can tell verified features from
+// ones that were only marked passing by hand.
+//
+// Returns ErrFailed if any verified feature failed.
+func VerifyFeature(args []string) error {
+	flags := flag.NewFlagSet("verify_feature", flag.ExitOnError)
+	workDir := flags.String("workdir", "", "project directory (default: current directory)")
+	all := flags.Bool("all", false, "verify every feature that has acceptance criteria")
+	timeout := flags.Duration("timeout", features.DefaultVerifyTimeout, "timeout per criterion")
+	flags.Parse(args)
+
+	dir := *workDir
+	if dir == "" {
+		dir = validation.GetWorkDir()
+	}
+	if err := validation.ValidateWorkDir(dir); err != nil {
+		return err
+	}
+
+	data, err := features.Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load features: %w", err)
+	}
+
+	targets, err := selectFeatures(data, flags.Args(), *all)
+	if err != nil {
+		return err
+	}
+
+	allPassed := true
+	var lines []string
+	var summaries []string
+	for _, f := range targets {
+		verification := features.Verify(dir, f, *timeout)
+		if verification == nil {
+			lines = append(lines, fmt.Sprintf("%s: no acceptance criteria defined - skipped", f.ID))
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s (%s): %s", f.ID, f.Name, strings.ToUpper(f.Status)))
+		for _, r := range verification.Results {
+			mark := "PASS"
+			if !r.Passed {
+				mark = "FAIL"
+			}
+			lines = append(lines, fmt.Sprintf("  [%s] %s", mark, r.Criterion))
+			if !r.Passed && r.Output != "" {
+				for _, l := range strings.Split(r.Output, "\n") {
+					lines = append(lines, "      "+l)
+				}
+			}
+		}
+
+		if !verification.Passed {
+			allPassed = false
+		}
+		summaries = append(summaries, f.ID+" "+f.Status)
+	}
+
+	if len(summaries) > 0 {
+		if err := features.Save(dir, data); err != nil {
+			return fmt.Errorf("failed to save features: %w", err)
+		}
+		progress.Append(progress.CommandSource(), "VERIFIED: "+strings.Join(summaries, ", "), dir)
+	}
+
+	fmt.Println(strings.Join(lines, "\n"))
+	if !allPassed {
+		return ErrFailed
+	}
+	return nil
+}
+
+// selectFeatures resolves the features to verify from IDs or -all.
+func selectFeatures(data *features.FeaturesData, ids []string, all bool) ([]*features.Feature, error) {
+	var targets []*features.Feature
+
+	if all {
+		for i := range data.Features {
+			if len(data.Features[i].AcceptanceCriteria) > 0 {
+				targets = append(targets, &data.Features[i])
+			}
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("no features have acceptance criteria")
+		}
+		return targets, nil
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("usage: verify_feature [-all] FEATURE_ID...")
+	}
+
+	for _, id := range ids {
+		f := data.Find(id)
+		if f == nil {
+			return nil, fmt.Errorf("unknown feature: %s", id)
+		}
+		targets = append(targets, f)
+	}
+	return targets, nil
+}
@@ -0,0 +1,338 @@
+// Package codemap accumulates a map of the codebase as the agent explores
+// it: each file's package, purpose (from its doc comment), key types, and
+// whether it is an entry point, plus where searched terms were found.
+// Sessions resuming after a compaction receive the map, so they do not
+// re-explore code they already read.
+//
+// The map is a plain JSON file in .claude, like the knowledge base.
+package codemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/text"
+)
+
+// FileName is the codebase map file inside .claude.
+const FileName = "codebase-map.json"
+
+// FilePermission is the permission for the codebase map
+const FilePermission = 0600
+
+// DirPermission is the permission for the map directory
+const DirPermission = 0700
+
+// Limits on the map's size; the least recently seen entries are dropped.
+const (
+	MaxFiles = 300
+	MaxTerms = 100
+	// MaxTermFiles is the number of files kept per searched term
+	MaxTermFiles = 5
+	maxTypes     = 8
+	maxPurpose   = 120
+)
+
+// FileNote is what was learned about one file.
+type FileNote struct {
+	Package    string    `json:"package,omitempty"`
+	Purpose    string    `json:"purpose,omitempty"`
+	Types      []string  `json:"types,omitempty"`
+	EntryPoint bool      `json:"entry_point,omitempty"`
+	SeenAt     time.Time `json:"seen_at"`
+}
+
+// Term records where a searched pattern was found.
+type Term struct {
+	Files  []string  `json:"files"`
+	SeenAt time.Time `json:"seen_at"`
+}
+
+// Map is the accumulated codebase map, keyed by path relative to the
+// project root.
+type Map struct {
+	Files map[string]FileNote `json:"files"`
+	Terms map[string]Term     `json:"terms,omitempty"`
+}
+
+// GetPath returns the path to the codebase map file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the codebase map. A missing file is an empty map.
+func Load(workDir string) (*Map, error) {
+	m := &Map{Files: map[string]FileNote{}, Terms: map[string]Term{}}
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if m.Files == nil {
+		m.Files = map[string]FileNote{}
+	}
+	if m.Terms == nil {
+		m.Terms = map[string]Term{}
+	}
+	return m, nil
+}
+
+// Save writes the codebase map.
+func (m *Map) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Len returns the number of files and terms in the map.
+func (m *Map) Len() int {
+	return len(m.Files) + len(m.Terms)
+}
+
+// RecordFile notes the file at rel, extracted from its content.
+func (m *Map) RecordFile(rel, content string) {
+	note := Extract(rel, content)
+	note.SeenAt = time.Now()
+	m.Files[rel] = note
+	if len(m.Files) > MaxFiles {
+		delete(m.Files, oldest(m.Files, func(n FileNote) time.Time { return n.SeenAt }))
+	}
+}
+
+// RecordSearch notes the files a search pattern was found in.
+func (m *Map) RecordSearch(pattern string, files []string) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || len(files) == 0 {
+		return
+	}
+	if len(files) > MaxTermFiles {
+		files = files[:MaxTermFiles]
+	}
+	m.Terms[pattern] = Term{Files: files, SeenAt: time.Now()}
+	if len(m.Terms) > MaxTerms {
+		delete(m.Terms, oldest(m.Terms, func(t Term) time.Time { return t.SeenAt }))
+	}
+}
+
+func oldest[V any](entries map[string]V, seen func(V) time.Time) string {
+	key := ""
+	var at time.Time
+	for k, v := range entries {
+		if key == "" || seen(v).Before(at) || (seen(v).Equal(at) && k < key) {
+			key, at = k, seen(v)
+		}
+	}
+	return key
+}
+
+var (
+	// lineNumberPrefix is the "   12\t" (or "12→") prefix Read adds to lines
+	lineNumberPrefix = regexp.MustCompile(`^\s*\d+(?:\t|→)`)
+	packagePattern   = regexp.MustCompile(`^package\s+([\w.]+)`)
+	typePatterns     = []*regexp.Regexp{
+		regexp.MustCompile(`^type\s+(\p{Lu}\w*)\s+(?:struct|interface)\b`),
+		regexp.MustCompile(`^class\s+(\w+)`),
+		regexp.MustCompile(`^export\s+(?:default\s+)?(?:abstract\s+)?(?:class|interface|type|enum)\s+(\w+)`),
+		regexp.MustCompile(`^pub\s+(?:struct|enum|trait)\s+(\w+)`),
+		regexp.MustCompile(`^public\s+(?:final\s+|abstract\s+)*(?:class|interface|enum|record)\s+(\w+)`),
+	}
+	entryPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^func\s+main\(\)`),
+		regexp.MustCompile(`^if\s+__name__\s*==\s*["']__main__["']`),
+		regexp.MustCompile(`^(?:pub\s+)?(?:async\s+)?fn\s+main\(`),
+		regexp.MustCompile(`public\s+static\s+void\s+main\(`),
+	}
+)
+
+// Extract reads a file's package, purpose, exported types, and entry point
+// from its content. The purpose is the first sentence of the doc comment
+// before the package clause, or else of the file's leading comment, skipping
+// license headers.
+func Extract(rel, content string) FileNote {
+	var note FileNote
+	var block, firstBlock []string
+	inBlock, sawCode := false, false
+	for i, line := range stripLineNumbers(content) {
+		trimmed := strings.TrimSpace(line)
+		if i == 0 && strings.HasPrefix(trimmed, "#!") {
+			continue
+		}
+
+		if text, ok := commentText(trimmed, &inBlock); ok {
+			if !strings.HasPrefix(text, "go:build") && !strings.HasPrefix(text, "+build") {
+				block = append(block, text)
+			}
+			continue
+		}
+		if len(block) > 0 && isLicense(block) {
+			block = nil
+		}
+		if len(block) > 0 && firstBlock == nil && !sawCode {
+			firstBlock = block
+		}
+
+		if m := packagePattern.FindStringSubmatch(trimmed); m != nil && note.Package == "" {
+			note.Package = strings.TrimSuffix(m[1], ";")
+			if len(block) > 0 {
+				note.Purpose = firstSentence(block)
+			}
+		}
+		if trimmed != "" {
+			block = nil
+			sawCode = true
+		}
+
+		for _, p := range typePatterns {
+			if m := p.FindStringSubmatch(trimmed); m != nil && len(note.Types) < maxTypes {
+				note.Types = append(note.Types, m[1])
+			}
+		}
+		for _, p := range entryPatterns {
+			if p.MatchString(trimmed) {
+				note.EntryPoint = true
+			}
+		}
+	}
+	if note.Purpose == "" && firstBlock != nil {
+		note.Purpose = firstSentence(firstBlock)
+	}
+	return note
+}
+
+// stripLineNumbers removes the line numbers Read prefixes to each line.
+func stripLineNumbers(content string) []string {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || !lineNumberPrefix.MatchString(lines[0]) {
+		return lines
+	}
+	for i, line := range lines {
+		lines[i] = lineNumberPrefix.ReplaceAllString(line, "")
+	}
+	return lines
+}
+
+// commentText returns the text of a comment line, tracking /* */ and """
+// blocks in inBlock.
+func commentText(line string, inBlock *bool) (string, bool) {
+	if *inBlock {
+		if strings.Contains(line, "*/") || strings.Contains(line, `"""`) {
+			*inBlock = false
+			line = strings.NewReplacer("*/", "", `"""`, "").Replace(line)
+		}
+		return strings.TrimSpace(strings.TrimPrefix(line, "*")), true
+	}
+	for _, open := range []string{"/**", "/*", `"""`} {
+		if strings.HasPrefix(line, open) {
+			rest := strings.TrimPrefix(line, open)
+			closing := "*/"
+			if open == `"""` {
+				closing = `"""`
+			}
+			if strings.Contains(rest, closing) {
+				rest = strings.Replace(rest, closing, "", 1)
+			} else {
+				*inBlock = true
+			}
+			return strings.TrimSpace(rest), true
+		}
+	}
+	for _, prefix := range []string{"///", "//!", "//", "#"} {
+		if strings.HasPrefix(line, prefix) && !strings.HasPrefix(line, "#[") && !strings.HasPrefix(line, "#include") {
+			return strings.TrimSpace(strings.TrimPrefix(line, prefix)), true
+		}
+	}
+	return "", false
+}
+
+func isLicense(block []string) bool {
+	text := strings.ToLower(strings.Join(block, " "))
+	return strings.Contains(text, "copyright") || strings.Contains(text, "license") || strings.Contains(text, "spdx-")
+}
+
+// firstSentence returns the first sentence of a comment block.
+func firstSentence(block []string) string {
+	sentence := strings.Join(strings.Fields(strings.Join(block, " ")), " ")
+	if i := strings.Index(sentence, ". "); i >= 0 {
+		sentence = sentence[:i+1]
+	}
+	return text.Truncate(sentence, maxPurpose)
+}
+
+// Format renders up to maxFiles files, most recently seen first, and up to
+// maxTerms searched terms, as lines for injection into the context.
+func (m *Map) Format(maxFiles, maxTerms int) []string {
+	paths := make([]string, 0, len(m.Files))
+	for p := range m.Files {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := m.Files[paths[i]], m.Files[paths[j]]
+		if !a.SeenAt.Equal(b.SeenAt) {
+			return a.SeenAt.After(b.SeenAt)
+		}
+		return paths[i] < paths[j]
+	})
+	omitted := 0
+	if len(paths) > maxFiles {
+		omitted = len(paths) - maxFiles
+		paths = paths[:maxFiles]
+	}
+	sort.Strings(paths)
+
+	var lines []string
+	for _, p := range paths {
+		note := m.Files[p]
+		line := "  " + p
+		if note.EntryPoint {
+			line += " [entry point]"
+		}
+		if note.Purpose != "" {
+			line += ": " + note.Purpose
+		}
+		if len(note.Types) > 0 {
+			line += fmt.Sprintf(" (types: %s)", strings.Join(note.Types, ", "))
+		}
+		lines = append(lines, line)
+	}
+	if omitted > 0 {
+		lines = append(lines, fmt.Sprintf("  ... and %d more files", omitted))
+	}
+
+	terms := make([]string, 0, len(m.Terms))
+	for t := range m.Terms {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		a, b := m.Terms[terms[i]], m.Terms[terms[j]]
+		if !a.SeenAt.Equal(b.SeenAt) {
+			return a.SeenAt.After(b.SeenAt)
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+	if len(terms) > 0 {
+		lines = append(lines, "Searched terms:")
+		for _, t := range terms {
+			lines = append(lines, fmt.Sprintf("  %q -> %s", t, strings.Join(m.Terms[t].Files, ", ")))
+		}
+	}
+	return lines
+}
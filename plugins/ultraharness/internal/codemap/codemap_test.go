@@ -0,0 +1,110 @@
+package codemap
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    FileNote
+	}{
+		{
+			name: "go package doc",
+			path: "internal/session/session.go",
+			content: "// Copyright 2026 Example. Licensed under MIT.\n\n" +
+				"// Package session tracks per-session activity. It is saved as JSON.\npackage session\n\n" +
+				"// State is one session.\ntype State struct {\n}\n\ntype helper struct{}\n\ntype Store interface {\n}\n",
+			want: FileNote{Package: "session", Purpose: "Package session tracks per-session activity.", Types: []string{"State", "Store"}},
+		},
+		{
+			name:    "go main with Read line numbers",
+			path:    "cmd/tool/main.go",
+			content: "     1\t// Tool command prints a report.\n     2\tpackage main\n     3\t\n     4\tfunc main() {\n     5\t}\n",
+			want:    FileNote{Package: "main", Purpose: "Tool command prints a report.", EntryPoint: true},
+		},
+		{
+			name:    "python docstring",
+			path:    "app/cli.py",
+			content: "#!/usr/bin/env python3\n\"\"\"Command line entry point.\n\nMore detail.\n\"\"\"\n\nclass Runner:\n    pass\n\nif __name__ == \"__main__\":\n    Runner()\n",
+			want:    FileNote{Purpose: "Command line entry point.", Types: []string{"Runner"}, EntryPoint: true},
+		},
+		{
+			name:    "typescript header",
+			path:    "src/api.ts",
+			content: "/**\n * HTTP client for the billing API.\n */\nimport x from 'y';\n\nexport class Client {}\nexport interface Options {}\n// helper for retries\nfunction retry() {}\n",
+			want:    FileNote{Purpose: "HTTP client for the billing API.", Types: []string{"Client", "Options"}},
+		},
+		{
+			name:    "no comments",
+			path:    "x.go",
+			content: "package x\n\n// Foo is documented but not the file.\nfunc Foo() {}\n",
+			want:    FileNote{Package: "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.path, tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapRecordAndFormat(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "codemap-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	m, err := Load(tmpDir)
+	if err != nil || m.Len() != 0 {
+		t.Fatalf("Load() of missing file = %v, %v, want empty map", m, err)
+	}
+	m.RecordFile("cmd/tool/main.go", "// Tool command prints a report.\npackage main\n\nfunc main() {}\n")
+	m.RecordFile("internal/a/a.go", "// Package a does things.\npackage a\n\ntype Thing struct{}\n")
+	note := m.Files["cmd/tool/main.go"]
+	note.SeenAt = note.SeenAt.Add(-time.Minute)
+	m.Files["cmd/tool/main.go"] = note
+	m.RecordSearch("LoadConfig", []string{"a.go", "b.go", "c.go", "d.go", "e.go", "f.go"})
+	m.RecordSearch("nothing", nil)
+	if err := m.Save(tmpDir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	m, err = Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if m.Len() != 3 || len(m.Terms["LoadConfig"].Files) != MaxTermFiles {
+		t.Errorf("Load() = %+v, want 2 files and 1 term with %d files", m, MaxTermFiles)
+	}
+
+	got := strings.Join(m.Format(1, 5), "\n")
+	for _, want := range []string{"internal/a/a.go: Package a does things. (types: Thing)", "... and 1 more files", `"LoadConfig" -> a.go`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Format() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestMapEvictsOldest(t *testing.T) {
+	m := &Map{Files: map[string]FileNote{}, Terms: map[string]Term{}}
+	m.Files["old.go"] = FileNote{SeenAt: time.Now().Add(-time.Hour)}
+	for i := 0; i < MaxFiles; i++ {
+		m.RecordFile(strings.Repeat("x", i+1)+".go", "package x")
+	}
+	if len(m.Files) != MaxFiles {
+		t.Errorf("len(Files) = %d, want %d", len(m.Files), MaxFiles)
+	}
+	if _, ok := m.Files["old.go"]; ok {
+		t.Error("RecordFile() over MaxFiles kept the least recently seen file")
+	}
+}
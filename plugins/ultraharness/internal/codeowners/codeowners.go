@@ -0,0 +1,123 @@
+// Package codeowners reads a repository's CODEOWNERS file and resolves the
+// owners of a path, following GitHub's rules: the last matching pattern
+// wins, and a pattern matching a directory owns everything below it.
+package codeowners
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"ultraharness/internal/glob"
+)
+
+// Locations are where CODEOWNERS is looked for, in GitHub's order.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule assigns owners to the paths matching a pattern. A rule without
+// owners leaves its paths unowned.
+type Rule struct {
+	Pattern string
+	Owners  []string
+}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	// Path is relative to the repository root
+	Path  string
+	Rules []Rule
+}
+
+// Load reads the first CODEOWNERS file found in workDir, or returns nil if
+// there is none.
+func Load(workDir string) (*File, error) {
+	for _, loc := range Locations {
+		data, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(loc)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &File{Path: loc, Rules: Parse(string(data))}, nil
+	}
+	return nil, nil
+}
+
+// Parse returns the rules in CODEOWNERS content, skipping blank lines and
+// comments.
+func Parse(content string) []Rule {
+	var rules []Rule
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, Rule{Pattern: fields[0], Owners: fields[1:]})
+	}
+	return rules
+}
+
+// Owners returns the owners of rel, a slash-separated path relative to the
+// repository root, from the last matching rule.
+func (f *File) Owners(rel string) []string {
+	rel = strings.TrimPrefix(path.Clean(filepath.ToSlash(rel)), "./")
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if Match(f.Rules[i].Pattern, rel) {
+			return f.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// Match reports whether a CODEOWNERS pattern matches rel. Unlike
+// gitignore, a leading slash anchors even single-segment patterns, and a
+// trailing "/*" matches only the directory's direct children.
+func Match(pattern, rel string) bool {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+	if pattern == "" {
+		return false
+	}
+	if !anchored && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	match := func(name string) bool {
+		// glob matches single segments at any depth, so anchor them here
+		if !strings.Contains(pattern, "/") {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		}
+		return glob.Match(pattern, name)
+	}
+
+	segments := strings.Split(rel, "/")
+	for i := len(segments); i > 0; i-- {
+		if match(strings.Join(segments[:i], "/")) {
+			return true
+		}
+		// "dir/*" owns the files in dir, not its subdirectories
+		if strings.HasSuffix(pattern, "/*") {
+			return false
+		}
+	}
+	return false
+}
+
+// IsOwner reports whether any of identities, such as "@alice",
+// "@org/team", or an email address, is among owners. Comparison ignores
+// case.
+func IsOwner(owners, identities []string) bool {
+	for _, o := range owners {
+		for _, id := range identities {
+			if id != "" && strings.EqualFold(o, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
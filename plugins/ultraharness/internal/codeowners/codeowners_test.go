@@ -0,0 +1,107 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*", "any/file.go", true},
+		{"*.js", "web/src/app.js", true},
+		{"*.js", "web/src/app.ts", false},
+		{"docs/", "docs/guide.md", true},
+		{"docs/", "api/docs/openapi.yaml", true},
+		{"/docs/", "api/docs/openapi.yaml", false},
+		{"/docs/", "docs/a/b.md", true},
+		{"apps", "apps/web/main.go", true},
+		{"/build/logs/", "build/logs/today.log", true},
+		{"/build/logs/", "src/build/logs/today.log", false},
+		{"docs/*", "docs/getting-started.md", true},
+		{"docs/*", "docs/build-app/troubleshooting.md", false},
+		{"**/logs", "deeply/nested/logs/x.log", true},
+		{"/internal/api/handler.go", "internal/api/handler.go", true},
+		{"/internal/api/handler.go", "internal/api/handler_test.go", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.rel); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestOwners(t *testing.T) {
+	f := &File{Rules: Parse(`
+# Default owners
+*                 @org/core
+
+/internal/billing/ @org/payments @alice  # money
+*.md              docs@example.com
+/internal/billing/README.md
+`)}
+
+	tests := []struct {
+		rel  string
+		want []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"internal/billing/invoice.go", []string{"@org/payments", "@alice"}},
+		{"guide.md", []string{"docs@example.com"}},
+		// The last match wins, and a rule without owners leaves paths unowned
+		{"internal/billing/README.md", nil},
+	}
+	for _, tt := range tests {
+		got := f.Owners(tt.rel)
+		if len(got) != len(tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.rel, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Owners(%q) = %v, want %v", tt.rel, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestIsOwner(t *testing.T) {
+	owners := []string{"@org/Payments", "alice@example.com"}
+	if !IsOwner(owners, []string{"@bob", "@org/payments"}) {
+		t.Error("IsOwner() = false for a member team, want true")
+	}
+	if !IsOwner(owners, []string{"Alice@Example.com"}) {
+		t.Error("IsOwner() = false for an owner email, want true")
+	}
+	if IsOwner(owners, []string{"@bob", ""}) {
+		t.Error("IsOwner() = true for a non-owner, want false")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "codeowners-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if f, err := Load(tmpDir); f != nil || err != nil {
+		t.Fatalf("Load() without CODEOWNERS = %v, %v, want nil, nil", f, err)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("* @root\n"), 0644)
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("* @github\n"), 0644)
+
+	f, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if f.Path != ".github/CODEOWNERS" || len(f.Rules) != 1 || f.Rules[0].Owners[0] != "@github" {
+		t.Errorf("Load() = %+v, want the .github/CODEOWNERS rules", f)
+	}
+}
@@ -0,0 +1,153 @@
+// Package compose assembles hook messages from prioritized sections and
+// trims them to fit a token budget, dropping the least important content
+// first.
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CharsPerToken approximates token counts from text length.
+const CharsPerToken = 4
+
+// Required is the priority of sections that are never trimmed.
+const Required = 100
+
+// minBodyLines is the fewest body lines worth keeping when trimming to the
+// overall budget; a section that would shrink below this is dropped.
+const minBodyLines = 2
+
+// Section is one block of a message. Its first line is the heading, which
+// is kept whenever any of the section is. Lines may contain newlines.
+type Section struct {
+	Name string
+	// Priority orders trimming: lower priorities are trimmed first, and
+	// Required sections are never trimmed
+	Priority int
+	// MaxShare caps the section at this fraction of the budget (0: no cap)
+	MaxShare float64
+	// KeepTail trims from the start, keeping the most recent lines
+	KeepTail bool
+	Lines    []string
+}
+
+// EstimateTokens approximates the number of tokens in s.
+func EstimateTokens(s string) int {
+	return (len(s) + CharsPerToken - 1) / CharsPerToken
+}
+
+func linesTokens(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		n += len(line) + 1
+	}
+	return (n + CharsPerToken - 1) / CharsPerToken
+}
+
+// Compose renders the sections in order, separated by blank lines, keeping
+// the result under maxTokens where possible. Each section is first capped
+// at its MaxShare; if the total is still over budget, sections are trimmed
+// or dropped in increasing priority order. A maxTokens of zero or less
+// disables the budget.
+func Compose(sections []Section, maxTokens int) string {
+	var active []*Section
+	for i := range sections {
+		s := &sections[i]
+		s.Lines = strings.Split(strings.Join(s.Lines, "\n"), "\n")
+		for len(s.Lines) > 0 && strings.TrimSpace(s.Lines[len(s.Lines)-1]) == "" {
+			s.Lines = s.Lines[:len(s.Lines)-1]
+		}
+		if len(s.Lines) > 0 {
+			active = append(active, s)
+		}
+	}
+
+	var omitted []string
+	if maxTokens > 0 {
+		for _, s := range active {
+			if s.MaxShare > 0 && s.Priority < Required {
+				s.Lines = trim(s.Lines, int(s.MaxShare*float64(maxTokens)), s.KeepTail)
+			}
+		}
+
+		order := make([]*Section, len(active))
+		copy(order, active)
+		sort.SliceStable(order, func(i, j int) bool { return order[i].Priority < order[j].Priority })
+
+		for _, s := range order {
+			over := total(active) - maxTokens
+			if over <= 0 {
+				break
+			}
+			if s.Priority >= Required {
+				continue
+			}
+			target := linesTokens(s.Lines) - over
+			if trimmed := trim(s.Lines, target, s.KeepTail); target > 0 && len(trimmed) >= minBodyLines+2 {
+				s.Lines = trimmed
+			} else {
+				s.Lines = nil
+				omitted = append(omitted, s.Name)
+			}
+		}
+	}
+
+	var blocks []string
+	for _, s := range active {
+		if len(s.Lines) > 0 {
+			blocks = append(blocks, strings.Join(s.Lines, "\n"))
+		}
+	}
+	if len(omitted) > 0 {
+		blocks = append(blocks, fmt.Sprintf("[Omitted to fit the startup budget: %s]", strings.Join(omitted, ", ")))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// total estimates the tokens of the rendered active sections.
+func total(sections []*Section) int {
+	n := 0
+	for _, s := range sections {
+		if len(s.Lines) > 0 {
+			n += linesTokens(s.Lines) + 1
+		}
+	}
+	return n
+}
+
+// trim keeps the heading and as many body lines as fit in budget tokens,
+// from the start (or the end with keepTail), noting how many were omitted.
+func trim(lines []string, budget int, keepTail bool) []string {
+	if linesTokens(lines) <= budget || len(lines) <= 1 {
+		return lines
+	}
+
+	heading, body := lines[0], lines[1:]
+	used := linesTokens([]string{heading, "[... 9999 lines omitted ...]"})
+	kept := 0
+	for kept < len(body) {
+		line := body[kept]
+		if keepTail {
+			line = body[len(body)-1-kept]
+		}
+		cost := (len(line) + 1 + CharsPerToken - 1) / CharsPerToken
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		kept++
+	}
+
+	marker := fmt.Sprintf("[... %d lines omitted ...]", len(body)-kept)
+	result := []string{heading}
+	if keepTail {
+		result = append(result, marker)
+		result = append(result, body[len(body)-kept:]...)
+	} else {
+		result = append(result, body[:kept]...)
+		result = append(result, marker)
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func numbered(heading string, n int) []string {
+	lines := []string{heading}
+	for i := 1; i <= n; i++ {
+		lines = append(lines, fmt.Sprintf("line %02d of %s", i, heading))
+	}
+	return lines
+}
+
+func TestComposeWithinBudget(t *testing.T) {
+	sections := []Section{
+		{Name: "a", Priority: Required, Lines: []string{"=== A ===", ""}},
+		{Name: "b", Priority: 10, Lines: []string{"--- B ---", "one\ntwo", ""}},
+	}
+	want := "=== A ===\n\n--- B ---\none\ntwo"
+	if got := Compose(sections, 1000); got != want {
+		t.Errorf("Compose() = %q, want %q", got, want)
+	}
+}
+
+func TestComposeTrimsLowPriorityFirst(t *testing.T) {
+	sections := []Section{
+		{Name: "header", Priority: Required, Lines: []string{"=== HEADER ==="}},
+		{Name: "important", Priority: 90, Lines: numbered("--- IMPORTANT ---", 10)},
+		{Name: "log", Priority: 10, KeepTail: true, Lines: numbered("--- LOG ---", 40)},
+	}
+	got := Compose(sections, 250)
+
+	if tokens := EstimateTokens(got); tokens > 250 {
+		t.Errorf("Compose() = %d tokens, want at most 250", tokens)
+	}
+	if !strings.Contains(got, "line 10 of --- IMPORTANT ---") {
+		t.Error("Compose() trimmed the high priority section")
+	}
+	if !strings.Contains(got, "line 40 of --- LOG ---") || strings.Contains(got, "line 01 of --- LOG ---") {
+		t.Error("Compose() did not keep the tail of the KeepTail section")
+	}
+	if !strings.Contains(got, "lines omitted ...]") {
+		t.Error("Compose() did not note omitted lines")
+	}
+}
+
+func TestComposeDropsSections(t *testing.T) {
+	sections := []Section{
+		{Name: "header", Priority: Required, Lines: numbered("=== HEADER ===", 10)},
+		{Name: "debt", Priority: 5, Lines: numbered("--- DEBT ---", 10)},
+	}
+	got := Compose(sections, 60)
+
+	if strings.Contains(got, "DEBT ---") {
+		t.Errorf("Compose() kept a section that could not fit: %q", got)
+	}
+	if !strings.Contains(got, "line 10 of === HEADER ===") {
+		t.Error("Compose() trimmed a Required section")
+	}
+	if !strings.HasSuffix(got, "[Omitted to fit the startup budget: debt]") {
+		t.Errorf("Compose() = %q, want omitted note", got)
+	}
+}
+
+func TestComposeMaxShare(t *testing.T) {
+	sections := []Section{
+		{Name: "files", Priority: 50, MaxShare: 0.1, Lines: numbered("--- FILES ---", 50)},
+	}
+	got := Compose(sections, 1000)
+	if tokens := EstimateTokens(got); tokens > 100 {
+		t.Errorf("Compose() = %d tokens, want at most 100 from MaxShare", tokens)
+	}
+	if !strings.HasPrefix(got, "--- FILES ---\nline 01") {
+		t.Errorf("Compose() = %q, want the head of the section kept", got)
+	}
+}
+
+func TestComposeUnlimited(t *testing.T) {
+	sections := []Section{{Name: "log", Priority: 1, MaxShare: 0.1, Lines: numbered("--- LOG ---", 100)}}
+	if got := Compose(sections, 0); strings.Contains(got, "omitted") {
+		t.Error("Compose() trimmed with the budget disabled")
+	}
+}
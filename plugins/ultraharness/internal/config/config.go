@@ -5,7 +5,12 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
 
+	"ultraharness/internal/filecache"
+	"ultraharness/internal/schema"
 	"ultraharness/internal/validation"
 )
 
@@ -20,10 +25,65 @@ const (
 	StrictnessRelaxed  = "relaxed"
 	StrictnessStandard = "standard"
 	StrictnessStrict   = "strict"
+	// StrictnessReview enforces everything strict does, and additionally
+	// holds files created by the agent for human approval
+	StrictnessReview = "review"
 )
 
+// Hook names, as used in the "hooks" config section
+const (
+	HookPreToolUse       = "pre_tool_use"
+	HookPostToolUse      = "post_tool_use"
+	HookSessionStart     = "session_start"
+	HookUserPromptSubmit = "user_prompt_submit"
+	HookSubagentStop     = "subagent_stop"
+	HookPreCompact       = "pre_compact"
+	HookStop             = "stop"
+	HookSessionEnd       = "session_end"
+)
+
+// Output verbosity levels
+const (
+	// VerbosityQuiet drops periodic status updates and decorative box art
+	VerbosityQuiet  = "quiet"
+	VerbosityNormal = "normal"
+	// VerbosityVerbose adds diagnostic detail to hook messages
+	VerbosityVerbose = "verbose"
+)
+
+// Headless modes
+const (
+	// HeadlessAuto turns headless mode on when a CI environment variable
+	// is set
+	HeadlessAuto = "auto"
+	HeadlessOn   = "on"
+	HeadlessOff  = "off"
+)
+
+// DefaultLocale is the locale used when none is configured
+const DefaultLocale = "en"
+
+// localePattern matches language tags: a language, then optional region
+// or script subtags
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// CIEnvVars are the environment variables that mark a CI run for
+// HeadlessAuto. Any non-empty value other than "false" or "0" counts.
+var CIEnvVars = []string{
+	"CI",
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"BUILDKITE",
+	"CIRCLECI",
+	"JENKINS_URL",
+	"TF_BUILD",
+	"TEAMCITY_VERSION",
+}
+
 // Config represents the harness configuration
 type Config struct {
+	// SchemaVersion is the config format version, set on save
+	SchemaVersion            int        `json:"schema_version"`
 	Strictness               string     `json:"strictness"`
 	FICEnabled               bool       `json:"fic_enabled"`
 	FICContextTracking       bool       `json:"fic_context_tracking"`
@@ -31,10 +91,363 @@ type Config struct {
 	AutoProgressLogging      bool       `json:"auto_progress_logging"`
 	AutoCheckpointSuggestions bool      `json:"auto_checkpoint_suggestions"`
 	CheckpointIntervalMinutes int       `json:"checkpoint_interval_minutes"`
+	// CheckpointSnapshots saves the harness state at each new commit and
+	// warns, with that snapshot, when HEAD moves back to an earlier one
+	CheckpointSnapshots      bool       `json:"checkpoint_snapshots"`
 	FeatureEnforcement       bool       `json:"feature_enforcement"`
 	InitScriptExecution      bool       `json:"init_script_execution"`
 	BaselineTestsOnStartup   bool       `json:"baseline_tests_on_startup"`
+	// BaselineIncremental limits the session start baseline to the tests
+	// affected since the last green run, with a full run once
+	// BaselineFullRunHours have passed since the last one
+	BaselineIncremental      bool       `json:"baseline_incremental"`
+	BaselineFullRunHours     int        `json:"baseline_full_run_hours,omitempty"`
+	TodoScanOnStartup        bool       `json:"todo_scan_on_startup"`
+	BuildVerification        bool       `json:"build_verification"`
+	BuildCommand             []string   `json:"build_command,omitempty"`
+	BuildTimeoutSeconds      int        `json:"build_timeout_seconds,omitempty"`
+	// TestWorkers and TestPackageTimeoutSeconds tune test_affected, which
+	// runs Go packages' tests in parallel, each with its own timeout
+	TestWorkers              int        `json:"test_workers,omitempty"`
+	TestPackageTimeoutSeconds int       `json:"test_package_timeout_seconds,omitempty"`
+	ChangelogStaging         bool       `json:"changelog_staging"`
+	WriteGuard               bool       `json:"write_guard"`
+	MaxWriteKB               int        `json:"max_write_kb,omitempty"`
+	DependencyGate           bool       `json:"dependency_gate"`
+	// InfraGate holds terraform apply and pulumi up until the stack was
+	// planned successfully within InfraPlanMaxAgeMinutes, and logs plan
+	// summaries to progress
+	InfraGate                bool       `json:"infra_gate"`
+	InfraPlanMaxAgeMinutes   int        `json:"infra_plan_max_age_minutes,omitempty"`
+	// MigrationGate blocks edits to applied database migrations, warns
+	// about migrations without a rollback, and logs new migrations
+	MigrationGate            bool       `json:"migration_gate"`
+	Migrations               *MigrationsConfig `json:"migrations,omitempty"`
+	// ContractCheck diffs edits to OpenAPI specs and .proto files, warns
+	// about breaking API changes, and logs the contract delta
+	ContractCheck            bool       `json:"contract_check"`
+	SyntaxCheck              bool       `json:"syntax_check"`
+	SyntaxTimeoutSeconds     int        `json:"syntax_timeout_seconds,omitempty"`
+	AutoFormat               bool       `json:"auto_format"`
+	ImportCheck              bool       `json:"import_check"`
+	// ChurnAdvisory warns before the first edit of a file that several
+	// authors changed often in recent history
+	ChurnAdvisory            bool       `json:"churn_advisory"`
+	Churn                    *ChurnConfig `json:"churn,omitempty"`
+	// RiskScoring rates each edit LOW, MEDIUM, or HIGH risk; HIGH edits
+	// need a checkpoint commit first in strict mode
+	RiskScoring              bool       `json:"risk_scoring"`
+	Risk                     *RiskConfig `json:"risk,omitempty"`
+	// LoopWatchdog interrupts runaway repetition: identical edits or
+	// commands, and edit and test-fail cycles
+	LoopWatchdog             bool       `json:"loop_watchdog"`
+	Watchdog                 *WatchdogConfig `json:"watchdog,omitempty"`
+	// TestImpact suggests the tests likely affected by each edit
+	TestImpact               bool       `json:"test_impact"`
+	MaxImpactedTests         int        `json:"max_impacted_tests,omitempty"`
+	// BlockerTracking records errors in Bash output in .claude/blockers.json
+	// and carries the unresolved ones across compactions and sessions
+	BlockerTracking          bool       `json:"blocker_tracking"`
+	// TicketPattern is a regular expression for issue-tracker IDs, e.g.
+	// PROJ-\d+; when set, Stop warns about code changed in a session
+	// whose progress entries and commit messages reference no ticket
+	TicketPattern            string     `json:"ticket_pattern,omitempty"`
+	// DriftDetection compares the files the active plan refers to with the
+	// code files changed since it was saved, at Stop
+	DriftDetection           bool       `json:"drift_detection"`
+	// DriftWarnRatio is the share of changed code files outside the plan
+	// above which the drift is a warning
+	DriftWarnRatio           float64    `json:"drift_warn_ratio,omitempty"`
+	// Retrospectives writes a retrospective to .claude/retrospectives.json
+	// and the knowledge base when a feature starts passing
+	Retrospectives           bool       `json:"retrospectives"`
+	// KnowledgeBase keeps discoveries in .claude/knowledge.json across
+	// tasks and injects the relevant ones at session start
+	KnowledgeBase            bool       `json:"knowledge_base"`
+	// KnowledgeTopK is the number of relevant facts injected at a time
+	KnowledgeTopK            int        `json:"knowledge_top_k,omitempty"`
+	// CodebaseMap records what Read and Grep reveal about the codebase in
+	// .claude/codebase-map.json and re-injects it after a compaction
+	CodebaseMap              bool       `json:"codebase_map"`
+	// ProjectTree summarizes the directory structure at session start
+	// while the task is still being explored
+	ProjectTree              bool       `json:"project_tree"`
+	ProjectTreeDepth         int        `json:"project_tree_depth,omitempty"`
+	ImportBoundaries         []ImportBoundary `json:"import_boundaries,omitempty"`
+	// Formatters maps file extensions to formatter commands, overriding the
+	// built-in gofmt/prettier/black/rustfmt; "{file}" is the file path
+	Formatters               map[string][]string `json:"formatters,omitempty"`
+	DoNotEdit                []ProtectedPath `json:"do_not_edit,omitempty"`
 	FICConfig                *FICConfig `json:"fic_config,omitempty"`
+	Budget                   *BudgetConfig `json:"budget,omitempty"`
+	Cost                     *CostConfig   `json:"cost,omitempty"`
+	InitScripts              *InitScriptConfig `json:"init_scripts,omitempty"`
+	Encryption               *EncryptionConfig `json:"encryption,omitempty"`
+	StorageBackend           string            `json:"storage_backend,omitempty"`
+	RemoteSync               *RemoteSyncConfig `json:"remote_sync,omitempty"`
+	// Jira mirrors feature checklist entries to Jira issues
+	Jira                     *JiraConfig       `json:"jira,omitempty"`
+	// Linear moves the Linear issue bound to the task through workflow
+	// states as the FIC phase advances
+	Linear                   *LinearConfig     `json:"linear,omitempty"`
+	LicenseHeader            *LicenseHeaderConfig `json:"license_header,omitempty"`
+	CodeOwners               *CodeOwnersConfig    `json:"code_owners,omitempty"`
+	ContextFiles             *ContextFilesConfig  `json:"context_files,omitempty"`
+	CodeFiles                *CodeFilesConfig     `json:"code_files,omitempty"`
+	SessionStartMaxTokens    int                  `json:"session_start_max_tokens,omitempty"`
+	// SubagentOutputMaxTokens is the largest subagent output summarized in
+	// full; larger outputs are saved to a file and only excerpted
+	SubagentOutputMaxTokens  int                  `json:"subagent_output_max_tokens,omitempty"`
+	// LargeReadLines is the longest file a Read may take in full without a
+	// suggestion to read it in ranges
+	LargeReadLines           int                  `json:"large_read_lines,omitempty"`
+	OutputVerbosity          string               `json:"output_verbosity,omitempty"`
+	Hooks                    map[string]HookConfig `json:"hooks,omitempty"`
+	// GlobalStats appends each session's stats to the user-level store
+	// shared by all projects (~/.ultraharness/stats.jsonl)
+	GlobalStats              bool                 `json:"global_stats"`
+	// DailyLog appends a line per session to .claude/daily-log.md under a
+	// heading per day
+	DailyLog                 bool                 `json:"daily_log"`
+	// HookLog appends one line per hook run to .claude/hook-log.jsonl
+	HookLog                  bool                 `json:"hook_log"`
+	// HookLogInputs adds each run's input and output to the hook log, so
+	// the runs can be replayed; the inputs include file contents
+	HookLogInputs            bool                 `json:"hook_log_inputs"`
+	// ErrorCodes shows a terse code line, e.g. "[FIC:E012] state
+	// unreadable", for errors the hooks recover from; their codes are
+	// logged either way
+	ErrorCodes               bool                 `json:"error_codes"`
+	// DryRun reports hook decisions without enforcing them
+	DryRun                   bool                 `json:"dry_run"`
+	// Headless withholds compaction directives, reminders, and non-blocking
+	// warnings from CI sessions and records them in the hook log instead:
+	// auto, on, or off
+	Headless                 string               `json:"headless,omitempty"`
+	// PhaseGuidance holds project instructions per FIC phase (or "all"),
+	// delivered with the built-in phase guidance
+	PhaseGuidance            map[string]string    `json:"phase_guidance,omitempty"`
+	// Locale selects the language of injected guidance, e.g. "es"; unset
+	// or unsupported languages use English
+	Locale                   string               `json:"locale,omitempty"`
+}
+
+// ImportBoundary forbids Go packages matching From from importing packages
+// matching any Deny pattern. Patterns are globs over package directories
+// relative to the module root, e.g. "internal/**" or "cmd/**"
+type ImportBoundary struct {
+	From   string   `json:"from"`
+	Deny   []string `json:"deny"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// ProtectedPath marks files the agent must not edit directly, such as
+// build output, vendored code, or generated sources
+type ProtectedPath struct {
+	// Pattern is a gitignore-style glob, e.g. "dist/**" or "*.pb.go"
+	Pattern string `json:"pattern"`
+	// Source tells the agent where to make the change instead
+	Source string `json:"source,omitempty"`
+}
+
+// DefaultDoNotEdit is used when do_not_edit is not configured
+var DefaultDoNotEdit = []ProtectedPath{
+	{Pattern: "dist/**", Source: "dist/ is build output. Edit the source files and rebuild."},
+	{Pattern: "vendor/**", Source: "vendor/ holds third-party code. Update the dependency through the package manager instead."},
+	{Pattern: "node_modules/**", Source: "node_modules/ is installed by the package manager. Update the dependency instead."},
+	{Pattern: "*_generated.go", Source: "This file is generated. Edit the generator input and regenerate (see its go:generate directive)."},
+	{Pattern: "*.pb.go", Source: "This file is generated by protoc. Edit the .proto file and regenerate."},
+}
+
+// HookConfig configures a single hook
+type HookConfig struct {
+	// Enabled turns the hook off when false (default true)
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// ContextFilesConfig lists project files embedded in every SessionStart
+// message, such as ARCHITECTURE.md or CONVENTIONS.md
+type ContextFilesConfig struct {
+	Files []string `json:"files"`
+	// MaxBytesPerFile limits each file; larger files are reduced to an
+	// outline (default 6000)
+	MaxBytesPerFile int `json:"max_bytes_per_file,omitempty"`
+	// MaxTotalBytes limits all files together (default 16000)
+	MaxTotalBytes int `json:"max_total_bytes,omitempty"`
+}
+
+// LicenseHeaderConfig requires a license or copyright header on new
+// source files
+type LicenseHeaderConfig struct {
+	// Template is the header text without comment markers; "{{year}}"
+	// matches any year
+	Template string `json:"template"`
+	// Extensions limits the check to these file extensions, e.g. ".go"
+	// (default: common code file extensions)
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+// CodeFilesConfig extends the built-in rules for which files count as code
+// (the Stop hook's tests-run check) and which Bash commands are logged to
+// progress
+type CodeFilesConfig struct {
+	// Extensions counted as code besides the built-in ones, e.g. ".sql",
+	// ".tf", or ".proto"
+	Extensions []string `json:"extensions,omitempty"`
+	// Exclude lists globs of files never counted as code, e.g. "*.pb.go"
+	Exclude []string `json:"exclude,omitempty"`
+	// SignificantCommands are regular expressions for Bash commands logged
+	// to progress besides tests, builds, and deploys, e.g.
+	// "terraform (plan|apply)"; invalid ones are ignored
+	SignificantCommands []string `json:"significant_commands,omitempty"`
+}
+
+// MigrationsConfig locates database migrations and tells which are applied
+type MigrationsConfig struct {
+	// Paths are globs of migration files (default: migrations/,
+	// db/migrate/, db/migrations/, and alembic/versions/)
+	Paths []string `json:"paths,omitempty"`
+	// AppliedMarker is a file listing the applied migrations, one name or
+	// version per line, e.g. exported from the schema_migrations table
+	AppliedMarker string `json:"applied_marker,omitempty"`
+	// AppliedThrough is the last applied version; migrations whose version
+	// is at or below it count as applied
+	AppliedThrough string `json:"applied_through,omitempty"`
+}
+
+// CodeOwnersConfig identifies the user to the CODEOWNERS check. Edits to
+// paths owned by none of the identities are flagged.
+type CodeOwnersConfig struct {
+	// Identities are CODEOWNERS owners the user belongs to, such as
+	// "@alice" or "@org/team"; the git user.email is always included
+	Identities []string `json:"identities"`
+}
+
+// RemoteSyncConfig controls syncing of FIC artifacts and the feature
+// checklist with a shared remote store
+type RemoteSyncConfig struct {
+	Enabled bool `json:"enabled"`
+	// URL is the base URL objects are stored under, e.g.
+	// "https://state.example.com/ultraharness" or
+	// "https://s3.us-east-1.amazonaws.com/bucket/prefix"
+	URL string `json:"url"`
+	// Provider is "http" (bearer token) or "s3" (AWS Signature V4)
+	Provider string `json:"provider,omitempty"`
+	// TokenEnv names the environment variable holding the bearer token
+	TokenEnv string `json:"token_env,omitempty"`
+	// Region is the S3 signing region (default us-east-1)
+	Region string `json:"region,omitempty"`
+}
+
+// JiraConfig maps feature checklist entries to Jira issues: summaries and
+// statuses are pulled into the checklist and progress milestones are
+// posted as issue comments
+type JiraConfig struct {
+	Enabled bool `json:"enabled"`
+	// BaseURLEnv names the environment variable holding the site URL, e.g.
+	// "https://example.atlassian.net"
+	BaseURLEnv string `json:"base_url_env,omitempty"`
+	// EmailEnv and TokenEnv name the environment variables holding the
+	// account email and API token
+	EmailEnv string `json:"email_env,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+	// Issues maps feature IDs to issue keys; a feature whose ID is an issue
+	// key, such as PROJ-12, maps to that issue without an entry
+	Issues map[string]string `json:"issues,omitempty"`
+	// Fields maps feature fields (name, description, category) to the Jira
+	// fields they are pulled from
+	Fields map[string]string `json:"fields,omitempty"`
+	// Statuses maps Jira status names to checklist statuses; issues in
+	// other statuses leave the feature's status alone
+	Statuses map[string]string `json:"statuses,omitempty"`
+	// MilestonePattern is a regular expression for the progress entries
+	// posted as comments on the issues they mention
+	MilestonePattern string `json:"milestone_pattern,omitempty"`
+}
+
+// LinearConfig maps FIC phases to the workflow states of the Linear issue
+// bound to the current task
+type LinearConfig struct {
+	Enabled bool `json:"enabled"`
+	// TokenEnv names the environment variable holding the API key
+	TokenEnv string `json:"token_env,omitempty"`
+	// URL is the GraphQL endpoint
+	URL string `json:"url,omitempty"`
+	// States maps FIC phases (research, planning, implementation, and
+	// review, once prepush passes) to workflow state names; the issue stays
+	// where it is in unmapped phases
+	States map[string]string `json:"states,omitempty"`
+}
+
+// EncryptionConfig controls encryption of harness state files at rest
+type EncryptionConfig struct {
+	Enabled bool `json:"enabled"`
+	// KeySource is "env" (ULTRAHARNESS_STATE_KEY) or "keychain"
+	KeySource string `json:"key_source,omitempty"`
+}
+
+// InitScriptConfig controls execution of init.sh and .claude/init.d scripts
+type InitScriptConfig struct {
+	// TimeoutSeconds applies to each script (default 60)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// ScriptTimeouts overrides the timeout for scripts by file name
+	ScriptTimeouts map[string]int `json:"script_timeouts,omitempty"`
+	// EnvAllowlist lists environment variables passed to scripts; entries
+	// ending in "*" match by prefix. Empty uses the built-in allowlist.
+	EnvAllowlist []string `json:"env_allowlist,omitempty"`
+	// CacheMaxAgeHours reuses successful results of unchanged scripts (default 24)
+	CacheMaxAgeHours int `json:"cache_max_age_hours,omitempty"`
+	// Force runs scripts on every session start, ignoring the cache
+	Force bool `json:"force,omitempty"`
+}
+
+// BudgetConfig contains per-session budget limits. Zero means unlimited.
+type BudgetConfig struct {
+	MaxToolCalls     int `json:"max_tool_calls"`
+	MaxWallMinutes   int `json:"max_wall_minutes"`
+	MaxFilesModified int `json:"max_files_modified"`
+	// MaxLinesChanged caps lines added plus removed by Edit/Write
+	MaxLinesChanged int `json:"max_lines_changed,omitempty"`
+	// MaxSubagents caps Task calls per session, and MaxConcurrentSubagents
+	// the Task calls running at once
+	MaxSubagents           int `json:"max_subagents,omitempty"`
+	MaxConcurrentSubagents int `json:"max_concurrent_subagents,omitempty"`
+
+	// Fraction of a limit at which to start warning (default 0.8)
+	WarnRatio float64 `json:"warn_ratio,omitempty"`
+}
+
+// ChurnConfig tunes the churn advisory
+type ChurnConfig struct {
+	// Days of git history analyzed (default 90)
+	WindowDays int `json:"window_days,omitempty"`
+	// A file is conflict-prone with at least MinCommits commits (default 5)
+	// by at least MinAuthors authors (default 2) in the window
+	MinCommits int `json:"min_commits,omitempty"`
+	MinAuthors int `json:"min_authors,omitempty"`
+}
+
+// RiskConfig tunes edit risk scoring
+type RiskConfig struct {
+	// Globs of critical files, such as auth, billing, or migrations
+	CriticalPaths []string `json:"critical_paths,omitempty"`
+	// Coverage profile (Go cover profile or LCOV); found automatically if
+	// unset
+	CoverageFile string `json:"coverage_file,omitempty"`
+	// Days of git history searched for fix and revert commits (default 90)
+	IncidentWindowDays int `json:"incident_window_days,omitempty"`
+	// Uncommitted lines in a file that make its diff large (default 200)
+	LargeDiffLines int `json:"large_diff_lines,omitempty"`
+}
+
+// WatchdogConfig sets when the watchdog reports a loop
+type WatchdogConfig struct {
+	// The same edit applied this many times (default 3)
+	RepeatedEdits int `json:"repeated_edits,omitempty"`
+	// The same Bash command run this many times (default 5)
+	RepeatedCommands int `json:"repeated_commands,omitempty"`
+	// This many edits each followed by failing tests in a row (default 4)
+	FailCycles int `json:"fail_cycles,omitempty"`
 }
 
 // FICConfig contains FIC-specific configuration
@@ -52,20 +465,98 @@ type FICConfig struct {
 	ResearchConfidenceThreshold float64 `json:"research_confidence_threshold"`
 	MaxOpenQuestions            int     `json:"max_open_questions"`
 
+	// Redundancy detection: repeats of the same Read/Grep/Glob before warning
+	RedundancyThreshold int `json:"redundancy_threshold"`
+
+	// Broad searches: a Grep or Glob returning more than this many results,
+	// repeatedly, gets advice to narrow the pattern or delegate
+	BroadSearchResults int `json:"broad_search_results"`
+
+	// Context pressure: above target_utilization_high, Task launches and
+	// full reads of long files get cheaper alternatives suggested; above
+	// auto_compact_threshold, Task launches can be denied until compaction
+	PressureGating        bool `json:"pressure_gating"`
+	DenyTasksWhenCritical bool `json:"deny_tasks_when_critical"`
+
 	// Gate behavior customization
 	WarnOnResearchIncomplete bool `json:"warn_on_research_incomplete"`
 	WarnOnPlanIncomplete     bool `json:"warn_on_plan_incomplete"`
 	BlockInStrictMode        bool `json:"block_in_strict_mode"`
 
+	// Queue research prompts that arrive during implementation instead of
+	// delegating them right away
+	DeferResearchInImplementation bool `json:"defer_research_in_implementation"`
+
+	// Extra regular expressions, matched case-insensitively, that mark a
+	// prompt as research or planning in addition to the built-in ones
+	ResearchPatterns []string `json:"research_patterns,omitempty"`
+	PlanningPatterns []string `json:"planning_patterns,omitempty"`
+
 	// Parallel implementation settings
 	ParallelImplementationEnabled bool `json:"parallel_implementation_enabled"`
 	MaxParallelAgents             int  `json:"max_parallel_agents"`
 	MinStepsForParallel           int  `json:"min_steps_for_parallel"`
+
+	// Strictness escalation by session age
+	StrictnessEscalation *StrictnessEscalation `json:"strictness_escalation,omitempty"`
+
+	// Research confidence decay as the codebase changes
+	ConfidenceDecay *ConfidenceDecay `json:"confidence_decay,omitempty"`
+}
+
+// StrictnessEscalation starts each session in relaxed mode and tightens
+// the strictness as the session's tool calls grow, so early exploration is
+// not nagged but long unplanned change sprees are reined in. It does not
+// apply in review mode.
+type StrictnessEscalation struct {
+	// StandardAfter is the tool call count that switches to standard mode
+	// (0: stay relaxed until StrictAfter)
+	StandardAfter int `json:"standard_after"`
+	// StrictAfter is the tool call count that switches to strict mode
+	// (0: never)
+	StrictAfter int `json:"strict_after"`
+}
+
+// ConfidenceDecay erodes a research artifact's confidence as time passes
+// and commits change the files it rests on, so research that was enough to
+// plan with can fall back below the research gate.
+type ConfidenceDecay struct {
+	// PerWeek is the confidence lost per week since the research was
+	// written
+	PerWeek float64 `json:"per_week"`
+	// PerCommit is the confidence lost per commit since then to the
+	// research's relevant files or the files its assumptions depend on
+	PerCommit float64 `json:"per_commit"`
+}
+
+// CostConfig controls session cost estimation
+type CostConfig struct {
+	// Model selects an entry from Prices (or the built-in price table)
+	Model string `json:"model"`
+	// Prices overrides or extends the built-in per-model prices
+	Prices map[string]ModelPrice `json:"prices,omitempty"`
+}
+
+// ModelPrice is the USD price per million tokens for a model
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// DefaultCostModel is the model used for cost estimation when none is configured
+const DefaultCostModel = "sonnet"
+
+// DefaultModelPrices contains built-in list prices per model family
+var DefaultModelPrices = map[string]ModelPrice{
+	"haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4.00},
+	"sonnet": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"opus":   {InputPerMillion: 15.00, OutputPerMillion: 75.00},
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
+		SchemaVersion:            Schema.Version(),
 		Strictness:               StrictnessStandard,
 		FICEnabled:               true,
 		FICContextTracking:       true,
@@ -73,9 +564,30 @@ func DefaultConfig() *Config {
 		AutoProgressLogging:      true,
 		AutoCheckpointSuggestions: true,
 		CheckpointIntervalMinutes: 30,
+		CheckpointSnapshots:      true,
 		FeatureEnforcement:       true,
 		InitScriptExecution:      true,
 		BaselineTestsOnStartup:   true,
+		BaselineIncremental:      true,
+		TodoScanOnStartup:        true,
+		BuildVerification:        true,
+		WriteGuard:               true,
+		DependencyGate:           true,
+		InfraGate:                true,
+		MigrationGate:            true,
+		ContractCheck:            true,
+		SyntaxCheck:              true,
+		ImportCheck:              true,
+		ChurnAdvisory:            true,
+		RiskScoring:              true,
+		TestImpact:               true,
+		LoopWatchdog:             true,
+		BlockerTracking:          true,
+		DriftDetection:           true,
+		Retrospectives:           true,
+		KnowledgeBase:            true,
+		CodebaseMap:              true,
+		ProjectTree:              true,
 		FICConfig: &FICConfig{
 			AutoCompactThreshold:        0.85,
 			CompactionToolThreshold:     50,
@@ -84,16 +596,28 @@ func DefaultConfig() *Config {
 			AutoCompactEnabled:          true,
 			ResearchConfidenceThreshold: 0.70,
 			MaxOpenQuestions:            2,
+			RedundancyThreshold:         3,
+			BroadSearchResults:          200,
+			PressureGating:              true,
 			WarnOnResearchIncomplete:      true,
+			DeferResearchInImplementation: true,
 			WarnOnPlanIncomplete:          true,
 			BlockInStrictMode:             true,
 			ParallelImplementationEnabled: true,
 			MaxParallelAgents:             3,
 			MinStepsForParallel:           3,
+			ConfidenceDecay:               &ConfidenceDecay{PerWeek: 0.05, PerCommit: 0.02},
 		},
 	}
 }
 
+// Schema is the config file format. Append a migration here when the
+// format changes incompatibly.
+var Schema = &schema.Format{
+	Name:       ConfigFileName,
+	Migrations: []schema.Migration{schema.Unversioned},
+}
+
 // Load reads the config file from the given working directory.
 // Returns default config if file doesn't exist.
 func Load(workDir string) (*Config, error) {
@@ -103,7 +627,7 @@ func Load(workDir string) (*Config, error) {
 
 	configPath := filepath.Join(workDir, ".claude", ConfigFileName)
 
-	data, err := os.ReadFile(configPath)
+	data, err := filecache.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return DefaultConfig(), nil
@@ -112,7 +636,7 @@ func Load(workDir string) (*Config, error) {
 	}
 
 	config := DefaultConfig()
-	if err := json.Unmarshal(data, config); err != nil {
+	if err := Schema.Unmarshal(data, config); err != nil {
 		return nil, err
 	}
 
@@ -135,9 +659,14 @@ func (c *Config) IsRelaxedMode() bool {
 	return c.Strictness == StrictnessRelaxed
 }
 
-// IsStrictMode returns true if strictness is strict
+// IsStrictMode returns true if strictness is strict or review
 func (c *Config) IsStrictMode() bool {
-	return c.Strictness == StrictnessStrict
+	return c.Strictness == StrictnessStrict || c.Strictness == StrictnessReview
+}
+
+// IsReviewMode returns true if new files require human approval
+func (c *Config) IsReviewMode() bool {
+	return c.Strictness == StrictnessReview
 }
 
 // IsStandardMode returns true if strictness is standard
@@ -145,6 +674,126 @@ func (c *Config) IsStandardMode() bool {
 	return c.Strictness == StrictnessStandard || c.Strictness == ""
 }
 
+// GetStrictnessEscalation returns the strictness escalation schedule, or
+// nil if there is none or the harness is in review mode
+func (c *Config) GetStrictnessEscalation() *StrictnessEscalation {
+	if c.FICConfig == nil || c.Strictness == StrictnessReview {
+		return nil
+	}
+	e := c.FICConfig.StrictnessEscalation
+	if e == nil || (e.StandardAfter <= 0 && e.StrictAfter <= 0) {
+		return nil
+	}
+	return e
+}
+
+// GetConfidenceDecay returns the research confidence decay rates, or nil
+// if confidence does not decay
+func (c *Config) GetConfidenceDecay() *ConfidenceDecay {
+	if c.FICConfig == nil {
+		return nil
+	}
+	d := c.FICConfig.ConfidenceDecay
+	if d == nil || (d.PerWeek <= 0 && d.PerCommit <= 0) {
+		return nil
+	}
+	return d
+}
+
+// EscalatedStrictness returns the strictness for a session that has made
+// toolCalls tool calls under the escalation schedule, or the configured
+// strictness if there is no schedule
+func (c *Config) EscalatedStrictness(toolCalls int) string {
+	e := c.GetStrictnessEscalation()
+	if e == nil {
+		return c.Strictness
+	}
+	switch {
+	case e.StrictAfter > 0 && toolCalls >= e.StrictAfter:
+		return StrictnessStrict
+	case e.StandardAfter > 0 && toolCalls >= e.StandardAfter:
+		return StrictnessStandard
+	}
+	return StrictnessRelaxed
+}
+
+// Escalate sets the strictness for a session that has made toolCalls tool
+// calls, and returns true if it differs from the configured strictness
+func (c *Config) Escalate(toolCalls int) bool {
+	level := c.EscalatedStrictness(toolCalls)
+	changed := level != c.Strictness
+	c.Strictness = level
+	return changed
+}
+
+// IsHookEnabled returns false if the named hook is disabled in the "hooks"
+// section; hooks are enabled by default
+func (c *Config) IsHookEnabled(name string) bool {
+	hook, ok := c.Hooks[name]
+	return !ok || hook.Enabled == nil || *hook.Enabled
+}
+
+// GetOutputVerbosity returns the output verbosity, defaulting to normal
+func (c *Config) GetOutputVerbosity() string {
+	switch c.OutputVerbosity {
+	case VerbosityQuiet, VerbosityVerbose:
+		return c.OutputVerbosity
+	}
+	return VerbosityNormal
+}
+
+// IsQuiet returns true if hooks should keep their output to a minimum
+func (c *Config) IsQuiet() bool {
+	return c.GetOutputVerbosity() == VerbosityQuiet
+}
+
+// IsVerbose returns true if hooks should include diagnostic detail
+func (c *Config) IsVerbose() bool {
+	return c.GetOutputVerbosity() == VerbosityVerbose
+}
+
+// GetHeadless returns the headless mode, defaulting to auto
+func (c *Config) GetHeadless() string {
+	switch c.Headless {
+	case HeadlessOn, HeadlessOff:
+		return c.Headless
+	}
+	return HeadlessAuto
+}
+
+// GetLocale returns the locale of injected guidance, defaulting to English
+// when unset or not a language tag
+func (c *Config) GetLocale() string {
+	if !localePattern.MatchString(c.Locale) {
+		return DefaultLocale
+	}
+	return c.Locale
+}
+
+// IsHeadless returns true if nobody is watching the session, either
+// because headless mode is on or because it is auto and this is a CI run
+func (c *Config) IsHeadless() bool {
+	switch c.GetHeadless() {
+	case HeadlessOn:
+		return true
+	case HeadlessOff:
+		return false
+	}
+	return InCI()
+}
+
+// InCI reports whether one of CIEnvVars is set
+func InCI() bool {
+	for _, name := range CIEnvVars {
+		switch strings.ToLower(os.Getenv(name)) {
+		case "", "false", "0":
+		default:
+			return true
+		}
+	}
+	return false
+}
+
 // GetAutoCompactThreshold returns the auto-compact threshold
 func (c *Config) GetAutoCompactThreshold() float64 {
 	if c.FICConfig != nil && c.FICConfig.AutoCompactThreshold > 0 {
@@ -153,6 +802,24 @@ func (c *Config) GetAutoCompactThreshold() float64 {
 	return 0.85
 }
 
+// GetTargetUtilizationLow returns the utilization below which the context
+// is considered fresh
+func (c *Config) GetTargetUtilizationLow() float64 {
+	if c.FICConfig != nil && c.FICConfig.TargetUtilizationLow > 0 {
+		return c.FICConfig.TargetUtilizationLow
+	}
+	return 0.40
+}
+
+// GetTargetUtilizationHigh returns the utilization above which the
+// context is under pressure
+func (c *Config) GetTargetUtilizationHigh() float64 {
+	if c.FICConfig != nil && c.FICConfig.TargetUtilizationHigh > 0 {
+		return c.FICConfig.TargetUtilizationHigh
+	}
+	return 0.60
+}
+
 // GetCompactionToolThreshold returns the compaction tool threshold
 func (c *Config) GetCompactionToolThreshold() int {
 	if c.FICConfig != nil && c.FICConfig.CompactionToolThreshold > 0 {
@@ -177,6 +844,24 @@ func (c *Config) GetMaxOpenQuestions() int {
 	return 2
 }
 
+// GetRedundancyThreshold returns how many times the same file or search may
+// be repeated before it is reported as redundant
+func (c *Config) GetRedundancyThreshold() int {
+	if c.FICConfig != nil && c.FICConfig.RedundancyThreshold > 0 {
+		return c.FICConfig.RedundancyThreshold
+	}
+	return 3
+}
+
+// GetBroadSearchResults returns how many results make a Grep or Glob too
+// broad
+func (c *Config) GetBroadSearchResults() int {
+	if c.FICConfig != nil && c.FICConfig.BroadSearchResults > 0 {
+		return c.FICConfig.BroadSearchResults
+	}
+	return 200
+}
+
 // IsAutoCompactEnabled returns whether auto-compaction is enabled
 func (c *Config) IsAutoCompactEnabled() bool {
 	if c.FICConfig != nil {
@@ -185,6 +870,21 @@ func (c *Config) IsAutoCompactEnabled() bool {
 	return true // Enabled by default
 }
 
+// IsPressureGatingEnabled returns whether expensive tools get cheaper
+// alternatives suggested while the context is under pressure
+func (c *Config) IsPressureGatingEnabled() bool {
+	if c.FICConfig != nil {
+		return c.FICConfig.PressureGating
+	}
+	return true
+}
+
+// ShouldDenyTasksWhenCritical returns whether Task launches are denied
+// above the auto-compact threshold until the context is compacted
+func (c *Config) ShouldDenyTasksWhenCritical() bool {
+	return c.FICConfig != nil && c.FICConfig.DenyTasksWhenCritical
+}
+
 // ShouldWarnOnResearchIncomplete returns whether to warn when research is incomplete
 func (c *Config) ShouldWarnOnResearchIncomplete() bool {
 	if c.FICConfig != nil {
@@ -193,6 +893,33 @@ func (c *Config) ShouldWarnOnResearchIncomplete() bool {
 	return true
 }
 
+// ShouldDeferResearch returns whether research prompts are queued during
+// implementation
+func (c *Config) ShouldDeferResearch() bool {
+	if c.FICConfig != nil {
+		return c.FICConfig.DeferResearchInImplementation
+	}
+	return true
+}
+
+// GetResearchPatterns returns the configured extra research prompt
+// patterns.
+func (c *Config) GetResearchPatterns() []string {
+	if c.FICConfig != nil {
+		return c.FICConfig.ResearchPatterns
+	}
+	return nil
+}
+
+// GetPlanningPatterns returns the configured extra planning prompt
+// patterns.
+func (c *Config) GetPlanningPatterns() []string {
+	if c.FICConfig != nil {
+		return c.FICConfig.PlanningPatterns
+	}
+	return nil
+}
+
 // ShouldWarnOnPlanIncomplete returns whether to warn when plan is incomplete
 func (c *Config) ShouldWarnOnPlanIncomplete() bool {
 	if c.FICConfig != nil {
@@ -209,6 +936,352 @@ func (c *Config) ShouldBlockInStrictMode() bool {
 	return true
 }
 
+// GetBudget returns the session budget limits (all zero if unconfigured)
+func (c *Config) GetBudget() BudgetConfig {
+	budget := BudgetConfig{}
+	if c.Budget != nil {
+		budget = *c.Budget
+	}
+	if budget.WarnRatio <= 0 || budget.WarnRatio > 1.0 {
+		budget.WarnRatio = 0.8
+	}
+	return budget
+}
+
+// GetInitScriptConfig returns the init script settings
+func (c *Config) GetInitScriptConfig() InitScriptConfig {
+	initScripts := InitScriptConfig{}
+	if c.InitScripts != nil {
+		initScripts = *c.InitScripts
+	}
+	if initScripts.TimeoutSeconds <= 0 {
+		initScripts.TimeoutSeconds = 60
+	}
+	if initScripts.CacheMaxAgeHours <= 0 {
+		initScripts.CacheMaxAgeHours = 24
+	}
+	return initScripts
+}
+
+// GetCodeFiles returns the code file and significant command settings
+func (c *Config) GetCodeFiles() CodeFilesConfig {
+	if c.CodeFiles != nil {
+		return *c.CodeFiles
+	}
+	return CodeFilesConfig{}
+}
+
+// GetChurn returns the churn advisory settings
+func (c *Config) GetChurn() ChurnConfig {
+	churn := ChurnConfig{}
+	if c.Churn != nil {
+		churn = *c.Churn
+	}
+	if churn.WindowDays <= 0 {
+		churn.WindowDays = 90
+	}
+	if churn.MinCommits <= 0 {
+		churn.MinCommits = 5
+	}
+	if churn.MinAuthors <= 0 {
+		churn.MinAuthors = 2
+	}
+	return churn
+}
+
+// GetRisk returns the edit risk scoring settings
+func (c *Config) GetRisk() RiskConfig {
+	risk := RiskConfig{}
+	if c.Risk != nil {
+		risk = *c.Risk
+	}
+	if risk.IncidentWindowDays <= 0 {
+		risk.IncidentWindowDays = 90
+	}
+	if risk.LargeDiffLines <= 0 {
+		risk.LargeDiffLines = 200
+	}
+	return risk
+}
+
+// GetBuildTimeoutSeconds returns the build verification timeout
+func (c *Config) GetBuildTimeoutSeconds() int {
+	if c.BuildTimeoutSeconds > 0 {
+		return c.BuildTimeoutSeconds
+	}
+	return 90
+}
+
+// GetBaselineFullRunHours returns how often the baseline runs every test
+// instead of only the affected ones, by default nightly
+func (c *Config) GetBaselineFullRunHours() int {
+	if c.BaselineFullRunHours > 0 {
+		return c.BaselineFullRunHours
+	}
+	return 24
+}
+
+// GetTestWorkers returns how many Go packages test_affected tests at once,
+// by default one per CPU
+func (c *Config) GetTestWorkers() int {
+	if c.TestWorkers > 0 {
+		return c.TestWorkers
+	}
+	return runtime.NumCPU()
+}
+
+// GetTestPackageTimeoutSeconds returns the timeout for each Go package's
+// tests in test_affected
+func (c *Config) GetTestPackageTimeoutSeconds() int {
+	if c.TestPackageTimeoutSeconds > 0 {
+		return c.TestPackageTimeoutSeconds
+	}
+	return 120
+}
+
+// GetDoNotEdit returns the protected path rules. An explicitly empty
+// do_not_edit list disables the guard.
+func (c *Config) GetDoNotEdit() []ProtectedPath {
+	if c.DoNotEdit == nil {
+		return DefaultDoNotEdit
+	}
+	return c.DoNotEdit
+}
+
+// GetSyntaxTimeoutSeconds returns the timeout for post-edit syntax checks
+func (c *Config) GetSyntaxTimeoutSeconds() int {
+	if c.SyntaxTimeoutSeconds > 0 {
+		return c.SyntaxTimeoutSeconds
+	}
+	return 5
+}
+
+// GetMaxWriteKB returns the largest Write content allowed by the write guard
+func (c *Config) GetMaxWriteKB() int {
+	if c.MaxWriteKB > 0 {
+		return c.MaxWriteKB
+	}
+	return 500
+}
+
+// GetInfraPlanMaxAgeMinutes returns how long a successful plan allows its
+// stack to be applied
+func (c *Config) GetInfraPlanMaxAgeMinutes() int {
+	if c.InfraPlanMaxAgeMinutes > 0 {
+		return c.InfraPlanMaxAgeMinutes
+	}
+	return 30
+}
+
+// GetMigrations returns the migration gate settings
+func (c *Config) GetMigrations() MigrationsConfig {
+	migrations := MigrationsConfig{}
+	if c.Migrations != nil {
+		migrations = *c.Migrations
+	}
+	if len(migrations.Paths) == 0 {
+		migrations.Paths = []string{"migrations/", "db/migrate/", "db/migrations/", "alembic/versions/"}
+	}
+	return migrations
+}
+
+// GetWatchdog returns the loop watchdog thresholds
+func (c *Config) GetWatchdog() WatchdogConfig {
+	watchdog := WatchdogConfig{}
+	if c.Watchdog != nil {
+		watchdog = *c.Watchdog
+	}
+	if watchdog.RepeatedEdits <= 0 {
+		watchdog.RepeatedEdits = 3
+	}
+	if watchdog.RepeatedCommands <= 0 {
+		watchdog.RepeatedCommands = 5
+	}
+	if watchdog.FailCycles <= 0 {
+		watchdog.FailCycles = 4
+	}
+	return watchdog
+}
+
+// GetMaxImpactedTests returns the number of test files suggested after an
+// edit
+func (c *Config) GetMaxImpactedTests() int {
+	if c.MaxImpactedTests > 0 {
+		return c.MaxImpactedTests
+	}
+	return 3
+}
+
+// GetEncryption returns the state encryption settings
+func (c *Config) GetEncryption() EncryptionConfig {
+	enc := EncryptionConfig{}
+	if c.Encryption != nil {
+		enc = *c.Encryption
+	}
+	if enc.KeySource == "" {
+		enc.KeySource = "env"
+	}
+	return enc
+}
+
+// GetRemoteSync returns the remote sync settings
+func (c *Config) GetRemoteSync() RemoteSyncConfig {
+	rs := RemoteSyncConfig{}
+	if c.RemoteSync != nil {
+		rs = *c.RemoteSync
+	}
+	if rs.Provider == "" {
+		rs.Provider = "http"
+	}
+	if rs.TokenEnv == "" {
+		rs.TokenEnv = "ULTRAHARNESS_REMOTE_TOKEN"
+	}
+	if rs.Region == "" {
+		rs.Region = "us-east-1"
+	}
+	return rs
+}
+
+// GetSessionStartMaxTokens returns the token budget for the SessionStart
+// message. Negative values disable the budget.
+func (c *Config) GetSessionStartMaxTokens() int {
+	if c.SessionStartMaxTokens != 0 {
+		return c.SessionStartMaxTokens
+	}
+	return 6000
+}
+
+// GetSubagentOutputMaxTokens returns the largest subagent output passed
+// on in full. Negative values disable the limit.
+func (c *Config) GetSubagentOutputMaxTokens() int {
+	if c.SubagentOutputMaxTokens != 0 {
+		return c.SubagentOutputMaxTokens
+	}
+	return 4000
+}
+
+// GetLargeReadLines returns the longest file a Read may take in full
+// without advice to read it in ranges. Negative values disable the advice.
+func (c *Config) GetLargeReadLines() int {
+	if c.LargeReadLines != 0 {
+		return c.LargeReadLines
+	}
+	return 3000
+}
+
+// GetDriftWarnRatio returns the share of changed code files the plan does
+// not refer to above which Stop warns about drift.
+func (c *Config) GetDriftWarnRatio() float64 {
+	if c.DriftWarnRatio > 0 {
+		return c.DriftWarnRatio
+	}
+	return 0.5
+}
+
+// GetJira returns the Jira settings. Fields and Statuses replace the
+// defaults when set.
+func (c *Config) GetJira() JiraConfig {
+	j := JiraConfig{}
+	if c.Jira != nil {
+		j = *c.Jira
+	}
+	if j.BaseURLEnv == "" {
+		j.BaseURLEnv = "JIRA_BASE_URL"
+	}
+	if j.EmailEnv == "" {
+		j.EmailEnv = "JIRA_EMAIL"
+	}
+	if j.TokenEnv == "" {
+		j.TokenEnv = "JIRA_API_TOKEN"
+	}
+	if len(j.Fields) == 0 {
+		j.Fields = map[string]string{"name": "summary", "description": "description"}
+	}
+	if len(j.Statuses) == 0 {
+		// Done is left out: a feature passes only once it is verified
+		j.Statuses = map[string]string{"To Do": "pending", "In Progress": "in_progress"}
+	}
+	if j.MilestonePattern == "" {
+		j.MilestonePattern = `(?i)^(VERIFIED|Research complete|HANDOFF|Milestone|Completed?|Done)\b`
+	}
+	return j
+}
+
+// GetLinear returns the Linear settings. States replaces the default
+// mapping when set.
+func (c *Config) GetLinear() LinearConfig {
+	l := LinearConfig{}
+	if c.Linear != nil {
+		l = *c.Linear
+	}
+	if l.TokenEnv == "" {
+		l.TokenEnv = "LINEAR_API_KEY"
+	}
+	if l.URL == "" {
+		l.URL = "https://api.linear.app/graphql"
+	}
+	if len(l.States) == 0 {
+		l.States = map[string]string{"research": "Research", "implementation": "In Progress", "review": "In Review"}
+	}
+	return l
+}
+
+// GetKnowledgeTopK returns the number of knowledge base facts injected
+// at session start or with a prompt.
+func (c *Config) GetKnowledgeTopK() int {
+	if c.KnowledgeTopK > 0 {
+		return c.KnowledgeTopK
+	}
+	return 5
+}
+
+// GetProjectTreeDepth returns how many directory levels the project tree
+// shows below the root.
+func (c *Config) GetProjectTreeDepth() int {
+	if c.ProjectTreeDepth > 0 {
+		return c.ProjectTreeDepth
+	}
+	return 2
+}
+
+// GetContextFiles returns the context file settings with defaults applied
+func (c *Config) GetContextFiles() ContextFilesConfig {
+	cf := ContextFilesConfig{}
+	if c.ContextFiles != nil {
+		cf = *c.ContextFiles
+	}
+	if cf.MaxBytesPerFile <= 0 {
+		cf.MaxBytesPerFile = 6000
+	}
+	if cf.MaxTotalBytes <= 0 {
+		cf.MaxTotalBytes = 16000
+	}
+	return cf
+}
+
+// GetCostModel returns the model name used for cost estimation
+func (c *Config) GetCostModel() string {
+	if c.Cost != nil && c.Cost.Model != "" {
+		return c.Cost.Model
+	}
+	return DefaultCostModel
+}
+
+// GetModelPrice returns the token prices for the configured cost model.
+// Configured prices take precedence over the built-in table.
+func (c *Config) GetModelPrice() ModelPrice {
+	model := c.GetCostModel()
+	if c.Cost != nil {
+		if price, ok := c.Cost.Prices[model]; ok {
+			return price
+		}
+	}
+	if price, ok := DefaultModelPrices[model]; ok {
+		return price
+	}
+	return DefaultModelPrices[DefaultCostModel]
+}
+
 // Save writes the config to disk
 func (c *Config) Save(workDir string) error {
 	if workDir == "" {
@@ -221,24 +1294,56 @@ func (c *Config) Save(workDir string) error {
 	}
 
 	configPath := filepath.Join(configDir, ConfigFileName)
+	c.SchemaVersion = Schema.Version()
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
 	}
 
+	defer filecache.Invalidate(configPath)
 	return os.WriteFile(configPath, data, 0600)
 }
 
 // SetStrictness updates the strictness level
 func (c *Config) SetStrictness(level string) {
 	switch level {
-	case StrictnessRelaxed, StrictnessStandard, StrictnessStrict:
+	case StrictnessRelaxed, StrictnessStandard, StrictnessStrict, StrictnessReview:
 		c.Strictness = level
 	default:
 		c.Strictness = StrictnessStandard
 	}
 }
 
+// SetOutputVerbosity updates the output verbosity
+func (c *Config) SetOutputVerbosity(level string) {
+	switch level {
+	case VerbosityQuiet, VerbosityNormal, VerbosityVerbose:
+		c.OutputVerbosity = level
+	default:
+		c.OutputVerbosity = VerbosityNormal
+	}
+}
+
+// SetLocale updates the locale, resetting it to English if locale is not
+// a language tag such as "es" or "pt-BR"
+func (c *Config) SetLocale(locale string) {
+	if localePattern.MatchString(locale) {
+		c.Locale = locale
+	} else {
+		c.Locale = ""
+	}
+}
+
+// SetHeadless updates the headless mode
+func (c *Config) SetHeadless(mode string) {
+	switch mode {
+	case HeadlessAuto, HeadlessOn, HeadlessOff:
+		c.Headless = mode
+	default:
+		c.Headless = HeadlessAuto
+	}
+}
+
 // SetResearchConfidenceThreshold updates the research confidence threshold
 func (c *Config) SetResearchConfidenceThreshold(threshold float64) {
 	if c.FICConfig == nil {
@@ -258,3 +1363,25 @@ func (c *Config) SetMaxOpenQuestions(max int) {
 		c.FICConfig.MaxOpenQuestions = max
 	}
 }
+
+// SetAutoCompactThreshold updates the context utilization that triggers
+// compaction
+func (c *Config) SetAutoCompactThreshold(threshold float64) {
+	if c.FICConfig == nil {
+		c.FICConfig = &FICConfig{}
+	}
+	if threshold > 0 && threshold <= 1.0 {
+		c.FICConfig.AutoCompactThreshold = threshold
+	}
+}
+
+// SetCompactionToolThreshold updates the tool call count that triggers
+// compaction
+func (c *Config) SetCompactionToolThreshold(calls int) {
+	if c.FICConfig == nil {
+		c.FICConfig = &FICConfig{}
+	}
+	if calls > 0 {
+		c.FICConfig.CompactionToolThreshold = calls
+	}
+}
@@ -2,9 +2,14 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"ultraharness/internal/schema"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -78,6 +83,13 @@ func TestStrictnessModes(t *testing.T) {
 			isStandard: false,
 			isStrict:   true,
 		},
+		{
+			name:       "review mode is strict",
+			strictness: StrictnessReview,
+			isRelaxed:  false,
+			isStandard: false,
+			isStrict:   true,
+		},
 		{
 			name:       "empty defaults to standard",
 			strictness: "",
@@ -232,6 +244,22 @@ func TestLoad(t *testing.T) {
 		}
 	})
 
+	t.Run("newer schema version is refused", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "config-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		os.MkdirAll(filepath.Join(tmpDir, ".claude"), 0755)
+		data := fmt.Sprintf(`{"schema_version": %d, "strictness": "strict"}`, Schema.Version()+1)
+		os.WriteFile(filepath.Join(tmpDir, ".claude", ConfigFileName), []byte(data), 0644)
+
+		if _, err := Load(tmpDir); !errors.Is(err, schema.ErrNewer) {
+			t.Errorf("Load() error = %v, want schema.ErrNewer", err)
+		}
+	})
+
 	t.Run("invalid JSON returns error", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "config-test")
 		if err != nil {
@@ -375,6 +403,121 @@ func TestGetMaxOpenQuestions(t *testing.T) {
 	}
 }
 
+func TestGetRedundancyThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       *Config
+		wantValue int
+	}{
+		{
+			name:      "nil FICConfig uses default",
+			cfg:       &Config{FICConfig: nil},
+			wantValue: 3,
+		},
+		{
+			name:      "zero threshold uses default",
+			cfg:       &Config{FICConfig: &FICConfig{RedundancyThreshold: 0}},
+			wantValue: 3,
+		},
+		{
+			name:      "custom threshold",
+			cfg:       &Config{FICConfig: &FICConfig{RedundancyThreshold: 5}},
+			wantValue: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.GetRedundancyThreshold(); got != tt.wantValue {
+				t.Errorf("GetRedundancyThreshold() = %v, want %v", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestGetBroadSearchResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       *Config
+		wantValue int
+	}{
+		{
+			name:      "nil FICConfig uses default",
+			cfg:       &Config{FICConfig: nil},
+			wantValue: 200,
+		},
+		{
+			name:      "zero uses default",
+			cfg:       &Config{FICConfig: &FICConfig{BroadSearchResults: 0}},
+			wantValue: 200,
+		},
+		{
+			name:      "custom limit",
+			cfg:       &Config{FICConfig: &FICConfig{BroadSearchResults: 50}},
+			wantValue: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.GetBroadSearchResults(); got != tt.wantValue {
+				t.Errorf("GetBroadSearchResults() = %v, want %v", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestGetBudget(t *testing.T) {
+	t.Run("nil budget is unlimited", func(t *testing.T) {
+		cfg := &Config{}
+		budget := cfg.GetBudget()
+		if budget.MaxToolCalls != 0 || budget.MaxWallMinutes != 0 || budget.MaxFilesModified != 0 {
+			t.Errorf("GetBudget() = %+v, want zero limits", budget)
+		}
+		if budget.WarnRatio != 0.8 {
+			t.Errorf("WarnRatio = %v, want 0.8", budget.WarnRatio)
+		}
+	})
+
+	t.Run("respects configured values", func(t *testing.T) {
+		cfg := &Config{Budget: &BudgetConfig{MaxToolCalls: 200, WarnRatio: 0.9}}
+		budget := cfg.GetBudget()
+		if budget.MaxToolCalls != 200 {
+			t.Errorf("MaxToolCalls = %v, want 200", budget.MaxToolCalls)
+		}
+		if budget.WarnRatio != 0.9 {
+			t.Errorf("WarnRatio = %v, want 0.9", budget.WarnRatio)
+		}
+	})
+}
+
+func TestGetModelPrice(t *testing.T) {
+	t.Run("nil cost config uses default model", func(t *testing.T) {
+		cfg := &Config{}
+		if cfg.GetCostModel() != DefaultCostModel {
+			t.Errorf("GetCostModel() = %v, want %v", cfg.GetCostModel(), DefaultCostModel)
+		}
+		if cfg.GetModelPrice() != DefaultModelPrices[DefaultCostModel] {
+			t.Errorf("GetModelPrice() = %+v, want default price", cfg.GetModelPrice())
+		}
+	})
+
+	t.Run("configured price overrides built-in", func(t *testing.T) {
+		custom := ModelPrice{InputPerMillion: 1, OutputPerMillion: 2}
+		cfg := &Config{Cost: &CostConfig{Model: "opus", Prices: map[string]ModelPrice{"opus": custom}}}
+		if cfg.GetModelPrice() != custom {
+			t.Errorf("GetModelPrice() = %+v, want %+v", cfg.GetModelPrice(), custom)
+		}
+	})
+
+	t.Run("unknown model falls back to default price", func(t *testing.T) {
+		cfg := &Config{Cost: &CostConfig{Model: "mystery"}}
+		if cfg.GetModelPrice() != DefaultModelPrices[DefaultCostModel] {
+			t.Errorf("GetModelPrice() = %+v, want default price", cfg.GetModelPrice())
+		}
+	})
+}
+
 func TestGateBehaviorGetters(t *testing.T) {
 	t.Run("nil FICConfig returns defaults", func(t *testing.T) {
 		cfg := &Config{FICConfig: nil}
@@ -387,6 +530,15 @@ func TestGateBehaviorGetters(t *testing.T) {
 		if !cfg.ShouldBlockInStrictMode() {
 			t.Error("ShouldBlockInStrictMode() should default to true")
 		}
+		if !cfg.ShouldDeferResearch() {
+			t.Error("ShouldDeferResearch() should default to true")
+		}
+		if !cfg.IsPressureGatingEnabled() || cfg.ShouldDenyTasksWhenCritical() {
+			t.Error("pressure gating should default to advice without denying tasks")
+		}
+		if got := cfg.GetTargetUtilizationHigh(); got != 0.60 {
+			t.Errorf("GetTargetUtilizationHigh() = %v, want 0.60", got)
+		}
 	})
 
 	t.Run("respects configured values", func(t *testing.T) {
@@ -406,6 +558,12 @@ func TestGateBehaviorGetters(t *testing.T) {
 		if cfg.ShouldBlockInStrictMode() {
 			t.Error("ShouldBlockInStrictMode() should be false")
 		}
+		if cfg.ShouldDeferResearch() {
+			t.Error("ShouldDeferResearch() should be false")
+		}
+		if cfg.IsPressureGatingEnabled() {
+			t.Error("IsPressureGatingEnabled() should be false")
+		}
 	})
 }
 
@@ -437,6 +595,11 @@ func TestSaveConfig(t *testing.T) {
 	if loaded.GetResearchConfidenceThreshold() != 0.85 {
 		t.Errorf("ResearchConfidenceThreshold = %v, want 0.85", loaded.GetResearchConfidenceThreshold())
 	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, ".claude", ConfigFileName))
+	if !strings.Contains(string(data), fmt.Sprintf(`"schema_version": %d`, Schema.Version())) {
+		t.Errorf("saved config does not record schema version %d", Schema.Version())
+	}
 }
 
 func TestSetStrictness(t *testing.T) {
@@ -452,12 +615,181 @@ func TestSetStrictness(t *testing.T) {
 		t.Errorf("SetStrictness(relaxed) = %v, want %v", cfg.Strictness, StrictnessRelaxed)
 	}
 
+	cfg.SetStrictness("review")
+	if cfg.Strictness != StrictnessReview || !cfg.IsReviewMode() {
+		t.Errorf("SetStrictness(review) = %v, want %v", cfg.Strictness, StrictnessReview)
+	}
+
 	cfg.SetStrictness("invalid")
 	if cfg.Strictness != StrictnessStandard {
 		t.Errorf("SetStrictness(invalid) = %v, want %v", cfg.Strictness, StrictnessStandard)
 	}
 }
 
+func TestOutputVerbosity(t *testing.T) {
+	tests := []struct {
+		value       string
+		wantLevel   string
+		wantQuiet   bool
+		wantVerbose bool
+	}{
+		{"", VerbosityNormal, false, false},
+		{"normal", VerbosityNormal, false, false},
+		{"quiet", VerbosityQuiet, true, false},
+		{"verbose", VerbosityVerbose, false, true},
+		{"loud", VerbosityNormal, false, false},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{OutputVerbosity: tt.value}
+		if got := cfg.GetOutputVerbosity(); got != tt.wantLevel {
+			t.Errorf("GetOutputVerbosity(%q) = %v, want %v", tt.value, got, tt.wantLevel)
+		}
+		if got := cfg.IsQuiet(); got != tt.wantQuiet {
+			t.Errorf("IsQuiet(%q) = %v, want %v", tt.value, got, tt.wantQuiet)
+		}
+		if got := cfg.IsVerbose(); got != tt.wantVerbose {
+			t.Errorf("IsVerbose(%q) = %v, want %v", tt.value, got, tt.wantVerbose)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.SetOutputVerbosity("quiet")
+	if cfg.OutputVerbosity != VerbosityQuiet {
+		t.Errorf("SetOutputVerbosity(quiet) = %v, want %v", cfg.OutputVerbosity, VerbosityQuiet)
+	}
+	cfg.SetOutputVerbosity("invalid")
+	if cfg.OutputVerbosity != VerbosityNormal {
+		t.Errorf("SetOutputVerbosity(invalid) = %v, want %v", cfg.OutputVerbosity, VerbosityNormal)
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	for _, name := range CIEnvVars {
+		t.Setenv(name, "")
+	}
+	tests := []struct {
+		value string
+		ci    string
+		want  bool
+	}{
+		{"", "", false},
+		{"", "true", true},
+		{"auto", "1", true},
+		{"auto", "false", false},
+		{"on", "", true},
+		{"off", "true", false},
+		{"sometimes", "true", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("CI", tt.ci)
+		cfg := &Config{Headless: tt.value}
+		if got := cfg.IsHeadless(); got != tt.want {
+			t.Errorf("IsHeadless(%q) with CI=%q = %v, want %v", tt.value, tt.ci, got, tt.want)
+		}
+	}
+
+	t.Setenv("CI", "")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	if !InCI() {
+		t.Error("InCI() = false with GITHUB_ACTIONS set")
+	}
+
+	cfg := DefaultConfig()
+	cfg.SetHeadless("on")
+	if cfg.Headless != HeadlessOn {
+		t.Errorf("SetHeadless(on) = %v, want %v", cfg.Headless, HeadlessOn)
+	}
+	cfg.SetHeadless("always")
+	if cfg.GetHeadless() != HeadlessAuto {
+		t.Errorf("SetHeadless(always) = %v, want %v", cfg.GetHeadless(), HeadlessAuto)
+	}
+}
+
+func TestLocale(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.GetLocale(); got != DefaultLocale {
+		t.Errorf("GetLocale() unset = %v, want %v", got, DefaultLocale)
+	}
+
+	for _, locale := range []string{"es", "pt-BR", "zh_Hant_TW"} {
+		if cfg.SetLocale(locale); cfg.GetLocale() != locale {
+			t.Errorf("SetLocale(%q) = %v, want it kept", locale, cfg.GetLocale())
+		}
+	}
+	for _, locale := range []string{"spanish!", "e", "../es"} {
+		if cfg.SetLocale(locale); cfg.GetLocale() != DefaultLocale {
+			t.Errorf("SetLocale(%q) = %v, want %v", locale, cfg.GetLocale(), DefaultLocale)
+		}
+	}
+	if cfg := (&Config{Locale: "../../etc"}); cfg.GetLocale() != DefaultLocale {
+		t.Errorf("GetLocale() of a hand-edited path = %v, want %v", cfg.GetLocale(), DefaultLocale)
+	}
+}
+
+func TestIsHookEnabled(t *testing.T) {
+	var cfg Config
+	data := `{"hooks": {"stop": {"enabled": false}, "post_tool_use": {"enabled": true}, "pre_compact": {}}}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	tests := []struct {
+		hook string
+		want bool
+	}{
+		{HookStop, false},
+		{HookPostToolUse, true},
+		{HookPreCompact, true},
+		{HookSessionStart, true},
+	}
+	for _, tt := range tests {
+		if got := cfg.IsHookEnabled(tt.hook); got != tt.want {
+			t.Errorf("IsHookEnabled(%q) = %v, want %v", tt.hook, got, tt.want)
+		}
+	}
+
+	if !DefaultConfig().IsHookEnabled(HookStop) {
+		t.Error("DefaultConfig().IsHookEnabled(stop) = false, want true")
+	}
+}
+
+func TestEscalatedStrictness(t *testing.T) {
+	schedule := &StrictnessEscalation{StandardAfter: 20, StrictAfter: 50}
+	tests := []struct {
+		name       string
+		strictness string
+		escalation *StrictnessEscalation
+		toolCalls  int
+		want       string
+	}{
+		{"no schedule", StrictnessStandard, nil, 100, StrictnessStandard},
+		{"empty schedule", StrictnessStrict, &StrictnessEscalation{}, 0, StrictnessStrict},
+		{"starts relaxed", StrictnessStandard, schedule, 0, StrictnessRelaxed},
+		{"standard at threshold", StrictnessStandard, schedule, 20, StrictnessStandard},
+		{"strict at threshold", StrictnessStandard, schedule, 50, StrictnessStrict},
+		{"strict only", StrictnessStandard, &StrictnessEscalation{StrictAfter: 30}, 29, StrictnessRelaxed},
+		{"standard only", StrictnessStrict, &StrictnessEscalation{StandardAfter: 10}, 500, StrictnessStandard},
+		{"review mode is never relaxed", StrictnessReview, schedule, 0, StrictnessReview},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Strictness: tt.strictness, FICConfig: &FICConfig{StrictnessEscalation: tt.escalation}}
+			if got := cfg.EscalatedStrictness(tt.toolCalls); got != tt.want {
+				t.Errorf("EscalatedStrictness(%d) = %v, want %v", tt.toolCalls, got, tt.want)
+			}
+			cfg.Escalate(tt.toolCalls)
+			if cfg.Strictness != tt.want {
+				t.Errorf("Strictness after Escalate(%d) = %v, want %v", tt.toolCalls, cfg.Strictness, tt.want)
+			}
+		})
+	}
+
+	if DefaultConfig().GetStrictnessEscalation() != nil {
+		t.Error("DefaultConfig().GetStrictnessEscalation() should be nil")
+	}
+}
+
 func TestSetResearchConfidenceThreshold(t *testing.T) {
 	cfg := &Config{}
 	cfg.SetResearchConfidenceThreshold(0.90)
@@ -498,3 +830,178 @@ func TestSetMaxOpenQuestions(t *testing.T) {
 		t.Error("Negative MaxOpenQuestions should be ignored")
 	}
 }
+
+func TestSetCompactionThresholds(t *testing.T) {
+	cfg := &Config{}
+	cfg.SetAutoCompactThreshold(0.75)
+	cfg.SetCompactionToolThreshold(80)
+
+	if cfg.FICConfig == nil {
+		t.Fatal("FICConfig should not be nil after SetAutoCompactThreshold")
+	}
+	if got := cfg.GetAutoCompactThreshold(); got != 0.75 {
+		t.Errorf("GetAutoCompactThreshold() = %v, want 0.75", got)
+	}
+	if got := cfg.GetCompactionToolThreshold(); got != 80 {
+		t.Errorf("GetCompactionToolThreshold() = %v, want 80", got)
+	}
+
+	// Out of range values should be ignored
+	cfg.SetAutoCompactThreshold(0)
+	cfg.SetAutoCompactThreshold(1.5)
+	cfg.SetCompactionToolThreshold(-3)
+	if got := cfg.GetAutoCompactThreshold(); got != 0.75 {
+		t.Errorf("GetAutoCompactThreshold() = %v after invalid values, want 0.75", got)
+	}
+	if got := cfg.GetCompactionToolThreshold(); got != 80 {
+		t.Errorf("GetCompactionToolThreshold() = %v after invalid values, want 80", got)
+	}
+}
+
+func TestGetInitScriptConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.GetInitScriptConfig().TimeoutSeconds; got != 60 {
+		t.Errorf("GetInitScriptConfig().TimeoutSeconds = %d, want 60", got)
+	}
+
+	cfg.InitScripts = &InitScriptConfig{TimeoutSeconds: 5, EnvAllowlist: []string{"PATH"}}
+	got := cfg.GetInitScriptConfig()
+	if got.TimeoutSeconds != 5 {
+		t.Errorf("GetInitScriptConfig().TimeoutSeconds = %d, want 5", got.TimeoutSeconds)
+	}
+	if len(got.EnvAllowlist) != 1 {
+		t.Errorf("GetInitScriptConfig().EnvAllowlist = %v, want [PATH]", got.EnvAllowlist)
+	}
+}
+
+func TestGetChurn(t *testing.T) {
+	cfg := DefaultConfig()
+	if !cfg.ChurnAdvisory {
+		t.Error("ChurnAdvisory = false, want true by default")
+	}
+	if !cfg.KnowledgeBase {
+		t.Error("KnowledgeBase = false, want true by default")
+	}
+	if !cfg.CodebaseMap {
+		t.Error("CodebaseMap = false, want true by default")
+	}
+	if !cfg.ProjectTree || cfg.GetProjectTreeDepth() != 2 {
+		t.Errorf("ProjectTree = %v with depth %d, want true with depth 2 by default", cfg.ProjectTree, cfg.GetProjectTreeDepth())
+	}
+	if got, want := cfg.GetChurn(), (ChurnConfig{WindowDays: 90, MinCommits: 5, MinAuthors: 2}); got != want {
+		t.Errorf("GetChurn() = %+v, want %+v", got, want)
+	}
+
+	cfg.Churn = &ChurnConfig{WindowDays: 30, MinAuthors: -1}
+	if got, want := cfg.GetChurn(), (ChurnConfig{WindowDays: 30, MinCommits: 5, MinAuthors: 2}); got != want {
+		t.Errorf("GetChurn() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetRisk(t *testing.T) {
+	cfg := DefaultConfig()
+	if !cfg.RiskScoring {
+		t.Error("RiskScoring = false, want true by default")
+	}
+	got := cfg.GetRisk()
+	if got.IncidentWindowDays != 90 || got.LargeDiffLines != 200 || len(got.CriticalPaths) != 0 {
+		t.Errorf("GetRisk() = %+v, want a 90 day window and 200 large diff lines", got)
+	}
+
+	if !cfg.TestImpact || cfg.GetMaxImpactedTests() != 3 {
+		t.Errorf("TestImpact = %v with %d tests, want true with 3 by default", cfg.TestImpact, cfg.GetMaxImpactedTests())
+	}
+
+	cfg.Risk = &RiskConfig{CriticalPaths: []string{"auth/**"}, LargeDiffLines: 50, IncidentWindowDays: -1}
+	got = cfg.GetRisk()
+	if got.IncidentWindowDays != 90 || got.LargeDiffLines != 50 || len(got.CriticalPaths) != 1 {
+		t.Errorf("GetRisk() = %+v, want a 90 day window, 50 large diff lines, and auth/**", got)
+	}
+}
+
+func TestGetWatchdog(t *testing.T) {
+	cfg := DefaultConfig()
+	if !cfg.LoopWatchdog {
+		t.Error("LoopWatchdog = false, want true by default")
+	}
+	if got, want := cfg.GetWatchdog(), (WatchdogConfig{RepeatedEdits: 3, RepeatedCommands: 5, FailCycles: 4}); got != want {
+		t.Errorf("GetWatchdog() = %+v, want %+v", got, want)
+	}
+
+	cfg.Watchdog = &WatchdogConfig{FailCycles: 6, RepeatedEdits: -1}
+	if got, want := cfg.GetWatchdog(), (WatchdogConfig{RepeatedEdits: 3, RepeatedCommands: 5, FailCycles: 6}); got != want {
+		t.Errorf("GetWatchdog() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetDoNotEdit(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.GetDoNotEdit(); len(got) != len(DefaultDoNotEdit) {
+		t.Errorf("GetDoNotEdit() = %v, want defaults", got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"do_not_edit": []}`), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.GetDoNotEdit(); len(got) != 0 {
+		t.Errorf("GetDoNotEdit() = %v, want none when explicitly empty", got)
+	}
+}
+
+func TestGetDriftWarnRatio(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.GetDriftWarnRatio(); got != 0.5 {
+		t.Errorf("GetDriftWarnRatio() = %v, want 0.5", got)
+	}
+	cfg.DriftWarnRatio = 0.8
+	if got := cfg.GetDriftWarnRatio(); got != 0.8 {
+		t.Errorf("GetDriftWarnRatio() = %v, want 0.8", got)
+	}
+}
+
+func TestGetJira(t *testing.T) {
+	cfg := DefaultConfig()
+	got := cfg.GetJira()
+	if got.Enabled || got.BaseURLEnv != "JIRA_BASE_URL" || got.EmailEnv != "JIRA_EMAIL" || got.TokenEnv != "JIRA_API_TOKEN" {
+		t.Errorf("GetJira() = %+v, want disabled with the JIRA_* variables", got)
+	}
+	if got.Fields["name"] != "summary" || got.Statuses["In Progress"] != "in_progress" || got.MilestonePattern == "" {
+		t.Errorf("GetJira() = %+v, want the default field, status, and milestone mappings", got)
+	}
+	if _, ok := got.Statuses["Done"]; ok {
+		t.Error("GetJira() maps Done by default, want passing left to verification")
+	}
+
+	cfg.Jira = &JiraConfig{Enabled: true, TokenEnv: "MY_TOKEN", Statuses: map[string]string{"Blocked": "failing"}}
+	got = cfg.GetJira()
+	if !got.Enabled || got.TokenEnv != "MY_TOKEN" || len(got.Statuses) != 1 {
+		t.Errorf("GetJira() = %+v, want the configured token variable and statuses", got)
+	}
+}
+
+func TestGetLinear(t *testing.T) {
+	cfg := DefaultConfig()
+	got := cfg.GetLinear()
+	if got.Enabled || got.TokenEnv != "LINEAR_API_KEY" || got.URL != "https://api.linear.app/graphql" {
+		t.Errorf("GetLinear() = %+v, want disabled with LINEAR_API_KEY and the public endpoint", got)
+	}
+	if got.States["research"] != "Research" || got.States["implementation"] != "In Progress" || got.States["review"] != "In Review" {
+		t.Errorf("GetLinear().States = %v, want Research, In Progress, and In Review", got.States)
+	}
+
+	cfg.Linear = &LinearConfig{Enabled: true, States: map[string]string{"implementation": "Doing"}}
+	if got := cfg.GetLinear(); !got.Enabled || len(got.States) != 1 {
+		t.Errorf("GetLinear() = %+v, want the configured states only", got)
+	}
+}
+
+func TestGetKnowledgeTopK(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.GetKnowledgeTopK(); got != 5 {
+		t.Errorf("GetKnowledgeTopK() = %d, want 5", got)
+	}
+	cfg.KnowledgeTopK = 3
+	if got := cfg.GetKnowledgeTopK(); got != 3 {
+		t.Errorf("GetKnowledgeTopK() = %d, want 3", got)
+	}
+}
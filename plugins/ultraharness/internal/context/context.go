@@ -7,15 +7,25 @@ package context
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 	"time"
+
+	"ultraharness/internal/quarantine"
+	"ultraharness/internal/schema"
+	"ultraharness/internal/storage"
 )
 
 // ContextStateFileName is the name of the context state file
 const ContextStateFileName = "fic-context-state.json"
 
+// ContextStateSchema is the context state file format
+var ContextStateSchema = &schema.Format{
+	Name:       ContextStateFileName,
+	Migrations: []schema.Migration{schema.Unversioned},
+}
+
 // FilePermission is the permission for state files
 const FilePermission = 0600
 
@@ -37,6 +47,13 @@ var toolWeights = map[string]int{
 	"Bash":  700,  // Command + output
 }
 
+// MinCalibrationSamples is the number of observed results of a tool after
+// which their average replaces the tool's default weight
+const MinCalibrationSamples = 3
+
+// MaxRecentSearches is the number of Grep/Glob result counts remembered
+const MaxRecentSearches = 5
+
 // BaseOverhead is tokens added per tool call for conversation structure
 const BaseOverhead = 400
 
@@ -58,6 +75,8 @@ type ToolCallsByType struct {
 
 // ContextState tracks context utilization
 type ContextState struct {
+	SchemaVersion int `json:"schema_version"`
+
 	// Session tracking - now persists across sessions
 	SessionID       string    `json:"session_id"`
 	SessionStarted  time.Time `json:"session_started"`
@@ -72,32 +91,87 @@ type ContextState struct {
 	TotalTokenEstimate int     `json:"total_token_estimate"`
 	UtilizationPercent float64 `json:"utilization_percent"`
 
+	// Redundancy tracking: how often each file/search was repeated
+	AccessCounts map[string]int `json:"access_counts,omitempty"`
+
+	// Search breadth: result counts of the most recent Grep/Glob calls
+	RecentSearchResults []int `json:"recent_search_results,omitempty"`
+
+	// Weight calibration: observed result sizes per tool, kept across
+	// compactions since they describe the project, not the conversation
+	Calibration map[string]*ToolSample `json:"calibration,omitempty"`
+
+	// Compaction effectiveness: a compaction is pending until the first
+	// tool call after it measures the context again
+	PendingCompaction *CompactionResult `json:"pending_compaction,omitempty"`
+	LastCompaction    *CompactionResult `json:"last_compaction,omitempty"`
+
 	// Legacy fields for compatibility
 	EntryCount           int       `json:"entry_count"`
 	RedundantDiscoveries []string  `json:"redundant_discoveries,omitempty"`
 	LastUpdated          time.Time `json:"last_updated"`
 }
 
+// ToolSample totals the observed result sizes of a tool
+type ToolSample struct {
+	Calls  int `json:"calls"`
+	Tokens int `json:"tokens"`
+}
+
+// CompactionResult compares the token estimate before a compaction with
+// the first measurement after it
+type CompactionResult struct {
+	At           time.Time `json:"at"`
+	TokensBefore int       `json:"tokens_before"`
+	TokensAfter  int       `json:"tokens_after"`
+	// Reported is set once a status update has shown the result
+	Reported bool `json:"reported,omitempty"`
+}
+
+// Freed returns the estimated tokens the compaction freed
+func (r *CompactionResult) Freed() int {
+	if r.TokensAfter >= r.TokensBefore {
+		return 0
+	}
+	return r.TokensBefore - r.TokensAfter
+}
+
+// String formats the result, e.g. "freed ~85k tokens (~92k -> ~7k)"
+func (r *CompactionResult) String() string {
+	return fmt.Sprintf("freed ~%dk tokens (~%dk -> ~%dk)",
+		r.Freed()/1000, r.TokensBefore/1000, r.TokensAfter/1000)
+}
+
 // LoadContextState loads the context state from the working directory.
 // Unlike before, this now PERSISTS state across sessions instead of resetting.
 func LoadContextState(sessionID, workDir string) (*ContextState, error) {
-	statePath := filepath.Join(workDir, ".claude", ContextStateFileName)
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(statePath)
+	data, err := backend.Get(ContextStateFileName)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return &ContextState{
-				SessionID:      sessionID,
-				SessionStarted: time.Now(),
-				LastUpdated:    time.Now(),
-			}, nil
+		if errors.Is(err, storage.ErrNotFound) {
+			return newContextState(sessionID), nil
 		}
 		return nil, err
 	}
 
 	var state ContextState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, err
+	if err := ContextStateSchema.Unmarshal(data, &state); err != nil {
+		if errors.Is(err, schema.ErrNewer) {
+			return nil, err
+		}
+		// Keep the damaged state for inspection and start tracking again
+		if _, qerr := quarantine.Save(workDir, ContextStateFileName, data, err, "the tool counts and context utilization estimate"); qerr != nil {
+			return nil, err
+		}
+		fresh := newContextState(sessionID)
+		if err := fresh.Save(workDir); err != nil {
+			return nil, err
+		}
+		return fresh, nil
 	}
 
 	// If session ID changed, track it but DON'T reset
@@ -111,26 +185,114 @@ func LoadContextState(sessionID, workDir string) (*ContextState, error) {
 	return &state, nil
 }
 
+func newContextState(sessionID string) *ContextState {
+	return &ContextState{
+		SessionID:      sessionID,
+		SessionStarted: time.Now(),
+		LastUpdated:    time.Now(),
+	}
+}
+
 // Save writes the context state to disk
 func (s *ContextState) Save(workDir string) error {
-	stateDir := filepath.Join(workDir, ".claude")
-	if err := os.MkdirAll(stateDir, DirPermission); err != nil {
+	backend, err := storage.Open(workDir)
+	if err != nil {
 		return err
 	}
 
-	s.LastUpdated = time.Now()
+	data, err := s.encode()
+	if err != nil {
+		return err
+	}
 
-	data, err := json.MarshalIndent(s, "", "  ")
+	return backend.Put(ContextStateFileName, data)
+}
+
+// Put stages the context state in tx, so it is saved together with other
+// state
+func (s *ContextState) Put(tx storage.Tx) error {
+	data, err := s.encode()
 	if err != nil {
 		return err
 	}
+	tx.Put(ContextStateFileName, data)
+	return nil
+}
 
-	statePath := filepath.Join(stateDir, ContextStateFileName)
-	return os.WriteFile(statePath, data, FilePermission)
+// encode stamps the state with the schema version and time and encodes it
+func (s *ContextState) encode() ([]byte, error) {
+	s.SchemaVersion = ContextStateSchema.Version()
+	s.LastUpdated = time.Now()
+	return json.MarshalIndent(s, "", "  ")
 }
 
 // AddEntry updates context tracking for a tool use
 func (s *ContextState) AddEntry(toolName string, toolResult string) string {
+	// Calculate token estimate with weights
+	weight := s.toolWeight(toolName)
+
+	// For tools with output, also consider actual result size
+	// Use the larger of weight estimate or actual result
+	if resultTokens := len(toolResult) / 4; resultTokens > weight {
+		weight = resultTokens
+	}
+
+	s.addTokens(toolName, weight)
+	return ""
+}
+
+// toolWeight returns the tokens a call of toolName is assumed to add: the
+// average of its observed results once calibrated, or its default weight.
+func (s *ContextState) toolWeight(toolName string) int {
+	if sample := s.Calibration[toolName]; sample != nil && sample.Calls >= MinCalibrationSamples {
+		return sample.Tokens / sample.Calls
+	}
+	if weight := toolWeights[toolName]; weight > 0 {
+		return weight
+	}
+	return 500 // Default for unknown tools
+}
+
+// ObserveResult records that a call of toolName returned tokens, calibrating
+// the weight later calls of the tool are estimated at.
+func (s *ContextState) ObserveResult(toolName string, tokens int) {
+	if s.Calibration == nil {
+		s.Calibration = make(map[string]*ToolSample)
+	}
+	sample := s.Calibration[toolName]
+	if sample == nil {
+		sample = &ToolSample{}
+		s.Calibration[toolName] = sample
+	}
+	sample.Calls++
+	sample.Tokens += tokens
+}
+
+// RecordSearchResults remembers how many results a Grep or Glob returned
+// and returns how many of the recent searches returned more than limit.
+func (s *ContextState) RecordSearchResults(results, limit int) int {
+	s.RecentSearchResults = append(s.RecentSearchResults, results)
+	if len(s.RecentSearchResults) > MaxRecentSearches {
+		s.RecentSearchResults = s.RecentSearchResults[len(s.RecentSearchResults)-MaxRecentSearches:]
+	}
+	broad := 0
+	for _, n := range s.RecentSearchResults {
+		if n > limit {
+			broad++
+		}
+	}
+	return broad
+}
+
+// AddMeasuredEntry updates context tracking for a tool use whose output
+// size is known, such as a Read of a file, counting tokens in place of the
+// tool's generic weight.
+func (s *ContextState) AddMeasuredEntry(toolName string, tokens int) {
+	s.addTokens(toolName, tokens)
+}
+
+// addTokens counts a tool call that added toolTokens to the context.
+func (s *ContextState) addTokens(toolName string, toolTokens int) {
 	s.EntryCount++
 	s.TotalToolCalls++
 
@@ -154,24 +316,6 @@ func (s *ContextState) AddEntry(toolName string, toolResult string) string {
 		s.ToolCalls.Other++
 	}
 
-	// Calculate token estimate with weights
-	weight := toolWeights[toolName]
-	if weight == 0 {
-		weight = 500 // Default for unknown tools
-	}
-
-	// Add base overhead + weighted tool tokens
-	toolTokens := BaseOverhead + weight
-
-	// For tools with output, also consider actual result size
-	if len(toolResult) > 0 {
-		resultTokens := len(toolResult) / 4
-		// Use the larger of weight estimate or actual result
-		if resultTokens > weight {
-			toolTokens = BaseOverhead + resultTokens
-		}
-	}
-
 	// Apply conversation multiplier based on depth
 	// Context grows non-linearly as conversation accumulates
 	depthMultiplier := 1.0
@@ -182,12 +326,54 @@ func (s *ContextState) AddEntry(toolName string, toolResult string) string {
 		}
 	}
 
-	s.TotalTokenEstimate += int(float64(toolTokens) * depthMultiplier)
+	// Add base overhead + tool tokens
+	s.TotalTokenEstimate += int(float64(BaseOverhead+toolTokens) * depthMultiplier)
 
 	// Update utilization
 	s.UtilizationPercent = float64(s.TotalTokenEstimate) / float64(MaxContextTokens)
+}
 
-	return ""
+// ReadLineOverhead is the characters Read adds to each line of a file: the
+// line number and the tab after it
+const ReadLineOverhead = 7
+
+// EstimateReadTokens returns the tokens a Read of content adds to the
+// context, at about four characters per token.
+func EstimateReadTokens(content string) int {
+	return (len(content) + (strings.Count(content, "\n")+1)*ReadLineOverhead) / 4
+}
+
+// RecordAccess counts a Read of a file range or a Grep/Glob of a pattern
+// in a path. Targets repeated more than threshold times are added to RedundantDiscoveries.
+// Returns the number of times the target has now been accessed.
+func (s *ContextState) RecordAccess(toolName, target string, threshold int) int {
+	if target == "" {
+		return 0
+	}
+	if s.AccessCounts == nil {
+		s.AccessCounts = make(map[string]int)
+	}
+
+	key := toolName + ":" + target
+	s.AccessCounts[key]++
+	count := s.AccessCounts[key]
+
+	if count == threshold+1 {
+		s.RedundantDiscoveries = append(s.RedundantDiscoveries, key)
+	}
+	return count
+}
+
+// RedundantAccessCount returns the number of repeated accesses (every access
+// of a target beyond the first) in the current context window
+func (s *ContextState) RedundantAccessCount() int {
+	total := 0
+	for _, count := range s.AccessCounts {
+		if count > 1 {
+			total += count - 1
+		}
+	}
+	return total
 }
 
 // NeedsCompaction returns true if context utilization is above threshold
@@ -195,10 +381,17 @@ func (s *ContextState) NeedsCompaction(threshold float64) bool {
 	return s.UtilizationPercent >= threshold
 }
 
-// NeedsCompactionByToolCount returns true if tool count exceeds limit
-// This is a more reliable heuristic than token estimation
+// CompactionToolCount returns the tool calls counted toward the tool-count
+// compaction limit: every call, and every repeated read or search once
+// more, since it adds content the context already holds.
+func (s *ContextState) CompactionToolCount() int {
+	return s.TotalToolCalls + s.RedundantAccessCount()
+}
+
+// NeedsCompactionByToolCount returns true if the compaction tool count
+// reaches limit. This is a more reliable heuristic than token estimation
 func (s *ContextState) NeedsCompactionByToolCount(maxTools int) bool {
-	return s.TotalToolCalls >= maxTools
+	return s.CompactionToolCount() >= maxTools
 }
 
 // GetUtilizationMessage returns a human-readable utilization message
@@ -224,6 +417,7 @@ func (s *ContextState) GetUtilizationMessage() string {
 // Reset clears the context state after compaction
 func (s *ContextState) Reset(sessionID string) {
 	s.CompactionCount++
+	s.PendingCompaction = &CompactionResult{At: time.Now(), TokensBefore: s.TotalTokenEstimate}
 	s.SessionID = sessionID
 	s.SessionStarted = time.Now()
 	s.ToolCalls = ToolCallsByType{}
@@ -231,16 +425,37 @@ func (s *ContextState) Reset(sessionID string) {
 	s.TotalTokenEstimate = 0
 	s.UtilizationPercent = 0
 	s.EntryCount = 0
+	s.AccessCounts = nil
+	s.RecentSearchResults = nil
 	s.RedundantDiscoveries = nil
 	s.LastUpdated = time.Now()
 }
 
+// ResolveCompaction completes a pending compaction with the current token
+// estimate, returning the result, or nil if no compaction is pending.
+// Call it after the first AddEntry following the compaction.
+func (s *ContextState) ResolveCompaction() *CompactionResult {
+	if s.PendingCompaction == nil {
+		return nil
+	}
+	result := s.PendingCompaction
+	result.TokensAfter = s.TotalTokenEstimate
+	s.LastCompaction = result
+	s.PendingCompaction = nil
+	return result
+}
+
 // GetSummary returns a summary of context usage
 func (s *ContextState) GetSummary() string {
-	return fmt.Sprintf("Tool calls: %d (Read:%d, Grep:%d, Glob:%d, Edit:%d, Write:%d, Bash:%d, Task:%d) | Est. tokens: %dk | Util: %.0f%%",
+	summary := fmt.Sprintf("Tool calls: %d (Read:%d, Grep:%d, Glob:%d, Edit:%d, Write:%d, Bash:%d, Task:%d) | Est. tokens: %dk | Util: %.0f%%",
 		s.TotalToolCalls,
 		s.ToolCalls.Read, s.ToolCalls.Grep, s.ToolCalls.Glob,
 		s.ToolCalls.Edit, s.ToolCalls.Write, s.ToolCalls.Bash, s.ToolCalls.Task,
 		s.TotalTokenEstimate/1000,
 		s.UtilizationPercent*100)
+
+	if redundant := s.RedundantAccessCount(); redundant > 0 {
+		summary += fmt.Sprintf(" | Redundant reads: %d", redundant)
+	}
+	return summary
 }
@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/quarantine"
 )
 
 func TestLoadContextState(t *testing.T) {
@@ -66,6 +69,35 @@ func TestLoadContextState(t *testing.T) {
 		}
 	})
 
+	t.Run("corrupt state is quarantined and reset", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "context-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		os.MkdirAll(filepath.Join(tmpDir, ".claude"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, ".claude", ContextStateFileName), []byte(`{"total_tool_calls": 12`), 0600)
+
+		state, err := LoadContextState("session-1", tmpDir)
+		if err != nil {
+			t.Fatalf("LoadContextState() error = %v", err)
+		}
+		if state.SessionID != "session-1" || state.TotalToolCalls != 0 {
+			t.Errorf("LoadContextState() = %+v, want a fresh state", state)
+		}
+		if notices := protocol.TakeNotices(); len(notices) != 1 || !strings.Contains(notices[0], "context utilization") {
+			t.Errorf("notices = %q, want one warning saying what was lost", notices)
+		}
+		if backups, _ := os.ReadDir(quarantine.Dir(tmpDir)); len(backups) != 1 {
+			t.Errorf("quarantined %d files, want 1", len(backups))
+		}
+
+		if _, err := LoadContextState("session-1", tmpDir); err != nil || protocol.TakeNotices() != nil {
+			t.Errorf("second LoadContextState() error = %v, want the reset state without a warning", err)
+		}
+	})
+
 	t.Run("same session ID loads existing state", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "context-test")
 		if err != nil {
@@ -195,6 +227,158 @@ func TestAddEntry(t *testing.T) {
 	})
 }
 
+func TestAddMeasuredEntry(t *testing.T) {
+	state := &ContextState{SessionID: "test"}
+	state.AddMeasuredEntry("Read", 20000)
+
+	if state.ToolCalls.Read != 1 || state.TotalToolCalls != 1 {
+		t.Errorf("ToolCalls = %+v, TotalToolCalls = %v, want one Read", state.ToolCalls, state.TotalToolCalls)
+	}
+	if want := BaseOverhead + 20000; state.TotalTokenEstimate != want {
+		t.Errorf("TotalTokenEstimate = %v, want %v (measured, not weighted)", state.TotalTokenEstimate, want)
+	}
+	if want := float64(BaseOverhead+20000) / MaxContextTokens; state.UtilizationPercent != want {
+		t.Errorf("UtilizationPercent = %v, want %v", state.UtilizationPercent, want)
+	}
+}
+
+func TestObserveResult(t *testing.T) {
+	state := &ContextState{SessionID: "test"}
+	for i := 0; i < MinCalibrationSamples-1; i++ {
+		state.ObserveResult("Grep", 3000)
+	}
+	if got := state.toolWeight("Grep"); got != toolWeights["Grep"] {
+		t.Errorf("toolWeight(Grep) before calibration = %v, want the default %v", got, toolWeights["Grep"])
+	}
+
+	state.ObserveResult("Grep", 6000)
+	if got, want := state.toolWeight("Grep"), (3000*(MinCalibrationSamples-1)+6000)/MinCalibrationSamples; got != want {
+		t.Errorf("toolWeight(Grep) after calibration = %v, want %v", got, want)
+	}
+	if got := state.toolWeight("Glob"); got != toolWeights["Glob"] {
+		t.Errorf("toolWeight(Glob) = %v, want the default %v", got, toolWeights["Glob"])
+	}
+
+	state.AddEntry("Grep", "")
+	if want := BaseOverhead + state.toolWeight("Grep"); state.TotalTokenEstimate != want {
+		t.Errorf("TotalTokenEstimate = %v, want %v (calibrated weight)", state.TotalTokenEstimate, want)
+	}
+
+	state.Reset("next")
+	if state.Calibration["Grep"] == nil {
+		t.Error("Reset() cleared the calibration, want it kept")
+	}
+}
+
+func TestRecordSearchResults(t *testing.T) {
+	state := &ContextState{SessionID: "test"}
+	if got := state.RecordSearchResults(500, 200); got != 1 {
+		t.Errorf("RecordSearchResults() = %v, want 1", got)
+	}
+	state.RecordSearchResults(10, 200)
+	if got := state.RecordSearchResults(300, 200); got != 2 {
+		t.Errorf("RecordSearchResults() = %v, want 2", got)
+	}
+
+	for i := 0; i < MaxRecentSearches; i++ {
+		state.RecordSearchResults(5, 200)
+	}
+	if len(state.RecentSearchResults) != MaxRecentSearches {
+		t.Errorf("len(RecentSearchResults) = %v, want %v", len(state.RecentSearchResults), MaxRecentSearches)
+	}
+	if got := state.RecordSearchResults(400, 200); got != 1 {
+		t.Errorf("RecordSearchResults() after older broad searches aged out = %v, want 1", got)
+	}
+
+	state.Reset("next")
+	if state.RecentSearchResults != nil {
+		t.Errorf("RecentSearchResults after Reset() = %v, want nil", state.RecentSearchResults)
+	}
+}
+
+func TestEstimateReadTokens(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"", 1},
+		{strings.Repeat("x", 4000), 1001},
+		// 1000 lines of 40 characters: the content plus a line number each
+		{strings.Repeat(strings.Repeat("x", 39)+"\n", 1000), 11751},
+	}
+	for _, tt := range tests {
+		if got := EstimateReadTokens(tt.content); got != tt.want {
+			t.Errorf("EstimateReadTokens(%d chars) = %v, want %v", len(tt.content), got, tt.want)
+		}
+	}
+}
+
+func TestRecordAccess(t *testing.T) {
+	t.Run("counts repeated targets per tool", func(t *testing.T) {
+		state := &ContextState{SessionID: "test"}
+
+		state.RecordAccess("Read", "main.go", 3)
+		state.RecordAccess("Read", "main.go", 3)
+		state.RecordAccess("Grep", "main.go", 3)
+
+		if got := state.RecordAccess("Read", "main.go", 3); got != 3 {
+			t.Errorf("RecordAccess() = %v, want 3", got)
+		}
+		if got := state.AccessCounts["Grep:main.go"]; got != 1 {
+			t.Errorf("AccessCounts[Grep:main.go] = %v, want 1", got)
+		}
+	})
+
+	t.Run("flags target once threshold is exceeded", func(t *testing.T) {
+		state := &ContextState{SessionID: "test"}
+
+		for i := 0; i < 5; i++ {
+			state.RecordAccess("Read", "config.go", 3)
+		}
+
+		if len(state.RedundantDiscoveries) != 1 {
+			t.Fatalf("RedundantDiscoveries = %v, want 1 entry", state.RedundantDiscoveries)
+		}
+		if state.RedundantDiscoveries[0] != "Read:config.go" {
+			t.Errorf("RedundantDiscoveries[0] = %v, want 'Read:config.go'", state.RedundantDiscoveries[0])
+		}
+	})
+
+	t.Run("empty target is ignored", func(t *testing.T) {
+		state := &ContextState{SessionID: "test"}
+
+		if got := state.RecordAccess("Read", "", 3); got != 0 {
+			t.Errorf("RecordAccess() = %v, want 0", got)
+		}
+		if len(state.AccessCounts) != 0 {
+			t.Errorf("AccessCounts = %v, want empty", state.AccessCounts)
+		}
+	})
+}
+
+func TestRedundantAccessCount(t *testing.T) {
+	state := &ContextState{
+		AccessCounts: map[string]int{
+			"Read:a.go": 4,
+			"Read:b.go": 1,
+			"Grep:TODO": 2,
+		},
+	}
+
+	if got := state.RedundantAccessCount(); got != 4 {
+		t.Errorf("RedundantAccessCount() = %v, want 4", got)
+	}
+
+	if !strings.Contains(state.GetSummary(), "Redundant reads: 4") {
+		t.Errorf("Summary should report redundant reads, got: %v", state.GetSummary())
+	}
+
+	state.Reset("new-session")
+	if state.RedundantAccessCount() != 0 {
+		t.Errorf("RedundantAccessCount() after Reset = %v, want 0", state.RedundantAccessCount())
+	}
+}
+
 func TestNeedsCompaction(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -221,17 +405,19 @@ func TestNeedsCompactionByToolCount(t *testing.T) {
 	tests := []struct {
 		name       string
 		toolCalls  int
+		redundant  int
 		maxTools   int
 		wantResult bool
 	}{
-		{"below limit", 30, 50, false},
-		{"at limit", 50, 50, true},
-		{"above limit", 60, 50, true},
+		{"below limit", 30, 0, 50, false},
+		{"at limit", 50, 0, 50, true},
+		{"above limit", 60, 0, 50, true},
+		{"repeats bring it forward", 40, 10, 50, true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			state := &ContextState{TotalToolCalls: tt.toolCalls}
+			state := &ContextState{TotalToolCalls: tt.toolCalls, AccessCounts: map[string]int{"Read:a.go": tt.redundant + 1}}
 			if got := state.NeedsCompactionByToolCount(tt.maxTools); got != tt.wantResult {
 				t.Errorf("NeedsCompactionByToolCount() = %v, want %v", got, tt.wantResult)
 			}
@@ -318,6 +504,39 @@ func TestReset(t *testing.T) {
 	if state.ToolCalls.Read != 0 {
 		t.Errorf("ToolCalls.Read = %v, want 0", state.ToolCalls.Read)
 	}
+	if state.PendingCompaction == nil || state.PendingCompaction.TokensBefore != 100000 {
+		t.Errorf("PendingCompaction = %+v, want TokensBefore 100000", state.PendingCompaction)
+	}
+}
+
+func TestResolveCompaction(t *testing.T) {
+	state := &ContextState{TotalTokenEstimate: 92000}
+	if state.ResolveCompaction() != nil {
+		t.Error("ResolveCompaction() without a compaction should be nil")
+	}
+
+	state.Reset("session")
+	state.AddEntry("Read", "")
+
+	result := state.ResolveCompaction()
+	if result == nil {
+		t.Fatal("ResolveCompaction() after Reset() = nil")
+	}
+	if result.TokensAfter != state.TotalTokenEstimate {
+		t.Errorf("TokensAfter = %v, want %v", result.TokensAfter, state.TotalTokenEstimate)
+	}
+	if want := 92000 - state.TotalTokenEstimate; result.Freed() != want {
+		t.Errorf("Freed() = %v, want %v", result.Freed(), want)
+	}
+	if got, want := result.String(), "freed ~90k tokens (~92k -> ~1k)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if state.PendingCompaction != nil || state.LastCompaction != result {
+		t.Error("ResolveCompaction() should move the pending compaction to LastCompaction")
+	}
+	if state.ResolveCompaction() != nil {
+		t.Error("ResolveCompaction() should only resolve a compaction once")
+	}
 }
 
 func TestGetSummary(t *testing.T) {
@@ -382,3 +601,12 @@ func TestToolWeights(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkAddEntry(b *testing.B) {
+	result := strings.Repeat("line of tool output\n", 200)
+	state := &ContextState{SessionID: "bench"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		state.AddEntry("Read", result)
+	}
+}
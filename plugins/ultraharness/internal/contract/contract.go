@@ -0,0 +1,224 @@
+// Package contract detects API contract changes in OpenAPI specs and
+// Protocol Buffers definitions, and tells which of them break existing
+// clients.
+//
+// The diff is semantic rather than textual: reordering, reformatting, or
+// editing descriptions is no change, while a removed field, operation, or
+// RPC, a changed type or field number, or a newly required property or
+// parameter is breaking. Additions are reported but do not break clients.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ultraharness/internal/git"
+)
+
+// Formats recognized by Detect.
+const (
+	FormatOpenAPI = "openapi"
+	FormatProto   = "proto"
+)
+
+// Change is one difference between two versions of a contract.
+type Change struct {
+	// Breaking reports whether existing clients may stop working
+	Breaking bool
+	// Description names the change, e.g. "removed field User.email (2)"
+	Description string
+}
+
+// String formats the change as "! removed field User.email (2)" when it
+// is breaking and "+ added field User.name (3)" otherwise.
+func (c Change) String() string {
+	if c.Breaking {
+		return "! " + c.Description
+	}
+	return "+ " + c.Description
+}
+
+// Detect returns the contract format of the file at path with the given
+// content, or "" if it is not a contract. OpenAPI and Swagger documents
+// are recognized by their top-level openapi or swagger key.
+func Detect(path, content string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".proto":
+		return FormatProto
+	case ".yaml", ".yml", ".json":
+		if doc, ok := parseOpenAPI(path, content); ok && isOpenAPI(doc) {
+			return FormatOpenAPI
+		}
+	}
+	return ""
+}
+
+// IsCandidate reports whether path could hold a contract, before reading
+// it: a .proto file, or a YAML or JSON file.
+func IsCandidate(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".proto", ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Diff returns the contract changes between two versions of the file at
+// path, breaking changes first. Either version may be empty, for a file
+// that is created or deleted. Files that are not contracts, or a version
+// that cannot be parsed, have none.
+func Diff(path, before, after string) []Change {
+	format := Detect(path, after)
+	if format == "" {
+		format = Detect(path, before)
+	}
+
+	var changes []Change
+	switch format {
+	case FormatProto:
+		changes = diffProto(parseProto(before), parseProto(after))
+	case FormatOpenAPI:
+		old, okOld := parseOpenAPI(path, before)
+		cur, okCur := parseOpenAPI(path, after)
+		if !okOld && strings.TrimSpace(before) != "" || !okCur && strings.TrimSpace(after) != "" {
+			return nil
+		}
+		changes = diffOpenAPI(asMap(old), asMap(cur))
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Breaking != changes[j].Breaking {
+			return changes[i].Breaking
+		}
+		return changes[i].Description < changes[j].Description
+	})
+	return changes
+}
+
+// HasBreaking reports whether any of the changes is breaking.
+func HasBreaking(changes []Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Format renders changes one per line with the given indent.
+func Format(changes []Change, indent string) string {
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, indent+c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FileChanges are the contract changes to one file.
+type FileChanges struct {
+	Path    string
+	Changes []Change
+}
+
+// Since returns the contract changes between revision base and the
+// working tree, committed or not, for each tracked contract file that
+// changed.
+func Since(workDir, base string) []FileChanges {
+	var files []FileChanges
+	for _, path := range git.ChangedFilesSince(workDir, base) {
+		if !IsCandidate(path) {
+			continue
+		}
+		before, _ := git.ShowFile(workDir, base, path)
+		after, _ := os.ReadFile(filepath.Join(workDir, path))
+		if changes := Diff(path, before, string(after)); len(changes) > 0 {
+			files = append(files, FileChanges{Path: path, Changes: changes})
+		}
+	}
+	return files
+}
+
+// Markdown renders the changes as an "API contract changes" section for a
+// pull request description, with breaking changes called out.
+func Markdown(files []FileChanges) string {
+	var b strings.Builder
+	b.WriteString("## API contract changes\n")
+	breaking := 0
+	for _, f := range files {
+		for _, c := range f.Changes {
+			if c.Breaking {
+				breaking++
+			}
+		}
+	}
+	if breaking > 0 {
+		fmt.Fprintf(&b, "\n**%d breaking change(s)** may stop existing clients from working.\n", breaking)
+	}
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n### `%s`\n\n", f.Path)
+		for _, c := range f.Changes {
+			if c.Breaking {
+				fmt.Fprintf(&b, "- **Breaking:** %s\n", c.Description)
+			} else {
+				fmt.Fprintf(&b, "- %s\n", strings.ToUpper(c.Description[:1])+c.Description[1:])
+			}
+		}
+	}
+	return b.String()
+}
+
+// parseOpenAPI parses a JSON or YAML document.
+func parseOpenAPI(path, content string) (interface{}, bool) {
+	if strings.TrimSpace(content) == "" {
+		return nil, false
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(content), &doc); err != nil {
+			return nil, false
+		}
+		return doc, true
+	}
+	return parseYAML(content)
+}
+
+func isOpenAPI(doc interface{}) bool {
+	m := asMap(doc)
+	_, openapi := m["openapi"]
+	_, swagger := m["swagger"]
+	return openapi || swagger
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	return nil
+}
+
+func asList(v interface{}) []interface{} {
+	if s, ok := v.([]interface{}); ok {
+		return s
+	}
+	return nil
+}
+
+func asString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// sortedKeys returns the keys of m in order, for stable output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
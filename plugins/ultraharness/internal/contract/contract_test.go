@@ -0,0 +1,362 @@
+package contract
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describe formats changes for comparison.
+func describe(changes []Change) []string {
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return lines
+}
+
+func replace(s, old, new string) string {
+	return strings.Replace(s, old, new, 1)
+}
+
+const petstore = `openapi: 3.0.3
+info:
+  title: Pets  # the sample API
+  description: |
+    Multi-line text: with a colon
+    - and a dash
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            format: int32
+        - $ref: '#/components/parameters/Owner'
+      responses:
+        '200':
+          description: A list of pets
+    post:
+      requestBody:
+        content: {}
+      responses:
+        '201': {description: Created}
+  /pets/{id}:
+    delete:
+      responses:
+        '204':
+          description: Deleted
+components:
+  parameters:
+    Owner:
+      name: owner
+      in: query
+      schema: {type: string}
+  schemas:
+    Pet:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+        status:
+          type: string
+          enum: [available, sold]
+        owner:
+          type: object
+          properties:
+            email: {type: string}
+    Error:
+      type: object
+`
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path, content, want string
+	}{
+		{"api/user.proto", "syntax = \"proto3\";", FormatProto},
+		{"openapi.yaml", petstore, FormatOpenAPI},
+		{"swagger.json", `{"swagger": "2.0", "paths": {}}`, FormatOpenAPI},
+		{"docker-compose.yml", "services:\n  web:\n    image: nginx\n", ""},
+		{"package.json", `{"name": "app"}`, ""},
+		{"main.go", "package main", ""},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.path, tt.content); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	doc, ok := parseYAML(petstore)
+	if !ok {
+		t.Fatal("parseYAML() failed")
+	}
+	get := asMap(asMap(asMap(asMap(doc)["paths"])["/pets"])["get"])
+	params := asList(get["parameters"])
+	if len(params) != 2 || asString(asMap(params[0])["in"]) != "query" || asString(asMap(params[1])["$ref"]) != "#/components/parameters/Owner" {
+		t.Errorf("parameters = %v", params)
+	}
+	pet := asMap(schemas(asMap(doc))["Pet"])
+	if !reflect.DeepEqual(pet["required"], []interface{}{"id"}) {
+		t.Errorf("required = %v", pet["required"])
+	}
+	if got := asString(asMap(doc)["info"].(map[string]interface{})["title"]); got != "Pets" {
+		t.Errorf("title = %q, want the comment stripped", got)
+	}
+}
+
+func TestDiffOpenAPI(t *testing.T) {
+	tests := []struct {
+		name  string
+		after string
+		want  []string
+	}{
+		{
+			name:  "description only",
+			after: replace(petstore, "description: A list of pets", "description: Every pet"),
+			want:  nil,
+		},
+		{
+			name:  "removed operation",
+			after: replace(petstore, "  /pets/{id}:\n    delete:\n      responses:\n        '204':\n          description: Deleted\n", ""),
+			want:  []string{"! removed path /pets/{id}"},
+		},
+		{
+			name: "parameters",
+			after: replace(replace(petstore, "            type: integer\n            format: int32", "            type: string"),
+				"        - $ref: '#/components/parameters/Owner'", "        - name: page\n          in: query\n          required: true"),
+			want: []string{
+				"! added required query page parameter to GET /pets",
+				"! changed type of query limit parameter in GET /pets from integer(int32) to string",
+				"! removed query owner parameter from GET /pets",
+			},
+		},
+		{
+			name: "schema properties",
+			after: replace(replace(replace(petstore, "      required: [id]", "      required: [id, name]"),
+				"          enum: [available, sold]", "          enum: [available, pending]"),
+				"            email: {type: string}", "            email: {type: string}\n            phone: {type: string}"),
+			want: []string{
+				"! made property Pet.name required",
+				"! removed enum value sold from Pet.status",
+				"+ added enum value pending to Pet.status",
+				"+ added property Pet.owner.phone",
+			},
+		},
+		{
+			name:  "schema removed and type changed",
+			after: replace(replace(petstore, "    Error:\n      type: object\n", ""), "        id:\n          type: integer", "        id:\n          type: string"),
+			want:  []string{"! changed type of Pet.id from integer to string", "! removed schema Error"},
+		},
+		{
+			name:  "added operation and response",
+			after: replace(petstore, "  /pets/{id}:\n", "  /pets/{id}:\n    get:\n      responses:\n        '200': {description: A pet}\n"),
+			want:  []string{"+ added operation GET /pets/{id}"},
+		},
+		{
+			name:  "unparsable edit",
+			after: petstore + "  bad\n",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describe(Diff("openapi.yaml", petstore, tt.after)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffOpenAPIJSON(t *testing.T) {
+	before := `{"swagger": "2.0", "paths": {"/users": {"get": {"responses": {"200": {}}}}},
+		"definitions": {"User": {"properties": {"id": {"type": "integer"}, "email": {"type": "string"}}}}}`
+	after := `{"swagger": "2.0", "paths": {"/users": {"get": {"responses": {"200": {}}}}},
+		"definitions": {"User": {"properties": {"id": {"type": "integer", "format": "int64"}}}}}`
+	want := []string{"! changed type of User.id from integer to integer(int64)", "! removed property User.email"}
+	if got := describe(Diff("api/swagger.json", before, after)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+}
+
+const userProto = `syntax = "proto3";
+
+package users.v1;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/users/v1;usersv1";
+
+// A user account.
+message User {
+  string id = 1;
+  string email = 2 [deprecated = true];
+  repeated string tags = 3;
+  map<string, string> labels = 4;
+  oneof contact {
+    string phone = 5;
+  }
+  google.protobuf.Timestamp created_at = 6;
+
+  message Address {
+    string city = 1;
+  }
+
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_ACTIVE = 1;
+  }
+}
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc WatchUsers(WatchRequest) returns (stream User) {
+    option (google.api.http) = { get: "/v1/users:watch" };
+  }
+}
+
+message GetUserRequest { string id = 1; }
+message WatchRequest {}
+`
+
+func TestDiffProto(t *testing.T) {
+	tests := []struct {
+		name  string
+		after string
+		want  []string
+	}{
+		{
+			name:  "comments and formatting",
+			after: replace(userProto, "// A user account.", "/* An account. */"),
+			want:  nil,
+		},
+		{
+			name:  "fields",
+			after: replace(replace(replace(userProto, "  string email = 2 [deprecated = true];\n", ""), "repeated string tags = 3", "string tags = 3"), "    string phone = 5;", "    string phone_number = 5;\n    string fax = 7;"),
+			want: []string{
+				"! changed type of field User.tags (3) from repeated string to string",
+				"! removed field User.email (2)",
+				"! renamed field User.phone (5) to phone_number",
+				"+ added field User.fax (7)",
+			},
+		},
+		{
+			name:  "map and nested",
+			after: replace(replace(userProto, "map<string, string> labels", "map<string, int32> labels"), "    string city = 1;", "    string city = 1;\n    string zip = 2;"),
+			want: []string{
+				"! changed type of field User.labels (4) from map<string,string> to map<string,int32>",
+				"+ added field User.Address.zip (2)",
+			},
+		},
+		{
+			name:  "enums",
+			after: replace(userProto, "    STATUS_ACTIVE = 1;", "    STATUS_ACTIVE = 2;\n    STATUS_BANNED = 3;"),
+			want:  []string{"! renumbered value User.Status.STATUS_ACTIVE from 1 to 2", "+ added value User.Status.STATUS_BANNED"},
+		},
+		{
+			name:  "rpcs",
+			after: replace(replace(userProto, "  rpc GetUser(GetUserRequest) returns (User);\n", "  rpc ListUsers(WatchRequest) returns (stream User);\n"), "returns (stream User) {", "returns (User) {"),
+			want: []string{
+				"! changed signature of rpc UserService.WatchUsers from (WatchRequest) returns (stream User) to (WatchRequest) returns (User)",
+				"! removed rpc UserService.GetUser",
+				"+ added rpc UserService.ListUsers",
+			},
+		},
+		{
+			name:  "deleted file",
+			after: "",
+			want: []string{
+				"! removed enum User.Status",
+				"! removed message GetUserRequest",
+				"! removed message User",
+				"! removed message User.Address",
+				"! removed message WatchRequest",
+				"! removed service UserService",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := describe(Diff("proto/users/v1/user.proto", userProto, tt.after))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasBreaking(t *testing.T) {
+	if HasBreaking([]Change{{false, "added field User.name (3)"}}) {
+		t.Error("HasBreaking() = true for additions only")
+	}
+	if !HasBreaking([]Change{{false, "added field User.name (3)"}, {true, "removed field User.email (2)"}}) {
+		t.Error("HasBreaking() = false with a removal")
+	}
+}
+
+func TestSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	tmpDir, err := os.MkdirTemp("", "contract-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	git := func(args ...string) {
+		exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...).Run()
+	}
+	write := func(name, content string) {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("proto/user.proto", userProto)
+	write("openapi.yaml", petstore)
+	write("config.yaml", "debug: true\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+	base := "HEAD"
+
+	write("proto/user.proto", replace(userProto, "  string email = 2 [deprecated = true];\n", ""))
+	write("config.yaml", "debug: false\n")
+	write("openapi.yaml", replace(petstore, "description: A list of pets", "description: Every pet"))
+
+	files := Since(tmpDir, base)
+	if len(files) != 1 || files[0].Path != "proto/user.proto" {
+		t.Fatalf("Since() = %+v, want only proto/user.proto", files)
+	}
+	if got := describe(files[0].Changes); !reflect.DeepEqual(got, []string{"! removed field User.email (2)"}) {
+		t.Errorf("Since() changes = %q", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	got := Markdown([]FileChanges{
+		{"proto/user.proto", []Change{{true, "removed field User.email (2)"}, {false, "added field User.name (3)"}}},
+		{"openapi.yaml", []Change{{false, "added operation GET /pets"}}},
+	})
+	want := "## API contract changes\n\n" +
+		"**1 breaking change(s)** may stop existing clients from working.\n\n" +
+		"### `proto/user.proto`\n\n" +
+		"- **Breaking:** removed field User.email (2)\n" +
+		"- Added field User.name (3)\n\n" +
+		"### `openapi.yaml`\n\n" +
+		"- Added operation GET /pets\n"
+	if got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+}
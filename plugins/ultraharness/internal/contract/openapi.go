@@ -0,0 +1,258 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+)
+
+// methods are the operation keys of an OpenAPI path item.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// diffOpenAPI compares the operations and the named schemas of two
+// OpenAPI (3.x) or Swagger (2.0) documents.
+func diffOpenAPI(old, cur map[string]interface{}) []Change {
+	var changes []Change
+	oldPaths, curPaths := asMap(old["paths"]), asMap(cur["paths"])
+	for _, path := range sortedKeys(oldPaths) {
+		oldItem := asMap(oldPaths[path])
+		curItem, ok := curPaths[path]
+		if !ok {
+			changes = append(changes, Change{true, "removed path " + path})
+			continue
+		}
+		for _, method := range methods {
+			oldOp, had := oldItem[method]
+			curOp, has := asMap(curItem)[method]
+			name := strings.ToUpper(method) + " " + path
+			switch {
+			case had && !has:
+				changes = append(changes, Change{true, "removed operation " + name})
+			case had && has:
+				changes = append(changes, diffOperation(name, old, cur,
+					parameters(old, oldItem, asMap(oldOp)), parameters(cur, asMap(curItem), asMap(curOp)),
+					asMap(oldOp), asMap(curOp))...)
+			}
+		}
+	}
+	for _, path := range sortedKeys(curPaths) {
+		curItem := asMap(curPaths[path])
+		oldItem := asMap(oldPaths[path])
+		for _, method := range methods {
+			if _, has := curItem[method]; !has {
+				continue
+			}
+			if _, had := oldItem[method]; !had {
+				changes = append(changes, Change{false, "added operation " + strings.ToUpper(method) + " " + path})
+			}
+		}
+	}
+
+	oldSchemas, curSchemas := schemas(old), schemas(cur)
+	for _, name := range sortedKeys(oldSchemas) {
+		curSchema, ok := curSchemas[name]
+		if !ok {
+			changes = append(changes, Change{true, "removed schema " + name})
+			continue
+		}
+		changes = append(changes, diffSchema(name, asMap(oldSchemas[name]), asMap(curSchema))...)
+	}
+	for _, name := range sortedKeys(curSchemas) {
+		if _, ok := oldSchemas[name]; !ok {
+			changes = append(changes, Change{false, "added schema " + name})
+		}
+	}
+	return changes
+}
+
+// schemas returns the named schemas: components/schemas in OpenAPI 3,
+// definitions in Swagger 2.
+func schemas(doc map[string]interface{}) map[string]interface{} {
+	if s := asMap(asMap(doc["components"])["schemas"]); s != nil {
+		return s
+	}
+	return asMap(doc["definitions"])
+}
+
+// parameters returns an operation's parameters, including those shared by
+// its path, keyed by location and name, e.g. "query limit".
+func parameters(doc, item, op map[string]interface{}) map[string]map[string]interface{} {
+	params := make(map[string]map[string]interface{})
+	for _, list := range []interface{}{item["parameters"], op["parameters"]} {
+		for _, p := range asList(list) {
+			param := resolve(doc, asMap(p))
+			if name := asString(param["name"]); name != "" {
+				params[asString(param["in"])+" "+name] = param
+			}
+		}
+	}
+	return params
+}
+
+// resolve follows a local $ref such as "#/components/parameters/Limit".
+func resolve(doc, v map[string]interface{}) map[string]interface{} {
+	ref := asString(v["$ref"])
+	if !strings.HasPrefix(ref, "#/") {
+		return v
+	}
+	var target interface{} = doc
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		target = asMap(target)[part]
+	}
+	if m := asMap(target); m != nil {
+		return m
+	}
+	return v
+}
+
+// diffOperation compares the parameters and responses of one operation.
+func diffOperation(name string, oldDoc, curDoc map[string]interface{}, oldParams, curParams map[string]map[string]interface{}, oldOp, curOp map[string]interface{}) []Change {
+	var changes []Change
+	for _, key := range sortedKeys(oldParams) {
+		oldParam := oldParams[key]
+		curParam, ok := curParams[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{true, fmt.Sprintf("removed %s parameter from %s", key, name)})
+		case isTrue(curParam["required"]) && !isTrue(oldParam["required"]):
+			changes = append(changes, Change{true, fmt.Sprintf("made %s parameter required in %s", key, name)})
+		}
+		if ok {
+			if from, to := paramType(oldParam), paramType(curParam); from != "" && to != "" && from != to {
+				changes = append(changes, Change{true, fmt.Sprintf("changed type of %s parameter in %s from %s to %s", key, name, from, to)})
+			}
+		}
+	}
+	for _, key := range sortedKeys(curParams) {
+		if _, ok := oldParams[key]; ok {
+			continue
+		}
+		if isTrue(curParams[key]["required"]) {
+			changes = append(changes, Change{true, fmt.Sprintf("added required %s parameter to %s", key, name)})
+		} else {
+			changes = append(changes, Change{false, fmt.Sprintf("added optional %s parameter to %s", key, name)})
+		}
+	}
+
+	if !isTrue(resolve(oldDoc, asMap(oldOp["requestBody"]))["required"]) && isTrue(resolve(curDoc, asMap(curOp["requestBody"]))["required"]) {
+		changes = append(changes, Change{true, "made the request body required in " + name})
+	}
+
+	oldResponses, curResponses := asMap(oldOp["responses"]), asMap(curOp["responses"])
+	for _, status := range sortedKeys(oldResponses) {
+		if _, ok := curResponses[status]; !ok {
+			changes = append(changes, Change{true, fmt.Sprintf("removed response %s from %s", status, name)})
+		}
+	}
+	for _, status := range sortedKeys(curResponses) {
+		if _, ok := oldResponses[status]; !ok {
+			changes = append(changes, Change{false, fmt.Sprintf("added response %s to %s", status, name)})
+		}
+	}
+	return changes
+}
+
+// paramType is the type of a parameter, from its schema in OpenAPI 3 or
+// its own type in Swagger 2.
+func paramType(param map[string]interface{}) string {
+	if schema := asMap(param["schema"]); schema != nil {
+		return schemaType(schema)
+	}
+	return schemaType(param)
+}
+
+// diffSchema compares two versions of a schema and, recursively, the
+// properties of inline objects, naming them by their path, e.g.
+// "User.address.city".
+func diffSchema(name string, old, cur map[string]interface{}) []Change {
+	var changes []Change
+	if from, to := schemaType(old), schemaType(cur); from != "" && to != "" && from != to {
+		return []Change{{true, fmt.Sprintf("changed type of %s from %s to %s", name, from, to)}}
+	}
+
+	oldEnum, curEnum := stringSet(old["enum"]), stringSet(cur["enum"])
+	if len(curEnum) > 0 {
+		for _, value := range sortedKeys(oldEnum) {
+			if _, ok := curEnum[value]; !ok {
+				changes = append(changes, Change{true, fmt.Sprintf("removed enum value %s from %s", value, name)})
+			}
+		}
+	}
+	for _, value := range sortedKeys(curEnum) {
+		if _, ok := oldEnum[value]; !ok && len(oldEnum) > 0 {
+			changes = append(changes, Change{false, fmt.Sprintf("added enum value %s to %s", value, name)})
+		}
+	}
+
+	oldRequired, curRequired := stringSet(old["required"]), stringSet(cur["required"])
+	oldProps, curProps := asMap(old["properties"]), asMap(cur["properties"])
+	for _, prop := range sortedKeys(oldProps) {
+		curProp, ok := curProps[prop]
+		path := name + "." + prop
+		if !ok {
+			changes = append(changes, Change{true, "removed property " + path})
+			continue
+		}
+		if _, was := oldRequired[prop]; !was {
+			if _, is := curRequired[prop]; is {
+				changes = append(changes, Change{true, "made property " + path + " required"})
+			}
+		}
+		changes = append(changes, diffSchema(path, asMap(oldProps[prop]), asMap(curProp))...)
+	}
+	for _, prop := range sortedKeys(curProps) {
+		if _, ok := oldProps[prop]; ok {
+			continue
+		}
+		if _, required := curRequired[prop]; required {
+			changes = append(changes, Change{true, "added required property " + name + "." + prop})
+		} else {
+			changes = append(changes, Change{false, "added property " + name + "." + prop})
+		}
+	}
+
+	if oldItems, curItems := asMap(old["items"]), asMap(cur["items"]); oldItems != nil && curItems != nil {
+		changes = append(changes, diffSchema(name+"[]", oldItems, curItems)...)
+	}
+	return changes
+}
+
+// schemaType describes a schema's type, such as "integer(int64)", "array
+// of User", or "User" for a reference, or returns "" if it has none.
+func schemaType(schema map[string]interface{}) string {
+	if ref := asString(schema["$ref"]); ref != "" {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	var t string
+	switch v := schema["type"].(type) {
+	case string:
+		t = v
+	case []interface{}:
+		var parts []string
+		for _, p := range v {
+			parts = append(parts, fmt.Sprint(p))
+		}
+		t = strings.Join(parts, "|")
+	}
+	if t == "array" {
+		if items := schemaType(asMap(schema["items"])); items != "" {
+			return "array of " + items
+		}
+	}
+	if format := asString(schema["format"]); t != "" && format != "" {
+		t += "(" + format + ")"
+	}
+	return t
+}
+
+func stringSet(v interface{}) map[string]interface{} {
+	set := make(map[string]interface{})
+	for _, item := range asList(v) {
+		set[fmt.Sprint(item)] = nil
+	}
+	return set
+}
+
+func isTrue(v interface{}) bool {
+	return v == true || v == "true"
+}
@@ -0,0 +1,276 @@
+package contract
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// protoFile is the contract a .proto file declares. Messages and enums are
+// keyed by their name nested in enclosing messages, e.g. "User.Address".
+type protoFile struct {
+	// messages map each message's field numbers to its fields
+	messages map[string]map[int]protoField
+	// enums map each enum's value names to their numbers
+	enums map[string]map[string]string
+	// services map each service's RPCs to their signatures
+	services map[string]map[string]string
+}
+
+type protoField struct {
+	name string
+	// typ includes the label, e.g. "repeated string"
+	typ string
+}
+
+var protoTokenPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|[A-Za-z_.][A-Za-z0-9_.]*|-?\d+|\S`)
+
+// mapPunctuationPattern matches the spacing in "map < string , int32 >".
+var mapPunctuationPattern = regexp.MustCompile(`\s*([<>,])\s*`)
+
+var protoCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+
+// parseProto parses the messages, enums, and services of a .proto file.
+// Content that cannot be parsed yields what was declared before the error.
+func parseProto(content string) *protoFile {
+	p := &protoParser{
+		tokens: protoTokenPattern.FindAllString(protoCommentPattern.ReplaceAllString(content, " "), -1),
+		file: &protoFile{
+			messages: make(map[string]map[int]protoField),
+			enums:    make(map[string]map[string]string),
+			services: make(map[string]map[string]string),
+		},
+	}
+	p.body("")
+	return p.file
+}
+
+type protoParser struct {
+	tokens []string
+	pos    int
+	file   *protoFile
+}
+
+func (p *protoParser) peek(offset int) string {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return ""
+}
+
+// body parses the top level of the file, or the body of the message named
+// message, up to its closing brace.
+func (p *protoParser) body(message string) {
+	for p.pos < len(p.tokens) {
+		tok := p.peek(0)
+		switch {
+		case tok == "}":
+			p.pos++
+			return
+		case (tok == "message" || tok == "enum" || tok == "service" || tok == "oneof") && p.peek(2) == "{":
+			name := p.peek(1)
+			p.pos += 3
+			switch tok {
+			case "message":
+				if message != "" {
+					name = message + "." + name
+				}
+				p.file.messages[name] = make(map[int]protoField)
+				p.body(name)
+			case "enum":
+				if message != "" {
+					name = message + "." + name
+				}
+				p.enum(name)
+			case "service":
+				p.service(name)
+			case "oneof":
+				// Fields of a oneof belong to the enclosing message
+				p.body(message)
+			}
+		case message != "" && tok != "option" && tok != "reserved" && tok != "extensions" && tok != "extend":
+			p.field(message)
+		default:
+			p.skip()
+		}
+	}
+}
+
+// field parses a field declaration such as "repeated string tags = 4;".
+func (p *protoParser) field(message string) {
+	start := p.pos
+	p.skip()
+	stmt := p.tokens[start:p.pos]
+	eq := indexOf(stmt, "=")
+	if eq < 2 || eq+1 >= len(stmt) {
+		return
+	}
+	number, err := strconv.Atoi(stmt[eq+1])
+	if err != nil {
+		return
+	}
+	typ := strings.Join(stmt[:eq-1], " ")
+	typ = mapPunctuationPattern.ReplaceAllString(typ, "$1")
+	p.file.messages[message][number] = protoField{name: stmt[eq-1], typ: typ}
+}
+
+// enum parses the body of an enum.
+func (p *protoParser) enum(name string) {
+	values := make(map[string]string)
+	p.file.enums[name] = values
+	for p.pos < len(p.tokens) {
+		if p.peek(0) == "}" {
+			p.pos++
+			return
+		}
+		if p.peek(0) != "option" && p.peek(0) != "reserved" && p.peek(1) == "=" {
+			values[p.peek(0)] = p.peek(2)
+		}
+		p.skip()
+	}
+}
+
+// service parses the body of a service.
+func (p *protoParser) service(name string) {
+	rpcs := make(map[string]string)
+	p.file.services[name] = rpcs
+	for p.pos < len(p.tokens) {
+		if p.peek(0) == "}" {
+			p.pos++
+			return
+		}
+		start := p.pos
+		p.skip()
+		stmt := p.tokens[start:p.pos]
+		if len(stmt) < 2 || stmt[0] != "rpc" {
+			continue
+		}
+		// rpc Name ( [stream] Request ) returns ( [stream] Response )
+		var sig []string
+		for _, tok := range stmt[2:] {
+			if tok == "{" || tok == ";" {
+				break
+			}
+			sig = append(sig, tok)
+		}
+		rpcs[stmt[1]] = strings.NewReplacer("( ", "(", " )", ")").Replace(strings.Join(sig, " "))
+	}
+}
+
+// skip moves past the current statement: up to its semicolon or the end of
+// its block. A closing brace of the enclosing block is not consumed.
+func (p *protoParser) skip() {
+	depth := 0
+	for ; p.pos < len(p.tokens); p.pos++ {
+		switch p.tokens[p.pos] {
+		case "{":
+			depth++
+		case "}":
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.pos++
+				return
+			}
+		case ";":
+			if depth == 0 {
+				p.pos++
+				return
+			}
+		}
+	}
+}
+
+func indexOf(tokens []string, s string) int {
+	for i, tok := range tokens {
+		if tok == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// diffProto compares two versions of a .proto file. Fields are matched by
+// number, since that is what the wire format carries.
+func diffProto(old, cur *protoFile) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(old.messages) {
+		oldFields := old.messages[name]
+		curFields, ok := cur.messages[name]
+		if !ok {
+			changes = append(changes, Change{true, "removed message " + name})
+			continue
+		}
+		for _, number := range sortedNumbers(oldFields) {
+			f := oldFields[number]
+			g, ok := curFields[number]
+			switch {
+			case !ok:
+				changes = append(changes, Change{true, fmt.Sprintf("removed field %s.%s (%d)", name, f.name, number)})
+			case f.typ != g.typ:
+				changes = append(changes, Change{true, fmt.Sprintf("changed type of field %s.%s (%d) from %s to %s", name, f.name, number, f.typ, g.typ)})
+			case f.name != g.name:
+				changes = append(changes, Change{true, fmt.Sprintf("renamed field %s.%s (%d) to %s", name, f.name, number, g.name)})
+			}
+		}
+		for _, number := range sortedNumbers(curFields) {
+			if _, ok := oldFields[number]; !ok {
+				changes = append(changes, Change{false, fmt.Sprintf("added field %s.%s (%d)", name, curFields[number].name, number)})
+			}
+		}
+	}
+	for _, name := range sortedKeys(cur.messages) {
+		if _, ok := old.messages[name]; !ok {
+			changes = append(changes, Change{false, "added message " + name})
+		}
+	}
+
+	changes = append(changes, diffNamed("enum", "value", old.enums, cur.enums, "renumbered value %s.%s from %s to %s")...)
+	changes = append(changes, diffNamed("service", "rpc", old.services, cur.services, "changed signature of rpc %s.%s from %s to %s")...)
+	return changes
+}
+
+// diffNamed compares enums or services, whose members are matched by name.
+func diffNamed(kind, member string, old, cur map[string]map[string]string, changed string) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(old) {
+		curMembers, ok := cur[name]
+		if !ok {
+			changes = append(changes, Change{true, "removed " + kind + " " + name})
+			continue
+		}
+		for _, m := range sortedKeys(old[name]) {
+			v, ok := curMembers[m]
+			switch {
+			case !ok:
+				changes = append(changes, Change{true, fmt.Sprintf("removed %s %s.%s", member, name, m)})
+			case v != old[name][m]:
+				changes = append(changes, Change{true, fmt.Sprintf(changed, name, m, old[name][m], v)})
+			}
+		}
+		for _, m := range sortedKeys(curMembers) {
+			if _, ok := old[name][m]; !ok {
+				changes = append(changes, Change{false, fmt.Sprintf("added %s %s.%s", member, name, m)})
+			}
+		}
+	}
+	for _, name := range sortedKeys(cur) {
+		if _, ok := old[name]; !ok {
+			changes = append(changes, Change{false, "added " + kind + " " + name})
+		}
+	}
+	return changes
+}
+
+func sortedNumbers(m map[int]protoField) []int {
+	numbers := make([]int, 0, len(m))
+	for n := range m {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
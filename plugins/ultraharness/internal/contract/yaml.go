@@ -0,0 +1,235 @@
+package contract
+
+import (
+	"strings"
+)
+
+// parseYAML parses the block-style YAML subset OpenAPI documents are
+// written in: nested mappings, sequences, plain and quoted scalars, flow
+// sequences and mappings on one line, comments, and block scalars. Mappings
+// become map[string]interface{}, sequences []interface{}, and scalars
+// strings. Anchors, tags, and multi-document streams are not supported.
+func parseYAML(content string) (interface{}, bool) {
+	var lines []yamlLine
+	var blockIndent = -1
+	for _, raw := range strings.Split(content, "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		text := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(text)
+		// Skip the body of a block scalar (| or >); its text is never
+		// part of the contract
+		if blockIndent >= 0 {
+			if text == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if text == "" || strings.HasPrefix(text, "#") || text == "---" {
+			continue
+		}
+		text = stripComment(text)
+		if v := strings.TrimSpace(afterKey(text)); strings.HasPrefix(v, "|") || strings.HasPrefix(v, ">") {
+			blockIndent = indent
+			if strings.HasPrefix(text, "- ") {
+				blockIndent += 2
+			}
+		}
+		lines = append(lines, yamlLine{indent, text})
+	}
+	if len(lines) == 0 {
+		return nil, false
+	}
+	p := &yamlParser{lines: lines}
+	v := p.node(lines[0].indent)
+	return v, p.pos == len(lines)
+}
+
+type yamlLine struct {
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// node parses the mapping or sequence whose entries start at indent.
+func (p *yamlParser) node(indent int) interface{} {
+	if p.pos >= len(p.lines) {
+		return nil
+	}
+	if strings.HasPrefix(p.lines[p.pos].text, "- ") || p.lines[p.pos].text == "-" {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) map[string]interface{} {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		text := p.lines[p.pos].text
+		if strings.HasPrefix(text, "- ") {
+			break
+		}
+		key, value, ok := splitKey(text)
+		if !ok {
+			return m
+		}
+		p.pos++
+		m[key] = p.value(value, indent)
+	}
+	return m
+}
+
+func (p *yamlParser) sequence(indent int) []interface{} {
+	var s []interface{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		text := p.lines[p.pos].text
+		if text != "-" && !strings.HasPrefix(text, "- ") {
+			break
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(text, "-"))
+		if _, _, ok := splitKey(item); ok && !isFlow(item) {
+			// "- key: value" starts a mapping indented past the dash
+			p.lines[p.pos] = yamlLine{indent + 2, item}
+			s = append(s, p.mapping(indent+2))
+			continue
+		}
+		p.pos++
+		s = append(s, p.value(item, indent))
+	}
+	return s
+}
+
+// value parses the value of a key or sequence item: inline, or a nested
+// node on the following lines.
+func (p *yamlParser) value(inline string, indent int) interface{} {
+	if inline != "" && !strings.HasPrefix(inline, "|") && !strings.HasPrefix(inline, ">") {
+		return flowValue(inline)
+	}
+	if p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		// A sequence may sit at the same indent as its key
+		if next.indent > indent || next.indent == indent && strings.HasPrefix(next.text, "- ") && inline == "" {
+			return p.node(next.indent)
+		}
+	}
+	return ""
+}
+
+// splitKey splits "key: value" into its key and value.
+func splitKey(text string) (key, value string, ok bool) {
+	if isFlow(text) {
+		return "", "", false
+	}
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		rest := text[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return text[1 : end+1], strings.TrimSpace(rest[1:]), true
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		i = len(text) - 1
+	}
+	return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+}
+
+func afterKey(text string) string {
+	text = strings.TrimPrefix(text, "- ")
+	if _, value, ok := splitKey(text); ok {
+		return value
+	}
+	return text
+}
+
+func isFlow(text string) bool {
+	return strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{")
+}
+
+// stripComment removes a trailing " # comment" outside quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && i > 0 && (text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+// flowValue parses a scalar, or a one-line flow sequence or mapping.
+func flowValue(text string) interface{} {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+		s := []interface{}{}
+		for _, item := range splitFlow(text[1 : len(text)-1]) {
+			s = append(s, flowValue(item))
+		}
+		return s
+	case strings.HasPrefix(text, "{") && strings.HasSuffix(text, "}"):
+		m := make(map[string]interface{})
+		for _, item := range splitFlow(text[1 : len(text)-1]) {
+			if key, value, ok := splitKey(item); ok {
+				m[unquote(key)] = flowValue(value)
+			}
+		}
+		return m
+	}
+	return unquote(text)
+}
+
+// splitFlow splits the items of a flow collection at top-level commas.
+func splitFlow(text string) []string {
+	var items []string
+	var depth, start int
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, text[start:i])
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		items = append(items, rest)
+	}
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
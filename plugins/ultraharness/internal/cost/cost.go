@@ -0,0 +1,36 @@
+// Package cost estimates the dollar cost of a session from token estimates.
+//
+// Hooks cannot observe real token usage, so estimates are built from the
+// same weighted context model used for utilization tracking: every tool
+// call re-sends the current context as input and produces a small amount
+// of output. Treat the numbers as order-of-magnitude guidance only.
+package cost
+
+import (
+	"fmt"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/session"
+)
+
+// OutputTokensPerCall is the assumed output (tool call + reasoning) per tool use
+const OutputTokensPerCall = 200
+
+// Estimate returns the estimated USD cost for the given token counts
+func Estimate(inputTokens, outputTokens int64, price config.ModelPrice) float64 {
+	return float64(inputTokens)/1e6*price.InputPerMillion +
+		float64(outputTokens)/1e6*price.OutputPerMillion
+}
+
+// ForSession returns the estimated USD cost of a session using the configured model prices
+func ForSession(state *session.State, cfg *config.Config) float64 {
+	return Estimate(state.InputTokens, state.OutputTokens, cfg.GetModelPrice())
+}
+
+// Format returns a human-readable dollar amount
+func Format(usd float64) string {
+	if usd < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
@@ -0,0 +1,49 @@
+package cost
+
+import (
+	"math"
+	"testing"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/session"
+)
+
+func TestEstimate(t *testing.T) {
+	price := config.ModelPrice{InputPerMillion: 3.0, OutputPerMillion: 15.0}
+
+	got := Estimate(1000000, 100000, price)
+	if math.Abs(got-4.5) > 1e-9 {
+		t.Errorf("Estimate() = %v, want 4.5", got)
+	}
+
+	if got := Estimate(0, 0, price); got != 0 {
+		t.Errorf("Estimate() with no tokens = %v, want 0", got)
+	}
+}
+
+func TestForSession(t *testing.T) {
+	state := &session.State{InputTokens: 2000000, OutputTokens: 0}
+	cfg := &config.Config{Cost: &config.CostConfig{Model: "opus"}}
+
+	if got := ForSession(state, cfg); math.Abs(got-30.0) > 1e-9 {
+		t.Errorf("ForSession() = %v, want 30.0", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		usd  float64
+		want string
+	}{
+		{0, "<$0.01"},
+		{0.004, "<$0.01"},
+		{1.234, "$1.23"},
+		{42, "$42.00"},
+	}
+
+	for _, tt := range tests {
+		if got := Format(tt.usd); got != tt.want {
+			t.Errorf("Format(%v) = %v, want %v", tt.usd, got, tt.want)
+		}
+	}
+}
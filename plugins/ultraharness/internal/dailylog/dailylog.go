@@ -0,0 +1,182 @@
+// Package dailylog keeps a skimmable history of the project in
+// .claude/daily-log.md: a heading per day and one line per session with
+// its length, the features it advanced, where the tests stood, and the
+// commits it made. SessionEnd appends to it when daily_log is enabled.
+package dailylog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+	"ultraharness/internal/session"
+)
+
+// FileName is the daily log file inside .claude.
+const FileName = "daily-log.md"
+
+// FilePermission is the permission for the daily log
+const FilePermission = 0600
+
+// DirPermission is the permission for the daily log directory
+const DirPermission = 0700
+
+// maxCommits limits the commit subjects listed for a session
+const maxCommits = 5
+
+// header starts a new daily log
+const header = "# Daily Log\n"
+
+// Entry summarizes one session.
+type Entry struct {
+	Start, End    time.Time
+	ToolCalls     int
+	FilesModified int
+	// Advanced lists feature status changes, e.g. "auth-1 pending→passing"
+	Advanced       []string
+	TestRuns       int
+	TestPasses     int
+	LastTestPassed bool
+	// Commits are the subjects of the session's commits, oldest first
+	Commits []string
+}
+
+// GetPath returns the path to the daily log.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// FeatureStatuses returns the checklist's feature statuses by ID, or nil
+// without a checklist.
+func FeatureStatuses(workDir string) map[string]string {
+	data, err := features.Load(workDir)
+	if err != nil {
+		return nil
+	}
+	statuses := make(map[string]string, len(data.Features))
+	for _, f := range data.Features {
+		statuses[f.ID] = f.Status
+	}
+	return statuses
+}
+
+// FromSession summarizes a session that ended at end.
+func FromSession(workDir string, state *session.State, end time.Time) Entry {
+	e := Entry{
+		Start:          state.StartedAt,
+		End:            end,
+		ToolCalls:      state.ToolCalls,
+		FilesModified:  len(state.FilesModified),
+		TestRuns:       state.TestRuns,
+		TestPasses:     state.TestPasses,
+		LastTestPassed: state.LastTestPassed,
+	}
+
+	if state.FeatureStatuses != nil {
+		current := FeatureStatuses(workDir)
+		ids := make([]string, 0, len(current))
+		for id := range current {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			before, known := state.FeatureStatuses[id]
+			switch {
+			case !known:
+				e.Advanced = append(e.Advanced, fmt.Sprintf("%s added (%s)", id, current[id]))
+			case before != current[id]:
+				e.Advanced = append(e.Advanced, fmt.Sprintf("%s %s→%s", id, before, current[id]))
+			}
+		}
+	}
+
+	if git.IsRepo(workDir) && !state.StartedAt.IsZero() {
+		commits := git.CommitsSince(workDir, state.StartedAt)
+		for i := len(commits) - 1; i >= 0; i-- {
+			e.Commits = append(e.Commits, commits[i].Subject)
+		}
+	}
+	return e
+}
+
+// Format renders the entry as one Markdown list item.
+func (e Entry) Format() string {
+	parts := []string{fmt.Sprintf("%d tool calls, %d files modified", e.ToolCalls, e.FilesModified)}
+	if len(e.Advanced) > 0 {
+		parts = append(parts, "features: "+strings.Join(e.Advanced, ", "))
+	}
+
+	tests := "not run"
+	if e.TestRuns > 0 {
+		last := "failing"
+		if e.LastTestPassed {
+			last = "passing"
+		}
+		tests = fmt.Sprintf("%s (%d/%d runs passed)", last, e.TestPasses, e.TestRuns)
+	}
+	parts = append(parts, "tests: "+tests)
+
+	if len(e.Commits) > 0 {
+		shown := e.Commits
+		if len(shown) > maxCommits {
+			shown = shown[len(shown)-maxCommits:]
+		}
+		changes := strings.Join(shown, "; ")
+		if more := len(e.Commits) - len(shown); more > 0 {
+			changes = fmt.Sprintf("%d earlier commits; %s", more, changes)
+		}
+		parts = append(parts, "changes: "+changes)
+	}
+
+	span := e.End.Format("15:04")
+	if !e.Start.IsZero() {
+		span = fmt.Sprintf("%s–%s (%s)", e.Start.Format("15:04"), span, e.End.Sub(e.Start).Round(time.Minute))
+	}
+	return fmt.Sprintf("- %s: %s", span, strings.Join(parts, "; "))
+}
+
+// Append adds the entry to the daily log under the heading for the day it
+// ended, starting the log or the day's heading as needed.
+func Append(workDir string, e Entry) error {
+	path := GetPath(workDir)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var add strings.Builder
+	if len(existing) == 0 {
+		add.WriteString(header)
+	}
+	if day := "## " + e.End.Format("2006-01-02"); lastHeading(string(existing)) != day {
+		add.WriteString("\n" + day + "\n\n")
+	}
+	add.WriteString(e.Format() + "\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), DirPermission); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, FilePermission)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(add.String())
+	return err
+}
+
+// lastHeading returns the last day heading in the log.
+func lastHeading(log string) string {
+	lines := strings.Split(log, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(lines[i], "## ") {
+			return strings.TrimSpace(lines[i])
+		}
+	}
+	return ""
+}
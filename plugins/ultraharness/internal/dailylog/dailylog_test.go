@@ -0,0 +1,91 @@
+package dailylog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/features"
+	"ultraharness/internal/session"
+)
+
+func TestFromSession(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dailylog-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	features.Save(tmpDir, &features.FeaturesData{Features: []features.Feature{
+		{ID: "auth-1", Status: features.StatusPassing},
+		{ID: "auth-2", Status: features.StatusInProgress},
+		{ID: "auth-3", Status: features.StatusPending},
+	}})
+
+	state := session.NewState("test")
+	state.FeatureStatuses = map[string]string{"auth-1": features.StatusInProgress, "auth-2": features.StatusInProgress}
+	state.RecordToolCall("Edit", "/p/a.go")
+	state.RecordTestRun(false)
+
+	e := FromSession(tmpDir, state, time.Now())
+	want := []string{"auth-1 in_progress→passing", "auth-3 added (pending)"}
+	if !reflect.DeepEqual(e.Advanced, want) {
+		t.Errorf("Advanced = %v, want %v", e.Advanced, want)
+	}
+	if e.ToolCalls != 1 || e.FilesModified != 1 || e.TestRuns != 1 || e.LastTestPassed {
+		t.Errorf("FromSession() = %+v, want 1 tool call, 1 file, and 1 failing test run", e)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	start := time.Date(2025, 3, 4, 9, 15, 0, 0, time.Local)
+	e := Entry{
+		Start: start, End: start.Add(90 * time.Minute),
+		ToolCalls: 40, FilesModified: 5,
+		Advanced: []string{"auth-1 in_progress→passing"},
+		TestRuns: 4, TestPasses: 3, LastTestPassed: true,
+		Commits: []string{"a", "b", "c", "d", "e", "f", "g"},
+	}
+	want := "- 09:15–10:45 (1h30m0s): 40 tool calls, 5 files modified; features: auth-1 in_progress→passing; " +
+		"tests: passing (3/4 runs passed); changes: 2 earlier commits; c; d; e; f; g"
+	if got := e.Format(); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+
+	quiet := Entry{Start: start, End: start.Add(time.Minute)}
+	if got := quiet.Format(); !strings.HasSuffix(got, "0 tool calls, 0 files modified; tests: not run") {
+		t.Errorf("Format() of an idle session = %q, want tests not run", got)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dailylog-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	day1 := time.Date(2025, 3, 4, 9, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+	for _, end := range []time.Time{day1, day1.Add(time.Hour), day2} {
+		if err := Append(tmpDir, Entry{End: end}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".claude", FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Daily Log\n\n## 2025-03-04\n\n" +
+		"- 09:00: 0 tool calls, 0 files modified; tests: not run\n" +
+		"- 10:00: 0 tool calls, 0 files modified; tests: not run\n" +
+		"\n## 2025-03-05\n\n" +
+		"- 09:00: 0 tool calls, 0 files modified; tests: not run\n"
+	if string(data) != want {
+		t.Errorf("daily log = %q, want %q", data, want)
+	}
+}
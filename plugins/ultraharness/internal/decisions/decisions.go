@@ -0,0 +1,239 @@
+// Package decisions keeps the project's decision log: lightweight
+// architecture decision records of the context, the options considered,
+// what was decided, and its consequences. Decisions are recorded with the
+// decision command or taken from "DECISION:" markers in subagent output.
+//
+// The log is a plain JSON file in .claude, like the research queue.
+// Unlike the knowledge base it is never capped, and compaction does not
+// touch it: decisions stay on record until rendered into docs/adr-style
+// markdown files on demand.
+package decisions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"ultraharness/internal/tags"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/text"
+)
+
+// FileName is the decision log file inside .claude.
+const FileName = "decisions.json"
+
+// FilePermission is the permission for the decision log
+const FilePermission = 0600
+
+// DirPermission is the permission for the decision log directory
+const DirPermission = 0700
+
+// DefaultDir is where records are rendered, relative to the project.
+const DefaultDir = "docs/adr"
+
+// TemplateName is the name of the template records are rendered with.
+const TemplateName = "adr"
+
+// StatusAccepted is the status of every recorded decision.
+const StatusAccepted = "accepted"
+
+// maxTitleLength limits titles taken from the decision text.
+const maxTitleLength = 80
+
+// Decision is one recorded decision.
+type Decision struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// Context is the situation that called for a decision
+	Context string `json:"context,omitempty"`
+	// Options are the alternatives considered, including the one chosen
+	Options      []string `json:"options,omitempty"`
+	Decision     string   `json:"decision"`
+	Consequences string   `json:"consequences,omitempty"`
+	Status       string   `json:"status"`
+	// Source names who recorded it: "agent", "human", or "subagent: TYPE"
+	Source     string    `json:"source,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Number returns the decision's record number, e.g. "ADR-3".
+func (d Decision) Number() string {
+	return fmt.Sprintf("ADR-%d", d.ID)
+}
+
+// String returns the decision on one line, e.g. "ADR-3 Refresh tokens in
+// middleware".
+func (d Decision) String() string {
+	return d.Number() + " " + d.Title
+}
+
+// Log is the project's decisions, oldest first.
+type Log struct {
+	Decisions []Decision `json:"decisions"`
+}
+
+// GetPath returns the path to the decision log file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the decision log. A missing file is an empty log.
+func Load(workDir string) (*Log, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Log{}, nil
+		}
+		return nil, err
+	}
+
+	var l Log
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Save writes the decision log.
+func (l *Log) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Add records d, numbering it after the last decision. A decision whose
+// text is already recorded, ignoring case, punctuation, and spacing, is not
+// recorded again. Without a title, one is taken from the decision text;
+// tags include the hashtags in the title and decision. Returns the
+// decision and whether it was added; an empty decision returns nil.
+func (l *Log) Add(d Decision) (*Decision, bool) {
+	d.Decision = strings.TrimSpace(d.Decision)
+	d.Title = strings.TrimSpace(d.Title)
+	key := normalize(d.Decision)
+	if key == "" {
+		return nil, false
+	}
+	for i := range l.Decisions {
+		if normalize(l.Decisions[i].Decision) == key {
+			return &l.Decisions[i], false
+		}
+	}
+
+	if d.Title == "" {
+		d.Title = titleOf(d.Decision)
+	}
+	d.ID = 1
+	for _, existing := range l.Decisions {
+		if existing.ID >= d.ID {
+			d.ID = existing.ID + 1
+		}
+	}
+	d.Status = StatusAccepted
+	d.Tags = tags.Merge(d.Tags, tags.Parse(d.Title), tags.Parse(d.Decision))
+	if d.RecordedAt.IsZero() {
+		d.RecordedAt = time.Now()
+	}
+	l.Decisions = append(l.Decisions, d)
+	return &l.Decisions[len(l.Decisions)-1], true
+}
+
+// Find returns the decision with the given ID, or nil.
+func (l *Log) Find(id int) *Decision {
+	for i := range l.Decisions {
+		if l.Decisions[i].ID == id {
+			return &l.Decisions[i]
+		}
+	}
+	return nil
+}
+
+// Recent returns the newest limit decisions, oldest first.
+func (l *Log) Recent(limit int) []Decision {
+	if len(l.Decisions) > limit {
+		return l.Decisions[len(l.Decisions)-limit:]
+	}
+	return l.Decisions
+}
+
+// FileNameOf returns the file a decision is rendered to, e.g.
+// "0003-refresh-tokens-in-middleware.md".
+func FileNameOf(d Decision) string {
+	name := fmt.Sprintf("%04d", d.ID)
+	if slug := slugify(d.Title); slug != "" {
+		name += "-" + slug
+	}
+	return name + ".md"
+}
+
+// Render renders a decision as a markdown record with the project's adr
+// template.
+func Render(workDir string, d Decision) string {
+	return templates.Render(workDir, TemplateName, templates.ADR{
+		Number:       d.Number(),
+		Title:        d.Title,
+		Date:         d.RecordedAt.Format("2006-01-02"),
+		Status:       d.Status,
+		Context:      d.Context,
+		Options:      d.Options,
+		Decision:     d.Decision,
+		Consequences: d.Consequences,
+		Source:       d.Source,
+		Tags:         d.Tags,
+	})
+}
+
+// Export renders every decision into dir, relative to workDir unless
+// absolute, rewriting records already there, and returns the paths written.
+func Export(workDir, dir string, l *Log) ([]string, error) {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, d := range l.Decisions {
+		path := filepath.Join(dir, FileNameOf(d))
+		if err := os.WriteFile(path, []byte(Render(workDir, d)+"\n"), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// titleOf returns the first sentence of a decision, shortened for a title.
+func titleOf(decision string) string {
+	title := strings.Join(strings.Fields(decision), " ")
+	if i := strings.Index(title, ". "); i > 0 {
+		title = title[:i]
+	}
+	return text.Truncate(strings.TrimSuffix(title, "."), maxTitleLength)
+}
+
+// slugify reduces a title to lowercase words joined by dashes, for file
+// names.
+func slugify(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	slug := strings.Join(words, "-")
+	return strings.Trim(text.Prefix(slug, 60), "-")
+}
+
+// normalize reduces text to its words for duplicate detection.
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
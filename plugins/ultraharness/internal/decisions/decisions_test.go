@@ -0,0 +1,188 @@
+package decisions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/templates"
+)
+
+func TestLoadSave(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	log, err := Load(tmpDir)
+	if err != nil || len(log.Decisions) != 0 {
+		t.Fatalf("Load() of missing file = %v, %v, want empty log", log, err)
+	}
+	log.Add(Decision{Decision: "Use Postgres for sessions", Source: "human"})
+	if err := log.Save(tmpDir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got.Decisions) != 1 || got.Decisions[0].Source != "human" || got.Decisions[0].RecordedAt.IsZero() {
+		t.Errorf("Load() = %+v, want the saved decision", got.Decisions)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	var log Log
+	d, added := log.Add(Decision{Decision: "Refresh auth tokens in middleware. Handlers stay unaware #auth", Tags: []string{"perf"}})
+	if !added || d.ID != 1 || d.Status != StatusAccepted {
+		t.Fatalf("Add() = %+v, %v, want ADR-1 accepted", d, added)
+	}
+	if d.Title != "Refresh auth tokens in middleware" || d.String() != "ADR-1 Refresh auth tokens in middleware" {
+		t.Errorf("Title = %q, want the first sentence", d.Title)
+	}
+	if strings.Join(d.Tags, " ") != "perf auth" {
+		t.Errorf("Tags = %v, want the listed tags and hashtags", d.Tags)
+	}
+
+	if d, added := log.Add(Decision{Decision: "refresh auth tokens in middleware; handlers stay unaware #AUTH"}); added || d.ID != 1 {
+		t.Errorf("Add() of a recorded decision = %+v, %v, want the existing one", d, added)
+	}
+	if d, added := log.Add(Decision{Decision: " ... "}); d != nil || added {
+		t.Errorf("Add() of an empty decision = %+v, %v", d, added)
+	}
+
+	log.Decisions[0].ID = 7
+	if d, _ := log.Add(Decision{Title: "Cache", Decision: "Cache user lookups for a minute"}); d.ID != 8 || d.Title != "Cache" {
+		t.Errorf("Add() = %+v, want ADR-8 with the given title", d)
+	}
+	if log.Find(8) == nil || log.Find(2) != nil {
+		t.Error("Find() did not find by ID")
+	}
+	if got := log.Recent(1); len(got) != 1 || got[0].ID != 8 {
+		t.Errorf("Recent(1) = %+v", got)
+	}
+}
+
+func TestFileNameOf(t *testing.T) {
+	tests := []struct {
+		d    Decision
+		want string
+	}{
+		{Decision{ID: 3, Title: "Refresh tokens in middleware!"}, "0003-refresh-tokens-in-middleware.md"},
+		{Decision{ID: 12, Title: "Use Café's API v2"}, "0012-use-caf-s-api-v2.md"},
+		{Decision{ID: 1, Title: "決定"}, "0001.md"},
+	}
+	for _, tt := range tests {
+		if got := FileNameOf(tt.d); got != tt.want {
+			t.Errorf("FileNameOf(%q) = %q, want %q", tt.d.Title, got, tt.want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	tmpDir := t.TempDir()
+	var log Log
+	log.Add(Decision{
+		Title:        "Refresh tokens in middleware",
+		Context:      "Each handler refreshed tokens itself",
+		Options:      []string{"Per handler", "Middleware"},
+		Decision:     "Refresh in middleware",
+		Consequences: "Middleware must run before auth checks",
+		Source:       "agent",
+		RecordedAt:   time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local),
+	})
+	log.Add(Decision{Decision: "Keep sessions in Postgres #db"})
+
+	paths, err := Export(tmpDir, DefaultDir, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != filepath.Join(tmpDir, "docs", "adr", "0001-refresh-tokens-in-middleware.md") {
+		t.Fatalf("Export() = %v", paths)
+	}
+
+	data, _ := os.ReadFile(paths[0])
+	want := `# ADR-1: Refresh tokens in middleware
+
+- Status: accepted
+- Date: 2026-10-15
+- Recorded by: agent
+
+## Context
+
+Each handler refreshed tokens itself
+
+## Options Considered
+
+- Per handler
+- Middleware
+
+## Decision
+
+Refresh in middleware
+
+## Consequences
+
+Middleware must run before auth checks
+`
+	if string(data) != want {
+		t.Errorf("record =\n%s\nwant\n%s", data, want)
+	}
+
+	data, _ = os.ReadFile(paths[1])
+	for _, s := range []string{"# ADR-2: Keep sessions in Postgres #db", "- Tags: #db", "## Context\n\nNot recorded."} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("record = %q, missing %q", data, s)
+		}
+	}
+	if strings.Contains(string(data), "Options Considered") {
+		t.Error("record lists options although none were recorded")
+	}
+
+	// Projects can override the record layout
+	dir := templates.Dir(tmpDir)
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, TemplateName+templates.Ext), []byte("{{.Number}} {{.Decision}}\n"), 0644)
+	if got := Render(tmpDir, log.Decisions[1]); got != "ADR-2 Keep sessions in Postgres #db" {
+		t.Errorf("Render() with an override = %q", got)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	output := `Research summary.
+
+Discoveries:
+- Tokens expire after 15 minutes
+
+DECISION: Refresh auth tokens in middleware
+Context: handlers each refreshed tokens
+Options: per handler; middleware
+Consequences: middleware must run first
+
+- **DECISION:** Keep the session store in Postgres
+  **Options considered:**
+  - Redis
+  - Postgres
+Unrelated line after the decision
+Context: not part of any decision
+
+Decision: lowercase prose is not a marker
+DECISION:
+`
+	got := Extract(output)
+	if len(got) != 2 {
+		t.Fatalf("Extract() = %+v, want 2 decisions", got)
+	}
+	d := got[0]
+	if d.Decision != "Refresh auth tokens in middleware" || d.Context != "handlers each refreshed tokens" ||
+		strings.Join(d.Options, "|") != "per handler|middleware" || d.Consequences != "middleware must run first" {
+		t.Errorf("Extract()[0] = %+v", d)
+	}
+	d = got[1]
+	if d.Decision != "Keep the session store in Postgres" || strings.Join(d.Options, "|") != "Redis|Postgres" || d.Context != "" {
+		t.Errorf("Extract()[1] = %+v", d)
+	}
+
+	if got := Extract("no decisions here"); len(got) != 0 {
+		t.Errorf("Extract() = %+v, want none", got)
+	}
+}
@@ -0,0 +1,124 @@
+package decisions
+
+import (
+	"strings"
+)
+
+// Marker starts a decision in subagent output.
+const Marker = "DECISION:"
+
+// Field labels that may follow a marker line, one per line.
+const (
+	labelContext      = "context:"
+	labelOptions      = "options:"
+	labelOptionsLong  = "options considered:"
+	labelConsequences = "consequences:"
+)
+
+// maxExtracted limits the decisions taken from one output.
+const maxExtracted = 10
+
+// Extract returns the decisions marked in output. A decision starts with a
+// line "DECISION: <what was decided>", in capitals and optionally as a
+// list item or in bold, and may be followed by "Context:", "Options:"
+// (separated by ";" or listed as items on the lines below), and
+// "Consequences:" lines. A blank line or another marker ends it. Source is
+// left to the caller.
+func Extract(output string) []Decision {
+	var found []Decision
+	var current *Decision
+	inOptions := false
+
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimSpace(raw)
+		item := strings.TrimSpace(strings.Trim(trimBullet(line), "*_"))
+
+		if rest, ok := strings.CutPrefix(item, Marker); ok {
+			if len(found) == maxExtracted {
+				break
+			}
+			found = append(found, Decision{Decision: strings.TrimSpace(strings.Trim(rest, "*_ "))})
+			current = &found[len(found)-1]
+			inOptions = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if line == "" {
+			current, inOptions = nil, false
+			continue
+		}
+
+		switch {
+		case hasLabel(item, labelContext):
+			current.Context = labelValue(item, labelContext)
+			inOptions = false
+		case hasLabel(item, labelOptionsLong), hasLabel(item, labelOptions):
+			value := labelValue(item, labelOptionsLong)
+			if !hasLabel(item, labelOptionsLong) {
+				value = labelValue(item, labelOptions)
+			}
+			current.Options = append(current.Options, SplitOptions(value)...)
+			inOptions = true
+		case hasLabel(item, labelConsequences):
+			current.Consequences = labelValue(item, labelConsequences)
+			inOptions = false
+		case inOptions && item != line:
+			// A list item under "Options:"
+			current.Options = append(current.Options, SplitOptions(item)...)
+		default:
+			current, inOptions = nil, false
+		}
+	}
+
+	kept := found[:0]
+	for _, d := range found {
+		if d.Decision != "" {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// trimBullet removes a leading "- ", "* ", or "1. " list marker.
+func trimBullet(line string) string {
+	for _, bullet := range []string{"- ", "* "} {
+		if strings.HasPrefix(line, bullet) {
+			return line[len(bullet):]
+		}
+	}
+	if len(line) >= 3 && line[0] >= '0' && line[0] <= '9' && (line[1] == '.' || line[1] == ')') && line[2] == ' ' {
+		return line[3:]
+	}
+	return line
+}
+
+// cutPrefixFold is strings.CutPrefix ignoring ASCII case.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
+
+func hasLabel(item, label string) bool {
+	_, ok := cutPrefixFold(strings.ReplaceAll(item, "**", ""), label)
+	return ok
+}
+
+func labelValue(item, label string) string {
+	rest, _ := cutPrefixFold(strings.ReplaceAll(item, "**", ""), label)
+	return strings.TrimSpace(rest)
+}
+
+// SplitOptions splits a ";"-separated list of options.
+func SplitOptions(value string) []string {
+	var options []string
+	for _, o := range strings.Split(value, ";") {
+		if o = strings.TrimSpace(o); o != "" {
+			options = append(options, o)
+		}
+	}
+	return options
+}
@@ -0,0 +1,344 @@
+// Package deps detects dependency changes in manifest edits and package
+// manager commands.
+package deps
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Ecosystems identify the package manager a dependency belongs to.
+const (
+	EcosystemGo    = "go"
+	EcosystemNPM   = "npm"
+	EcosystemPyPI  = "pypi"
+	EcosystemCargo = "cargo"
+)
+
+// Change kinds.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Updated = "updated"
+)
+
+// Dependency is a named dependency at an optional version.
+type Dependency struct {
+	Ecosystem string
+	Name      string
+	Version   string
+}
+
+// Key identifies the dependency independent of its version.
+func (d Dependency) Key() string {
+	return d.Ecosystem + "/" + d.Name
+}
+
+// Change is a dependency added, removed, or updated.
+type Change struct {
+	Dependency
+	Kind       string
+	OldVersion string
+}
+
+// String formats the change as "+ npm lodash ^4.17.21".
+func (c Change) String() string {
+	switch c.Kind {
+	case Removed:
+		return strings.TrimSpace("- " + c.Ecosystem + " " + c.Name + " " + c.OldVersion)
+	case Updated:
+		return "~ " + c.Ecosystem + " " + c.Name + " " + c.OldVersion + " -> " + c.Version
+	}
+	return strings.TrimSpace("+ " + c.Ecosystem + " " + c.Name + " " + c.Version)
+}
+
+// IsManifest reports whether path is a dependency manifest.
+func IsManifest(path string) bool {
+	return ecosystem(path) != ""
+}
+
+func ecosystem(path string) string {
+	base := filepath.Base(path)
+	switch {
+	case base == "go.mod":
+		return EcosystemGo
+	case base == "package.json":
+		return EcosystemNPM
+	case base == "Cargo.toml":
+		return EcosystemCargo
+	case strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt"):
+		return EcosystemPyPI
+	}
+	return ""
+}
+
+// Parse returns the dependencies declared in a manifest, keyed by Key.
+// Content that cannot be parsed yields no dependencies.
+func Parse(path, content string) map[string]Dependency {
+	var list []Dependency
+	switch ecosystem(path) {
+	case EcosystemGo:
+		list = parseGoMod(content)
+	case EcosystemNPM:
+		list = parsePackageJSON(content)
+	case EcosystemCargo:
+		list = parseCargoToml(content)
+	case EcosystemPyPI:
+		list = parseRequirements(content)
+	}
+
+	result := make(map[string]Dependency)
+	for _, d := range list {
+		result[d.Key()] = d
+	}
+	return result
+}
+
+// Diff returns the dependency changes between two versions of a manifest.
+func Diff(path, before, after string) []Change {
+	old := Parse(path, before)
+	cur := Parse(path, after)
+
+	var changes []Change
+	for key, d := range cur {
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Dependency: d, Kind: Added})
+		case prev.Version != d.Version:
+			changes = append(changes, Change{Dependency: d, Kind: Updated, OldVersion: prev.Version})
+		}
+	}
+	for key, d := range old {
+		if _, ok := cur[key]; !ok {
+			changes = append(changes, Change{Dependency: Dependency{Ecosystem: d.Ecosystem, Name: d.Name}, Kind: Removed, OldVersion: d.Version})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key() < changes[j].Key() })
+	return changes
+}
+
+var goRequireLine = regexp.MustCompile(`^([^\s()]+)\s+(v[^\s]+)`)
+
+func parseGoMod(content string) []Dependency {
+	var list []Dependency
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "//", 2)[0])
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+		if m := goRequireLine.FindStringSubmatch(line); m != nil {
+			list = append(list, Dependency{Ecosystem: EcosystemGo, Name: m[1], Version: m[2]})
+		}
+	}
+	return list
+}
+
+func parsePackageJSON(content string) []Dependency {
+	var pkg map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil
+	}
+
+	var list []Dependency
+	for _, section := range []string{"dependencies", "devDependencies", "peerDependencies", "optionalDependencies"} {
+		var deps map[string]string
+		if raw, ok := pkg[section]; ok && json.Unmarshal(raw, &deps) == nil {
+			for name, version := range deps {
+				list = append(list, Dependency{Ecosystem: EcosystemNPM, Name: name, Version: version})
+			}
+		}
+	}
+	return list
+}
+
+var cargoDepLine = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*=\s*(.+)$`)
+var cargoVersion = regexp.MustCompile(`version\s*=\s*"([^"]*)"`)
+
+func parseCargoToml(content string) []Dependency {
+	var list []Dependency
+	inDeps := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "#", 2)[0])
+		if strings.HasPrefix(line, "[") {
+			section := strings.Trim(line, "[] ")
+			inDeps = strings.HasSuffix(section, "dependencies")
+			continue
+		}
+		if !inDeps {
+			continue
+		}
+		m := cargoDepLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		version := strings.Trim(m[2], `"`)
+		if v := cargoVersion.FindStringSubmatch(m[2]); v != nil {
+			version = v[1]
+		} else if strings.HasPrefix(m[2], "{") {
+			version = ""
+		}
+		list = append(list, Dependency{Ecosystem: EcosystemCargo, Name: m[1], Version: version})
+	}
+	return list
+}
+
+var requirementLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(\[[^\]]*\])?\s*(.*)$`)
+
+func parseRequirements(content string) []Dependency {
+	var list []Dependency
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "#", 2)[0])
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if m := requirementLine.FindStringSubmatch(line); m != nil {
+			version := strings.TrimSpace(strings.SplitN(m[3], ";", 2)[0])
+			list = append(list, Dependency{Ecosystem: EcosystemPyPI, Name: strings.ToLower(m[1]), Version: version})
+		}
+	}
+	return list
+}
+
+// installCommands maps package manager invocations that add dependencies
+// to their ecosystem.
+var installCommands = []struct {
+	prefix    []string
+	ecosystem string
+}{
+	{[]string{"npm", "install"}, EcosystemNPM},
+	{[]string{"npm", "i"}, EcosystemNPM},
+	{[]string{"npm", "add"}, EcosystemNPM},
+	{[]string{"yarn", "add"}, EcosystemNPM},
+	{[]string{"pnpm", "add"}, EcosystemNPM},
+	{[]string{"pnpm", "install"}, EcosystemNPM},
+	{[]string{"go", "get"}, EcosystemGo},
+	{[]string{"pip", "install"}, EcosystemPyPI},
+	{[]string{"pip3", "install"}, EcosystemPyPI},
+	{[]string{"poetry", "add"}, EcosystemPyPI},
+	{[]string{"uv", "add"}, EcosystemPyPI},
+	{[]string{"cargo", "add"}, EcosystemCargo},
+}
+
+var commandSeparators = regexp.MustCompile(`&&|\|\||;|\||\n`)
+
+// redirection matches shell redirections such as >out.log or 2>&1.
+var redirection = regexp.MustCompile(`^\d*[<>]`)
+
+// valueFlags are package manager options whose next argument is a value
+// rather than a package.
+var valueFlags = map[string]bool{
+	"-r": true, "-c": true, "-e": true, "-i": true, "--requirement": true, "--constraint": true,
+	"--index-url": true, "--extra-index-url": true, "--registry": true, "--prefix": true,
+	"-F": true, "--features": true, "--path": true, "--git": true, "--branch": true,
+	"--tag": true, "--rev": true, "--rename": true, "--package": true, "-p": true,
+}
+
+// FromCommand returns the dependencies a shell command installs, such as
+// `npm install lodash` or `go get example.com/mod@v1.2.0`. Installing from
+// an existing manifest or lock file adds nothing and returns nil.
+func FromCommand(command string) []Change {
+	var changes []Change
+	for _, segment := range commandSeparators.Split(command, -1) {
+		fields := strings.Fields(segment)
+		for len(fields) > 0 && strings.Contains(fields[0], "=") {
+			fields = fields[1:] // environment assignments
+		}
+		if len(fields) > 0 && (fields[0] == "sudo" || fields[0] == "python" || fields[0] == "python3") {
+			if fields[0] != "sudo" && len(fields) > 2 && fields[1] == "-m" {
+				fields = fields[2:]
+			} else {
+				fields = fields[1:]
+			}
+		}
+
+		for _, ic := range installCommands {
+			if len(fields) < len(ic.prefix) || !equal(fields[:len(ic.prefix)], ic.prefix) {
+				continue
+			}
+			skip := false
+			for _, arg := range fields[len(ic.prefix):] {
+				if skip {
+					skip = false
+					continue
+				}
+				if strings.HasPrefix(arg, "-") {
+					skip = valueFlags[arg]
+					continue
+				}
+				if redirection.MatchString(arg) {
+					continue
+				}
+				if d, ok := parseArg(ic.ecosystem, arg); ok {
+					changes = append(changes, Change{Dependency: d, Kind: Added})
+				}
+			}
+			break
+		}
+	}
+	return changes
+}
+
+func parseArg(eco, arg string) (Dependency, bool) {
+	arg = strings.Trim(arg, `"'`)
+	if arg == "" || arg == "." || strings.HasPrefix(arg, "./") || strings.HasSuffix(arg, ".txt") {
+		return Dependency{}, false
+	}
+
+	switch eco {
+	case EcosystemGo:
+		if arg == "./..." || !strings.Contains(arg, ".") {
+			return Dependency{}, false
+		}
+		name, version, _ := strings.Cut(arg, "@")
+		return Dependency{Ecosystem: eco, Name: name, Version: version}, true
+	case EcosystemNPM:
+		// Scoped packages start with "@": @scope/name@version
+		at := strings.LastIndex(arg, "@")
+		if at > 0 {
+			return Dependency{Ecosystem: eco, Name: arg[:at], Version: arg[at+1:]}, true
+		}
+		return Dependency{Ecosystem: eco, Name: arg}, true
+	case EcosystemPyPI:
+		if m := requirementLine.FindStringSubmatch(arg); m != nil {
+			return Dependency{Ecosystem: eco, Name: strings.ToLower(m[1]), Version: m[3]}, true
+		}
+		return Dependency{}, false
+	case EcosystemCargo:
+		name, version, _ := strings.Cut(arg, "@")
+		return Dependency{Ecosystem: eco, Name: name, Version: version}, true
+	}
+	return Dependency{}, false
+}
+
+func equal(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Format joins changes for display, one per line with the given indent.
+func Format(changes []Change, indent string) string {
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, indent+c.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,150 @@
+package deps
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsManifest(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/project/go.mod", true},
+		{"web/package.json", true},
+		{"requirements.txt", true},
+		{"requirements-dev.txt", true},
+		{"crates/core/Cargo.toml", true},
+		{"go.sum", false},
+		{"package-lock.json", false},
+		{"main.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsManifest(tt.path); got != tt.want {
+			t.Errorf("IsManifest(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		before string
+		after  string
+		want   []string
+	}{
+		{
+			name: "go.mod require block",
+			path: "go.mod",
+			before: `module example.com/app
+
+go 1.21
+
+require (
+	github.com/pkg/errors v0.9.1
+	golang.org/x/net v0.1.0 // indirect
+)
+`,
+			after: `module example.com/app
+
+go 1.21
+
+require (
+	golang.org/x/net v0.2.0 // indirect
+	github.com/google/uuid v1.6.0
+)
+
+require golang.org/x/text v0.14.0
+`,
+			want: []string{
+				"+ go github.com/google/uuid v1.6.0",
+				"- go github.com/pkg/errors v0.9.1",
+				"~ go golang.org/x/net v0.1.0 -> v0.2.0",
+				"+ go golang.org/x/text v0.14.0",
+			},
+		},
+		{
+			name:   "package.json sections",
+			path:   "package.json",
+			before: `{"name": "app", "dependencies": {"react": "^18.0.0"}}`,
+			after:  `{"name": "app", "dependencies": {"react": "^18.0.0", "lodash": "^4.17.21"}, "devDependencies": {"@types/node": "^20.0.0"}}`,
+			want: []string{
+				"+ npm @types/node ^20.0.0",
+				"+ npm lodash ^4.17.21",
+			},
+		},
+		{
+			name:   "requirements.txt",
+			path:   "requirements.txt",
+			before: "# pinned\nrequests==2.31.0\n-r base.txt\n",
+			after:  "requests==2.32.0\nDjango>=4.2 ; python_version >= '3.10'\n",
+			want: []string{
+				"+ pypi django >=4.2",
+				"~ pypi requests ==2.31.0 -> ==2.32.0",
+			},
+		},
+		{
+			name:   "Cargo.toml",
+			path:   "Cargo.toml",
+			before: "[package]\nname = \"app\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = \"1.0\"\n",
+			after:  "[package]\nname = \"app\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\n\n[dev-dependencies]\ntokio = \"1\"\n",
+			want: []string{
+				"+ cargo tokio 1",
+			},
+		},
+		{
+			name:   "invalid JSON",
+			path:   "package.json",
+			before: `{"dependencies": {"react": "^18.0.0"}}`,
+			after:  `{"dependencies": {`,
+			want: []string{
+				"- npm react ^18.0.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range Diff(tt.path, tt.before, tt.after) {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"npm install lodash", []string{"npm/lodash"}},
+		{"npm i -D @types/node@20 typescript", []string{"npm/@types/node", "npm/typescript"}},
+		{"cd web && yarn add react-query", []string{"npm/react-query"}},
+		{"go get golang.org/x/net@v0.2.0", []string{"go/golang.org/x/net"}},
+		{"python -m pip install 'requests>=2' flask", []string{"pypi/requests", "pypi/flask"}},
+		{"cargo add serde --features derive", []string{"cargo/serde"}},
+		{"npm install --registry https://registry.example.com left-pad 2>&1", []string{"npm/left-pad"}},
+		{"npm install", nil},
+		{"npm ci", nil},
+		{"pip install -r requirements.txt", nil},
+		{"go get ./...", nil},
+		{"go build ./...", nil},
+		{"git commit -m 'npm install lodash'", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, c := range FromCommand(tt.command) {
+			got = append(got, c.Key())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FromCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package digest composes a plain-language summary of a period's work for
+// stakeholders, suitable for pasting into email or Slack. It is built
+// only from harness data: the feature checklist, the retrospectives
+// recorded when features start passing, and the unresolved blockers. The
+// text comes from the digest template, which projects can override in
+// .claude/templates/digest.tmpl.
+package digest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"ultraharness/internal/blockers"
+	"ultraharness/internal/features"
+	"ultraharness/internal/retro"
+	"ultraharness/internal/templates"
+)
+
+// TemplateName is the name of the digest template.
+const TemplateName = "digest"
+
+// maxNext limits the features listed as up next.
+const maxNext = 3
+
+// dateFormat formats the period bounds.
+const dateFormat = "Jan 2, 2006"
+
+// Build collects the digest data for the days before now.
+func Build(workDir string, days int, now time.Time) templates.Digest {
+	since := now.AddDate(0, 0, -days)
+	d := templates.Digest{
+		Project: filepath.Base(workDir),
+		From:    since.Format(dateFormat),
+		To:      now.Format(dateFormat),
+		Period:  fmt.Sprintf("in the last %d days", days),
+	}
+	if days == 7 {
+		d.Period = "this week"
+	}
+
+	data, err := features.Load(workDir)
+	if err != nil {
+		data = &features.FeaturesData{}
+	}
+	completed := completedAt(workDir, data)
+
+	for _, f := range data.Features {
+		d.Total++
+		switch f.Status {
+		case features.StatusPassing:
+			d.Done++
+			if at, ok := completed[f.ID]; ok && at.After(since) && !at.After(now) {
+				d.Completed = append(d.Completed, feature(f))
+			}
+		case features.StatusInProgress:
+			d.InProgress = append(d.InProgress, feature(f))
+		case features.StatusFailing:
+			d.Risks = append(d.Risks, fmt.Sprintf("%s is not working yet: its checks are failing.", f.Name))
+		}
+	}
+	d.Next = next(data)
+
+	for _, v := range data.DependencyViolations() {
+		names := make([]string, 0, len(v.Unmet))
+		for _, id := range v.Unmet {
+			if dep := data.Find(id); dep != nil {
+				names = append(names, dep.Name)
+			} else {
+				names = append(names, id)
+			}
+		}
+		d.Risks = append(d.Risks, fmt.Sprintf("%s was started before the work it depends on (%s) is finished.", v.Feature.Name, joinNames(names)))
+	}
+
+	if list, err := blockers.Load(workDir); err == nil {
+		if open := list.Unresolved(); len(open) > 0 {
+			d.Risks = append(d.Risks, fmt.Sprintf("%d unresolved build or test error(s), the oldest from %s.",
+				len(open), open[0].FirstSeen.Format(dateFormat)))
+		}
+	}
+	return d
+}
+
+// Render renders the digest data with the project's digest template.
+func Render(workDir string, d templates.Digest) string {
+	return templates.Render(workDir, TemplateName, d)
+}
+
+// completedAt returns when each feature last started passing: from its
+// retrospective, or else from its last passing verification.
+func completedAt(workDir string, data *features.FeaturesData) map[string]time.Time {
+	times := make(map[string]time.Time)
+	for _, f := range data.Features {
+		if v := f.Verification; v != nil && v.Passed {
+			if at, err := time.Parse(time.RFC3339, v.VerifiedAt); err == nil {
+				times[f.ID] = at
+			}
+		}
+	}
+	if log, err := retro.Load(workDir); err == nil {
+		for _, r := range log.Retrospectives {
+			times[r.FeatureID] = r.CompletedAt
+		}
+	}
+	return times
+}
+
+// next returns the highest-priority pending features whose prerequisites
+// all pass.
+func next(data *features.FeaturesData) []templates.DigestFeature {
+	var ready []features.Feature
+	for _, f := range data.Features {
+		if f.Status == features.StatusPending && len(data.UnmetDependencies(f)) == 0 {
+			ready = append(ready, f)
+		}
+	}
+	sort.SliceStable(ready, func(i, j int) bool { return ready[i].GetPriority() < ready[j].GetPriority() })
+
+	var list []templates.DigestFeature
+	for i, f := range ready {
+		if i >= maxNext {
+			break
+		}
+		list = append(list, feature(f))
+	}
+	return list
+}
+
+func feature(f features.Feature) templates.DigestFeature {
+	return templates.DigestFeature{ID: f.ID, Name: f.Name, Description: f.Description}
+}
+
+// joinNames joins names as "a", "a and b", or "a, b and c".
+func joinNames(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	}
+	last := len(names) - 1
+	out := names[0]
+	for _, n := range names[1:last] {
+		out += ", " + n
+	}
+	return out + " and " + names[last]
+}
@@ -0,0 +1,105 @@
+package digest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/blockers"
+	"ultraharness/internal/features"
+	"ultraharness/internal/retro"
+	"ultraharness/internal/templates"
+)
+
+func TestBuild(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	features.Save(tmpDir, &features.FeaturesData{Features: []features.Feature{
+		{ID: "F1", Name: "Checkout", Status: features.StatusPassing},
+		{ID: "F2", Name: "Login", Status: features.StatusPassing,
+			Verification: &features.Verification{Passed: true, VerifiedAt: now.AddDate(0, 0, -2).Format(time.RFC3339)}},
+		{ID: "F3", Name: "Search", Status: features.StatusFailing},
+		{ID: "F4", Name: "Reviews", Status: features.StatusInProgress, DependsOn: []string{"F3", "F9"}},
+		{ID: "F5", Name: "Wishlist", Status: features.StatusPending, Priority: 2},
+		{ID: "F6", Name: "Coupons", Status: features.StatusPending, Priority: 1},
+		{ID: "F7", Name: "Ratings", Status: features.StatusPending, DependsOn: []string{"F4"}},
+		{ID: "F8", Name: "Legacy export", Status: features.StatusPassing},
+	}})
+
+	log := &retro.Log{Retrospectives: []retro.Retrospective{
+		{FeatureID: "F1", CompletedAt: now.AddDate(0, 0, -1)},
+		{FeatureID: "F8", CompletedAt: now.AddDate(0, 0, -30)},
+	}}
+	if err := log.Save(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	list := &blockers.List{Blockers: []blockers.Blocker{
+		{Message: "undefined: x", FirstSeen: now.AddDate(0, 0, -3)},
+		{Message: "fixed", Resolved: true},
+	}}
+	if err := list.Save(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	d := Build(tmpDir, 7, now)
+	if d.Period != "this week" || d.From != "Oct 8, 2026" || d.To != "Oct 15, 2026" {
+		t.Errorf("period = %q from %q to %q", d.Period, d.From, d.To)
+	}
+	if d.Done != 3 || d.Total != 8 {
+		t.Errorf("Done/Total = %d/%d, want 3/8", d.Done, d.Total)
+	}
+	if got := names(d.Completed); got != "Checkout, Login" {
+		t.Errorf("Completed = %s, want the features passing since last week", got)
+	}
+	if got := names(d.InProgress); got != "Reviews" {
+		t.Errorf("InProgress = %s", got)
+	}
+	if got := names(d.Next); got != "Coupons, Wishlist" {
+		t.Errorf("Next = %s, want ready pending features by priority", got)
+	}
+	want := []string{
+		"Search is not working yet: its checks are failing.",
+		"Reviews was started before the work it depends on (Search and F9) is finished.",
+		"1 unresolved build or test error(s), the oldest from Oct 12, 2026.",
+	}
+	if strings.Join(d.Risks, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Risks = %q, want %q", d.Risks, want)
+	}
+
+	if d := Build(tmpDir, 14, now); d.Period != "in the last 14 days" {
+		t.Errorf("Period = %q", d.Period)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpDir := t.TempDir()
+	d := templates.Digest{Project: "shop", From: "Oct 8", To: "Oct 15", Period: "this week", Total: 2}
+
+	text := Render(tmpDir, d)
+	for _, want := range []string{"shop update, Oct 8 to Oct 15", "0 of 2 planned features", "Completed this week:\n- Nothing new was completed.", "- None at the moment."} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Render() = %q, missing %q", text, want)
+		}
+	}
+	if strings.Contains(text, "Up next") {
+		t.Error("Render() lists Up next without features ready to start")
+	}
+
+	dir := templates.Dir(tmpDir)
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, TemplateName+templates.Ext), []byte("{{.Done}}/{{.Total}} done\n"), 0644)
+	if got := Render(tmpDir, d); got != "0/2 done" {
+		t.Errorf("Render() with an override = %q", got)
+	}
+}
+
+func names(list []templates.DigestFeature) string {
+	var out []string
+	for _, f := range list {
+		out = append(out, f.Name)
+	}
+	return strings.Join(out, ", ")
+}
@@ -0,0 +1,206 @@
+// Package drift measures how far an implementation has strayed from its
+// plan: the code files changed since the plan was first saved, committed
+// or not, against the files and directories the plan's goal and steps
+// refer to. A plan that names 5 files while the diff touches 14 no longer
+// describes the work, and the next session or compaction will resume from
+// a plan that misleads it.
+package drift
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/git"
+	"ultraharness/internal/snapshot"
+	"ultraharness/internal/storage"
+)
+
+var (
+	// A file name or path, e.g. config.go or internal/session/session.go
+	fileReferencePattern = regexp.MustCompile(`(?:[\w.-]+/)*[\w-]{2,}\.[A-Za-z]{1,5}\b`)
+
+	// A directory path ending in a slash, e.g. internal/hooks/
+	dirReferencePattern = regexp.MustCompile("(?:^|[\\s`\"'(])((?:[\\w.-]+/)+)(?:[\\s`\"',;:.)]|$)")
+)
+
+// Report compares a plan with the code files changed since it was saved.
+type Report struct {
+	// Planned are the files and directories the plan refers to; directories
+	// end in a slash
+	Planned []string
+	// Touched are the code files changed since the plan was saved
+	Touched []string
+	// Unplanned are the touched files the plan does not refer to
+	Unplanned []string
+}
+
+// Ratio returns the share of touched files the plan does not refer to.
+func (r Report) Ratio() float64 {
+	if len(r.Touched) == 0 {
+		return 0
+	}
+	return float64(len(r.Unplanned)) / float64(len(r.Touched))
+}
+
+// Exceeds reports whether the drift is above the ratio max.
+func (r Report) Exceeds(max float64) bool {
+	return r.Ratio() > max
+}
+
+// String summarizes the report, e.g. "plan referenced 5 files, diff
+// touches 14 (9 unplanned, 64% drift)".
+func (r Report) String() string {
+	return fmt.Sprintf("plan referenced %d files, diff touches %d (%d unplanned, %.0f%% drift)",
+		len(r.Planned), len(r.Touched), len(r.Unplanned), r.Ratio()*100)
+}
+
+// References returns the files and directories mentioned in the plan's goal
+// and step descriptions, sorted.
+func References(plan *artifacts.Plan) []string {
+	texts := []string{plan.Goal}
+	for _, step := range plan.Steps {
+		texts = append(texts, step.Description)
+	}
+
+	seen := map[string]bool{}
+	var refs []string
+	add := func(ref string) {
+		ref = strings.TrimPrefix(ref, "./")
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	for _, t := range texts {
+		for _, ref := range fileReferencePattern.FindAllString(t, -1) {
+			add(ref)
+		}
+		for _, m := range dirReferencePattern.FindAllStringSubmatch(t, -1) {
+			add(m[1])
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Covers reports whether a reference names file: the file itself, its path
+// from any directory (stop.go names internal/hooks/stop/stop.go), or a
+// directory containing it.
+func Covers(ref, file string) bool {
+	if strings.HasSuffix(ref, "/") {
+		return strings.HasPrefix(file, ref) || strings.Contains(file, "/"+ref)
+	}
+	return file == ref || strings.HasSuffix(file, "/"+ref)
+}
+
+// Compare builds the report for the touched files against the plan's
+// references.
+func Compare(refs, touched []string) Report {
+	r := Report{Planned: refs, Touched: touched}
+	for _, file := range touched {
+		covered := false
+		for _, ref := range refs {
+			if Covers(ref, file) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			r.Unplanned = append(r.Unplanned, file)
+		}
+	}
+	return r
+}
+
+// Check compares the latest plan with the code files changed since it was
+// first saved, in the repository at workDir. isCode decides which changed
+// files count. Reports false if there is no plan or no changed code file.
+func Check(workDir string, isCode func(string) bool) (Report, bool) {
+	plan, started, ok := latestPlan(workDir)
+	if !ok {
+		return Report{}, false
+	}
+
+	var touched []string
+	for _, file := range changedSince(workDir, started) {
+		if isCode(file) {
+			touched = append(touched, file)
+		}
+	}
+	if len(touched) == 0 {
+		return Report{}, false
+	}
+	return Compare(References(plan), touched), true
+}
+
+// latestPlan returns the latest plan and when it was first saved: the
+// oldest save of a plan with its ID, since a plan is saved again as it is
+// revised.
+func latestPlan(workDir string) (*artifacts.Plan, time.Time, bool) {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	keys, err := artifacts.Keys(backend, artifacts.ArtifactPlan)
+	if err != nil || len(keys) == 0 {
+		return nil, time.Time{}, false
+	}
+
+	latest, _ := artifacts.Get(backend, keys[len(keys)-1], artifacts.ArtifactPlan)
+	plan, ok := latest.(*artifacts.Plan)
+	if !ok || plan == nil {
+		return nil, time.Time{}, false
+	}
+	started, ok := artifacts.SavedAt(keys[len(keys)-1])
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	if plan.ID == "" {
+		return plan, started, true
+	}
+	for i := len(keys) - 2; i >= 0; i-- {
+		earlier, _ := artifacts.Get(backend, keys[i], artifacts.ArtifactPlan)
+		p, ok := earlier.(*artifacts.Plan)
+		if !ok || p == nil || p.ID != plan.ID {
+			break
+		}
+		if t, ok := artifacts.SavedAt(keys[i]); ok {
+			started = t
+		}
+	}
+	return plan, started, true
+}
+
+// changedSince returns the files changed since the last commit before
+// since, committed or not, and the untracked files, leaving out the
+// harness's own state. Commit times and artifact names have a resolution of
+// a second, so commits made in the second of since count as changes.
+func changedSince(workDir string, since time.Time) []string {
+	var changed []string
+	if base := git.CommitBefore(workDir, since.Add(-time.Second)); base != "" {
+		changed = git.ChangedFilesSince(workDir, base)
+	}
+	changed = append(changed, git.ModifiedFiles(workDir)...)
+
+	seen := map[string]bool{}
+	var files []string
+	for _, file := range changed {
+		if seen[file] || isHarnessState(file) {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// isHarnessState reports whether file is one of the harness's own state
+// files, or Claude Code's files under .claude, which no plan mentions.
+func isHarnessState(file string) bool {
+	return strings.HasPrefix(file, ".claude/") || snapshot.IsStatePath(file)
+}
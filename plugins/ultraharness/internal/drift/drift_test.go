@@ -0,0 +1,133 @@
+package drift
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/git"
+)
+
+func TestReferences(t *testing.T) {
+	plan := &artifacts.Plan{
+		Goal: "Add drift detection to the Stop hook",
+		Steps: []artifacts.PlanStep{
+			{ID: "1", Description: "Add internal/drift/drift.go comparing plans with diffs"},
+			{ID: "2", Description: "Call it from stop.go, e.g. after the merge conflict check"},
+			{ID: "3", Description: "Document it in `commands/` and ./README.md."},
+			{ID: "4", Description: "Call it from stop.go again"},
+		},
+	}
+	want := []string{"README.md", "commands/", "internal/drift/drift.go", "stop.go"}
+	if got := References(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("References() = %v, want %v", got, want)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		ref, file string
+		want      bool
+	}{
+		{"stop.go", "stop.go", true},
+		{"stop.go", "internal/hooks/stop/stop.go", true},
+		{"stop.go", "internal/hooks/stop/nonstop.go", false},
+		{"hooks/stop/stop.go", "internal/hooks/stop/stop.go", true},
+		{"internal/hooks/", "internal/hooks/stop/stop.go", true},
+		{"hooks/", "internal/hooks/stop/stop.go", true},
+		{"hooks/", "internal/webhooks/hook.go", false},
+		{"internal/drift.go", "internal/drift/drift.go", false},
+	}
+	for _, tt := range tests {
+		if got := Covers(tt.ref, tt.file); got != tt.want {
+			t.Errorf("Covers(%q, %q) = %v, want %v", tt.ref, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	refs := []string{"internal/drift/", "stop.go"}
+	touched := []string{"internal/drift/drift.go", "internal/drift/drift_test.go", "internal/hooks/stop/stop.go", "internal/config/config.go"}
+	r := Compare(refs, touched)
+	if !reflect.DeepEqual(r.Unplanned, []string{"internal/config/config.go"}) {
+		t.Errorf("Unplanned = %v, want config.go", r.Unplanned)
+	}
+	if r.Ratio() != 0.25 || r.Exceeds(0.5) || !r.Exceeds(0.2) {
+		t.Errorf("Ratio() = %v, want 0.25", r.Ratio())
+	}
+	if got := r.String(); got != "plan referenced 2 files, diff touches 4 (1 unplanned, 25% drift)" {
+		t.Errorf("String() = %q", got)
+	}
+	if (Report{}).Ratio() != 0 {
+		t.Error("Ratio() of an empty report should be 0")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(file string) {
+		path := filepath.Join(tmpDir, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		content, _ := os.ReadFile(path)
+		if err := os.WriteFile(path, append(content, file+"\n"...), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	isCode := func(path string) bool { return git.IsCodeFile(path, nil, nil) }
+
+	run("init", "-q")
+	write("main.go")
+	write("old.go")
+	run("add", ".")
+	run("commit", "-qm", "initial")
+
+	if _, ok := Check(tmpDir, isCode); ok {
+		t.Error("Check() without a plan reported drift")
+	}
+
+	// The commit above predates the plan by a second, so it is the base
+	time.Sleep(1100 * time.Millisecond)
+	plan := &artifacts.Plan{ID: "plan-1", Goal: "Fix main.go", Steps: []artifacts.PlanStep{{ID: "1", Description: "Edit main.go"}}}
+	if err := artifacts.SaveArtifact(tmpDir, artifacts.ArtifactPlan, plan); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Check(tmpDir, isCode); ok {
+		t.Error("Check() without changes reported drift")
+	}
+
+	write("main.go")
+	write("notes.txt")
+	run("add", ".")
+	run("commit", "-qm", "work")
+	write("pkg/extra.go")
+	write("pkg/more.go")
+
+	// A revision of the same plan keeps the base of the first save
+	time.Sleep(1100 * time.Millisecond)
+	plan.Steps = append(plan.Steps, artifacts.PlanStep{ID: "2", Description: "Test it"})
+	if err := artifacts.SaveArtifact(tmpDir, artifacts.ArtifactPlan, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	r, ok := Check(tmpDir, isCode)
+	if !ok {
+		t.Fatal("Check() found no drift")
+	}
+	if strings.Join(r.Touched, ",") != "main.go,pkg/extra.go,pkg/more.go" {
+		t.Errorf("Touched = %v, want the committed and uncommitted code files", r.Touched)
+	}
+	if strings.Join(r.Unplanned, ",") != "pkg/extra.go,pkg/more.go" {
+		t.Errorf("Unplanned = %v, want the pkg files", r.Unplanned)
+	}
+}
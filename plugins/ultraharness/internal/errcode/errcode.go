@@ -0,0 +1,183 @@
+// Package errcode classifies the errors hooks recover from into categories
+// with stable codes. A hook never fails the tool call, so most errors end
+// in empty output; reporting them here records their codes in the hook
+// log, and with error_codes on, shows a terse line such as
+//
+//	[FIC:E012] state unreadable — run /ultraharness:repair
+//
+// so a user can quote the code when asking for help.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/schema"
+)
+
+// Category groups codes by what went wrong.
+type Category string
+
+// Categories
+const (
+	// CategoryProtocol is hook input or output that could not be handled
+	CategoryProtocol Category = "protocol"
+	// CategoryState is harness state that is missing, corrupt, or unusable
+	CategoryState Category = "state"
+	// CategoryPermission is a file the harness may not read or write
+	CategoryPermission Category = "permission"
+	// CategoryTimeout is an operation that ran out of time
+	CategoryTimeout Category = "timeout"
+	// CategoryConfig is a config file that could not be loaded
+	CategoryConfig Category = "config"
+	// CategoryInternal is anything else
+	CategoryInternal Category = "internal"
+)
+
+// Code is a stable error code. IDs never change meaning once released;
+// retired codes are not reused.
+type Code struct {
+	// ID is the code quoted to users, e.g. "E012"
+	ID       string
+	Category Category
+	// Summary says what went wrong in a few words
+	Summary string
+	// Remedy is the first thing to try, if there is one
+	Remedy string
+}
+
+// Codes, numbered by category: E00x protocol, E01x state, E02x
+// permission, E03x timeout, E04x config, E09x internal.
+var (
+	InputInvalid       = Code{"E001", CategoryProtocol, "hook input unreadable", "update ultraharness to match Claude Code"}
+	StorageUnavailable = Code{"E011", CategoryState, "state storage unavailable", "check storage_backend and encryption in .claude/claude-harness.json"}
+	StateUnreadable    = Code{"E012", CategoryState, "state unreadable", "run /ultraharness:repair"}
+	StateNewer         = Code{"E013", CategoryState, "state written by a newer version", "update ultraharness"}
+	StateCorrupt       = Code{"E014", CategoryState, "state corrupt and reset", "see .claude/corrupt"}
+	StateNotSaved      = Code{"E015", CategoryState, "state not saved", "run /ultraharness:repair"}
+	PermissionDenied   = Code{"E021", CategoryPermission, "permission denied", "check the permissions of .claude"}
+	TimedOut           = Code{"E031", CategoryTimeout, "timed out", "raise the timeout in .claude/claude-harness.json"}
+	ConfigUnreadable   = Code{"E041", CategoryConfig, "config unreadable", "fix the JSON in .claude/claude-harness.json"}
+	Internal           = Code{"E099", CategoryInternal, "internal error", "report it with .claude/hook-log.jsonl"}
+)
+
+// All lists every code, in ID order.
+var All = []Code{
+	InputInvalid,
+	StorageUnavailable, StateUnreadable, StateNewer, StateCorrupt, StateNotSaved,
+	PermissionDenied,
+	TimedOut,
+	ConfigUnreadable,
+	Internal,
+}
+
+// Error is an error with a code.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// New returns err with code, or nil if err is nil.
+func New(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Code.ID, e.Code.Summary, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the code of err. Permission errors, timeouts, and state
+// from a newer version get their own codes whatever err was created with,
+// since those say more about the fix; otherwise it is the code err was
+// created with, or Internal.
+func Classify(err error) Code {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return PermissionDenied
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, context.DeadlineExceeded):
+		return TimedOut
+	case errors.Is(err, schema.ErrNewer):
+		return StateNewer
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return Internal
+}
+
+// Message is the terse line shown for code.
+func Message(code Code) string {
+	msg := fmt.Sprintf("[FIC:%s] %s", code.ID, code.Summary)
+	if code.Remedy != "" {
+		msg += " — " + code.Remedy
+	}
+	return msg
+}
+
+// visible shows reported codes to the user as notices
+var visible bool
+
+// reported are the codes reported in this run, in order
+var reported []string
+
+// SetVisible turns showing reported codes on or off.
+func SetVisible(enabled bool) {
+	visible = enabled
+}
+
+// Report records err, which the hook recovered from, for the hook log and,
+// when codes are visible, queues its message as a notice. It returns the
+// code, and does nothing for a nil error.
+func Report(err error) Code {
+	if err == nil {
+		return Code{}
+	}
+	code, added := record(err)
+	if added && visible {
+		protocol.AddNotice(Message(code))
+	}
+	return code
+}
+
+// Record records err for the hook log without showing it, for errors the
+// user has already been told about. It returns the code.
+func Record(err error) Code {
+	if err == nil {
+		return Code{}
+	}
+	code, _ := record(err)
+	return code
+}
+
+// record classifies err and adds its code to reported, reporting whether
+// the code is new in this run.
+func record(err error) (Code, bool) {
+	code := Classify(err)
+	for _, id := range reported {
+		if id == code.ID {
+			return code, false
+		}
+	}
+	reported = append(reported, code.ID)
+	return code, true
+}
+
+// Take returns the IDs of the codes reported since the last call, without
+// duplicates, and clears them.
+func Take() []string {
+	taken := reported
+	reported = nil
+	return taken
+}
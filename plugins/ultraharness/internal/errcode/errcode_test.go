@@ -0,0 +1,95 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/schema"
+)
+
+func TestCodesStable(t *testing.T) {
+	seen := map[string]bool{}
+	prev := ""
+	for _, c := range All {
+		if len(c.ID) != 4 || c.ID[0] != 'E' || c.Summary == "" || c.Category == "" {
+			t.Errorf("malformed code %+v", c)
+		}
+		if seen[c.ID] {
+			t.Errorf("duplicate code %s", c.ID)
+		}
+		if c.ID <= prev {
+			t.Errorf("code %s listed after %s, want ID order", c.ID, prev)
+		}
+		seen[c.ID], prev = true, c.ID
+	}
+	// Users quote these; changing one breaks their reports and our docs
+	if StateUnreadable.ID != "E012" || ConfigUnreadable.ID != "E041" || InputInvalid.ID != "E001" {
+		t.Error("a released code changed its ID")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"coded", New(StateUnreadable, errors.New("bad")), StateUnreadable},
+		{"wrapped coded", fmt.Errorf("loading: %w", New(ConfigUnreadable, errors.New("bad"))), ConfigUnreadable},
+		{"permission wins", New(StateUnreadable, &os.PathError{Op: "open", Path: "x", Err: os.ErrPermission}), PermissionDenied},
+		{"deadline", fmt.Errorf("git: %w", context.DeadlineExceeded), TimedOut},
+		{"newer state", New(StateUnreadable, fmt.Errorf("state: %w", schema.ErrNewer)), StateNewer},
+		{"plain", errors.New("boom"), Internal},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%s) = %s, want %s", tt.name, got.ID, tt.want.ID)
+		}
+	}
+	if New(StateUnreadable, nil) != nil {
+		t.Error("New() of a nil error is not nil")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if got := Message(StateUnreadable); got != "[FIC:E012] state unreadable — run /ultraharness:repair" {
+		t.Errorf("Message() = %q", got)
+	}
+	if got := Message(Code{ID: "E000", Summary: "test"}); strings.Contains(got, "—") {
+		t.Errorf("Message() of a code without a remedy = %q", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	defer SetVisible(false)
+	defer protocol.TakeNotices()
+
+	Report(New(StateUnreadable, errors.New("a")))
+	Report(New(StateUnreadable, errors.New("b")))
+	Record(New(StateCorrupt, errors.New("c")))
+	Report(nil)
+	if got := Take(); !reflect.DeepEqual(got, []string{"E012", "E014"}) {
+		t.Errorf("Take() = %v, want [E012 E014]", got)
+	}
+	if got := Take(); got != nil {
+		t.Errorf("Take() again = %v, want none", got)
+	}
+	if notices := protocol.TakeNotices(); notices != nil {
+		t.Errorf("notices with codes hidden = %v, want none", notices)
+	}
+
+	SetVisible(true)
+	Report(New(StateUnreadable, errors.New("a")))
+	Report(New(StateUnreadable, errors.New("b")))
+	Record(New(StateCorrupt, errors.New("c")))
+	Take()
+	if notices := protocol.TakeNotices(); !reflect.DeepEqual(notices, []string{Message(StateUnreadable)}) {
+		t.Errorf("notices = %v, want the E012 message once", notices)
+	}
+}
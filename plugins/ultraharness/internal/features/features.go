@@ -5,23 +5,43 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 // FeaturesFile is the name of the features file.
 const FeaturesFile = "claude-features.json"
 
+// Feature status values.
+const (
+	StatusPassing    = "passing"
+	StatusFailing    = "failing"
+	StatusInProgress = "in_progress"
+	StatusPending    = "pending"
+)
+
+// DefaultPriority is used for features without an explicit priority.
+// Lower numbers are higher priority.
+const DefaultPriority = 999
+
 // Feature represents a single feature in the checklist.
 type Feature struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Status      string `json:"status"` // passing, failing, in_progress, pending
-	Priority    int    `json:"priority,omitempty"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Category    string   `json:"category,omitempty"`
+	Status      string   `json:"status"` // passing, failing, in_progress, pending
+	Priority    int      `json:"priority,omitempty"`
+	DependsOn   []string `json:"depends_on,omitempty"` // IDs of prerequisite features
+
+	// AcceptanceCriteria are checks run by verify_feature to decide pass/fail
+	AcceptanceCriteria []Criterion   `json:"acceptance_criteria,omitempty"`
+	Verification       *Verification `json:"verification,omitempty"`
 }
 
 // FeaturesData represents the features checklist file structure.
 type FeaturesData struct {
-	Features []Feature `json:"features"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Features []Feature              `json:"features"`
 }
 
 // Summary provides aggregate information about features.
@@ -31,7 +51,16 @@ type Summary struct {
 	Failing    int
 	InProgress int
 	Pending    int
+	Blocked    int // not passing and waiting on prerequisites
 	NextItems  []Feature
+	// NextActionable is the highest-priority feature whose dependencies all pass
+	NextActionable *Feature
+}
+
+// DependencyViolation describes a feature started before its prerequisites pass.
+type DependencyViolation struct {
+	Feature Feature
+	Unmet   []string
 }
 
 // Load reads and parses the features checklist file.
@@ -42,6 +71,20 @@ func Load(workDir string) (*FeaturesData, error) {
 		return nil, err
 	}
 
+	return Parse(data)
+}
+
+// Save writes the features checklist file.
+func Save(workDir string, data *FeaturesData) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(workDir, FeaturesFile), append(content, '\n'), 0644)
+}
+
+// Parse parses features checklist content.
+func Parse(data []byte) (*FeaturesData, error) {
 	var features FeaturesData
 	if err := json.Unmarshal(data, &features); err != nil {
 		return nil, err
@@ -80,7 +123,7 @@ func GetSummary(workDir string) (*Summary, error) {
 	}
 
 	// Get next priority items (failing and in_progress, up to 5)
-	for _, f := range data.Features {
+	for _, f := range sortByPriority(data.Features) {
 		if f.Status == "failing" || f.Status == "in_progress" {
 			summary.NextItems = append(summary.NextItems, f)
 			if len(summary.NextItems) >= 5 {
@@ -89,9 +132,111 @@ func GetSummary(workDir string) (*Summary, error) {
 		}
 	}
 
+	for _, f := range data.Features {
+		if f.Status != StatusPassing && len(data.UnmetDependencies(f)) > 0 {
+			summary.Blocked++
+		}
+	}
+	summary.NextActionable = data.NextActionable()
+
 	return summary, nil
 }
 
+// GetPriority returns the feature priority, using DefaultPriority when unset.
+func (f *Feature) GetPriority() int {
+	if f.Priority <= 0 {
+		return DefaultPriority
+	}
+	return f.Priority
+}
+
+// Find returns the feature with the given ID, or nil if not found.
+func (d *FeaturesData) Find(id string) *Feature {
+	for i := range d.Features {
+		if d.Features[i].ID == id {
+			return &d.Features[i]
+		}
+	}
+	return nil
+}
+
+// UnmetDependencies returns IDs of prerequisites that are not passing.
+// Unknown prerequisite IDs count as unmet.
+func (d *FeaturesData) UnmetDependencies(f Feature) []string {
+	var unmet []string
+	for _, dep := range f.DependsOn {
+		prereq := d.Find(dep)
+		if prereq == nil || prereq.Status != StatusPassing {
+			unmet = append(unmet, dep)
+		}
+	}
+	return unmet
+}
+
+// NextActionable returns the feature to work on next: work already in
+// progress first, then the highest-priority feature whose dependencies all
+// pass. Returns nil if nothing is actionable.
+func (d *FeaturesData) NextActionable() *Feature {
+	for _, f := range sortByPriority(d.Features) {
+		if f.Status == StatusPassing {
+			continue
+		}
+		if len(d.UnmetDependencies(f)) > 0 {
+			continue
+		}
+		next := f
+		return &next
+	}
+	return nil
+}
+
+// DependencyViolations returns in-progress features whose prerequisites are not passing.
+func (d *FeaturesData) DependencyViolations() []DependencyViolation {
+	var violations []DependencyViolation
+	for _, f := range d.Features {
+		if f.Status != StatusInProgress {
+			continue
+		}
+		if unmet := d.UnmetDependencies(f); len(unmet) > 0 {
+			violations = append(violations, DependencyViolation{Feature: f, Unmet: unmet})
+		}
+	}
+	return violations
+}
+
+// NewDependencyViolations returns the dependency violations of features
+// that are in progress in d but were not in before, so an edit is only
+// held to the features it starts. A nil before counts every in-progress
+// feature as new.
+func (d *FeaturesData) NewDependencyViolations(before *FeaturesData) []DependencyViolation {
+	var violations []DependencyViolation
+	for _, v := range d.DependencyViolations() {
+		if before != nil {
+			if f := before.Find(v.Feature.ID); f != nil && f.Status == StatusInProgress {
+				continue
+			}
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// sortByPriority returns a copy of features ordered with in-progress work
+// first, then by ascending priority, preserving file order for ties.
+func sortByPriority(features []Feature) []Feature {
+	sorted := make([]Feature, len(features))
+	copy(sorted, features)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iWIP := sorted[i].Status == StatusInProgress
+		jWIP := sorted[j].Status == StatusInProgress
+		if iWIP != jWIP {
+			return iWIP
+		}
+		return sorted[i].GetPriority() < sorted[j].GetPriority()
+	})
+	return sorted
+}
+
 // GetInProgress returns features currently in progress.
 func GetInProgress(workDir string) ([]Feature, error) {
 	data, err := Load(workDir)
@@ -0,0 +1,136 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testChecklist() *FeaturesData {
+	return &FeaturesData{
+		Features: []Feature{
+			{ID: "F1", Name: "Auth", Status: StatusPassing, Priority: 1},
+			{ID: "F2", Name: "Profile", Status: StatusFailing, Priority: 3, DependsOn: []string{"F1"}},
+			{ID: "F3", Name: "Billing", Status: StatusFailing, Priority: 2, DependsOn: []string{"F2"}},
+			{ID: "F4", Name: "Search", Status: StatusPending},
+		},
+	}
+}
+
+func TestUnmetDependencies(t *testing.T) {
+	data := testChecklist()
+
+	if unmet := data.UnmetDependencies(*data.Find("F2")); len(unmet) != 0 {
+		t.Errorf("UnmetDependencies(F2) = %v, want none", unmet)
+	}
+	if unmet := data.UnmetDependencies(*data.Find("F3")); len(unmet) != 1 || unmet[0] != "F2" {
+		t.Errorf("UnmetDependencies(F3) = %v, want [F2]", unmet)
+	}
+
+	unknown := Feature{ID: "F9", DependsOn: []string{"missing"}}
+	if unmet := data.UnmetDependencies(unknown); len(unmet) != 1 {
+		t.Errorf("UnmetDependencies() with unknown prerequisite = %v, want [missing]", unmet)
+	}
+}
+
+func TestNextActionable(t *testing.T) {
+	t.Run("skips features with unmet dependencies", func(t *testing.T) {
+		data := testChecklist()
+
+		// F3 has higher priority than F2 but depends on it
+		next := data.NextActionable()
+		if next == nil || next.ID != "F2" {
+			t.Errorf("NextActionable() = %v, want F2", next)
+		}
+	})
+
+	t.Run("in-progress work comes first", func(t *testing.T) {
+		data := testChecklist()
+		data.Find("F4").Status = StatusInProgress
+
+		next := data.NextActionable()
+		if next == nil || next.ID != "F4" {
+			t.Errorf("NextActionable() = %v, want F4", next)
+		}
+	})
+
+	t.Run("nothing actionable when all passing", func(t *testing.T) {
+		data := &FeaturesData{Features: []Feature{{ID: "F1", Status: StatusPassing}}}
+		if next := data.NextActionable(); next != nil {
+			t.Errorf("NextActionable() = %v, want nil", next)
+		}
+	})
+}
+
+func TestDependencyViolations(t *testing.T) {
+	data := testChecklist()
+	if v := data.DependencyViolations(); len(v) != 0 {
+		t.Errorf("DependencyViolations() = %v, want none", v)
+	}
+
+	data.Find("F3").Status = StatusInProgress
+	violations := data.DependencyViolations()
+	if len(violations) != 1 {
+		t.Fatalf("DependencyViolations() = %v, want 1", violations)
+	}
+	if violations[0].Feature.ID != "F3" || violations[0].Unmet[0] != "F2" {
+		t.Errorf("violation = %+v, want F3 waiting on F2", violations[0])
+	}
+}
+
+func TestNewDependencyViolations(t *testing.T) {
+	before := testChecklist()
+	before.Find("F3").Status = StatusInProgress
+	after := testChecklist()
+	after.Find("F3").Status = StatusInProgress
+	after.Find("F1").Name = "Renamed"
+
+	if v := after.NewDependencyViolations(before); len(v) != 0 {
+		t.Errorf("NewDependencyViolations() for an unrelated edit = %v, want none", v)
+	}
+	if v := after.NewDependencyViolations(nil); len(v) != 1 || v[0].Feature.ID != "F3" {
+		t.Errorf("NewDependencyViolations(nil) = %v, want F3", v)
+	}
+	before.Find("F3").Status = StatusFailing
+	if v := after.NewDependencyViolations(before); len(v) != 1 || v[0].Feature.ID != "F3" {
+		t.Errorf("NewDependencyViolations() starting F3 = %v, want F3", v)
+	}
+}
+
+func TestGetSummary(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "features-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	content := `{
+  "metadata": {"project": "demo"},
+  "features": [
+    {"id": "F1", "name": "Auth", "status": "passing", "priority": 1},
+    {"id": "F2", "name": "Profile", "status": "failing", "priority": 5},
+    {"id": "F3", "name": "Billing", "status": "failing", "priority": 2, "depends_on": ["F2"]}
+  ]
+}`
+	if err := os.WriteFile(filepath.Join(tmpDir, FeaturesFile), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write features file: %v", err)
+	}
+
+	summary, err := GetSummary(tmpDir)
+	if err != nil {
+		t.Fatalf("GetSummary() error = %v", err)
+	}
+
+	if summary.Total != 3 || summary.Passing != 1 || summary.Failing != 2 {
+		t.Errorf("counts = %+v, want 3 total, 1 passing, 2 failing", summary)
+	}
+	if summary.Blocked != 1 {
+		t.Errorf("Blocked = %v, want 1", summary.Blocked)
+	}
+	if len(summary.NextItems) != 2 || summary.NextItems[0].ID != "F3" {
+		t.Errorf("NextItems = %v, want priority order [F3 F2]", summary.NextItems)
+	}
+	if summary.NextActionable == nil || summary.NextActionable.ID != "F2" {
+		t.Errorf("NextActionable = %v, want F2", summary.NextActionable)
+	}
+}
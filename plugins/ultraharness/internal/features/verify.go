@@ -0,0 +1,188 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"time"
+
+	"ultraharness/internal/git"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/testrunner"
+	"ultraharness/internal/text"
+)
+
+// DefaultVerifyTimeout is the per-criterion timeout for verification commands.
+const DefaultVerifyTimeout = 120 * time.Second
+
+// MaxVerifyOutput limits the output stored per criterion result.
+const MaxVerifyOutput = 500
+
+// Criterion is a single acceptance check. Either Command (a shell command
+// that must exit 0) or TestFilter (a filter passed to the project's test
+// command) must be set. A plain JSON string is treated as a Command.
+type Criterion struct {
+	Command    string `json:"command,omitempty"`
+	TestFilter string `json:"test_filter,omitempty"`
+}
+
+// UnmarshalJSON accepts either a command string or a criterion object.
+func (c *Criterion) UnmarshalJSON(data []byte) error {
+	var command string
+	if err := json.Unmarshal(data, &command); err == nil {
+		c.Command = command
+		return nil
+	}
+
+	type plain Criterion
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*c = Criterion(p)
+	return nil
+}
+
+// MarshalJSON writes command-only criteria back as plain strings.
+func (c Criterion) MarshalJSON() ([]byte, error) {
+	if c.TestFilter == "" {
+		return json.Marshal(c.Command)
+	}
+
+	type plain Criterion
+	return json.Marshal(plain(c))
+}
+
+// String returns a short description of the criterion.
+func (c Criterion) String() string {
+	if c.TestFilter != "" {
+		return "tests matching " + c.TestFilter
+	}
+	return c.Command
+}
+
+// Verification records the outcome of the last verify_feature run.
+type Verification struct {
+	VerifiedAt string `json:"verified_at"`
+	// Tree is the TreeFingerprint of the working tree the criteria ran on
+	Tree    string            `json:"tree,omitempty"`
+	Passed  bool              `json:"passed"`
+	Results []CriterionResult `json:"results,omitempty"`
+}
+
+// CriterionResult is the outcome of a single acceptance criterion.
+type CriterionResult struct {
+	Criterion string `json:"criterion"`
+	Passed    bool   `json:"passed"`
+	Output    string `json:"output,omitempty"`
+}
+
+// TreeFingerprint returns the fingerprint of the working tree that
+// verifications are recorded against. The features and progress files are
+// left out, since recording a verification writes them.
+func TreeFingerprint(workDir string) string {
+	return git.StateFingerprint(workDir, FeaturesFile, progress.ProgressFileName)
+}
+
+// IsVerified returns true if the feature has no acceptance criteria, or
+// its last verification run passed on tree, the current TreeFingerprint.
+// A verification goes stale once files change after it.
+func (f *Feature) IsVerified(tree string) bool {
+	if len(f.AcceptanceCriteria) == 0 {
+		return true
+	}
+	return f.Verification != nil && f.Verification.Passed && f.Verification.Tree == tree
+}
+
+// UnverifiedPassing returns features marked passing whose acceptance
+// criteria have not been verified by a successful run on tree, the current
+// TreeFingerprint.
+func (d *FeaturesData) UnverifiedPassing(tree string) []Feature {
+	var unverified []Feature
+	for _, f := range d.Features {
+		if f.Status == StatusPassing && !f.IsVerified(tree) {
+			unverified = append(unverified, f)
+		}
+	}
+	return unverified
+}
+
+// Verify runs all acceptance criteria for the feature, records the result,
+// and flips its status to passing or failing. Features without criteria
+// are left unchanged and return nil.
+func Verify(workDir string, f *Feature, timeout time.Duration) *Verification {
+	if len(f.AcceptanceCriteria) == 0 {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = DefaultVerifyTimeout
+	}
+
+	verification := &Verification{
+		VerifiedAt: time.Now().Format(time.RFC3339),
+		Passed:     true,
+	}
+
+	for _, criterion := range f.AcceptanceCriteria {
+		result := runCriterion(workDir, criterion, timeout)
+		if !result.Passed {
+			verification.Passed = false
+		}
+		verification.Results = append(verification.Results, result)
+	}
+
+	// Criteria can write files, so the tree is taken after they ran
+	verification.Tree = TreeFingerprint(workDir)
+	f.Verification = verification
+	if verification.Passed {
+		f.Status = StatusPassing
+	} else {
+		f.Status = StatusFailing
+	}
+	return verification
+}
+
+func runCriterion(workDir string, criterion Criterion, timeout time.Duration) CriterionResult {
+	result := CriterionResult{Criterion: criterion.String()}
+
+	if criterion.TestFilter != "" {
+		summary := testrunner.RunFiltered(workDir, criterion.TestFilter, timeout)
+		result.Passed = summary.Result == testrunner.Passed
+		result.Output = tail(summary.RawOutput)
+		if summary.Result == testrunner.NotRun {
+			result.Output = "no test command detected for this project"
+		}
+		return result
+	}
+
+	if strings.TrimSpace(criterion.Command) == "" {
+		result.Output = "empty criterion"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "bash", "-c", criterion.Command)
+	cmd.Dir = workDir
+	output, err := cmd.CombinedOutput()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Output = "timed out after " + timeout.String()
+		return result
+	}
+
+	result.Passed = err == nil
+	result.Output = tail(string(output))
+	return result
+}
+
+// tail keeps the end of command output, where failures are usually reported.
+func tail(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > MaxVerifyOutput {
+		return "...[truncated]" + text.Suffix(output, MaxVerifyOutput)
+	}
+	return output
+}
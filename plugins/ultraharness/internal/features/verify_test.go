@@ -0,0 +1,116 @@
+package features
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCriterionUnmarshal(t *testing.T) {
+	data, err := Parse([]byte(`{"features": [{"id": "F1", "acceptance_criteria": ["true", {"test_filter": "TestLogin"}]}]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	criteria := data.Features[0].AcceptanceCriteria
+	if len(criteria) != 2 {
+		t.Fatalf("len(AcceptanceCriteria) = %d, want 2", len(criteria))
+	}
+	if criteria[0].Command != "true" {
+		t.Errorf("criteria[0].Command = %q, want %q", criteria[0].Command, "true")
+	}
+	if criteria[1].TestFilter != "TestLogin" {
+		t.Errorf("criteria[1].TestFilter = %q, want %q", criteria[1].TestFilter, "TestLogin")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "features-verify-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		name       string
+		criteria   []Criterion
+		wantStatus string
+	}{
+		{"all pass", []Criterion{{Command: "true"}, {Command: "exit 0"}}, StatusPassing},
+		{"one fails", []Criterion{{Command: "true"}, {Command: "exit 3"}}, StatusFailing},
+		{"empty command fails", []Criterion{{Command: " "}}, StatusFailing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Feature{ID: "F1", Status: StatusInProgress, AcceptanceCriteria: tt.criteria}
+
+			v := Verify(tmpDir, f, 0)
+			if v == nil {
+				t.Fatal("Verify() = nil, want result")
+			}
+			if f.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", f.Status, tt.wantStatus)
+			}
+			if len(v.Results) != len(tt.criteria) {
+				t.Errorf("len(Results) = %d, want %d", len(v.Results), len(tt.criteria))
+			}
+			if f.IsVerified(TreeFingerprint(tmpDir)) != (tt.wantStatus == StatusPassing) {
+				t.Errorf("IsVerified() = %v", f.IsVerified(TreeFingerprint(tmpDir)))
+			}
+		})
+	}
+
+	t.Run("no criteria", func(t *testing.T) {
+		f := &Feature{ID: "F2", Status: StatusPending}
+		if v := Verify(tmpDir, f, 0); v != nil {
+			t.Errorf("Verify() = %+v, want nil", v)
+		}
+		if f.Status != StatusPending {
+			t.Errorf("Status = %q, want unchanged", f.Status)
+		}
+	})
+}
+
+func TestUnverifiedPassing(t *testing.T) {
+	data := &FeaturesData{
+		Features: []Feature{
+			{ID: "F1", Status: StatusPassing},
+			{ID: "F2", Status: StatusPassing, AcceptanceCriteria: []Criterion{{Command: "true"}}},
+			{ID: "F3", Status: StatusPassing, AcceptanceCriteria: []Criterion{{Command: "true"}},
+				Verification: &Verification{Passed: true, Tree: "current"}},
+			{ID: "F4", Status: StatusFailing, AcceptanceCriteria: []Criterion{{Command: "true"}}},
+			{ID: "F5", Status: StatusPassing, AcceptanceCriteria: []Criterion{{Command: "true"}},
+				Verification: &Verification{Passed: true, Tree: "before"}},
+		},
+	}
+
+	unverified := data.UnverifiedPassing("current")
+	if len(unverified) != 2 || unverified[0].ID != "F2" || unverified[1].ID != "F5" {
+		t.Errorf("UnverifiedPassing() = %v, want [F2 F5]", unverified)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "features-save-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	data := testChecklist()
+	data.Features[0].AcceptanceCriteria = []Criterion{{Command: "true"}}
+	if err := Save(tmpDir, data); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Features) != len(data.Features) {
+		t.Errorf("len(Features) = %d, want %d", len(loaded.Features), len(data.Features))
+	}
+	if got := loaded.Find("F1").AcceptanceCriteria; len(got) != 1 || got[0].Command != "true" {
+		t.Errorf("AcceptanceCriteria = %v, want [true]", got)
+	}
+}
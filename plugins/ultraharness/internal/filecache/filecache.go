@@ -0,0 +1,88 @@
+// Package filecache caches the config and state files a hook reads, so
+// reading the same file again in one run costs a stat instead of a read
+// and a copy from disk.
+//
+// The cache lives in the process's memory. Every hook invocation is a new
+// process and starts with it empty, so it saves repeated reads within one
+// invocation, not across the calls of a tool batch.
+//
+// Every read is revalidated: a file whose size and modification time are
+// unchanged since it was cached is served from memory. Writers in this
+// process call Invalidate, so a rewrite that lands within the file
+// system's timestamp granularity is never served stale.
+package filecache
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+var (
+	mu      sync.Mutex
+	entries = map[string]entry{}
+	hits    int
+	misses  int
+)
+
+// ReadFile returns the contents of path, like os.ReadFile. The returned
+// slice is the caller's to modify.
+func ReadFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		Invalidate(path)
+		return nil, err
+	}
+
+	mu.Lock()
+	e, ok := entries[path]
+	if ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		hits++
+		mu.Unlock()
+		return append([]byte(nil), e.data...), nil
+	}
+	misses++
+	mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		Invalidate(path)
+		return nil, err
+	}
+	// A write between the stat and the read leaves a newer modification
+	// time on disk than the one cached, so the next read reloads
+	mu.Lock()
+	entries[path] = entry{size: info.Size(), modTime: info.ModTime(), data: append([]byte(nil), data...)}
+	mu.Unlock()
+	return data, nil
+}
+
+// Invalidate drops path from the cache. Call it after writing, renaming
+// onto, or removing a cached file.
+func Invalidate(path string) {
+	mu.Lock()
+	delete(entries, path)
+	mu.Unlock()
+}
+
+// Reset empties the cache and its counters.
+func Reset() {
+	mu.Lock()
+	entries = map[string]entry{}
+	hits, misses = 0, 0
+	mu.Unlock()
+}
+
+// Stats returns the reads served from the cache and from disk since the
+// last Reset.
+func Stats() (cached, read int) {
+	mu.Lock()
+	defer mu.Unlock()
+	return hits, misses
+}
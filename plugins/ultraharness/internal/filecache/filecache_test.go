@@ -0,0 +1,78 @@
+package filecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "filecache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	Reset()
+	defer Reset()
+
+	path := filepath.Join(tmpDir, "state.json")
+	if _, err := ReadFile(path); !os.IsNotExist(err) {
+		t.Fatalf("ReadFile() of missing file error = %v, want not exist", err)
+	}
+
+	os.WriteFile(path, []byte(`{"a": 1}`), 0600)
+	for i := 0; i < 3; i++ {
+		data, err := ReadFile(path)
+		if err != nil || string(data) != `{"a": 1}` {
+			t.Fatalf("ReadFile() = %q, %v", data, err)
+		}
+		data[0] = 'x'
+	}
+	if cached, read := Stats(); cached != 2 || read != 1 {
+		t.Errorf("Stats() = %d cached, %d read, want 2 and 1", cached, read)
+	}
+
+	// A change on disk is picked up from the modification time
+	os.WriteFile(path, []byte(`{"a": 2}`), 0600)
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	if data, _ := ReadFile(path); string(data) != `{"a": 2}` {
+		t.Errorf("ReadFile() after a change = %q, want the new contents", data)
+	}
+
+	// A same-size rewrite within the timestamp granularity needs Invalidate
+	os.WriteFile(path, []byte(`{"a": 3}`), 0600)
+	os.Chtimes(path, later, later)
+	Invalidate(path)
+	if data, _ := ReadFile(path); string(data) != `{"a": 3}` {
+		t.Errorf("ReadFile() after Invalidate = %q, want the new contents", data)
+	}
+
+	os.Remove(path)
+	if _, err := ReadFile(path); !os.IsNotExist(err) {
+		t.Errorf("ReadFile() of removed file error = %v, want not exist", err)
+	}
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	tmpDir, err := os.MkdirTemp("", "filecache-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := filepath.Join(tmpDir, "state.json")
+	os.WriteFile(path, make([]byte, 8192), 0600)
+
+	b.Run("cached", func(b *testing.B) {
+		Reset()
+		for i := 0; i < b.N; i++ {
+			ReadFile(path)
+		}
+	})
+	b.Run("os", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			os.ReadFile(path)
+		}
+	})
+}
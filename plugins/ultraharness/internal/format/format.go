@@ -0,0 +1,150 @@
+// Package format runs the project's code formatter on a single file and
+// reports whether it changed anything.
+package format
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/text"
+)
+
+// DefaultTimeout bounds each formatter run.
+const DefaultTimeout = 10 * time.Second
+
+// MaxOutputLength limits the reported formatter output.
+const MaxOutputLength = 500
+
+// FilePlaceholder in a formatter command is replaced by the file path.
+const FilePlaceholder = "{file}"
+
+// Formatter reformats files with the given extensions in place.
+type Formatter struct {
+	Name       string
+	Extensions []string
+	Command    []string
+}
+
+// Defaults lists the built-in formatters.
+var Defaults = []Formatter{
+	{Name: "gofmt", Extensions: []string{".go"}, Command: []string{"gofmt", "-w", FilePlaceholder}},
+	{
+		Name: "prettier",
+		Extensions: []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".css", ".scss",
+			".json", ".md", ".yaml", ".yml", ".html", ".vue", ".svelte"},
+		Command: []string{"prettier", "--write", "--log-level", "warn", FilePlaceholder},
+	},
+	{Name: "black", Extensions: []string{".py"}, Command: []string{"black", "-q", FilePlaceholder}},
+	{Name: "rustfmt", Extensions: []string{".rs"}, Command: []string{"rustfmt", FilePlaceholder}},
+}
+
+// Result is the outcome of formatting a file.
+type Result struct {
+	Formatter string
+	Changed   bool
+	Error     string
+}
+
+// CommandFor returns the formatter command for path. overrides maps file
+// extensions to commands and takes precedence over Defaults; an empty
+// command disables formatting for that extension. Returns nil if no
+// formatter applies.
+func CommandFor(path string, overrides map[string][]string) []string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return nil
+	}
+	if command, ok := overrides[ext]; ok {
+		return command
+	}
+	for _, f := range Defaults {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f.Command
+			}
+		}
+	}
+	return nil
+}
+
+// Run formats path in place. Returns nil if no formatter applies or its
+// tool is not installed.
+func Run(workDir, path string, overrides map[string][]string, timeout time.Duration) *Result {
+	command := CommandFor(path, overrides)
+	if len(command) == 0 {
+		return nil
+	}
+	executable := resolve(workDir, command[0])
+	if executable == "" {
+		return nil
+	}
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, strings.ReplaceAll(arg, FilePlaceholder, path))
+	}
+
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Dir = workDir
+	cmd.WaitDelay = time.Second
+
+	result := &Result{Formatter: filepath.Base(command[0])}
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Error = "timed out after " + timeout.String()
+		return result
+	}
+	if err != nil {
+		result.Error = formatOutput(string(output), workDir)
+		if result.Error == "" {
+			result.Error = err.Error()
+		}
+	}
+
+	after, err := os.ReadFile(path)
+	result.Changed = err == nil && !bytes.Equal(before, after)
+	return result
+}
+
+// resolve finds the formatter executable on PATH, falling back to the
+// project's node_modules/.bin for locally installed tools like prettier.
+func resolve(workDir, name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	if filepath.Base(name) != name {
+		return ""
+	}
+	local := filepath.Join(workDir, "node_modules", ".bin", name)
+	if info, err := os.Stat(local); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+		return local
+	}
+	return ""
+}
+
+func formatOutput(output, workDir string) string {
+	output = strings.TrimSpace(output)
+	if workDir != "" {
+		output = strings.ReplaceAll(output, strings.TrimSuffix(workDir, "/")+"/", "")
+	}
+	if len(output) > MaxOutputLength {
+		output = text.Prefix(output, MaxOutputLength) + "...[truncated]"
+	}
+	return output
+}
@@ -0,0 +1,92 @@
+package format
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCommandFor(t *testing.T) {
+	overrides := map[string][]string{
+		".py": {"ruff", "format", FilePlaceholder},
+		".md": {},
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"gofmt", "-w", FilePlaceholder}},
+		{"tool.py", []string{"ruff", "format", FilePlaceholder}},
+		{"README.md", []string{}},
+		{"Makefile", nil},
+		{"image.png", nil},
+	}
+
+	for _, tt := range tests {
+		if got := CommandFor(tt.path, overrides); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CommandFor(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRunGofmt(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("gofmt not installed")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "format-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "main.go")
+	os.WriteFile(path, []byte("package main\nfunc main() {  }\n"), 0644)
+
+	r := Run(tmpDir, path, nil, 0)
+	if r == nil || r.Error != "" || !r.Changed {
+		t.Fatalf("Run() = %+v, want reformatted", r)
+	}
+
+	r = Run(tmpDir, path, nil, 0)
+	if r == nil || r.Changed {
+		t.Errorf("Run() on formatted file = %+v, want unchanged", r)
+	}
+}
+
+func TestRunLocalTool(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "format-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// A project-local formatter in node_modules/.bin is used when the
+	// tool is not on PATH
+	bin := filepath.Join(tmpDir, "node_modules", ".bin")
+	os.MkdirAll(bin, 0755)
+	script := "#!/bin/sh\nprintf 'formatted\\n' > \"$2\"\n"
+	os.WriteFile(filepath.Join(bin, "fake-prettier"), []byte(script), 0755)
+
+	path := filepath.Join(tmpDir, "app.js")
+	os.WriteFile(path, []byte("ugly"), 0644)
+
+	t.Setenv("PATH", "/usr/bin:/bin")
+	overrides := map[string][]string{".js": {"fake-prettier", "--write", FilePlaceholder}}
+	r := Run(tmpDir, path, overrides, 0)
+	if r == nil || !r.Changed {
+		t.Fatalf("Run() = %+v, want reformatted by local tool", r)
+	}
+	if r.Formatter != "fake-prettier" {
+		t.Errorf("Run().Formatter = %q, want fake-prettier", r.Formatter)
+	}
+}
+
+func TestRunMissingTool(t *testing.T) {
+	overrides := map[string][]string{".go": {"no-such-formatter-xyz", FilePlaceholder}}
+	if r := Run(os.TempDir(), "main.go", overrides, 0); r != nil {
+		t.Errorf("Run() with missing tool = %+v, want nil", r)
+	}
+}
@@ -0,0 +1,212 @@
+package gates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/config"
+	"ultraharness/internal/storage"
+)
+
+// ErrNotReady reports that a phase cannot be marked done because its
+// prerequisites are not met
+var ErrNotReady = errors.New("prerequisites not met")
+
+// Completion describes a phase marked done
+type Completion struct {
+	State *FICState
+	// Research is the latest research artifact, if any
+	Research *artifacts.Research
+	// Plan is the latest plan artifact, if any
+	Plan *artifacts.Plan
+	// Overridden lists the unmet prerequisites a forced completion skipped
+	Overridden []string
+}
+
+// CompleteResearch marks research complete and moves the workflow on to
+// planning, opening the research gate. The latest research artifact must
+// be confident enough and have no blocking open questions; force marks
+// research complete regardless. Confidence counts after the configured
+// decay. The research's assumptions are pinned to
+// the current content of the files they depend on, saved together with
+// the state, so later sessions can tell when those files change.
+func CompleteResearch(workDir string, force bool) (*Completion, error) {
+	state, err := LoadFICState(workDir)
+	if err != nil {
+		return nil, err
+	}
+	c := &Completion{State: state}
+	if a, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch); a != nil {
+		c.Research, _ = a.(*artifacts.Research)
+	}
+
+	problems := ResearchProblems(c.Research)
+	if problem := decayProblem(workDir, c.Research); problem != "" {
+		problems = append(problems, problem)
+	}
+	if err := c.check(problems, force); err != nil {
+		return nil, err
+	}
+
+	state.ResearchComplete = true
+	if state.Phase == "" || state.Phase == "research" {
+		state.Phase = "planning"
+	}
+
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	err = backend.Update(func(tx storage.Tx) error {
+		if c.Research != nil && c.Research.PinAssumptions(workDir) > 0 {
+			c.Research.UpdatedAt = time.Now().Format(time.RFC3339)
+			if err := artifacts.PutLatest(tx, backend, artifacts.ArtifactResearch, c.Research); err != nil {
+				return err
+			}
+		}
+		return PutFICState(tx, state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ReopenResearch moves the workflow back to research, closing the research
+// gate, when research was marked complete but the plan has not been
+// validated yet. Returns whether it did.
+func ReopenResearch(workDir string) (bool, error) {
+	state, err := LoadFICState(workDir)
+	if err != nil {
+		return false, err
+	}
+	if !state.ResearchComplete || state.PlanValidated {
+		return false, nil
+	}
+	state.ResearchComplete = false
+	state.Phase = "research"
+	return true, SaveFICState(workDir, state)
+}
+
+// CompletePlan marks the plan validated and moves the workflow on to
+// implementation, opening the plan gate. Research must be complete, and
+// the latest plan must have steps and not have been sent back by
+// validation; force marks the plan validated regardless. A plan without a
+// validation result is recorded as validated, saved together with the
+// state so the two never disagree.
+func CompletePlan(workDir string, force bool) (*Completion, error) {
+	state, err := LoadFICState(workDir)
+	if err != nil {
+		return nil, err
+	}
+	c := &Completion{State: state}
+	if a, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan); a != nil {
+		c.Plan, _ = a.(*artifacts.Plan)
+	}
+
+	problems := PlanProblems(c.Plan)
+	if !state.ResearchComplete {
+		problems = append([]string{"research is not marked complete"}, problems...)
+	}
+	if err := c.check(problems, force); err != nil {
+		return nil, err
+	}
+
+	state.ResearchComplete = true
+	state.PlanValidated = true
+	state.Phase = "implementation"
+
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	err = backend.Update(func(tx storage.Tx) error {
+		if c.Plan != nil && c.Plan.ValidationResult == nil {
+			c.Plan.ValidationResult = &artifacts.ValidationResult{Recommendation: "PROCEED"}
+			c.Plan.UpdatedAt = time.Now().Format(time.RFC3339)
+			if err := artifacts.PutLatest(tx, backend, artifacts.ArtifactPlan, c.Plan); err != nil {
+				return err
+			}
+		}
+		return PutFICState(tx, state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// check fails with the unmet prerequisites, or records them as overridden
+// when forced
+func (c *Completion) check(problems []string, force bool) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("%w: %s", ErrNotReady, strings.Join(problems, "; "))
+	}
+	c.Overridden = problems
+	return nil
+}
+
+// decayProblem explains why research confident enough when written no
+// longer is, after the configured confidence decay, or returns "".
+func decayProblem(workDir string, r *artifacts.Research) string {
+	if r == nil {
+		return ""
+	}
+	cfg, err := config.Load(workDir)
+	if err != nil {
+		return ""
+	}
+	rates := cfg.GetConfidenceDecay()
+	if rates == nil {
+		return ""
+	}
+	if d := r.Decay(workDir, rates.PerWeek, rates.PerCommit, time.Now()); d.Decayed(r) {
+		return "research confidence decayed to " + d.String() + " since it was written"
+	}
+	return ""
+}
+
+// researchProblems lists why research is not ready to hand over to
+// planning
+func ResearchProblems(r *artifacts.Research) []string {
+	if r == nil {
+		return []string{"no research artifact has been saved"}
+	}
+
+	var problems []string
+	if !r.IsComplete() {
+		problems = append(problems, fmt.Sprintf("research confidence is %.0f%%, below the 70%% needed", r.ConfidenceScore*100))
+	}
+	var blocking []string
+	for _, q := range r.OpenQuestions {
+		if q.Blocking {
+			blocking = append(blocking, q.Question)
+		}
+	}
+	if len(blocking) > 0 {
+		problems = append(problems, fmt.Sprintf("%d blocking open question(s): %s", len(blocking), strings.Join(blocking, "; ")))
+	}
+	return problems
+}
+
+// planProblems lists why a plan is not ready to implement
+func PlanProblems(p *artifacts.Plan) []string {
+	if p == nil {
+		return []string{"no plan artifact has been saved"}
+	}
+
+	var problems []string
+	if len(p.Steps) == 0 {
+		problems = append(problems, "the plan has no steps")
+	}
+	if v := p.ValidationResult; v != nil && v.Recommendation != "PROCEED" {
+		problems = append(problems, fmt.Sprintf("plan validation recommends %s", v.Recommendation))
+	}
+	return problems
+}
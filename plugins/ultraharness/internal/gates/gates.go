@@ -4,10 +4,14 @@ package gates
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
+
+	"ultraharness/internal/quarantine"
+	"ultraharness/internal/schema"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/templates"
 )
 
 // Gate types
@@ -26,15 +30,23 @@ const (
 	ActionBlock GateAction = "block"
 )
 
+// Gate result codes, identifying which gate stopped an operation
+const (
+	CodeResearchIncomplete = "research_incomplete"
+	CodePlanIncomplete     = "plan_incomplete"
+)
+
 // GateResult contains the result of a gate check
 type GateResult struct {
 	Action      GateAction
+	Code        string
 	Reason      string
 	Suggestions []string
 }
 
 // FICState represents the current FIC workflow state
 type FICState struct {
+	SchemaVersion    int       `json:"schema_version"`
 	Phase            string    `json:"phase"` // "research", "planning", "implementation"
 	ResearchComplete bool      `json:"research_complete"`
 	PlanValidated    bool      `json:"plan_validated"`
@@ -44,31 +56,61 @@ type FICState struct {
 // FICStateFileName is the name of the FIC state file
 const FICStateFileName = "fic-state.json"
 
+// FICStateSchema is the FIC state file format
+var FICStateSchema = &schema.Format{
+	Name:       FICStateFileName,
+	Migrations: []schema.Migration{schema.Unversioned},
+}
+
 // LoadFICState loads the FIC state from the working directory
 func LoadFICState(workDir string) (*FICState, error) {
-	statePath := filepath.Join(workDir, ".claude", FICStateFileName)
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(statePath)
+	data, err := backend.Get(FICStateFileName)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Default state: not initialized
-			return &FICState{
-				Phase:            "research",
-				ResearchComplete: false,
-				PlanValidated:    false,
-			}, nil
+		if errors.Is(err, storage.ErrNotFound) {
+			return defaultFICState(), nil
 		}
 		return nil, err
 	}
 
 	var state FICState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, err
+	if err := FICStateSchema.Unmarshal(data, &state); err != nil {
+		if errors.Is(err, schema.ErrNewer) {
+			return nil, err
+		}
+		return resetFICState(workDir, data, err)
 	}
 
 	return &state, nil
 }
 
+// defaultFICState is the state of a project that has not started the
+// workflow
+func defaultFICState() *FICState {
+	return &FICState{
+		Phase:            "research",
+		ResearchComplete: false,
+		PlanValidated:    false,
+	}
+}
+
+// resetFICState quarantines a corrupt FIC state and starts over from the
+// default, rather than failing the load and leaving the gates open
+func resetFICState(workDir string, data []byte, cause error) (*FICState, error) {
+	if _, err := quarantine.Save(workDir, FICStateFileName, data, cause, "the workflow phase and research and plan completion"); err != nil {
+		return nil, cause
+	}
+	state := defaultFICState()
+	if err := SaveFICState(workDir, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
 // CheckGate checks if an operation is allowed based on FIC state
 func CheckGate(gate string, workDir string, strictness string) *GateResult {
 	// Relaxed mode: always allow
@@ -101,6 +143,7 @@ func checkEditWriteGate(state *FICState, strictness string) *GateResult {
 	// If research is not complete, block/warn
 	if !state.ResearchComplete {
 		result := &GateResult{
+			Code:   CodeResearchIncomplete,
 			Reason: "Research phase not complete",
 			Suggestions: []string{
 				"Complete research using Read, Grep, Glob, Task tools first",
@@ -118,6 +161,7 @@ func checkEditWriteGate(state *FICState, strictness string) *GateResult {
 	// If plan is not validated, block/warn
 	if !state.PlanValidated {
 		result := &GateResult{
+			Code:   CodePlanIncomplete,
 			Reason: "Planning phase not complete",
 			Suggestions: []string{
 				"Create and validate your implementation plan",
@@ -142,22 +186,19 @@ func checkBashGate(state *FICState, strictness string) *GateResult {
 	return &GateResult{Action: ActionAllow}
 }
 
-// FormatGateMessage formats the gate result as a user-friendly message
-func FormatGateMessage(result *GateResult) string {
+// FormatGateMessage formats the gate result as a user-friendly message,
+// using workDir's gate_message template
+func FormatGateMessage(workDir string, result *GateResult) string {
 	if result.Action == ActionAllow {
 		return ""
 	}
 
-	msg := fmt.Sprintf("[FIC Gate] %s: %s", result.Action, result.Reason)
-
-	if len(result.Suggestions) > 0 {
-		msg += "\nSuggestions:"
-		for _, s := range result.Suggestions {
-			msg += fmt.Sprintf("\n  - %s", s)
-		}
-	}
-
-	return msg
+	return templates.Render(workDir, "gate_message", templates.Gate{
+		Action:      string(result.Action),
+		Code:        result.Code,
+		Reason:      result.Reason,
+		Suggestions: result.Suggestions,
+	})
 }
 
 // GateConfig holds gate-specific configuration options
@@ -216,6 +257,7 @@ func checkEditWriteGateWithConfig(state *FICState, strictness string, gateConfig
 		}
 
 		result := &GateResult{
+			Code:   CodeResearchIncomplete,
 			Reason: "Research phase not complete",
 			Suggestions: []string{
 				"Complete research using Read, Grep, Glob, Task tools first",
@@ -237,6 +279,7 @@ func checkEditWriteGateWithConfig(state *FICState, strictness string, gateConfig
 		}
 
 		result := &GateResult{
+			Code:   CodePlanIncomplete,
 			Reason: "Planning phase not complete",
 			Suggestions: []string{
 				"Create and validate your implementation plan",
@@ -257,18 +300,33 @@ func checkEditWriteGateWithConfig(state *FICState, strictness string, gateConfig
 
 // SaveFICState saves the FIC state to disk
 func SaveFICState(workDir string, state *FICState) error {
-	stateDir := filepath.Join(workDir, ".claude")
-	if err := os.MkdirAll(stateDir, 0700); err != nil {
+	backend, err := storage.Open(workDir)
+	if err != nil {
 		return err
 	}
 
-	state.LastUpdated = time.Now()
+	data, err := encodeFICState(state)
+	if err != nil {
+		return err
+	}
+	return backend.Put(FICStateFileName, data)
+}
 
-	data, err := json.MarshalIndent(state, "", "  ")
+// PutFICState stages the FIC state in tx, so it is saved together with
+// other state
+func PutFICState(tx storage.Tx, state *FICState) error {
+	data, err := encodeFICState(state)
 	if err != nil {
 		return err
 	}
+	tx.Put(FICStateFileName, data)
+	return nil
+}
 
-	statePath := filepath.Join(stateDir, FICStateFileName)
-	return os.WriteFile(statePath, data, 0600)
+// encodeFICState stamps the state with the schema version and time and
+// encodes it
+func encodeFICState(state *FICState) ([]byte, error) {
+	state.SchemaVersion = FICStateSchema.Version()
+	state.LastUpdated = time.Now()
+	return json.MarshalIndent(state, "", "  ")
 }
@@ -2,9 +2,15 @@ package gates
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/quarantine"
+	"ultraharness/internal/schema"
 )
 
 func TestLoadFICState(t *testing.T) {
@@ -76,7 +82,46 @@ func TestLoadFICState(t *testing.T) {
 		}
 	})
 
-	t.Run("invalid JSON returns error", func(t *testing.T) {
+	t.Run("newer schema version is refused, not reset", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "gates-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		os.MkdirAll(filepath.Join(tmpDir, ".claude"), 0755)
+		statePath := filepath.Join(tmpDir, ".claude", FICStateFileName)
+		data := []byte(`{"schema_version": 99, "phase": "implementation"}`)
+		os.WriteFile(statePath, data, 0644)
+
+		if _, err := LoadFICState(tmpDir); !errors.Is(err, schema.ErrNewer) {
+			t.Errorf("LoadFICState() error = %v, want schema.ErrNewer", err)
+		}
+		if kept, _ := os.ReadFile(statePath); string(kept) != string(data) {
+			t.Errorf("state file = %s, want it left alone", kept)
+		}
+		if _, err := os.Stat(quarantine.Dir(tmpDir)); !os.IsNotExist(err) {
+			t.Error("a newer state file was quarantined")
+		}
+	})
+
+	t.Run("saved state records the schema version", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "gates-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if err := SaveFICState(tmpDir, &FICState{Phase: "planning"}); err != nil {
+			t.Fatalf("SaveFICState() error = %v", err)
+		}
+		state, err := LoadFICState(tmpDir)
+		if err != nil || state.SchemaVersion != FICStateSchema.Version() {
+			t.Errorf("LoadFICState() = %+v, %v; want schema version %d", state, err, FICStateSchema.Version())
+		}
+	})
+
+	t.Run("invalid JSON is quarantined and reset", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "gates-test")
 		if err != nil {
 			t.Fatalf("Failed to create temp dir: %v", err)
@@ -93,9 +138,31 @@ func TestLoadFICState(t *testing.T) {
 			t.Fatalf("Failed to write state file: %v", err)
 		}
 
-		_, err = LoadFICState(tmpDir)
-		if err == nil {
-			t.Error("LoadFICState() should return error for invalid JSON")
+		state, err := LoadFICState(tmpDir)
+		if err != nil {
+			t.Fatalf("LoadFICState() error = %v", err)
+		}
+		if state.Phase != "research" || state.ResearchComplete || state.PlanValidated {
+			t.Errorf("LoadFICState() = %+v, want the default state", state)
+		}
+		if notices := protocol.TakeNotices(); len(notices) != 1 || !strings.Contains(notices[0], FICStateFileName) {
+			t.Errorf("notices = %q, want one warning naming %s", notices, FICStateFileName)
+		}
+		backups, _ := os.ReadDir(quarantine.Dir(tmpDir))
+		if len(backups) != 1 {
+			t.Fatalf("quarantined %d files, want 1", len(backups))
+		}
+		if data, _ := os.ReadFile(filepath.Join(quarantine.Dir(tmpDir), backups[0].Name())); string(data) != "invalid json{" {
+			t.Errorf("quarantined copy = %q, want the corrupt contents", data)
+		}
+
+		// The reset state is saved, so the next load neither warns nor
+		// quarantines again, and the gates enforce it
+		if _, err := LoadFICState(tmpDir); err != nil || protocol.TakeNotices() != nil {
+			t.Errorf("second LoadFICState() error = %v, want the reset state without a warning", err)
+		}
+		if result := CheckGate(GateAllowEdit, tmpDir, "strict"); result.Action != ActionBlock {
+			t.Errorf("CheckGate() after reset = %v, want block", result.Action)
 		}
 	})
 }
@@ -234,7 +301,7 @@ func TestCheckGate(t *testing.T) {
 func TestFormatGateMessage(t *testing.T) {
 	t.Run("allow returns empty", func(t *testing.T) {
 		result := &GateResult{Action: ActionAllow}
-		if msg := FormatGateMessage(result); msg != "" {
+		if msg := FormatGateMessage("", result); msg != "" {
 			t.Errorf("FormatGateMessage() = %v, want empty", msg)
 		}
 	})
@@ -245,7 +312,7 @@ func TestFormatGateMessage(t *testing.T) {
 			Reason:      "Research not complete",
 			Suggestions: []string{"Do more research"},
 		}
-		msg := FormatGateMessage(result)
+		msg := FormatGateMessage("", result)
 		if msg == "" {
 			t.Error("FormatGateMessage() should not be empty for warn")
 		}
@@ -260,7 +327,7 @@ func TestFormatGateMessage(t *testing.T) {
 			Action: ActionBlock,
 			Reason: "Plan not validated",
 		}
-		msg := FormatGateMessage(result)
+		msg := FormatGateMessage("", result)
 		if msg == "" {
 			t.Error("FormatGateMessage() should not be empty for block")
 		}
@@ -307,3 +374,19 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func BenchmarkCheckGate(b *testing.B) {
+	tmpDir, err := os.MkdirTemp("", "gates-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := SaveFICState(tmpDir, &FICState{Phase: "implementation", ResearchComplete: true, PlanValidated: true}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CheckGate(GateAllowEdit, tmpDir, "standard")
+	}
+}
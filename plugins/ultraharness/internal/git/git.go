@@ -3,10 +3,18 @@ package git
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"ultraharness/internal/glob"
 )
 
 // DefaultTimeout for git commands
@@ -59,7 +67,474 @@ func Log(workDir string, numCommits int) string {
 	return strings.TrimSpace(string(output))
 }
 
-// ModifiedFiles returns list of modified files (staged, unstaged, and untracked).
+// LastCommitSubject returns the subject line of the HEAD commit.
+func LastCommitSubject(workDir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "log", "-1", "--format=%s")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// RemoteURL returns the URL of the origin remote, or "" if there is none.
+func RemoteURL(workDir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// CurrentBranch returns the checked-out branch name, or "" when HEAD is
+// detached or the directory is not a repository.
+func CurrentBranch(workDir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "--short", "-q", "HEAD")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Upstream returns the upstream of the checked-out branch, e.g.
+// "origin/main", or "" if it has none.
+func Upstream(workDir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ChangedFilesSince returns the files that differ between base and the
+// working tree, committed or not, relative to workDir.
+func ChangedFilesSince(workDir, base string) []string {
+	return nameOnly(workDir, "diff", "--name-only", "--relative", base, "--")
+}
+
+// ShowFile returns the content of path, relative to workDir, at revision
+// rev. Reports false if the file does not exist there.
+func ShowFile(workDir, rev, path string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "show", rev+":./"+filepath.ToSlash(path))
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return string(output), true
+}
+
+// UnmergedFiles returns the files with unresolved merge conflicts in the
+// index.
+func UnmergedFiles(workDir string) []string {
+	return nameOnly(workDir, "diff", "--name-only", "--diff-filter=U")
+}
+
+// HooksDir returns the directory git runs hooks from, honoring
+// core.hooksPath, or "" outside a repository.
+func HooksDir(workDir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+	return dir
+}
+
+// nameOnly runs a git command that prints one path per line.
+func nameOnly(workDir string, args ...string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// UserEmail returns the configured user.email, or "" if it is not set.
+func UserEmail(workDir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "config", "user.email")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Commit is a commit and the files it changed.
+type Commit struct {
+	Hash    string
+	Author  string
+	Email   string
+	Subject string
+	Files   []string
+}
+
+// CommitsSince returns the commits on HEAD made after since, newest first.
+func CommitsSince(workDir string, since time.Time) []Commit {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "log", "--since="+since.Format(time.RFC3339),
+		"--format=%x00%h%x1f%an%x1f%ae%x1f%s", "--name-only", "--no-renames")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x00") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		c := Commit{Hash: fields[0], Author: fields[1], Email: fields[2], Subject: fields[3]}
+		for _, f := range lines[1:] {
+			if f = strings.TrimSpace(f); f != "" {
+				c.Files = append(c.Files, f)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// MessagesSince returns the full messages of the commits on HEAD made
+// after since, newest first.
+func MessagesSince(workDir string, since time.Time) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "log", "--since="+since.Format(time.RFC3339), "--format=%B%x00")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, m := range strings.Split(string(output), "\x00") {
+		if m = strings.TrimSpace(m); m != "" {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// FileChurn summarizes a file's recent history.
+type FileChurn struct {
+	Path    string
+	Commits int
+	// Lines added plus removed
+	Lines int
+	// Authors are the distinct author names, most commits first
+	Authors []string
+}
+
+// IsConflictProne reports whether the file changed often and by several
+// people, so concurrent edits are likely to conflict.
+func (c FileChurn) IsConflictProne(minCommits, minAuthors int) bool {
+	return c.Commits >= minCommits && len(c.Authors) >= minAuthors
+}
+
+// Churn returns the history of the files changed by commits made after
+// since, from git log --numstat, ordered by commits, most first. Paths
+// limit the analysis to those files and are relative to the repository
+// root in the result.
+func Churn(workDir string, since time.Time, paths ...string) []FileChurn {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	args := []string{"log", "--since=" + since.Format(time.RFC3339), "--format=%x00%ae%x1f%an", "--numstat", "--no-renames", "--"}
+	cmd := exec.CommandContext(ctx, "git", append(args, paths...)...)
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	byPath := map[string]*FileChurn{}
+	authorCommits := map[string]map[string]int{}
+	names := map[string]string{}
+	for _, record := range strings.Split(string(output), "\x00") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 2 {
+			continue
+		}
+		email, name := fields[0], fields[1]
+		if _, ok := names[email]; !ok {
+			names[email] = name
+		}
+		for _, line := range lines[1:] {
+			// added<TAB>removed<TAB>path, with "-" counts for binary files
+			cols := strings.SplitN(line, "\t", 3)
+			if len(cols) != 3 {
+				continue
+			}
+			c, ok := byPath[cols[2]]
+			if !ok {
+				c = &FileChurn{Path: cols[2]}
+				byPath[cols[2]] = c
+				authorCommits[cols[2]] = map[string]int{}
+			}
+			c.Commits++
+			for _, n := range cols[:2] {
+				if v, err := strconv.Atoi(n); err == nil {
+					c.Lines += v
+				}
+			}
+			authorCommits[cols[2]][email]++
+		}
+	}
+
+	churn := make([]FileChurn, 0, len(byPath))
+	for path, c := range byPath {
+		emails := make([]string, 0, len(authorCommits[path]))
+		for email := range authorCommits[path] {
+			emails = append(emails, email)
+		}
+		counts := authorCommits[path]
+		sort.Slice(emails, func(i, j int) bool {
+			if counts[emails[i]] != counts[emails[j]] {
+				return counts[emails[i]] > counts[emails[j]]
+			}
+			return emails[i] < emails[j]
+		})
+		for _, email := range emails {
+			c.Authors = append(c.Authors, names[email])
+		}
+		churn = append(churn, *c)
+	}
+	sort.Slice(churn, func(i, j int) bool {
+		if churn[i].Commits != churn[j].Commits {
+			return churn[i].Commits > churn[j].Commits
+		}
+		return churn[i].Path < churn[j].Path
+	})
+	return churn
+}
+
+// Owner is an author and the number of lines of a file they last changed.
+type Owner struct {
+	Author string
+	Lines  int
+}
+
+// BlameOwners returns who last changed each line of a committed file,
+// from git blame, ordered by lines, most first.
+func BlameOwners(workDir, path string) []Owner {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "blame", "--line-porcelain", "--", path)
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	lines := map[string]int{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if author, ok := strings.CutPrefix(line, "author "); ok {
+			lines[author]++
+		}
+	}
+
+	owners := make([]Owner, 0, len(lines))
+	for author, n := range lines {
+		owners = append(owners, Owner{Author: author, Lines: n})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].Lines != owners[j].Lines {
+			return owners[i].Lines > owners[j].Lines
+		}
+		return owners[i].Author < owners[j].Author
+	})
+	return owners
+}
+
+// Head returns the HEAD commit hash, or "" if there is none.
+func Head(workDir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// IsCommit reports whether rev names a commit in the repository, e.g. one
+// that was not lost to a rebase or garbage collection.
+func IsCommit(workDir, rev string) bool {
+	if rev == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "-e", rev+"^{commit}")
+	cmd.Dir = workDir
+	return cmd.Run() == nil
+}
+
+// CommitBefore returns the hash of the last commit on HEAD made before t,
+// or "" if there is none.
+func CommitBefore(workDir string, t time.Time) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "-1", "--before="+t.Format(time.RFC3339), "HEAD")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// IsAncestor reports whether commit ancestor is reachable from rev, so that
+// moving from rev to ancestor moves back in history. A commit is its own
+// ancestor.
+func IsAncestor(workDir, ancestor, rev string) bool {
+	if ancestor == "" || rev == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", ancestor, rev)
+	cmd.Dir = workDir
+	return cmd.Run() == nil
+}
+
+// UncommittedLines returns the lines added plus removed in a file since
+// HEAD, or the file's line count if it is untracked.
+func UncommittedLines(workDir, path string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "diff", "--numstat", "HEAD", "--", path)
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	if fields := strings.Fields(string(output)); len(fields) >= 2 {
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		return added + removed
+	}
+
+	if len(nameOnly(workDir, "ls-files", "--others", "--exclude-standard", "--", path)) == 0 {
+		return 0
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(string(data), "\n"), "\n") + 1
+}
+
+// SubjectsSince returns the subjects of the commits made after since that
+// changed path, newest first.
+func SubjectsSince(workDir string, since time.Time, path string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "log", "--since="+since.Format(time.RFC3339), "--format=%s", "--", path)
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var subjects []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects
+}
+
+// CommitCountSince returns how many commits on HEAD made after since
+// changed any of paths, relative to workDir. It returns 0 outside a git
+// repository or without paths.
+func CommitCountSince(workDir string, since time.Time, paths ...string) int {
+	if len(paths) == 0 {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	args := []string{"rev-list", "--count", "--since=" + since.Format(time.RFC3339), "HEAD", "--"}
+	cmd := exec.CommandContext(ctx, "git", append(args, paths...)...)
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+	return n
+}
+
+// ModifiedFiles returns list of modified files (staged, unstaged, and untracked),
+// relative to workDir.
 func ModifiedFiles(workDir string) []string {
 	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 	defer cancel()
@@ -67,7 +542,7 @@ func ModifiedFiles(workDir string) []string {
 	var files []string
 
 	// Get staged and unstaged changes
-	cmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "HEAD")
+	cmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "--relative", "HEAD")
 	cmd.Dir = workDir
 	output, err := cmd.Output()
 	if err == nil && len(output) > 0 {
@@ -93,6 +568,97 @@ func ModifiedFiles(workDir string) []string {
 	return files
 }
 
+// ListFiles returns the tracked files and the untracked files .gitignore
+// does not exclude, relative to workDir. ok is false if git could not list
+// them.
+func ListFiles(workDir string) (files []string, ok bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, false
+	}
+	for _, f := range strings.Split(string(output), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, true
+}
+
+// Grep searches tracked files for an extended regular expression and
+// returns matching lines as "path:line:text". Binary files are skipped.
+func Grep(workDir, pattern string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "grep", "-n", "-I", "-E", "-e", pattern)
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 means no matches
+		return nil
+	}
+
+	var matches []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			matches = append(matches, line)
+		}
+	}
+	return matches
+}
+
+// StateFingerprint returns a hash of the working tree state: the HEAD
+// commit, uncommitted changes to tracked files, and the size and mtime of
+// untracked files outside .claude/. It changes whenever code is modified,
+// but not when the harness writes its own state. The exclude paths, such
+// as state files at the project root, are left out as well.
+func StateFingerprint(workDir string, exclude ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	diff := []string{"diff", "HEAD", "--binary"}
+	if len(exclude) > 0 {
+		diff = append(diff, "--", ".")
+		for _, path := range exclude {
+			diff = append(diff, ":(exclude)"+path)
+		}
+	}
+	excluded := make(map[string]bool, len(exclude))
+	for _, path := range exclude {
+		excluded[path] = true
+	}
+
+	h := sha256.New()
+	for _, args := range [][]string{
+		{"rev-parse", "HEAD"},
+		diff,
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = workDir
+		output, _ := cmd.Output()
+		h.Write(output)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "--others", "--exclude-standard")
+	cmd.Dir = workDir
+	output, _ := cmd.Output()
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f == "" || strings.HasPrefix(f, ".claude/") || excluded[f] {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(workDir, f)); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", f, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // CodeExtensions lists common code file extensions.
 var CodeExtensions = map[string]bool{
 	".py": true, ".js": true, ".ts": true, ".jsx": true, ".tsx": true,
@@ -101,12 +667,29 @@ var CodeExtensions = map[string]bool{
 	".kt": true, ".scala": true, ".php": true, ".vue": true, ".svelte": true,
 }
 
-// CodeWasModified returns true if code files were modified.
-func CodeWasModified(workDir string) bool {
+// IsCodeFile reports whether path is a code file: its extension is one of
+// CodeExtensions or extra ("sql" or ".sql"), and it matches none of the
+// exclude globs.
+func IsCodeFile(path string, extra, exclude []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	isCode := CodeExtensions[ext]
+	for _, e := range extra {
+		if e = strings.ToLower(e); e == ext || "."+e == ext {
+			isCode = true
+		}
+	}
+	return isCode && !glob.MatchAny(exclude, filepath.ToSlash(path))
+}
+
+// CodeWasModified returns true if code files were modified. extra and
+// exclude adjust which files are code, as for IsCodeFile.
+func CodeWasModified(workDir string, extra, exclude []string) bool {
 	files := ModifiedFiles(workDir)
 	for _, f := range files {
-		ext := strings.ToLower(filepath.Ext(f))
-		if CodeExtensions[ext] {
+		if IsCodeFile(f, extra, exclude) {
 			return true
 		}
 	}
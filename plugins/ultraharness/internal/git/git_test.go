@@ -4,7 +4,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Helper to create a git repo for testing
@@ -178,7 +181,7 @@ func TestCodeWasModified(t *testing.T) {
 		textFile := filepath.Join(tmpDir, "notes.txt")
 		os.WriteFile(textFile, []byte("notes"), 0644)
 
-		if CodeWasModified(tmpDir) {
+		if CodeWasModified(tmpDir, nil, nil) {
 			t.Error("CodeWasModified() = true, want false for .txt file")
 		}
 	})
@@ -197,10 +200,57 @@ func TestCodeWasModified(t *testing.T) {
 		codeFile := filepath.Join(tmpDir, "main.go")
 		os.WriteFile(codeFile, []byte("package main"), 0644)
 
-		if !CodeWasModified(tmpDir) {
+		if !CodeWasModified(tmpDir, nil, nil) {
 			t.Error("CodeWasModified() = false, want true for .go file")
 		}
 	})
+
+	t.Run("configured extension modified", func(t *testing.T) {
+		tmpDir := createTestRepo(t)
+		defer os.RemoveAll(tmpDir)
+
+		initialFile := filepath.Join(tmpDir, "readme.md")
+		os.WriteFile(initialFile, []byte("readme"), 0644)
+		exec.Command("git", "-C", tmpDir, "add", ".").Run()
+		exec.Command("git", "-C", tmpDir, "commit", "-m", "initial").Run()
+
+		os.MkdirAll(filepath.Join(tmpDir, "migrations"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "migrations", "001_users.sql"), []byte("CREATE TABLE users ();"), 0644)
+
+		if CodeWasModified(tmpDir, nil, nil) {
+			t.Error("CodeWasModified() = true, want false for .sql file by default")
+		}
+		if !CodeWasModified(tmpDir, []string{".sql"}, nil) {
+			t.Error("CodeWasModified() = false, want true with .sql configured")
+		}
+		if CodeWasModified(tmpDir, []string{".sql"}, []string{"migrations/**"}) {
+			t.Error("CodeWasModified() = true, want false with migrations excluded")
+		}
+	})
+}
+
+func TestIsCodeFile(t *testing.T) {
+	tests := []struct {
+		path    string
+		extra   []string
+		exclude []string
+		want    bool
+	}{
+		{"main.go", nil, nil, true},
+		{"README.md", nil, nil, false},
+		{"Makefile", []string{""}, nil, false},
+		{"infra/main.tf", nil, nil, false},
+		{"infra/main.tf", []string{"tf"}, nil, true},
+		{"api/user.proto", []string{".PROTO"}, nil, true},
+		{"api/v1/user.pb.go", nil, []string{"*.pb.go"}, false},
+		{"vendor/lib/lib.go", nil, []string{"vendor/**"}, false},
+		{"cmd/vendor.go", nil, []string{"vendor/**"}, true},
+	}
+	for _, tt := range tests {
+		if got := IsCodeFile(tt.path, tt.extra, tt.exclude); got != tt.want {
+			t.Errorf("IsCodeFile(%q, %q, %q) = %v, want %v", tt.path, tt.extra, tt.exclude, got, tt.want)
+		}
+	}
 }
 
 func TestCodeExtensions(t *testing.T) {
@@ -277,3 +327,296 @@ func TestDefaultTimeout(t *testing.T) {
 		t.Errorf("DefaultTimeout = %v, want 10s", DefaultTimeout)
 	}
 }
+
+func TestGrep(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n// TODO: wire up\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "untracked.go"), []byte("// TODO: ignored\n"), 0644)
+	exec.Command("git", "-C", tmpDir, "add", "main.go").Run()
+
+	matches := Grep(tmpDir, "TODO")
+	if len(matches) != 1 || matches[0] != "main.go:2:// TODO: wire up" {
+		t.Errorf("Grep() = %v, want [main.go:2:// TODO: wire up]", matches)
+	}
+
+	if matches := Grep(tmpDir, "NOMATCH"); matches != nil {
+		t.Errorf("Grep() with no matches = %v, want nil", matches)
+	}
+}
+
+func TestStateFingerprint(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644)
+	exec.Command("git", "-C", tmpDir, "add", ".").Run()
+	exec.Command("git", "-C", tmpDir, "commit", "-m", "initial").Run()
+
+	clean := StateFingerprint(tmpDir)
+	if clean != StateFingerprint(tmpDir) {
+		t.Error("StateFingerprint() not stable for unchanged tree")
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	modified := StateFingerprint(tmpDir)
+	if modified == clean {
+		t.Error("StateFingerprint() unchanged after editing a tracked file")
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "new.go"), []byte("package main\n"), 0644)
+	if StateFingerprint(tmpDir) == modified {
+		t.Error("StateFingerprint() unchanged after adding an untracked file")
+	}
+
+	excluded := StateFingerprint(tmpDir, "main.go", "state.txt")
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\nfunc init() {}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "state.txt"), []byte("written by the harness\n"), 0644)
+	if StateFingerprint(tmpDir, "main.go", "state.txt") != excluded {
+		t.Error("StateFingerprint() changed after editing excluded files")
+	}
+}
+
+func TestCommitsSince(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	if got := UserEmail(tmpDir); got != "test@test.com" {
+		t.Errorf("UserEmail() = %q, want test@test.com", got)
+	}
+
+	commit := func(name, email, file string) {
+		os.WriteFile(filepath.Join(tmpDir, file), []byte(file), 0644)
+		exec.Command("git", "-C", tmpDir, "add", ".").Run()
+		exec.Command("git", "-C", tmpDir, "-c", "user.name="+name, "-c", "user.email="+email,
+			"commit", "-q", "-m", "add "+file).Run()
+	}
+	commit("Test User", "test@test.com", "mine.go")
+	commit("Other Dev", "other@test.com", "theirs.go")
+
+	commits := CommitsSince(tmpDir, time.Now().Add(-time.Hour))
+	if len(commits) != 2 {
+		t.Fatalf("CommitsSince() returned %d commits, want 2", len(commits))
+	}
+	newest := commits[0]
+	if newest.Author != "Other Dev" || newest.Email != "other@test.com" || newest.Subject != "add theirs.go" {
+		t.Errorf("CommitsSince()[0] = %+v, want the commit by Other Dev", newest)
+	}
+	if len(newest.Files) != 1 || newest.Files[0] != "theirs.go" {
+		t.Errorf("CommitsSince()[0].Files = %v, want [theirs.go]", newest.Files)
+	}
+
+	if got := CommitsSince(tmpDir, time.Now().Add(time.Hour)); len(got) != 0 {
+		t.Errorf("CommitsSince() in the future = %v, want none", got)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "body.go"), []byte("body"), 0644)
+	exec.Command("git", "-C", tmpDir, "add", ".").Run()
+	exec.Command("git", "-C", tmpDir, "commit", "-q", "-m", "add body.go", "-m", "Refs PROJ-12").Run()
+	messages := MessagesSince(tmpDir, time.Now().Add(-time.Hour))
+	if len(messages) != 3 || messages[0] != "add body.go\n\nRefs PROJ-12" || messages[2] != "add mine.go" {
+		t.Errorf("MessagesSince() = %q, want the three messages with bodies, newest first", messages)
+	}
+
+	since := time.Now().Add(-time.Hour)
+	if got := CommitCountSince(tmpDir, since, "mine.go", "body.go", "gone.go"); got != 2 {
+		t.Errorf("CommitCountSince() = %d, want the 2 commits to those files", got)
+	}
+	if got := CommitCountSince(tmpDir, since); got != 0 {
+		t.Errorf("CommitCountSince() without paths = %d, want 0", got)
+	}
+}
+
+func TestChurnAndBlameOwners(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	commit := func(name, file, content string) {
+		os.WriteFile(filepath.Join(tmpDir, file), []byte(content), 0644)
+		exec.Command("git", "-C", tmpDir, "add", ".").Run()
+		exec.Command("git", "-C", tmpDir, "-c", "user.name="+name, "-c", "user.email="+name+"@test.com",
+			"commit", "-q", "-m", "change "+file).Run()
+	}
+	commit("alice", "hot.go", "a\nb\nc\n")
+	commit("bob", "hot.go", "a\nB\nc\n")
+	commit("alice", "hot.go", "A\nB\nc\nd\n")
+	commit("alice", "quiet.go", "x\n")
+
+	churn := Churn(tmpDir, time.Now().Add(-time.Hour))
+	if len(churn) != 2 {
+		t.Fatalf("Churn() returned %d files, want 2", len(churn))
+	}
+	hot := churn[0]
+	if hot.Path != "hot.go" || hot.Commits != 3 || hot.Lines != 8 {
+		t.Errorf("Churn()[0] = %+v, want hot.go with 3 commits and 8 lines", hot)
+	}
+	if len(hot.Authors) != 2 || hot.Authors[0] != "alice" || hot.Authors[1] != "bob" {
+		t.Errorf("Churn()[0].Authors = %v, want [alice bob]", hot.Authors)
+	}
+	if !hot.IsConflictProne(3, 2) || churn[1].IsConflictProne(1, 2) {
+		t.Errorf("IsConflictProne() = %v, %v, want true, false", hot.IsConflictProne(3, 2), churn[1].IsConflictProne(1, 2))
+	}
+
+	if got := Churn(tmpDir, time.Now().Add(-time.Hour), filepath.Join(tmpDir, "quiet.go")); len(got) != 1 || got[0].Path != "quiet.go" {
+		t.Errorf("Churn() for quiet.go = %+v, want only quiet.go", got)
+	}
+
+	owners := BlameOwners(tmpDir, "hot.go")
+	want := []Owner{{"alice", 3}, {"bob", 1}}
+	if len(owners) != len(want) || owners[0] != want[0] || owners[1] != want[1] {
+		t.Errorf("BlameOwners() = %v, want %v", owners, want)
+	}
+	if got := BlameOwners(tmpDir, "missing.go"); got != nil {
+		t.Errorf("BlameOwners() for an untracked file = %v, want nil", got)
+	}
+}
+
+func TestRemoteURLAndCurrentBranch(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	if got := RemoteURL(tmpDir); got != "" {
+		t.Errorf("RemoteURL() without origin = %q, want empty", got)
+	}
+	exec.Command("git", "-C", tmpDir, "remote", "add", "origin", "git@example.com:team/repo.git").Run()
+	if got := RemoteURL(tmpDir); got != "git@example.com:team/repo.git" {
+		t.Errorf("RemoteURL() = %q, want git@example.com:team/repo.git", got)
+	}
+
+	exec.Command("git", "-C", tmpDir, "checkout", "-q", "-b", "feature/sync").Run()
+	if got := CurrentBranch(tmpDir); got != "feature/sync" {
+		t.Errorf("CurrentBranch() = %q, want feature/sync", got)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("build/\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "tracked.go"), []byte("package x\n"), 0644)
+	exec.Command("git", "-C", tmpDir, "add", ".").Run()
+	exec.Command("git", "-C", tmpDir, "commit", "-q", "-m", "init").Run()
+	os.WriteFile(filepath.Join(tmpDir, "new file.go"), []byte("package x\n"), 0644)
+	os.MkdirAll(filepath.Join(tmpDir, "build"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "build", "out.bin"), []byte("x"), 0644)
+
+	files, ok := ListFiles(tmpDir)
+	if !ok {
+		t.Fatal("ListFiles() ok = false, want true")
+	}
+	sort.Strings(files)
+	want := []string{".gitignore", "new file.go", "tracked.go"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("ListFiles() = %q, want %q", files, want)
+	}
+
+	notRepo, _ := os.MkdirTemp("", "git-test")
+	defer os.RemoveAll(notRepo)
+	if _, ok := ListFiles(notRepo); ok {
+		t.Error("ListFiles() outside a repository ok = true, want false")
+	}
+}
+
+func TestMergeState(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+	git := func(args ...string) {
+		exec.Command("git", append([]string{"-C", tmpDir}, args...)...).Run()
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("base\n"), 0644)
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+	git("branch", "-M", "main")
+	if got := Upstream(tmpDir); got != "" {
+		t.Errorf("Upstream() without one = %q, want empty", got)
+	}
+
+	git("checkout", "-q", "-b", "topic")
+	git("branch", "-q", "--set-upstream-to=main")
+	if got := Upstream(tmpDir); got != "main" {
+		t.Errorf("Upstream() = %q, want main", got)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("topic\n"), 0644)
+	git("commit", "-q", "-am", "topic")
+	os.WriteFile(filepath.Join(tmpDir, "b.txt"), []byte("new\n"), 0644)
+	git("add", "b.txt")
+	if got := ChangedFilesSince(tmpDir, "main"); strings.Join(got, ",") != "a.txt,b.txt" {
+		t.Errorf("ChangedFilesSince(main) = %v, want a.txt and b.txt", got)
+	}
+	git("commit", "-q", "-m", "b")
+	if got, ok := ShowFile(tmpDir, "main", "a.txt"); !ok || got != "base\n" {
+		t.Errorf("ShowFile(main, a.txt) = %q, %v, want the base content", got, ok)
+	}
+	if _, ok := ShowFile(tmpDir, "main", "b.txt"); ok {
+		t.Error("ShowFile(main, b.txt) found a file added later")
+	}
+	if !IsCommit(tmpDir, "main") || IsCommit(tmpDir, "0123456789abcdef0123456789abcdef01234567") {
+		t.Error("IsCommit() should only accept commits in the repository")
+	}
+
+	if got := UnmergedFiles(tmpDir); got != nil {
+		t.Errorf("UnmergedFiles() = %v, want none", got)
+	}
+	git("checkout", "-q", "main")
+	os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("main\n"), 0644)
+	git("commit", "-q", "-am", "main")
+	git("merge", "-q", "topic")
+	if got := UnmergedFiles(tmpDir); len(got) != 1 || got[0] != "a.txt" {
+		t.Errorf("UnmergedFiles() during a conflict = %v, want a.txt", got)
+	}
+}
+
+func TestEditHistory(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	if got := Head(tmpDir); got != "" {
+		t.Errorf("Head() before the first commit = %q, want empty", got)
+	}
+
+	commit := func(file, content, subject string) {
+		os.WriteFile(filepath.Join(tmpDir, file), []byte(content), 0644)
+		exec.Command("git", "-C", tmpDir, "add", ".").Run()
+		exec.Command("git", "-C", tmpDir, "commit", "-q", "-m", subject).Run()
+	}
+	commit("app.go", "a\nb\nc\n", "add app")
+	commit("app.go", "a\nB\nc\n", "fix crash in app")
+	commit("other.go", "x\n", "add other")
+
+	head := Head(tmpDir)
+	if len(head) != 40 {
+		t.Errorf("Head() = %q, want a commit hash", head)
+	}
+	if !IsAncestor(tmpDir, "HEAD~2", head) || IsAncestor(tmpDir, head, "HEAD~2") {
+		t.Error("IsAncestor() should only accept earlier commits")
+	}
+	if got := CommitBefore(tmpDir, time.Now().Add(time.Minute)); got != head {
+		t.Errorf("CommitBefore(now) = %q, want HEAD %q", got, head)
+	}
+	if got := CommitBefore(tmpDir, time.Now().Add(-time.Hour)); got != "" {
+		t.Errorf("CommitBefore() ahead of every commit = %q, want empty", got)
+	}
+	if IsAncestor(tmpDir, "", head) || IsAncestor(tmpDir, "0123456789abcdef0123456789abcdef01234567", head) {
+		t.Error("IsAncestor() accepted a missing commit")
+	}
+
+	subjects := SubjectsSince(tmpDir, time.Now().Add(-time.Hour), "app.go")
+	if len(subjects) != 2 || subjects[0] != "fix crash in app" || subjects[1] != "add app" {
+		t.Errorf("SubjectsSince() = %v, want [fix crash in app, add app]", subjects)
+	}
+
+	if got := UncommittedLines(tmpDir, "app.go"); got != 0 {
+		t.Errorf("UncommittedLines() for an unchanged file = %d, want 0", got)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte("a\nB\nC\nd\n"), 0644)
+	if got := UncommittedLines(tmpDir, "app.go"); got != 3 {
+		t.Errorf("UncommittedLines() for an edited file = %d, want 3", got)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "new.go"), []byte("1\n2\n"), 0644)
+	if got := UncommittedLines(tmpDir, filepath.Join(tmpDir, "new.go")); got != 2 {
+		t.Errorf("UncommittedLines() for an untracked file = %d, want 2", got)
+	}
+}
@@ -0,0 +1,68 @@
+// Package glob matches slash-separated paths against gitignore-style
+// patterns with "**" support.
+package glob
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether name matches pattern. Both use forward slashes
+// and name is relative to the project root.
+//
+// A pattern without a slash matches the base name at any depth, so
+// "*.pb.go" matches "api/v1/user.pb.go". Otherwise the pattern is anchored
+// at the root and "**" matches zero or more directories: "dist/**" matches
+// everything under dist, "**/testdata/*" matches testdata files anywhere.
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	name = strings.TrimPrefix(path.Clean(name), "./")
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		pattern = "**/" + pattern
+	}
+	// A trailing slash matches the directory's contents
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// MatchAny reports whether name matches any of the patterns.
+func MatchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			// A trailing ** needs at least one segment: "dist/**" is the
+			// contents of dist, not dist itself
+			if len(rest) == 0 {
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,46 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"dist/**", "dist/app.js", true},
+		{"dist/**", "dist/assets/logo.svg", true},
+		{"dist/**", "dist", false},
+		{"dist/**", "src/dist/app.js", false},
+		{"dist/", "dist/app.js", true},
+		{"vendor/**", "vendor/github.com/pkg/errors/errors.go", true},
+		{"*_generated.go", "zz_generated.go", true},
+		{"*_generated.go", "pkg/api/zz_generated.go", true},
+		{"*.pb.go", "api/v1/user.pb.go", true},
+		{"*.pb.go", "api/v1/user.go", false},
+		{"**/testdata/*", "internal/x/testdata/a.json", true},
+		{"**/testdata/*", "testdata/a.json", true},
+		{"internal/*/gen.go", "internal/api/gen.go", true},
+		{"internal/*/gen.go", "internal/api/v2/gen.go", false},
+		{"/docs/*.md", "docs/index.md", true},
+		{"*.go", "./main.go", true},
+		{"", "main.go", false},
+		{"[", "main.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	patterns := []string{"dist/**", "*.pb.go"}
+	if !MatchAny(patterns, "api/user.pb.go") {
+		t.Error("MatchAny() = false, want true")
+	}
+	if MatchAny(patterns, "main.go") {
+		t.Error("MatchAny() = true, want false")
+	}
+}
@@ -0,0 +1,104 @@
+// Package guidance loads a project's own instructions for each FIC phase,
+// such as "always run make proto after touching .proto files" during
+// implementation, to be delivered alongside the built-in phase guidance.
+//
+// Guidance comes from the phase_guidance setting, a map from phase to text,
+// and from .claude/guidance.md, whose "## PHASE" sections hold the
+// guidance for that phase. A heading may list several phases separated by
+// commas, and ALL applies to every phase. Phase names are matched without
+// regard to case, with spaces standing for underscores ("## Planning
+// ready"). Text before the first section is ignored, so the file can start
+// with a title or notes for its authors.
+package guidance
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ultraharness/internal/config"
+)
+
+// FileName is the name of the guidance file inside .claude.
+const FileName = "guidance.md"
+
+// All is the key of guidance for every phase.
+const All = "ALL"
+
+// GetPath returns the path to the guidance file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// For returns the project's guidance for phase, or "" if it has none:
+// the phase_guidance entries for all phases and for phase, then the
+// matching sections of guidance.md, in order.
+func For(workDir string, cfg *config.Config, phase string) string {
+	phase = normalize(phase)
+
+	keys := make([]string, 0, len(cfg.PhaseGuidance))
+	for k := range cfg.PhaseGuidance {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, want := range []string{All, phase} {
+		for _, k := range keys {
+			if text := strings.TrimSpace(cfg.PhaseGuidance[k]); normalize(k) == want && text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(GetPath(workDir)); err == nil {
+		for _, s := range parse(string(data)) {
+			if s.phases[All] || s.phases[phase] {
+				parts = append(parts, s.text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// section is a "## PHASE" section of the guidance file.
+type section struct {
+	phases map[string]bool
+	text   string
+}
+
+// parse splits the guidance file into its non-empty sections.
+func parse(data string) []section {
+	var sections []section
+	var current *section
+	var body []string
+	flush := func() {
+		if current != nil {
+			if current.text = strings.TrimSpace(strings.Join(body, "\n")); current.text != "" {
+				sections = append(sections, *current)
+			}
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if heading, ok := strings.CutPrefix(line, "## "); ok {
+			flush()
+			current = &section{phases: map[string]bool{}}
+			for _, p := range strings.Split(heading, ",") {
+				current.phases[normalize(p)] = true
+			}
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
+
+// normalize returns the canonical form of a phase name, e.g.
+// "Planning ready" becomes PLANNING_READY.
+func normalize(phase string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(phase), "_"))
+}
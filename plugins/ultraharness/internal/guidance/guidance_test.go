@@ -0,0 +1,54 @@
+package guidance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ultraharness/internal/config"
+)
+
+func TestFor(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "guidance-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cfg := config.DefaultConfig()
+	if got := For(tmpDir, cfg, "IMPLEMENTATION"); got != "" {
+		t.Errorf("For() without guidance = %q, want empty", got)
+	}
+
+	cfg.PhaseGuidance = map[string]string{
+		"implementation": "Run make proto after touching .proto files.",
+		"all":            "Ask before adding dependencies.",
+		"RESEARCH":       "Start from docs/architecture.md.",
+	}
+	os.MkdirAll(filepath.Join(tmpDir, ".claude"), 0755)
+	os.WriteFile(GetPath(tmpDir), []byte(`# Guidance for the payments service
+
+## Implementation, Planning ready
+Keep handlers thin.
+
+## PLANNING
+Plans need a rollback step.
+
+## Research
+`), 0644)
+
+	tests := []struct {
+		phase string
+		want  string
+	}{
+		{"IMPLEMENTATION", "Ask before adding dependencies.\nRun make proto after touching .proto files.\nKeep handlers thin."},
+		{"PLANNING_READY", "Ask before adding dependencies.\nKeep handlers thin."},
+		{"PLANNING", "Ask before adding dependencies.\nPlans need a rollback step."},
+		{"RESEARCH", "Ask before adding dependencies.\nStart from docs/architecture.md."},
+	}
+	for _, tt := range tests {
+		if got := For(tmpDir, cfg, tt.phase); got != tt.want {
+			t.Errorf("For(%s) = %q, want %q", tt.phase, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+// Package handoff exports the current task state as a bundle a teammate
+// can import into their own checkout to continue the work.
+//
+// A bundle is a single Markdown file: a readable summary followed by the
+// machine-readable state in a fenced JSON block, so it can be reviewed,
+// pasted into a ticket, or imported as is.
+package handoff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/context"
+	"ultraharness/internal/git"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/session"
+	"ultraharness/internal/snapshot"
+	"ultraharness/internal/storage"
+)
+
+// Version is the bundle format version.
+const Version = 1
+
+// HandoffsDir is where exported bundles are written by default.
+const HandoffsDir = ".claude/handoffs"
+
+// preservedContextKey is the storage key read by SessionStart; it matches
+// the file written by the PreCompact hook.
+const preservedContextKey = "fic-preserved-context.json"
+
+// MaxHotFiles limits the hot files included in a bundle.
+const MaxHotFiles = 15
+
+// ProgressTailLines is the number of progress log lines included.
+const ProgressTailLines = 30
+
+// marker identifies bundle files and precedes the JSON block.
+const marker = "<!-- ultraharness-handoff -->"
+
+// ErrNotBundle is returned when a file contains no handoff data.
+var ErrNotBundle = errors.New("not a handoff bundle")
+
+// HotFile is a file the task touched recently.
+type HotFile struct {
+	Path   string `json:"path"`
+	Edited bool   `json:"edited,omitempty"`
+	Reads  int    `json:"reads,omitempty"`
+}
+
+// Bundle is the exported task state.
+type Bundle struct {
+	Version          int                       `json:"version"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	CreatedBy        string                    `json:"created_by,omitempty"`
+	Branch           string                    `json:"branch,omitempty"`
+	Phase            string                    `json:"phase"`
+	PreservedContext map[string]interface{}    `json:"preserved_context,omitempty"`
+	Research         *artifacts.Research       `json:"research,omitempty"`
+	Plan             *artifacts.Plan           `json:"plan,omitempty"`
+	Implementation   *artifacts.Implementation `json:"implementation,omitempty"`
+	OpenQuestions    []artifacts.OpenQuestion  `json:"open_questions,omitempty"`
+	HotFiles         []HotFile                 `json:"hot_files,omitempty"`
+	ProgressTail     []string                  `json:"progress_tail,omitempty"`
+}
+
+// Export collects the current task state of workDir.
+func Export(workDir string) (*Bundle, error) {
+	b := &Bundle{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: author(),
+		Branch:    git.CurrentBranch(workDir),
+		Phase:     artifacts.GetCurrentPhase(workDir),
+	}
+
+	if research, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch); research != nil {
+		b.Research, _ = research.(*artifacts.Research)
+	}
+	if plan, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan); plan != nil {
+		b.Plan, _ = plan.(*artifacts.Plan)
+	}
+	if impl, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactImplementation); impl != nil {
+		b.Implementation, _ = impl.(*artifacts.Implementation)
+	}
+	if b.Research != nil {
+		b.OpenQuestions = b.Research.OpenQuestions
+	}
+
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := backend.Get(preservedContextKey); err == nil {
+		json.Unmarshal(data, &b.PreservedContext)
+	}
+
+	b.HotFiles = hotFiles(workDir)
+
+	if content, err := progress.Read(workDir); err == nil && content != "" {
+		lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+		if len(lines) > ProgressTailLines {
+			lines = lines[len(lines)-ProgressTailLines:]
+		}
+		b.ProgressTail = lines
+	}
+
+	return b, nil
+}
+
+// hotFiles ranks files edited in the latest session or left uncommitted
+// first, followed by the most frequently read files.
+func hotFiles(workDir string) []HotFile {
+	files := make(map[string]*HotFile)
+	get := func(path string) *HotFile {
+		if f, ok := files[path]; ok {
+			return f
+		}
+		f := &HotFile{Path: path}
+		files[path] = f
+		return f
+	}
+
+	if state, err := session.LoadLatest(workDir); err == nil && state != nil {
+		for _, path := range state.FilesModified {
+			get(relPath(workDir, path)).Edited = true
+		}
+	}
+	for _, path := range git.ModifiedFiles(workDir) {
+		if !strings.HasPrefix(path, ".claude/") {
+			get(path).Edited = true
+		}
+	}
+	if state, err := context.LoadContextState("", workDir); err == nil {
+		for key, count := range state.AccessCounts {
+			if path := strings.TrimPrefix(key, "Read:"); path != key {
+				get(relPath(workDir, path)).Reads += count
+			}
+		}
+	}
+
+	var ranked []HotFile
+	for _, f := range files {
+		ranked = append(ranked, *f)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Edited != ranked[j].Edited {
+			return ranked[i].Edited
+		}
+		if ranked[i].Reads != ranked[j].Reads {
+			return ranked[i].Reads > ranked[j].Reads
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	if len(ranked) > MaxHotFiles {
+		ranked = ranked[:MaxHotFiles]
+	}
+	return ranked
+}
+
+func relPath(workDir, path string) string {
+	if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func author() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Markdown renders the bundle as a readable summary followed by the
+// JSON data used by Import.
+func (b *Bundle) Markdown() (string, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Handoff\n\n")
+	fmt.Fprintf(&sb, "- From: %s\n", valueOr(b.CreatedBy, "unknown"))
+	fmt.Fprintf(&sb, "- Created: %s\n", b.CreatedAt.Format(time.RFC3339))
+	if b.Branch != "" {
+		fmt.Fprintf(&sb, "- Branch: %s\n", b.Branch)
+	}
+	fmt.Fprintf(&sb, "- Phase: %s\n", b.Phase)
+	if focus, ok := b.PreservedContext["focus_directive"].(string); ok && focus != "" {
+		fmt.Fprintf(&sb, "- Focus: %s\n", focus)
+	}
+
+	if b.Plan != nil {
+		fmt.Fprintf(&sb, "\n## Plan\n\n%s\n\n", b.Plan.Goal)
+		done := make(map[string]bool)
+		if b.Implementation != nil {
+			for _, id := range b.Implementation.StepsCompleted {
+				done[id] = true
+			}
+		}
+		for _, step := range b.Plan.Steps {
+			mark := " "
+			if step.Completed || done[step.ID] {
+				mark = "x"
+			}
+			fmt.Fprintf(&sb, "- [%s] %s: %s\n", mark, step.ID, step.Description)
+		}
+	}
+
+	if b.Research != nil {
+		fmt.Fprintf(&sb, "\n## Research\n\n%s (confidence %.0f%%)\n", b.Research.FeatureOrTask, b.Research.ConfidenceScore*100)
+		for _, d := range b.Research.Discoveries {
+			fmt.Fprintf(&sb, "- %s\n", d.Summary)
+		}
+	}
+
+	if len(b.OpenQuestions) > 0 {
+		sb.WriteString("\n## Open Questions\n\n")
+		for _, q := range b.OpenQuestions {
+			if q.Blocking {
+				fmt.Fprintf(&sb, "- **(blocking)** %s\n", q.Question)
+			} else {
+				fmt.Fprintf(&sb, "- %s\n", q.Question)
+			}
+		}
+	}
+
+	if len(b.HotFiles) > 0 {
+		sb.WriteString("\n## Hot Files\n\n")
+		for _, f := range b.HotFiles {
+			var notes []string
+			if f.Edited {
+				notes = append(notes, "edited")
+			}
+			if f.Reads > 0 {
+				notes = append(notes, fmt.Sprintf("read %dx", f.Reads))
+			}
+			fmt.Fprintf(&sb, "- `%s` (%s)\n", f.Path, strings.Join(notes, ", "))
+		}
+	}
+
+	if len(b.ProgressTail) > 0 {
+		sb.WriteString("\n## Recent Progress\n\n```\n")
+		sb.WriteString(strings.Join(b.ProgressTail, "\n"))
+		sb.WriteString("\n```\n")
+	}
+
+	sb.WriteString("\n## Bundle Data\n\n")
+	sb.WriteString(marker + "\n")
+	sb.WriteString("```json\n")
+	sb.Write(data)
+	sb.WriteString("\n```\n")
+	return sb.String(), nil
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// Write exports workDir's task state to path, or to a timestamped file in
+// HandoffsDir if path is empty. Returns the path written.
+func Write(workDir, path string) (string, error) {
+	b, err := Export(workDir)
+	if err != nil {
+		return "", err
+	}
+	content, err := b.Markdown()
+	if err != nil {
+		return "", err
+	}
+
+	if path == "" {
+		dir := filepath.Join(workDir, HandoffsDir)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+		path = filepath.Join(dir, "handoff-"+time.Now().Format("20060102-150405")+".md")
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Parse extracts the bundle from a Markdown handoff file.
+func Parse(content []byte) (*Bundle, error) {
+	text := string(content)
+	i := strings.LastIndex(text, marker)
+	if i < 0 {
+		return nil, ErrNotBundle
+	}
+	text = text[i+len(marker):]
+
+	start := strings.Index(text, "```json\n")
+	if start < 0 {
+		return nil, ErrNotBundle
+	}
+	text = text[start+len("```json\n"):]
+	end := strings.Index(text, "\n```")
+	if end < 0 {
+		return nil, ErrNotBundle
+	}
+
+	var b Bundle
+	if err := json.Unmarshal([]byte(text[:end]), &b); err != nil {
+		return nil, fmt.Errorf("invalid handoff data: %w", err)
+	}
+	if b.Version > Version {
+		return nil, fmt.Errorf("handoff bundle version %d is newer than supported version %d", b.Version, Version)
+	}
+	return &b, nil
+}
+
+// Import installs a bundle as the current task state of workDir. The
+// existing state is saved to a snapshot first; its name is returned so the
+// import can be undone with the restore command.
+func Import(workDir string, b *Bundle) (string, error) {
+	backup := "pre-handoff-" + snapshot.DefaultName()
+	if _, err := snapshot.Create(workDir, backup); err != nil {
+		return "", fmt.Errorf("failed to save current state: %w", err)
+	}
+
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return backup, err
+	}
+
+	// Artifacts the bundle lacks are cleared so the phase matches the sender's
+	for _, a := range []struct {
+		kind     artifacts.ArtifactType
+		artifact interface{}
+		present  bool
+	}{
+		{artifacts.ArtifactResearch, b.Research, b.Research != nil},
+		{artifacts.ArtifactPlan, b.Plan, b.Plan != nil},
+		{artifacts.ArtifactImplementation, b.Implementation, b.Implementation != nil},
+	} {
+		if a.present {
+			if err := artifacts.SaveArtifact(workDir, a.kind, a.artifact); err != nil {
+				return backup, err
+			}
+			continue
+		}
+		keys, err := backend.List("fic-artifacts/" + string(a.kind) + "/")
+		if err != nil {
+			return backup, err
+		}
+		for _, key := range keys {
+			if err := backend.Delete(key); err != nil {
+				return backup, err
+			}
+		}
+	}
+
+	preserved := make(map[string]interface{})
+	for k, v := range b.PreservedContext {
+		preserved[k] = v
+	}
+	from := valueOr(b.CreatedBy, "a teammate")
+	focus, _ := preserved["focus_directive"].(string)
+	preserved["focus_directive"] = strings.TrimSpace(fmt.Sprintf("Continue the task handed off by %s. %s", from, focus))
+	preserved["timestamp"] = time.Now().Format(time.RFC3339)
+	preserved["phase"] = b.Phase
+	preserved["handoff_from"] = from
+	preserved["handoff_created_at"] = b.CreatedAt.Format(time.RFC3339)
+	var hot []string
+	for _, f := range b.HotFiles {
+		hot = append(hot, f.Path)
+	}
+	preserved["hot_files"] = hot
+
+	data, err := json.MarshalIndent(preserved, "", "  ")
+	if err != nil {
+		return backup, err
+	}
+	if err := backend.Put(preservedContextKey, data); err != nil {
+		return backup, err
+	}
+
+	progress.Append(progress.CommandSource(), fmt.Sprintf("HANDOFF IMPORTED: from %s (%s, created %s)",
+		from, b.Phase, b.CreatedAt.Local().Format("2006-01-02 15:04")), workDir)
+	return backup, nil
+}
@@ -0,0 +1,105 @@
+package handoff
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/snapshot"
+	"ultraharness/internal/storage"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src, err := os.MkdirTemp("", "handoff-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := os.MkdirTemp("", "handoff-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	os.MkdirAll(filepath.Join(src, ".claude"), 0700)
+	os.MkdirAll(filepath.Join(dst, ".claude"), 0700)
+
+	artifacts.SaveArtifact(src, artifacts.ArtifactResearch, &artifacts.Research{
+		FeatureOrTask:   "rate limiting",
+		ConfidenceScore: 0.8,
+		OpenQuestions:   []artifacts.OpenQuestion{{Question: "Per user or per IP?", Blocking: true}},
+	})
+	artifacts.SaveArtifact(src, artifacts.ArtifactPlan, &artifacts.Plan{
+		Goal:  "Add a token bucket limiter",
+		Steps: []artifacts.PlanStep{{ID: "1", Description: "Add limiter", Completed: true}, {ID: "2", Description: "Wire middleware"}},
+	})
+	progress.Append(progress.SourceAgent, "Started rate limiting", src)
+
+	// The receiver has an implementation in progress for another task
+	artifacts.SaveArtifact(dst, artifacts.ArtifactImplementation, &artifacts.Implementation{PlanArtifactID: "other"})
+
+	path, err := Write(src, "")
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Handoff", "- [x] 1: Add limiter", "- [ ] 2: Wire middleware", "**(blocking)** Per user or per IP?", "Started rate limiting"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("bundle missing %q", want)
+		}
+	}
+
+	bundle, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if bundle.Phase != "PLANNING" || bundle.Plan == nil || bundle.Plan.Goal != "Add a token bucket limiter" {
+		t.Errorf("Parse() = phase %q, plan %+v", bundle.Phase, bundle.Plan)
+	}
+
+	backup, err := Import(dst, bundle)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if _, err := os.Stat(snapshot.GetPath(dst, backup)); err != nil {
+		t.Errorf("Import() backup snapshot %q not created: %v", backup, err)
+	}
+
+	if phase := artifacts.GetCurrentPhase(dst); phase != "PLANNING" {
+		t.Errorf("GetCurrentPhase() after import = %q, want PLANNING", phase)
+	}
+
+	backend, _ := storage.Open(dst)
+	data, err := backend.Get(preservedContextKey)
+	if err != nil {
+		t.Fatalf("preserved context not written: %v", err)
+	}
+	var preserved map[string]interface{}
+	json.Unmarshal(data, &preserved)
+	if focus, _ := preserved["focus_directive"].(string); !strings.Contains(focus, "handed off by") {
+		t.Errorf("focus_directive = %q, want handoff note", focus)
+	}
+
+	log, _ := progress.Read(dst)
+	if !strings.Contains(log, "HANDOFF IMPORTED") {
+		t.Errorf("progress log = %q, want HANDOFF IMPORTED entry", log)
+	}
+}
+
+func TestParseRejectsOtherFiles(t *testing.T) {
+	if _, err := Parse([]byte("# Notes\n\n```json\n{}\n```\n")); !errors.Is(err, ErrNotBundle) {
+		t.Errorf("Parse() error = %v, want ErrNotBundle", err)
+	}
+
+	future := marker + "\n```json\n{\"version\": 99}\n```\n"
+	if _, err := Parse([]byte(future)); err == nil {
+		t.Error("Parse() accepted a newer bundle version")
+	}
+}
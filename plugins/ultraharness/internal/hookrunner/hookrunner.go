@@ -0,0 +1,309 @@
+// Package hookrunner runs a hook binary on the marketplace SDK's hook
+// runner, which resolves and validates the working directory and sends
+// the output. On top of it this package checks the harness is initialized
+// and the hook enabled, loads the config, configures state storage, reads
+// the input, and then calls the hook's handler. Every hook gets the same
+// behavior for these steps, plus optional logging and dry-run mode.
+package hookrunner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sdk "github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/hookrunner"
+	"github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/logging"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/text"
+)
+
+// DryRunEnvVar turns on dry-run mode for one run, whatever the config says.
+const DryRunEnvVar = "ULTRAHARNESS_DRY_RUN"
+
+// LogFile is the hook log inside .claude, written when hook_log is on.
+const LogFile = "hook-log.jsonl"
+
+// MaxLogBytes is the size at which the hook log is rotated to LogFile.1.
+const MaxLogBytes = 1 << 20
+
+// MaxPromptSize limits the prompt text handed to handlers, bounding the
+// cost of pattern matching on it.
+const MaxPromptSize = 100000
+
+// Hook describes a hook binary.
+type Hook struct {
+	// Name is the Claude Code event name, e.g. "PreToolUse"
+	Name string
+	// Key is the hook's key in the config's hooks map, e.g.
+	// config.HookPreToolUse
+	Key string
+	// RequireFIC skips the hook when FIC is disabled
+	RequireFIC bool
+	// AutoInit, if set, initializes an uninitialized project instead of
+	// skipping the hook; the hook is skipped if it fails
+	AutoInit func(workDir string) error
+	// DefaultConfigOnError runs the hook with the default config when the
+	// config cannot be read, instead of skipping it
+	DefaultConfigOnError bool
+	// SkipInput runs the handler without reading stdin
+	SkipInput bool
+	// Handler does the hook's work and writes its output
+	Handler func(c *Context) error
+}
+
+// Context is what a handler works with: the SDK's hook context, with its
+// WorkDir, Input, and SessionID, plus the harness's config. Handlers write
+// output through the protocol package, which sends it through the SDK
+// context.
+type Context struct {
+	*sdk.Context
+	Config *config.Config
+	// StorageErr is the error configuring state storage, if any
+	StorageErr error
+}
+
+// Debugf formats a "[Harness debug]" line in verbose mode, and returns ""
+// otherwise.
+func (c *Context) Debugf(format string, args ...interface{}) string {
+	if !c.Config.IsVerbose() {
+		return ""
+	}
+	return "[Harness debug] " + fmt.Sprintf(format, args...)
+}
+
+// ToolEvent is a PreToolUse or PostToolUse call. Handlers needing more of
+// the tool input read it from Context.Input.
+type ToolEvent struct {
+	SessionID string
+	Tool      string
+	FilePath  string
+	Command   string
+	// Response is the tool's result; nil before the tool runs
+	Response *protocol.ToolResponse
+}
+
+// Tool returns the tool call event.
+func (c *Context) Tool() ToolEvent {
+	return ToolEvent{
+		SessionID: c.SessionID(),
+		Tool:      c.Input.ToolName,
+		FilePath:  c.Input.GetFilePath(),
+		Command:   c.Input.GetCommand(),
+		Response:  c.Input.ToolResponse,
+	}
+}
+
+// PromptEvent is a UserPromptSubmit prompt.
+type PromptEvent struct {
+	SessionID string
+	// Prompt is cut to at most MaxPromptSize bytes
+	Prompt string
+}
+
+// Prompt returns the prompt event.
+func (c *Context) Prompt() PromptEvent {
+	return PromptEvent{SessionID: c.SessionID(), Prompt: text.Prefix(c.Input.GetPrompt(), MaxPromptSize)}
+}
+
+// SubagentEvent is a finished subagent.
+type SubagentEvent struct {
+	SessionID   string
+	Type        string
+	Description string
+	Output      string
+}
+
+// Subagent returns the subagent event.
+func (c *Context) Subagent() SubagentEvent {
+	return SubagentEvent{
+		SessionID:   c.SessionID(),
+		Type:        c.Input.GetSubagentType(),
+		Description: c.Input.GetDescription(),
+		Output:      c.Input.GetOutput(),
+	}
+}
+
+// StopEvent is the agent finishing its turn.
+type StopEvent struct {
+	SessionID  string
+	Reason     string
+	Transcript string
+}
+
+// Stop returns the stop event.
+func (c *Context) Stop() StopEvent {
+	return StopEvent{
+		SessionID:  c.SessionID(),
+		Reason:     c.Input.GetStopReason(),
+		Transcript: c.Input.GetTranscript(),
+	}
+}
+
+// Main runs h as the process's hook and exits. A hook never fails the
+// tool call: errors are reported as a system message and the exit code
+// is 0.
+func Main(h Hook) {
+	protocol.SetHook(h.Name)
+	if err := Run(h, os.Stdin); err != nil {
+		protocol.WriteError("%v", err)
+	}
+	os.Exit(0)
+}
+
+// Run prepares the context for h, reading input from stdin, and calls its
+// handler. A skipped hook writes empty output.
+func Run(h Hook, stdin io.Reader) error {
+	// The harness reads the input itself, once it knows the hook runs
+	hook := sdk.Hook{Name: h.Name, SkipInput: true, Handler: func(sc *sdk.Context) error {
+		protocol.SetSink(sc.Write)
+		defer protocol.SetSink(nil)
+		return runHarness(h, &Context{Context: sc}, stdin)
+	}}
+	return sdk.Run(hook, stdin, protocol.Output())
+}
+
+// runHarness does the harness's steps for h in the SDK's context.
+func runHarness(h Hook, c *Context, stdin io.Reader) error {
+	workDir := c.WorkDir
+	if !config.IsHarnessInitialized(workDir) {
+		if h.AutoInit == nil || h.AutoInit(workDir) != nil {
+			return protocol.WriteEmpty()
+		}
+	}
+
+	// Codes left over from an earlier run in this process, as in a replay,
+	// belong to that run
+	errcode.Take()
+
+	cfg, err := config.Load(workDir)
+	if err != nil {
+		errcode.Report(errcode.New(errcode.ConfigUnreadable, err))
+		if !h.DefaultConfigOnError {
+			// Without a config there is no hook_log setting; the error is
+			// logged anyway, like every error
+			werr := protocol.WriteEmpty()
+			writeLog(workDir, h.Name, c.Input, 0, nil, errcode.Take(), false)
+			return werr
+		}
+		cfg = config.DefaultConfig()
+	}
+	if !cfg.IsHookEnabled(h.Key) || (h.RequireFIC && !cfg.FICEnabled) {
+		return protocol.WriteEmpty()
+	}
+
+	c.Config = cfg
+	errcode.SetVisible(cfg.ErrorCodes)
+	c.StorageErr = storage.Configure(cfg)
+	errcode.Report(errcode.New(errcode.StorageUnavailable, c.StorageErr))
+	protocol.SetDryRun(cfg.DryRun || envEnabled(DryRunEnvVar))
+	protocol.SetHeadless(cfg.IsHeadless())
+	templates.SetLocale(cfg.GetLocale())
+
+	if !h.SkipInput {
+		input, err := protocol.ReadInputFrom(stdin)
+		if err != nil {
+			errcode.Report(errcode.New(errcode.InputInvalid, err))
+			werr := protocol.WriteEmpty()
+			writeLog(workDir, h.Name, c.Input, 0, nil, errcode.Take(), cfg.HookLogInputs)
+			return werr
+		}
+		c.Input = input
+	}
+
+	start := time.Now()
+	err = h.Handler(c)
+	// Main reports the error itself; only its code is recorded here
+	errcode.Record(err)
+	codes := errcode.Take()
+	// Headless runs always log, since withheld advisories go nowhere else,
+	// and so do runs that hit an error
+	if cfg.HookLog || protocol.IsHeadless() || len(codes) > 0 {
+		writeLog(workDir, h.Name, c.Input, time.Since(start), err, codes, cfg.HookLogInputs)
+	}
+	return err
+}
+
+func envEnabled(name string) bool {
+	switch strings.ToLower(os.Getenv(name)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// LogEntry is one line of the hook log.
+type LogEntry = logging.Entry
+
+// Record is a hook log line: the entry, the codes of the errors reported
+// in the run, and, when hook_log_inputs is on, the run's input and output
+// so that it can be replayed.
+type Record struct {
+	LogEntry
+	// Codes are the errcode IDs reported in the run, e.g. "E012"
+	Codes []string `json:"codes,omitempty"`
+	// WorkDir is the project the hook ran in
+	WorkDir string              `json:"work_dir,omitempty"`
+	Input   *protocol.HookInput `json:"input,omitempty"`
+	Output  json.RawMessage     `json:"output,omitempty"`
+}
+
+// writeLog appends an entry for this run to the hook log, rotating it once
+// it reaches MaxLogBytes, with the input and output if record is set.
+// Logging failures are ignored.
+func writeLog(workDir, hook string, input *protocol.HookInput, elapsed time.Duration, runErr error, codes []string, record bool) {
+	entry := LogEntry{
+		Time:       time.Now(),
+		Hook:       hook,
+		Tool:       input.ToolName,
+		SessionID:  input.SessionID,
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+	}
+	if meta := protocol.LastMetadata(); meta != nil {
+		entry.Event, _ = meta["event"].(string)
+		entry.DryRun, _ = meta["dry_run"].(bool)
+		entry.Withheld, _ = meta["withheld"].(string)
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	log := logging.Log{Path: filepath.Join(workDir, ".claude", LogFile), MaxBytes: MaxLogBytes}
+	if !record {
+		log.Append(Record{LogEntry: entry, Codes: codes})
+		return
+	}
+	log.Append(Record{LogEntry: entry, Codes: codes, WorkDir: workDir, Input: input, Output: protocol.LastOutput()})
+}
+
+// LoadRecords reads the hook log at path, oldest run first. Runs logged
+// without hook_log_inputs have no Input. Lines that are not valid entries
+// are skipped; a missing log has none.
+func LoadRecords(path string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var r Record
+		if json.Unmarshal(line, &r) == nil {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,321 @@
+package hookrunner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	sdk "github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/hookrunner"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/protocol"
+)
+
+// setupProject creates an initialized project with the given config and
+// points the hooks at it.
+func setupProject(t *testing.T, cfg string) string {
+	tmpDir, err := os.MkdirTemp("", "hookrunner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	if err := os.MkdirAll(claudeDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, config.InitMarkerFileName), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, config.ConfigFileName), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLAUDE_WORKING_DIRECTORY", tmpDir)
+	t.Setenv(DryRunEnvVar, "")
+	for _, name := range config.CIEnvVars {
+		t.Setenv(name, "")
+	}
+	return tmpDir
+}
+
+// run runs h with stdin and returns its output and the context its
+// handler was called with, or nil if it was skipped.
+func run(t *testing.T, h Hook, stdin string) (string, *Context) {
+	var buf bytes.Buffer
+	protocol.SetOutput(&buf)
+	defer protocol.SetOutput(os.Stdout)
+	defer protocol.SetDryRun(false)
+	defer protocol.SetHeadless(false)
+
+	var called *Context
+	handler := h.Handler
+	h.Handler = func(c *Context) error {
+		called = c
+		if handler != nil {
+			return handler(c)
+		}
+		return protocol.WriteEmpty()
+	}
+	if err := Run(h, strings.NewReader(stdin)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return buf.String(), called
+}
+
+func TestRunSkips(t *testing.T) {
+	t.Run("not initialized", func(t *testing.T) {
+		dir := setupProject(t, "{}")
+		os.Remove(filepath.Join(dir, ".claude", config.InitMarkerFileName))
+		if out, c := run(t, Hook{Name: "Stop", Key: config.HookStop}, "{}"); c != nil || out != "{}" {
+			t.Errorf("Run() = %q, handler called %v, want skipped", out, c != nil)
+		}
+	})
+
+	t.Run("invalid work dir", func(t *testing.T) {
+		setupProject(t, "{}")
+		t.Setenv("CLAUDE_WORKING_DIRECTORY", "relative/dir")
+		if out, c := run(t, Hook{Name: "Stop", Key: config.HookStop}, "{}"); c != nil || out != "{}" {
+			t.Errorf("Run() = %q, handler called %v, want skipped", out, c != nil)
+		}
+	})
+
+	t.Run("hook disabled", func(t *testing.T) {
+		setupProject(t, `{"hooks": {"stop": {"enabled": false}}}`)
+		if out, c := run(t, Hook{Name: "Stop", Key: config.HookStop}, "{}"); c != nil || out != "{}" {
+			t.Errorf("Run() = %q, handler called %v, want skipped", out, c != nil)
+		}
+	})
+
+	t.Run("FIC disabled", func(t *testing.T) {
+		setupProject(t, `{"fic_enabled": false}`)
+		h := Hook{Name: "UserPromptSubmit", Key: config.HookUserPromptSubmit, RequireFIC: true}
+		if _, c := run(t, h, "{}"); c != nil {
+			t.Error("Run() called the handler with FIC disabled")
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		setupProject(t, "{}")
+		if _, c := run(t, Hook{Name: "Stop", Key: config.HookStop}, "{"); c != nil {
+			t.Error("Run() called the handler with invalid input")
+		}
+	})
+}
+
+func TestRunAutoInit(t *testing.T) {
+	dir := setupProject(t, "{}")
+	os.Remove(filepath.Join(dir, ".claude", config.InitMarkerFileName))
+
+	initialized := ""
+	h := Hook{
+		Name:      "SessionStart",
+		Key:       config.HookSessionStart,
+		SkipInput: true,
+		AutoInit: func(workDir string) error {
+			initialized = workDir
+			return os.WriteFile(filepath.Join(workDir, ".claude", config.InitMarkerFileName), nil, 0600)
+		},
+	}
+	if _, c := run(t, h, ""); c == nil || initialized != dir {
+		t.Errorf("Run() initialized %q, handler called %v, want %q initialized and the handler called", initialized, c != nil, dir)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	dir := setupProject(t, `{"strictness": "strict"}`)
+	stdin := `{"session_id": "s1", "tool_name": "Edit", "tool_input": {"file_path": "a.go"}}`
+	_, c := run(t, Hook{Name: "PreToolUse", Key: config.HookPreToolUse}, stdin)
+	if c == nil {
+		t.Fatal("Run() did not call the handler")
+	}
+	if c.WorkDir != dir || c.Config.Strictness != config.StrictnessStrict {
+		t.Errorf("Context = %q with strictness %q, want %q with strict", c.WorkDir, c.Config.Strictness, dir)
+	}
+	if event := c.Tool(); event.SessionID != "s1" || event.Tool != "Edit" || event.FilePath != "a.go" {
+		t.Errorf("Tool() = %+v, want an Edit of a.go in session s1", event)
+	}
+
+	c.Input = &protocol.HookInput{SessionID: "../bad"}
+	if got := c.SessionID(); got != "default" {
+		t.Errorf("SessionID() of an invalid ID = %q, want default", got)
+	}
+}
+
+func TestPromptTruncated(t *testing.T) {
+	long := strings.Repeat("a", MaxPromptSize+10)
+	c := &Context{Context: &sdk.Context{Input: &protocol.HookInput{SessionID: "s1", Prompt: long}}}
+	if got := c.Prompt(); len(got.Prompt) != MaxPromptSize || got.SessionID != "s1" {
+		t.Errorf("Prompt() = %d bytes in session %q, want %d bytes in s1", len(got.Prompt), got.SessionID, MaxPromptSize)
+	}
+}
+
+func TestDebugf(t *testing.T) {
+	c := &Context{Config: config.DefaultConfig()}
+	if got := c.Debugf("phase %s", "RESEARCH"); got != "" {
+		t.Errorf("Debugf() at normal verbosity = %q, want empty", got)
+	}
+	c.Config.SetOutputVerbosity(config.VerbosityVerbose)
+	if got := c.Debugf("phase %s", "RESEARCH"); got != "[Harness debug] phase RESEARCH" {
+		t.Errorf("Debugf() in verbose mode = %q", got)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	deny := Hook{Name: "PreToolUse", Key: config.HookPreToolUse, Handler: func(c *Context) error {
+		return protocol.WriteDeny("blocked", nil)
+	}}
+
+	setupProject(t, `{"dry_run": true}`)
+	if out, _ := run(t, deny, "{}"); strings.Contains(out, "permissionDecision") || !strings.Contains(out, "dry run") {
+		t.Errorf("Run() with dry_run = %s, want the denial described only", out)
+	}
+
+	setupProject(t, "{}")
+	t.Setenv(DryRunEnvVar, "1")
+	if out, _ := run(t, deny, "{}"); strings.Contains(out, "permissionDecision") {
+		t.Errorf("Run() with %s=1 = %s, want the denial described only", DryRunEnvVar, out)
+	}
+
+	t.Setenv(DryRunEnvVar, "")
+	if out, _ := run(t, deny, "{}"); !strings.Contains(out, "permissionDecision") {
+		t.Errorf("Run() = %s, want a denial", out)
+	}
+}
+
+func TestHookLog(t *testing.T) {
+	dir := setupProject(t, "{}")
+	h := Hook{Name: "PostToolUse", Key: config.HookPostToolUse, Handler: func(c *Context) error {
+		return protocol.WriteMessage("ok", protocol.Metadata{"event": protocol.EventContextStatus})
+	}}
+	path := filepath.Join(dir, ".claude", LogFile)
+
+	run(t, h, `{"session_id": "s1", "tool_name": "Bash"}`)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("hook log written with hook_log off: %v", err)
+	}
+
+	setupProject(t, `{"hook_log": true}`)
+	path = filepath.Join(os.Getenv("CLAUDE_WORKING_DIRECTORY"), ".claude", LogFile)
+	run(t, h, `{"session_id": "s1", "tool_name": "Bash"}`)
+	run(t, h, `{"session_id": "s1", "tool_name": "Read"}`)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("hook log not written: %v", err)
+	}
+	defer f.Close()
+	var entries []LogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("hook log has %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e.Hook != "PostToolUse" || e.Tool != "Bash" || e.SessionID != "s1" || e.Event != protocol.EventContextStatus {
+		t.Errorf("entry = %+v, want PostToolUse of Bash in s1 with the context status event", e)
+	}
+}
+
+func TestHookLogInputs(t *testing.T) {
+	dir := setupProject(t, `{"hook_log": true, "hook_log_inputs": true}`)
+	h := Hook{Name: "PreToolUse", Key: config.HookPreToolUse, Handler: func(c *Context) error {
+		return protocol.WriteDeny("blocked", protocol.Metadata{"check": "watchdog"})
+	}}
+	run(t, h, `{"session_id": "s1", "tool_name": "Edit", "tool_input": {"file_path": "a.go"}}`)
+
+	records, err := LoadRecords(filepath.Join(dir, ".claude", LogFile))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("LoadRecords() = %+v, %v, want one record", records, err)
+	}
+	r := records[0]
+	if r.Hook != "PreToolUse" || r.Event != protocol.EventBlocked || r.WorkDir != dir {
+		t.Errorf("record = %+v, want a blocked PreToolUse in %s", r.LogEntry, dir)
+	}
+	if r.Input == nil || r.Input.ToolName != "Edit" || r.Input.GetFilePath() != "a.go" {
+		t.Errorf("record input = %+v, want the Edit of a.go", r.Input)
+	}
+	var out protocol.HookOutput
+	if err := json.Unmarshal(r.Output, &out); err != nil || out.HookSpecificOutput == nil || out.HookSpecificOutput.PermissionDecision != protocol.PermissionDeny {
+		t.Errorf("record output = %s (%v), want the denial", r.Output, err)
+	}
+
+	if records, err := LoadRecords(filepath.Join(dir, "missing.jsonl")); err != nil || records != nil {
+		t.Errorf("LoadRecords() of a missing log = %v, %v, want none", records, err)
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	h := Hook{Name: "PostToolUse", Key: config.HookPostToolUse, Handler: func(c *Context) error {
+		return protocol.WriteAdvice("compact now", protocol.Metadata{"event": protocol.EventCompactionRequired})
+	}}
+
+	setupProject(t, "{}")
+	if out, _ := run(t, h, "{}"); !strings.Contains(out, "compact now") {
+		t.Errorf("Run() = %s, want the advice shown", out)
+	}
+
+	// CI turns headless on: the advice is withheld and logged even with
+	// hook_log off
+	dir := setupProject(t, "{}")
+	t.Setenv("CI", "true")
+	if out, _ := run(t, h, `{"tool_name": "Read"}`); strings.Contains(out, "systemMessage") {
+		t.Errorf("headless Run() = %s, want no system message", out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".claude", LogFile))
+	if err != nil {
+		t.Fatalf("hook log not written in headless mode: %v", err)
+	}
+	var entry LogEntry
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil || entry.Withheld != "compact now" || entry.Event != protocol.EventCompactionRequired {
+		t.Errorf("log entry = %+v (%v), want the withheld advice and its event", entry, err)
+	}
+
+	setupProject(t, `{"headless": "off"}`)
+	t.Setenv("CI", "true")
+	if out, _ := run(t, h, "{}"); !strings.Contains(out, "compact now") {
+		t.Errorf("Run() with headless off = %s, want the advice shown", out)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	h := Hook{Name: "PreToolUse", Key: config.HookPreToolUse}
+
+	// An unreadable config skips the hook but is logged, hook_log or not
+	dir := setupProject(t, "{")
+	if out, c := run(t, h, "{}"); c != nil || out != "{}" {
+		t.Errorf("Run() with an unreadable config = %q, handler called %v, want skipped", out, c != nil)
+	}
+	records, err := LoadRecords(filepath.Join(dir, ".claude", LogFile))
+	if err != nil || len(records) != 1 || !reflect.DeepEqual(records[0].Codes, []string{"E041"}) {
+		t.Fatalf("LoadRecords() = %+v, %v, want one run with E041", records, err)
+	}
+
+	// Invalid input is shown as a code with error_codes on
+	dir = setupProject(t, `{"error_codes": true}`)
+	if out, c := run(t, h, "{"); c != nil || !strings.Contains(out, "[FIC:E001]") {
+		t.Errorf("Run() with invalid input = %q, handler called %v, want the E001 notice", out, c != nil)
+	}
+	records, _ = LoadRecords(filepath.Join(dir, ".claude", LogFile))
+	if len(records) != 1 || !reflect.DeepEqual(records[0].Codes, []string{"E001"}) {
+		t.Errorf("LoadRecords() = %+v, want one run with E001", records)
+	}
+
+	// A clean run with hook_log off logs nothing
+	dir = setupProject(t, `{"error_codes": true}`)
+	if out, _ := run(t, h, "{}"); strings.Contains(out, "FIC:") {
+		t.Errorf("Run() = %q, want no codes", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".claude", LogFile)); !os.IsNotExist(err) {
+		t.Errorf("hook log written for a clean run: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// Package hooks lists the harness hooks by the names they are registered
+// under in hooks.json.
+package hooks
+
+import (
+	"strings"
+
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/hooks/posttooluse"
+	"ultraharness/internal/hooks/precompact"
+	"ultraharness/internal/hooks/pretooluse"
+	"ultraharness/internal/hooks/sessionend"
+	"ultraharness/internal/hooks/sessionstart"
+	"ultraharness/internal/hooks/stop"
+	"ultraharness/internal/hooks/subagentstop"
+	"ultraharness/internal/hooks/userpromptsubmit"
+)
+
+// Names lists the hook names in the order the hooks run in a session.
+var Names = []string{
+	"session_start",
+	"user_prompt_submit",
+	"pre_tool_use",
+	"post_tool_use",
+	"subagent_stop",
+	"pre_compact",
+	"stop",
+	"session_end",
+}
+
+var byName = map[string]hookrunner.Hook{
+	"session_start":      sessionstart.Hook,
+	"user_prompt_submit": userpromptsubmit.Hook,
+	"pre_tool_use":       pretooluse.Hook,
+	"post_tool_use":      posttooluse.Hook,
+	"subagent_stop":      subagentstop.Hook,
+	"pre_compact":        precompact.Hook,
+	"stop":               stop.Hook,
+	"session_end":        sessionend.Hook,
+}
+
+// Lookup returns the hook called name, accepting dashes for underscores
+// ("post-tool-use") and the event name ("PostToolUse").
+func Lookup(name string) (hookrunner.Hook, bool) {
+	name = strings.ReplaceAll(name, "-", "_")
+	if h, ok := byName[name]; ok {
+		return h, true
+	}
+	for _, h := range byName {
+		if strings.EqualFold(h.Name, name) {
+			return h, true
+		}
+	}
+	return hookrunner.Hook{}, false
+}
@@ -0,0 +1,137 @@
+package hooks
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/protocol"
+)
+
+func TestNamesRegistered(t *testing.T) {
+	if len(Names) != len(byName) {
+		t.Fatalf("Names has %d hooks, registry has %d", len(Names), len(byName))
+	}
+	for _, name := range Names {
+		h, ok := byName[name]
+		if !ok || h.Handler == nil || h.Key == "" {
+			t.Errorf("hook %q is not fully registered: %+v", name, h)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"post_tool_use", "PostToolUse"},
+		{"post-tool-use", "PostToolUse"},
+		{"PostToolUse", "PostToolUse"},
+		{"session-start", "SessionStart"},
+		{"stop", "Stop"},
+		{"report", ""},
+	}
+	for _, tt := range tests {
+		h, ok := Lookup(tt.name)
+		if ok != (tt.want != "") || h.Name != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.name, h.Name, ok, tt.want)
+		}
+	}
+}
+
+// setupBenchProject initializes a project in dir and points the hooks at
+// it.
+func setupBenchProject(tb testing.TB, dir string) {
+	claudeDir := filepath.Join(dir, ".claude")
+	if err := os.MkdirAll(claudeDir, 0700); err != nil {
+		tb.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(claudeDir, config.InitMarkerFileName), nil, 0600)
+	os.WriteFile(filepath.Join(claudeDir, config.ConfigFileName), []byte("{}"), 0600)
+	tb.Setenv("CLAUDE_WORKING_DIRECTORY", dir)
+	for _, name := range config.CIEnvVars {
+		tb.Setenv(name, "")
+	}
+}
+
+// PerfBudgetEnvVar overrides defaultPerfBudget, e.g. "10ms".
+const PerfBudgetEnvVar = "ULTRAHARNESS_PERF_BUDGET"
+
+// defaultPerfBudget is the median wall time allowed for a PostToolUse run.
+// Hooks run synchronously in the edit loop; the budget is loose enough for
+// slow CI disks and catches regressions of an order of magnitude.
+const defaultPerfBudget = 25 * time.Millisecond
+
+// postToolUseInput is a Read call, the most frequent tool.
+func postToolUseInput(dir string) string {
+	return `{"session_id": "bench", "tool_name": "Read", "tool_input": {"file_path": "` + filepath.Join(dir, "main.go") + `"}, "tool_response": {"file": {"content": "package main"}}}`
+}
+
+// TestPostToolUseBudget fails if the median of a series of full PostToolUse
+// runs exceeds the performance budget.
+func TestPostToolUseBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("performance budget skipped in short mode")
+	}
+	budget := defaultPerfBudget
+	if v := os.Getenv(PerfBudgetEnvVar); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			t.Fatalf("invalid %s %q: %v", PerfBudgetEnvVar, v, err)
+		}
+		budget = d
+	}
+
+	dir, err := os.MkdirTemp("", "hooks-budget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setupBenchProject(t, dir)
+	protocol.SetOutput(io.Discard)
+	defer protocol.SetOutput(os.Stdout)
+
+	h, _ := Lookup("post_tool_use")
+	const runs = 21
+	times := make([]time.Duration, runs)
+	for i := range times {
+		start := time.Now()
+		if err := hookrunner.Run(h, strings.NewReader(postToolUseInput(dir))); err != nil {
+			t.Fatal(err)
+		}
+		times[i] = time.Since(start)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	if median := times[runs/2]; median > budget {
+		t.Errorf("PostToolUse median %v exceeds the %v budget (slowest %v); set %s to adjust", median, budget, times[runs-1], PerfBudgetEnvVar)
+	}
+}
+
+// BenchmarkPostToolUse measures a full PostToolUse run for a Read call,
+// the most frequent tool, once its state files exist.
+func BenchmarkPostToolUse(b *testing.B) {
+	dir, err := os.MkdirTemp("", "hooks-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setupBenchProject(b, dir)
+	protocol.SetOutput(io.Discard)
+	defer protocol.SetOutput(os.Stdout)
+
+	stdin := postToolUseInput(dir)
+	h, _ := Lookup("post_tool_use")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := hookrunner.Run(h, strings.NewReader(stdin)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,1103 @@
+// Package posttooluse implements the PostToolUse hook, which handles
+// context tracking, change detection, and progress logging.
+//
+// This hook runs after Edit, Write, Bash, Read, Grep, Glob, and Task tools to:
+// 1. Track context utilization with weighted tool estimates
+// 2. Warn when context is filling up (50%+)
+// 3. Trigger compaction directive when critical (70%+)
+// 4. Auto-log significant changes
+// 5. Suggest checkpoints after major changes
+// 6. Track session budget usage and estimated cost
+// 7. Stage changelog entries after commits
+// 8. Syntax check and auto-format edited files
+// 9. Check edited Go files for import cycles and boundary violations
+// 10. Map files read and searched into the codebase map
+// 11. Score the risk of each edit
+// 12. Suggest the tests likely affected by each edit
+// 13. Interrupt repeated edits, commands, and edit/test-fail cycles
+// 14. Record errors in Bash output as blockers until they are resolved
+// 15. Write a retrospective when a feature starts passing
+// 16. Record infrastructure plans and log their summaries
+// 17. Snapshot the harness state at each commit and warn when HEAD moves back
+package posttooluse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"ultraharness/internal/blockers"
+	"ultraharness/internal/budget"
+	"ultraharness/internal/changelog"
+	"ultraharness/internal/checkpoint"
+	"ultraharness/internal/codemap"
+	"ultraharness/internal/config"
+	"ultraharness/internal/context"
+	"ultraharness/internal/cost"
+	"ultraharness/internal/dailylog"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/features"
+	"ultraharness/internal/format"
+	"ultraharness/internal/git"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/imports"
+	"ultraharness/internal/infra"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/project"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/retro"
+	"ultraharness/internal/risk"
+	"ultraharness/internal/session"
+	"ultraharness/internal/syntax"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/testimpact"
+	"ultraharness/internal/testrunner"
+	"ultraharness/internal/text"
+	"ultraharness/internal/validation"
+	"ultraharness/internal/watchdog"
+)
+
+// Default thresholds if not configured
+const (
+	DefaultToolCountWarning  = 30  // Warn after 30 tool calls
+	DefaultToolCountCritical = 50  // Critical after 50 tool calls
+	DefaultUtilizationWarn   = 0.5 // 50% utilization warning
+)
+
+// Hook is the PostToolUse hook.
+var Hook = hookrunner.Hook{
+	Name:    "PostToolUse",
+	Key:     config.HookPostToolUse,
+	Handler: run,
+}
+
+func run(c *hookrunner.Context) error {
+	workDir, cfg, input := c.WorkDir, c.Config, c.Input
+
+	var messages []string
+	meta := protocol.Metadata{"tool": input.ToolName}
+
+	// Per-session activity tracking (budget and cost)
+	sess, err := session.Load(session.ResolveID(input.SessionID), workDir)
+	if err != nil {
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+		sess = session.NewState(session.ResolveID(input.SessionID))
+		if cfg.DailyLog {
+			sess.FeatureStatuses = dailylog.FeatureStatuses(workDir)
+		}
+	}
+	sess.RecordToolCall(input.ToolName, input.GetFilePath())
+	if input.ToolName == "Task" {
+		sess.RecordSubagentDone()
+	}
+	if cfg.LoopWatchdog {
+		if action, ok := watchdog.ActionFor(input); ok {
+			sess.RecordAction(action)
+		}
+	}
+
+	// Warn about files changed on disk since the session last read or wrote
+	// them, so the agent re-reads them instead of editing stale content
+	switch input.ToolName {
+	case "Read", "Edit", "Write":
+		sess.TrackFile(input.GetFilePath())
+	}
+	if changed := sess.ChangedFiles(); len(changed) > 0 {
+		messages = append(messages, formatChangedFiles(workDir, changed))
+		meta["files_changed"] = len(changed)
+	}
+
+	// Map what exploration reveals so sessions after a compaction do not
+	// re-explore it
+	if cfg.CodebaseMap && (input.ToolName == "Read" || input.ToolName == "Grep") {
+		if recordCodebaseMap(workDir, input) {
+			meta["mapped"] = true
+		}
+	}
+
+	// Strictness escalation by session age; announce the switch on the call
+	// that crosses a threshold
+	previous := cfg.EscalatedStrictness(sess.ToolCalls - 1)
+	cfg.Escalate(sess.ToolCalls)
+	if cfg.Strictness != previous {
+		messages = append(messages, fmt.Sprintf("[Harness] Session escalated to %s mode after %d tool calls.",
+			cfg.Strictness, sess.ToolCalls))
+		meta["escalated_to"] = cfg.Strictness
+	}
+
+	// Context intelligence tracking
+	var contextMsg, contextEvent string
+	if cfg.FICEnabled && cfg.FICContextTracking {
+		contextMsg, contextEvent = trackContext(input, workDir, cfg, sess, meta)
+	}
+
+	// Save session state before any early return
+	// Continue even if the save fails
+	errcode.Report(errcode.New(errcode.StateNotSaved, sess.Save(workDir)))
+
+	if contextMsg != "" {
+		switch {
+		case protocol.IsHeadless():
+			// Compaction directives and context warnings only go to the log
+			meta["withheld"] = contextMsg
+		case contextEvent == protocol.EventCompactionRequired || contextEvent == protocol.EventCompactionRecommended:
+			// If compaction is needed, return immediately with high priority
+			return protocol.WriteMessage(contextMsg, meta)
+		default:
+			messages = append(messages, contextMsg)
+		}
+	}
+
+	// Session budget check
+	if msg := checkBudget(sess, cfg); msg != "" {
+		messages = append(messages, msg)
+		meta["budget_status"] = true
+	}
+
+	// Skip further processing in relaxed mode, and only track progress for
+	// file modifications
+	toolName := input.ToolName
+	if cfg.IsRelaxedMode() || (toolName != "Edit" && toolName != "Write" && toolName != "Bash") {
+		return writeMessages(cfg, input, sess, messages, nil, meta)
+	}
+
+	var checks []string
+
+	// Classify change and auto-log
+	if cfg.AutoProgressLogging {
+		logEntry := classifyAndLog(toolName, input, workDir, cfg)
+		if logEntry != "" {
+			messages = append(messages, logEntry)
+			meta["progress_logged"] = true
+		}
+	}
+
+	// Surface syntax errors in the edited file right away, then format it;
+	// there is no point formatting code that does not parse
+	if toolName == "Edit" || toolName == "Write" {
+		syntaxMsg := ""
+		if cfg.SyntaxCheck {
+			syntaxMsg = checkSyntax(workDir, cfg, input.GetFilePath())
+			checks = append(checks, "syntax")
+		}
+		if syntaxMsg != "" {
+			messages = append(messages, syntaxMsg)
+			meta["syntax_error"] = true
+		} else if cfg.AutoFormat {
+			checks = append(checks, "format")
+			if msg := autoFormat(workDir, cfg, input.GetFilePath()); msg != "" {
+				messages = append(messages, msg)
+				meta["formatted"] = true
+				// The message already asks for a re-read
+				sess.TrackFile(input.GetFilePath())
+				sess.Save(workDir)
+			}
+		}
+
+		if syntaxMsg == "" && cfg.ImportCheck && touchesImports(input) {
+			checks = append(checks, "imports")
+			if msg := checkImports(cfg, input.GetFilePath()); msg != "" {
+				messages = append(messages, msg)
+				meta["import_violations"] = true
+			}
+		}
+
+		if cfg.RiskScoring {
+			checks = append(checks, "risk")
+			if msg := assessRisk(workDir, cfg, sess, input.GetFilePath(), meta); msg != "" {
+				messages = append(messages, msg)
+			}
+		}
+
+		if syntaxMsg == "" && cfg.TestImpact {
+			checks = append(checks, "test impact")
+			if msg := suggestTests(workDir, cfg, sess, input.GetFilePath()); msg != "" {
+				messages = append(messages, msg)
+				meta["tests_suggested"] = true
+			}
+		}
+	}
+
+	// Check for test results in Bash output
+	var testOutcome string
+	if toolName == "Bash" {
+		checks = append(checks, "tests")
+		outcome, testMsg := checkTestResults(workDir, input)
+		testOutcome = outcome
+		if testMsg != "" {
+			messages = append(messages, testMsg)
+			meta["tests"] = outcome
+			sess.RecordTestRun(outcome == "passed")
+			sess.Save(workDir)
+		}
+	}
+
+	// Errors in the output stay on the blockers list until a rerun of the
+	// command no longer reports them
+	if toolName == "Bash" && cfg.BlockerTracking {
+		checks = append(checks, "blockers")
+		if msg := recordBlockers(workDir, input, meta); msg != "" {
+			messages = append(messages, msg)
+		}
+	}
+
+	// Plans that allow an infrastructure apply, and applies that use them
+	if toolName == "Bash" && cfg.InfraGate {
+		checks = append(checks, "infra")
+		if msg := recordInfraCommands(workDir, cfg, input); msg != "" {
+			messages = append(messages, msg)
+			meta["infra_plan"] = true
+		}
+	}
+
+	// Features that started passing, by a verify_feature run or a hand
+	// edit of the checklist
+	if cfg.Retrospectives && features.Exists(workDir) &&
+		(toolName == "Bash" || filepath.Base(input.GetFilePath()) == features.FeaturesFile) {
+		checks = append(checks, "retrospective")
+		if msg := writeRetrospectives(workDir, cfg, testOutcome); msg != "" {
+			messages = append(messages, msg)
+			meta["retrospective"] = true
+		}
+	}
+
+	// Stage changelog entries after a commit
+	if toolName == "Bash" && cfg.ChangelogStaging && strings.Contains(input.GetCommand(), "git commit") {
+		checks = append(checks, "changelog")
+		if msg := stageChangelog(workDir); msg != "" {
+			messages = append(messages, msg)
+			meta["changelog_staged"] = true
+		}
+	}
+
+	// A commit is a checkpoint to snapshot the harness state at; a reset or
+	// checkout back to one leaves the FIC artifacts describing later work
+	if toolName == "Bash" && cfg.CheckpointSnapshots {
+		checks = append(checks, "checkpoint")
+		if msg := observeCheckpoint(workDir, meta); msg != "" {
+			messages = append(messages, msg)
+		}
+	}
+
+	// Runaway repetition, once this call's test results are recorded
+	if cfg.LoopWatchdog {
+		checks = append(checks, "watchdog")
+		if loop := watchdog.Detect(sess, cfg.GetWatchdog()); loop != nil {
+			watchdog.Report(sess)
+			held := cfg.IsStrictMode()
+			if held {
+				watchdog.Hold(workDir, sess, loop)
+			}
+			sess.Save(workDir)
+			messages = append(messages, watchdog.Directive(workDir, loop, sess.LoopWarnings, held))
+			meta["loop"] = loop.Kind
+		}
+	}
+
+	return writeMessages(cfg, input, sess, messages, checks, meta)
+}
+
+// assessRisk scores the edit and records it in the session. It returns an
+// advisory when the file's risk first reaches MEDIUM or HIGH this session.
+func assessRisk(workDir string, cfg *config.Config, sess *session.State, path string, meta protocol.Metadata) string {
+	if path == "" {
+		return ""
+	}
+	codeFiles := cfg.GetCodeFiles()
+	assessment := risk.Assess(workDir, cfg.GetRisk(), path, git.UncommittedLines(workDir, path), func(rel string) bool {
+		return git.IsCodeFile(rel, codeFiles.Extensions, codeFiles.Exclude)
+	})
+	meta["risk"] = assessment.Level
+
+	previous, seen := sess.EditRisks[assessment.Path]
+	sess.RecordRisk(assessment.Path, assessment.Score)
+	sess.Save(workDir)
+	if assessment.Level == risk.Low || (seen && risk.Level(previous) == assessment.Level) || previous > assessment.Score {
+		return ""
+	}
+
+	msg := "[Harness] " + assessment.Summary() + "."
+	if assessment.Level == risk.High {
+		msg += "\nRun the tests that exercise this file, and commit a checkpoint before changing it further."
+	}
+	return msg
+}
+
+// recordInfraCommands records the summary of each successful
+// infrastructure plan in a Bash command, allowing its stack to be applied,
+// and drops a stack's plan once it is applied. Both are logged to progress.
+// It returns a message for the recorded plans.
+func recordInfraCommands(workDir string, cfg *config.Config, input *protocol.HookInput) string {
+	commands := infra.Parse(input.GetCommand())
+	if len(commands) == 0 {
+		return ""
+	}
+	plans, err := infra.Load(workDir)
+	if err != nil {
+		return ""
+	}
+
+	code, hasCode := input.GetExitCode()
+	output := input.GetToolResult()
+	var lines []string
+	for _, c := range commands {
+		summary, summarized := infra.Summarize(c.Tool, output)
+		switch c.Kind {
+		case infra.KindPlan:
+			// terraform plan -detailed-exitcode exits 2 when there are changes
+			if !summarized || (hasCode && code != 0 && code != 2) {
+				continue
+			}
+			plans.Record(c, input.GetCommand(), summary, time.Now())
+			lines = append(lines, fmt.Sprintf("[Harness] Recorded the plan of %s: %s\nReview the changes with the user; applying them is allowed for the next %d minutes.",
+				c, summary, cfg.GetInfraPlanMaxAgeMinutes()))
+			logInfra(workDir, cfg, "INFRA PLAN", c, summary)
+		case infra.KindApply:
+			// A failed apply keeps the plan; one without an exit code is
+			// taken to have used it up, so the next apply is planned again
+			if hasCode && code != 0 {
+				continue
+			}
+			plans.Remove(c)
+			if !summarized {
+				summary = c.Tool + " " + c.Subcommand
+			}
+			logInfra(workDir, cfg, "INFRA APPLY", c, summary)
+		}
+	}
+	if err := plans.Save(workDir); err != nil {
+		return ""
+	}
+	return strings.Join(lines, "\n")
+}
+
+// logInfra records an infrastructure plan or apply in the progress log.
+func logInfra(workDir string, cfg *config.Config, label string, c infra.Command, summary string) {
+	if cfg.AutoProgressLogging {
+		progress.Append(progress.SourceAuto, fmt.Sprintf("%s (%s): %s", label, c, summary), workDir)
+	}
+}
+
+// maxReportedBlockers limits how many new blockers a message lists.
+const maxReportedBlockers = 3
+
+// recordBlockers records the errors in a Bash command's output as
+// blockers, and resolves those the command reported before but not now.
+// A command that exits 0 reports none.
+func recordBlockers(workDir string, input *protocol.HookInput, meta protocol.Metadata) string {
+	var found []blockers.Blocker
+	if code, ok := input.GetExitCode(); !ok || code != 0 {
+		found = blockers.Extract(workDir, input.GetToolResult())
+	}
+
+	list, err := blockers.Load(workDir)
+	if err != nil {
+		return ""
+	}
+	added, resolved := list.Record(input.GetCommand(), found)
+	if len(found) == 0 && resolved == 0 {
+		return ""
+	}
+	if err := list.Save(workDir); err != nil {
+		return ""
+	}
+	open := list.Unresolved()
+	meta["blockers"] = len(open)
+
+	var lines []string
+	if added > 0 {
+		lines = append(lines, fmt.Sprintf("[Harness] Recorded %d new blocker(s), %d unresolved:", added, len(open)))
+		for i, b := range found {
+			if i == maxReportedBlockers {
+				lines = append(lines, fmt.Sprintf("  ... and %d more", len(found)-i))
+				break
+			}
+			lines = append(lines, "  - "+b.String())
+		}
+	}
+	if resolved > 0 {
+		lines = append(lines, fmt.Sprintf("[Harness] Resolved %d blocker(s); %d unresolved.", resolved, len(open)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeRetrospectives counts a test run toward the next retrospective and
+// writes one for each feature that started passing, adding its summary to
+// the knowledge base.
+func writeRetrospectives(workDir string, cfg *config.Config, testOutcome string) string {
+	data, err := features.Load(workDir)
+	if err != nil {
+		return ""
+	}
+	log, err := retro.Load(workDir)
+	if err != nil {
+		return ""
+	}
+	if testOutcome != "" {
+		log.RecordTestRun(testOutcome == "passed")
+	}
+	done := log.Check(workDir, data)
+	if err := log.Save(workDir); err != nil || len(done) == 0 {
+		return ""
+	}
+
+	var base *knowledge.Base
+	if cfg.KnowledgeBase {
+		base, _ = knowledge.Load(workDir)
+	}
+	var lines []string
+	for _, r := range done {
+		summary := r.Summary()
+		lines = append(lines, "[Harness] "+summary+".")
+		if base != nil {
+			base.Add(summary, "retrospective: "+r.FeatureID, false)
+		}
+	}
+	if base != nil {
+		base.Save(workDir)
+	}
+	lines = append(lines, fmt.Sprintf("Saved in .claude/%s.", retro.FileName))
+	return strings.Join(lines, "\n")
+}
+
+// suggestTests names the tests likely affected by an edit, once per file
+// until the next test run.
+func suggestTests(workDir string, cfg *config.Config, sess *session.State, path string) string {
+	if path == "" {
+		return ""
+	}
+	if !sess.SuggestTests(path) {
+		return ""
+	}
+	sess.Save(workDir)
+
+	impact := testimpact.Find(workDir, path, cfg.GetMaxImpactedTests(), imports.DefaultTimeout)
+	if len(impact.Files) == 0 {
+		return ""
+	}
+
+	msg := fmt.Sprintf("[Harness] Tests likely affected by %s: %s.", filepath.Base(path), strings.Join(impact.Files, ", "))
+	if impact.Command != "" {
+		return msg + "\nRun them to verify the change cheaply: `" + impact.Command + "`"
+	}
+	return msg + "\nRun them to verify the change cheaply before the full suite."
+}
+
+// writeMessages outputs the collected messages, followed in verbose mode by
+// a diagnostic line naming the checks that ran.
+func writeMessages(cfg *config.Config, input *protocol.HookInput, sess *session.State, messages, checks []string, meta protocol.Metadata) error {
+	if cfg.IsVerbose() {
+		ran := "none"
+		if len(checks) > 0 {
+			ran = strings.Join(checks, ", ")
+		}
+		messages = append(messages, fmt.Sprintf("[Harness debug] %s (call %d, session %s) | checks: %s",
+			input.ToolName, sess.ToolCalls, sess.SessionID, ran))
+	}
+	if len(messages) > 0 || meta["withheld"] != nil {
+		return protocol.WriteMessage(strings.Join(messages, "\n"), meta)
+	}
+	return protocol.WriteEmpty()
+}
+
+// maxChangedFilesListed caps the files named in the changed-on-disk warning
+const maxChangedFilesListed = 5
+
+// formatChangedFiles warns about tracked files changed outside the session.
+func formatChangedFiles(workDir string, changed []string) string {
+	var names []string
+	for i, path := range changed {
+		if i == maxChangedFilesListed {
+			names = append(names, fmt.Sprintf("and %d more", len(changed)-i))
+			break
+		}
+		if r, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(r, "..") {
+			path = r
+		}
+		names = append(names, path)
+	}
+	return fmt.Sprintf("[Harness] Changed on disk since last read: %s. Re-read before editing so you do not overwrite changes made outside this session.",
+		strings.Join(names, ", "))
+}
+
+// recordCodebaseMap adds a Read file's notes, or the files a Grep pattern
+// matched, to the codebase map. Returns true if the map was updated.
+func recordCodebaseMap(workDir string, input *protocol.HookInput) bool {
+	m, err := codemap.Load(workDir)
+	if err != nil {
+		return false
+	}
+	switch input.ToolName {
+	case "Read":
+		rel, err := filepath.Rel(workDir, input.GetFilePath())
+		content := input.ToolResponse.FileContent()
+		if content == "" {
+			content = input.ToolResult
+		}
+		if err != nil || strings.HasPrefix(rel, "..") || content == "" {
+			return false
+		}
+		m.RecordFile(filepath.ToSlash(rel), content)
+	case "Grep":
+		files := grepFiles(workDir, input)
+		if len(files) == 0 {
+			return false
+		}
+		m.RecordSearch(input.GetPattern(), files)
+	}
+	return m.Save(workDir) == nil
+}
+
+// grepFiles returns the project-relative files a Grep matched, from the
+// response's file list or the "path:line:text" lines of content output.
+func grepFiles(workDir string, input *protocol.HookInput) []string {
+	names := input.ToolResponse.Filenames()
+	if names == nil {
+		for _, line := range strings.Split(input.GetToolResult(), "\n") {
+			if i := strings.Index(line, ":"); i > 0 {
+				line = line[:i]
+			}
+			names = append(names, strings.TrimSpace(line))
+		}
+	}
+
+	var files []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, path)
+		}
+		rel, err := filepath.Rel(workDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") || seen[rel] {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		seen[rel] = true
+		files = append(files, filepath.ToSlash(rel))
+		if len(files) == codemap.MaxTermFiles {
+			break
+		}
+	}
+	return files
+}
+
+// trackContext records the tool call in the context state and returns any
+// compaction directive, warning, or status update with its event, also
+// describing it in meta.
+func trackContext(input *protocol.HookInput, workDir string, cfg *config.Config, sess *session.State, meta protocol.Metadata) (string, string) {
+	sessionID := input.SessionID
+	if sessionID == "" {
+		sessionID = "default"
+	}
+
+	// Validate session ID
+	if err := validation.ValidateSessionID(sessionID); err != nil {
+		sessionID = "default"
+	}
+
+	state, err := context.LoadContextState(sessionID, workDir)
+	if err != nil {
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+		return "", ""
+	}
+
+	// Add this tool use to context tracking. A Read reports the file
+	// content it returned, which measures its size better than the weight.
+	if content := input.ToolResponse.FileContent(); input.ToolName == "Read" && content != "" {
+		state.AddMeasuredEntry(input.ToolName, context.EstimateReadTokens(content))
+	} else {
+		state.AddEntry(input.ToolName, input.GetToolResult())
+	}
+
+	// Each call re-sends the current context as input for cost estimation
+	sess.RecordTokens(state.TotalTokenEstimate, cost.OutputTokensPerCall)
+
+	// The first call after a compaction measures how much it freed
+	if result := state.ResolveCompaction(); result != nil {
+		sess.RecordCompactionFreed(result.Freed())
+		meta["compaction_freed"] = result.Freed()
+	}
+
+	// Detect repeated reads of the same file or runs of the same search
+	redundancyMsg := checkRedundancy(input, state, cfg.GetRedundancyThreshold())
+	if redundancyMsg != "" {
+		meta["redundant_access"] = true
+	}
+
+	// Searches calibrate the weight of later ones, and repeated searches
+	// returning hundreds of results get advice to narrow them
+	if input.ToolName == "Grep" || input.ToolName == "Glob" {
+		if result := input.GetToolResult(); result != "" {
+			state.ObserveResult(input.ToolName, len(result)/4)
+		}
+		if searchMsg := checkSearchBreadth(input, state, cfg.GetBroadSearchResults()); searchMsg != "" {
+			meta["broad_search"] = true
+			redundancyMsg = joinMessages(redundancyMsg, searchMsg)
+		}
+	}
+
+	// Save updated state
+	errcode.Report(errcode.New(errcode.StateNotSaved, state.Save(workDir)))
+
+	// Get thresholds from config
+	autoCompactThreshold := cfg.GetAutoCompactThreshold()
+	compactionToolThreshold := cfg.GetCompactionToolThreshold()
+	if compactionToolThreshold == 0 {
+		compactionToolThreshold = DefaultToolCountCritical
+	}
+	autoCompactEnabled := cfg.IsAutoCompactEnabled()
+	quiet := cfg.IsQuiet()
+	meta["utilization"] = state.UtilizationPercent
+	meta["token_estimate"] = state.TotalTokenEstimate
+	meta["tool_calls"] = state.TotalToolCalls
+
+	// Check for CRITICAL: auto-compaction needed (token-based)
+	if state.NeedsCompaction(autoCompactThreshold) {
+		meta["event"] = protocol.EventCompactionRequired
+		meta["reason"] = "utilization"
+		meta["threshold"] = autoCompactThreshold
+		directive := "compaction_directive"
+		if autoCompactEnabled {
+			directive = "auto_compact_directive"
+		}
+		return templates.Render(workDir, directive,
+			contextData(state, "utilization", autoCompactThreshold, compactionToolThreshold, quiet)), protocol.EventCompactionRequired
+	}
+
+	// Check for CRITICAL: tool count exceeded
+	if state.NeedsCompactionByToolCount(compactionToolThreshold) {
+		event, directive := protocol.EventCompactionRecommended, "tool_count_directive"
+		if autoCompactEnabled {
+			event, directive = protocol.EventCompactionRequired, "auto_compact_directive"
+		}
+		meta["event"] = event
+		meta["reason"] = "tool_count"
+		meta["threshold"] = compactionToolThreshold
+		return templates.Render(workDir, directive,
+			contextData(state, "tool_count", autoCompactThreshold, compactionToolThreshold, quiet)), event
+	}
+
+	// Check for WARNING: approaching limits
+	warningToolCount := compactionToolThreshold * 2 / 3 // ~67% of critical
+	if state.CompactionToolCount() >= warningToolCount || state.UtilizationPercent >= DefaultUtilizationWarn {
+		meta["event"] = protocol.EventContextWarning
+		warning := templates.Render(workDir, "context_warning",
+			contextData(state, "", autoCompactThreshold, compactionToolThreshold, quiet))
+		return joinMessages(warning, redundancyMsg), protocol.EventContextWarning
+	}
+
+	// Periodic status update every 10 tool calls (every call when verbose,
+	// never when quiet)
+	if !quiet && state.TotalToolCalls > 0 && (state.TotalToolCalls%10 == 0 || cfg.IsVerbose()) {
+		meta["event"] = protocol.EventContextStatus
+		status := fmt.Sprintf("[FIC] %s | Est. cost: %s", state.GetSummary(),
+			cost.Format(cost.Estimate(sess.InputTokens, sess.OutputTokens, cfg.GetModelPrice())))
+		if last := state.LastCompaction; last != nil && !last.Reported {
+			status += " | Last compaction " + last.String()
+			last.Reported = true
+			state.Save(workDir)
+		}
+		return joinMessages(status, redundancyMsg), protocol.EventContextStatus
+	}
+
+	return redundancyMsg, ""
+}
+
+// checkRedundancy records Read/Grep/Glob targets and returns a gentle notice
+// once the same target has been repeated more than threshold times.
+func checkRedundancy(input *protocol.HookInput, state *context.ContextState, threshold int) string {
+	// A different range of a file, or the same pattern in another path or
+	// file glob, is new content rather than a repeat
+	var target, scope string
+	switch input.ToolName {
+	case "Read":
+		target = input.GetFilePath()
+		scope = readRange(input)
+	case "Grep", "Glob":
+		target = input.GetPattern()
+		scope = searchScope(input)
+	default:
+		return ""
+	}
+	if target == "" {
+		return ""
+	}
+	key := target
+	if scope != "" {
+		key += " (" + scope + ")"
+	}
+
+	count := state.RecordAccess(input.ToolName, key, threshold)
+	if count <= threshold {
+		return ""
+	}
+
+	if input.ToolName == "Read" {
+		label := filepath.Base(target)
+		if scope != "" {
+			label += " (" + scope + ")"
+		}
+		return fmt.Sprintf("[FIC] You've read %s %d times — consider noting key facts instead of re-reading.",
+			label, count)
+	}
+	label := "'" + target + "'"
+	if scope != "" {
+		label += " " + scope
+	}
+	return fmt.Sprintf("[FIC] You've run %s %s %d times — consider noting the results instead of re-searching.",
+		input.ToolName, label, count)
+}
+
+// readRange describes the lines a Read asked for, e.g. "lines 100-149",
+// or "" for the whole file.
+func readRange(input *protocol.HookInput) string {
+	offset, hasOffset := input.ToolInput["offset"].(float64)
+	limit, hasLimit := input.ToolInput["limit"].(float64)
+	switch {
+	case hasOffset && hasLimit:
+		return fmt.Sprintf("lines %d-%d", int(offset), int(offset+limit)-1)
+	case hasOffset:
+		return fmt.Sprintf("lines %d-end", int(offset))
+	case hasLimit:
+		return fmt.Sprintf("lines 1-%d", int(limit))
+	}
+	return ""
+}
+
+// searchScope describes where a Grep or Glob searched, e.g.
+// "in src glob *.go", or "" for the whole project.
+func searchScope(input *protocol.HookInput) string {
+	var parts []string
+	if path, _ := input.ToolInput["path"].(string); path != "" {
+		parts = append(parts, "in "+path)
+	}
+	if glob, _ := input.ToolInput["glob"].(string); glob != "" {
+		parts = append(parts, "glob "+glob)
+	}
+	return strings.Join(parts, " ")
+}
+
+// BroadSearchRepeats is the number of the recent searches that must have
+// been too broad, the latest included, before advice to narrow them
+const BroadSearchRepeats = 2
+
+// checkSearchBreadth records how many results a Grep or Glob returned and
+// returns advice once searches keep returning more than limit.
+func checkSearchBreadth(input *protocol.HookInput, state *context.ContextState, limit int) string {
+	results, truncated := searchResultCount(input)
+	if truncated && results <= limit {
+		// The tool cut the listing short, so there were more results
+		results = limit + 1
+	}
+	broad := state.RecordSearchResults(results, limit)
+	if results <= limit || broad < BroadSearchRepeats {
+		return ""
+	}
+	return fmt.Sprintf("[FIC] %d of your last %d searches returned more than %d results. "+
+		"Narrow the pattern (a more specific term, or a path, glob, or type filter), use files_with_matches "+
+		"or count mode, or delegate the exploration to a Task subagent that reports back only what matters.",
+		broad, len(state.RecentSearchResults), limit)
+}
+
+// searchResultCount returns how many results a Grep or Glob returned: the
+// count its response reports, or else the paths or lines it lists.
+// truncated is set when the response says the listing was cut short.
+func searchResultCount(input *protocol.HookInput) (results int, truncated bool) {
+	if r := input.ToolResponse; r != nil {
+		truncated, _ = r.Fields["truncated"].(bool)
+		// Content mode reports lines, count mode matches, and file modes files
+		for _, key := range []string{"numLines", "numMatches", "numFiles"} {
+			if n, ok := r.Fields[key].(float64); ok {
+				return int(n), truncated
+			}
+		}
+		if names := r.Filenames(); names != nil {
+			return len(names), truncated
+		}
+	}
+	for _, line := range strings.Split(input.GetToolResult(), "\n") {
+		if strings.TrimSpace(line) != "" {
+			results++
+		}
+	}
+	return results, truncated
+}
+
+// joinMessages joins non-empty messages with newlines
+func joinMessages(msgs ...string) string {
+	var nonEmpty []string
+	for _, m := range msgs {
+		if m != "" {
+			nonEmpty = append(nonEmpty, m)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+// checkBudget returns a warning when session budget limits are
+// approaching (every 5 calls, unless quiet) or exceeded.
+func checkBudget(sess *session.State, cfg *config.Config) string {
+	status := budget.Check(sess, cfg.GetBudget())
+	if !status.IsExceeded() && (cfg.IsQuiet() || sess.ToolCalls%5 != 0) {
+		return ""
+	}
+	return budget.FormatStatus(status)
+}
+
+// contextData is the template data describing the context state.
+func contextData(state *context.ContextState, reason string, threshold float64, maxTools int, quiet bool) templates.Context {
+	remaining := maxTools - state.CompactionToolCount()
+	if remaining < 0 {
+		remaining = 0
+	}
+	return templates.Context{
+		Reason:        reason,
+		Utilization:   state.UtilizationPercent,
+		Threshold:     threshold,
+		TokenEstimate: state.TotalTokenEstimate,
+		ToolCalls:     state.TotalToolCalls,
+		ToolLimit:     maxTools,
+		Remaining:     remaining,
+		Redundant:     state.RedundantAccessCount(),
+		Summary:       state.GetSummary(),
+		Compactions:   state.CompactionCount,
+		Quiet:         quiet,
+	}
+}
+
+// significantKeywords mark Bash commands worth logging to progress: tests,
+// builds, and deployments.
+var significantKeywords = []string{"test", "build", "deploy", "npm", "cargo", "go build"}
+
+// isSignificantCommand reports whether cmd contains a significant keyword,
+// is one of the project's own test/build/lint commands, or matches one of
+// the configured patterns. Patterns that do not compile are skipped.
+func isSignificantCommand(cmd, workDir string, patterns []string) bool {
+	for _, k := range significantKeywords {
+		if strings.Contains(cmd, k) {
+			return true
+		}
+	}
+	if project.Detect(workDir).IsToolCommand(cmd) {
+		return true
+	}
+	for _, p := range patterns {
+		if re, err := regexp.Compile(p); err == nil && p != "" && re.MatchString(cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+func classifyAndLog(toolName string, input *protocol.HookInput, workDir string, cfg *config.Config) string {
+	// Classify change level based on tool and file
+	filePath := input.GetFilePath()
+	if filePath == "" && toolName != "Bash" {
+		return ""
+	}
+
+	// Determine if significant
+	isSignificant := false
+	var reason string
+
+	switch toolName {
+	case "Write":
+		isSignificant = true
+		reason = "new file created"
+	case "Edit":
+		// Large edits are significant
+		if len(input.GetToolResult()) > 500 {
+			isSignificant = true
+			reason = "substantial edit"
+		}
+	case "Bash":
+		if isSignificantCommand(input.GetCommand(), workDir, cfg.GetCodeFiles().SignificantCommands) {
+			isSignificant = true
+			reason = "build/test command"
+		}
+	}
+
+	if !isSignificant {
+		return ""
+	}
+
+	// Format log entry
+	var logEntry string
+	switch toolName {
+	case "Write":
+		filename := filepath.Base(filePath)
+		logEntry = fmt.Sprintf("AUTO: Created %s (%s)", filename, reason)
+	case "Edit":
+		filename := filepath.Base(filePath)
+		logEntry = fmt.Sprintf("AUTO: Modified %s (%s)", filename, reason)
+	case "Bash":
+		logEntry = fmt.Sprintf("AUTO: Ran '%s' (%s)", text.Truncate(input.GetCommand(), 40), reason)
+	}
+
+	// Append to progress file (ignore errors)
+	progress.Append(progress.SourceAuto, logEntry, workDir)
+
+	return ""
+}
+
+// checkSyntax runs the syntax checker for the edited file and reports any
+// errors it finds.
+func checkSyntax(workDir string, cfg *config.Config, path string) string {
+	if path == "" {
+		return ""
+	}
+	timeout := time.Duration(cfg.GetSyntaxTimeoutSeconds()) * time.Second
+	result := syntax.Check(workDir, path, timeout)
+	if result == nil || result.OK {
+		return ""
+	}
+
+	rel := path
+	if r, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(r, "..") {
+		rel = r
+	}
+	return fmt.Sprintf("[Harness] Syntax error in %s (%s):\n%s\nFix it before continuing.", rel, result.Checker, result.Output)
+}
+
+// autoFormat runs the project's formatter on the edited file and reports
+// whether it reformatted anything.
+func autoFormat(workDir string, cfg *config.Config, path string) string {
+	if path == "" {
+		return ""
+	}
+	result := format.Run(workDir, path, cfg.Formatters, format.DefaultTimeout)
+	if result == nil {
+		return ""
+	}
+
+	rel := path
+	if r, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(r, "..") {
+		rel = r
+	}
+	switch {
+	case result.Error != "":
+		return fmt.Sprintf("[Harness] %s could not format %s:\n%s", result.Formatter, rel, result.Error)
+	case result.Changed:
+		return fmt.Sprintf("[Harness] Reformatted %s with %s. Re-read it before editing it again.", rel, result.Formatter)
+	}
+	return ""
+}
+
+// importLine matches an import declaration or a quoted path alone on a
+// line, as inside an import block.
+var importLine = regexp.MustCompile(`(?m)^\s*(import\b|[\w.]*\s*"[^"\s]+"\s*$)`)
+
+// touchesImports reports whether the Write or Edit may have changed a Go
+// file's imports, so unrelated edits skip the package graph.
+func touchesImports(input *protocol.HookInput) bool {
+	if !strings.HasSuffix(input.GetFilePath(), ".go") {
+		return false
+	}
+	if input.ToolName == "Write" {
+		return true
+	}
+	return importLine.MatchString(input.GetOldString()) || importLine.MatchString(input.GetNewString())
+}
+
+// checkImports reports import cycles and boundary violations introduced
+// by the edited Go file.
+func checkImports(cfg *config.Config, path string) string {
+	violations, _ := imports.Check(path, cfg.ImportBoundaries, imports.DefaultTimeout)
+	if len(violations) == 0 {
+		return ""
+	}
+
+	lines := []string{fmt.Sprintf("[Harness] Import problems in %s:", filepath.Base(path))}
+	for _, v := range violations {
+		lines = append(lines, "  - "+v.String())
+	}
+	lines = append(lines, "Remove or invert the offending import, e.g. by moving shared code to a lower-level package.")
+	return strings.Join(lines, "\n")
+}
+
+func stageChangelog(workDir string) string {
+	progressContent, _ := progress.Read(workDir)
+	entries := changelog.Collect(workDir, git.LastCommitSubject(workDir), progressContent)
+
+	added, err := changelog.Stage(workDir, entries)
+	if err != nil || added == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[Harness] Staged %d changelog entries under Unreleased in %s.", added, changelog.ChangelogFile)
+}
+
+// observeCheckpoint records HEAD and returns an advisory when it moved back
+// to an earlier commit.
+func observeCheckpoint(workDir string, meta protocol.Metadata) string {
+	move, err := checkpoint.Observe(workDir)
+	if err != nil {
+		errcode.Report(errcode.New(errcode.StateNotSaved, err))
+		return ""
+	}
+	if move == nil {
+		return ""
+	}
+	meta["head_moved_back"] = true
+	if move.Snapshot != "" {
+		meta["checkpoint"] = move.Snapshot
+	}
+	return move.Advisory()
+}
+
+// maxFailingTests limits how many failing test names a message lists.
+const maxFailingTests = 5
+
+// checkTestResults reports the outcome of a Bash command that runs tests,
+// returning "passed" or "failed" and a message naming the failing tests.
+// The exit code decides when the tool response includes one; otherwise the
+// runner's pass/fail counts do.
+func checkTestResults(workDir string, input *protocol.HookInput) (string, string) {
+	command := input.GetCommand()
+	if !testrunner.IsTestCommand(command) && !project.Detect(workDir).IsTestCommand(command) {
+		return "", ""
+	}
+
+	output := input.GetToolResult()
+	failing := testrunner.FailingTests(output)
+
+	var passed bool
+	code, hasCode := input.GetExitCode()
+	if hasCode {
+		passed = code == 0
+	} else {
+		summary := testrunner.ParseOutput(output)
+		if summary.Failed == 0 && len(failing) == 0 {
+			if summary.Passed == 0 {
+				return "", ""
+			}
+			passed = true
+		}
+	}
+
+	if passed {
+		return "passed", "[FIC] Tests passed! Implementation verification gate satisfied."
+	}
+
+	msg := "[FIC] Tests failed"
+	if hasCode {
+		msg += fmt.Sprintf(" (exit code %d)", code)
+	}
+	if len(failing) > 0 {
+		shown := failing
+		if len(shown) > maxFailingTests {
+			shown = shown[:maxFailingTests]
+		}
+		msg += ": " + strings.Join(shown, ", ")
+		if more := len(failing) - len(shown); more > 0 {
+			msg += fmt.Sprintf(" and %d more", more)
+		}
+	}
+	return "failed", msg + ". Review failures before continuing."
+}
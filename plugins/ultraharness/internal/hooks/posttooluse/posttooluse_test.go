@@ -0,0 +1,106 @@
+package posttooluse
+
+import (
+	"strings"
+	"testing"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/context"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/session"
+)
+
+func TestCheckRedundancy(t *testing.T) {
+	read := func(toolInput map[string]interface{}) *protocol.HookInput {
+		return &protocol.HookInput{ToolName: "Read", ToolInput: toolInput}
+	}
+	grep := func(toolInput map[string]interface{}) *protocol.HookInput {
+		return &protocol.HookInput{ToolName: "Grep", ToolInput: toolInput}
+	}
+
+	tests := []struct {
+		name  string
+		calls []*protocol.HookInput
+		want  string // in the notice for the last call, or "" for none
+	}{
+		{
+			name: "same file read again",
+			calls: []*protocol.HookInput{
+				read(map[string]interface{}{"file_path": "/w/a.go"}),
+				read(map[string]interface{}{"file_path": "/w/a.go"}),
+			},
+			want: "read a.go 2 times",
+		},
+		{
+			name: "different ranges of a file",
+			calls: []*protocol.HookInput{
+				read(map[string]interface{}{"file_path": "/w/a.go", "offset": 1.0, "limit": 100.0}),
+				read(map[string]interface{}{"file_path": "/w/a.go", "offset": 101.0, "limit": 100.0}),
+			},
+		},
+		{
+			name: "same range read again",
+			calls: []*protocol.HookInput{
+				read(map[string]interface{}{"file_path": "/w/a.go", "offset": 101.0, "limit": 100.0}),
+				read(map[string]interface{}{"file_path": "/w/a.go", "offset": 101.0, "limit": 100.0}),
+			},
+			want: "read a.go (lines 101-200) 2 times",
+		},
+		{
+			name: "same pattern in different paths",
+			calls: []*protocol.HookInput{
+				grep(map[string]interface{}{"pattern": "TODO", "path": "src"}),
+				grep(map[string]interface{}{"pattern": "TODO", "path": "docs"}),
+				grep(map[string]interface{}{"pattern": "TODO", "path": "src", "glob": "*.go"}),
+			},
+		},
+		{
+			name: "same search again",
+			calls: []*protocol.HookInput{
+				grep(map[string]interface{}{"pattern": "TODO", "path": "src", "glob": "*.go"}),
+				grep(map[string]interface{}{"pattern": "TODO", "path": "src", "glob": "*.go"}),
+			},
+			want: "run Grep 'TODO' in src glob *.go 2 times",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &context.ContextState{}
+			var got string
+			for _, call := range tt.calls {
+				got = checkRedundancy(call, state, 1)
+			}
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("checkRedundancy() = %q, want notice containing %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTrackContextEvent checks that a compaction directive is reported by
+// its event, whatever the template's wording.
+func TestTrackContextEvent(t *testing.T) {
+	tests := []struct {
+		autoCompact bool
+		want        string
+	}{
+		{true, protocol.EventCompactionRequired},
+		{false, protocol.EventCompactionRecommended},
+	}
+	for _, tt := range tests {
+		workDir := t.TempDir()
+		cfg := config.DefaultConfig()
+		cfg.FICConfig.AutoCompactEnabled = tt.autoCompact
+		cfg.FICConfig.CompactionToolThreshold = 2
+		sess := session.NewState("ctx")
+		input := &protocol.HookInput{SessionID: "ctx", ToolName: "Bash", ToolInput: map[string]interface{}{"command": "ls"}}
+
+		var msg, event string
+		for i := 0; i < 2; i++ {
+			msg, event = trackContext(input, workDir, cfg, sess, protocol.Metadata{})
+		}
+		if event != tt.want || msg == "" {
+			t.Errorf("auto compact %v: event = %q (message %q), want %q", tt.autoCompact, event, msg, tt.want)
+		}
+	}
+}
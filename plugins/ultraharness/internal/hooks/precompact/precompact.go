@@ -0,0 +1,350 @@
+// Package precompact implements the PreCompact hook, which preserves
+// essential context before compaction.
+//
+// This hook runs before context compaction to:
+// 1. Extract essential context (decisions, blockers, discoveries)
+// 2. Save to preserved context file
+// 3. Add discoveries to the project knowledge base
+// 4. Inject focus directive for post-compaction
+package precompact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/blockers"
+	"ultraharness/internal/config"
+	"ultraharness/internal/context"
+	"ultraharness/internal/decisions"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/session"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/text"
+)
+
+// PreservedContextFile is the name of the preserved context file.
+const PreservedContextFile = "fic-preserved-context.json"
+
+// CompactionHistoryFile records one entry per compaction.
+const CompactionHistoryFile = "fic-compaction-history.json"
+
+// MaxCompactionHistory is the number of compaction entries kept.
+const MaxCompactionHistory = 50
+
+// Hook is the PreCompact hook.
+var Hook = hookrunner.Hook{Name: "PreCompact", Key: config.HookPreCompact, Handler: run}
+
+func run(c *hookrunner.Context) error {
+	workDir, cfg := c.WorkDir, c.Config
+	sessionID := c.SessionID()
+
+	// Count the compaction for session stats
+	if sess, err := session.Load(sessionID, workDir); err == nil {
+		sess.RecordCompaction()
+		sess.Save(workDir)
+	} else {
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+	}
+
+	// Check if FIC is enabled
+	if !cfg.FICEnabled {
+		return protocol.WriteEmpty()
+	}
+
+	var messages []string
+	quiet := cfg.IsQuiet()
+
+	// Get current phase info (with safe type assertions)
+	phaseInfo := artifacts.GetPhaseInfo(workDir)
+	phase, _ := phaseInfo["phase"].(string)
+	if phase == "" {
+		phase = "NEW_SESSION"
+	}
+	details, _ := phaseInfo["details"].(map[string]interface{})
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+
+	// Load context state for additional info, then reset for next session
+	var tokenEstimate int
+	var utilization float64
+	compactionCount := -1
+	if cfg.FICContextTracking {
+		state, err := context.LoadContextState(sessionID, workDir)
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+		if err == nil && state != nil {
+			tokenEstimate = state.TotalTokenEstimate
+			utilization = state.UtilizationPercent
+			if !quiet {
+				messages = append(messages, fmt.Sprintf("[FIC] Context state: %.0f%% utilization, %d tokens estimated, %d compactions",
+					utilization*100, tokenEstimate, state.CompactionCount))
+			}
+
+			// Reset context state for fresh start after compaction
+			state.Reset(sessionID)
+			compactionCount = state.CompactionCount
+			if err := state.Save(workDir); err == nil && !quiet {
+				messages = append(messages, "[FIC] Context tracking reset for fresh start.")
+			}
+		}
+	}
+
+	// Build focus directive
+	focusDirective := buildFocusDirective(phase, details)
+
+	// Assemble preserved context
+	discoveries, task := essentialDiscoveries(workDir)
+	preservedContext := map[string]interface{}{
+		"timestamp":                 time.Now().Format(time.RFC3339),
+		"session_id":                sessionID,
+		"phase":                     phase,
+		"phase_details":             details,
+		"focus_directive":           focusDirective,
+		"essential_discoveries":     discoveries,
+		"token_estimate_at_compact": tokenEstimate,
+		"utilization_at_compact":    utilization,
+	}
+	// UserPromptSubmit re-injects the focus directive once the context
+	// state shows this compaction
+	if compactionCount >= 0 {
+		preservedContext["compaction_count"] = compactionCount
+	}
+	// Errors still unresolved, so the fix is not forgotten with the output
+	// that reported them
+	if cfg.BlockerTracking {
+		if open := unresolvedBlockers(workDir); len(open) > 0 {
+			preservedContext["unresolved_blockers"] = open
+		}
+	}
+	// Decisions already settled, so they are not reopened after compaction
+	if recorded := recentDecisions(workDir); len(recorded) > 0 {
+		preservedContext["decisions"] = recorded
+	}
+
+	meta := protocol.Metadata{
+		"event":          protocol.EventContextPreserved,
+		"phase":          phase,
+		"utilization":    utilization,
+		"token_estimate": tokenEstimate,
+		"preserved":      false,
+	}
+
+	// Discoveries outlive the research artifact in the knowledge base
+	if cfg.KnowledgeBase {
+		if added := rememberDiscoveries(workDir, task, discoveries); added > 0 {
+			meta["knowledge_added"] = added
+		}
+	}
+
+	// Save preserved context
+	if savePreservedContext(preservedContext, workDir) {
+		meta["preserved"] = true
+		if !quiet {
+			messages = append(messages, "[FIC] Context preserved for next session.")
+		}
+		if cfg.IsVerbose() {
+			messages = append(messages, fmt.Sprintf("[Harness debug] Preserved context for session %s (%s)",
+				sessionID, PreservedContextFile))
+		}
+	}
+
+	if quiet {
+		messages = append(messages,
+			fmt.Sprintf("[FIC] Phase: %s | Focus: %s", phase, focusDirective),
+			"After compaction, continue with the focus directive above.")
+		return protocol.WriteSystemMessage(strings.Join(messages, "\n"), meta)
+	}
+
+	// Build focus directive message
+	messages = append(messages, "")
+	messages = append(messages, strings.Repeat("=", 50))
+	messages = append(messages, "FIC CONTEXT PRESERVATION")
+	messages = append(messages, strings.Repeat("=", 50))
+	messages = append(messages, fmt.Sprintf("Phase: %s", phase))
+	messages = append(messages, fmt.Sprintf("Focus: %s", focusDirective))
+
+	messages = append(messages, strings.Repeat("=", 50))
+	messages = append(messages, "")
+	messages = append(messages, "After compaction, continue with the focus directive above.")
+	messages = append(messages, "Disregard exploration noise. Focus on completing the current phase.")
+
+	return protocol.WriteSystemMessage(strings.Join(messages, "\n"), meta)
+}
+
+func buildFocusDirective(phase string, details map[string]interface{}) string {
+	switch phase {
+	case "IMPLEMENTATION":
+		if stepsInProgress, ok := details["steps_in_progress"].([]string); ok && len(stepsInProgress) > 0 {
+			if len(stepsInProgress) > 3 {
+				stepsInProgress = stepsInProgress[:3]
+			}
+			return fmt.Sprintf("Continue implementation. In progress: %s", strings.Join(stepsInProgress, ", "))
+		}
+		if completed, ok := details["steps_completed"].(int); ok {
+			return fmt.Sprintf("Continue implementation. %d steps completed.", completed)
+		}
+		return "Continue implementation."
+
+	case "IMPLEMENTATION_READY":
+		if goal, ok := details["goal"].(string); ok && goal != "" {
+			return fmt.Sprintf("Plan validated. Begin implementation of: %s", text.Truncate(goal, 60))
+		}
+		return "Plan validated. Begin implementation."
+
+	case "PLANNING":
+		if goal, ok := details["goal"].(string); ok && goal != "" {
+			return fmt.Sprintf("Continue planning. Goal: %s", text.Truncate(goal, 60))
+		}
+		return "Continue planning."
+
+	case "PLANNING_READY":
+		if confidence, ok := details["confidence"].(float64); ok {
+			return fmt.Sprintf("Research complete (confidence: %.0f%%). Create implementation plan.", confidence*100)
+		}
+		return "Research complete. Create implementation plan."
+
+	case "RESEARCH":
+		if feature, ok := details["feature"].(string); ok && feature != "" {
+			return fmt.Sprintf("Continue research on: %s. Build confidence to >= 70%%.", feature)
+		}
+		return "Continue research. Build confidence to >= 70%."
+
+	default:
+		return "Review context and determine next steps."
+	}
+}
+
+// maxPreservedBlockers limits the unresolved blockers carried across a
+// compaction.
+const maxPreservedBlockers = 10
+
+// unresolvedBlockers returns the most recently seen unresolved blockers,
+// rendered as location: message.
+func unresolvedBlockers(workDir string) []string {
+	list, err := blockers.Load(workDir)
+	if err != nil {
+		return nil
+	}
+	open := list.Unresolved()
+	if len(open) > maxPreservedBlockers {
+		open = open[len(open)-maxPreservedBlockers:]
+	}
+	lines := make([]string, len(open))
+	for i, b := range open {
+		lines[i] = b.String()
+	}
+	return lines
+}
+
+// maxPreservedDecisions limits the decisions carried across a compaction;
+// older ones stay in the decision log.
+const maxPreservedDecisions = 10
+
+// recentDecisions returns the most recent decisions, rendered as
+// "ADR-3 Title: decision".
+func recentDecisions(workDir string) []string {
+	log, err := decisions.Load(workDir)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, d := range log.Recent(maxPreservedDecisions) {
+		lines = append(lines, text.Truncate(d.String()+": "+d.Decision, 200))
+	}
+	return lines
+}
+
+// maxPreservedDiscoveries limits the research discoveries carried across a
+// compaction.
+const maxPreservedDiscoveries = 5
+
+// essentialDiscoveries returns the active research's discoveries to
+// preserve, critical ones first, and the task they were researched for.
+func essentialDiscoveries(workDir string) ([]artifacts.Discovery, string) {
+	research, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch)
+	r, ok := research.(*artifacts.Research)
+	if !ok {
+		return []artifacts.Discovery{}, ""
+	}
+	discoveries := make([]artifacts.Discovery, 0, maxPreservedDiscoveries)
+	for _, critical := range []bool{true, false} {
+		for _, d := range r.Discoveries {
+			if d.Critical == critical && d.Summary != "" && len(discoveries) < maxPreservedDiscoveries {
+				discoveries = append(discoveries, d)
+			}
+		}
+	}
+	return discoveries, r.FeatureOrTask
+}
+
+// rememberDiscoveries adds discoveries to the knowledge base and returns
+// how many were new.
+func rememberDiscoveries(workDir, task string, discoveries []artifacts.Discovery) int {
+	if len(discoveries) == 0 {
+		return 0
+	}
+	base, err := knowledge.Load(workDir)
+	if err != nil {
+		return 0
+	}
+	source := "research"
+	if task != "" {
+		source += ": " + task
+	}
+	added := 0
+	for _, d := range discoveries {
+		if base.Add(d.Summary, source, d.Critical, d.Tags...) {
+			added++
+		}
+	}
+	if err := base.Save(workDir); err != nil {
+		return 0
+	}
+	return added
+}
+
+// savePreservedContext stores the preserved context and appends an entry
+// to the compaction history in a single storage update.
+func savePreservedContext(ctx map[string]interface{}, workDir string) bool {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return false
+	}
+
+	data, err := json.MarshalIndent(ctx, "", "  ")
+	if err != nil {
+		return false
+	}
+
+	var history []map[string]interface{}
+	if existing, err := backend.Get(CompactionHistoryFile); err == nil {
+		json.Unmarshal(existing, &history)
+	}
+	history = append(history, map[string]interface{}{
+		"timestamp":      ctx["timestamp"],
+		"session_id":     ctx["session_id"],
+		"phase":          ctx["phase"],
+		"token_estimate": ctx["token_estimate_at_compact"],
+		"utilization":    ctx["utilization_at_compact"],
+	})
+	if len(history) > MaxCompactionHistory {
+		history = history[len(history)-MaxCompactionHistory:]
+	}
+	historyData, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return false
+	}
+
+	err = backend.Update(func(tx storage.Tx) error {
+		tx.Put(PreservedContextFile, data)
+		tx.Put(CompactionHistoryFile, historyData)
+		return nil
+	})
+	return err == nil
+}
@@ -0,0 +1,1076 @@
+// Package pretooluse implements the PreToolUse hook, which enforces FIC
+// verification gates for file modifications, gates dependency additions
+// made by edits or package manager commands, guards database migrations,
+// holds infrastructure applies until a recent plan, and holds Task
+// subagents to the session's subagent budgets. Reads of whole files over
+// the read limit get ranged reads suggested, as do full reads of long files
+// and Task launches while the context is under pressure.
+//
+// Gate behavior by strictness mode:
+// - relaxed: No validation, all operations allowed
+// - standard: Warn on gate violations, allow operation
+// - strict: Block operations that violate gates or budgets; hold new dependencies for approval
+// - review: As strict, and new files are held for human approval
+package pretooluse
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/approvals"
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/budget"
+	"ultraharness/internal/codeowners"
+	"ultraharness/internal/config"
+	"ultraharness/internal/context"
+	"ultraharness/internal/contract"
+	"ultraharness/internal/deps"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/features"
+	"ultraharness/internal/gates"
+	"ultraharness/internal/git"
+	"ultraharness/internal/glob"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/infra"
+	"ultraharness/internal/license"
+	"ultraharness/internal/migrations"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/risk"
+	"ultraharness/internal/session"
+	"ultraharness/internal/watchdog"
+	"ultraharness/internal/writeguard"
+)
+
+// Hook is the PreToolUse hook.
+var Hook = hookrunner.Hook{
+	Name:    "PreToolUse",
+	Key:     config.HookPreToolUse,
+	Handler: run,
+}
+
+func run(c *hookrunner.Context) error {
+	workDir, cfg, input := c.WorkDir, c.Config, c.Input
+
+	// Session state is nil if it cannot be read
+	state, err := session.Load(session.ResolveID(input.SessionID), workDir)
+	errcode.Report(errcode.New(errcode.StateUnreadable, err))
+	toolCalls := 0
+	if state != nil {
+		toolCalls = state.ToolCalls
+	}
+	cfg.Escalate(toolCalls)
+
+	// Skip all validation in relaxed mode
+	if cfg.IsRelaxedMode() {
+		return protocol.WriteEmpty()
+	}
+	input = withNotebookPath(input)
+
+	// A session the watchdog caught looping waits for a revised plan
+	if cfg.IsStrictMode() && state != nil && state.LoopHold != nil {
+		if msg := checkLoopHold(workDir, input, state); msg != "" {
+			return block(workDir, state, "watchdog", msg)
+		}
+	}
+
+	// Approvals are granted by a human, never by the agent. Running the
+	// approve command is denied in every mode, since what it records
+	// still counts once the project turns strict.
+	if input.ToolName == "Bash" && approvals.CommandApproves(input.GetCommand()) {
+		return block(workDir, state, "approve_command", "[Harness] Approvals are granted by a human. "+
+			"Ask the user to run /ultraharness:approve instead of running the approve command.")
+	}
+	if cfg.IsStrictMode() && writesApprovals(workDir, input) {
+		return block(workDir, state, "approvals_file", "[Harness] Approvals are granted by a human. "+
+			"Ask the user to run /ultraharness:approve instead of editing "+approvals.FileName+".")
+	}
+
+	// Infrastructure applies without a recent plan, then package manager
+	// commands that add dependencies
+	toolName := input.ToolName
+	if toolName == "Bash" {
+		if msg := checkInfraApply(workDir, cfg, input.GetCommand()); msg != "" {
+			if cfg.IsStrictMode() {
+				return block(workDir, state, "infra_gate", msg+"\n\n[Harness: Operation blocked. Infrastructure changes need a recent plan in strict mode.]")
+			}
+			return confirm("infra_gate", msg)
+		}
+		return checkDependencyCommand(workDir, cfg, input, state)
+	}
+
+	// Subagent budgets, and expensive tools while the context is full
+	if toolName == "Task" {
+		advice, deny := checkContextPressure(workDir, cfg, input)
+		if deny {
+			return block(workDir, state, "context_pressure", advice)
+		}
+		return checkSubagent(workDir, cfg, input, state, advice)
+	}
+	if toolName == "Read" {
+		if advice, _ := checkContextPressure(workDir, cfg, input); advice != "" {
+			return protocol.WriteAdvice(advice, protocol.Metadata{"event": protocol.EventContextWarning, "check": "context_pressure"})
+		}
+		if advice := checkReadSize(workDir, cfg, input); advice != "" {
+			return protocol.WriteAdvice(advice, protocol.Metadata{"event": protocol.EventWarning, "check": "read_size"})
+		}
+		return protocol.WriteEmpty()
+	}
+
+	// Only check gates for file modifications
+	gatedTool, ok := gatedTools[toolName]
+	if !ok {
+		return protocol.WriteEmpty()
+	}
+
+	// Generated, vendored, and build output files, regardless of FIC phase
+	if msg := checkDoNotEdit(workDir, cfg, input.GetFilePath()); msg != "" {
+		return block(workDir, state, "do_not_edit", msg)
+	}
+
+	// Hold files awaiting human approval in review mode
+	if cfg.IsReviewMode() {
+		if msg := checkApproval(workDir, input.GetFilePath()); msg != "" {
+			return block(workDir, state, "approval", msg)
+		}
+	}
+
+	// Enforce hard session budget limits in strict mode
+	if cfg.IsStrictMode() && state != nil {
+		status := budget.CheckEdit(state, cfg.GetBudget(), input.GetFilePath())
+		if status.IsExceeded() {
+			msg := budget.FormatStatus(status)
+			msg += "\n\n[Harness: Operation blocked. Session budget exhausted in strict mode.]"
+			return block(workDir, state, "budget", msg)
+		}
+	}
+
+	// Non-blocking warnings collected from the checks below
+	var warnings []string
+
+	// Diff budget: project this change onto the session's line tally
+	lines := projectedLinesChanged(input)
+	if state != nil {
+		if msg, exceeded := budget.ProjectDiff(state, cfg.GetBudget(), lines); msg != "" {
+			if exceeded && cfg.IsStrictMode() {
+				msg += "\n\n[Harness: Confirmation required. Diff budget exhausted in strict mode.]"
+				return confirm("diff_budget", msg)
+			}
+			warnings = append(warnings, msg)
+		}
+	}
+
+	// Oversized, binary, or base64 Write content
+	if cfg.WriteGuard && toolName == "Write" {
+		path := approvals.Normalize(workDir, input.GetFilePath())
+		if findings := writeguard.Check(path, input.GetContent(), cfg.GetMaxWriteKB()*1024); len(findings) > 0 {
+			msg := "[Harness] Suspicious write: " + strings.Join(findings, "; ") + "." +
+				"\nGenerated assets and binaries do not belong in the repository. Produce them at build time, " +
+				"or ask the user to add the file themselves."
+			if cfg.IsStrictMode() {
+				msg += "\n\n[Harness: Confirmation required. Write guard in strict mode.]"
+				return confirm("write_guard", msg)
+			}
+			warnings = append(warnings, msg)
+		}
+	}
+
+	// License header on new source files: added for the agent, except in
+	// strict mode where the agent must write it
+	var updatedInput map[string]interface{}
+	if cfg.LicenseHeader != nil && toolName == "Write" {
+		if header := missingLicenseHeader(cfg.LicenseHeader, input, isCodeFile(workDir, cfg)); header != "" {
+			rel := approvals.Normalize(workDir, input.GetFilePath())
+			if cfg.IsStrictMode() {
+				msg := fmt.Sprintf("[Harness] %s is missing the project's license header. Start the file with "+
+					"(after any shebang or build constraint):\n\n%s", rel, header)
+				msg += "\n\n[Harness: Operation blocked. New source files need the license header in strict mode.]"
+				return block(workDir, state, "license_header", msg)
+			}
+			updatedInput = withContent(input.ToolInput, license.Insert(header, input.GetContent()))
+			warnings = append(warnings, fmt.Sprintf("[Harness] Added the project's license header to %s.", rel))
+		}
+	}
+
+	// Dependency manifest changes
+	var depChanges []deps.Change
+	if cfg.DependencyGate && deps.IsManifest(input.GetFilePath()) {
+		depChanges = projectedDependencyChanges(input)
+		deny, warning := checkDependencies(workDir, cfg, input.SessionID, depChanges)
+		if deny != "" {
+			return block(workDir, state, "dependency_gate", deny)
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// Database migrations: applied ones are never edited, and new ones are
+	// logged and should have a rollback
+	var newMigration string
+	if cfg.MigrationGate {
+		deny, warning, added := checkMigration(workDir, cfg, state, input)
+		if deny != "" {
+			return block(workDir, state, "migration_gate", deny)
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		newMigration = added
+	}
+
+	// API contracts: breaking changes to OpenAPI specs and .proto files
+	// are flagged, and every contract delta is logged
+	var contractChanges []contract.Change
+	if cfg.ContractCheck && contract.IsCandidate(input.GetFilePath()) {
+		contractChanges = projectedContractChanges(input)
+		if contract.HasBreaking(contractChanges) {
+			msg := formatContractChanges(approvals.Normalize(workDir, input.GetFilePath()), contractChanges)
+			if cfg.IsStrictMode() {
+				msg += "\n\n[Harness: Confirmation required. Breaking API changes need a human decision in strict mode.]"
+				return confirm("contract_check", msg)
+			}
+			warnings = append(warnings, msg)
+		}
+	}
+
+	// Feature checklist dependency enforcement
+	if cfg.FeatureEnforcement && filepath.Base(input.GetFilePath()) == features.FeaturesFile {
+		if msg := checkFeatureDependencies(input); msg != "" {
+			if cfg.IsStrictMode() {
+				msg += "\n\n[Harness: Confirmation required. Prerequisite features should pass first.]"
+				return confirm("feature_dependencies", msg)
+			}
+			warnings = append(warnings, msg)
+		}
+	}
+
+	// Code owned by other teams: flagged on the session's first edit of each
+	// file, and blocked in strict mode
+	if cfg.CodeOwners != nil {
+		if msg := checkCodeOwners(workDir, cfg, input.GetFilePath()); msg != "" {
+			if cfg.IsStrictMode() {
+				msg += "\n\n[Harness: Operation blocked. Path owned by another team in strict mode.]"
+				return block(workDir, state, "code_owners", msg)
+			}
+			if !modifiedThisSession(state, input.GetFilePath()) {
+				warnings = append(warnings, msg)
+			}
+		}
+	}
+
+	// Files several people change often, on the session's first edit
+	if cfg.ChurnAdvisory && !modifiedThisSession(state, input.GetFilePath()) {
+		if msg := checkChurn(workDir, cfg, input.GetFilePath()); msg != "" {
+			warnings = append(warnings, msg)
+		}
+	}
+
+	// HIGH risk edits need a checkpoint commit to return to in strict mode
+	if cfg.RiskScoring && cfg.IsStrictMode() {
+		if msg := checkRiskCheckpoint(workDir, cfg, state, input.GetFilePath(), lines); msg != "" {
+			return block(workDir, state, "risk_checkpoint", msg)
+		}
+	}
+
+	// Check if FIC is enabled
+	if !cfg.FICEnabled {
+		return allow(workDir, cfg, input, state, lines, depChanges, newMigration, contractChanges, warnings, updatedInput)
+	}
+
+	// Determine which gate to check
+	var gate string
+	if gatedTool == "Edit" {
+		gate = gates.GateAllowEdit
+	} else {
+		gate = gates.GateAllowWrite
+	}
+
+	// Check the gate; review mode gates like strict mode
+	strictness := cfg.Strictness
+	if cfg.IsReviewMode() {
+		strictness = config.StrictnessStrict
+	}
+	result := gates.CheckGate(gate, workDir, strictness)
+
+	// Handle result
+	switch result.Action {
+	case gates.ActionBlock:
+		return block(workDir, state, "fic_gate", gates.FormatGateMessage(workDir, result))
+
+	case gates.ActionWarn:
+		if msg := gates.FormatGateMessage(workDir, result); msg != "" {
+			warnings = append(warnings, msg)
+		}
+		return allow(workDir, cfg, input, state, lines, depChanges, newMigration, contractChanges, warnings, updatedInput)
+
+	default:
+		return allow(workDir, cfg, input, state, lines, depChanges, newMigration, contractChanges, warnings, updatedInput)
+	}
+}
+
+// allow permits the operation, adds its projected size to the session's
+// line tally, and logs any dependency changes, new migration, or API
+// contract changes. In review mode, a Write that creates a new file queues
+// it for human approval first.
+func allow(workDir string, cfg *config.Config, input *protocol.HookInput, state *session.State, lines int, depChanges []deps.Change, newMigration string, contractChanges []contract.Change, warnings []string, updatedInput map[string]interface{}) error {
+	if state != nil && lines > 0 {
+		state.RecordLinesChanged(lines)
+		state.Save(workDir)
+	}
+	logDependencies(workDir, cfg, approvals.Normalize(workDir, input.GetFilePath()), depChanges)
+	if newMigration != "" && cfg.AutoProgressLogging {
+		progress.Append(progress.SourceAuto, "MIGRATION ADDED: "+newMigration, workDir)
+	}
+	if len(contractChanges) > 0 && cfg.AutoProgressLogging {
+		var parts []string
+		for _, c := range contractChanges {
+			parts = append(parts, c.String())
+		}
+		progress.Append(progress.SourceAuto, fmt.Sprintf("CONTRACT (%s): %s", approvals.Normalize(workDir, input.GetFilePath()), strings.Join(parts, "; ")), workDir)
+	}
+	if cfg.IsReviewMode() && gatedTools[input.ToolName] == "Write" {
+		if msg := queueNewFile(workDir, input); msg != "" {
+			warnings = append(warnings, msg)
+		}
+	}
+	if cfg.IsVerbose() {
+		debug := fmt.Sprintf("[Harness debug] Allowed %s of %s (%s mode, ~%d lines",
+			input.ToolName, approvals.Normalize(workDir, input.GetFilePath()), cfg.Strictness, lines)
+		if state != nil {
+			debug += fmt.Sprintf(", %d this session", state.LinesChanged)
+		}
+		warnings = append(warnings, debug+")")
+	}
+	if updatedInput != nil {
+		// Allowing outright would skip the user's usual edit prompt
+		decision := protocol.PermissionAsk
+		if input.AutoApprovesEdits() {
+			decision = protocol.PermissionAllow
+		}
+		return protocol.WriteUpdatedInput(strings.Join(warnings, "\n\n"), decision, updatedInput)
+	}
+	return writeWarnings(warnings)
+}
+
+// checkDoNotEdit returns a denial message if path matches a do_not_edit
+// rule, pointing at where the change belongs instead.
+func checkDoNotEdit(workDir string, cfg *config.Config, path string) string {
+	if path == "" {
+		return ""
+	}
+	rel := approvals.Normalize(workDir, path)
+	if strings.HasPrefix(rel, "../") {
+		return ""
+	}
+	for _, rule := range cfg.GetDoNotEdit() {
+		if !glob.Match(rule.Pattern, rel) {
+			continue
+		}
+		msg := fmt.Sprintf("[Harness] %s must not be edited directly (matches %q).", rel, rule.Pattern)
+		if rule.Source != "" {
+			msg += "\n" + rule.Source
+		}
+		return msg + "\n\n[Harness: Operation blocked. Protected path.]"
+	}
+	return ""
+}
+
+// maxOwnersListed caps the owners named in the churn advisory
+const maxOwnersListed = 3
+
+// modifiedThisSession reports whether the session already edited path.
+func modifiedThisSession(state *session.State, path string) bool {
+	if state == nil {
+		return false
+	}
+	for _, f := range state.FilesModified {
+		if f == path {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCodeOwners returns a message if CODEOWNERS assigns path to owners
+// that include none of the user's identities.
+func checkCodeOwners(workDir string, cfg *config.Config, path string) string {
+	if path == "" {
+		return ""
+	}
+	rel := approvals.Normalize(workDir, path)
+	if strings.HasPrefix(rel, "../") {
+		return ""
+	}
+	file, err := codeowners.Load(workDir)
+	if err != nil || file == nil {
+		return ""
+	}
+	owners := file.Owners(rel)
+	if len(owners) == 0 {
+		return ""
+	}
+	identities := append([]string{git.UserEmail(workDir)}, cfg.CodeOwners.Identities...)
+	if codeowners.IsOwner(owners, identities) {
+		return ""
+	}
+	return fmt.Sprintf("[Harness] %s is owned by %s (%s), and you are not among its owners.\n"+
+		"Confirm the change with the owning team, or leave it for them.",
+		rel, strings.Join(owners, ", "), file.Path)
+}
+
+// checkChurn returns an advisory if path is conflict-prone: committed often
+// by several authors within the churn window. New files are skipped.
+func checkChurn(workDir string, cfg *config.Config, path string) string {
+	if path == "" || !git.IsRepo(workDir) {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+
+	settings := cfg.GetChurn()
+	churn := git.Churn(workDir, time.Now().AddDate(0, 0, -settings.WindowDays), path)
+	if len(churn) == 0 || !churn[0].IsConflictProne(settings.MinCommits, settings.MinAuthors) {
+		return ""
+	}
+
+	msg := fmt.Sprintf("[Harness] %s is a high-churn file: %d commits by %d authors in the last %d days.",
+		approvals.Normalize(workDir, path), churn[0].Commits, len(churn[0].Authors), settings.WindowDays)
+	if owners := git.BlameOwners(workDir, path); len(owners) > 0 {
+		total := 0
+		for _, o := range owners {
+			total += o.Lines
+		}
+		var names []string
+		for i, o := range owners {
+			if i == maxOwnersListed {
+				break
+			}
+			names = append(names, fmt.Sprintf("%s (%d%%)", o.Author, o.Lines*100/total))
+		}
+		msg += " Likely owners from git blame: " + strings.Join(names, ", ") + "."
+	}
+	return msg + "\nCoordinate with the owners and keep the change focused to avoid merge conflicts."
+}
+
+// checkRiskCheckpoint returns a denial message if the edit is HIGH risk
+// and there is no checkpoint to return to: code changes are uncommitted and
+// no HIGH risk edit was allowed since the last commit.
+func checkRiskCheckpoint(workDir string, cfg *config.Config, state *session.State, path string, lines int) string {
+	if path == "" || !git.IsRepo(workDir) {
+		return ""
+	}
+	assessment := risk.Assess(workDir, cfg.GetRisk(), path, git.UncommittedLines(workDir, path)+lines, isCodeFile(workDir, cfg))
+	if assessment.Level != risk.High {
+		return ""
+	}
+
+	head := git.Head(workDir)
+	if state != nil && head != "" && state.RiskCheckpoint == head {
+		return ""
+	}
+	for _, f := range git.ModifiedFiles(workDir) {
+		// Harness state is not part of the checkpoint
+		if !strings.HasPrefix(f, ".claude/") {
+			return "[Harness] " + assessment.Summary() + ".\n" +
+				"Commit a checkpoint of the current changes first, so this edit can be reviewed and reverted on its own." +
+				"\n\n[Harness: Operation blocked. HIGH risk edit without a checkpoint in strict mode.]"
+		}
+	}
+	if state != nil {
+		state.RiskCheckpoint = head
+		state.Save(workDir)
+	}
+	return ""
+}
+
+// checkLoopHold returns a denial message for file tools and Bash while
+// the watchdog holds the session, until a plan artifact is saved. Writing
+// the plan itself stays allowed.
+func checkLoopHold(workDir string, input *protocol.HookInput, state *session.State) string {
+	if watchdog.Release(workDir, state) {
+		state.Save(workDir)
+		return ""
+	}
+	switch input.ToolName {
+	case "Bash":
+	case "Edit", "Write", "MultiEdit", "NotebookEdit":
+		if watchdog.IsPlanArtifact(workDir, input.GetFilePath()) {
+			return ""
+		}
+	default:
+		return ""
+	}
+	return fmt.Sprintf("[Harness] Watchdog: the session is held because %s.\n"+
+		"Save a revised plan in %s/%s/ explaining why the previous attempts failed and what changes, then continue.\n\n"+
+		"[Harness: Operation blocked. Loop detected in strict mode.]",
+		state.LoopHold.Reason, artifacts.ArtifactsDir, artifacts.ArtifactPlan)
+}
+
+// checkApproval returns a denial message if path is a file still awaiting
+// approval in review mode.
+func checkApproval(workDir, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	queue, err := approvals.Load(workDir)
+	if err != nil || !queue.IsPending(workDir, path) {
+		return ""
+	}
+	return fmt.Sprintf("[Harness] Review mode: %s was created by the agent and is awaiting human approval.\n"+
+		"Ask the user to review it and run /ultraharness:approve (or edit .claude/%s) before changing it further.",
+		approvals.Normalize(workDir, path), approvals.FileName)
+}
+
+// queueNewFile adds the file being written to the approval queue if it
+// does not exist yet.
+func queueNewFile(workDir string, input *protocol.HookInput) string {
+	path := input.GetFilePath()
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return ""
+	}
+
+	queue, err := approvals.Load(workDir)
+	if err != nil {
+		return ""
+	}
+	if !queue.Request(workDir, path, input.SessionID) || queue.Save(workDir) != nil {
+		return ""
+	}
+	return fmt.Sprintf("[Harness] Review mode: %s is a new file and has been queued for human approval. "+
+		"Further edits to it are blocked until the user approves it.", approvals.Normalize(workDir, path))
+}
+
+// isCodeFile returns whether a path, absolute or relative to workDir, is a
+// code file under the code_files config.
+func isCodeFile(workDir string, cfg *config.Config) func(string) bool {
+	codeFiles := cfg.GetCodeFiles()
+	return func(path string) bool {
+		return git.IsCodeFile(approvals.Normalize(workDir, path), codeFiles.Extensions, codeFiles.Exclude)
+	}
+}
+
+// missingLicenseHeader returns the header to add when a Write creates a
+// source file without the configured license header.
+func missingLicenseHeader(lh *config.LicenseHeaderConfig, input *protocol.HookInput, isCode func(string) bool) string {
+	path := input.GetFilePath()
+	if strings.TrimSpace(lh.Template) == "" || path == "" || !license.Applies(path, lh.Extensions, isCode) {
+		return ""
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return ""
+	}
+	if license.HasHeader(lh.Template, input.GetContent()) {
+		return ""
+	}
+	return license.Render(lh.Template, path, time.Now().Year())
+}
+
+// withContent copies a Write tool input with its content replaced.
+func withContent(toolInput map[string]interface{}, content string) map[string]interface{} {
+	updated := make(map[string]interface{}, len(toolInput))
+	for k, v := range toolInput {
+		updated[k] = v
+	}
+	updated["content"] = content
+	return updated
+}
+
+// checkSubagent checks a Task call against the subagent budgets, blocking
+// it in strict mode once a limit is reached, and counts it as started.
+// advice about context pressure is added to any budget warning.
+func checkSubagent(workDir string, cfg *config.Config, input *protocol.HookInput, state *session.State, advice string) error {
+	if state == nil {
+		state = session.NewState(session.ResolveID(input.SessionID))
+	}
+	msg, exceeded := budget.ProjectSubagent(state, cfg.GetBudget())
+	if exceeded && cfg.IsStrictMode() {
+		msg += "\n\n[Harness: Operation blocked. Subagent budget exhausted in strict mode.]"
+		return block(workDir, state, "subagent_budget", msg)
+	}
+
+	state.RecordSubagentStart()
+	state.Save(workDir)
+	check := "subagent_budget"
+	switch {
+	case msg == "" && advice == "":
+		return protocol.WriteEmpty()
+	case msg == "":
+		msg, check = advice, "context_pressure"
+	case advice != "":
+		msg += "\n\n" + advice
+	}
+	return protocol.WriteAdvice(msg, protocol.Metadata{"event": protocol.EventWarning, "check": check})
+}
+
+// PressureReadLines is the length above which a full Read of a file gets a
+// ranged read suggested while the context is under pressure
+const PressureReadLines = 500
+
+// checkContextPressure returns advice for a Task launch, or a Read of a
+// whole long file, while the context's estimated utilization is above
+// target_utilization_high, pointing at cheaper ways to get the same
+// information. deny is set for a Task above auto_compact_threshold when
+// fic_config.deny_tasks_when_critical is on: it waits for compaction.
+func checkContextPressure(workDir string, cfg *config.Config, input *protocol.HookInput) (advice string, deny bool) {
+	if !cfg.FICEnabled || !cfg.FICContextTracking || !cfg.IsPressureGatingEnabled() {
+		return "", false
+	}
+	state, err := context.LoadContextState(session.ResolveID(input.SessionID), workDir)
+	if err != nil {
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+		return "", false
+	}
+	if state.UtilizationPercent < cfg.GetTargetUtilizationHigh() {
+		return "", false
+	}
+	utilization := fmt.Sprintf("%.0f%%", state.UtilizationPercent*100)
+
+	if input.ToolName == "Task" {
+		if critical := cfg.GetAutoCompactThreshold(); state.UtilizationPercent >= critical && cfg.ShouldDenyTasksWhenCritical() {
+			return fmt.Sprintf("[FIC] Context is at ~%s, past the %.0f%% compaction threshold. "+
+				"Subagent launches are held until the context is compacted: run /compact first, then launch the task.",
+				utilization, critical*100), true
+		}
+		return fmt.Sprintf("[FIC] Context is at ~%s. A subagent's report adds several thousand tokens; "+
+			"if you know roughly where to look, a Grep with a narrow pattern (a specific symbol, "+
+			"a path glob, files_with_matches mode) or a Read with offset and limit is cheaper.", utilization), false
+	}
+
+	rel, lines, tokens, ok := wholeFileRead(workDir, input, PressureReadLines)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("[FIC] Context is at ~%s, and %s is %d lines (~%dk tokens). "+
+		"Grep it for the symbol or text you need, then Read just that part with offset and limit.",
+		utilization, rel, lines, tokens/1000), false
+}
+
+// checkReadSize returns advice for a Read of a whole file longer than
+// large_read_lines, measured on disk before it enters the context.
+func checkReadSize(workDir string, cfg *config.Config, input *protocol.HookInput) string {
+	limit := cfg.GetLargeReadLines()
+	if limit < 0 {
+		return ""
+	}
+	rel, lines, tokens, ok := wholeFileRead(workDir, input, limit)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("[Harness] %s is %d lines (~%dk tokens), over the %d-line read limit. "+
+		"Read it in ranges with offset and limit, or Grep for what you need first.", rel, lines, tokens/1000, limit)
+}
+
+// wholeFileRead measures the file a Read takes in full: one without offset
+// or limit, of a text file longer than minLines. It returns the file's
+// path relative to workDir, its line count, and the tokens reading it is
+// estimated to add.
+func wholeFileRead(workDir string, input *protocol.HookInput, minLines int) (rel string, lines, tokens int, ok bool) {
+	// A Read with offset or limit already takes only part of the file
+	if _, ok := input.ToolInput["offset"]; ok {
+		return "", 0, 0, false
+	}
+	if _, ok := input.ToolInput["limit"]; ok {
+		return "", 0, 0, false
+	}
+	path := input.GetFilePath()
+	if path == "" {
+		return "", 0, 0, false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() <= int64(minLines) {
+		return "", 0, 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		// Images and other binary files are not read by line
+		return "", 0, 0, false
+	}
+	lines = bytes.Count(data, []byte("\n"))
+	if lines <= minLines {
+		return "", 0, 0, false
+	}
+	return approvals.Normalize(workDir, input.GetFilePath()), lines, context.EstimateReadTokens(string(data)), true
+}
+
+// checkInfraApply returns why an infrastructure apply in command, such as
+// `terraform apply` or `pulumi up`, should wait for a plan: its stack has
+// no successful plan recorded within the configured age.
+func checkInfraApply(workDir string, cfg *config.Config, command string) string {
+	if !cfg.InfraGate {
+		return ""
+	}
+	var applies []infra.Command
+	for _, c := range infra.Parse(command) {
+		if c.Kind == infra.KindApply {
+			applies = append(applies, c)
+		}
+	}
+	if len(applies) == 0 {
+		return ""
+	}
+
+	plans, err := infra.Load(workDir)
+	if err != nil {
+		return fmt.Sprintf("[Harness] Could not read %s to check for a plan: %v", infra.FileName, err)
+	}
+	maxAge := time.Duration(cfg.GetInfraPlanMaxAgeMinutes()) * time.Minute
+	for _, c := range applies {
+		if reason := plans.Check(c, time.Now(), maxAge); reason != "" {
+			return fmt.Sprintf("[Harness] `%s` changes infrastructure, but %s.\n"+
+				"Run `%s` first, review the changes with the user, then retry.", c.Tool+" "+c.Subcommand, reason, infra.PlanCommand(c))
+		}
+	}
+	return ""
+}
+
+// checkMigration checks an Edit or Write of a database migration. Editing
+// a migration that was already applied is denied, since databases that ran
+// it will not see the change. A new migration is returned for the progress
+// log, and a warning names it and any missing rollback, which is also
+// flagged on the session's first edit of an existing migration.
+func checkMigration(workDir string, cfg *config.Config, state *session.State, input *protocol.HookInput) (deny, warning, added string) {
+	path := input.GetFilePath()
+	rel := approvals.Normalize(workDir, path)
+	settings := cfg.GetMigrations()
+	if path == "" || strings.HasPrefix(rel, "../") || !migrations.IsMigration(settings, rel) {
+		return "", "", ""
+	}
+
+	_, err := os.Stat(path)
+	exists := err == nil
+	if exists {
+		if reason := migrations.Applied(workDir, settings, rel); reason != "" {
+			return fmt.Sprintf("[Harness] %s is an applied database migration (%s). Databases that already ran it "+
+				"will not see an edit, so they would drift from new ones. Write a new migration instead.", rel, reason) +
+				"\n\n[Harness: Operation blocked. Applied migrations must not be edited.]", "", ""
+		}
+	} else {
+		added = rel
+		warning = fmt.Sprintf("[Harness] New database migration: %s.", rel)
+	}
+
+	if content, ok := projectedContent(input); ok && (!exists || !modifiedThisSession(state, path)) &&
+		!migrations.HasRollback(workDir, rel, content) {
+		msg := fmt.Sprintf("[Harness] Migration %s has no down/rollback section. Add one, or a matching down migration, "+
+			"so the change can be reverted.", rel)
+		if warning == "" {
+			warning = msg
+		} else {
+			warning += "\n" + strings.TrimPrefix(msg, "[Harness] ")
+		}
+	}
+	return "", warning, added
+}
+
+// checkDependencyCommand gates Bash commands that install new packages,
+// such as `npm install lodash` or `go get example.com/mod`.
+func checkDependencyCommand(workDir string, cfg *config.Config, input *protocol.HookInput, state *session.State) error {
+	if !cfg.DependencyGate {
+		return protocol.WriteEmpty()
+	}
+	changes := deps.FromCommand(input.GetCommand())
+	if len(changes) == 0 {
+		return protocol.WriteEmpty()
+	}
+
+	deny, warning := checkDependencies(workDir, cfg, input.SessionID, changes)
+	if deny != "" {
+		return block(workDir, state, "dependency_gate", deny)
+	}
+	logDependencies(workDir, cfg, "command", changes)
+	if warning == "" {
+		return protocol.WriteEmpty()
+	}
+	return protocol.WriteAdvice(warning, protocol.Metadata{"event": protocol.EventWarning, "check": "dependency_gate"})
+}
+
+// checkDependencies reviews a dependency delta. In strict mode, additions
+// that have not been approved are queued for approval and the operation is
+// denied. Otherwise the delta is returned as a warning.
+func checkDependencies(workDir string, cfg *config.Config, sessionID string, changes []deps.Change) (deny, warning string) {
+	if len(changes) == 0 {
+		return "", ""
+	}
+	if !cfg.IsStrictMode() {
+		return "", "[Harness] Dependency change:\n" + deps.Format(changes, "  ") +
+			"\nMake sure new dependencies are necessary and come from trusted sources."
+	}
+
+	queue, err := approvals.Load(workDir)
+	if err != nil {
+		return fmt.Sprintf("[Harness] Could not read %s to check dependency approvals: %v", approvals.FileName, err), ""
+	}
+
+	var unapproved []deps.Change
+	for _, c := range changes {
+		if c.Kind == deps.Added && !queue.IsDependencyApproved(c.Key()) {
+			queue.RequestDependency(c.Key(), c.String(), sessionID)
+			unapproved = append(unapproved, c)
+		}
+	}
+	if len(unapproved) == 0 {
+		return "", ""
+	}
+	queue.Save(workDir)
+
+	var names []string
+	for _, c := range unapproved {
+		names = append(names, c.Key())
+	}
+	msg := "[Harness] New dependencies require human approval:\n" + deps.Format(unapproved, "  ") +
+		"\nAsk the user to review them and run /ultraharness:approve " + strings.Join(names, " ") + ", then retry." +
+		"\n\n[Harness: Operation blocked. Dependency additions need approval in strict mode.]"
+	return msg, ""
+}
+
+// logDependencies records a dependency delta in the progress log.
+func logDependencies(workDir string, cfg *config.Config, source string, changes []deps.Change) {
+	if len(changes) == 0 || !cfg.AutoProgressLogging {
+		return
+	}
+	var parts []string
+	for _, c := range changes {
+		parts = append(parts, c.String())
+	}
+	progress.Append(progress.SourceAuto, fmt.Sprintf("DEPENDENCIES (%s): %s", source, strings.Join(parts, ", ")), workDir)
+}
+
+// projectedDependencyChanges diffs the dependencies declared in a manifest
+// before and after the Edit or Write.
+func projectedDependencyChanges(input *protocol.HookInput) []deps.Change {
+	after, ok := projectedContent(input)
+	if !ok {
+		return nil
+	}
+	before, _ := os.ReadFile(input.GetFilePath())
+	return deps.Diff(input.GetFilePath(), string(before), after)
+}
+
+// projectedContractChanges diffs an OpenAPI spec or .proto file before and
+// after the Edit or Write.
+func projectedContractChanges(input *protocol.HookInput) []contract.Change {
+	after, ok := projectedContent(input)
+	if !ok {
+		return nil
+	}
+	before, _ := os.ReadFile(input.GetFilePath())
+	return contract.Diff(input.GetFilePath(), string(before), after)
+}
+
+// formatContractChanges warns about the breaking changes to the contract
+// at rel, listing the whole delta.
+func formatContractChanges(rel string, changes []contract.Change) string {
+	return fmt.Sprintf("[Harness] Breaking API contract change in %s:\n%s\n"+
+		"Existing clients may stop working. Prefer an additive change or a new API version, "+
+		"and call out the break in the PR description (see /ultraharness:api-changes).", rel, contract.Format(changes, "  "))
+}
+
+// writeWarnings writes collected warnings as a single non-blocking message
+func writeWarnings(warnings []string) error {
+	if len(warnings) == 0 {
+		return protocol.WriteEmpty()
+	}
+	return protocol.WriteAdvice(strings.Join(warnings, "\n\n"),
+		protocol.Metadata{"event": protocol.EventWarning, "warnings": len(warnings)})
+}
+
+// block denies the operation, naming the check that blocked it in the
+// output metadata, and counts the block in the session state.
+func block(workDir string, state *session.State, check, message string) error {
+	if state != nil {
+		state.RecordGateBlock()
+		state.Save(workDir)
+	}
+	return protocol.WriteDeny(message, protocol.Metadata{"check": check})
+}
+
+// gatedTools maps the file tools to the tool they are gated as: MultiEdit
+// is a series of Edits, and NotebookEdit rewrites the notebook like a
+// Write.
+var gatedTools = map[string]string{
+	"Edit":         "Edit",
+	"Write":        "Write",
+	"MultiEdit":    "Edit",
+	"NotebookEdit": "Write",
+}
+
+// withNotebookPath returns input with a NotebookEdit's notebook_path
+// copied to file_path, so every file tool names its file the same way.
+func withNotebookPath(input *protocol.HookInput) *protocol.HookInput {
+	path, ok := input.ToolInput["notebook_path"].(string)
+	if input.ToolName != "NotebookEdit" || !ok || input.GetFilePath() != "" {
+		return input
+	}
+	notebook := *input
+	notebook.ToolInput = make(map[string]interface{}, len(input.ToolInput)+1)
+	for k, v := range input.ToolInput {
+		notebook.ToolInput[k] = v
+	}
+	notebook.ToolInput["file_path"] = path
+	return &notebook
+}
+
+// writesApprovals reports whether the tool call writes the approval
+// queue: an Edit, Write, MultiEdit, or NotebookEdit of it, or a Bash
+// command that, as far as its text shows, writes it.
+func writesApprovals(workDir string, input *protocol.HookInput) bool {
+	switch input.ToolName {
+	case "Edit", "Write", "MultiEdit", "NotebookEdit":
+		return approvals.IsQueueFile(workDir, input.GetFilePath())
+	case "Bash":
+		return approvals.CommandWrites(workDir, input.GetCommand())
+	}
+	return false
+}
+
+// confirm asks the user whether to let the operation through, for
+// violations that are worth a human decision but not a hard block.
+func confirm(check, message string) error {
+	return protocol.WriteAsk(message, protocol.Metadata{"check": check})
+}
+
+// checkFeatureDependencies compares the projected feature checklist with
+// the current one and reports features this edit marks in_progress before
+// their prerequisites pass. Features already in progress are left alone,
+// so an old violation does not hold up unrelated edits.
+func checkFeatureDependencies(input *protocol.HookInput) string {
+	content, ok := projectedContent(input)
+	if !ok {
+		return ""
+	}
+
+	data, err := features.Parse([]byte(content))
+	if err != nil {
+		return ""
+	}
+
+	var before *features.FeaturesData
+	if current, err := os.ReadFile(input.GetFilePath()); err == nil {
+		before, _ = features.Parse(current)
+	}
+
+	violations := data.NewDependencyViolations(before)
+	if len(violations) == 0 {
+		return ""
+	}
+
+	lines := []string{"[Harness] Feature dependency violation:"}
+	for _, v := range violations {
+		lines = append(lines, fmt.Sprintf("  - %s (%s) is in_progress but depends on: %s",
+			v.Feature.ID, v.Feature.Name, strings.Join(v.Unmet, ", ")))
+	}
+	if next := data.NextActionable(); next != nil {
+		lines = append(lines, fmt.Sprintf("Work on %s (%s) instead - its prerequisites are met.", next.ID, next.Name))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// projectedLinesChanged estimates the lines added plus removed by the
+// file tool. Returns 0 if it cannot be determined.
+func projectedLinesChanged(input *protocol.HookInput) int {
+	switch input.ToolName {
+	case "Write":
+		current, _ := os.ReadFile(input.GetFilePath())
+		return budget.LinesChanged(string(current), input.GetContent())
+	case "Edit":
+		lines := budget.LinesChanged(input.GetOldString(), input.GetNewString())
+		if input.GetReplaceAll() && input.GetOldString() != "" {
+			if current, err := os.ReadFile(input.GetFilePath()); err == nil {
+				if n := strings.Count(string(current), input.GetOldString()); n > 1 {
+					lines *= n
+				}
+			}
+		}
+		return lines
+	case "MultiEdit":
+		lines := 0
+		for _, e := range multiEdits(input) {
+			lines += budget.LinesChanged(e.oldString, e.newString)
+		}
+		return lines
+	case "NotebookEdit":
+		// The cell being replaced is inside the notebook's JSON; count the
+		// new source only
+		source, _ := input.ToolInput["new_source"].(string)
+		return budget.LinesChanged("", source)
+	}
+	return 0
+}
+
+// multiEdit is one replacement of a MultiEdit.
+type multiEdit struct {
+	oldString, newString string
+	replaceAll           bool
+}
+
+// multiEdits returns the replacements of a MultiEdit, in order.
+func multiEdits(input *protocol.HookInput) []multiEdit {
+	raw, _ := input.ToolInput["edits"].([]interface{})
+	var edits []multiEdit
+	for _, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var e multiEdit
+		e.oldString, _ = m["old_string"].(string)
+		e.newString, _ = m["new_string"].(string)
+		e.replaceAll, _ = m["replace_all"].(bool)
+		edits = append(edits, e)
+	}
+	return edits
+}
+
+// projectedContent returns the file content as it would be after the
+// Write, Edit, or MultiEdit is applied. Returns false if it cannot be
+// determined.
+func projectedContent(input *protocol.HookInput) (string, bool) {
+	switch input.ToolName {
+	case "Write":
+		return input.GetContent(), true
+	case "Edit":
+		current, err := os.ReadFile(input.GetFilePath())
+		if err != nil {
+			return "", false
+		}
+		oldString := input.GetOldString()
+		if oldString == "" || !strings.Contains(string(current), oldString) {
+			return "", false
+		}
+		if input.GetReplaceAll() {
+			return strings.ReplaceAll(string(current), oldString, input.GetNewString()), true
+		}
+		return strings.Replace(string(current), oldString, input.GetNewString(), 1), true
+	case "MultiEdit":
+		current, err := os.ReadFile(input.GetFilePath())
+		edits := multiEdits(input)
+		if err != nil || len(edits) == 0 {
+			return "", false
+		}
+		content := string(current)
+		for _, e := range edits {
+			if e.oldString == "" || !strings.Contains(content, e.oldString) {
+				return "", false
+			}
+			if e.replaceAll {
+				content = strings.ReplaceAll(content, e.oldString, e.newString)
+			} else {
+				content = strings.Replace(content, e.oldString, e.newString, 1)
+			}
+		}
+		return content, true
+	}
+	return "", false
+}
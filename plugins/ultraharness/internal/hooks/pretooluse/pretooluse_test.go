@@ -0,0 +1,261 @@
+package pretooluse
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/approvals"
+	"ultraharness/internal/config"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/session"
+)
+
+const openAPISpec = `openapi: 3.0.3
+info:
+  title: Pets
+paths:
+  /pets:
+    get:
+      summary: List pets
+  /owners:
+    get:
+      summary: List owners
+`
+
+// notebook is a one-cell notebook for NotebookEdit.
+const notebook = `{"cells": [{"cell_type": "code", "source": ["print(1)"], "metadata": {}, "outputs": [], "execution_count": null}], "metadata": {}, "nbformat": 4, "nbformat_minor": 5}`
+
+// toolInput returns the input of an Edit, MultiEdit, Write, or
+// NotebookEdit of path that replaces oldString with newString, or writes
+// content.
+func toolInput(tool, path, oldString, newString, content string) map[string]interface{} {
+	switch tool {
+	case "Edit":
+		return map[string]interface{}{"file_path": path, "old_string": oldString, "new_string": newString}
+	case "MultiEdit":
+		return map[string]interface{}{"file_path": path, "edits": []interface{}{
+			map[string]interface{}{"old_string": oldString, "new_string": newString},
+		}}
+	case "NotebookEdit":
+		return map[string]interface{}{"notebook_path": path, "new_source": newString, "edit_mode": "replace"}
+	}
+	return map[string]interface{}{"file_path": path, "content": content}
+}
+
+// runPreToolUse runs the hook for a tool call in a project with config,
+// files, and the session state, if any, and returns the check that denied
+// or asked, or "".
+func runPreToolUse(t *testing.T, cfg map[string]interface{}, files map[string]string, state func(dir string) *session.State, tool string, input func(dir string) map[string]interface{}) string {
+	t.Helper()
+	dir := t.TempDir()
+	claudeDir := filepath.Join(dir, ".claude")
+	if err := os.MkdirAll(claudeDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(claudeDir, config.InitMarkerFileName), nil, 0600)
+	data, _ := json.Marshal(cfg)
+	os.WriteFile(filepath.Join(claudeDir, config.ConfigFileName), data, 0600)
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state != nil {
+		if err := state(dir).Save(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CLAUDE_WORKING_DIRECTORY", dir)
+	for _, name := range config.CIEnvVars {
+		t.Setenv(name, "")
+	}
+
+	stdin, _ := json.Marshal(map[string]interface{}{
+		"session_id":      "gates",
+		"hook_event_name": "PreToolUse",
+		"tool_name":       tool,
+		"tool_input":      input(dir),
+	})
+	var out bytes.Buffer
+	protocol.SetOutput(&out)
+	defer protocol.SetOutput(os.Stdout)
+	if err := hookrunner.Run(Hook, bytes.NewReader(stdin)); err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		HookSpecificOutput struct {
+			PermissionDecision string `json:"permissionDecision"`
+		} `json:"hookSpecificOutput"`
+		Metadata struct {
+			Check string `json:"check"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &result); err != nil {
+		t.Fatalf("invalid output %q: %v", out.String(), err)
+	}
+	switch result.HookSpecificOutput.PermissionDecision {
+	case protocol.PermissionDeny, protocol.PermissionAsk:
+		return result.Metadata.Check
+	}
+	return ""
+}
+
+// TestFileToolGates checks that MultiEdit is gated like Edit and
+// NotebookEdit like Write.
+func TestFileToolGates(t *testing.T) {
+	pending := func(paths ...string) string {
+		var q approvals.Queue
+		for _, path := range paths {
+			q.Files = append(q.Files, approvals.Entry{Path: path, Status: approvals.StatusPending})
+		}
+		data, _ := json.Marshal(q)
+		return string(data)
+	}
+	overBudget := func(string) *session.State {
+		state := session.NewState("gates")
+		state.ToolCalls = 10
+		return state
+	}
+
+	tests := []struct {
+		name  string
+		tools []string
+		cfg   map[string]interface{}
+		files map[string]string
+		state func(dir string) *session.State
+		path  string
+		old   string
+		new   string
+		want  string
+	}{
+		{
+			name:  "fic gate before research",
+			tools: []string{"Edit", "MultiEdit", "Write", "NotebookEdit"},
+			cfg:   map[string]interface{}{"strictness": "strict"},
+			files: map[string]string{"src/app.go": "package app\n", "src/report.ipynb": notebook},
+			path:  "src/app.go",
+			old:   "package app", new: "package app // edited",
+			want: "fic_gate",
+		},
+		{
+			name:  "do not edit",
+			tools: []string{"Edit", "MultiEdit", "Write", "NotebookEdit"},
+			cfg:   map[string]interface{}{"strictness": "standard", "fic_enabled": false},
+			files: map[string]string{"dist/app.js": "var a = 1;\n", "dist/report.ipynb": notebook},
+			path:  "dist/app.js",
+			old:   "var a = 1;", new: "var a = 2;",
+			want: "do_not_edit",
+		},
+		{
+			name:  "pending approval",
+			tools: []string{"Edit", "MultiEdit", "Write", "NotebookEdit"},
+			cfg:   map[string]interface{}{"strictness": "review", "fic_enabled": false},
+			files: map[string]string{
+				"src/new.go":                    "package app\n",
+				"src/report.ipynb":              notebook,
+				".claude/" + approvals.FileName: pending("src/new.go", "src/report.ipynb"),
+			},
+			path: "src/new.go",
+			old:  "package app", new: "package app // edited",
+			want: "approval",
+		},
+		{
+			name:  "session budget",
+			tools: []string{"Edit", "MultiEdit", "Write", "NotebookEdit"},
+			cfg: map[string]interface{}{"strictness": "strict", "fic_enabled": false,
+				"budget": map[string]interface{}{"max_tool_calls": 5}},
+			files: map[string]string{"src/app.go": "package app\n", "src/report.ipynb": notebook},
+			state: overBudget,
+			path:  "src/app.go",
+			old:   "package app", new: "package app // edited",
+			want: "budget",
+		},
+		{
+			name:  "diff budget",
+			tools: []string{"Edit", "MultiEdit", "Write", "NotebookEdit"},
+			cfg: map[string]interface{}{"strictness": "strict", "fic_enabled": false,
+				"budget": map[string]interface{}{"max_lines_changed": 1}},
+			files: map[string]string{"src/app.go": "package app\n", "src/report.ipynb": notebook},
+			path:  "src/app.go",
+			old:   "package app", new: "package app\n\nfunc A() {}\n\nfunc B() {}",
+			want: "diff_budget",
+		},
+		{
+			name:  "applied migration",
+			tools: []string{"Edit", "MultiEdit", "Write"},
+			cfg: map[string]interface{}{"strictness": "standard", "fic_enabled": false, "migration_gate": true,
+				"migrations": map[string]interface{}{"applied_through": "002"}},
+			files: map[string]string{"migrations/001_init.sql": "CREATE TABLE pets (id int);\n"},
+			path:  "migrations/001_init.sql",
+			old:   "id int", new: "id bigint",
+			want: "migration_gate",
+		},
+		{
+			name:  "breaking contract change",
+			tools: []string{"Edit", "MultiEdit", "Write"},
+			cfg:   map[string]interface{}{"strictness": "strict", "fic_enabled": false, "contract_check": true},
+			files: map[string]string{"openapi.yaml": openAPISpec},
+			path:  "openapi.yaml",
+			old:   "  /owners:\n    get:\n      summary: List owners\n", new: "",
+			want: "contract_check",
+		},
+	}
+	// NotebookEdit edits report.ipynb next to path
+	for _, tt := range tests {
+		for _, tool := range tt.tools {
+			t.Run(tt.name+"/"+tool, func(t *testing.T) {
+				got := runPreToolUse(t, tt.cfg, tt.files, tt.state, tool, func(dir string) map[string]interface{} {
+					path := filepath.Join(dir, tt.path)
+					if tool == "NotebookEdit" {
+						path = filepath.Join(dir, filepath.Dir(tt.path), "report.ipynb")
+						return toolInput(tool, path, "", tt.new, "")
+					}
+					content := strings.Replace(tt.files[tt.path], tt.old, tt.new, 1)
+					return toolInput(tool, path, tt.old, tt.new, content)
+				})
+				if got != tt.want {
+					t.Errorf("check = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// TestFilesModifiedBudget checks that at N/N files modified, the N files
+// can still be edited and only a new file is blocked.
+func TestFilesModifiedBudget(t *testing.T) {
+	cfg := map[string]interface{}{"strictness": "strict", "fic_enabled": false,
+		"budget": map[string]interface{}{"max_files_modified": 2}}
+	files := map[string]string{"src/a.go": "package app\n", "src/b.go": "package app\n"}
+	state := func(dir string) *session.State {
+		state := session.NewState("gates")
+		state.RecordToolCall("Edit", filepath.Join(dir, "src/a.go"))
+		state.RecordToolCall("Edit", filepath.Join(dir, "src/b.go"))
+		return state
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"src/a.go", ""},
+		{"src/b.go", ""},
+		{"src/c.go", "budget"},
+	}
+	for _, tt := range tests {
+		got := runPreToolUse(t, cfg, files, state, "Write", func(dir string) map[string]interface{} {
+			return toolInput("Write", filepath.Join(dir, tt.path), "", "", "package app // edited\n")
+		})
+		if got != tt.want {
+			t.Errorf("Write of %s at 2/2 files: check = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
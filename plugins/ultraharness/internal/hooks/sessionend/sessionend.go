@@ -0,0 +1,83 @@
+// Package sessionend implements the SessionEnd hook, which records a
+// summary of the session that just ended.
+//
+// This hook runs when a Claude Code session ends to:
+// 1. Summarize session activity (tool calls, files modified, duration)
+// 2. Estimate session cost from tracked token usage
+// 3. Append the summary to the progress log
+// 4. Push shared state when remote sync is enabled
+// 5. Post progress milestones to Jira when the Jira integration is enabled
+// 6. Record the session in the cross-project stats store when enabled
+// 7. Append the session to the daily log when enabled
+//
+// SessionEnd output is not shown to the agent, so the hook only writes
+// the summary to disk and returns an empty response.
+package sessionend
+
+import (
+	"fmt"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/cost"
+	"ultraharness/internal/dailylog"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/jira"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/remote"
+	"ultraharness/internal/session"
+	"ultraharness/internal/stats"
+)
+
+// Hook is the SessionEnd hook.
+var Hook = hookrunner.Hook{Name: "SessionEnd", Key: config.HookSessionEnd, Handler: run}
+
+func run(c *hookrunner.Context) error {
+	workDir, cfg := c.WorkDir, c.Config
+
+	// Push this session's artifacts and feature updates for teammates;
+	// conflicts are recorded and shown at the next SessionStart
+	if syncer, err := remote.New(workDir, cfg); err == nil && syncer != nil {
+		syncer.Sync()
+	}
+
+	state, err := session.Load(c.SessionID(), workDir)
+	errcode.Report(errcode.New(errcode.StateUnreadable, err))
+	if err != nil || state.ToolCalls == 0 {
+		// Nothing happened in this session worth summarizing
+		return protocol.WriteEmpty()
+	}
+
+	if cfg.AutoProgressLogging {
+		progress.Append(progress.SourceAuto, formatSessionSummary(state, cfg), workDir)
+	}
+
+	// Mirror the milestones logged this session, including the summary
+	// above, to the issues they mention
+	if syncer, err := jira.New(workDir, cfg); err == nil && syncer != nil {
+		syncer.Push(false)
+	}
+
+	if cfg.GlobalStats {
+		if path, err := stats.DefaultPath(); err == nil {
+			stats.Append(path, stats.FromSession(workDir, state, time.Now()))
+		}
+	}
+
+	if cfg.DailyLog {
+		dailylog.Append(workDir, dailylog.FromSession(workDir, state, time.Now()))
+	}
+
+	return protocol.WriteEmpty()
+}
+
+func formatSessionSummary(state *session.State, cfg *config.Config) string {
+	return fmt.Sprintf("SESSION END: %d tool calls, %d files modified, %s, est. cost %s (%s)",
+		state.ToolCalls,
+		len(state.FilesModified),
+		state.Elapsed().Round(time.Minute),
+		cost.Format(cost.ForSession(state, cfg)),
+		cfg.GetCostModel())
+}
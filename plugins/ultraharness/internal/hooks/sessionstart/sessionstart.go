@@ -0,0 +1,1026 @@
+// Package sessionstart implements the SessionStart hook, which provides
+// session context with FIC workflow state.
+//
+// This hook runs at the start of each Claude Code session to:
+// 1. Check if harness is initialized for the current project
+// 2. Load FIC state: phase, confidence, artifacts
+// 3. Show preserved context from prior sessions
+// 4. Execute init.sh if it exists
+// 5. Run baseline tests if configured
+// 6. Display git status and recent commits
+// 7. Read progress file for context
+// 8. Read feature checklist status
+// 9. Embed configured project context files
+// 10. List knowledge base facts relevant to the current task
+// 11. Summarize the directory structure before a plan exists
+// 12. Inject context into the session via systemMessage
+package sessionstart
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/approvals"
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/baseline"
+	"ultraharness/internal/blockers"
+	"ultraharness/internal/checkpoint"
+	"ultraharness/internal/compose"
+	"ultraharness/internal/config"
+	"ultraharness/internal/decisions"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/features"
+	"ultraharness/internal/gates"
+	"ultraharness/internal/git"
+	"ultraharness/internal/guidance"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/initscript"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/primer"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/project"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/remote"
+	"ultraharness/internal/researchqueue"
+	"ultraharness/internal/session"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/testrunner"
+	"ultraharness/internal/text"
+	"ultraharness/internal/todos"
+	"ultraharness/internal/tree"
+)
+
+// PreservedContextFile is the name of the preserved context file.
+const PreservedContextFile = "fic-preserved-context.json"
+
+// Hook is the SessionStart hook. It initializes the harness if needed
+// (zero user input required) and falls back to the defaults if the config
+// cannot be read.
+var Hook = hookrunner.Hook{
+	Name:                 "SessionStart",
+	Key:                  config.HookSessionStart,
+	AutoInit:             autoInitialize,
+	DefaultConfigOnError: true,
+	SkipInput:            true,
+	Handler:              run,
+}
+
+func run(c *hookrunner.Context) error {
+	return writeContextMessage(c.WorkDir, c.Config, c.StorageErr)
+}
+
+func writeInitMessage() error {
+	msg := "[FIC System] This project has not been initialized. " +
+		"Run `/ultraharness:init` to enable the FIC (Flow-Information-Context) system. " +
+		"This provides automatic Research → Plan → Implement workflow with verification gates."
+	return protocol.WriteSystemMessage(msg, protocol.Metadata{"event": protocol.EventNotInitialized})
+}
+
+// Section priorities for the startup budget: lower priorities are trimmed
+// first when the message is over session_start_max_tokens.
+const (
+	priorityCodeDebt     = 20
+	priorityProgress     = 30
+	priorityInitScripts  = 40
+	priorityTree         = 42
+	priorityCommits      = 45
+	priorityContextFiles = 50
+	priorityGitStatus    = 55
+	priorityRemoteSync   = 60
+	priorityKnowledge    = 65
+	priorityFeatures     = 70
+	priorityResearch     = 75
+	priorityTests        = 80
+	priorityAssumptions  = 84
+	priorityApprovals    = 85
+	priorityDecisions    = 86
+	priorityBlockers     = 87
+	priorityResume       = 88
+	priorityCheckpoint   = 89
+	priorityFICState     = 90
+	priorityGuidance     = 95
+)
+
+// staleSessionGap is the idle time after which resuming is treated as
+// stale: assumptions need re-validating and others' changes are listed.
+const staleSessionGap = 72 * time.Hour
+
+// maxResumeFiles limits the files changed by others listed on resumption.
+const maxResumeFiles = 10
+
+// formatEscalation describes the session's starting strictness and when
+// it escalates.
+func formatEscalation(strictness string, e *config.StrictnessEscalation) string {
+	var steps []string
+	if e.StandardAfter > 0 {
+		steps = append(steps, fmt.Sprintf("standard after %d", e.StandardAfter))
+	}
+	if e.StrictAfter > 0 {
+		steps = append(steps, fmt.Sprintf("strict after %d", e.StrictAfter))
+	}
+	return fmt.Sprintf("%s (escalates to %s tool calls)", strictness, strings.Join(steps, " and "))
+}
+
+// storageErr is the error configuring the state storage backend, if any.
+func writeContextMessage(workDir string, cfg *config.Config, storageErr error) error {
+	var sections []compose.Section
+	add := func(name string, priority int, maxShare float64, lines []string) {
+		sections = append(sections, compose.Section{Name: name, Priority: priority, MaxShare: maxShare, Lines: lines})
+	}
+
+	// A new session starts at the bottom of the escalation schedule
+	mode := cfg.Strictness
+	if e := cfg.GetStrictnessEscalation(); e != nil {
+		cfg.Escalate(0)
+		mode = formatEscalation(cfg.Strictness, e)
+	}
+
+	header := []string{
+		"=== FIC SYSTEM SESSION STARTUP ===",
+		fmt.Sprintf("Session started: %s", time.Now().Format(time.RFC3339)),
+		fmt.Sprintf("Working directory: %s", workDir),
+		fmt.Sprintf("Mode: %s", mode),
+	}
+	if storageErr != nil {
+		header = append(header, fmt.Sprintf("WARNING: State storage: %v", storageErr))
+	}
+	info := project.Detect(workDir)
+	if summary := info.Summary(); summary != "" {
+		header = append(header, fmt.Sprintf("Project: %s", summary))
+	}
+	if cfg.InfraGate && info.HasInfrastructure() {
+		header = append(header, fmt.Sprintf("Infrastructure: run terraform plan or pulumi preview and review it before applying; applies need a plan from the last %d minutes",
+			cfg.GetInfraPlanMaxAgeMinutes()))
+	}
+	add("header", compose.Required, 0, header)
+
+	// How long the project sat idle since the last session
+	add("resumption", priorityResume, 0, formatResumption(workDir, time.Now()))
+
+	// HEAD moved back since the last session, leaving artifacts ahead of it
+	if cfg.CheckpointSnapshots {
+		add("checkpoint", priorityCheckpoint, 0, formatCheckpoint(workDir))
+	}
+
+	// Project conventions the agent should know without re-reading them
+	add("project context", priorityContextFiles, 0.4, primer.Format(primer.Load(workDir, cfg.GetContextFiles())))
+
+	// Research whose confidence decayed below the gate goes back to
+	// research before anything reports the phase
+	var decayLines []string
+	if cfg.FICEnabled {
+		decayLines = formatConfidenceDecay(workDir, cfg)
+	}
+	decayed := len(decayLines) > 0
+
+	// Orientation for sessions that will explore before planning
+	if cfg.ProjectTree {
+		switch currentPhase(workDir, decayed) {
+		case "NEW_SESSION", "RESEARCH":
+			add("project tree", priorityTree, 0.15, formatProjectTree(workDir, cfg.GetProjectTreeDepth()))
+		}
+	}
+
+	// Shared team state is pulled before it is summarized below
+	add("team sync", priorityRemoteSync, 0, formatRemoteSync(workDir, cfg))
+
+	// FIC Workflow State (High Priority)
+	if cfg.FICEnabled {
+		add("FIC state", priorityFICState, 0, formatFICState(workDir, decayed))
+		add("confidence decay", priorityFICState, 0, decayLines)
+		add("assumptions", priorityAssumptions, 0.1, formatAssumptions(workDir))
+	}
+
+	// Run init script
+	if cfg.InitScriptExecution {
+		initResults := initscript.RunAll(workDir, initScriptOptions(cfg))
+		if resultStr := initscript.GetResultsString(initResults); resultStr != "" {
+			add("init scripts", priorityInitScripts, 0.15, []string{"--- INIT SCRIPTS ---", resultStr})
+		}
+	}
+
+	// Run baseline tests
+	if cfg.BaselineTestsOnStartup {
+		run := baseline.Run(workDir, cfg, false)
+		testSummary := run.Summary
+		if run.Mode == baseline.ModeUnchanged {
+			add("baseline tests", priorityTests, 0, []string{"--- BASELINE TESTS ---", "Baseline unchanged: " + run.Describe()})
+		} else if testSummary.Result != testrunner.NotRun {
+			lines := []string{"--- BASELINE TESTS ---", "Scope: " + run.Describe()}
+			summaryStr := testrunner.GetSummaryString(testSummary)
+			if testSummary.Result == testrunner.Passed {
+				lines = append(lines, fmt.Sprintf("Baseline tests PASSED: %s", summaryStr))
+			} else if testSummary.Result == testrunner.Failed {
+				lines = append(lines, fmt.Sprintf("WARNING: Baseline tests FAILING: %s", summaryStr))
+				lines = append(lines, "Review failures before making changes.")
+			} else if testSummary.TimedOut {
+				reason, _, _ := strings.Cut(testSummary.RawOutput, "\n")
+				lines = append(lines, "Baseline test error: "+reason)
+			} else {
+				lines = append(lines, fmt.Sprintf("Baseline test error: %s", testSummary.RawOutput[:min(200, len(testSummary.RawOutput))]))
+			}
+			add("baseline tests", priorityTests, 0, lines)
+		}
+	}
+
+	// Git status and log
+	if git.IsRepo(workDir) {
+		status := git.Status(workDir)
+		if status == "" {
+			status = "(clean)"
+		}
+		add("git status", priorityGitStatus, 0.15, []string{"--- GIT STATUS ---", status})
+
+		log := git.Log(workDir, 10)
+		if log == "" {
+			log = "(no commits)"
+		}
+		add("recent commits", priorityCommits, 0.1, []string{"--- RECENT COMMITS ---", log})
+	}
+
+	// Progress file, most recent entries first to survive trimming
+	progressContent, err := progress.Read(workDir)
+	if err == nil && progressContent != "" {
+		lines := strings.Split(progressContent, "\n")
+		if len(lines) > 50 {
+			lines = append([]string{"[...truncated...]"}, lines[len(lines)-50:]...)
+		}
+		sections = append(sections, compose.Section{
+			Name: "progress log", Priority: priorityProgress, MaxShare: 0.25, KeepTail: true,
+			Lines: append([]string{"--- PROGRESS LOG ---"}, lines...),
+		})
+	}
+
+	// Features checklist
+	if features.Exists(workDir) {
+		add("feature checklist", priorityFeatures, 0, formatFeatures(workDir))
+	}
+
+	// Research questions deferred during implementation
+	if cfg.FICEnabled {
+		add("deferred research", priorityResearch, 0, formatResearchQueue(workDir))
+	}
+
+	// Past discoveries relevant to the current task
+	if cfg.KnowledgeBase {
+		add("knowledge", priorityKnowledge, 0.15, formatKnowledge(workDir, cfg.GetKnowledgeTopK()))
+	}
+
+	// Files and dependencies awaiting human approval
+	if cfg.IsStrictMode() {
+		add("pending approvals", priorityApprovals, 0, formatPendingApprovals(workDir))
+	}
+
+	// Decisions recorded in earlier sessions or before a compaction
+	add("decisions", priorityDecisions, 0.1, formatDecisions(workDir))
+
+	// Errors a past session left unresolved
+	if cfg.BlockerTracking {
+		add("blockers", priorityBlockers, 0.1, formatBlockers(workDir))
+	}
+
+	// Untracked code debt
+	if cfg.TodoScanOnStartup && git.IsRepo(workDir) {
+		add("code debt", priorityCodeDebt, 0, formatCodeDebt(workDir, cfg.GetCodeFiles()))
+	}
+
+	add("end", compose.Required, 0, []string{"=== END SESSION CONTEXT ==="})
+
+	// Automation features
+	var autoFeatures []string
+	if cfg.AutoProgressLogging {
+		autoFeatures = append(autoFeatures, "auto-logging")
+	}
+	if cfg.AutoCheckpointSuggestions {
+		autoFeatures = append(autoFeatures, "checkpoint suggestions")
+	}
+	if cfg.FeatureEnforcement {
+		autoFeatures = append(autoFeatures, "feature enforcement")
+	}
+	if cfg.FICEnabled {
+		autoFeatures = append(autoFeatures, "FIC context tracking")
+	}
+	if len(autoFeatures) > 0 && !cfg.IsQuiet() {
+		add("automation", compose.Required, 0, []string{fmt.Sprintf("Automation enabled: %s", strings.Join(autoFeatures, ", "))})
+	}
+
+	// Phase-specific guidance
+	phase := currentPhase(workDir, decayed)
+	add("phase guidance", compose.Required, 0, []string{
+		templates.Render(workDir, "phase_guidance", templates.Phase{Phase: phase}),
+	})
+	if text := guidance.For(workDir, cfg, phase); text != "" {
+		add("project guidance", priorityGuidance, 0, append([]string{"Project guidance for " + phase + ":"},
+			strings.Split(text, "\n")...))
+	}
+
+	var diagnostics string
+	if cfg.IsVerbose() {
+		diagnostics = formatDiagnostics(sections, cfg.GetSessionStartMaxTokens())
+	}
+	msg := compose.Compose(sections, cfg.GetSessionStartMaxTokens())
+	if diagnostics != "" {
+		msg += "\n\n" + diagnostics
+	}
+	return protocol.WriteSystemMessage(msg, protocol.Metadata{
+		"event":          protocol.EventSessionContext,
+		"phase":          phase,
+		"strictness":     cfg.Strictness,
+		"token_estimate": compose.EstimateTokens(msg),
+	})
+}
+
+// formatDiagnostics lists the estimated size of each section before
+// budgeting, so users can see what is using up the startup message.
+func formatDiagnostics(sections []compose.Section, maxTokens int) string {
+	var sizes []string
+	for _, s := range sections {
+		if len(s.Lines) > 0 {
+			sizes = append(sizes, fmt.Sprintf("%s ~%d", s.Name, compose.EstimateTokens(strings.Join(s.Lines, "\n"))))
+		}
+	}
+	return fmt.Sprintf("[Harness debug] Section tokens before budgeting (max %d): %s", maxTokens, strings.Join(sizes, ", "))
+}
+
+func formatFeatures(workDir string) []string {
+	summary, err := features.GetSummary(workDir)
+	if err != nil {
+		return nil
+	}
+
+	messages := []string{"--- FEATURE CHECKLIST STATUS ---"}
+	messages = append(messages, fmt.Sprintf("Total: %d | Passing: %d | Failing: %d | In Progress: %d",
+		summary.Total, summary.Passing, summary.Failing, summary.InProgress))
+
+	if len(summary.NextItems) > 0 {
+		messages = append(messages, "")
+		messages = append(messages, "Next priority items:")
+		for _, item := range summary.NextItems {
+			statusIcon := "[TODO]"
+			if item.Status == "in_progress" {
+				statusIcon = "[WIP]"
+			}
+			messages = append(messages, fmt.Sprintf("  %s %s. %s: %s", statusIcon, item.ID, item.Name, text.Truncate(item.Description, 60)))
+		}
+	}
+	if summary.Blocked > 0 {
+		messages = append(messages, fmt.Sprintf("Blocked by prerequisites: %d", summary.Blocked))
+	}
+	if next := summary.NextActionable; next != nil {
+		messages = append(messages, "")
+		messages = append(messages, fmt.Sprintf("NEXT TASK: Work on feature %s (%s) next - all its prerequisites are passing.", next.ID, next.Name))
+	}
+	return messages
+}
+
+func initScriptOptions(cfg *config.Config) initscript.Options {
+	initCfg := cfg.GetInitScriptConfig()
+
+	opts := initscript.Options{
+		Timeout:        time.Duration(initCfg.TimeoutSeconds) * time.Second,
+		ScriptTimeouts: make(map[string]time.Duration),
+		CacheMaxAge:    time.Duration(initCfg.CacheMaxAgeHours) * time.Hour,
+		Force:          initCfg.Force,
+	}
+	for name, seconds := range initCfg.ScriptTimeouts {
+		opts.ScriptTimeouts[name] = time.Duration(seconds) * time.Second
+	}
+	if len(initCfg.EnvAllowlist) > 0 {
+		opts.EnvAllowlist = initCfg.EnvAllowlist
+	}
+	return opts
+}
+
+func formatRemoteSync(workDir string, cfg *config.Config) []string {
+	syncer, err := remote.New(workDir, cfg)
+	if syncer == nil && err == nil {
+		return nil
+	}
+
+	messages := []string{"--- TEAM SYNC ---"}
+	if err != nil {
+		messages = append(messages, fmt.Sprintf("WARNING: Remote sync unavailable: %v", err))
+	} else if result, err := syncer.Sync(); err != nil {
+		messages = append(messages, fmt.Sprintf("WARNING: Remote sync failed: %v", err))
+	} else {
+		messages = append(messages, remote.GetResultString(result))
+	}
+
+	if conflicts := remote.TakeConflicts(workDir); len(conflicts) > 0 {
+		messages = append(messages, "CONFLICTS (resolved last-writer-wins; review the kept versions):")
+		for _, c := range conflicts {
+			messages = append(messages, "  - "+c.String())
+		}
+	}
+	messages = append(messages, "")
+	return messages
+}
+
+// maxResearchListed caps the deferred research items listed at startup
+const maxResearchListed = 5
+
+// formatResearchQueue lists research questions deferred during
+// implementation. Outside implementation the phase boundary has been
+// reached, so they are due.
+func formatResearchQueue(workDir string) []string {
+	queue, err := researchqueue.Load(workDir)
+	if err != nil {
+		return []string{fmt.Sprintf("WARNING: Could not read %s: %v", researchqueue.FileName, err), ""}
+	}
+	pending := queue.Pending()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	messages := []string{"--- DEFERRED RESEARCH ---"}
+	for i, item := range pending {
+		if i == maxResearchListed {
+			messages = append(messages, fmt.Sprintf("  ... and %d more", len(pending)-i))
+			break
+		}
+		prompt := strings.Join(strings.Fields(item.Prompt), " ")
+		messages = append(messages, fmt.Sprintf("  #%d %s", item.ID, text.Truncate(prompt, 100)))
+	}
+	if artifacts.GetCurrentPhase(workDir) == "IMPLEMENTATION" {
+		messages = append(messages, "Handle these at the next phase boundary, once the current implementation is done.")
+	} else {
+		messages = append(messages, "Phase boundary reached: delegate these to research subagents, then mark them done with /ultraharness:research-queue.")
+	}
+	messages = append(messages, "")
+	return messages
+}
+
+// maxTreeLines caps the directories listed in the project tree
+const maxTreeLines = 40
+
+// formatProjectTree summarizes the directory structure to depth levels.
+func formatProjectTree(workDir string, depth int) []string {
+	root, err := tree.Summarize(workDir)
+	if err != nil || root.Files == 0 {
+		return nil
+	}
+	messages := []string{"--- PROJECT TREE ---"}
+	messages = append(messages, root.Format(depth, maxTreeLines)...)
+	return append(messages, "")
+}
+
+// currentTask returns the title of the task being worked on: the latest
+// plan's goal, or the latest research's subject.
+func currentTask(workDir string) string {
+	if plan, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan); plan != nil {
+		if p, ok := plan.(*artifacts.Plan); ok && p.Goal != "" {
+			return p.Goal
+		}
+	}
+	if research, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch); research != nil {
+		if r, ok := research.(*artifacts.Research); ok {
+			return r.FeatureOrTask
+		}
+	}
+	return ""
+}
+
+// formatKnowledge lists the topK knowledge base facts most relevant to
+// the current task: those sharing its tags first, then the best keyword
+// matches. Without a task there is nothing to rank against.
+func formatKnowledge(workDir string, topK int) []string {
+	task := currentTask(workDir)
+	if task == "" {
+		return nil
+	}
+	base, err := knowledge.Load(workDir)
+	if err != nil {
+		return []string{fmt.Sprintf("WARNING: Could not read %s: %v", knowledge.FileName, err), ""}
+	}
+	facts := base.Relevant(task, artifacts.CurrentTags(workDir), topK)
+	if len(facts) == 0 {
+		return nil
+	}
+
+	messages := []string{"--- KNOWLEDGE ---"}
+	for _, f := range facts {
+		marker := ""
+		if f.Critical {
+			marker = "[CRITICAL] "
+		}
+		messages = append(messages, fmt.Sprintf("  %s%s (%s)", marker, f.Text, f.Source))
+	}
+	messages = append(messages, "Search all past discoveries with /ultraharness:knowledge.", "")
+	return messages
+}
+
+// maxStartupBlockers limits the unresolved blockers listed at startup.
+const maxStartupBlockers = 10
+
+// formatBlockers lists the unresolved blockers, most recently seen first.
+func formatBlockers(workDir string) []string {
+	list, err := blockers.Load(workDir)
+	if err != nil {
+		return []string{fmt.Sprintf("WARNING: Could not read %s: %v", blockers.FileName, err), ""}
+	}
+	open := list.Unresolved()
+	if len(open) == 0 {
+		return nil
+	}
+
+	messages := []string{fmt.Sprintf("--- UNRESOLVED BLOCKERS (%d) ---", len(open))}
+	for i := len(open) - 1; i >= 0 && len(messages) <= maxStartupBlockers; i-- {
+		b := open[i]
+		messages = append(messages, fmt.Sprintf("  %s (seen %dx, first %s, from `%s`)",
+			b, b.Count, b.FirstSeen.Format("2006-01-02 15:04"), b.Command))
+	}
+	if more := len(open) - maxStartupBlockers; more > 0 {
+		messages = append(messages, fmt.Sprintf("  ... and %d more", more))
+	}
+	messages = append(messages, "Rerun the reporting command once fixed; blockers it no longer reports are resolved.", "")
+	return messages
+}
+
+// formatAssumptions flags the latest research's assumptions whose files
+// changed or were removed since they were pinned, so stale research does
+// not silently drive the plan or implementation.
+func formatAssumptions(workDir string) []string {
+	a, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch)
+	research, ok := a.(*artifacts.Research)
+	if !ok {
+		return nil
+	}
+	var flagged []string
+	for _, assumption := range research.Assumptions {
+		switch artifacts.CheckAssumption(workDir, assumption) {
+		case artifacts.AssumptionChanged:
+			flagged = append(flagged, fmt.Sprintf("  - %s (%s changed)", text.Truncate(assumption.Statement, 150), assumption.Path()))
+		case artifacts.AssumptionMissing:
+			flagged = append(flagged, fmt.Sprintf("  - %s (%s was removed)", text.Truncate(assumption.Statement, 150), assumption.Path()))
+		}
+	}
+	if len(flagged) == 0 {
+		return nil
+	}
+
+	messages := []string{fmt.Sprintf("--- POSSIBLY INVALIDATED ASSUMPTIONS (%d) ---", len(flagged))}
+	messages = append(messages, "The research for \""+text.Truncate(research.FeatureOrTask, 100)+"\" assumed:")
+	messages = append(messages, flagged...)
+	messages = append(messages, "Re-check these before relying on the research; confirm the ones that still hold with /ultraharness:assumptions.", "")
+	return messages
+}
+
+// maxStartupDecisions limits the decisions listed at startup.
+const maxStartupDecisions = 10
+
+// formatDecisions lists the most recent decisions, newest first.
+func formatDecisions(workDir string) []string {
+	log, err := decisions.Load(workDir)
+	if err != nil {
+		return []string{fmt.Sprintf("WARNING: Could not read %s: %v", decisions.FileName, err), ""}
+	}
+	if len(log.Decisions) == 0 {
+		return nil
+	}
+
+	messages := []string{fmt.Sprintf("--- DECISIONS (%d) ---", len(log.Decisions))}
+	recent := log.Recent(maxStartupDecisions)
+	for i := len(recent) - 1; i >= 0; i-- {
+		d := recent[i]
+		messages = append(messages, fmt.Sprintf("  %s: %s", d, text.Truncate(d.Decision, 150)))
+	}
+	if more := len(log.Decisions) - len(recent); more > 0 {
+		messages = append(messages, fmt.Sprintf("  ... and %d earlier", more))
+	}
+	messages = append(messages, "Build on these rather than reopening them; see the full records with /ultraharness:decision.", "")
+	return messages
+}
+
+func formatPendingApprovals(workDir string) []string {
+	queue, err := approvals.Load(workDir)
+	if err != nil {
+		return []string{fmt.Sprintf("WARNING: Could not read %s: %v", approvals.FileName, err), ""}
+	}
+	pending := queue.PendingPaths()
+	dependencies := queue.PendingDependencies()
+	if len(pending) == 0 && len(dependencies) == 0 {
+		return nil
+	}
+
+	messages := []string{"--- PENDING APPROVALS ---"}
+	for _, path := range pending {
+		messages = append(messages, "  "+path)
+	}
+	if len(pending) > 0 {
+		messages = append(messages, "These files are read-only until the user approves them with /ultraharness:approve.")
+	}
+	for _, e := range dependencies {
+		messages = append(messages, fmt.Sprintf("  %s: %s", e.Name, e.Change))
+	}
+	if len(dependencies) > 0 {
+		messages = append(messages, "These dependencies cannot be added until the user approves them with /ultraharness:approve.")
+	}
+	messages = append(messages, "")
+	return messages
+}
+
+func formatCodeDebt(workDir string, codeFiles config.CodeFilesConfig) []string {
+	data, _ := features.Load(workDir)
+	items := todos.Scan(workDir, func(path string) bool {
+		return git.IsCodeFile(path, codeFiles.Extensions, codeFiles.Exclude)
+	})
+	untracked := todos.Untracked(items, data)
+	if len(untracked) == 0 {
+		return nil
+	}
+
+	var messages []string
+	messages = append(messages, "--- UNTRACKED CODE DEBT ---")
+	for i, item := range untracked {
+		if i >= 5 {
+			messages = append(messages, fmt.Sprintf("  ... and %d more", len(untracked)-5))
+			break
+		}
+		messages = append(messages, fmt.Sprintf("  %s %s: %s", item.Tag, item.Location(), item.Text))
+	}
+	messages = append(messages, "Offer to track these as pending features with /ultraharness:todos.")
+	messages = append(messages, "")
+	return messages
+}
+
+// formatCheckpoint records HEAD and returns the advisory for a move back to
+// an earlier commit, if it made one.
+func formatCheckpoint(workDir string) []string {
+	move, err := checkpoint.Observe(workDir)
+	if err != nil {
+		errcode.Report(errcode.New(errcode.StateNotSaved, err))
+		return nil
+	}
+	if move == nil {
+		return nil
+	}
+	return []string{"--- CHECKPOINT ---", move.Advisory(), ""}
+}
+
+// formatResumption describes the gap since the last recorded session
+// activity. After a long gap it lists files changed by others since and
+// the FIC artifacts that predate it.
+func formatResumption(workDir string, now time.Time) []string {
+	last, err := session.LoadLatest(workDir)
+	if err != nil || last == nil || last.LastActivity.IsZero() {
+		return nil
+	}
+	gap := now.Sub(last.LastActivity)
+	if gap < time.Minute {
+		return nil
+	}
+
+	if gap < staleSessionGap {
+		return []string{"--- RESUMING ---", fmt.Sprintf("Resuming after %s (last session: %d tool calls, %d files modified).",
+			formatGap(gap), last.ToolCalls, len(last.FilesModified))}
+	}
+
+	lines := []string{
+		"--- RESUMING STALE SESSION ---",
+		fmt.Sprintf("Resuming after %s. Re-validate assumptions from the last session before relying on them.", formatGap(gap)),
+	}
+
+	if git.IsRepo(workDir) {
+		me := git.UserEmail(workDir)
+		var files, authors []string
+		seenFile, seenAuthor := map[string]bool{}, map[string]bool{}
+		commits := 0
+		for _, c := range git.CommitsSince(workDir, last.LastActivity) {
+			if me != "" && c.Email == me {
+				continue
+			}
+			commits++
+			if !seenAuthor[c.Author] {
+				seenAuthor[c.Author] = true
+				authors = append(authors, c.Author)
+			}
+			for _, f := range c.Files {
+				if !seenFile[f] {
+					seenFile[f] = true
+					files = append(files, f)
+				}
+			}
+		}
+		if commits > 0 {
+			lines = append(lines, fmt.Sprintf("Changed by others since then (%d commits by %s):", commits, strings.Join(authors, ", ")))
+			for i, f := range files {
+				if i >= maxResumeFiles {
+					lines = append(lines, fmt.Sprintf("  ... and %d more", len(files)-i))
+					break
+				}
+				lines = append(lines, "  "+f)
+			}
+		}
+	}
+
+	var outdated []string
+	if research, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch); research != nil {
+		if r, ok := research.(*artifacts.Research); ok {
+			outdated = append(outdated, describeArtifact("Research", r.FeatureOrTask, r.UpdatedAt, now))
+		}
+	}
+	if plan, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan); plan != nil {
+		if p, ok := plan.(*artifacts.Plan); ok {
+			outdated = append(outdated, describeArtifact("Plan", p.Goal, p.UpdatedAt, now))
+		}
+	}
+	if len(outdated) > 0 {
+		lines = append(lines, "May be outdated (from before the break):")
+		for _, a := range outdated {
+			lines = append(lines, "  - "+a)
+		}
+	}
+	return lines
+}
+
+// describeArtifact names an artifact and, when its timestamp parses, its age.
+func describeArtifact(kind, name, updatedAt string, now time.Time) string {
+	desc := fmt.Sprintf("%s: %s", kind, text.Truncate(name, 60))
+	// Artifacts written by the Python hooks use isoformat without a zone
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999"} {
+		if t, err := time.Parse(layout, updatedAt); err == nil {
+			return desc + fmt.Sprintf(" (updated %s ago)", formatGap(now.Sub(t)))
+		}
+	}
+	return desc
+}
+
+// formatGap renders an idle time coarsely, e.g. "20 minutes" or "2 weeks".
+func formatGap(d time.Duration) string {
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+	switch {
+	case d < time.Hour:
+		return unit(int(d/time.Minute), "minute")
+	case d < 48*time.Hour:
+		return unit(int(d/time.Hour), "hour")
+	case d < 14*24*time.Hour:
+		return unit(int(d/(24*time.Hour)), "day")
+	default:
+		return unit(int(d/(7*24*time.Hour)), "week")
+	}
+}
+
+// currentPhase returns the FIC phase, or RESEARCH for research that would
+// be ready for planning but whose confidence decayed.
+func currentPhase(workDir string, decayed bool) string {
+	phase := artifacts.GetCurrentPhase(workDir)
+	if decayed && phase == "PLANNING_READY" {
+		return "RESEARCH"
+	}
+	return phase
+}
+
+// formatConfidenceDecay reports research whose confidence decayed below
+// what planning needs since it was written. If planning has not been
+// validated yet, the research gate is closed again, moving the workflow
+// back to research.
+func formatConfidenceDecay(workDir string, cfg *config.Config) []string {
+	rates := cfg.GetConfidenceDecay()
+	if rates == nil {
+		return nil
+	}
+	a, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch)
+	research, ok := a.(*artifacts.Research)
+	if !ok {
+		return nil
+	}
+	decay := research.Decay(workDir, rates.PerWeek, rates.PerCommit, time.Now())
+	if !decay.Decayed(research) {
+		return nil
+	}
+
+	messages := []string{
+		"--- RESEARCH CONFIDENCE DECAYED ---",
+		fmt.Sprintf("The research for \"%s\" was %.0f%% confident on %s and is down to %s, below the %.0f%% planning needs.",
+			text.Truncate(research.FeatureOrTask, 100), research.ConfidenceScore*100, decay.Written.Format("2006-01-02"), decay, artifacts.CompleteConfidence*100),
+	}
+	reopened, err := gates.ReopenResearch(workDir)
+	switch {
+	case err != nil:
+		messages = append(messages, fmt.Sprintf("WARNING: Could not move back to research: %v", err))
+	case reopened:
+		messages = append(messages, "Phase moved back to RESEARCH. Re-check the research against the current code, update the artifact's confidence and updated_at, then run /fic-research-done.")
+	default:
+		messages = append(messages, "Re-check the research against the current code before relying on it, and update the artifact's confidence and updated_at.")
+	}
+	return append(messages, "")
+}
+
+func formatFICState(workDir string, decayed bool) []string {
+	var messages []string
+
+	messages = append(messages, "--- FIC WORKFLOW STATE ---")
+
+	phase := currentPhase(workDir, decayed)
+	messages = append(messages, fmt.Sprintf("Phase: %s", phase))
+
+	// Show preserved context from prior session
+	preserved := loadPreservedContext(workDir)
+	if preserved != nil {
+		messages = append(messages, "")
+		messages = append(messages, "Prior Session Context:")
+		if discoveries, ok := preserved["essential_discoveries"].([]interface{}); ok {
+			for i, d := range discoveries {
+				if i >= 5 {
+					break
+				}
+				if disc, ok := d.(map[string]interface{}); ok {
+					if summary, ok := disc["summary"].(string); ok {
+						messages = append(messages, fmt.Sprintf("  - %s", summary))
+					}
+				}
+			}
+		}
+		if focus, ok := preserved["focus_directive"].(string); ok && focus != "" {
+			messages = append(messages, fmt.Sprintf("Focus: %s", focus))
+		}
+		if from, ok := preserved["handoff_from"].(string); ok && from != "" {
+			messages = append(messages, fmt.Sprintf("Handoff from: %s", from))
+			if hot, ok := preserved["hot_files"].([]interface{}); ok && len(hot) > 0 {
+				var paths []string
+				for i, h := range hot {
+					if i >= 5 {
+						break
+					}
+					if p, ok := h.(string); ok {
+						paths = append(paths, p)
+					}
+				}
+				messages = append(messages, fmt.Sprintf("Hot files: %s", strings.Join(paths, ", ")))
+			}
+		}
+	}
+
+	// Show research state
+	if research, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch); research != nil {
+		if r, ok := research.(*artifacts.Research); ok {
+			messages = append(messages, "")
+			messages = append(messages, fmt.Sprintf("Active Research: %s", r.FeatureOrTask))
+			if r.InitialContext != "" {
+				messages = append(messages, "  Initial Context: "+text.Truncate(strings.Join(strings.Fields(r.InitialContext), " "), 300))
+			}
+			messages = append(messages, fmt.Sprintf("  Confidence: %.0f%%", r.ConfidenceScore*100))
+			messages = append(messages, fmt.Sprintf("  Discoveries: %d", len(r.Discoveries)))
+
+			blockingQ := 0
+			for _, q := range r.OpenQuestions {
+				if q.Blocking {
+					blockingQ++
+				}
+			}
+			messages = append(messages, fmt.Sprintf("  Open Questions: %d (%d blocking)", len(r.OpenQuestions), blockingQ))
+		}
+	}
+
+	// Show plan state
+	if plan, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan); plan != nil {
+		if p, ok := plan.(*artifacts.Plan); ok {
+			messages = append(messages, "")
+			messages = append(messages, fmt.Sprintf("Active Plan: %s", text.Truncate(p.Goal, 60)))
+			messages = append(messages, fmt.Sprintf("  Steps: %d", len(p.Steps)))
+			if p.ValidationResult != nil {
+				messages = append(messages, fmt.Sprintf("  Validation: %s", p.ValidationResult.Recommendation))
+			}
+		}
+	}
+
+	// Show implementation progress
+	if impl, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactImplementation); impl != nil {
+		if i, ok := impl.(*artifacts.Implementation); ok {
+			messages = append(messages, "")
+			messages = append(messages, "Implementation Progress:")
+			messages = append(messages, fmt.Sprintf("  Completed Steps: %d", len(i.StepsCompleted)))
+			messages = append(messages, fmt.Sprintf("  In Progress: %d", len(i.StepsInProgress)))
+			if len(i.PlanDeviations) > 0 {
+				messages = append(messages, fmt.Sprintf("  Plan Deviations: %d", len(i.PlanDeviations)))
+			}
+		}
+	}
+
+	messages = append(messages, "")
+	return messages
+}
+
+func loadPreservedContext(workDir string) map[string]interface{} {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil
+	}
+	data, err := backend.Get(PreservedContextFile)
+	if err != nil {
+		return nil
+	}
+
+	var preserved map[string]interface{}
+	if err := json.Unmarshal(data, &preserved); err != nil {
+		return nil
+	}
+	return preserved
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// autoInitialize sets up the harness with zero user input.
+// Creates .claude directory, marker file, and default config.
+func autoInitialize(workDir string) error {
+	claudeDir := filepath.Join(workDir, ".claude")
+
+	// Create .claude directory if it doesn't exist
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create .claude directory: %w", err)
+	}
+
+	// Create marker file
+	markerPath := filepath.Join(claudeDir, config.InitMarkerFileName)
+	markerContent := fmt.Sprintf("# Ultraharness initialized\n# Auto-initialized: %s\n", time.Now().Format(time.RFC3339))
+	if err := os.WriteFile(markerPath, []byte(markerContent), 0644); err != nil {
+		return fmt.Errorf("failed to create marker file: %w", err)
+	}
+
+	// Write default config
+	configPath := filepath.Join(claudeDir, config.ConfigFileName)
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		defaultCfg := config.DefaultConfig()
+		configData, err := json.MarshalIndent(defaultCfg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		if err := os.WriteFile(configPath, configData, 0644); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+
+	// Create initial progress file with auto-init entry
+	progressPath := progress.GetProgressPath(workDir)
+	if _, err := os.Stat(progressPath); os.IsNotExist(err) {
+		initialProgress := fmt.Sprintf("# Ultraharness Progress Log\n# Auto-initialized: %s\n\n", time.Now().Format(time.RFC3339))
+		if err := os.WriteFile(progressPath, []byte(initialProgress), 0600); err != nil {
+			// Non-fatal - progress file is optional
+		}
+	}
+
+	// Update .gitignore to ignore harness-specific files
+	updateGitignore(workDir)
+
+	return nil
+}
+
+// updateGitignore adds harness files to .gitignore
+func updateGitignore(workDir string) {
+	gitignorePath := filepath.Join(workDir, ".gitignore")
+
+	harnessIgnores := []string{
+		"# Ultraharness local files",
+		"claude-progress.txt",
+		".claude/fic-*.json",
+		".claude/snapshots/",
+		".claude/handoffs/",
+		".claude/.claude-harness-initialized",
+	}
+
+	// Read existing .gitignore
+	existing := ""
+	if data, err := os.ReadFile(gitignorePath); err == nil {
+		existing = string(data)
+	}
+
+	// Check which entries need to be added
+	var toAdd []string
+	for _, entry := range harnessIgnores {
+		if !strings.Contains(existing, entry) {
+			toAdd = append(toAdd, entry)
+		}
+	}
+
+	if len(toAdd) == 0 {
+		return
+	}
+
+	// Append new entries
+	f, err := os.OpenFile(gitignorePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// Add newline if file doesn't end with one
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		f.WriteString("\n")
+	}
+	f.WriteString("\n" + strings.Join(toAdd, "\n") + "\n")
+}
@@ -0,0 +1,286 @@
+// Package stop implements the Stop hook, which validates session stop
+// conditions.
+//
+// This hook runs when a session is stopping to:
+// 1. Check if tests were run (if code was modified)
+// 2. Check for uncommitted changes
+// 3. Check for features still in progress
+// 4. Check if progress log was updated
+// 5. Report session budget overruns
+// 6. Check that passing features were verified against acceptance criteria
+// 7. Check that the project builds (cached per working tree state)
+// 8. Check for unresolved merge conflicts
+// 9. Check that the changed code files are still the ones the plan refers to
+// 10. Check that code changes reference a ticket, when ticket_pattern is set
+//
+// Behavior by strictness mode:
+// - strict: Block if validation fails
+// - standard: Strong warnings but no blocking
+// - relaxed: Minimal suggestions only
+package stop
+
+import (
+	"regexp"
+	"strings"
+
+	"ultraharness/internal/budget"
+	"ultraharness/internal/config"
+	"ultraharness/internal/drift"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/project"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/readiness"
+	"ultraharness/internal/session"
+	"ultraharness/internal/testrunner"
+	"ultraharness/internal/tickets"
+)
+
+// Hook is the Stop hook.
+var Hook = hookrunner.Hook{Name: "Stop", Key: config.HookStop, Handler: run}
+
+func run(c *hookrunner.Context) error {
+	workDir, cfg := c.WorkDir, c.Config
+	event := c.Stop()
+
+	// Apply the strictness escalation schedule for this session
+	if state, err := session.Load(event.SessionID, workDir); err == nil {
+		cfg.Escalate(state.ToolCalls)
+	} else {
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+		cfg.Escalate(0)
+	}
+
+	// Get stop reason
+	stopReason := event.Reason
+
+	// Only validate for normal stops (not errors/interrupts)
+	if stopReason != "end_turn" && stopReason != "stop_sequence" && stopReason != "" && stopReason != "unknown" {
+		return protocol.WriteEmpty()
+	}
+
+	// Get transcript for test detection
+	transcript := event.Transcript
+
+	// Run validation
+	canStop, blockingReasons, warnings := validateStop(workDir, cfg, transcript, event.SessionID)
+
+	if cfg.IsVerbose() && len(blockingReasons) == 0 && len(warnings) == 0 {
+		return protocol.WriteMessage("[Harness debug] All stop checks passed ("+cfg.Strictness+" mode).",
+			protocol.Metadata{"event": protocol.EventStopAllowed})
+	}
+
+	// Handle based on strictness mode
+	if cfg.IsStrictMode() {
+		return handleStrictMode(canStop, blockingReasons, warnings)
+	} else if !cfg.IsRelaxedMode() {
+		return handleStandardMode(blockingReasons, warnings)
+	}
+	return handleRelaxedMode(blockingReasons, warnings)
+}
+
+func validateStop(workDir string, cfg *config.Config, transcript string, sessionID string) (bool, []string, []string) {
+	var blockingReasons []string
+	var warnings []string
+
+	codeFiles := cfg.GetCodeFiles()
+	codeModified := git.CodeWasModified(workDir, codeFiles.Extensions, codeFiles.Exclude)
+
+	// Check 1: Tests not run (if code was modified)
+	if codeModified {
+		testsRan := testrunner.DidTestsRun(transcript)
+		if !testsRan {
+			reason := "Code was modified but tests were not run"
+			if project.Detect(workDir).Uses(project.LangGo) {
+				reason += " - run test_affected to test just the affected Go packages, in parallel"
+			}
+			blockingReasons = append(blockingReasons, reason)
+		}
+	}
+
+	// Check 2: Uncommitted changes
+	if git.HasUncommittedChanges(workDir) {
+		warnings = append(warnings, "Uncommitted changes exist - consider creating a checkpoint")
+	}
+
+	// Check 3: Features still in progress
+	if features.Exists(workDir) {
+		inProgress, err := features.GetInProgress(workDir)
+		if err == nil && len(inProgress) > 0 {
+			featureNames := make([]string, 0, 3)
+			for i, f := range inProgress {
+				if i >= 3 {
+					break
+				}
+				featureNames = append(featureNames, f.Name)
+			}
+			warnings = append(warnings, "Features still in progress: "+strings.Join(featureNames, ", "))
+		}
+	}
+
+	// Check 4: Progress log not updated
+	if codeModified {
+		progressPath := progress.GetProgressPath(workDir)
+		if !git.FileModified(workDir, progressPath) {
+			warnings = append(warnings, "Progress log not updated - consider logging your accomplishments")
+		}
+	}
+
+	// Check 5: Session budget usage
+	if state, err := session.Load(sessionID, workDir); err == nil {
+		status := budget.Check(state, cfg.GetBudget())
+		if status.IsExceeded() {
+			warnings = append(warnings, "Session budget exceeded: "+strings.Join(status.Exceeded, ", "))
+		}
+	}
+
+	// Check 6: Features marked passing without verification
+	if reason := readiness.UnverifiedFeatures(workDir); reason != "" {
+		blockingReasons = append(blockingReasons, reason)
+	}
+
+	// Check 7: Project builds after code changes, except in relaxed mode,
+	// which never blocks a stop and so is not worth a build
+	if codeModified && cfg.BuildVerification && !cfg.IsRelaxedMode() {
+		if reason := readiness.BuildFailure(workDir, cfg); reason != "" {
+			blockingReasons = append(blockingReasons, reason)
+		}
+	}
+
+	// Check 8: Merge conflicts left unresolved
+	if conflicts := readiness.Conflicts(workDir); len(conflicts) > 0 {
+		blockingReasons = append(blockingReasons,
+			"Unresolved merge conflicts in: "+strings.Join(conflicts, ", "))
+	}
+
+	// Check 9: Implementation drifted from the plan
+	if cfg.FICEnabled && cfg.DriftDetection {
+		if warning := checkDrift(workDir, cfg); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// Check 10: Code changes without a ticket reference
+	if re, ok := tickets.Compile(cfg.TicketPattern); ok {
+		if warning := checkTicket(workDir, cfg, re, sessionID, codeModified); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// Determine if stopping is allowed
+	canStop := len(blockingReasons) == 0
+
+	return canStop, blockingReasons, warnings
+}
+
+// checkDrift returns a warning when more of the code files changed since
+// the plan was saved lie outside it than drift_warn_ratio allows.
+func checkDrift(workDir string, cfg *config.Config) string {
+	codeFiles := cfg.GetCodeFiles()
+	report, ok := drift.Check(workDir, func(path string) bool {
+		return git.IsCodeFile(path, codeFiles.Extensions, codeFiles.Exclude)
+	})
+	if !ok || !report.Exceeds(cfg.GetDriftWarnRatio()) {
+		return ""
+	}
+	return "Implementation drifted from the plan: " + report.String() +
+		" - update the plan artifact with the files and steps the work took, or record the deviations in the implementation artifact"
+}
+
+// checkTicket returns a warning when the session changed code, committed
+// or not, and no progress entry or commit message written during it
+// references a ticket.
+func checkTicket(workDir string, cfg *config.Config, re *regexp.Regexp, sessionID string, codeModified bool) string {
+	state, err := session.Load(sessionID, workDir)
+	if err != nil {
+		return ""
+	}
+	codeFiles := cfg.GetCodeFiles()
+	changed := codeModified || tickets.CodeCommitted(workDir, state.StartedAt, func(path string) bool {
+		return git.IsCodeFile(path, codeFiles.Extensions, codeFiles.Exclude)
+	})
+	if !changed || tickets.Referenced(workDir, re, state.StartedAt) != "" {
+		return ""
+	}
+	return "Code changed without a ticket reference matching " + cfg.TicketPattern +
+		" - mention the ticket in a progress entry or the commit message"
+}
+
+func handleStrictMode(canStop bool, blockingReasons, warnings []string) error {
+	if !canStop {
+		var messageParts []string
+		messageParts = append(messageParts, "[Harness - STRICT MODE] Cannot stop due to:")
+		for _, r := range blockingReasons {
+			messageParts = append(messageParts, "  ! "+r)
+		}
+
+		if len(warnings) > 0 {
+			messageParts = append(messageParts, "")
+			messageParts = append(messageParts, "Additional reminders:")
+			for _, w := range warnings {
+				messageParts = append(messageParts, "  - "+w)
+			}
+		}
+
+		return protocol.WriteDeny(strings.Join(messageParts, "\n"), stopMetadata(protocol.EventStopBlocked, blockingReasons, warnings))
+	}
+
+	if len(warnings) > 0 {
+		msg := "[Harness] Approved to stop.\n\nReminders:\n"
+		for _, w := range warnings {
+			msg += "  - " + w + "\n"
+		}
+		return protocol.WriteAdvice(msg, stopMetadata(protocol.EventStopReminders, nil, warnings))
+	}
+
+	return protocol.WriteEmpty()
+}
+
+func handleStandardMode(blockingReasons, warnings []string) error {
+	var messageParts []string
+
+	if len(blockingReasons) > 0 {
+		messageParts = append(messageParts, "[Harness] IMPORTANT - Before stopping:")
+		for _, r := range blockingReasons {
+			messageParts = append(messageParts, "  ! "+r)
+		}
+		messageParts = append(messageParts, "")
+	}
+
+	if len(warnings) > 0 {
+		if len(messageParts) == 0 {
+			messageParts = append(messageParts, "[Harness] Reminders before stopping:")
+		} else {
+			messageParts = append(messageParts, "Additional reminders:")
+		}
+		for _, w := range warnings {
+			messageParts = append(messageParts, "  - "+w)
+		}
+	}
+
+	if len(messageParts) > 0 {
+		return protocol.WriteAdvice(strings.Join(messageParts, "\n"), stopMetadata(protocol.EventStopReminders, blockingReasons, warnings))
+	}
+	return protocol.WriteEmpty()
+}
+
+func handleRelaxedMode(blockingReasons, warnings []string) error {
+	allItems := append(blockingReasons, warnings...)
+	if len(allItems) > 0 {
+		return protocol.WriteAdvice("[Harness] FYI: "+allItems[0], stopMetadata(protocol.EventStopReminders, blockingReasons, warnings))
+	}
+	return protocol.WriteEmpty()
+}
+
+// stopMetadata lists the stop checks that failed, as blocking reasons and
+// warnings.
+func stopMetadata(event string, blockingReasons, warnings []string) protocol.Metadata {
+	return protocol.Metadata{
+		"event":            event,
+		"blocking_reasons": blockingReasons,
+		"warnings":         warnings,
+	}
+}
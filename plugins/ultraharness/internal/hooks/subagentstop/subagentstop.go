@@ -0,0 +1,586 @@
+// Package subagentstop implements the SubagentStop hook, which processes
+// research subagent results.
+//
+// This hook runs when a subagent completes to:
+// 1. Detect if it was a FIC research subagent
+// 2. Extract structured findings from the output
+// 3. Inject only essential findings into main context
+// 4. Save oversized outputs to a file and inject only an excerpt
+// 5. Add research discoveries to the project knowledge base
+// 6. Record decisions marked "DECISION:" in the decision log
+// 7. Add assumptions marked "ASSUMPTION:" by research subagents to the
+// research artifact, pinned to the files they depend on
+package subagentstop
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/compose"
+	"ultraharness/internal/config"
+	"ultraharness/internal/decisions"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/text"
+)
+
+// Pre-compiled patterns for extraction
+var (
+	confidencePattern = regexp.MustCompile(`(?i)confidence\s*(?:score)?[:\s]+(\d+\.?\d*)%?`)
+	proceedPattern    = regexp.MustCompile(`(?i)\bPROCEED\b`)
+	blockPattern      = regexp.MustCompile(`(?i)\bBLOCK\b`)
+	revisePattern     = regexp.MustCompile(`(?i)\bREVISE\b`)
+	scorePattern      = regexp.MustCompile(`(?i)overall\s+score[:\s]+(\d+)/10`)
+	criticalPattern   = regexp.MustCompile(`(?i)\[CRITICAL\]\s+(.+?)(?:\n|$)`)
+)
+
+// OutputsDir holds the full text of oversized subagent outputs
+const OutputsDir = ".claude/subagent-outputs"
+
+// excerptTokens is the size of the excerpt injected for an oversized output
+const excerptTokens = 400
+
+// Hook is the SubagentStop hook.
+var Hook = hookrunner.Hook{Name: "SubagentStop", Key: config.HookSubagentStop, Handler: run}
+
+func run(c *hookrunner.Context) error {
+	workDir, cfg := c.WorkDir, c.Config
+
+	// Get subagent info
+	event := c.Subagent()
+	subagentType, description, output := event.Type, event.Description, event.Output
+
+	if output == "" {
+		return protocol.WriteEmpty()
+	}
+
+	var messages []string
+	meta := protocol.Metadata{"event": protocol.EventSubagentResult, "subagent_type": subagentType, "kind": "other"}
+
+	// Oversized output: keep the full text on disk and pass on an excerpt,
+	// or the structured summary below for FIC subagents
+	oversized := ""
+	if limit := cfg.GetSubagentOutputMaxTokens(); limit > 0 && compose.EstimateTokens(output) > limit {
+		if path, err := saveOutput(workDir, subagentType, output); err == nil {
+			oversized = path
+			meta["output_tokens"] = compose.EstimateTokens(output)
+			meta["output_file"] = path
+		}
+	}
+
+	// Without FIC only the output size is governed
+	if cfg.FICEnabled && isResearchSubagent(subagentType, description) {
+		meta["kind"] = "research"
+		// Extract structured information
+		confidence := extractConfidenceScore(output)
+		discoveries := extractDiscoveries(output)
+		files := extractRelevantFiles(output)
+		questions := extractOpenQuestions(output)
+		meta["confidence"] = confidence
+		if cfg.KnowledgeBase {
+			if added := rememberDiscoveries(workDir, subagentType, description, discoveries); added > 0 {
+				meta["knowledge_added"] = added
+			}
+		}
+
+		// Format summary for main context
+		summary := formatResearchSummary(confidence, discoveries, files, questions, cfg.IsQuiet())
+		messages = append(messages, summary)
+		if recorded := recordAssumptions(workDir, output); recorded > 0 {
+			meta["assumptions_recorded"] = recorded
+			messages = append(messages, fmt.Sprintf("[FIC] Recorded %d assumption(s) in the research artifact. Session start flags them if the files they depend on change.", recorded))
+		}
+
+		// Add guidance based on confidence
+		if confidence >= 0.7 {
+			messages = append(messages, "")
+			messages = append(messages, "[FIC] Research confidence threshold met. Ready for PLANNING phase.")
+		} else {
+			messages = append(messages, "")
+			messages = append(messages, fmt.Sprintf("[FIC] Research confidence at %.0f%%. Continue to build understanding.", confidence*100))
+		}
+	} else if cfg.FICEnabled && isPlanValidator(subagentType, description) {
+		// Check if this was a plan validator
+		recommendation := extractRecommendation(output)
+		meta["kind"] = "plan_validator"
+		meta["recommendation"] = recommendation
+		summary := formatValidationSummary(recommendation, output, cfg.IsQuiet())
+		messages = append(messages, summary)
+
+		switch recommendation {
+		case "PROCEED":
+			messages = append(messages, "")
+			messages = append(messages, "[FIC] Plan validated. Ready for IMPLEMENTATION phase.")
+		case "BLOCK":
+			messages = append(messages, "")
+			messages = append(messages, "[FIC] Plan validation BLOCKED. Major revision required.")
+		case "REVISE":
+			messages = append(messages, "")
+			messages = append(messages, "[FIC] Plan needs revision. Address feedback before implementation.")
+		}
+	}
+
+	// Decisions any subagent marked go to the decision log
+	if recorded := recordDecisions(workDir, subagentType, output); len(recorded) > 0 {
+		meta["decisions_recorded"] = len(recorded)
+		if len(messages) > 0 {
+			messages = append(messages, "")
+		}
+		messages = append(messages, "[Harness] Recorded decision(s): "+strings.Join(recorded, "; ")+". See /ultraharness:decision.")
+	}
+
+	// FIC summaries stand in for the excerpt
+	if oversized != "" {
+		messages = append(messages, formatOversized(oversized, output, meta["kind"] == "other"))
+	}
+
+	if cfg.IsVerbose() {
+		messages = append(messages, fmt.Sprintf("[Harness debug] Subagent %q classified as %s (%d bytes of output)",
+			subagentType, meta["kind"], len(output)))
+	}
+
+	// Output result
+	if len(messages) > 0 {
+		return protocol.WriteSystemMessage(strings.Join(messages, "\n"), meta)
+	}
+	return protocol.WriteEmpty()
+}
+
+// recordDecisions adds the decisions marked in a subagent's output to the
+// decision log and returns the new ones, e.g. "ADR-3 Use middleware".
+func recordDecisions(workDir, subagentType, output string) []string {
+	found := decisions.Extract(output)
+	if len(found) == 0 {
+		return nil
+	}
+	log, err := decisions.Load(workDir)
+	if err != nil {
+		return nil
+	}
+	var recorded []string
+	for _, d := range found {
+		d.Source = "subagent: " + subagentType
+		if added, ok := log.Add(d); ok {
+			recorded = append(recorded, added.String())
+		}
+	}
+	if len(recorded) == 0 || log.Save(workDir) != nil {
+		return nil
+	}
+	for _, r := range recorded {
+		progress.Append(progress.SourceAuto, "DECISION: "+r, workDir)
+	}
+	return recorded
+}
+
+// recordAssumptions adds the assumptions marked in a research subagent's
+// output to the latest research artifact, pinned to the current content of
+// the files they depend on, and returns how many were new. Without a
+// research artifact nothing is recorded.
+func recordAssumptions(workDir, output string) int {
+	found := artifacts.ExtractAssumptions(output)
+	if len(found) == 0 {
+		return 0
+	}
+	a, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch)
+	research, ok := a.(*artifacts.Research)
+	if !ok {
+		return 0
+	}
+	added := 0
+	for _, assumption := range found {
+		if research.AddAssumption(assumption) {
+			added++
+		}
+	}
+	if added == 0 {
+		return 0
+	}
+	research.PinAssumptions(workDir)
+	research.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return 0
+	}
+	err = backend.Update(func(tx storage.Tx) error {
+		return artifacts.PutLatest(tx, backend, artifacts.ArtifactResearch, research)
+	})
+	if err != nil {
+		return 0
+	}
+	return added
+}
+
+// saveOutput writes a subagent's full output to OutputsDir and returns its
+// path relative to workDir. Outputs saved in the same second, as when
+// parallel subagents finish together, get a -2, -3, ... suffix rather than
+// overwriting each other.
+func saveOutput(workDir, subagentType, output string) (string, error) {
+	dir := filepath.Join(workDir, OutputsDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	name := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(subagentType), "-"), "-")
+	if name == "" {
+		name = "subagent"
+	}
+	base := time.Now().Format("20060102-150405") + "-" + name
+	for n := 1; n <= maxOutputSuffix; n++ {
+		rel := filepath.Join(OutputsDir, base+".md")
+		if n > 1 {
+			rel = filepath.Join(OutputsDir, fmt.Sprintf("%s-%d.md", base, n))
+		}
+		f, err := os.OpenFile(filepath.Join(workDir, rel), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.WriteString(output)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", err
+		}
+		return rel, nil
+	}
+	return "", fmt.Errorf("too many subagent outputs named %s in %s", base, OutputsDir)
+}
+
+// maxOutputSuffix limits the outputs saved under one name in one second
+const maxOutputSuffix = 100
+
+// nonSlug matches runs of characters not allowed in output file names
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// formatOversized describes an oversized output saved to path, with an
+// excerpt of its headings and opening lines if excerpt is set.
+func formatOversized(path, output string, excerpt bool) string {
+	msg := fmt.Sprintf("[Harness] Subagent output was large (~%d tokens); the full text is in %s. Read only the parts you need.",
+		compose.EstimateTokens(output), path)
+	if !excerpt {
+		return msg
+	}
+	return msg + "\n\nExcerpt:\n" + excerptOf(output, excerptTokens)
+}
+
+// excerptOf keeps the headings of output and the first lines under each,
+// up to maxTokens.
+func excerptOf(output string, maxTokens int) string {
+	var kept []string
+	used, omitted, underHeading := 0, 0, 0
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		heading := strings.HasPrefix(trimmed, "#")
+		if heading {
+			underHeading = 0
+		} else {
+			underHeading++
+		}
+		cost := compose.EstimateTokens(line)
+		if (!heading && underHeading > 3) || used+cost > maxTokens {
+			omitted++
+			continue
+		}
+		kept = append(kept, line)
+		used += cost
+	}
+	if omitted > 0 {
+		kept = append(kept, fmt.Sprintf("... (%d more lines)", omitted))
+	}
+	return strings.Join(kept, "\n")
+}
+
+func isResearchSubagent(subagentType, description string) bool {
+	indicators := []string{"fic-researcher", "research", "explore", "investigation", "analysis", "exploration"}
+
+	lower := strings.ToLower(subagentType + " " + description)
+	for _, indicator := range indicators {
+		if strings.Contains(lower, indicator) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPlanValidator(subagentType, description string) bool {
+	indicators := []string{"fic-plan-validator", "plan-validator", "validation", "validate plan"}
+
+	lower := strings.ToLower(subagentType + " " + description)
+	for _, indicator := range indicators {
+		if strings.Contains(lower, indicator) {
+			return true
+		}
+	}
+	return false
+}
+
+func extractConfidenceScore(output string) float64 {
+	matches := confidencePattern.FindStringSubmatch(output)
+	if len(matches) > 1 {
+		var value float64
+		fmt.Sscanf(matches[1], "%f", &value)
+		// Normalize to 0-1 range
+		if value > 1 {
+			value = value / 100
+		}
+		if value > 1 {
+			value = 1.0
+		}
+		if value < 0 {
+			value = 0.0
+		}
+		return value
+	}
+	return 0.5 // Default confidence
+}
+
+func extractDiscoveries(output string) []string {
+	var discoveries []string
+
+	// Look for discoveries section
+	lines := strings.Split(output, "\n")
+	inDiscoveries := false
+
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "discover") && strings.Contains(lower, ":") {
+			inDiscoveries = true
+			continue
+		}
+
+		if inDiscoveries {
+			// Stop at next section header
+			if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "===") {
+				break
+			}
+
+			line = strings.TrimSpace(line)
+			// Remove bullet points
+			line = strings.TrimPrefix(line, "- ")
+			line = strings.TrimPrefix(line, "* ")
+			if len(line) >= 3 && line[0] >= '0' && line[0] <= '9' && (line[1] == '.' || line[1] == ')') {
+				line = strings.TrimSpace(line[2:])
+			}
+
+			if len(line) > 10 {
+				discoveries = append(discoveries, text.Prefix(line, 200))
+			}
+		}
+
+		if len(discoveries) >= 10 {
+			break
+		}
+	}
+
+	return discoveries
+}
+
+// maxRememberedDiscoveries limits the discoveries one research subagent
+// adds to the knowledge base.
+const maxRememberedDiscoveries = 5
+
+// rememberDiscoveries adds a research subagent's discoveries to the
+// knowledge base and returns how many were new. Discoveries tagged
+// [CRITICAL] are marked critical.
+func rememberDiscoveries(workDir, subagentType, description string, discoveries []string) int {
+	if len(discoveries) == 0 {
+		return 0
+	}
+	base, err := knowledge.Load(workDir)
+	if err != nil {
+		return 0
+	}
+	source := "subagent: " + subagentType
+	if description != "" {
+		source += " (" + description + ")"
+	}
+	added := 0
+	for i, d := range discoveries {
+		if i == maxRememberedDiscoveries {
+			break
+		}
+		// Not ToUpper, which can change the byte length of the prefix
+		critical := len(d) >= len("[CRITICAL]") && strings.EqualFold(d[:len("[CRITICAL]")], "[CRITICAL]")
+		if critical {
+			d = d[len("[CRITICAL]"):]
+		}
+		if base.Add(d, source, critical) {
+			added++
+		}
+	}
+	if err := base.Save(workDir); err != nil {
+		return 0
+	}
+	return added
+}
+
+func extractRelevantFiles(output string) []string {
+	var files []string
+
+	// Simple file path extraction
+	filePattern := regexp.MustCompile(`[\w./\-_]+\.\w{1,10}`)
+	matches := filePattern.FindAllString(output, 15)
+
+	for _, m := range matches {
+		if strings.Contains(m, "/") || strings.Contains(m, ".") {
+			files = append(files, m)
+		}
+	}
+
+	return files
+}
+
+func extractOpenQuestions(output string) []map[string]interface{} {
+	var questions []map[string]interface{}
+
+	lines := strings.Split(output, "\n")
+	inQuestions := false
+
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "question") && strings.Contains(lower, ":") {
+			inQuestions = true
+			continue
+		}
+
+		if inQuestions {
+			if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "===") {
+				break
+			}
+
+			line = strings.TrimSpace(line)
+			line = strings.TrimPrefix(line, "- ")
+			line = strings.TrimPrefix(line, "* ")
+
+			if len(line) > 10 {
+				isBlocking := strings.Contains(strings.ToLower(line), "[blocking]")
+				line = strings.ReplaceAll(line, "[blocking]", "")
+				line = strings.ReplaceAll(line, "[BLOCKING]", "")
+				line = strings.TrimSpace(line)
+
+				questions = append(questions, map[string]interface{}{
+					"question": text.Prefix(line, 200),
+					"blocking": isBlocking,
+				})
+			}
+		}
+
+		if len(questions) >= 5 {
+			break
+		}
+	}
+
+	return questions
+}
+
+func extractRecommendation(output string) string {
+	if proceedPattern.MatchString(output) {
+		return "PROCEED"
+	}
+	if blockPattern.MatchString(output) {
+		return "BLOCK"
+	}
+	if revisePattern.MatchString(output) {
+		return "REVISE"
+	}
+	return "UNKNOWN"
+}
+
+func formatResearchSummary(confidence float64, discoveries, files []string, questions []map[string]interface{}, quiet bool) string {
+	lines := banner("RESEARCH SUBAGENT RESULTS", quiet)
+	lines = append(lines, fmt.Sprintf("Confidence: %.0f%%", confidence*100))
+
+	if len(discoveries) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("Key Discoveries (%d):", len(discoveries)))
+		for i, disc := range discoveries {
+			if i >= 5 {
+				break
+			}
+			lines = append(lines, fmt.Sprintf("  - %s", text.Truncate(disc, 80)))
+		}
+	}
+
+	if len(files) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("Relevant Files (%d):", len(files)))
+		for i, f := range files {
+			if i >= 5 {
+				break
+			}
+			lines = append(lines, fmt.Sprintf("  - %s", f))
+		}
+	}
+
+	if len(questions) > 0 {
+		blocking := 0
+		for _, q := range questions {
+			if b, ok := q["blocking"].(bool); ok && b {
+				blocking++
+			}
+		}
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("Open Questions: %d (%d blocking)", len(questions), blocking))
+		for i, q := range questions {
+			if i >= 3 {
+				break
+			}
+			prefix := ""
+			if b, ok := q["blocking"].(bool); ok && b {
+				prefix = "[BLOCKING] "
+			}
+			question := q["question"].(string)
+			lines = append(lines, fmt.Sprintf("  - %s%s", prefix, text.Truncate(question, 60)))
+		}
+	}
+
+	lines = append(lines, strings.Repeat("=", 40))
+
+	return strings.Join(lines, "\n")
+}
+
+// banner returns the heading of a subagent summary, framed by rules unless
+// output is quiet.
+func banner(title string, quiet bool) []string {
+	if quiet {
+		return []string{"[FIC] " + title}
+	}
+	return []string{strings.Repeat("=", 40), title, strings.Repeat("=", 40)}
+}
+
+func formatValidationSummary(recommendation, output string, quiet bool) string {
+	lines := banner("PLAN VALIDATION RESULTS", quiet)
+	lines = append(lines, fmt.Sprintf("Recommendation: %s", recommendation))
+
+	// Extract overall score if present
+	scoreMatches := scorePattern.FindStringSubmatch(output)
+	if len(scoreMatches) > 1 {
+		lines = append(lines, fmt.Sprintf("Overall Score: %s/10", scoreMatches[1]))
+	}
+
+	// Extract critical issues
+	criticalMatches := criticalPattern.FindStringSubmatch(output)
+	if len(criticalMatches) > 1 {
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("Critical Issue: %s", text.Prefix(criticalMatches[1], 100)))
+	}
+
+	lines = append(lines, strings.Repeat("=", 40))
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,104 @@
+package subagentstop
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/decisions"
+)
+
+// sampleOutputs are subagent outputs in the shapes the extractors look for.
+var sampleOutputs = []string{
+	"## Research\nConfidence: 85%\n\nDiscoveries:\n- The config is loaded in config.go on every run\n- [CRITICAL] Sessions are keyed by ID in state/session.json\n---\nOpen questions:\n- [BLOCKING] Should the cache be shared between sessions?\n",
+	"Confidence score: 0.7\nKey discoveries:\n1. Retries live in internal/retry/retry.go\n2) Backoff is linear\n",
+	"Overall score: 8/10\nRecommendation: PROCEED",
+	"The plan needs work. REVISE the migration step.",
+	"Discoveries:\n- " + strings.Repeat("日本語のテキスト", 20) + "\nQuestions:\n- " + strings.Repeat("🚀 launch ", 30),
+	"confidence 1e999",
+	"DECISION: Retry with backoff\nOptions:\n- fixed\n- backoff\n\n- **DECISION:**\nDECISION: " + strings.Repeat("x", 300),
+	"- ASSUMPTION: Tokens refresh in middleware (depends on: auth/refresh.go:42)\nASSUMPTION: İstanbul DEPENDS ON: ẞ\nDepends on: go.mod\nASSUMPTION:",
+	"",
+}
+
+func FuzzExtractors(f *testing.F) {
+	for _, output := range sampleOutputs {
+		f.Add(output)
+	}
+	f.Fuzz(func(t *testing.T, output string) {
+		valid := utf8.ValidString(output)
+		if c := extractConfidenceScore(output); c < 0 || c > 1 {
+			t.Errorf("extractConfidenceScore(%q) = %v, want a value in [0, 1]", output, c)
+		}
+		discoveries := extractDiscoveries(output)
+		if len(discoveries) > 10 {
+			t.Errorf("extractDiscoveries() returned %d discoveries, want at most 10", len(discoveries))
+		}
+		for _, d := range discoveries {
+			if len(d) > 200 {
+				t.Errorf("discovery %q is %d bytes, want at most 200", d, len(d))
+			}
+			if valid && !utf8.ValidString(d) {
+				t.Errorf("discovery %q is not valid UTF-8", d)
+			}
+		}
+		for _, d := range decisions.Extract(output) {
+			if d.Decision == "" {
+				t.Errorf("decisions.Extract(%q) returned an empty decision", output)
+			}
+			if valid && !utf8.ValidString(d.Decision) {
+				t.Errorf("decision %q is not valid UTF-8", d.Decision)
+			}
+		}
+		for _, a := range artifacts.ExtractAssumptions(output) {
+			if a.Statement == "" {
+				t.Errorf("artifacts.ExtractAssumptions(%q) returned an empty assumption", output)
+			}
+			if valid && !utf8.ValidString(a.Statement+a.File+a.Fact) {
+				t.Errorf("assumption %+v is not valid UTF-8", a)
+			}
+		}
+		if files := extractRelevantFiles(output); len(files) > 15 {
+			t.Errorf("extractRelevantFiles() returned %d files, want at most 15", len(files))
+		}
+		questions := extractOpenQuestions(output)
+		if len(questions) > 5 {
+			t.Errorf("extractOpenQuestions() returned %d questions, want at most 5", len(questions))
+		}
+		for _, q := range questions {
+			s, _ := q["question"].(string)
+			if len(s) > 200 {
+				t.Errorf("question %q is %d bytes, want at most 200", s, len(s))
+			}
+			if valid && !utf8.ValidString(s) {
+				t.Errorf("question %q is not valid UTF-8", s)
+			}
+		}
+		switch r := extractRecommendation(output); r {
+		case "PROCEED", "BLOCK", "REVISE", "UNKNOWN":
+		default:
+			t.Errorf("extractRecommendation(%q) = %q", output, r)
+		}
+	})
+}
+
+func TestSaveOutputSameSecond(t *testing.T) {
+	dir := t.TempDir()
+	seen := map[string]bool{}
+	for _, output := range []string{"first", "second", "third"} {
+		rel, err := saveOutput(dir, "fic-researcher", output)
+		if err != nil {
+			t.Fatalf("saveOutput() error = %v", err)
+		}
+		if seen[rel] {
+			t.Fatalf("saveOutput() reused %s", rel)
+		}
+		seen[rel] = true
+		if data, err := os.ReadFile(filepath.Join(dir, rel)); err != nil || string(data) != output {
+			t.Errorf("%s = %q, %v, want %q", rel, data, err, output)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+// Package userpromptsubmit implements the UserPromptSubmit hook, which
+// detects research/planning patterns and triggers auto-compaction.
+//
+// This hook runs when the user submits a prompt to:
+// 1. Check context utilization and trigger compaction when >= 70%
+// 2. Detect research-triggering prompts (exploration, investigation)
+// 3. Detect planning-triggering prompts
+// 4. Inject directives to delegate to appropriate subagents
+// 5. Re-inject the preserved focus directive after a compaction
+// 6. Queue research prompts that arrive during implementation
+// 7. Add knowledge base facts relevant to research and planning prompts
+// 8. Re-inject the codebase map after a compaction
+package userpromptsubmit
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/codemap"
+	"ultraharness/internal/config"
+	"ultraharness/internal/context"
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/guidance"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/researchqueue"
+	"ultraharness/internal/session"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/tags"
+	"ultraharness/internal/templates"
+	"ultraharness/internal/text"
+)
+
+// PreservedContextFile is the name of the preserved context file.
+const PreservedContextFile = "fic-preserved-context.json"
+
+// maxCarryoverDiscoveries limits the discoveries re-injected after a
+// compaction.
+const maxCarryoverDiscoveries = 5
+
+// Prompt classification patterns, matched case-insensitively as whole
+// words. Each set is compiled into a single alternation, \b(?:a|b|...)\b,
+// so a prompt is scanned once per set; research_patterns and
+// planning_patterns in fic_config add to them.
+var (
+	researchPatterns = []string{
+		`how does`,
+		`where is`,
+		`find the`,
+		`understand`,
+		`explore`,
+		`investigate`,
+		`what is`,
+		`explain the`,
+		`what does`,
+		`how is`,
+		`where are`,
+		`look for`,
+		`search for`,
+		`figure out`,
+		`learn about`,
+		`research`,
+	}
+
+	planningPatterns = []string{
+		`implement`,
+		`add\b.*\bfeature`,
+		`create\b.*\bfunction`,
+		`build`,
+		`refactor`,
+		`fix\b.*\bbug`,
+		`update\b.*\bcode`,
+		`modify`,
+		`change\b.*\bimplementation`,
+	}
+
+	defaultPatterns = promptPatterns{
+		research: compileAlternation(researchPatterns, nil),
+		planning: compileAlternation(planningPatterns, nil),
+	}
+
+	// Signs that a question spans more than a single lookup
+	multiStepPattern = regexp.MustCompile(`(?i)\b(?:and then|then|across|all|every|each|end[- ]to[- ]end|architecture|flow|overall|throughout|works?|relationship|interact)\b`)
+
+	// A file name or path, e.g. config.go or internal/session/session.go
+	fileReferencePattern = regexp.MustCompile(`(?:[\w.-]+/)*[\w-]{2,}\.[A-Za-z]{1,5}\b`)
+)
+
+// trivialMaxWords is the longest single question treated as answerable
+// without research; a question about a named file may be twice as long.
+const trivialMaxWords = 12
+
+// quotedPromptLength is the number of characters of a prompt quoted in a
+// directive.
+const quotedPromptLength = 100
+
+// Hook is the UserPromptSubmit hook.
+var Hook = hookrunner.Hook{
+	Name:       "UserPromptSubmit",
+	Key:        config.HookUserPromptSubmit,
+	RequireFIC: true,
+	Handler:    run,
+}
+
+func run(c *hookrunner.Context) error {
+	workDir, cfg, input := c.WorkDir, c.Config, c.Input
+
+	// The prompt is size-limited to bound the cost of pattern matching
+	prompt := c.Prompt().Prompt
+	if prompt == "" {
+		return protocol.WriteEmpty()
+	}
+
+	var messages []string
+
+	// Check context utilization for auto-compaction
+	sessionID := input.SessionID
+	if sessionID == "" {
+		sessionID = "default"
+	}
+
+	meta := protocol.Metadata{"event": protocol.EventPromptGuidance}
+	if cfg.FICContextTracking {
+		state, err := context.LoadContextState(sessionID, workDir)
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+		if err == nil && state != nil {
+			if carryover := deliverCarryover(workDir, state, cfg); carryover != "" {
+				messages = append(messages, carryover)
+				meta["focus_carryover"] = true
+			}
+
+			threshold := cfg.GetAutoCompactThreshold()
+			if state.NeedsCompaction(threshold) {
+				msg := templates.Render(workDir, "prompt_compaction_directive", templates.Context{
+					Reason:        "utilization",
+					Utilization:   state.UtilizationPercent,
+					Threshold:     threshold,
+					TokenEstimate: state.TotalTokenEstimate,
+					Quiet:         cfg.IsQuiet(),
+				})
+				return protocol.WriteAdvice(msg, protocol.Metadata{
+					"event":          protocol.EventCompactionRequired,
+					"reason":         "utilization",
+					"utilization":    state.UtilizationPercent,
+					"token_estimate": state.TotalTokenEstimate,
+					"threshold":      threshold,
+				})
+			}
+		}
+	}
+
+	// Get current phase
+	phase := artifacts.GetCurrentPhase(workDir)
+
+	// Remember the prompt; a repeat has already had its guidance
+	repeated, phaseChanged := false, false
+	if sess, err := session.Load(session.ResolveID(input.SessionID), workDir); err == nil {
+		repeated = sess.RecordPrompt(prompt)
+		phaseChanged = sess.EnterPhase(phase)
+		sess.Save(workDir)
+	} else {
+		errcode.Report(errcode.New(errcode.StateUnreadable, err))
+	}
+
+	// The project's own guidance for a phase entered since the last prompt
+	if phaseChanged {
+		if text := guidance.For(workDir, cfg, phase); text != "" {
+			messages = append(messages, fmt.Sprintf("[FIC] Project guidance for %s:\n%s", phase, text))
+			meta["project_guidance"] = phase
+		}
+	}
+
+	// Check for research prompt, skipping questions answerable in a step
+	trivial := isTrivialPrompt(prompt, repeated)
+	patterns := loadPromptPatterns(cfg)
+	isResearch := patterns.isResearch(prompt) && !trivial
+	isPlanning := patterns.isPlanning(prompt)
+
+	// Auto-delegate research, deferring it during implementation
+	if cfg.FICAutoDelegateResearch && isResearch {
+		ack := ""
+		if phase == "IMPLEMENTATION" && cfg.ShouldDeferResearch() {
+			ack = deferResearch(workDir, prompt, phase, input.SessionID)
+		}
+		if ack != "" {
+			messages = append(messages, ack)
+			meta["research_deferred"] = true
+		} else {
+			messages = append(messages, templates.Render(workDir, "research_directive",
+				templates.Prompt{Phase: phase, Prompt: text.Truncate(prompt, quotedPromptLength)}))
+		}
+	} else if isPlanning && isPhaseNeedingGuidance(phase) {
+		// Planning guidance
+		research, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch)
+		hasCompleteResearch := false
+		if r, ok := research.(*artifacts.Research); ok {
+			hasCompleteResearch = r.IsComplete()
+		}
+
+		directive := templates.Render(workDir, "planning_directive", templates.Prompt{
+			Phase:       phase,
+			Prompt:      text.Truncate(prompt, quotedPromptLength),
+			HasResearch: hasCompleteResearch,
+		})
+		if directive != "" {
+			messages = append(messages, directive)
+		}
+	}
+
+	// Past discoveries that bear on a new research or planning request
+	if cfg.KnowledgeBase && (isResearch || isPlanning) && !repeated {
+		if facts := relevantKnowledge(workDir, prompt, cfg.GetKnowledgeTopK()); len(facts) > 0 {
+			messages = append(messages, formatKnowledge(facts))
+			meta["knowledge_facts"] = len(facts)
+		}
+	}
+
+	if debug := c.Debugf("Phase: %s | research prompt: %t | planning prompt: %t | trivial: %t",
+		phase, isResearch, isPlanning, trivial); debug != "" {
+		messages = append(messages, debug)
+	}
+
+	// Output result
+	if len(messages) > 0 {
+		meta["phase"] = phase
+		meta["research"] = isResearch
+		meta["planning"] = isPlanning
+		return protocol.WriteSystemMessage(strings.Join(messages, "\n\n"), meta)
+	}
+	return protocol.WriteEmpty()
+}
+
+// relevantKnowledge returns the topK knowledge base facts most relevant
+// to prompt: those sharing a hashtag in the prompt or a tag of the current
+// task first, then the best keyword matches.
+func relevantKnowledge(workDir, prompt string, topK int) []knowledge.Fact {
+	base, err := knowledge.Load(workDir)
+	if err != nil {
+		return nil
+	}
+	return base.Relevant(prompt, tags.Merge(tags.Parse(prompt), artifacts.CurrentTags(workDir)), topK)
+}
+
+// formatKnowledge lists facts so the agent can build on them rather than
+// rediscover them.
+func formatKnowledge(facts []knowledge.Fact) string {
+	lines := []string{"[Harness] Known from earlier work (check before re-exploring):"}
+	for _, f := range facts {
+		marker := ""
+		if f.Critical {
+			marker = "[CRITICAL] "
+		}
+		lines = append(lines, fmt.Sprintf("  - %s%s (%s)", marker, f.Text, f.Source))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// deliverCarryover returns the focus directive and top discoveries saved
+// by PreCompact, with the codebase map, for the first prompt after a
+// compaction, and marks them as delivered. The context state is fresh
+// after a compaction when it carries the compaction count saved with the
+// preserved context and its utilization is still low.
+func deliverCarryover(workDir string, state *context.ContextState, cfg *config.Config) string {
+	if state.UtilizationPercent >= cfg.GetTargetUtilizationLow() {
+		return ""
+	}
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return ""
+	}
+	data, err := backend.Get(PreservedContextFile)
+	if err != nil {
+		return ""
+	}
+	var preserved map[string]interface{}
+	if err := json.Unmarshal(data, &preserved); err != nil {
+		return ""
+	}
+
+	count, ok := preserved["compaction_count"].(float64)
+	if !ok || int(count) != state.CompactionCount {
+		return ""
+	}
+	if delivered, _ := preserved["carryover_delivered"].(bool); delivered {
+		return ""
+	}
+
+	msg := formatCarryover(preserved, cfg.IsQuiet())
+	if msg == "" {
+		return ""
+	}
+	if cfg.CodebaseMap {
+		msg = joinNonEmpty(msg, formatCodebaseMap(workDir, cfg.IsQuiet()))
+	}
+	preserved["carryover_delivered"] = true
+	if data, err := json.MarshalIndent(preserved, "", "  "); err == nil {
+		backend.Put(PreservedContextFile, data)
+	}
+	return msg
+}
+
+// Limits on the codebase map re-injected after a compaction
+const (
+	maxCarryoverMapFiles = 40
+	maxCarryoverMapTerms = 10
+)
+
+// formatCodebaseMap renders the codebase map built from earlier exploration,
+// or "" if nothing has been mapped.
+func formatCodebaseMap(workDir string, quiet bool) string {
+	m, err := codemap.Load(workDir)
+	if err != nil || m.Len() == 0 {
+		return ""
+	}
+	files, terms := maxCarryoverMapFiles, maxCarryoverMapTerms
+	if quiet {
+		files, terms = files/2, terms/2
+	}
+	lines := append([]string{"Codebase map from earlier exploration (re-read a file only for details not noted here):"},
+		m.Format(files, terms)...)
+	return strings.Join(lines, "\n")
+}
+
+// joinNonEmpty joins the non-empty messages with blank lines.
+func joinNonEmpty(msgs ...string) string {
+	var kept []string
+	for _, m := range msgs {
+		if m != "" {
+			kept = append(kept, m)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// formatCarryover renders the preserved focus directive, discoveries,
+// unresolved blockers, and recorded decisions.
+func formatCarryover(preserved map[string]interface{}, quiet bool) string {
+	focus, _ := preserved["focus_directive"].(string)
+	var discoveries []string
+	if list, ok := preserved["essential_discoveries"].([]interface{}); ok {
+		for _, d := range list {
+			if disc, ok := d.(map[string]interface{}); ok {
+				if summary, ok := disc["summary"].(string); ok && summary != "" {
+					discoveries = append(discoveries, summary)
+				}
+			}
+			if len(discoveries) >= maxCarryoverDiscoveries {
+				break
+			}
+		}
+	}
+	var blocked []string
+	if list, ok := preserved["unresolved_blockers"].([]interface{}); ok {
+		for _, b := range list {
+			if text, ok := b.(string); ok && text != "" {
+				blocked = append(blocked, text)
+			}
+		}
+	}
+	var decided []string
+	if list, ok := preserved["decisions"].([]interface{}); ok {
+		for _, d := range list {
+			if text, ok := d.(string); ok && text != "" {
+				decided = append(decided, text)
+			}
+		}
+	}
+	if focus == "" && len(discoveries) == 0 && len(blocked) == 0 && len(decided) == 0 {
+		return ""
+	}
+
+	if quiet {
+		msg := "[FIC] Resuming after compaction."
+		if focus != "" {
+			msg += " Focus: " + focus
+		}
+		if len(discoveries) > 0 {
+			msg += " Key discoveries: " + strings.Join(discoveries, "; ")
+		}
+		if len(blocked) > 0 {
+			msg += " Unresolved blockers: " + strings.Join(blocked, "; ")
+		}
+		if len(decided) > 0 {
+			msg += " Decisions: " + strings.Join(decided, "; ")
+		}
+		return msg
+	}
+
+	lines := []string{"[FIC] Resuming after compaction. Context preserved before it ran:"}
+	if phase, ok := preserved["phase"].(string); ok && phase != "" {
+		lines = append(lines, "Phase: "+phase)
+	}
+	if focus != "" {
+		lines = append(lines, "Focus: "+focus)
+	}
+	if len(discoveries) > 0 {
+		lines = append(lines, "Key discoveries:")
+		for _, d := range discoveries {
+			lines = append(lines, "  - "+d)
+		}
+	}
+	if len(blocked) > 0 {
+		lines = append(lines, "Unresolved blockers (still failing before compaction):")
+		for _, b := range blocked {
+			lines = append(lines, "  - "+b)
+		}
+	}
+	if len(decided) > 0 {
+		lines = append(lines, "Decisions already made (do not reopen without a reason):")
+		for _, d := range decided {
+			lines = append(lines, "  - "+d)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// promptPatterns classifies prompts.
+type promptPatterns struct {
+	research *regexp.Regexp
+	planning *regexp.Regexp
+}
+
+// loadPromptPatterns returns the built-in patterns plus any added in the
+// config.
+func loadPromptPatterns(cfg *config.Config) promptPatterns {
+	research, planning := cfg.GetResearchPatterns(), cfg.GetPlanningPatterns()
+	if len(research) == 0 && len(planning) == 0 {
+		return defaultPatterns
+	}
+	return promptPatterns{
+		research: compileAlternation(researchPatterns, research),
+		planning: compileAlternation(planningPatterns, planning),
+	}
+}
+
+// compileAlternation compiles the built-in patterns, as whole words, and
+// the extra ones into one case-insensitive alternation. Extra patterns
+// that do not compile on their own are skipped, so a typo in the config
+// cannot break the built-in patterns.
+func compileAlternation(patterns, extra []string) *regexp.Regexp {
+	all := []string{`\b(?:` + strings.Join(patterns, "|") + `)\b`}
+	for _, p := range extra {
+		if _, err := regexp.Compile(p); err == nil && p != "" {
+			// Grouped so flags set inside a pattern stay inside it
+			all = append(all, "(?:"+p+")")
+		}
+	}
+	return regexp.MustCompile(`(?i)(?:` + strings.Join(all, "|") + `)`)
+}
+
+func (p promptPatterns) isResearch(prompt string) bool {
+	return p.research.MatchString(prompt)
+}
+
+// isTrivialPrompt reports whether a prompt is answerable without delegated
+// research: a repeat of a recent prompt, a short single question, or a
+// question about a named file, none of which spans multiple steps.
+func isTrivialPrompt(prompt string, repeated bool) bool {
+	if repeated {
+		return true
+	}
+	prompt = strings.TrimSpace(prompt)
+	if strings.Contains(prompt, "\n") || strings.Count(prompt, "?") > 1 || multiStepPattern.MatchString(prompt) {
+		return false
+	}
+
+	words := len(strings.Fields(prompt))
+	if words <= trivialMaxWords {
+		return true
+	}
+	return words <= 2*trivialMaxWords && fileReferencePattern.MatchString(prompt)
+}
+
+func (p promptPatterns) isPlanning(prompt string) bool {
+	return p.planning.MatchString(prompt)
+}
+
+func isPhaseNeedingGuidance(phase string) bool {
+	return phase == "NEW_SESSION" || phase == "RESEARCH" ||
+		phase == "PLANNING_READY" || phase == "PLANNING"
+}
+
+// deferResearch queues a research prompt for the next phase boundary and
+// returns the acknowledgment, or "" if the queue cannot be written.
+func deferResearch(workDir, prompt, phase, sessionID string) string {
+	queue, err := researchqueue.Load(workDir)
+	if err != nil {
+		return ""
+	}
+	item, added := queue.Add(prompt, phase, sessionID)
+	if added {
+		if err := queue.Save(workDir); err != nil {
+			return ""
+		}
+	}
+
+	return templates.Render(workDir, "research_deferred", templates.Deferral{
+		Added:   added,
+		ID:      item.ID,
+		Pending: len(queue.Pending()),
+		File:    researchqueue.FileName,
+	})
+}
@@ -0,0 +1,146 @@
+package userpromptsubmit
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/hookrunner"
+	"ultraharness/internal/text"
+)
+
+// matchesAny is the pattern-by-pattern match the alternations replace.
+func matchesAny(patterns []string, prompt string) bool {
+	for _, p := range patterns {
+		if regexp.MustCompile(`(?i)\b(?:` + p + `)\b`).MatchString(prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+var samplePrompts = []string{
+	"How does the session state get saved?",
+	"where is the config loaded",
+	"Please implement retries for the uploader",
+	"add a dark mode feature to settings",
+	"Fix the login bug\nand then update the code",
+	"Rebuild everything",
+	"thanks!",
+	"",
+}
+
+func TestPromptPatterns(t *testing.T) {
+	for _, prompt := range samplePrompts {
+		if got, want := defaultPatterns.isResearch(prompt), matchesAny(researchPatterns, prompt); got != want {
+			t.Errorf("isResearch(%q) = %v, want %v", prompt, got, want)
+		}
+		if got, want := defaultPatterns.isPlanning(prompt), matchesAny(planningPatterns, prompt); got != want {
+			t.Errorf("isPlanning(%q) = %v, want %v", prompt, got, want)
+		}
+	}
+}
+
+func TestLoadPromptPatterns(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if p := loadPromptPatterns(cfg); p != defaultPatterns {
+		t.Error("loadPromptPatterns() without extra patterns should reuse the defaults")
+	}
+
+	cfg.FICConfig.ResearchPatterns = []string{`\bdig into\b`, `(broken`, `(?s)trace.*path`}
+	cfg.FICConfig.PlanningPatterns = []string{`\bship\b`}
+	p := loadPromptPatterns(cfg)
+	tests := []struct {
+		prompt             string
+		research, planning bool
+	}{
+		{"Dig into the cache layer", true, false},
+		{"Trace the\nrequest path", true, false},
+		{"ship the fix", false, true},
+		{"How does it work", true, false},
+		{"(broken", false, false},
+		// (?s) in one pattern must not make . match newlines in another
+		{"add a\nfeature", false, false},
+	}
+	for _, tt := range tests {
+		if got := p.isResearch(tt.prompt); got != tt.research {
+			t.Errorf("isResearch(%q) = %v, want %v", tt.prompt, got, tt.research)
+		}
+		if got := p.isPlanning(tt.prompt); got != tt.planning {
+			t.Errorf("isPlanning(%q) = %v, want %v", tt.prompt, got, tt.planning)
+		}
+	}
+}
+
+// TestPromptPatternsAdversarial checks that prompts built to make a
+// backtracking matcher blow up are still matched in linear time.
+func TestPromptPatternsAdversarial(t *testing.T) {
+	prompts := []string{
+		strings.Repeat("add ", hookrunner.MaxPromptSize/4),
+		strings.Repeat("fix update change create ", hookrunner.MaxPromptSize/25),
+		strings.Repeat("a", hookrunner.MaxPromptSize),
+		strings.Repeat("how ", hookrunner.MaxPromptSize/4),
+	}
+	for _, prompt := range prompts {
+		start := time.Now()
+		defaultPatterns.isResearch(prompt)
+		defaultPatterns.isPlanning(prompt)
+		isTrivialPrompt(prompt, false)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("matching a %d-byte prompt starting %q took %v", len(prompt), prompt[:20], elapsed)
+		}
+	}
+}
+
+func FuzzPromptPatterns(f *testing.F) {
+	for _, prompt := range samplePrompts {
+		f.Add(prompt)
+	}
+	f.Fuzz(func(t *testing.T, prompt string) {
+		if got, want := defaultPatterns.isResearch(prompt), matchesAny(researchPatterns, prompt); got != want {
+			t.Errorf("isResearch(%q) = %v, want %v", prompt, got, want)
+		}
+		if got, want := defaultPatterns.isPlanning(prompt), matchesAny(planningPatterns, prompt); got != want {
+			t.Errorf("isPlanning(%q) = %v, want %v", prompt, got, want)
+		}
+		isTrivialPrompt(prompt, false)
+		if got := text.Truncate(prompt, quotedPromptLength); utf8.ValidString(prompt) && !utf8.ValidString(got) {
+			t.Errorf("quoting %q gave %q, not valid UTF-8", prompt, got)
+		}
+	})
+}
+
+func FuzzCompileAlternation(f *testing.F) {
+	f.Add(`deploy`, "deploy the app")
+	f.Add(`(?i)ship\s+it`, "Ship it now")
+	f.Add(`a)|(b`, "how does this work")
+	f.Add(`(?s).*`, "日本語")
+	f.Add(`\p{Han}+`, "修正して")
+	f.Fuzz(func(t *testing.T, extra, prompt string) {
+		// A pattern from the config only ever adds matches
+		p := compileAlternation(researchPatterns, []string{extra})
+		if defaultPatterns.isResearch(prompt) && !p.MatchString(prompt) {
+			t.Errorf("adding %q stopped %q matching", extra, prompt)
+		}
+	})
+}
+
+func BenchmarkPromptPatterns(b *testing.B) {
+	prompts := map[string]string{
+		"short": "How does the session state get saved?",
+		"long":  strings.Repeat("Please refactor the request handler so that errors are wrapped consistently. ", 200),
+		"limit": strings.Repeat("x", hookrunner.MaxPromptSize),
+	}
+	for name, prompt := range prompts {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				defaultPatterns.isResearch(prompt)
+				defaultPatterns.isPlanning(prompt)
+				isTrivialPrompt(prompt, false)
+			}
+		})
+	}
+}
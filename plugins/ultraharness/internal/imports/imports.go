@@ -0,0 +1,271 @@
+// Package imports checks Go imports against configured architecture
+// boundaries and detects import cycles introduced by an edited file.
+package imports
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/glob"
+)
+
+// DefaultTimeout bounds the go list call used to build the package graph.
+const DefaultTimeout = 10 * time.Second
+
+// Violation kinds.
+const (
+	KindCycle    = "cycle"
+	KindBoundary = "boundary"
+)
+
+// Violation is an offending import edge from the edited package.
+type Violation struct {
+	Kind string
+	// From and To are package directories relative to the module root
+	From string
+	To   string
+	// Detail is the full cycle for cycles, or the rule's reason
+	Detail string
+}
+
+// String formats the violation with its offending edge.
+func (v Violation) String() string {
+	if v.Kind == KindCycle {
+		return fmt.Sprintf("import cycle: %s", v.Detail)
+	}
+	msg := fmt.Sprintf("%s must not import %s", v.From, v.To)
+	if v.Detail != "" {
+		msg += " (" + v.Detail + ")"
+	}
+	return msg
+}
+
+// Module is a Go module on disk.
+type Module struct {
+	Root string
+	Path string
+}
+
+// FindModule returns the module containing file, or nil if there is none.
+func FindModule(file string) *Module {
+	for dir := filepath.Dir(file); ; dir = filepath.Dir(dir) {
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			if path := modulePath(string(data)); path != "" {
+				return &Module{Root: dir, Path: path}
+			}
+			return nil
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return nil
+		}
+	}
+}
+
+func modulePath(gomod string) string {
+	for _, line := range strings.Split(gomod, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// rel returns the package directory of importPath relative to the module
+// root, or false if the package is outside the module.
+func (m *Module) rel(importPath string) (string, bool) {
+	if importPath == m.Path {
+		return ".", true
+	}
+	if strings.HasPrefix(importPath, m.Path+"/") {
+		return strings.TrimPrefix(importPath, m.Path+"/"), true
+	}
+	return "", false
+}
+
+// Check reports boundary violations and import cycles created by the
+// imports of a single Go file. Test files are not checked. The package
+// graph is only loaded when the file imports packages from its own module.
+func Check(file string, rules []config.ImportBoundary, timeout time.Duration) ([]Violation, error) {
+	if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+		return nil, nil
+	}
+	mod := FindModule(file)
+	if mod == nil {
+		return nil, nil
+	}
+
+	parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, nil // syntax errors are reported elsewhere
+	}
+
+	from, err := filepath.Rel(mod.Root, filepath.Dir(file))
+	if err != nil {
+		return nil, nil
+	}
+	from = filepath.ToSlash(from)
+
+	var local []string
+	for _, spec := range parsed.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if to, ok := mod.rel(path); ok {
+			local = append(local, to)
+		}
+	}
+	if len(local) == 0 {
+		return nil, nil
+	}
+
+	violations := CheckBoundaries(from, local, rules)
+
+	graph, err := LoadGraph(mod, timeout)
+	if err != nil {
+		return violations, err
+	}
+	for _, to := range local {
+		if cycle := graph.CycleThrough(from, to); cycle != nil {
+			violations = append(violations, Violation{
+				Kind: KindCycle, From: from, To: to, Detail: strings.Join(cycle, " -> "),
+			})
+		}
+	}
+	return violations, nil
+}
+
+// CheckBoundaries returns the imports of package from that a rule denies.
+func CheckBoundaries(from string, imports []string, rules []config.ImportBoundary) []Violation {
+	var violations []Violation
+	for _, to := range imports {
+		for _, rule := range rules {
+			if !glob.Match(rule.From, from) || !glob.MatchAny(rule.Deny, to) {
+				continue
+			}
+			violations = append(violations, Violation{Kind: KindBoundary, From: from, To: to, Detail: rule.Reason})
+			break
+		}
+	}
+	return violations
+}
+
+// Graph maps module-local packages to the local packages they import.
+type Graph map[string][]string
+
+// LoadGraph lists the module's packages with go list.
+func LoadGraph(mod *Module, timeout time.Duration) (Graph, error) {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-f", "{{.ImportPath}} {{join .Imports \" \"}}", "./...")
+	cmd.Dir = mod.Root
+	cmd.WaitDelay = time.Second
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("go list timed out after %s", timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w", err)
+	}
+
+	graph := Graph{}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		pkg, ok := mod.rel(fields[0])
+		if !ok {
+			continue
+		}
+		graph[pkg] = nil
+		for _, imp := range fields[1:] {
+			if to, ok := mod.rel(imp); ok {
+				graph[pkg] = append(graph[pkg], to)
+			}
+		}
+	}
+	return graph, nil
+}
+
+// CycleThrough returns the shortest cycle formed by the edge from -> to,
+// starting and ending at from, or nil if to does not lead back to from.
+func (g Graph) CycleThrough(from, to string) []string {
+	if from == to {
+		return nil
+	}
+	parent := map[string]string{to: ""}
+	queue := []string{to}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, next := range g[pkg] {
+			if _, seen := parent[next]; seen {
+				continue
+			}
+			parent[next] = pkg
+			if next == from {
+				cycle := []string{from}
+				for p := pkg; p != ""; p = parent[p] {
+					cycle = append(cycle, p)
+				}
+				cycle = append(cycle, from)
+				// Reverse the path walked back from from
+				for i, j := 1, len(cycle)-2; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return cycle
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// Dependents returns the packages that import pkg, directly or through up
+// to depth hops, closest first and sorted within each hop.
+func (g Graph) Dependents(pkg string, depth int) []string {
+	importers := map[string][]string{}
+	for from, tos := range g {
+		for _, to := range tos {
+			importers[to] = append(importers[to], from)
+		}
+	}
+
+	seen := map[string]bool{pkg: true}
+	var dependents []string
+	frontier := []string{pkg}
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, p := range frontier {
+			for _, from := range importers[p] {
+				if !seen[from] {
+					seen[from] = true
+					next = append(next, from)
+				}
+			}
+		}
+		sort.Strings(next)
+		dependents = append(dependents, next...)
+		frontier = next
+	}
+	return dependents
+}
@@ -0,0 +1,126 @@
+package imports
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/config"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "imports-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["go.mod"] = "module example.com/app\n\ngo 1.21\n"
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+func TestFindModule(t *testing.T) {
+	tmpDir := writeModule(t, map[string]string{"internal/x/x.go": "package x\n"})
+	defer os.RemoveAll(tmpDir)
+
+	mod := FindModule(filepath.Join(tmpDir, "internal", "x", "x.go"))
+	if mod == nil || mod.Root != tmpDir || mod.Path != "example.com/app" {
+		t.Errorf("FindModule() = %+v, want root %s and path example.com/app", mod, tmpDir)
+	}
+}
+
+func TestCheckBoundaries(t *testing.T) {
+	rules := []config.ImportBoundary{
+		{From: "internal/**", Deny: []string{"cmd/**"}, Reason: "libraries must not depend on binaries"},
+	}
+	got := CheckBoundaries("internal/store", []string{"internal/util", "cmd/server"}, rules)
+	want := []Violation{{Kind: KindBoundary, From: "internal/store", To: "cmd/server", Detail: "libraries must not depend on binaries"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckBoundaries() = %+v, want %+v", got, want)
+	}
+
+	if got := CheckBoundaries("cmd/server", []string{"internal/store"}, rules); len(got) != 0 {
+		t.Errorf("CheckBoundaries() = %+v, want none", got)
+	}
+}
+
+func TestCycleThrough(t *testing.T) {
+	g := Graph{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a", "d"},
+		"d": nil,
+	}
+	if got, want := g.CycleThrough("a", "b"), []string{"a", "b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CycleThrough(a, b) = %v, want %v", got, want)
+	}
+	if got := g.CycleThrough("c", "d"); got != nil {
+		t.Errorf("CycleThrough(c, d) = %v, want nil", got)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	g := Graph{"cmd": {"api", "store"}, "api": {"store"}, "store": {"util"}, "util": nil, "tools": {"util"}}
+	if got, want := g.Dependents("store", 2), []string{"api", "cmd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(store, 2) = %v, want %v", got, want)
+	}
+	if got, want := g.Dependents("util", 1), []string{"store", "tools"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(util, 1) = %v, want %v", got, want)
+	}
+	if got := g.Dependents("cmd", 2); got != nil {
+		t.Errorf("Dependents(cmd, 2) = %v, want nil", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+
+	tmpDir := writeModule(t, map[string]string{
+		"cmd/app/main.go":      "package main\n\nimport _ \"example.com/app/internal/a\"\n\nfunc main() {}\n",
+		"internal/a/a.go":      "package a\n\nimport _ \"example.com/app/internal/b\"\n",
+		"internal/b/b.go":      "package b\n\nimport (\n\t_ \"fmt\"\n\t_ \"example.com/app/internal/a\"\n\t_ \"example.com/app/cmd/app\"\n)\n",
+		"internal/b/b_test.go": "package b\n\nimport _ \"example.com/app/cmd/app\"\n",
+	})
+	defer os.RemoveAll(tmpDir)
+
+	rules := []config.ImportBoundary{{From: "internal/**", Deny: []string{"cmd/**"}}}
+	violations, err := Check(filepath.Join(tmpDir, "internal", "b", "b.go"), rules, 0)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	want := []string{
+		"internal/b must not import cmd/app",
+		"import cycle: internal/b -> internal/a -> internal/b",
+		"import cycle: internal/b -> cmd/app -> internal/a -> internal/b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %q, want %q", got, want)
+	}
+
+	violations, _ = Check(filepath.Join(tmpDir, "internal", "b", "b_test.go"), rules, 0)
+	if len(violations) != 0 {
+		t.Errorf("Check(test file) = %v, want none", violations)
+	}
+
+	violations, _ = Check(filepath.Join(tmpDir, "cmd", "app", "main.go"), rules, 0)
+	if len(violations) != 1 || violations[0].Kind != KindCycle ||
+		!strings.HasPrefix(violations[0].Detail, "cmd/app -> internal/a") {
+		t.Errorf("Check(main.go) = %v, want only the cycle through internal/a", violations)
+	}
+}
@@ -0,0 +1,309 @@
+// Package infra recognizes infrastructure-as-code commands (Terraform,
+// OpenTofu, and Pulumi) and keeps the last successful plan for each stack,
+// so an apply can be held until a recent plan shows what it will change.
+//
+// Plans are stored in .claude/infra-plans.json. PostToolUse records them
+// from successful `terraform plan` and `pulumi preview` runs and drops
+// them once applied; PreToolUse checks them before `terraform apply` and
+// `pulumi up`.
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FileName is the plans file inside .claude.
+const FileName = "infra-plans.json"
+
+// FilePermission is the permission for the plans file
+const FilePermission = 0600
+
+// DirPermission is the permission for the plans directory
+const DirPermission = 0700
+
+// Tools recognized by Parse.
+const (
+	Terraform = "terraform"
+	OpenTofu  = "tofu"
+	Pulumi    = "pulumi"
+)
+
+// Command kinds.
+const (
+	// KindPlan previews changes: terraform plan, pulumi preview
+	KindPlan = "plan"
+	// KindApply changes infrastructure: terraform apply or destroy,
+	// pulumi up or destroy
+	KindApply = "apply"
+)
+
+// Command is an infrastructure command found in a shell command line.
+type Command struct {
+	Tool       string
+	Kind       string
+	Subcommand string
+	// Dir is the directory the command runs in, relative to the project
+	// root, from a preceding cd or -chdir/--cwd
+	Dir string
+	// Stack is the Pulumi stack selected with --stack, if any
+	Stack string
+}
+
+// String names the command's stack for messages, e.g. "terraform in infra".
+func (c Command) String() string {
+	s := c.family()
+	if c.Stack != "" {
+		s += " stack " + c.Stack
+	}
+	if c.Dir != "." {
+		s += " in " + c.Dir
+	}
+	return s
+}
+
+// family groups OpenTofu with Terraform, since they share state and plans.
+func (c Command) family() string {
+	if c.Tool == OpenTofu {
+		return Terraform
+	}
+	return c.Tool
+}
+
+func (c Command) key() string {
+	return c.family() + "\x00" + c.Dir + "\x00" + c.Stack
+}
+
+var subcommandKinds = map[string]map[string]string{
+	Terraform: {"plan": KindPlan, "apply": KindApply, "destroy": KindApply},
+	OpenTofu:  {"plan": KindPlan, "apply": KindApply, "destroy": KindApply},
+	Pulumi:    {"preview": KindPlan, "up": KindApply, "update": KindApply, "destroy": KindApply},
+}
+
+// pulumiValueFlags are the Pulumi flags that take a separate value.
+var pulumiValueFlags = map[string]bool{
+	"--cwd": true, "-C": true, "--stack": true, "-s": true,
+}
+
+// separatorPattern splits a command line into simple commands.
+var separatorPattern = regexp.MustCompile(`&&|\|\||[;|\n]`)
+
+// Parse returns the plan and apply commands in a shell command line, in
+// order. A `cd DIR` earlier in the line sets the directory of the commands
+// after it.
+func Parse(command string) []Command {
+	var found []Command
+	dir := "."
+	for _, part := range separatorPattern.Split(command, -1) {
+		fields := strings.Fields(part)
+		// Skip environment assignments, e.g. TF_LOG=debug
+		for len(fields) > 0 && strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "cd" {
+			if len(fields) > 1 {
+				dir = join(dir, fields[1])
+			}
+			continue
+		}
+		if c, ok := parseOne(fields, dir); ok {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+func parseOne(fields []string, dir string) (Command, bool) {
+	c := Command{Tool: filepath.Base(fields[0]), Dir: dir}
+	kinds, ok := subcommandKinds[c.Tool]
+	if !ok {
+		return Command{}, false
+	}
+
+	args := fields[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if c.Tool == Pulumi && pulumiValueFlags[arg] && i+1 < len(args) {
+			i++
+			name, value, hasValue = arg, args[i], true
+		}
+		switch {
+		case name == "-chdir" && hasValue, name == "--cwd" && hasValue, name == "-C" && hasValue:
+			c.Dir = join(dir, value)
+		case name == "--stack" && hasValue, name == "-s" && hasValue:
+			c.Stack = value
+		case !strings.HasPrefix(arg, "-") && c.Subcommand == "":
+			c.Subcommand = arg
+		}
+	}
+	c.Kind = kinds[c.Subcommand]
+	return c, c.Kind != ""
+}
+
+// join resolves a possibly quoted directory argument against dir.
+func join(dir, target string) string {
+	target = strings.Trim(target, `"'`)
+	if filepath.IsAbs(target) {
+		return filepath.Clean(target)
+	}
+	return filepath.Join(dir, target)
+}
+
+var (
+	ansiPattern          = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	terraformPlanPattern = regexp.MustCompile(`(?m)^\s*(?:Plan: \d+ to add|(?:Apply|Destroy) complete!).*$`)
+	terraformNoChanges   = regexp.MustCompile(`(?m)^\s*No changes\.`)
+	pulumiChangePattern  = regexp.MustCompile(`(?m)^\s*[-+~]*\s*(\d+ (?:to (?:create|update|delete|replace)|created|updated|deleted|replaced|unchanged))\s*$`)
+)
+
+// Summarize extracts the change summary from a plan or apply command's
+// output, such as "Plan: 2 to add, 1 to change, 0 to destroy.", or reports
+// false if the output has none.
+func Summarize(tool, output string) (string, bool) {
+	output = ansiPattern.ReplaceAllString(output, "")
+	if tool == Pulumi {
+		var parts []string
+		for _, m := range pulumiChangePattern.FindAllStringSubmatch(output, -1) {
+			parts = append(parts, m[1])
+		}
+		if len(parts) == 0 {
+			return "", false
+		}
+		return "Resources: " + strings.Join(parts, ", "), true
+	}
+
+	if m := terraformPlanPattern.FindString(output); m != "" {
+		return strings.TrimSpace(m), true
+	}
+	if terraformNoChanges.MatchString(output) {
+		return "No changes.", true
+	}
+	return "", false
+}
+
+// Plan is a successful plan of one stack.
+type Plan struct {
+	Tool    string    `json:"tool"`
+	Dir     string    `json:"dir"`
+	Stack   string    `json:"stack,omitempty"`
+	Command string    `json:"command"`
+	Summary string    `json:"summary"`
+	At      time.Time `json:"at"`
+}
+
+// Plans are the latest plan of each stack.
+type Plans struct {
+	Plans []Plan `json:"plans"`
+}
+
+// GetPath returns the path to the plans file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the plans. A missing file has none.
+func Load(workDir string) (*Plans, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Plans{}, nil
+		}
+		return nil, err
+	}
+
+	var p Plans
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Save writes the plans.
+func (p *Plans) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Record stores a successful plan of c's stack, replacing the previous one.
+func (p *Plans) Record(c Command, command, summary string, at time.Time) {
+	p.Remove(c)
+	p.Plans = append(p.Plans, Plan{
+		Tool:    c.family(),
+		Dir:     c.Dir,
+		Stack:   c.Stack,
+		Command: strings.Join(strings.Fields(command), " "),
+		Summary: summary,
+		At:      at,
+	})
+}
+
+// Find returns the plan of c's stack, or nil if there is none.
+func (p *Plans) Find(c Command) *Plan {
+	for i := range p.Plans {
+		if p.Plans[i].key() == c.key() {
+			return &p.Plans[i]
+		}
+	}
+	return nil
+}
+
+// Remove drops the plan of c's stack, once it has been applied.
+func (p *Plans) Remove(c Command) bool {
+	for i := range p.Plans {
+		if p.Plans[i].key() == c.key() {
+			p.Plans = append(p.Plans[:i], p.Plans[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (pl Plan) key() string {
+	return Command{Tool: pl.Tool, Dir: pl.Dir, Stack: pl.Stack}.key()
+}
+
+// Check returns why the apply command c should not run yet, or "" if its
+// stack was planned successfully within maxAge of now.
+func (p *Plans) Check(c Command, now time.Time, maxAge time.Duration) string {
+	plan := p.Find(c)
+	if plan == nil {
+		return fmt.Sprintf("no successful plan of %s has been recorded", c)
+	}
+	if age := now.Sub(plan.At); age > maxAge {
+		return fmt.Sprintf("the last plan of %s is %s old (limit %s)", c, age.Round(time.Minute), maxAge)
+	}
+	return ""
+}
+
+// PlanCommand returns the command that previews what c would change.
+func PlanCommand(c Command) string {
+	var prefix string
+	if c.Dir != "." {
+		prefix = "cd " + c.Dir + " && "
+	}
+	if c.Tool == Pulumi {
+		if c.Stack != "" {
+			return prefix + "pulumi preview --stack " + c.Stack
+		}
+		return prefix + "pulumi preview"
+	}
+	if c.Subcommand == "destroy" {
+		return prefix + c.Tool + " plan -destroy"
+	}
+	return prefix + c.Tool + " plan"
+}
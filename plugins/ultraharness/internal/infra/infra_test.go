@@ -0,0 +1,112 @@
+package infra
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string // "kind tool dir stack" per command, joined by "; "
+	}{
+		{"terraform plan", "plan terraform . "},
+		{"terraform apply -auto-approve", "apply terraform . "},
+		{"terraform -chdir=infra/prod apply tfplan", "apply terraform infra/prod "},
+		{"cd infra && TF_LOG=debug terraform plan -out=tfplan", "plan terraform infra "},
+		{"cd infra && tofu destroy", "apply tofu infra "},
+		{"/usr/local/bin/terraform plan -destroy", "plan terraform . "},
+		{"pulumi preview -s dev", "plan pulumi . dev"},
+		{"pulumi --cwd stacks/api up --yes --stack=prod", "apply pulumi stacks/api prod"},
+		{"terraform fmt && terraform validate", ""},
+		{"terraform plan && terraform apply", "plan terraform . ; apply terraform . "},
+		{"echo terraform apply", ""},
+		{"go test ./...", ""},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range Parse(tt.command) {
+			got = append(got, strings.Join([]string{c.Kind, c.Tool, c.Dir, c.Stack}, " "))
+		}
+		if strings.Join(got, "; ") != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.command, strings.Join(got, "; "), tt.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		tool, output, want string
+	}{
+		{Terraform, "  # aws_s3_bucket.logs will be created\n\x1b[1mPlan:\x1b[0m 1 to add, 0 to change, 0 to destroy.\n", "Plan: 1 to add, 0 to change, 0 to destroy."},
+		{Terraform, "No changes. Your infrastructure matches the configuration.\n", "No changes."},
+		{Terraform, "Apply complete! Resources: 1 added, 0 changed, 0 destroyed.\n", "Apply complete! Resources: 1 added, 0 changed, 0 destroyed."},
+		{Terraform, "Error: Invalid reference\n", ""},
+		{Pulumi, "Resources:\n    + 2 to create\n    ~ 1 to update\n    4 unchanged\n", "Resources: 2 to create, 1 to update, 4 unchanged"},
+		{Pulumi, "error: could not find stack\n", ""},
+	}
+	for _, tt := range tests {
+		got, ok := Summarize(tt.tool, tt.output)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Summarize(%s, %q) = %q, %v, want %q", tt.tool, tt.output, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPlans(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "infra-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	plan, apply := Parse("cd infra && terraform plan")[0], Parse("tofu -chdir=infra apply")[0]
+	other := Parse("terraform apply")[0]
+
+	plans, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if reason := plans.Check(apply, now, time.Hour); !strings.Contains(reason, "no successful plan of terraform in infra") {
+		t.Errorf("Check() without a plan = %q", reason)
+	}
+
+	plans.Record(plan, "cd infra &&  terraform plan", "Plan: 1 to add, 0 to change, 0 to destroy.", now.Add(-10*time.Minute))
+	if err := plans.Save(tmpDir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	plans, _ = Load(tmpDir)
+	if reason := plans.Check(apply, now, time.Hour); reason != "" {
+		t.Errorf("Check() after a plan of the same stack = %q, want it allowed", reason)
+	}
+	if reason := plans.Check(other, now, time.Hour); reason == "" {
+		t.Error("Check() allowed a stack in another directory")
+	}
+	if reason := plans.Check(apply, now, 5*time.Minute); !strings.Contains(reason, "10m0s old") {
+		t.Errorf("Check() with a stale plan = %q", reason)
+	}
+
+	plans.Record(plan, "terraform plan", "No changes.", now)
+	if len(plans.Plans) != 1 || plans.Find(apply).Summary != "No changes." {
+		t.Errorf("Plans = %+v, want the plan replaced", plans.Plans)
+	}
+	if !plans.Remove(apply) || plans.Find(plan) != nil {
+		t.Error("Remove() kept the applied plan")
+	}
+}
+
+func TestPlanCommand(t *testing.T) {
+	tests := map[string]string{
+		"terraform apply":              "terraform plan",
+		"cd infra && tofu destroy":     "cd infra && tofu plan -destroy",
+		"pulumi up --stack prod --yes": "pulumi preview --stack prod",
+	}
+	for command, want := range tests {
+		if got := PlanCommand(Parse(command)[0]); got != want {
+			t.Errorf("PlanCommand(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
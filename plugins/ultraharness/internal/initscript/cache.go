@@ -0,0 +1,115 @@
+package initscript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CacheFileName is the name of the init script result cache.
+const CacheFileName = "fic-init-cache.json"
+
+// DefaultCacheMaxAge is how long a successful run is reused.
+const DefaultCacheMaxAge = 24 * time.Hour
+
+// FilePermission is the permission for the cache file
+const FilePermission = 0600
+
+// DirPermission is the permission for the cache directory
+const DirPermission = 0700
+
+// CacheEntry records the last successful run of a script.
+type CacheEntry struct {
+	Hash   string    `json:"hash"`
+	RanAt  time.Time `json:"ran_at"`
+	Output string    `json:"output,omitempty"`
+}
+
+// Cache maps script names to their last successful run.
+type Cache map[string]CacheEntry
+
+// GetCachePath returns the path to the cache file.
+func GetCachePath(workDir string) string {
+	return filepath.Join(workDir, ".claude", CacheFileName)
+}
+
+// LoadCache reads the cache. A missing or unreadable cache is empty.
+func LoadCache(workDir string) Cache {
+	cache := Cache{}
+	data, err := os.ReadFile(GetCachePath(workDir))
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return Cache{}
+	}
+	return cache
+}
+
+// Save writes the cache to disk.
+func (c Cache) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetCachePath(workDir), data, FilePermission)
+}
+
+// lookup returns a cached result for the script if its hash matches and
+// the entry is younger than maxAge.
+func (c Cache) lookup(script, hash string, maxAge time.Duration) (*Result, bool) {
+	entry, ok := c[script]
+	if !ok || entry.Hash != hash || time.Since(entry.RanAt) > maxAge {
+		return nil, false
+	}
+
+	return &Result{
+		Name:     script,
+		Executed: true,
+		Success:  true,
+		Cached:   true,
+		RanAt:    entry.RanAt,
+		Output:   entry.Output,
+	}, true
+}
+
+// record stores a successful result; failures are dropped so they rerun.
+func (c Cache) record(hash string, result *Result) {
+	if !result.Success {
+		delete(c, result.Name)
+		return
+	}
+	c[result.Name] = CacheEntry{Hash: hash, RanAt: result.RanAt, Output: result.Output}
+}
+
+// hashFile returns the SHA-256 of a file's content.
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// formatAge renders a duration as a coarse "2h ago" style string.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,88 @@
+package initscript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunAllCache(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "initscript-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	counter := filepath.Join(tmpDir, "runs")
+	script := filepath.Join(tmpDir, InitScript)
+	writeScript(t, script, "echo run >> "+counter+"\n")
+
+	runs := func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+
+	RunAll(tmpDir, Options{})
+	results := RunAll(tmpDir, Options{})
+	if runs() != 1 {
+		t.Errorf("script ran %d times, want 1 (second run cached)", runs())
+	}
+	if !results[0].Cached {
+		t.Errorf("Cached = false, want true for unchanged script")
+	}
+	if got := GetResultString(results[0]); !strings.Contains(got, "cached: succeeded") {
+		t.Errorf("GetResultString() = %q, want cached message", got)
+	}
+
+	RunAll(tmpDir, Options{Force: true})
+	if runs() != 2 {
+		t.Errorf("script ran %d times with Force, want 2", runs())
+	}
+
+	writeScript(t, script, "echo run >> "+counter+"\n# changed\n")
+	RunAll(tmpDir, Options{})
+	if runs() != 3 {
+		t.Errorf("script ran %d times after change, want 3", runs())
+	}
+
+	RunAll(tmpDir, Options{CacheMaxAge: time.Nanosecond})
+	if runs() != 4 {
+		t.Errorf("script ran %d times after cache expiry, want 4", runs())
+	}
+}
+
+func TestRunAllDoesNotCacheFailures(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "initscript-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeScript(t, filepath.Join(tmpDir, InitScript), "exit 1\n")
+
+	RunAll(tmpDir, Options{})
+	results := RunAll(tmpDir, Options{})
+	if results[0].Cached {
+		t.Error("Cached = true for failing script, want false")
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{2*time.Hour + 10*time.Minute, "2h ago"},
+		{72 * time.Hour, "3d ago"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -6,24 +6,63 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"ultraharness/internal/text"
+	"ultraharness/internal/validation"
 )
 
 // InitScript is the default init script name.
 const InitScript = "init.sh"
 
+// InitDir holds additional numbered init scripts, relative to the work directory.
+const InitDir = ".claude/init.d"
+
 // MaxScriptSize is the maximum allowed script size (10KB).
 const MaxScriptSize = 10000
 
+// MaxOutputLength limits the captured output per script.
+const MaxOutputLength = 500
+
 // DefaultTimeout is the default script timeout.
 const DefaultTimeout = 60 * time.Second
 
-// Result contains the outcome of running the init script.
+// DefaultEnvAllowlist lists environment variables passed to init scripts.
+// Entries ending in "*" match by prefix.
+var DefaultEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TMPDIR",
+	"LANG", "LC_*", "CLAUDE_*",
+}
+
+// Result contains the outcome of running an init script.
 type Result struct {
+	Name     string
 	Executed bool
 	Success  bool
 	Output   string
 	Error    string
+	Duration time.Duration
+	// Cached is set when the result was reused from a previous run
+	Cached bool
+	RanAt  time.Time
+}
+
+// Options controls how init scripts are run.
+type Options struct {
+	// Timeout applies to each script (default DefaultTimeout)
+	Timeout time.Duration
+	// ScriptTimeouts overrides Timeout for scripts by name
+	ScriptTimeouts map[string]time.Duration
+	// EnvAllowlist restricts the script environment (default DefaultEnvAllowlist)
+	EnvAllowlist []string
+	// CacheMaxAge reuses successful results of unchanged scripts for this
+	// long (default DefaultCacheMaxAge)
+	CacheMaxAge time.Duration
+	// Force runs every script even if a cached result is available
+	Force bool
 }
 
 // Exists checks if init.sh exists in the work directory.
@@ -33,34 +72,110 @@ func Exists(workDir string) bool {
 	return err == nil
 }
 
+// Scripts returns the init scripts to run, in order: init.sh followed by
+// the regular files in .claude/init.d sorted by name.
+func Scripts(workDir string) []string {
+	var scripts []string
+	if Exists(workDir) {
+		scripts = append(scripts, InitScript)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(workDir, InitDir))
+	if err != nil {
+		return scripts
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		names = append(names, filepath.Join(InitDir, e.Name()))
+	}
+	sort.Strings(names)
+
+	return append(scripts, names...)
+}
+
 // Run executes the init.sh script if it exists.
 func Run(workDir string, timeout time.Duration) *Result {
+	if !Exists(workDir) {
+		return &Result{Name: InitScript}
+	}
+	return runScript(workDir, InitScript, Options{Timeout: timeout})
+}
+
+// RunAll executes init.sh and every script in .claude/init.d in order.
+// A failing script does not stop later scripts from running. Scripts whose
+// content is unchanged since their last successful run are skipped and
+// report the cached result, unless opts.Force is set or the cache entry is
+// older than opts.CacheMaxAge.
+func RunAll(workDir string, opts Options) []*Result {
+	maxAge := opts.CacheMaxAge
+	if maxAge == 0 {
+		maxAge = DefaultCacheMaxAge
+	}
+
+	cache := LoadCache(workDir)
+	changed := false
+
+	var results []*Result
+	for _, script := range Scripts(workDir) {
+		hash, err := hashFile(filepath.Join(workDir, script))
+		if err == nil && !opts.Force {
+			if cached, ok := cache.lookup(script, hash, maxAge); ok {
+				results = append(results, cached)
+				continue
+			}
+		}
+
+		result := runScript(workDir, script, opts)
+		if err == nil {
+			cache.record(hash, result)
+			changed = true
+		}
+		results = append(results, result)
+	}
+
+	if changed {
+		cache.Save(workDir)
+	}
+	return results
+}
+
+// runScript executes a single script, given relative to workDir.
+func runScript(workDir, script string, opts Options) *Result {
+	result := &Result{Name: script, Executed: true}
+
+	timeout := opts.Timeout
+	if t, ok := opts.ScriptTimeouts[filepath.Base(script)]; ok && t > 0 {
+		timeout = t
+	}
 	if timeout == 0 {
 		timeout = DefaultTimeout
 	}
 
-	result := &Result{}
-	scriptPath := filepath.Join(workDir, InitScript)
+	scriptPath, err := confine(workDir, script)
+	if err != nil {
+		result.Error = script + " resolves outside the project directory, skipping"
+		return result
+	}
 
-	// Check if script exists
 	info, err := os.Stat(scriptPath)
 	if err != nil {
-		return result // Script doesn't exist, not an error
+		result.Error = script + " not readable: " + err.Error()
+		return result
 	}
 
 	// Validate script size
 	if info.Size() > MaxScriptSize {
-		result.Executed = true
-		result.Success = false
-		result.Error = "init.sh too large (>10KB), skipping for safety"
+		result.Error = script + " too large (>10KB), skipping for safety"
 		return result
 	}
 
 	// Check if script is executable
 	if info.Mode()&0111 == 0 {
-		result.Executed = true
-		result.Success = false
-		result.Error = "init.sh not executable (run: chmod +x init.sh)"
+		result.Error = script + " not executable (run: chmod +x " + script + ")"
 		return result
 	}
 
@@ -70,29 +185,31 @@ func Run(workDir string, timeout time.Duration) *Result {
 
 	cmd := exec.CommandContext(ctx, "bash", scriptPath)
 	cmd.Dir = workDir
+	cmd.Env = FilterEnv(os.Environ(), opts.EnvAllowlist)
+	// Don't wait on background children still holding the output pipe
+	cmd.WaitDelay = time.Second
 
+	result.RanAt = time.Now()
 	output, err := cmd.CombinedOutput()
-	result.Executed = true
+	result.Duration = time.Since(result.RanAt)
 
 	if ctx.Err() == context.DeadlineExceeded {
-		result.Success = false
-		result.Error = "init.sh timed out after " + timeout.String()
+		result.Error = script + " timed out after " + timeout.String()
 		return result
 	}
 
 	// Truncate output if too long
 	outputStr := string(output)
-	if len(outputStr) > 500 {
-		outputStr = outputStr[:500] + "...[truncated]"
+	if len(outputStr) > MaxOutputLength {
+		outputStr = text.Prefix(outputStr, MaxOutputLength) + "...[truncated]"
 	}
 	result.Output = outputStr
 
 	if err != nil {
-		result.Success = false
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			result.Error = "init.sh warning (exit " + string(rune('0'+exitErr.ExitCode())) + ")"
+			result.Error = script + " warning (exit " + strconv.Itoa(exitErr.ExitCode()) + ")"
 		} else {
-			result.Error = "init.sh failed: " + err.Error()
+			result.Error = script + " failed: " + err.Error()
 		}
 	} else {
 		result.Success = true
@@ -101,22 +218,83 @@ func Run(workDir string, timeout time.Duration) *Result {
 	return result
 }
 
+// confine resolves symlinks and verifies the script stays inside workDir.
+func confine(workDir, script string) (string, error) {
+	resolvedDir, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(workDir, script))
+	if err != nil {
+		return "", err
+	}
+
+	return validation.ValidatePath(resolved, resolvedDir)
+}
+
+// FilterEnv keeps only environment entries whose names match the allowlist.
+// Allowlist entries ending in "*" match by prefix. A nil allowlist uses
+// DefaultEnvAllowlist.
+func FilterEnv(env []string, allowlist []string) []string {
+	if allowlist == nil {
+		allowlist = DefaultEnvAllowlist
+	}
+
+	filtered := []string{}
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		for _, allowed := range allowlist {
+			if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+				if strings.HasPrefix(name, prefix) {
+					filtered = append(filtered, kv)
+					break
+				}
+			} else if name == allowed {
+				filtered = append(filtered, kv)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 // GetResultString returns a human-readable result string.
 func GetResultString(result *Result) string {
 	if !result.Executed {
 		return ""
 	}
 
+	name := result.Name
+	if name == "" {
+		name = InitScript
+	}
+
+	if result.Cached {
+		return name + " cached: succeeded " + formatAge(time.Since(result.RanAt))
+	}
+
 	if result.Success {
 		if result.Output != "" {
-			return "init.sh executed successfully:\n" + result.Output
+			return name + " executed successfully:\n" + result.Output
 		}
-		return "init.sh executed successfully"
+		return name + " executed successfully"
 	}
 
 	if result.Error != "" {
 		return "Warning: " + result.Error
 	}
 
-	return "init.sh execution completed"
+	return name + " execution completed"
+}
+
+// GetResultsString returns a human-readable summary of several script runs.
+func GetResultsString(results []*Result) string {
+	var parts []string
+	for _, r := range results {
+		if s := GetResultString(r); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
 }
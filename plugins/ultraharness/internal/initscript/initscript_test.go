@@ -0,0 +1,113 @@
+package initscript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScripts(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "initscript-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeScript(t, filepath.Join(tmpDir, InitScript), "true\n")
+	writeScript(t, filepath.Join(tmpDir, InitDir, "20-services.sh"), "true\n")
+	writeScript(t, filepath.Join(tmpDir, InitDir, "10-deps.sh"), "true\n")
+	writeScript(t, filepath.Join(tmpDir, InitDir, ".hidden"), "true\n")
+
+	got := Scripts(tmpDir)
+	want := []string{InitScript, filepath.Join(InitDir, "10-deps.sh"), filepath.Join(InitDir, "20-services.sh")}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Scripts() = %v, want %v", got, want)
+	}
+}
+
+func TestRunAll(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "initscript-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	outside, err := os.MkdirTemp("", "initscript-outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outside)
+
+	writeScript(t, filepath.Join(tmpDir, InitDir, "10-ok.sh"), "echo ok\n")
+	writeScript(t, filepath.Join(tmpDir, InitDir, "20-fail.sh"), "exit 12\n")
+	writeScript(t, filepath.Join(tmpDir, InitDir, "30-slow.sh"), "sleep 5\n")
+	writeScript(t, filepath.Join(outside, "evil.sh"), "true\n")
+	os.Symlink(filepath.Join(outside, "evil.sh"), filepath.Join(tmpDir, InitDir, "40-link.sh"))
+
+	results := RunAll(tmpDir, Options{
+		Timeout:        10 * time.Second,
+		ScriptTimeouts: map[string]time.Duration{"30-slow.sh": 100 * time.Millisecond},
+	})
+	if len(results) != 4 {
+		t.Fatalf("len(RunAll()) = %d, want 4", len(results))
+	}
+
+	if !results[0].Success || strings.TrimSpace(results[0].Output) != "ok" {
+		t.Errorf("10-ok.sh = %+v, want success with output ok", results[0])
+	}
+	if results[1].Success || !strings.Contains(results[1].Error, "exit 12") {
+		t.Errorf("20-fail.sh error = %q, want exit 12", results[1].Error)
+	}
+	if results[2].Success || !strings.Contains(results[2].Error, "timed out") {
+		t.Errorf("30-slow.sh error = %q, want timeout", results[2].Error)
+	}
+	if results[3].Success || !strings.Contains(results[3].Error, "outside the project") {
+		t.Errorf("40-link.sh error = %q, want confinement error", results[3].Error)
+	}
+}
+
+func TestFilterEnv(t *testing.T) {
+	env := []string{"PATH=/bin", "AWS_SECRET_ACCESS_KEY=x", "LC_ALL=C", "HOME=/root", "PATHX=1"}
+
+	got := FilterEnv(env, nil)
+	want := "PATH=/bin,LC_ALL=C,HOME=/root"
+	if strings.Join(got, ",") != want {
+		t.Errorf("FilterEnv() = %v, want %s", got, want)
+	}
+
+	got = FilterEnv(env, []string{"AWS_*"})
+	if strings.Join(got, ",") != "AWS_SECRET_ACCESS_KEY=x" {
+		t.Errorf("FilterEnv() with custom allowlist = %v", got)
+	}
+}
+
+func TestFilterEnvPassedToScript(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "initscript-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Setenv("ULTRAHARNESS_TEST_SECRET", "leaked")
+	writeScript(t, filepath.Join(tmpDir, InitScript), "echo \"secret=$ULTRAHARNESS_TEST_SECRET\"\n")
+
+	result := Run(tmpDir, 0)
+	if !result.Success {
+		t.Fatalf("Run() = %+v, want success", result)
+	}
+	if strings.Contains(result.Output, "leaked") {
+		t.Errorf("Run() output = %q, secret should be filtered", result.Output)
+	}
+}
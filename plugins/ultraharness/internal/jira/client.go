@@ -0,0 +1,170 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each Jira request.
+const DefaultTimeout = 10 * time.Second
+
+// maxResponseSize limits the size of issue responses.
+const maxResponseSize = 1 << 20
+
+// ErrNotFound is returned for issues that do not exist or are not visible
+// to the account.
+var ErrNotFound = errors.New("jira issue not found")
+
+// Client talks to the Jira REST API (version 2, whose text fields are
+// plain strings).
+type Client struct {
+	baseURL *url.URL
+	email   string
+	token   string
+	http    *http.Client
+}
+
+// Issue is an issue's key and the fields requested for it.
+type Issue struct {
+	Key    string                     `json:"key"`
+	Fields map[string]json.RawMessage `json:"fields"`
+}
+
+// NewClient returns a client for the Jira site at baseURL. With an email
+// it authenticates with basic auth (Jira Cloud API tokens); with only a
+// token it sends a bearer token (Data Center personal access tokens).
+func NewClient(baseURL, email, token string) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Jira URL %q", baseURL)
+	}
+	// Credentials must not travel in cleartext except to a local test
+	// server
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname())) {
+		return nil, fmt.Errorf("Jira URL must use https: %s", baseURL)
+	}
+
+	return &Client{
+		baseURL: u,
+		email:   email,
+		token:   token,
+		http:    &http.Client{Timeout: DefaultTimeout},
+	}, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Issue fetches the given fields of an issue, or ErrNotFound.
+func (c *Client) Issue(key string, fields []string) (*Issue, error) {
+	query := url.Values{"fields": {strings.Join(fields, ",")}}
+	resp, err := c.do(http.MethodGet, "issue/"+url.PathEscape(key)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET issue %s: %s", key, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("GET issue %s: response exceeds %d bytes", key, maxResponseSize)
+	}
+	var issue Issue
+	if err := json.Unmarshal(data, &issue); err != nil {
+		return nil, fmt.Errorf("invalid issue %s: %w", key, err)
+	}
+	return &issue, nil
+}
+
+// AddComment adds a comment to an issue.
+func (c *Client) AddComment(key, body string) error {
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(http.MethodPost, "issue/"+url.PathEscape(key)+"/comment", payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("POST comment on %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+func (c *Client) do(method, path string, payload []byte) (*http.Response, error) {
+	u, err := c.baseURL.Parse(c.baseURL.EscapedPath() + "/rest/api/2/" + path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.email != "":
+		req.SetBasicAuth(c.email, c.token)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return c.http.Do(req)
+}
+
+// Text returns a field's value as text: strings as they are, and the
+// name or value of objects such as statuses, priorities, and select
+// options. Other values, including unset fields, are "".
+func (i *Issue) Text(field string) string {
+	raw, ok := i.Fields[field]
+	if !ok {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var obj struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		if obj.Name != "" {
+			return obj.Name
+		}
+		return obj.Value
+	}
+	return ""
+}
@@ -0,0 +1,319 @@
+// Package jira mirrors the feature checklist to Jira. Features map to
+// issues through the jira.issues config or by having an issue key as
+// their ID. Pull copies the mapped issue fields and statuses into
+// claude-features.json; Push posts progress-log milestones as comments on
+// the issues they mention.
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/text"
+)
+
+// StateKey is the storage key of the local sync state.
+const StateKey = "fic-jira-sync.json"
+
+// issueKey matches Jira issue keys such as PROJ-12.
+var issueKey = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[0-9]+$`)
+
+// featureFields are the feature fields a Jira field can be pulled into.
+var featureFields = map[string]func(f *features.Feature) *string{
+	"name":        func(f *features.Feature) *string { return &f.Name },
+	"description": func(f *features.Feature) *string { return &f.Description },
+	"category":    func(f *features.Feature) *string { return &f.Category },
+}
+
+// State is the local record of the milestones already posted.
+type State struct {
+	// LastPosted is the time of the latest progress entry considered
+	LastPosted time.Time `json:"last_posted"`
+}
+
+// Change is a feature field updated from its issue.
+type Change struct {
+	Feature string
+	Issue   string
+	Field   string
+	From    string
+	To      string
+}
+
+// String describes the change for display.
+func (c Change) String() string {
+	return fmt.Sprintf("%s (%s) %s: %q -> %q", c.Feature, c.Issue, c.Field, text.Truncate(c.From, 60), text.Truncate(c.To, 60))
+}
+
+// Comment is a progress entry posted to an issue.
+type Comment struct {
+	Issue string
+	Entry progress.Entry
+}
+
+// Body returns the comment text.
+func (c Comment) Body() string {
+	return fmt.Sprintf("[ultraharness %s] %s", c.Entry.Time.Format(progress.TimeFormat), c.Entry.Message)
+}
+
+// Syncer syncs one work directory's checklist with Jira.
+type Syncer struct {
+	WorkDir   string
+	Client    *Client
+	Config    config.JiraConfig
+	milestone *regexp.Regexp
+}
+
+// New returns a Syncer for the project's Jira config, or nil if the
+// integration is disabled.
+func New(workDir string, cfg *config.Config) (*Syncer, error) {
+	j := cfg.GetJira()
+	if !j.Enabled {
+		return nil, nil
+	}
+	baseURL := os.Getenv(j.BaseURLEnv)
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s is not set", j.BaseURLEnv)
+	}
+	milestone, err := regexp.Compile(j.MilestonePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jira.milestone_pattern: %w", err)
+	}
+	for field := range j.Fields {
+		if featureFields[field] == nil {
+			return nil, fmt.Errorf("unknown feature field %q in jira.fields (expected name, description, or category)", field)
+		}
+	}
+
+	client, err := NewClient(baseURL, os.Getenv(j.EmailEnv), os.Getenv(j.TokenEnv))
+	if err != nil {
+		return nil, err
+	}
+	return &Syncer{WorkDir: workDir, Client: client, Config: j, milestone: milestone}, nil
+}
+
+// IssueKey returns the issue a feature maps to, or "" if it maps to none.
+func (s *Syncer) IssueKey(featureID string) string {
+	if key := s.Config.Issues[featureID]; key != "" {
+		return key
+	}
+	if issueKey.MatchString(featureID) {
+		return featureID
+	}
+	return ""
+}
+
+// Pull updates the mapped fields and statuses of features from their
+// issues and saves the checklist unless dryRun is set. Statuses follow
+// the configured mapping, except that passing features keep their status
+// and no feature is marked passing: that takes verify_feature.
+func (s *Syncer) Pull(dryRun bool) ([]Change, error) {
+	data, err := features.Load(s.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{"status"}
+	for _, name := range sortedKeys(s.Config.Fields) {
+		fields = append(fields, s.Config.Fields[name])
+	}
+
+	var changes []Change
+	for i := range data.Features {
+		f := &data.Features[i]
+		key := s.IssueKey(f.ID)
+		if key == "" {
+			continue
+		}
+		issue, err := s.Client.Issue(key, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range sortedKeys(s.Config.Fields) {
+			value := issue.Text(s.Config.Fields[name])
+			target := featureFields[name](f)
+			if value == "" || value == *target {
+				continue
+			}
+			changes = append(changes, Change{Feature: f.ID, Issue: key, Field: name, From: *target, To: value})
+			*target = value
+		}
+
+		if status := s.status(issue.Text("status")); status != "" && status != f.Status && f.Status != features.StatusPassing {
+			changes = append(changes, Change{Feature: f.ID, Issue: key, Field: "status", From: f.Status, To: status})
+			f.Status = status
+		}
+	}
+
+	if len(changes) > 0 && !dryRun {
+		if err := features.Save(s.WorkDir, data); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// status maps a Jira status name to a checklist status, or "".
+func (s *Syncer) status(name string) string {
+	for jiraStatus, status := range s.Config.Statuses {
+		if !strings.EqualFold(jiraStatus, name) {
+			continue
+		}
+		switch status {
+		case features.StatusPending, features.StatusInProgress, features.StatusFailing:
+			return status
+		}
+	}
+	return ""
+}
+
+// Push posts the milestone entries logged since the last push as
+// comments on the issues they mention, by feature ID or issue key. With
+// dryRun it returns the comments without posting them or recording the
+// push.
+func (s *Syncer) Push(dryRun bool) ([]Comment, error) {
+	state, err := LoadState(s.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := progress.ReadEntries(s.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	issues := s.mentionable()
+
+	var posted []Comment
+	for _, e := range entries {
+		if e.Time.IsZero() || !e.Time.After(state.LastPosted) {
+			continue
+		}
+		if s.milestone.MatchString(e.Message) {
+			for _, key := range mentioned(e.Message, issues) {
+				c := Comment{Issue: key, Entry: e}
+				if !dryRun {
+					if err := s.Client.AddComment(key, c.Body()); err != nil {
+						// Keep the entries already posted from being posted again
+						SaveState(s.WorkDir, state)
+						return posted, err
+					}
+				}
+				posted = append(posted, c)
+			}
+		}
+		state.LastPosted = e.Time
+	}
+
+	if dryRun {
+		return posted, nil
+	}
+	return posted, SaveState(s.WorkDir, state)
+}
+
+// mentionable maps the names an entry can mention an issue by, feature
+// IDs and issue keys, to the issue key.
+func (s *Syncer) mentionable() map[string]string {
+	names := make(map[string]string)
+	if data, err := features.Load(s.WorkDir); err == nil {
+		for _, f := range data.Features {
+			if key := s.IssueKey(f.ID); key != "" {
+				names[f.ID] = key
+				names[key] = key
+			}
+		}
+	}
+	for id, key := range s.Config.Issues {
+		names[id] = key
+		names[key] = key
+	}
+	return names
+}
+
+// mentioned returns the issues a message mentions, sorted and without
+// duplicates.
+func mentioned(message string, names map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for name, key := range names {
+		if seen[key] || !containsWord(message, name) {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsWord reports whether s contains word between non-word
+// characters, so F1 is not found in F12.
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if (start == 0 || !isWordByte(s[start-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// LoadState loads the local sync state.
+func LoadState(workDir string) (*State, error) {
+	state := &State{}
+
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := backend.Get(StateKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// SaveState saves the local sync state.
+func SaveState(workDir string, state *State) error {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return backend.Put(StateKey, data)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,216 @@
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+	"ultraharness/internal/progress"
+)
+
+// fakeJira serves issues and records comments, requiring basic auth.
+type fakeJira struct {
+	mu       sync.Mutex
+	issues   map[string]map[string]interface{}
+	comments map[string][]string
+}
+
+func newFakeJira(t *testing.T) (*fakeJira, *httptest.Server) {
+	f := &fakeJira{issues: make(map[string]map[string]interface{}), comments: make(map[string][]string)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "me@example.com" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+	switch {
+	case r.Method == http.MethodGet:
+		fields, ok := f.issues[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"key": path, "fields": fields})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/comment"):
+		var body struct {
+			Body string `json:"body"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		key := strings.TrimSuffix(path, "/comment")
+		f.comments[key] = append(f.comments[key], body.Body)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestSyncer(t *testing.T, url string, jira *config.JiraConfig) *Syncer {
+	dir := t.TempDir()
+	t.Setenv("JIRA_BASE_URL", url)
+	t.Setenv("JIRA_EMAIL", "me@example.com")
+	t.Setenv("JIRA_API_TOKEN", "secret")
+
+	cfg := config.DefaultConfig()
+	cfg.Jira = jira
+	s, err := New(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNew(t *testing.T) {
+	if s, err := New(t.TempDir(), config.DefaultConfig()); s != nil || err != nil {
+		t.Errorf("New() = %v, %v, want nil when disabled", s, err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Jira = &config.JiraConfig{Enabled: true, BaseURLEnv: "JIRA_TEST_UNSET_URL"}
+	if _, err := New(t.TempDir(), cfg); err == nil {
+		t.Error("New() without a base URL should fail")
+	}
+
+	t.Setenv("JIRA_BASE_URL", "http://jira.example.com")
+	cfg.Jira = &config.JiraConfig{Enabled: true}
+	if _, err := New(t.TempDir(), cfg); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("New() = %v, want an https error", err)
+	}
+
+	t.Setenv("JIRA_BASE_URL", "https://jira.example.com")
+	cfg.Jira = &config.JiraConfig{Enabled: true, Fields: map[string]string{"owner": "assignee"}}
+	if _, err := New(t.TempDir(), cfg); err == nil {
+		t.Error("New() accepted an unknown feature field")
+	}
+}
+
+func TestPull(t *testing.T) {
+	fake, srv := newFakeJira(t)
+	fake.issues["PROJ-1"] = map[string]interface{}{
+		"summary":     "Login with SSO",
+		"description": "Support SAML login",
+		"status":      map[string]string{"name": "In Progress"},
+	}
+	fake.issues["PROJ-2"] = map[string]interface{}{
+		"summary": "Audit log",
+		"status":  map[string]string{"name": "Done"},
+	}
+	fake.issues["PROJ-3"] = map[string]interface{}{
+		"summary": "Rate limits",
+		"status":  map[string]string{"name": "To Do"},
+	}
+
+	s := newTestSyncer(t, srv.URL, &config.JiraConfig{Enabled: true, Issues: map[string]string{"F1": "PROJ-1"}})
+	features.Save(s.WorkDir, &features.FeaturesData{Features: []features.Feature{
+		{ID: "F1", Name: "SSO", Status: features.StatusPending},
+		{ID: "PROJ-2", Name: "Audit log", Status: features.StatusInProgress},
+		{ID: "PROJ-3", Name: "Limits", Status: features.StatusPassing},
+		{ID: "F4", Name: "Unmapped", Status: features.StatusPending},
+	}})
+
+	changes, err := s.Pull(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Feature+" "+c.Field+"="+c.To)
+	}
+	want := "F1 description=Support SAML login, F1 name=Login with SSO, F1 status=in_progress, PROJ-3 name=Rate limits"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("Pull() = %v, want %s", got, want)
+	}
+	if data, _ := features.Load(s.WorkDir); data.Features[0].Name != "SSO" {
+		t.Error("Pull(dryRun) saved the checklist")
+	}
+
+	if _, err := s.Pull(false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := features.Load(s.WorkDir)
+	if f := data.Find("F1"); f.Name != "Login with SSO" || f.Status != features.StatusInProgress {
+		t.Errorf("F1 = %+v, want the issue's summary and status", f)
+	}
+	if f := data.Find("PROJ-2"); f.Status != features.StatusInProgress {
+		t.Errorf("PROJ-2 status = %s, want unmapped Done to leave it alone", f.Status)
+	}
+	if f := data.Find("PROJ-3"); f.Status != features.StatusPassing {
+		t.Errorf("PROJ-3 status = %s, want passing kept", f.Status)
+	}
+
+	delete(fake.issues, "PROJ-1")
+	if _, err := s.Pull(false); err == nil {
+		t.Error("Pull() of a missing issue should fail")
+	}
+}
+
+func TestPush(t *testing.T) {
+	fake, srv := newFakeJira(t)
+	s := newTestSyncer(t, srv.URL, &config.JiraConfig{Enabled: true, Issues: map[string]string{"F1": "PROJ-1"}})
+	features.Save(s.WorkDir, &features.FeaturesData{Features: []features.Feature{
+		{ID: "F1", Name: "SSO"},
+		{ID: "PROJ-2", Name: "Audit log"},
+	}})
+
+	content := "# Progress\n" +
+		"[2026-10-01 09:00:00] [agent] Started F1\n" +
+		"[2026-10-01 10:00:00] [auto] VERIFIED: F1 passing, PROJ-2 failing\n" +
+		"[2026-10-01 11:00:00] [agent] Done with F12 cleanup\n" +
+		"[2026-10-01 12:00:00] [human] Milestone: PROJ-1 shipped\n"
+	if err := progress.AppendRaw(content, s.WorkDir); err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := s.Push(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 3 || len(fake.comments) != 0 {
+		t.Fatalf("Push(dryRun) = %v with %d posted, want 3 unposted", comments, len(fake.comments))
+	}
+
+	if _, err := s.Push(false); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.comments["PROJ-1"]; len(got) != 2 || got[0] != "[ultraharness 2026-10-01 10:00:00] VERIFIED: F1 passing, PROJ-2 failing" {
+		t.Errorf("PROJ-1 comments = %q", got)
+	}
+	if got := fake.comments["PROJ-2"]; len(got) != 1 {
+		t.Errorf("PROJ-2 comments = %q, want the VERIFIED entry", got)
+	}
+
+	if comments, err := s.Push(false); err != nil || len(comments) != 0 {
+		t.Errorf("second Push() = %v, %v, want nothing new", comments, err)
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	tests := []struct {
+		s, word string
+		want    bool
+	}{
+		{"VERIFIED: F1 passing", "F1", true},
+		{"F12 done", "F1", false},
+		{"done (PROJ-1)", "PROJ-1", true},
+		{"PROJ-12 and PROJ-1", "PROJ-1", true},
+		{"PROJ-12", "PROJ-1", false},
+	}
+	for _, tt := range tests {
+		if got := containsWord(tt.s, tt.word); got != tt.want {
+			t.Errorf("containsWord(%q, %q) = %v, want %v", tt.s, tt.word, got, tt.want)
+		}
+	}
+}
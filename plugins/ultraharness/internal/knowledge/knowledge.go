@@ -0,0 +1,188 @@
+// Package knowledge keeps a project knowledge base of facts discovered
+// across tasks, so critical findings outlive the research artifacts and
+// compactions they came from.
+//
+// Facts are stored in .claude/knowledge.json. PreCompact and SubagentStop
+// add them; SessionStart injects the ones relevant to the current task.
+package knowledge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"ultraharness/internal/relevance"
+	"ultraharness/internal/tags"
+)
+
+// FileName is the knowledge base file inside .claude.
+const FileName = "knowledge.json"
+
+// FilePermission is the permission for the knowledge base
+const FilePermission = 0600
+
+// DirPermission is the permission for the knowledge base directory
+const DirPermission = 0700
+
+// MaxFacts caps the knowledge base; the oldest facts are dropped first.
+const MaxFacts = 500
+
+// Fact is one discovery worth remembering.
+type Fact struct {
+	Text string `json:"text"`
+	// Source names where the fact came from, e.g. "research: add caching"
+	Source   string `json:"source"`
+	Critical bool   `json:"critical,omitempty"`
+	// Tags label the fact, e.g. "auth", including the hashtags in its text
+	Tags    []string  `json:"tags,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Base is the project's knowledge base, oldest facts first.
+type Base struct {
+	Facts []Fact `json:"facts"`
+}
+
+// GetPath returns the path to the knowledge base file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the knowledge base. A missing file is an empty base.
+func Load(workDir string) (*Base, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Base{}, nil
+		}
+		return nil, err
+	}
+
+	var b Base
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Save writes the knowledge base.
+func (b *Base) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Add records a fact with the given tags and those in its text unless the
+// base already has it, ignoring case, punctuation, and spacing; a known
+// fact rediscovered as critical is marked critical, and gains any new
+// tags. Returns true if the fact was added.
+func (b *Base) Add(text, source string, critical bool, labels ...string) bool {
+	text = strings.TrimSpace(text)
+	key := normalize(text)
+	if key == "" {
+		return false
+	}
+	labels = tags.Of(labels, text)
+	for i := range b.Facts {
+		if normalize(b.Facts[i].Text) == key {
+			b.Facts[i].Critical = b.Facts[i].Critical || critical
+			b.Facts[i].Tags = tags.Merge(b.Facts[i].Tags, labels)
+			return false
+		}
+	}
+
+	b.Facts = append(b.Facts, Fact{Text: text, Source: source, Critical: critical, Tags: labels, AddedAt: time.Now()})
+	if len(b.Facts) > MaxFacts {
+		b.Facts = b.Facts[len(b.Facts)-MaxFacts:]
+	}
+	return true
+}
+
+// Search returns up to limit facts relevant to query, ranked by TF-IDF,
+// then critical facts, then newer facts.
+func (b *Base) Search(query string, limit int) []Fact {
+	texts := make([]string, len(b.Facts))
+	for i, f := range b.Facts {
+		texts[i] = f.Text
+	}
+	matches := relevance.NewIndex(texts).Rank(query)
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		a, c := b.Facts[matches[i].Index], b.Facts[matches[j].Index]
+		if a.Critical != c.Critical {
+			return a.Critical
+		}
+		return matches[i].Index > matches[j].Index
+	})
+
+	var facts []Fact
+	for i, m := range matches {
+		if i == limit {
+			break
+		}
+		facts = append(facts, b.Facts[m.Index])
+	}
+	return facts
+}
+
+// Tagged returns up to limit facts carrying any of the given tags,
+// critical facts first, then newer facts.
+func (b *Base) Tagged(labels []string, limit int) []Fact {
+	var facts []Fact
+	for i := len(b.Facts) - 1; i >= 0; i-- {
+		if tags.Any(b.Facts[i].Tags, labels) {
+			facts = append(facts, b.Facts[i])
+		}
+	}
+	sort.SliceStable(facts, func(i, j int) bool { return facts[i].Critical && !facts[j].Critical })
+	if len(facts) > limit {
+		facts = facts[:limit]
+	}
+	return facts
+}
+
+// Relevant returns up to limit facts for a task: first those carrying any
+// of the task's tags, then those most relevant to query.
+func (b *Base) Relevant(query string, labels []string, limit int) []Fact {
+	facts := b.Tagged(labels, limit)
+	listed := map[string]bool{}
+	for _, f := range facts {
+		listed[f.Text] = true
+	}
+	for _, f := range b.Search(query, limit) {
+		if len(facts) == limit {
+			break
+		}
+		if !listed[f.Text] {
+			facts = append(facts, f)
+		}
+	}
+	return facts
+}
+
+// Recent returns the newest limit facts, newest first.
+func (b *Base) Recent(limit int) []Fact {
+	var facts []Fact
+	for i := len(b.Facts) - 1; i >= 0 && len(facts) < limit; i-- {
+		facts = append(facts, b.Facts[i])
+	}
+	return facts
+}
+
+// normalize reduces text to its words for duplicate detection.
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
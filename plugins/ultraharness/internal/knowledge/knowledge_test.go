@@ -0,0 +1,122 @@
+package knowledge
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadSave(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "knowledge-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	base, err := Load(tmpDir)
+	if err != nil || len(base.Facts) != 0 {
+		t.Fatalf("Load() of missing file = %v, %v, want empty base", base, err)
+	}
+
+	base.Add("Sessions are stored as JSON under .claude", "research: sessions", false)
+	if err := base.Save(tmpDir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got.Facts) != 1 || got.Facts[0].Source != "research: sessions" || got.Facts[0].AddedAt.IsZero() {
+		t.Errorf("Load() = %+v, want the saved fact", got.Facts)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	var base Base
+	if !base.Add("The cache is keyed by content hash.", "research", false) {
+		t.Error("Add() of new fact = false, want true")
+	}
+	if base.Add("  the cache is keyed by CONTENT hash ", "subagent: explore", true) {
+		t.Error("Add() of duplicate fact = true, want false")
+	}
+	if len(base.Facts) != 1 || !base.Facts[0].Critical || base.Facts[0].Source != "research" {
+		t.Errorf("Facts = %+v, want one fact from research, marked critical", base.Facts)
+	}
+	if base.Add(" ... ", "research", false) {
+		t.Error("Add() of empty fact = true, want false")
+	}
+
+	for i := 0; i < MaxFacts+5; i++ {
+		base.Add(string(rune('a'+i%26))+string(rune('a'+i/26))+" fact", "research", false)
+	}
+	if len(base.Facts) != MaxFacts {
+		t.Errorf("len(Facts) = %d, want %d", len(base.Facts), MaxFacts)
+	}
+	if base.Facts[0].Text == "The cache is keyed by content hash." {
+		t.Error("Add() over MaxFacts kept the oldest fact")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var base Base
+	base.Add("Login tokens expire after one hour", "research: auth", false)
+	base.Add("The payment webhook retries three times", "research: payments", false)
+	base.Add("Login form validation runs client side", "research: auth", false)
+	base.Add("Token refresh must happen before the login redirect", "research: auth", true)
+
+	got := base.Search("Fix login token refresh", 2)
+	if len(got) != 2 {
+		t.Fatalf("Search() returned %d facts, want 2", len(got))
+	}
+	if got[0].Text != "Token refresh must happen before the login redirect" {
+		t.Errorf("Search()[0] = %q, want the fact matching most words", got[0].Text)
+	}
+
+	// Equal scores: newer first
+	got = base.Search("login", 5)
+	if len(got) != 3 || got[1].Text != "Login form validation runs client side" {
+		t.Errorf("Search(login) = %+v, want critical, then newest first", got)
+	}
+
+	if got := base.Search("the and for", 5); len(got) != 0 {
+		t.Errorf("Search() of stop words = %+v, want none", got)
+	}
+	if got := base.Recent(1); len(got) != 1 || !got[0].Critical {
+		t.Errorf("Recent(1) = %+v, want the newest fact", got)
+	}
+}
+
+func TestTagged(t *testing.T) {
+	var base Base
+	base.Add("Tokens are signed with the #auth service key", "research: auth", false)
+	base.Add("The connection pool is capped at 10", "research: db", false, "#perf")
+	base.Add("Sessions expire after one hour", "research: auth", false, "auth")
+	base.Add("The payment webhook retries three times", "research: payments", false)
+	base.Add("tokens are signed with the auth service key", "subagent: explore", true, "security")
+
+	if got := strings.Join(base.Facts[0].Tags, " "); got != "auth security" || !base.Facts[0].Critical {
+		t.Errorf("rediscovered fact = %+v, want its tags merged and marked critical", base.Facts[0])
+	}
+
+	got := base.Tagged([]string{"#AUTH"}, 5)
+	if len(got) != 2 || got[0].Text != "Tokens are signed with the #auth service key" || got[1].Text != "Sessions expire after one hour" {
+		t.Errorf("Tagged(auth) = %+v, want critical, then newest first", got)
+	}
+	if got := base.Tagged([]string{"auth", "perf"}, 1); len(got) != 1 {
+		t.Errorf("Tagged() with limit 1 returned %d facts", len(got))
+	}
+	if got := base.Tagged(nil, 5); len(got) != 0 {
+		t.Errorf("Tagged() without tags = %+v", got)
+	}
+
+	// Tagged facts first, then the best keyword matches not yet listed
+	got = base.Relevant("payment webhook sessions", []string{"perf"}, 3)
+	var texts []string
+	for _, f := range got {
+		texts = append(texts, f.Text)
+	}
+	want := "The connection pool is capped at 10 | The payment webhook retries three times | Sessions expire after one hour"
+	if strings.Join(texts, " | ") != want {
+		t.Errorf("Relevant() = %q, want %q", strings.Join(texts, " | "), want)
+	}
+}
@@ -0,0 +1,166 @@
+// Package license checks new source files for the project's license or
+// copyright header and renders the header in the file's comment syntax.
+package license
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// YearPlaceholder in a template matches any year, or a range of years.
+const YearPlaceholder = "{{year}}"
+
+// ScanLines is how many leading lines of a file are searched for the header.
+const ScanLines = 50
+
+// hashComments lists extensions whose line comments start with "#".
+var hashComments = map[string]bool{
+	".py": true, ".rb": true, ".sh": true, ".bash": true, ".pl": true,
+	".r": true, ".yaml": true, ".yml": true, ".toml": true, ".tf": true,
+}
+
+// markupComments lists extensions commented with <!-- -->.
+var markupComments = map[string]bool{
+	".vue": true, ".svelte": true, ".html": true, ".xml": true, ".md": true,
+}
+
+// Applies reports whether a file at path needs the header. An empty
+// extensions list applies it to the files isCode reports as code.
+func Applies(path string, extensions []string, isCode func(string) bool) bool {
+	if len(extensions) == 0 {
+		return isCode(path)
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext || strings.ToLower("."+e) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns the template as a comment block for the file at path,
+// with the year placeholder replaced by year.
+func Render(template, path string, year int) string {
+	text := strings.ReplaceAll(strings.TrimSpace(template), YearPlaceholder, strconv.Itoa(year))
+	lines := strings.Split(text, "\n")
+
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case markupComments[ext]:
+		return "<!--\n" + text + "\n-->"
+	case hashComments[ext]:
+		return prefixLines(lines, "#")
+	}
+	return prefixLines(lines, "//")
+}
+
+func prefixLines(lines []string, marker string) string {
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			lines[i] = marker
+		} else {
+			lines[i] = marker + " " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Insert returns content with the rendered header added at the top, after
+// any shebang, XML declaration, or Go build constraint lines.
+func Insert(header, content string) string {
+	lines := strings.SplitAfter(content, "\n")
+	keep := 0
+	for keep < len(lines) {
+		line := strings.TrimSpace(lines[keep])
+		if strings.HasPrefix(line, "#!") || strings.HasPrefix(line, "<?xml") ||
+			strings.HasPrefix(line, "//go:build") || strings.HasPrefix(line, "// +build") {
+			keep++
+			continue
+		}
+		break
+	}
+
+	prefix := strings.Join(lines[:keep], "")
+	if prefix != "" && !strings.HasSuffix(prefix, "\n") {
+		prefix += "\n"
+	}
+	if prefix != "" {
+		prefix += "\n"
+	}
+	rest := strings.TrimLeft(strings.Join(lines[keep:], ""), "\n")
+	if rest == "" {
+		return prefix + header + "\n"
+	}
+	return prefix + header + "\n\n" + rest
+}
+
+// HasHeader reports whether content starts with the template text in any
+// comment syntax. Only the first ScanLines lines are searched, so a shebang
+// or build constraint may precede the header.
+func HasHeader(template, content string) bool {
+	want := patterns(template)
+	if len(want) == 0 {
+		return true
+	}
+
+	lines := strings.SplitN(content, "\n", ScanLines+1)
+	if len(lines) > ScanLines {
+		lines = lines[:ScanLines]
+	}
+	var got []string
+	for _, line := range lines {
+		if line = stripComment(line); line != "" {
+			got = append(got, line)
+		}
+	}
+
+	for start := 0; start+len(want) <= len(got); start++ {
+		matched := true
+		for i, re := range want {
+			if !re.MatchString(got[start+i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// patterns compiles each non-blank template line into an anchored regexp
+// with the year placeholder matching any year or year range.
+func patterns(template string) []*regexp.Regexp {
+	var result []*regexp.Regexp
+	for _, line := range strings.Split(template, "\n") {
+		line = stripComment(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, YearPlaceholder)
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		expr := "^" + strings.Join(parts, `\d{4}(\s*[-–]\s*\d{4})?`) + "$"
+		result = append(result, regexp.MustCompile(expr))
+	}
+	return result
+}
+
+// stripComment removes comment markers and surrounding whitespace.
+func stripComment(line string) string {
+	line = strings.TrimSpace(line)
+	for _, marker := range []string{"<!--", "-->", "/*", "*/", "//", "#", "*"} {
+		line = strings.TrimSpace(strings.TrimPrefix(line, marker))
+		line = strings.TrimSpace(strings.TrimSuffix(line, marker))
+	}
+	return line
+}
@@ -0,0 +1,104 @@
+package license
+
+import (
+	"testing"
+
+	"ultraharness/internal/git"
+)
+
+const template = `Copyright {{year}} Example Corp.
+SPDX-License-Identifier: Apache-2.0`
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "// Copyright 2026 Example Corp.\n// SPDX-License-Identifier: Apache-2.0"},
+		{"tool.py", "# Copyright 2026 Example Corp.\n# SPDX-License-Identifier: Apache-2.0"},
+		{"App.vue", "<!--\nCopyright 2026 Example Corp.\nSPDX-License-Identifier: Apache-2.0\n-->"},
+	}
+
+	for _, tt := range tests {
+		if got := Render(template, tt.path, 2026); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestHasHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"line comments", "// Copyright 2024 Example Corp.\n// SPDX-License-Identifier: Apache-2.0\n\npackage main\n", true},
+		{"year range", "// Copyright 2019-2024 Example Corp.\n// SPDX-License-Identifier: Apache-2.0\n", true},
+		{"block comment", "/*\n * Copyright 2024 Example Corp.\n * SPDX-License-Identifier: Apache-2.0\n */\n", true},
+		{"after shebang", "#!/usr/bin/env python3\n# Copyright 2024 Example Corp.\n# SPDX-License-Identifier: Apache-2.0\n", true},
+		{"missing", "package main\n", false},
+		{"wrong holder", "// Copyright 2024 Someone Else\n// SPDX-License-Identifier: Apache-2.0\n", false},
+		{"partial", "// Copyright 2024 Example Corp.\n\npackage main\n", false},
+	}
+
+	for _, tt := range tests {
+		if got := HasHeader(template, tt.content); got != tt.want {
+			t.Errorf("HasHeader(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHasHeaderRoundTrip(t *testing.T) {
+	for _, path := range []string{"a.go", "a.py", "a.svelte"} {
+		content := Render(template, path, 2026) + "\n\ncode\n"
+		if !HasHeader(template, content) {
+			t.Errorf("HasHeader(Render(%q)) = false, want true", path)
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	header := "// Copyright 2026 Example Corp."
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", "package main\n", "// Copyright 2026 Example Corp.\n\npackage main\n"},
+		{"leading blank lines", "\n\npackage main\n", "// Copyright 2026 Example Corp.\n\npackage main\n"},
+		{"shebang", "#!/bin/sh\necho hi\n", "#!/bin/sh\n\n// Copyright 2026 Example Corp.\n\necho hi\n"},
+		{"build constraint", "//go:build linux\n\npackage main\n", "//go:build linux\n\n// Copyright 2026 Example Corp.\n\npackage main\n"},
+		{"empty", "", "// Copyright 2026 Example Corp.\n"},
+	}
+
+	for _, tt := range tests {
+		if got := Insert(header, tt.content); got != tt.want {
+			t.Errorf("Insert(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplies(t *testing.T) {
+	tests := []struct {
+		path       string
+		extensions []string
+		want       bool
+	}{
+		{"main.go", nil, true},
+		{"README.md", nil, false},
+		{"Makefile", nil, false},
+		{"main.go", []string{".py"}, false},
+		{"tool.PY", []string{"py"}, true},
+		{"gen/api.pb.go", nil, false},
+	}
+
+	// code_files excludes generated code
+	isCode := func(path string) bool {
+		return git.IsCodeFile(path, nil, []string{"gen/**"})
+	}
+	for _, tt := range tests {
+		if got := Applies(tt.path, tt.extensions, isCode); got != tt.want {
+			t.Errorf("Applies(%q, %v) = %v, want %v", tt.path, tt.extensions, got, tt.want)
+		}
+	}
+}
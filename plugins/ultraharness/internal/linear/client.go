@@ -0,0 +1,184 @@
+package linear
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each Linear request.
+const DefaultTimeout = 10 * time.Second
+
+// maxResponseSize limits the size of GraphQL responses.
+const maxResponseSize = 1 << 20
+
+// ErrNotFound is returned for issues that do not exist or are not visible
+// to the API key.
+var ErrNotFound = errors.New("linear issue not found")
+
+// Client talks to the Linear GraphQL API.
+type Client struct {
+	endpoint string
+	token    string
+	http     *http.Client
+}
+
+// State is a workflow state of an issue's team.
+type State struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Issue is a Linear issue with the workflow states it can move to.
+type Issue struct {
+	ID          string `json:"id"`
+	Identifier  string `json:"identifier"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	State       State  `json:"state"`
+	Team        struct {
+		States struct {
+			Nodes []State `json:"nodes"`
+		} `json:"states"`
+	} `json:"team"`
+}
+
+// FindState returns the team's workflow state with the given name,
+// ignoring case.
+func (i *Issue) FindState(name string) (State, bool) {
+	for _, s := range i.Team.States.Nodes {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return State{}, false
+}
+
+// NewClient returns a client for the GraphQL endpoint that authenticates
+// with an API key.
+func NewClient(endpoint, token string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Linear URL %q", endpoint)
+	}
+	// The API key must not travel in cleartext except to a local test
+	// server
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname())) {
+		return nil, fmt.Errorf("Linear URL must use https: %s", endpoint)
+	}
+	return &Client{endpoint: u.String(), token: token, http: &http.Client{Timeout: DefaultTimeout}}, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+const issueQuery = `query Issue($id: String!) {
+  issue(id: $id) {
+    id identifier title description url
+    state { id name }
+    team { states { nodes { id name } } }
+  }
+}`
+
+const updateStateMutation = `mutation UpdateState($id: String!, $stateId: String!) {
+  issueUpdate(id: $id, input: {stateId: $stateId}) { success }
+}`
+
+// Issue fetches an issue by identifier, such as ENG-123, or ErrNotFound.
+func (c *Client) Issue(identifier string) (*Issue, error) {
+	var data struct {
+		Issue *Issue `json:"issue"`
+	}
+	err := c.do(issueQuery, map[string]string{"id": identifier}, &data)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if data.Issue == nil {
+		return nil, fmt.Errorf("%s: %w", identifier, ErrNotFound)
+	}
+	return data.Issue, nil
+}
+
+// SetState moves an issue to a workflow state.
+func (c *Client) SetState(issueID, stateID string) error {
+	var data struct {
+		IssueUpdate struct {
+			Success bool `json:"success"`
+		} `json:"issueUpdate"`
+	}
+	if err := c.do(updateStateMutation, map[string]string{"id": issueID, "stateId": stateID}, &data); err != nil {
+		return err
+	}
+	if !data.IssueUpdate.Success {
+		return errors.New("Linear did not update the issue state")
+	}
+	return nil
+}
+
+// do runs a GraphQL operation and decodes its data into out.
+func (c *Client) do(query string, variables map[string]string, out interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxResponseSize {
+		return fmt.Errorf("Linear response exceeds %d bytes", maxResponseSize)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message    string `json:"message"`
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("Linear API: %s", resp.Status)
+	}
+	if len(result.Errors) > 0 {
+		// Linear reports unknown issues as an error rather than null data
+		if strings.Contains(strings.ToLower(result.Errors[0].Message), "not found") ||
+			result.Errors[0].Extensions.Code == "ENTITY_NOT_FOUND" {
+			return ErrNotFound
+		}
+		return fmt.Errorf("Linear API: %s", result.Errors[0].Message)
+	}
+	if resp.StatusCode != http.StatusOK || len(result.Data) == 0 {
+		return fmt.Errorf("Linear API: %s", resp.Status)
+	}
+	return json.Unmarshal(result.Data, out)
+}
@@ -0,0 +1,239 @@
+// Package linear mirrors the FIC phase of the current task to a Linear
+// issue. Binding a task to an issue seeds the research artifact with the
+// issue's description; from then on the issue moves through the workflow
+// states mapped to each phase (by default Research, In Progress, and In
+// Review) as the phases advance.
+package linear
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/config"
+	"ultraharness/internal/gates"
+	"ultraharness/internal/storage"
+)
+
+// BindingKey is the storage key of the task's issue binding.
+const BindingKey = "fic-linear.json"
+
+// PhaseReview is the phase after implementation, entered when prepush
+// finds the branch ready to push.
+const PhaseReview = "review"
+
+// ErrNotBound is returned when no issue is bound to the task.
+var ErrNotBound = errors.New("no Linear issue is bound to the task")
+
+// Binding records the issue bound to the current task.
+type Binding struct {
+	Issue   string `json:"issue"`
+	IssueID string `json:"issue_id"`
+	Title   string `json:"title"`
+	URL     string `json:"url,omitempty"`
+	// State is the workflow state the harness last moved the issue to
+	State   string    `json:"state,omitempty"`
+	BoundAt time.Time `json:"bound_at"`
+}
+
+// Move is an issue state change.
+type Move struct {
+	Issue string
+	From  string
+	To    string
+}
+
+// String describes the move for display.
+func (m *Move) String() string {
+	return fmt.Sprintf("Linear %s moved from %s to %s", m.Issue, m.From, m.To)
+}
+
+// Linker binds tasks to issues and moves them with the FIC phase.
+type Linker struct {
+	WorkDir string
+	Client  *Client
+	States  map[string]string
+}
+
+// New returns a Linker for the project's Linear config, or nil if the
+// integration is disabled.
+func New(workDir string, cfg *config.Config) (*Linker, error) {
+	l := cfg.GetLinear()
+	if !l.Enabled {
+		return nil, nil
+	}
+	token := os.Getenv(l.TokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("%s is not set", l.TokenEnv)
+	}
+	client, err := NewClient(l.URL, token)
+	if err != nil {
+		return nil, err
+	}
+	return &Linker{WorkDir: workDir, Client: client, States: l.States}, nil
+}
+
+// Bind binds the task to an issue, seeds the research artifact with its
+// description, and moves it to the state of the current phase. The move
+// is nil if the phase maps to no state or the issue is already there.
+func (l *Linker) Bind(identifier string) (*Binding, *Move, error) {
+	issue, err := l.Client.Issue(identifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	binding := &Binding{
+		Issue:   issue.Identifier,
+		IssueID: issue.ID,
+		Title:   issue.Title,
+		URL:     issue.URL,
+		State:   issue.State.Name,
+		BoundAt: time.Now(),
+	}
+	if err := SaveBinding(l.WorkDir, binding); err != nil {
+		return nil, nil, err
+	}
+	if err := seedResearch(l.WorkDir, issue); err != nil {
+		return binding, nil, fmt.Errorf("bound %s, but failed to seed the research artifact: %w", issue.Identifier, err)
+	}
+
+	move, err := l.move(binding, issue, CurrentPhase(l.WorkDir))
+	return binding, move, err
+}
+
+// Advance moves the bound issue to the state mapped to phase. It returns
+// nil without a bound issue, for an unmapped phase, or when the harness
+// already moved the issue there.
+func (l *Linker) Advance(phase string) (*Move, error) {
+	binding, err := LoadBinding(l.WorkDir)
+	if errors.Is(err, ErrNotBound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	target := l.States[phase]
+	if target == "" || strings.EqualFold(target, binding.State) {
+		return nil, nil
+	}
+	issue, err := l.Client.Issue(binding.Issue)
+	if err != nil {
+		return nil, err
+	}
+	return l.move(binding, issue, phase)
+}
+
+// Follow moves the task's issue to the state mapped to phase, when the
+// integration is enabled and an issue is bound. It is called wherever a
+// phase advances.
+func Follow(workDir, phase string) (*Move, error) {
+	cfg, err := config.Load(workDir)
+	if err != nil {
+		return nil, err
+	}
+	l, err := New(workDir, cfg)
+	if l == nil || err != nil {
+		return nil, err
+	}
+	return l.Advance(phase)
+}
+
+// move sets the issue to the state mapped to phase and records it.
+func (l *Linker) move(binding *Binding, issue *Issue, phase string) (*Move, error) {
+	target := l.States[phase]
+	if target == "" || strings.EqualFold(target, issue.State.Name) {
+		return nil, nil
+	}
+	state, ok := issue.FindState(target)
+	if !ok {
+		return nil, fmt.Errorf("%s has no workflow state %q for the %s phase (check linear.states)", issue.Identifier, target, phase)
+	}
+	if err := l.Client.SetState(issue.ID, state.ID); err != nil {
+		return nil, err
+	}
+	binding.State = state.Name
+	if err := SaveBinding(l.WorkDir, binding); err != nil {
+		return nil, err
+	}
+	return &Move{Issue: issue.Identifier, From: issue.State.Name, To: state.Name}, nil
+}
+
+// CurrentPhase returns the FIC phase, research if none was recorded.
+func CurrentPhase(workDir string) string {
+	if state, err := gates.LoadFICState(workDir); err == nil && state.Phase != "" {
+		return state.Phase
+	}
+	return "research"
+}
+
+// seedResearch puts the issue's description in the research artifact as
+// initial context. Research on the issue already under way keeps its
+// discoveries; otherwise a new artifact is started for it.
+func seedResearch(workDir string, issue *Issue) error {
+	task := issue.Identifier + ": " + issue.Title
+	research := &artifacts.Research{
+		ID:            "research-" + strings.ToLower(issue.Identifier),
+		FeatureOrTask: task,
+	}
+	if a, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch); a != nil {
+		if r, ok := a.(*artifacts.Research); ok && strings.HasPrefix(r.FeatureOrTask, issue.Identifier+":") {
+			research = r
+		}
+	}
+	context := strings.TrimSpace(issue.Description)
+	if context == "" || context == research.InitialContext {
+		return nil
+	}
+	research.InitialContext = context
+	research.UpdatedAt = time.Now().Format(time.RFC3339)
+	return artifacts.SaveArtifact(workDir, artifacts.ArtifactResearch, research)
+}
+
+// LoadBinding loads the task's issue binding, or ErrNotBound.
+func LoadBinding(workDir string) (*Binding, error) {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := backend.Get(BindingKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotBound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var binding Binding
+	if err := json.Unmarshal(data, &binding); err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// SaveBinding saves the task's issue binding.
+func SaveBinding(workDir string, binding *Binding) error {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(binding, "", "  ")
+	if err != nil {
+		return err
+	}
+	return backend.Put(BindingKey, data)
+}
+
+// Unbind removes the task's issue binding, or returns ErrNotBound.
+func Unbind(workDir string) (*Binding, error) {
+	binding, err := LoadBinding(workDir)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	return binding, backend.Delete(BindingKey)
+}
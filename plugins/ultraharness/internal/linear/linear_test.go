@@ -0,0 +1,187 @@
+package linear
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/config"
+	"ultraharness/internal/gates"
+)
+
+// fakeLinear answers the issue query and state mutation for one issue.
+type fakeLinear struct {
+	mu    sync.Mutex
+	issue Issue
+	moves int
+}
+
+func newFakeLinear(t *testing.T) (*fakeLinear, *httptest.Server) {
+	f := &fakeLinear{issue: Issue{
+		ID:          "uuid-1",
+		Identifier:  "ENG-7",
+		Title:       "Rate limit the API",
+		Description: "Clients hammer /search; cap them at 10 rps.",
+		State:       State{ID: "s-todo", Name: "Todo"},
+	}}
+	f.issue.Team.States.Nodes = []State{
+		{ID: "s-todo", Name: "Todo"},
+		{ID: "s-research", Name: "Research"},
+		{ID: "s-progress", Name: "In Progress"},
+		{ID: "s-review", Name: "In Review"},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeLinear) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "lin_api_test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors": [{"message": "Authentication required"}]}`))
+		return
+	}
+	var req struct {
+		Query     string            `json:"query"`
+		Variables map[string]string `json:"variables"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(req.Query, "query Issue"):
+		if req.Variables["id"] != f.issue.Identifier {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "Entity not found: Issue", "extensions": {"code": "ENTITY_NOT_FOUND"}}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"issue": f.issue}})
+	case strings.HasPrefix(req.Query, "mutation UpdateState"):
+		var state State
+		ok := false
+		for _, s := range f.issue.Team.States.Nodes {
+			if s.ID == req.Variables["stateId"] {
+				state, ok = s, true
+			}
+		}
+		f.issue.State = state
+		f.moves++
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"issueUpdate": map[string]bool{"success": ok}}})
+	}
+}
+
+func newTestLinker(t *testing.T, url string) *Linker {
+	t.Setenv("LINEAR_API_KEY", "lin_api_test")
+	cfg := config.DefaultConfig()
+	cfg.Linear = &config.LinearConfig{Enabled: true, URL: url}
+	l, err := New(t.TempDir(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestNew(t *testing.T) {
+	if l, err := New(t.TempDir(), config.DefaultConfig()); l != nil || err != nil {
+		t.Errorf("New() = %v, %v, want nil when disabled", l, err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Linear = &config.LinearConfig{Enabled: true, TokenEnv: "LINEAR_TEST_UNSET_KEY"}
+	if _, err := New(t.TempDir(), cfg); err == nil {
+		t.Error("New() without an API key should fail")
+	}
+
+	t.Setenv("LINEAR_API_KEY", "lin_api_test")
+	cfg.Linear = &config.LinearConfig{Enabled: true, URL: "http://linear.example.com/graphql"}
+	if _, err := New(t.TempDir(), cfg); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("New() = %v, want an https error", err)
+	}
+}
+
+func TestBindAndAdvance(t *testing.T) {
+	fake, srv := newFakeLinear(t)
+	l := newTestLinker(t, srv.URL)
+
+	if move, err := l.Advance("implementation"); move != nil || err != nil {
+		t.Errorf("Advance() without a binding = %v, %v, want nothing", move, err)
+	}
+	if _, _, err := l.Bind("ENG-99"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Bind() of an unknown issue = %v, want ErrNotFound", err)
+	}
+
+	binding, move, err := l.Bind("ENG-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binding.Issue != "ENG-7" || move == nil || move.String() != "Linear ENG-7 moved from Todo to Research" {
+		t.Errorf("Bind() = %+v, %v, want ENG-7 moved to Research", binding, move)
+	}
+
+	a, _ := artifacts.GetLatestArtifact(l.WorkDir, artifacts.ArtifactResearch)
+	r, ok := a.(*artifacts.Research)
+	if !ok || r.FeatureOrTask != "ENG-7: Rate limit the API" || r.InitialContext != fake.issue.Description {
+		t.Errorf("research artifact = %+v, want the issue's title and description", a)
+	}
+
+	// Planning is unmapped, so the issue stays in Research
+	if move, err := l.Advance("planning"); move != nil || err != nil {
+		t.Errorf("Advance(planning) = %v, %v, want nothing", move, err)
+	}
+	if move, err := l.Advance("implementation"); err != nil || move == nil || move.To != "In Progress" {
+		t.Errorf("Advance(implementation) = %v, %v, want In Progress", move, err)
+	}
+	if move, err := l.Advance("implementation"); move != nil || err != nil {
+		t.Errorf("repeated Advance(implementation) = %v, %v, want nothing", move, err)
+	}
+	if move, err := l.Advance(PhaseReview); err != nil || move == nil || move.To != "In Review" {
+		t.Errorf("Advance(review) = %v, %v, want In Review", move, err)
+	}
+	if fake.moves != 3 {
+		t.Errorf("moves = %d, want 3", fake.moves)
+	}
+
+	if _, err := Unbind(l.WorkDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unbind(l.WorkDir); !errors.Is(err, ErrNotBound) {
+		t.Errorf("second Unbind() = %v, want ErrNotBound", err)
+	}
+}
+
+func TestBindKeepsResearch(t *testing.T) {
+	fake, srv := newFakeLinear(t)
+	l := newTestLinker(t, srv.URL)
+	l.States = map[string]string{"implementation": "In Progress"}
+
+	gates.SaveFICState(l.WorkDir, &gates.FICState{Phase: "implementation", ResearchComplete: true, PlanValidated: true})
+	artifacts.SaveArtifact(l.WorkDir, artifacts.ArtifactResearch, &artifacts.Research{
+		ID:              "research-1",
+		FeatureOrTask:   "ENG-7: rate limits",
+		ConfidenceScore: 0.8,
+		Discoveries:     []artifacts.Discovery{{Summary: "limits live in middleware/"}},
+	})
+
+	_, move, err := l.Bind("ENG-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if move == nil || move.To != "In Progress" {
+		t.Errorf("Bind() move = %v, want the implementation state", move)
+	}
+	a, _ := artifacts.GetLatestArtifact(l.WorkDir, artifacts.ArtifactResearch)
+	if r := a.(*artifacts.Research); r.ID != "research-1" || len(r.Discoveries) != 1 || r.InitialContext != fake.issue.Description {
+		t.Errorf("research artifact = %+v, want the existing research with the description added", r)
+	}
+
+	l.States = map[string]string{PhaseReview: "Shipped"}
+	if _, err := l.Advance(PhaseReview); err == nil || !strings.Contains(err.Error(), "Shipped") {
+		t.Errorf("Advance() to a missing state = %v, want an error naming it", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package mcp serves the harness state over the Model Context Protocol, so
+// Claude can read the workflow phase, plan, features, and knowledge base as
+// resources and act on them with tools, rather than only being nudged by
+// hook messages. The server speaks JSON-RPC 2.0, one message per line, on
+// stdin and stdout.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"ultraharness/internal/protocol"
+)
+
+// Server name and version reported to clients; the version follows the
+// plugin's.
+const (
+	ServerName    = "ultraharness"
+	ServerVersion = "1.0.0"
+)
+
+// ProtocolVersions lists the protocol versions the server speaks, newest
+// first. A client asking for another version is offered the newest.
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	codeParseError       = -32700
+	codeInvalidRequest   = -32600
+	codeMethodNotFound   = -32601
+	codeInvalidParams    = -32602
+	codeInternalError    = -32603
+	codeResourceNotFound = -32002
+)
+
+// Server serves one project's harness state.
+type Server struct {
+	// WorkDir is the validated project directory
+	WorkDir string
+}
+
+// request is a JSON-RPC request, or a notification if it has no ID.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func errorf(code int, format string, args ...interface{}) *rpcError {
+	return &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Serve answers requests read from r, one per line, writing a response
+// per line to w, until r is exhausted.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), protocol.MaxInputSize)
+	enc := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := enc.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: errorf(codeParseError, "invalid JSON: %v", err)}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, rpcErr := s.handle(&req)
+		// Notifications get no response
+		if len(req.ID) == 0 {
+			continue
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+		if rpcErr == nil && result == nil {
+			resp.Result = struct{}{}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handle answers one request.
+func (s *Server) handle(req *request) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, errorf(codeInvalidRequest, "not a JSON-RPC 2.0 request")
+	}
+
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping", "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "resources/list":
+		return map[string]interface{}{"resources": resources}, nil
+	case "resources/read":
+		return s.readResource(req.Params)
+	case "tools/list":
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(req.Params)
+	}
+	return nil, errorf(codeMethodNotFound, "unknown method %q", req.Method)
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, errorf(codeInvalidParams, "invalid initialize params: %v", err)
+		}
+	}
+
+	version := ProtocolVersions[0]
+	for _, v := range ProtocolVersions {
+		if v == p.ProtocolVersion {
+			version = v
+		}
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"resources": map[string]interface{}{},
+			"tools":     map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    ServerName,
+			"version": ServerVersion,
+		},
+		"instructions": "Read ultraharness://phase for the workflow phase before editing. " +
+			"Call mark_research_complete once research is done, update_feature_status as features change, " +
+			"and append_progress to record notable progress.",
+	}, nil
+}
@@ -0,0 +1,267 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+	"ultraharness/internal/gates"
+	"ultraharness/internal/progress"
+)
+
+// serve sends each request line to a server for workDir and returns the
+// responses.
+func serve(t *testing.T, workDir string, lines ...string) []map[string]interface{} {
+	t.Helper()
+	var out strings.Builder
+	s := &Server{WorkDir: workDir}
+	if err := s.Serve(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var responses []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("response %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// call sends one request and returns its result, failing on an error.
+func call(t *testing.T, workDir, method, params string) map[string]interface{} {
+	t.Helper()
+	responses := serve(t, workDir, `{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":`+params+`}`)
+	if len(responses) != 1 {
+		t.Fatalf("%s: got %d responses, want 1", method, len(responses))
+	}
+	if e, ok := responses[0]["error"]; ok {
+		t.Fatalf("%s: error %v", method, e)
+	}
+	return responses[0]["result"].(map[string]interface{})
+}
+
+// callTool calls a tool and returns its text and whether it failed.
+func callTool(t *testing.T, workDir, name, args string) (string, bool) {
+	t.Helper()
+	result := call(t, workDir, "tools/call", `{"name":"`+name+`","arguments":`+args+`}`)
+	content := result["content"].([]interface{})[0].(map[string]interface{})
+	return content["text"].(string), result["isError"].(bool)
+}
+
+func readResource(t *testing.T, workDir, uri string) string {
+	t.Helper()
+	result := call(t, workDir, "resources/read", `{"uri":"`+uri+`"}`)
+	return result["contents"].([]interface{})[0].(map[string]interface{})["text"].(string)
+}
+
+func setupProject(t *testing.T) string {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "mcp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+	return tmpDir
+}
+
+func TestServe(t *testing.T) {
+	tmpDir := setupProject(t)
+
+	t.Run("initialize", func(t *testing.T) {
+		result := call(t, tmpDir, "initialize", `{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}`)
+		if result["protocolVersion"] != "2024-11-05" {
+			t.Errorf("protocolVersion = %v, want the client's", result["protocolVersion"])
+		}
+		if info := result["serverInfo"].(map[string]interface{}); info["name"] != ServerName {
+			t.Errorf("serverInfo = %v", info)
+		}
+
+		result = call(t, tmpDir, "initialize", `{"protocolVersion":"1999-01-01"}`)
+		if result["protocolVersion"] != ProtocolVersions[0] {
+			t.Errorf("protocolVersion = %v, want the newest for an unknown version", result["protocolVersion"])
+		}
+	})
+
+	t.Run("notifications get no response", func(t *testing.T) {
+		responses := serve(t, tmpDir,
+			`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			`{"jsonrpc":"2.0","id":"p","method":"ping"}`,
+		)
+		if len(responses) != 1 || responses[0]["id"] != "p" {
+			t.Errorf("responses = %v, want only the ping's", responses)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		responses := serve(t, tmpDir,
+			`{not json`,
+			`{"jsonrpc":"2.0","id":2,"method":"sampling/createMessage"}`,
+			`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"ultraharness://nope"}}`,
+			`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"nope"}}`,
+		)
+		want := []float64{codeParseError, codeMethodNotFound, codeResourceNotFound, codeInvalidParams}
+		if len(responses) != len(want) {
+			t.Fatalf("got %d responses, want %d", len(responses), len(want))
+		}
+		for i, resp := range responses {
+			e, ok := resp["error"].(map[string]interface{})
+			if !ok || e["code"] != want[i] {
+				t.Errorf("response %d = %v, want error %v", i, resp, want[i])
+			}
+		}
+	})
+
+	t.Run("lists", func(t *testing.T) {
+		if got := call(t, tmpDir, "resources/list", `{}`)["resources"].([]interface{}); len(got) != len(resources) {
+			t.Errorf("resources/list returned %d resources, want %d", len(got), len(resources))
+		}
+		if got := call(t, tmpDir, "tools/list", `{}`)["tools"].([]interface{}); len(got) != len(tools) {
+			t.Errorf("tools/list returned %d tools, want %d", len(got), len(tools))
+		}
+	})
+}
+
+func TestResources(t *testing.T) {
+	tmpDir := setupProject(t)
+
+	t.Run("new project", func(t *testing.T) {
+		var phase map[string]interface{}
+		if err := json.Unmarshal([]byte(readResource(t, tmpDir, "ultraharness://phase")), &phase); err != nil {
+			t.Fatal(err)
+		}
+		if phase["phase"] != "NEW_SESSION" || phase["research_complete"] != false {
+			t.Errorf("phase = %v", phase)
+		}
+		if got := readResource(t, tmpDir, "ultraharness://plan"); got != "null" {
+			t.Errorf("plan = %s, want null", got)
+		}
+		if got := readResource(t, tmpDir, "ultraharness://features"); !strings.Contains(got, `"features": []`) {
+			t.Errorf("features = %s, want an empty list", got)
+		}
+		if got := readResource(t, tmpDir, "ultraharness://knowledge"); !strings.Contains(got, `"facts": []`) {
+			t.Errorf("knowledge = %s, want an empty list", got)
+		}
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		if err := progress.Append(progress.SourceAgent, "Wired up the cache", tmpDir); err != nil {
+			t.Fatal(err)
+		}
+		if got := readResource(t, tmpDir, "ultraharness://progress"); !strings.Contains(got, "Wired up the cache") {
+			t.Errorf("progress = %q", got)
+		}
+	})
+}
+
+func TestTools(t *testing.T) {
+	tmpDir := setupProject(t)
+	data := &features.FeaturesData{Features: []features.Feature{
+		{ID: "auth", Name: "Login", Status: features.StatusPassing},
+		{ID: "cache", Name: "Caching", Status: features.StatusPending, DependsOn: []string{"db"}},
+		{ID: "db", Name: "Database", Status: features.StatusPending, AcceptanceCriteria: []features.Criterion{{Command: "true"}}},
+		{ID: "api", Name: "API", Status: features.StatusPending, AcceptanceCriteria: []features.Criterion{{Command: "false"}}},
+	}}
+	if err := features.Save(tmpDir, data); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("mark_research_complete", func(t *testing.T) {
+		research := &artifacts.Research{ID: "r1", FeatureOrTask: "sessions", ConfidenceScore: 0.4}
+		if err := artifacts.SaveArtifact(tmpDir, artifacts.ArtifactResearch, research); err != nil {
+			t.Fatal(err)
+		}
+		if text, isError := callTool(t, tmpDir, "mark_research_complete", `{}`); !isError || !strings.Contains(text, "40%") {
+			t.Errorf("mark_research_complete = %q, %v, want it refused for low confidence", text, isError)
+		}
+
+		research.ConfidenceScore = 0.8
+		if err := artifacts.SaveArtifact(tmpDir, artifacts.ArtifactResearch, research); err != nil {
+			t.Fatal(err)
+		}
+		text, isError := callTool(t, tmpDir, "mark_research_complete", `{"summary":"Sessions are stored in Redis"}`)
+		if isError || !strings.Contains(text, "planning") {
+			t.Errorf("mark_research_complete = %q, %v", text, isError)
+		}
+		if state, _ := gates.LoadFICState(tmpDir); !state.ResearchComplete {
+			t.Error("research not marked complete")
+		}
+		if log, _ := progress.Read(tmpDir); !strings.Contains(log, "Research complete: Sessions are stored in Redis") {
+			t.Errorf("progress = %q, want the summary", log)
+		}
+	})
+
+	t.Run("update_feature_status", func(t *testing.T) {
+		text, isError := callTool(t, tmpDir, "update_feature_status", `{"id":"cache","status":"in_progress"}`)
+		if isError || !strings.Contains(text, "now in_progress (was pending)") || !strings.Contains(text, "db") {
+			t.Errorf("update_feature_status = %q, %v, want the change and the unmet prerequisite", text, isError)
+		}
+		text, _ = callTool(t, tmpDir, "update_feature_status", `{"id":"db","status":"passing"}`)
+		if !strings.Contains(text, "now passing") || !strings.Contains(text, "All 1 acceptance criteria passed") {
+			t.Errorf("update_feature_status = %q, want db verified and passing", text)
+		}
+		text, _ = callTool(t, tmpDir, "update_feature_status", `{"id":"api","status":"passing"}`)
+		if !strings.Contains(text, "now failing") || !strings.Contains(text, "Failing acceptance criteria: false") {
+			t.Errorf("update_feature_status = %q, want api failing its criteria", text)
+		}
+
+		saved, err := features.Load(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Find("cache").Status != features.StatusInProgress || saved.Find("db").Status != features.StatusPassing ||
+			saved.Find("api").Status != features.StatusFailing || !saved.Find("db").IsVerified(features.TreeFingerprint(tmpDir)) {
+			t.Errorf("features = %+v", saved.Features)
+		}
+
+		for _, args := range []string{`{"id":"cache","status":"done"}`, `{"id":"nope","status":"passing"}`} {
+			if text, isError := callTool(t, tmpDir, "update_feature_status", args); !isError {
+				t.Errorf("update_feature_status(%s) = %q, want an error", args, text)
+			}
+		}
+	})
+
+	t.Run("update_feature_status strict", func(t *testing.T) {
+		strictDir := setupProject(t)
+		data := &features.FeaturesData{Features: []features.Feature{
+			{ID: "db", Name: "Database", Status: features.StatusPending},
+			{ID: "cache", Name: "Caching", Status: features.StatusPending, DependsOn: []string{"db"}},
+		}}
+		if err := features.Save(strictDir, data); err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Join(strictDir, ".claude"), 0700)
+		os.WriteFile(filepath.Join(strictDir, ".claude", config.ConfigFileName), []byte(`{"strictness": "strict"}`), 0600)
+
+		for _, status := range []string{features.StatusInProgress, features.StatusPassing} {
+			text, isError := callTool(t, strictDir, "update_feature_status", `{"id":"cache","status":"`+status+`"}`)
+			if !isError || !strings.Contains(text, "db") {
+				t.Errorf("update_feature_status(cache, %s) = %q, %v, want it refused for the unmet prerequisite", status, text, isError)
+			}
+		}
+		if saved, _ := features.Load(strictDir); saved.Find("cache").Status != features.StatusPending {
+			t.Errorf("cache status = %s, want it unchanged", saved.Find("cache").Status)
+		}
+	})
+
+	t.Run("append_progress", func(t *testing.T) {
+		if text, isError := callTool(t, tmpDir, "append_progress", `{"message":"  Added the retry loop "}`); isError {
+			t.Errorf("append_progress = %q, want success", text)
+		}
+		if log, _ := progress.Read(tmpDir); !strings.Contains(log, "] Added the retry loop\n") {
+			t.Errorf("progress = %q", log)
+		}
+		if _, isError := callTool(t, tmpDir, "append_progress", `{"message":" "}`); !isError {
+			t.Error("append_progress with an empty message succeeded")
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"encoding/json"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/features"
+	"ultraharness/internal/gates"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/progress"
+)
+
+// resource is a piece of harness state a client can read.
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MimeType    string `json:"mimeType"`
+	read        func(workDir string) (string, error)
+}
+
+// resources lists the harness state served, by URI.
+var resources = []resource{
+	{"ultraharness://phase", "phase", "Current FIC workflow phase, its details, and which gates are open", "application/json", readPhase},
+	{"ultraharness://research", "research", "Latest research artifact: discoveries, open questions, and confidence", "application/json", latestArtifact(artifacts.ArtifactResearch)},
+	{"ultraharness://plan", "plan", "Latest plan artifact: goal, steps, and validation", "application/json", latestArtifact(artifacts.ArtifactPlan)},
+	{"ultraharness://features", "features", "Feature checklist from " + features.FeaturesFile, "application/json", readFeatures},
+	{"ultraharness://knowledge", "knowledge", "Project knowledge base of discoveries from past tasks", "application/json", readKnowledge},
+	{"ultraharness://progress", "progress", "Progress log from " + progress.ProgressFileName, "text/plain", progress.Read},
+}
+
+func (s *Server) readResource(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, errorf(codeInvalidParams, "invalid resources/read params: %v", err)
+	}
+
+	for _, r := range resources {
+		if r.URI != p.URI {
+			continue
+		}
+		text, err := r.read(s.WorkDir)
+		if err != nil {
+			return nil, errorf(codeInternalError, "failed to read %s: %v", r.URI, err)
+		}
+		return map[string]interface{}{
+			"contents": []map[string]string{{"uri": r.URI, "mimeType": r.MimeType, "text": text}},
+		}, nil
+	}
+	return nil, errorf(codeResourceNotFound, "unknown resource %q", p.URI)
+}
+
+// readPhase combines the phase derived from the artifacts with the gate
+// state, which can disagree when research or the plan was marked done
+// by hand.
+func readPhase(workDir string) (string, error) {
+	state, err := gates.LoadFICState(workDir)
+	if err != nil {
+		return "", err
+	}
+	info := artifacts.GetPhaseInfo(workDir)
+	info["research_complete"] = state.ResearchComplete
+	info["plan_validated"] = state.PlanValidated
+	return marshal(info)
+}
+
+// latestArtifact reads the latest artifact of a type, or null if there is
+// none.
+func latestArtifact(artifactType artifacts.ArtifactType) func(string) (string, error) {
+	return func(workDir string) (string, error) {
+		artifact, err := artifacts.GetLatestArtifact(workDir, artifactType)
+		if err != nil {
+			return "", err
+		}
+		return marshal(artifact)
+	}
+}
+
+func readFeatures(workDir string) (string, error) {
+	if !features.Exists(workDir) {
+		return marshal(&features.FeaturesData{Features: []features.Feature{}})
+	}
+	data, err := features.Load(workDir)
+	if err != nil {
+		return "", err
+	}
+	return marshal(data)
+}
+
+func readKnowledge(workDir string) (string, error) {
+	base, err := knowledge.Load(workDir)
+	if err != nil {
+		return "", err
+	}
+	if base.Facts == nil {
+		base.Facts = []knowledge.Fact{}
+	}
+	return marshal(base)
+}
+
+func marshal(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	return string(data), err
+}
@@ -0,0 +1,218 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+	"ultraharness/internal/gates"
+	"ultraharness/internal/linear"
+	"ultraharness/internal/progress"
+)
+
+// tool is an action a client can take on the harness state.
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	call        func(workDir string, args json.RawMessage) (string, error)
+}
+
+// tools lists the actions served, by name.
+var tools = []tool{
+	{
+		Name:        "mark_research_complete",
+		Description: "Mark the research phase complete, opening the research gate so planning can begin. Research must be at least 70% confident with no blocking open questions.",
+		InputSchema: object(map[string]interface{}{
+			"summary": property("string", "What the research established, recorded in the progress log"),
+		}),
+		call: markResearchComplete,
+	},
+	{
+		Name:        "update_feature_status",
+		Description: "Set a feature's status in the feature checklist. Setting passing on a feature with acceptance criteria runs them, and the feature passes only if they do. In strict mode a feature cannot start or pass before its prerequisites pass.",
+		InputSchema: object(map[string]interface{}{
+			"id": property("string", "Feature ID"),
+			"status": map[string]interface{}{
+				"type":        "string",
+				"description": "New status",
+				"enum":        []string{features.StatusPending, features.StatusInProgress, features.StatusPassing, features.StatusFailing},
+			},
+		}, "id", "status"),
+		call: updateFeatureStatus,
+	},
+	{
+		Name:        "append_progress",
+		Description: "Append a timestamped entry to the progress log, which survives compaction and new sessions.",
+		InputSchema: object(map[string]interface{}{
+			"message": property("string", "Progress entry"),
+		}, "message"),
+		call: appendProgress,
+	},
+}
+
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func property(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+// callTool runs a tool. A tool that fails reports its error in the result,
+// for the model to see, rather than as a protocol error.
+func (s *Server) callTool(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, errorf(codeInvalidParams, "invalid tools/call params: %v", err)
+	}
+	if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+		p.Arguments = json.RawMessage("{}")
+	}
+
+	for _, t := range tools {
+		if t.Name != p.Name {
+			continue
+		}
+		text, err := t.call(s.WorkDir, p.Arguments)
+		if err != nil {
+			text = err.Error()
+		}
+		return map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": text}},
+			"isError": err != nil,
+		}, nil
+	}
+	return nil, errorf(codeInvalidParams, "unknown tool %q", p.Name)
+}
+
+func markResearchComplete(workDir string, args json.RawMessage) (string, error) {
+	var a struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	c, err := gates.CompleteResearch(workDir, false)
+	if errors.Is(err, gates.ErrNotReady) {
+		return "", fmt.Errorf("%v. Keep researching, or ask the user to run /fic-research-done -force", err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to update %s: %w", gates.FICStateFileName, err)
+	}
+	if summary := strings.TrimSpace(a.Summary); summary != "" {
+		if err := progress.Append(progress.SourceAgent, "Research complete: "+summary, workDir); err != nil {
+			return "", fmt.Errorf("research marked complete, but failed to record the summary: %w", err)
+		}
+	}
+
+	msg := fmt.Sprintf("Research marked complete; the workflow is in the %s phase.", c.State.Phase)
+	if !c.State.PlanValidated {
+		msg += " Edits stay gated until the plan is validated."
+	}
+	if move, err := linear.Follow(workDir, c.State.Phase); err != nil {
+		msg += fmt.Sprintf(" Could not update the Linear issue: %v.", err)
+	} else if move != nil {
+		msg += " " + move.String() + "."
+	}
+	return msg, nil
+}
+
+func updateFeatureStatus(workDir string, args json.RawMessage) (string, error) {
+	var a struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	switch a.Status {
+	case features.StatusPending, features.StatusInProgress, features.StatusPassing, features.StatusFailing:
+	default:
+		return "", fmt.Errorf("invalid status %q (want pending, in_progress, passing, or failing)", a.Status)
+	}
+
+	data, err := features.Load(workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", features.FeaturesFile, err)
+	}
+	f := data.Find(a.ID)
+	if f == nil {
+		return "", fmt.Errorf("no feature %q in %s", a.ID, features.FeaturesFile)
+	}
+
+	// The same dependency rule PreToolUse applies to edits of the checklist,
+	// as a refusal since there is no user to confirm with here
+	cfg, err := config.Load(workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", config.ConfigFileName, err)
+	}
+	var unmet []string
+	if a.Status == features.StatusInProgress || a.Status == features.StatusPassing {
+		unmet = data.UnmetDependencies(*f)
+	}
+	if len(unmet) > 0 && cfg.FeatureEnforcement && cfg.IsStrictMode() && f.Status != a.Status {
+		return "", fmt.Errorf("feature %s depends on %s, which must pass first in strict mode", f.ID, strings.Join(unmet, ", "))
+	}
+
+	// Acceptance criteria decide whether a feature passes
+	previous := f.Status
+	var verification *features.Verification
+	if a.Status == features.StatusPassing && len(f.AcceptanceCriteria) > 0 {
+		verification = features.Verify(workDir, f, 0)
+	} else {
+		f.Status = a.Status
+	}
+	if err := features.Save(workDir, data); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", features.FeaturesFile, err)
+	}
+
+	msg := fmt.Sprintf("Feature %s (%s) is now %s (was %s).", f.ID, f.Name, f.Status, previous)
+	if verification != nil {
+		progress.Append(progress.SourceAgent, "VERIFIED: "+f.ID+" "+f.Status, workDir)
+		var failed []string
+		for _, r := range verification.Results {
+			if !r.Passed {
+				failed = append(failed, r.Criterion)
+			}
+		}
+		if len(failed) == 0 {
+			msg += fmt.Sprintf(" All %d acceptance criteria passed.", len(verification.Results))
+		} else {
+			msg += fmt.Sprintf(" Failing acceptance criteria: %s. Run verify_feature %s for their output.",
+				strings.Join(failed, "; "), f.ID)
+		}
+	}
+	if len(unmet) > 0 {
+		msg += fmt.Sprintf(" Its prerequisites are not passing yet: %s.", strings.Join(unmet, ", "))
+	}
+	return msg, nil
+}
+
+func appendProgress(workDir string, args json.RawMessage) (string, error) {
+	var a struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	message := strings.TrimSpace(a.Message)
+	if message == "" {
+		return "", fmt.Errorf("message is empty")
+	}
+	if err := progress.Append(progress.SourceAgent, message, workDir); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", progress.ProgressFileName, err)
+	}
+	return "Recorded in " + progress.ProgressFileName + ".", nil
+}
@@ -0,0 +1,141 @@
+// Package migrations recognizes database migration files and checks them:
+// whether a migration has a rollback, and whether it was already applied
+// and so must not be edited.
+//
+// A migration is applied if it is listed in the configured marker file
+// (one file name, name without extension, or version per line, e.g.
+// exported from schema_migrations), or if its version is at or below the
+// configured applied_through version. The version is the leading number
+// of the file name or, for migrations kept in a directory each, of the
+// directory name: "20240105120000_add_users.sql", "V3__init.sql", and
+// "0007_auto.py" have versions 20240105120000, 3, and 0007.
+package migrations
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/glob"
+)
+
+// extensions are the file types migration tools write; other files in a
+// migration directory, such as a README, are not migrations.
+var extensions = map[string]bool{
+	".sql": true, ".py": true, ".rb": true, ".js": true, ".ts": true,
+	".go": true, ".php": true, ".exs": true,
+}
+
+// IsMigration reports whether rel, relative to the project root, is a
+// migration file under one of the configured paths.
+func IsMigration(settings config.MigrationsConfig, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := path.Base(rel)
+	if !extensions[strings.ToLower(path.Ext(base))] || base == "__init__.py" {
+		return false
+	}
+	return glob.MatchAny(settings.Paths, rel)
+}
+
+var versionPattern = regexp.MustCompile(`^[VvUu]?(\d+)`)
+
+// Version returns the version of the migration at rel, or "" if neither
+// its name nor its directory starts with one.
+func Version(rel string) string {
+	rel = filepath.ToSlash(rel)
+	for _, name := range []string{path.Base(rel), path.Base(path.Dir(rel))} {
+		if m := versionPattern.FindStringSubmatch(name); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// compareVersions compares two numeric versions of any length.
+func compareVersions(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	switch {
+	case len(a) != len(b):
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Applied returns why the migration at rel counts as applied, or "" if it
+// does not.
+func Applied(workDir string, settings config.MigrationsConfig, rel string) string {
+	version := Version(rel)
+	if settings.AppliedThrough != "" && version != "" && compareVersions(version, strings.TrimLeft(settings.AppliedThrough, "Vv")) <= 0 {
+		return "its version " + version + " is at or below applied_through " + settings.AppliedThrough
+	}
+
+	if settings.AppliedMarker == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, settings.AppliedMarker))
+	if err != nil {
+		return ""
+	}
+	base := path.Base(filepath.ToSlash(rel))
+	names := map[string]bool{base: true, strings.TrimSuffix(base, path.Ext(base)): true}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if names[line] || (version != "" && compareVersions(line, version) == 0 && versionPattern.MatchString(line)) {
+			return "it is listed in " + settings.AppliedMarker
+		}
+	}
+	return ""
+}
+
+// rollbackPattern matches the down sections of common migration tools:
+// goose and sql-migrate annotations, Rails and Alembic methods, knex and
+// Sequelize exports, golang-migrate style Go functions, Liquibase rollback
+// comments, and the reversible Rails change method and Django migrations.
+var rollbackPattern = regexp.MustCompile(`(?im)` +
+	`^\s*--\s*\+(?:goose|migrate)\s+down\b` +
+	`|\bdef\s+(?:down|downgrade|change)\b` +
+	`|\bexports\.down\b|\bdown\s*[:(]|\basync\s+down\b` +
+	`|\bfunc\s+\w*[Dd]own\w*\s*\(` +
+	`|^\s*--\s*rollback\b` +
+	`|\bmigrations\.Migration\b`)
+
+// HasRollback reports whether the migration at rel, with the given
+// content, can be rolled back: it has a down section, it is itself a down
+// migration, or its down migration exists next to it (name.down.sql for
+// name.up.sql, and Flyway's U<version>__ undo file for V<version>__).
+func HasRollback(workDir, rel, content string) bool {
+	rel = filepath.ToSlash(rel)
+	dir, base := path.Dir(rel), path.Base(rel)
+	lower := strings.ToLower(base)
+
+	if strings.Contains(lower, ".down.") || strings.HasPrefix(base, "U") && versionPattern.MatchString(base) {
+		return true
+	}
+	var sibling string
+	switch {
+	case strings.Contains(lower, ".up."):
+		i := strings.Index(lower, ".up.")
+		sibling = base[:i] + ".down." + base[i+len(".up."):]
+	case strings.HasPrefix(base, "V") && strings.Contains(base, "__") && versionPattern.MatchString(base):
+		sibling = "U" + base[1:]
+	}
+	if sibling != "" {
+		if _, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(path.Join(dir, sibling)))); err == nil {
+			return true
+		}
+	}
+	return rollbackPattern.MatchString(content)
+}
@@ -0,0 +1,131 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/config"
+)
+
+func TestIsMigration(t *testing.T) {
+	settings := config.DefaultConfig().GetMigrations()
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"migrations/0001_init.sql", true},
+		{"app/users/migrations/0002_auto.py", true},
+		{"db/migrate/20240105120000_add_users.rb", true},
+		{"alembic/versions/abc123_add_index.py", true},
+		{"migrations/README.md", false},
+		{"app/migrations/__init__.py", false},
+		{"internal/db/queries.sql", false},
+	}
+	for _, tt := range tests {
+		if got := IsMigration(settings, tt.path); got != tt.want {
+			t.Errorf("IsMigration(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	custom := config.MigrationsConfig{Paths: []string{"schema/changes/*.sql"}}
+	if !IsMigration(custom, "schema/changes/004.sql") || IsMigration(custom, "migrations/0001_init.sql") {
+		t.Error("IsMigration() did not use the configured paths")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := map[string]string{
+		"db/migrate/20240105120000_add_users.rb":        "20240105120000",
+		"sql/V3__init.sql":                              "3",
+		"app/migrations/0007_auto.py":                   "0007",
+		"prisma/migrations/20240101_init/migration.sql": "20240101",
+		"alembic/versions/abc123_add_index.py":          "",
+	}
+	for path, want := range tests {
+		if got := Version(path); got != want {
+			t.Errorf("Version(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestApplied(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "migrations-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	marker := "# exported from schema_migrations\n0001_init\n20240105120000\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "applied.txt"), []byte(marker), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		settings config.MigrationsConfig
+		path     string
+		want     string
+	}{
+		{"nothing configured", config.MigrationsConfig{}, "migrations/0001_init.sql", ""},
+		{"listed by name", config.MigrationsConfig{AppliedMarker: "applied.txt"}, "migrations/0001_init.sql", "listed in applied.txt"},
+		{"listed by version", config.MigrationsConfig{AppliedMarker: "applied.txt"}, "db/migrate/20240105120000_add_users.rb", "listed in applied.txt"},
+		{"not listed", config.MigrationsConfig{AppliedMarker: "applied.txt"}, "migrations/0002_users.sql", ""},
+		{"missing marker", config.MigrationsConfig{AppliedMarker: "nope.txt"}, "migrations/0001_init.sql", ""},
+		{"through a version", config.MigrationsConfig{AppliedThrough: "12"}, "migrations/0009_index.sql", "at or below applied_through 12"},
+		{"after the version", config.MigrationsConfig{AppliedThrough: "12"}, "migrations/0100_index.sql", ""},
+		{"flyway version", config.MigrationsConfig{AppliedThrough: "V3"}, "sql/V3__init.sql", "at or below"},
+		{"no version", config.MigrationsConfig{AppliedThrough: "12"}, "alembic/versions/abc123_add_index.py", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Applied(tmpDir, tt.settings, tt.path)
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("Applied(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasRollback(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "migrations-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, name := range []string{"migrations/0002_users.down.sql", "sql/U4__index.sql"} {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, path, content string
+		want                bool
+	}{
+		{"plain sql", "migrations/0001_init.sql", "CREATE TABLE users (id int);", false},
+		{"goose", "migrations/0001_init.sql", "-- +goose Up\nCREATE TABLE users (id int);\n-- +goose Down\nDROP TABLE users;", true},
+		{"goose up only", "migrations/0001_init.sql", "-- +goose Up\nCREATE TABLE users (id int);", false},
+		{"rails", "db/migrate/1_add_users.rb", "class AddUsers < ActiveRecord::Migration[7.0]\n  def up\n  end\n  def down\n  end\nend", true},
+		{"rails change", "db/migrate/1_add_users.rb", "class AddUsers < ActiveRecord::Migration[7.0]\n  def change\n  end\nend", true},
+		{"alembic", "alembic/versions/a1_users.py", "def upgrade():\n    pass\n\ndef downgrade():\n    pass", true},
+		{"alembic upgrade only", "alembic/versions/a1_users.py", "def upgrade():\n    pass", false},
+		{"django", "app/migrations/0002_auto.py", "class Migration(migrations.Migration):\n    operations = []", true},
+		{"knex", "migrations/1_users.js", "exports.up = function(knex) {};\nexports.down = function(knex) {};", true},
+		{"go", "migrations/00001_users.go", "func upUsers(tx *sql.Tx) error { return nil }\nfunc downUsers(tx *sql.Tx) error { return nil }", true},
+		{"up with down file", "migrations/0002_users.up.sql", "CREATE TABLE users (id int);", true},
+		{"up without down file", "migrations/0003_posts.up.sql", "CREATE TABLE posts (id int);", false},
+		{"down file itself", "migrations/0003_posts.down.sql", "DROP TABLE posts;", true},
+		{"flyway with undo", "sql/V4__index.sql", "CREATE INDEX i ON users (id);", true},
+		{"flyway without undo", "sql/V5__index.sql", "CREATE INDEX j ON users (id);", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasRollback(tmpDir, tt.path, tt.content); got != tt.want {
+				t.Errorf("HasRollback(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package plugincheck
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Events are the hook events Claude Code runs hooks for.
+var Events = []string{
+	"PreToolUse", "PostToolUse", "UserPromptSubmit", "Notification",
+	"Stop", "SubagentStop", "PreCompact", "SessionStart", "SessionEnd",
+}
+
+// toolEvents are the events whose matcher selects tools
+var toolEvents = map[string]bool{"PreToolUse": true, "PostToolUse": true}
+
+// interpreters run the script named by their first argument
+var interpreters = map[string]bool{
+	"bash": true, "sh": true, "zsh": true, "node": true, "deno": true, "bun": true,
+	"python": true, "python3": true, "ruby": true, "perl": true,
+}
+
+// hooksConfig is the structure of hooks/hooks.json.
+type hooksConfig struct {
+	Description string                   `json:"description"`
+	Hooks       map[string][]hookMatcher `json:"hooks"`
+}
+
+type hookMatcher struct {
+	Matcher string      `json:"matcher"`
+	Hooks   []hookEntry `json:"hooks"`
+}
+
+type hookEntry struct {
+	Type    string   `json:"type"`
+	Command string   `json:"command"`
+	Prompt  string   `json:"prompt"`
+	Timeout *float64 `json:"timeout"`
+}
+
+// checkHooks checks the hook registrations: known events, valid matchers,
+// positive timeouts, and commands that run scripts the plugin ships.
+func checkHooks(r *Report, dir, prefix string) {
+	path := filepath.Join(prefix, HooksFile)
+	data, err := os.ReadFile(filepath.Join(dir, HooksFile))
+	if err != nil {
+		// Hooks are optional
+		return
+	}
+	var cfg hooksConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		r.errorf(path, "invalid JSON: %v", err)
+		return
+	}
+	if len(cfg.Hooks) == 0 {
+		r.warnf(path, "no hooks registered")
+	}
+
+	known := map[string]bool{}
+	for _, e := range Events {
+		known[e] = true
+	}
+	events := make([]string, 0, len(cfg.Hooks))
+	for event := range cfg.Hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		matchers := cfg.Hooks[event]
+		if !known[event] {
+			r.errorf(path, "unknown hook event %q (want one of %s)", event, strings.Join(Events, ", "))
+			continue
+		}
+		for _, m := range matchers {
+			if toolEvents[event] && m.Matcher != "" && m.Matcher != "*" {
+				if _, err := regexp.Compile(m.Matcher); err != nil {
+					r.errorf(path, "%s: invalid matcher %q: %v", event, m.Matcher, err)
+				}
+			}
+			if len(m.Hooks) == 0 {
+				r.errorf(path, "%s: matcher %q has no hooks", event, m.Matcher)
+			}
+			for _, h := range m.Hooks {
+				checkHook(r, dir, path, event, h)
+			}
+		}
+	}
+}
+
+func checkHook(r *Report, dir, path, event string, h hookEntry) {
+	if h.Timeout != nil && *h.Timeout <= 0 {
+		r.errorf(path, "%s: timeout must be positive, got %v", event, *h.Timeout)
+	}
+	switch h.Type {
+	case "command":
+		if strings.TrimSpace(h.Command) == "" {
+			r.errorf(path, "%s: command hook has no command", event)
+			return
+		}
+	case "prompt":
+		if strings.TrimSpace(h.Prompt) == "" {
+			r.errorf(path, "%s: prompt hook has no prompt", event)
+		}
+		return
+	default:
+		r.errorf(path, `%s: hook type %q must be "command" or "prompt"`, event, h.Type)
+		return
+	}
+
+	script, interpreted := commandScript(h.Command)
+	if !strings.Contains(script, PluginRootVar) {
+		// Found on PATH, or outside the plugin; nothing to check
+		return
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(script, PluginRootVar), "/")
+	info, err := os.Stat(filepath.Join(dir, rel))
+	switch {
+	case err != nil:
+		r.errorf(path, "%s: command runs %s, which does not exist", event, rel)
+	case info.IsDir():
+		r.errorf(path, "%s: command runs %s, which is a directory", event, rel)
+	case !interpreted && info.Mode()&0111 == 0:
+		r.errorf(path, "%s: command runs %s, which is not executable (chmod +x)", event, rel)
+	}
+}
+
+// commandScript returns the script a hook command runs: its first word, or
+// the script passed to an interpreter, with quotes removed.
+func commandScript(command string) (string, bool) {
+	fields := strings.Fields(command)
+	unquote := func(s string) string { return strings.Trim(s, `"'`) }
+	if len(fields) == 0 {
+		return "", false
+	}
+	first := unquote(fields[0])
+	if !interpreters[filepath.Base(first)] {
+		return first, false
+	}
+	for _, f := range fields[1:] {
+		if !strings.HasPrefix(f, "-") {
+			return unquote(f), true
+		}
+	}
+	return "", true
+}
@@ -0,0 +1,125 @@
+package plugincheck
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// commandKeys are the frontmatter keys Claude Code reads from commands
+var commandKeys = map[string]bool{
+	"description": true, "argument-hint": true, "allowed-tools": true,
+	"model": true, "disable-model-invocation": true,
+}
+
+// agentKeys are the frontmatter keys Claude Code reads from agents
+var agentKeys = map[string]bool{
+	"name": true, "description": true, "tools": true, "model": true, "color": true,
+}
+
+// frontmatter is a Markdown file's YAML frontmatter as flat key: value
+// pairs.
+type frontmatter struct {
+	// present is false if the file has no frontmatter
+	present bool
+	fields  map[string]string
+	// unterminated is true if the closing --- is missing
+	unterminated bool
+}
+
+// parseFrontmatter reads the frontmatter between the leading --- lines.
+// Nested YAML is not interpreted; only top-level keys are recorded.
+func parseFrontmatter(content string) frontmatter {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return frontmatter{}
+	}
+	fm := frontmatter{present: true, fields: map[string]string{}}
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "---" {
+			return fm
+		}
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fm.fields[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	fm.unterminated = true
+	return fm
+}
+
+// checkMarkdown runs check on each Markdown file in the plugin's subdir.
+func checkMarkdown(r *Report, dir, prefix, subdir string, check func(r *Report, path string, fm frontmatter, body string)) {
+	matches, _ := filepath.Glob(filepath.Join(dir, subdir, "*.md"))
+	sort.Strings(matches)
+	for _, file := range matches {
+		path := filepath.Join(prefix, subdir, filepath.Base(file))
+		data, err := os.ReadFile(file)
+		if err != nil {
+			r.errorf(path, "unreadable: %v", err)
+			continue
+		}
+		content := string(data)
+		fm := parseFrontmatter(content)
+		if fm.unterminated {
+			r.errorf(path, "frontmatter is missing its closing ---")
+			continue
+		}
+		check(r, path, fm, content)
+	}
+}
+
+func checkCommand(r *Report, path string, fm frontmatter, content string) {
+	if strings.TrimSpace(content) == "" {
+		r.errorf(path, "command is empty")
+		return
+	}
+	if !fm.present {
+		r.warnf(path, "no frontmatter; add a description so the command list explains it")
+		return
+	}
+	if fm.fields["description"] == "" {
+		r.warnf(path, "frontmatter has no description; the command list shows it")
+	}
+	checkKeys(r, path, fm, commandKeys)
+}
+
+func checkAgent(r *Report, path string, fm frontmatter, content string) {
+	if strings.TrimSpace(content) == "" {
+		r.errorf(path, "agent definition is empty")
+		return
+	}
+	if !fm.present {
+		r.warnf(path, "no frontmatter; Claude Code only registers agents with a name and description")
+		return
+	}
+	name := fm.fields["name"]
+	switch {
+	case name == "":
+		r.errorf(path, "frontmatter has no name")
+	case !namePattern.MatchString(name):
+		r.errorf(path, "name %q must be kebab-case", name)
+	}
+	if fm.fields["description"] == "" {
+		r.errorf(path, "frontmatter has no description; it tells Claude when to use the agent")
+	}
+	checkKeys(r, path, fm, agentKeys)
+}
+
+// checkKeys warns about frontmatter keys Claude Code does not read, which
+// are usually typos.
+func checkKeys(r *Report, path string, fm frontmatter, known map[string]bool) {
+	keys := make([]string, 0, len(fm.fields))
+	for key := range fm.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] {
+			r.warnf(path, "unknown frontmatter key %q", key)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package plugincheck validates Claude Code plugins and the marketplace
+// that lists them: the plugin.json manifest, hook registrations and the
+// scripts they run, command frontmatter, and agent definitions. It only
+// reads files, so any plugin in the marketplace can be checked, whatever
+// language its hooks are written in.
+package plugincheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severities of problems
+const (
+	Error   = "ERROR"
+	Warning = "WARNING"
+)
+
+// Locations of plugin and marketplace files
+const (
+	ManifestFile    = ".claude-plugin/plugin.json"
+	MarketplaceFile = ".claude-plugin/marketplace.json"
+	HooksFile       = "hooks/hooks.json"
+	CommandsDir     = "commands"
+	AgentsDir       = "agents"
+)
+
+// PluginRootVar is replaced with the plugin directory in hook commands
+const PluginRootVar = "${CLAUDE_PLUGIN_ROOT}"
+
+// Problem is one thing wrong with a plugin.
+type Problem struct {
+	Severity string
+	// Path is the file, relative to the directory being checked
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s %s: %s", p.Severity, p.Path, p.Message)
+}
+
+// Report collects the problems found in a plugin or marketplace.
+type Report struct {
+	Problems []Problem
+}
+
+func (r *Report) errorf(path, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, Problem{Error, filepath.ToSlash(path), fmt.Sprintf(format, args...)})
+}
+
+func (r *Report) warnf(path, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, Problem{Warning, filepath.ToSlash(path), fmt.Sprintf(format, args...)})
+}
+
+// Errors returns the number of problems that are errors.
+func (r *Report) Errors() int {
+	n := 0
+	for _, p := range r.Problems {
+		if p.Severity == Error {
+			n++
+		}
+	}
+	return n
+}
+
+// Warnings returns the number of problems that are warnings.
+func (r *Report) Warnings() int {
+	return len(r.Problems) - r.Errors()
+}
+
+// sort orders the problems by path, errors first within a file.
+func (r *Report) sort() {
+	sort.SliceStable(r.Problems, func(i, j int) bool {
+		a, b := r.Problems[i], r.Problems[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Severity == Error && b.Severity != Error
+	})
+}
+
+var (
+	namePattern    = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	versionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+// manifest is the part of plugin.json that is checked.
+type manifest struct {
+	Name        string          `json:"name"`
+	Version     string          `json:"version"`
+	Description string          `json:"description"`
+	Author      json.RawMessage `json:"author"`
+	// Component paths that replace the default directories
+	Commands json.RawMessage `json:"commands"`
+	Agents   json.RawMessage `json:"agents"`
+	Hooks    json.RawMessage `json:"hooks"`
+}
+
+// IsMarketplace reports whether dir is a marketplace root rather than a
+// plugin.
+func IsMarketplace(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, MarketplaceFile))
+	return err == nil
+}
+
+// Plugin checks the plugin in dir.
+func Plugin(dir string) *Report {
+	r := &Report{}
+	checkPlugin(r, dir, "")
+	r.sort()
+	return r
+}
+
+// checkPlugin checks the plugin in dir, reporting paths under prefix, and
+// returns its manifest name.
+func checkPlugin(r *Report, dir, prefix string) string {
+	m := checkManifest(r, dir, prefix)
+	checkHooks(r, dir, prefix)
+	checkMarkdown(r, dir, prefix, CommandsDir, checkCommand)
+	checkMarkdown(r, dir, prefix, AgentsDir, checkAgent)
+	if m == nil {
+		return ""
+	}
+	return m.Name
+}
+
+func checkManifest(r *Report, dir, prefix string) *manifest {
+	path := filepath.Join(prefix, ManifestFile)
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		r.errorf(path, "missing plugin manifest")
+		return nil
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		r.errorf(path, "invalid JSON: %v", err)
+		return nil
+	}
+
+	switch {
+	case m.Name == "":
+		r.errorf(path, "name is required")
+	case !namePattern.MatchString(m.Name):
+		r.errorf(path, "name %q must be kebab-case: lowercase letters, digits, and dashes", m.Name)
+	}
+	if m.Version != "" && !versionPattern.MatchString(m.Version) {
+		r.errorf(path, "version %q is not a semantic version such as 1.2.0", m.Version)
+	}
+	if strings.TrimSpace(m.Description) == "" {
+		r.warnf(path, "description is empty; the marketplace shows it when browsing plugins")
+	}
+	if len(m.Author) > 0 {
+		var author struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(m.Author, &author); err != nil || author.Name == "" {
+			r.errorf(path, `author must be an object with a name, e.g. {"name": "Jane Doe"}`)
+		}
+	}
+
+	components := []struct {
+		field string
+		raw   json.RawMessage
+	}{{"commands", m.Commands}, {"agents", m.Agents}, {"hooks", m.Hooks}}
+	for _, c := range components {
+		var p string
+		if len(c.raw) == 0 || json.Unmarshal(c.raw, &p) != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			r.errorf(path, "%s path %q does not exist", c.field, p)
+		}
+	}
+	return &m
+}
+
+// marketplace is the part of marketplace.json that is checked.
+type marketplace struct {
+	Name  string `json:"name"`
+	Owner *struct {
+		Name string `json:"name"`
+	} `json:"owner"`
+	Plugins []struct {
+		Name   string          `json:"name"`
+		Source json.RawMessage `json:"source"`
+	} `json:"plugins"`
+}
+
+// Marketplace checks the marketplace manifest in root and every plugin it
+// lists with a local source.
+func Marketplace(root string) *Report {
+	r := &Report{}
+	data, err := os.ReadFile(filepath.Join(root, MarketplaceFile))
+	if err != nil {
+		r.errorf(MarketplaceFile, "missing marketplace manifest")
+		return r
+	}
+	var m marketplace
+	if err := json.Unmarshal(data, &m); err != nil {
+		r.errorf(MarketplaceFile, "invalid JSON: %v", err)
+		return r
+	}
+
+	if !namePattern.MatchString(m.Name) {
+		r.errorf(MarketplaceFile, "name %q must be kebab-case", m.Name)
+	}
+	if m.Owner == nil || m.Owner.Name == "" {
+		r.errorf(MarketplaceFile, "owner.name is required")
+	}
+	if len(m.Plugins) == 0 {
+		r.warnf(MarketplaceFile, "no plugins listed")
+	}
+
+	seen := map[string]bool{}
+	for i, p := range m.Plugins {
+		entry := fmt.Sprintf("plugins[%d]", i)
+		if p.Name == "" {
+			r.errorf(MarketplaceFile, "%s: name is required", entry)
+		} else if seen[p.Name] {
+			r.errorf(MarketplaceFile, "%s: duplicate plugin name %q", entry, p.Name)
+		}
+		seen[p.Name] = true
+
+		var source string
+		if len(p.Source) == 0 {
+			r.errorf(MarketplaceFile, "%s: source is required", entry)
+			continue
+		}
+		if json.Unmarshal(p.Source, &source) != nil {
+			// A GitHub or git source is fetched at install time
+			continue
+		}
+		dir := filepath.Join(root, source)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			r.errorf(MarketplaceFile, "%s: source %q is not a directory", entry, source)
+			continue
+		}
+		if name := checkPlugin(r, dir, filepath.Clean(source)); name != "" && p.Name != "" && name != p.Name {
+			r.errorf(MarketplaceFile, "%s: name %q does not match %q in %s", entry, p.Name, name,
+				filepath.ToSlash(filepath.Join(filepath.Clean(source), ManifestFile)))
+		}
+	}
+	r.sort()
+	return r
+}
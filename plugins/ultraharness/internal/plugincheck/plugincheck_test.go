@@ -0,0 +1,230 @@
+package plugincheck
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files under dir; a name ending in "*" is executable.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		perm := os.FileMode(0644)
+		if strings.HasSuffix(name, "*") {
+			name, perm = strings.TrimSuffix(name, "*"), 0755
+		}
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// validPlugin is a plugin with nothing to report.
+func validPlugin() map[string]string {
+	return map[string]string{
+		ManifestFile: `{"name": "demo", "version": "1.0.0", "description": "Demo", "author": {"name": "A"}}`,
+		HooksFile: `{"hooks": {"PreToolUse": [{"matcher": "Edit|Write", "hooks": [
+			{"type": "command", "command": "${CLAUDE_PLUGIN_ROOT}/bin/run-hook pre_tool_use", "timeout": 5}]}],
+			"Stop": [{"hooks": [{"type": "command", "command": "python3 ${CLAUDE_PLUGIN_ROOT}/hooks/stop.py"}]}]}}`,
+		"bin/run-hook*":     "#!/bin/sh\n",
+		"hooks/stop.py":     "print()\n",
+		"commands/go.md":    "---\ndescription: Go\nargument-hint: [target]\n---\n\n# Go\n",
+		"agents/helper.md":  "---\nname: helper\ndescription: Helps\ntools: Read, Grep\n---\n\nYou help.\n",
+		"agents/notes.txt":  "not an agent",
+		"commands/README":   "not a command",
+		"hooks/unused.json": "{}",
+	}
+}
+
+// problems returns the report's problems as strings.
+func problems(r *Report) []string {
+	var out []string
+	for _, p := range r.Problems {
+		out = append(out, p.String())
+	}
+	return out
+}
+
+func TestPlugin(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		// want are substrings of the problems expected, in order
+		want []string
+	}{
+		{"valid", nil, nil},
+		{
+			"manifest",
+			map[string]string{ManifestFile: `{"name": "Demo_Plugin", "version": "v1", "author": "A", "commands": "./cmds"}`},
+			[]string{
+				`ERROR .claude-plugin/plugin.json: name "Demo_Plugin" must be kebab-case`,
+				`ERROR .claude-plugin/plugin.json: version "v1" is not a semantic version`,
+				`ERROR .claude-plugin/plugin.json: author must be an object`,
+				`ERROR .claude-plugin/plugin.json: commands path "./cmds" does not exist`,
+				`WARNING .claude-plugin/plugin.json: description is empty`,
+			},
+		},
+		{
+			"invalid manifest JSON",
+			map[string]string{ManifestFile: `{"name": `},
+			[]string{"ERROR .claude-plugin/plugin.json: invalid JSON"},
+		},
+		{
+			"hooks",
+			map[string]string{HooksFile: `{"hooks": {
+				"PreToolUse": [{"matcher": "Edit(", "hooks": [{"type": "command", "command": "${CLAUDE_PLUGIN_ROOT}/bin/missing"}]}],
+				"PostToolUse": [{"matcher": "*", "hooks": [{"type": "command", "command": "\"${CLAUDE_PLUGIN_ROOT}/hooks/stop.py\"", "timeout": 0}]}],
+				"BeforeEdit": [{"hooks": [{"type": "command", "command": "true"}]}],
+				"Stop": [{"hooks": [{"type": "script", "command": "true"}, {"type": "prompt"}]}],
+				"SessionStart": [{"hooks": []}]
+			}}`},
+			[]string{
+				`ERROR hooks/hooks.json: unknown hook event "BeforeEdit"`,
+				`ERROR hooks/hooks.json: PostToolUse: timeout must be positive`,
+				`ERROR hooks/hooks.json: PostToolUse: command runs hooks/stop.py, which is not executable`,
+				`ERROR hooks/hooks.json: PreToolUse: invalid matcher "Edit("`,
+				`ERROR hooks/hooks.json: PreToolUse: command runs bin/missing, which does not exist`,
+				`ERROR hooks/hooks.json: SessionStart: matcher "" has no hooks`,
+				`ERROR hooks/hooks.json: Stop: hook type "script" must be "command" or "prompt"`,
+				`ERROR hooks/hooks.json: Stop: prompt hook has no prompt`,
+			},
+		},
+		{
+			"commands",
+			map[string]string{
+				"commands/go.md":   "---\ndescripton: Go\n---\n# Go\n",
+				"commands/bare.md": "# Bare\n",
+				"commands/open.md": "---\ndescription: Open\n# Open\n",
+			},
+			[]string{
+				`WARNING commands/bare.md: no frontmatter`,
+				`WARNING commands/go.md: frontmatter has no description`,
+				`WARNING commands/go.md: unknown frontmatter key "descripton"`,
+				`ERROR commands/open.md: frontmatter is missing its closing ---`,
+			},
+		},
+		{
+			"agents",
+			map[string]string{
+				"agents/helper.md": "---\nname: Helper Agent\n---\nYou help.\n",
+				"agents/plain.md":  "# Plain Agent\n",
+			},
+			[]string{
+				`ERROR agents/helper.md: name "Helper Agent" must be kebab-case`,
+				`ERROR agents/helper.md: frontmatter has no description`,
+				`WARNING agents/plain.md: no frontmatter`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir, err := os.MkdirTemp("", "plugincheck-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpDir)
+			writeFiles(t, tmpDir, validPlugin())
+			writeFiles(t, tmpDir, tt.files)
+
+			got := problems(Plugin(tmpDir))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Plugin() = %q, want %d problems", got, len(tt.want))
+			}
+			// Errors sort before warnings in the same file
+			for i := range tt.want {
+				if !strings.HasPrefix(got[i], tt.want[i]) {
+					t.Errorf("problem %d = %q, want prefix %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("missing manifest", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "plugincheck-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		r := Plugin(tmpDir)
+		if r.Errors() != 1 || r.Problems[0].Message != "missing plugin manifest" {
+			t.Errorf("Plugin() of an empty directory = %q, want a missing manifest", problems(r))
+		}
+	})
+}
+
+func TestMarketplace(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "plugincheck-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFiles(t, filepath.Join(tmpDir, "plugins", "demo"), validPlugin())
+	writeFiles(t, tmpDir, map[string]string{MarketplaceFile: `{
+		"name": "my-plugins",
+		"owner": {"name": "A"},
+		"plugins": [
+			{"name": "demo", "source": "./plugins/demo"},
+			{"name": "renamed", "source": "./plugins/demo"},
+			{"name": "demo", "source": {"source": "github", "repo": "a/b"}},
+			{"name": "gone", "source": "./plugins/gone"},
+			{"source": "./plugins/demo"}
+		]
+	}`})
+
+	if !IsMarketplace(tmpDir) || IsMarketplace(filepath.Join(tmpDir, "plugins", "demo")) {
+		t.Error("IsMarketplace() should only be true for the marketplace root")
+	}
+
+	got := problems(Marketplace(tmpDir))
+	want := []string{
+		`ERROR .claude-plugin/marketplace.json: plugins[1]: name "renamed" does not match "demo" in plugins/demo/.claude-plugin/plugin.json`,
+		`ERROR .claude-plugin/marketplace.json: plugins[2]: duplicate plugin name "demo"`,
+		`ERROR .claude-plugin/marketplace.json: plugins[3]: source "./plugins/gone" is not a directory`,
+		`ERROR .claude-plugin/marketplace.json: plugins[4]: name is required`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Marketplace() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	t.Run("plugin problems", func(t *testing.T) {
+		writeFiles(t, tmpDir, map[string]string{"plugins/demo/agents/bad.md": "---\nname: bad\n---\n"})
+		r := Marketplace(tmpDir)
+		found := false
+		for _, p := range r.Problems {
+			if p.Path == "plugins/demo/agents/bad.md" && p.Severity == Error {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Marketplace() = %q, want the plugin's agent error under its source path", problems(r))
+		}
+	})
+}
+
+func TestCommandScript(t *testing.T) {
+	tests := []struct {
+		command     string
+		script      string
+		interpreted bool
+	}{
+		{"${CLAUDE_PLUGIN_ROOT}/bin/run-hook stop", "${CLAUDE_PLUGIN_ROOT}/bin/run-hook", false},
+		{`"${CLAUDE_PLUGIN_ROOT}/bin/hook"`, "${CLAUDE_PLUGIN_ROOT}/bin/hook", false},
+		{"python3 ${CLAUDE_PLUGIN_ROOT}/hooks/stop.py", "${CLAUDE_PLUGIN_ROOT}/hooks/stop.py", true},
+		{"/usr/bin/node --no-warnings ${CLAUDE_PLUGIN_ROOT}/hook.js", "${CLAUDE_PLUGIN_ROOT}/hook.js", true},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		script, interpreted := commandScript(tt.command)
+		if script != tt.script || interpreted != tt.interpreted {
+			t.Errorf("commandScript(%q) = %q, %v, want %q, %v", tt.command, script, interpreted, tt.script, tt.interpreted)
+		}
+	}
+}
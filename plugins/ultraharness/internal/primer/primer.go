@@ -0,0 +1,146 @@
+// Package primer loads the project's "always include" context files, such
+// as ARCHITECTURE.md or CONVENTIONS.md, for embedding in the SessionStart
+// message. Files over their size limit are reduced to an outline.
+package primer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/text"
+	"ultraharness/internal/validation"
+)
+
+// File is a context file prepared for embedding.
+type File struct {
+	Path    string
+	Content string
+	// Size is the original file size in bytes
+	Size int
+	// Reduced is set when Content is an outline or truncation of the file
+	Reduced bool
+	Error   string
+}
+
+// Load reads the configured context files in order. Each file is limited
+// to MaxBytesPerFile; files are skipped once MaxTotalBytes is used up.
+func Load(workDir string, cfg config.ContextFilesConfig) []File {
+	var files []File
+	remaining := cfg.MaxTotalBytes
+	for _, path := range cfg.Files {
+		f := File{Path: path}
+
+		absPath, err := validation.ValidatePath(path, workDir)
+		if err != nil {
+			f.Error = err.Error()
+			files = append(files, f)
+			continue
+		}
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			f.Error = "not found"
+			if !os.IsNotExist(err) {
+				f.Error = err.Error()
+			}
+			files = append(files, f)
+			continue
+		}
+
+		limit := cfg.MaxBytesPerFile
+		if remaining < limit {
+			limit = remaining
+		}
+		if limit <= 0 {
+			f.Size = len(data)
+			f.Error = "skipped: context file budget used up"
+			files = append(files, f)
+			continue
+		}
+
+		f.Size = len(data)
+		f.Content = strings.TrimSpace(string(data))
+		if len(f.Content) > limit {
+			f.Content = Reduce(f.Content, limit)
+			f.Reduced = true
+		}
+		remaining -= len(f.Content)
+		files = append(files, f)
+	}
+	return files
+}
+
+// Reduce shrinks content to at most limit bytes. Markdown documents keep
+// their outline: every heading with the first line of its section. If the
+// outline is still too long, or there are no headings, the text is cut at
+// a line boundary.
+func Reduce(content string, limit int) string {
+	if len(content) <= limit {
+		return content
+	}
+	if outline := Outline(content); outline != "" {
+		if len(outline) <= limit {
+			return outline
+		}
+		content = outline
+	}
+
+	cut := text.Prefix(content, limit)
+	if i := strings.LastIndex(cut, "\n"); i > limit/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, "\n ")
+}
+
+// Outline returns the Markdown headings of content, each followed by the
+// first line of text in its section. Returns "" if there are no headings.
+func Outline(content string) string {
+	var lines []string
+	wantText, inFence := false, false
+	headings := 0
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			lines = append(lines, trimmed)
+			wantText = true
+			headings++
+		case wantText && trimmed != "":
+			lines = append(lines, trimmed)
+			wantText = false
+		}
+	}
+	if headings == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Format renders the files as a SessionStart section.
+func Format(files []File) []string {
+	if len(files) == 0 {
+		return nil
+	}
+
+	messages := []string{"--- PROJECT CONTEXT ---"}
+	for _, f := range files {
+		if f.Error != "" {
+			messages = append(messages, fmt.Sprintf("[%s: %s]", f.Path, f.Error))
+			continue
+		}
+		header := fmt.Sprintf(">>> %s", f.Path)
+		if f.Reduced {
+			header += fmt.Sprintf(" (reduced from %d bytes; read the file for full detail)", f.Size)
+		}
+		messages = append(messages, header, f.Content, "")
+	}
+	return messages
+}
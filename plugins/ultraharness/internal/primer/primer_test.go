@@ -0,0 +1,95 @@
+package primer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/config"
+)
+
+const architecture = `# Architecture
+
+The service is split into three layers.
+
+## Storage
+
+All persistence goes through the repository interfaces.
+More detail about storage that is not part of the outline.
+
+` + "```go" + `
+# not a heading
+` + "```" + `
+
+## HTTP
+
+Handlers are thin.
+`
+
+func TestOutline(t *testing.T) {
+	want := "# Architecture\nThe service is split into three layers.\n## Storage\nAll persistence goes through the repository interfaces.\n## HTTP\nHandlers are thin."
+	if got := Outline(architecture); got != want {
+		t.Errorf("Outline() = %q, want %q", got, want)
+	}
+	if got := Outline("no headings here"); got != "" {
+		t.Errorf("Outline() = %q, want empty", got)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	if got := Reduce("short", 100); got != "short" {
+		t.Errorf("Reduce() = %q, want unchanged", got)
+	}
+	if got := Reduce(architecture, 150); got != Outline(architecture) {
+		t.Errorf("Reduce() = %q, want outline", got)
+	}
+
+	plain := strings.Repeat("line of plain text\n", 20)
+	got := Reduce(plain, 100)
+	if len(got) > 100 || strings.HasSuffix(got, "\n") || !strings.HasSuffix(got, "text") {
+		t.Errorf("Reduce() = %q, want cut at a line boundary within 100 bytes", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "primer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "ARCHITECTURE.md"), []byte(architecture), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CONVENTIONS.md"), []byte("Use tabs.\n"), 0644)
+
+	files := Load(tmpDir, config.ContextFilesConfig{
+		Files:           []string{"ARCHITECTURE.md", "CONVENTIONS.md", "MISSING.md", "../etc/passwd"},
+		MaxBytesPerFile: 150,
+		MaxTotalBytes:   1000,
+	})
+	if len(files) != 4 {
+		t.Fatalf("Load() returned %d files, want 4", len(files))
+	}
+	if !files[0].Reduced || files[0].Size != len(architecture) {
+		t.Errorf("Load()[0] = %+v, want reduced with original size", files[0])
+	}
+	if files[1].Content != "Use tabs." || files[1].Reduced {
+		t.Errorf("Load()[1] = %+v, want full content", files[1])
+	}
+	if files[2].Error != "not found" {
+		t.Errorf("Load()[2].Error = %q, want not found", files[2].Error)
+	}
+	if files[3].Error == "" {
+		t.Error("Load() read a file outside the project")
+	}
+
+	// The total budget applies across files
+	files = Load(tmpDir, config.ContextFilesConfig{
+		Files:           []string{"ARCHITECTURE.md", "CONVENTIONS.md"},
+		MaxBytesPerFile: 1000,
+		MaxTotalBytes:   len(strings.TrimSpace(architecture)),
+	})
+	if files[0].Reduced || files[1].Error == "" {
+		t.Errorf("Load() = %+v, want second file skipped once the budget is used", files)
+	}
+}
@@ -1,12 +1,19 @@
 // Package progress handles progress file operations.
+//
+// Each entry is a line "[timestamp] [source] message", where the source
+// says who wrote it: a hook recording what it observed, the agent through
+// a command or tool, or a person. Lines written by hand or by older
+// versions have no source.
 package progress
 
 import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"ultraharness/internal/tags"
 	"ultraharness/internal/validation"
 )
 
@@ -24,12 +31,43 @@ func GetProgressPath(workDir string) string {
 	return filepath.Join(workDir, ProgressFileName)
 }
 
-// Append adds a timestamped entry to the progress file
-func Append(message string, workDir string) error {
+// TimeFormat is the format of entry timestamps, in local time
+const TimeFormat = "2006-01-02 15:04:05"
+
+// Source is who wrote an entry.
+type Source string
+
+// Sources
+const (
+	// SourceAuto is a hook recording what it observed
+	SourceAuto Source = "auto"
+	// SourceAgent is the agent, through a command or MCP tool
+	SourceAgent Source = "agent"
+	// SourceHuman is a person running a command
+	SourceHuman Source = "human"
+)
+
+// Sources lists the sources in the order reports show them.
+var Sources = []Source{SourceAgent, SourceAuto, SourceHuman}
+
+// AgentEnvVar is set by Claude Code in the shell its agent runs commands in.
+const AgentEnvVar = "CLAUDECODE"
+
+// CommandSource is the source of entries written by a CLI command: the
+// agent when Claude Code ran it, and otherwise a person.
+func CommandSource() Source {
+	if os.Getenv(AgentEnvVar) != "" {
+		return SourceAgent
+	}
+	return SourceHuman
+}
+
+// Append adds a timestamped entry from source to the progress file
+func Append(source Source, message string, workDir string) error {
 	path := GetProgressPath(workDir)
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	entry := fmt.Sprintf("[%s] %s\n", timestamp, message)
+	timestamp := time.Now().Format(TimeFormat)
+	entry := fmt.Sprintf("[%s] [%s] %s\n", timestamp, source, message)
 
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, FilePermission)
 	if err != nil {
@@ -69,3 +107,80 @@ func Read(workDir string) (string, error) {
 
 	return string(data), nil
 }
+
+// Entry is one line of the progress file.
+type Entry struct {
+	// Time is zero for a line without a timestamp
+	Time time.Time
+	// Source is empty for a line written without one
+	Source  Source
+	Message string
+}
+
+// Tags returns the hashtags in the entry's message, e.g. "auth" for a
+// message ending "#auth".
+func (e Entry) Tags() []string {
+	return tags.Parse(e.Message)
+}
+
+// ParseEntry parses a progress line. Blank lines and comments are not
+// entries.
+func ParseEntry(line string) (Entry, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Entry{}, false
+	}
+	var e Entry
+	if strings.HasPrefix(line, "[") {
+		if i := strings.Index(line, "] "); i >= 0 {
+			if t, err := time.ParseInLocation(TimeFormat, line[1:i], time.Local); err == nil {
+				e.Time = t
+				line = line[i+2:]
+			}
+		}
+	}
+	// Only known sources, so a message starting "[CRITICAL]" stays whole
+	for _, source := range Sources {
+		if tag := "[" + string(source) + "] "; strings.HasPrefix(line, tag) {
+			e.Source = source
+			line = line[len(tag):]
+			break
+		}
+	}
+	e.Message = line
+	return e, true
+}
+
+// Entries parses the entries of progress file content, oldest first.
+func Entries(content string) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(content, "\n") {
+		if e, ok := ParseEntry(line); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ReadEntries returns the progress file's entries, oldest first, keeping
+// only those from the given sources if any are given.
+func ReadEntries(workDir string, sources ...Source) ([]Entry, error) {
+	content, err := Read(workDir)
+	if err != nil {
+		return nil, err
+	}
+	entries := Entries(content)
+	if len(sources) == 0 {
+		return entries, nil
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		for _, source := range sources {
+			if e.Source == source {
+				kept = append(kept, e)
+				break
+			}
+		}
+	}
+	return kept, nil
+}
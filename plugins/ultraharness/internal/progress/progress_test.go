@@ -24,7 +24,7 @@ func TestAppend(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	t.Run("creates file if not exists", func(t *testing.T) {
-		if err := Append("Test message", tmpDir); err != nil {
+		if err := Append(SourceAgent, "Test message", tmpDir); err != nil {
 			t.Fatalf("Append() error = %v", err)
 		}
 
@@ -35,7 +35,7 @@ func TestAppend(t *testing.T) {
 	})
 
 	t.Run("appends with timestamp", func(t *testing.T) {
-		if err := Append("Second message", tmpDir); err != nil {
+		if err := Append(SourceAgent, "Second message", tmpDir); err != nil {
 			t.Fatalf("Append() error = %v", err)
 		}
 
@@ -113,6 +113,76 @@ func TestRead(t *testing.T) {
 	})
 }
 
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		line    string
+		source  Source
+		message string
+		timed   bool
+	}{
+		{"[2024-01-02 10:00:00] [auto] AUTO: Modified main.go (source file edited)", SourceAuto, "AUTO: Modified main.go (source file edited)", true},
+		{"[2024-01-02 10:00:00] [agent] fix: handle empty input", SourceAgent, "fix: handle empty input", true},
+		{"[2024-01-02 10:00:00] [human] APPROVED: npm/lodash", SourceHuman, "APPROVED: npm/lodash", true},
+		// Written by hand or by an older version
+		{"[2024-01-02 10:00:00] RESEARCH: Explored the codebase", "", "RESEARCH: Explored the codebase", true},
+		{"[2024-01-02 10:00:00] [CRITICAL] keep tokens secret", "", "[CRITICAL] keep tokens secret", true},
+		{"[Mon Jan 2] TEST: Manual entry", "", "[Mon Jan 2] TEST: Manual entry", false},
+		{"finished the parser", "", "finished the parser", false},
+	}
+	for _, tt := range tests {
+		e, ok := ParseEntry(tt.line)
+		if !ok || e.Source != tt.source || e.Message != tt.message || e.Time.IsZero() == tt.timed {
+			t.Errorf("ParseEntry(%q) = %+v, %v, want source %q, message %q", tt.line, e, ok, tt.source, tt.message)
+		}
+	}
+	for _, line := range []string{"", "   ", "# Claude Agent Progress Log"} {
+		if _, ok := ParseEntry(line); ok {
+			t.Errorf("ParseEntry(%q) is an entry, want none", line)
+		}
+	}
+}
+
+func TestEntryTags(t *testing.T) {
+	e, _ := ParseEntry("[2024-01-02 10:00:00] [agent] Moved token refresh into middleware #auth #Tech-Debt")
+	if got := e.Tags(); len(got) != 2 || got[0] != "auth" || got[1] != "tech-debt" {
+		t.Errorf("Tags() = %v, want auth and tech-debt", got)
+	}
+	if e, _ := ParseEntry("fix: issue #42"); len(e.Tags()) != 0 {
+		t.Errorf("Tags() = %v, want none for an issue number", e.Tags())
+	}
+}
+
+func TestReadEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(GetProgressPath(tmpDir), []byte("# Progress\n[2024-01-02 10:00:00] Set up the project\n"), 0600)
+	Append(SourceAuto, "AUTO: Modified a.go (source file edited)", tmpDir)
+	Append(SourceAgent, "Finished the parser", tmpDir)
+	Append(SourceHuman, "APPROVED: a.go", tmpDir)
+
+	all, err := ReadEntries(tmpDir)
+	if err != nil || len(all) != 4 {
+		t.Fatalf("ReadEntries() = %+v, %v, want 4 entries", all, err)
+	}
+	if all[1].Source != SourceAuto || all[1].Time.IsZero() {
+		t.Errorf("appended entry = %+v, want a timed auto entry", all[1])
+	}
+	claimed, _ := ReadEntries(tmpDir, SourceAgent, SourceHuman)
+	if len(claimed) != 2 || claimed[0].Message != "Finished the parser" || claimed[1].Source != SourceHuman {
+		t.Errorf("ReadEntries(agent, human) = %+v, want the agent and human entries", claimed)
+	}
+}
+
+func TestCommandSource(t *testing.T) {
+	t.Setenv(AgentEnvVar, "1")
+	if got := CommandSource(); got != SourceAgent {
+		t.Errorf("CommandSource() in Claude Code = %q, want agent", got)
+	}
+	t.Setenv(AgentEnvVar, "")
+	if got := CommandSource(); got != SourceHuman {
+		t.Errorf("CommandSource() in a terminal = %q, want human", got)
+	}
+}
+
 func TestProgressConstants(t *testing.T) {
 	if ProgressFileName != "claude-progress.txt" {
 		t.Errorf("ProgressFileName = %v, want 'claude-progress.txt'", ProgressFileName)
@@ -121,3 +191,18 @@ func TestProgressConstants(t *testing.T) {
 		t.Errorf("FilePermission = %o, want 0600", FilePermission)
 	}
 }
+
+func BenchmarkAppend(b *testing.B) {
+	tmpDir, err := os.MkdirTemp("", "progress-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Append(SourceAgent, "Modified internal/app/main.go", tmpDir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
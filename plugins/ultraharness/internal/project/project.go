@@ -0,0 +1,416 @@
+// Package project detects a project's languages, frameworks, and the
+// commands used to test, build, and lint it.
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Language names reported by Detect.
+const (
+	LangGo         = "Go"
+	LangRust       = "Rust"
+	LangJavaScript = "JavaScript"
+	LangTypeScript = "TypeScript"
+	LangPython     = "Python"
+	LangJava       = "Java"
+	LangRuby       = "Ruby"
+
+	// Infrastructure-as-code projects are reported as languages too
+	LangTerraform = "Terraform"
+	LangPulumi    = "Pulumi"
+)
+
+// Info describes a detected project.
+type Info struct {
+	Languages  []string
+	Frameworks []string
+
+	// Commands for the root project; nil if none was detected
+	TestCommand  []string
+	BuildCommand []string
+	LintCommand  []string
+
+	// Workspaces are subprojects found in immediate subdirectories
+	Workspaces []Workspace
+}
+
+// Workspace is a subproject of a monorepo.
+type Workspace struct {
+	Path       string
+	Languages  []string
+	Frameworks []string
+}
+
+// IsMonorepo returns true if subprojects were found.
+func (i *Info) IsMonorepo() bool {
+	return len(i.Workspaces) > 0
+}
+
+// marker maps a manifest file to a language and its commands. Markers are
+// checked in order; the first marker providing a command wins.
+type marker struct {
+	file     string
+	language string
+	test     []string
+	build    []string
+	lint     []string
+}
+
+var markers = []marker{
+	{"package.json", LangJavaScript, []string{"npm", "test", "--", "--passWithNoTests"}, nil, nil},
+	{"Cargo.toml", LangRust, []string{"cargo", "test"}, []string{"cargo", "build"}, []string{"cargo", "clippy"}},
+	{"go.mod", LangGo, []string{"go", "test", "./..."}, []string{"go", "build", "./..."}, []string{"go", "vet", "./..."}},
+	{"pyproject.toml", LangPython, []string{"pytest", "-q"}, nil, nil},
+	{"setup.py", LangPython, []string{"pytest", "-q"}, nil, nil},
+	{"requirements.txt", LangPython, nil, nil, nil},
+	{"Makefile", "", nil, nil, nil}, // Commands depend on targets
+	{"pom.xml", LangJava, []string{"mvn", "test", "-q"}, []string{"mvn", "package", "-q", "-DskipTests"}, nil},
+	{"build.gradle", LangJava, []string{"./gradlew", "test"}, []string{"./gradlew", "build", "-x", "test"}, nil},
+	{"Gemfile", LangRuby, nil, nil, nil},
+	{"Pulumi.yaml", LangPulumi, nil, nil, nil},
+}
+
+// frontendFrameworks lists frameworks described as "<name> frontend".
+var frontendFrameworks = map[string]bool{
+	"React": true, "Next.js": true, "Vue": true, "Svelte": true, "Angular": true,
+}
+
+// skipDirs are never treated as workspaces.
+var skipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "target": true, "dist": true, "build": true,
+}
+
+// Detect inspects workDir and its immediate subdirectories.
+func Detect(workDir string) *Info {
+	info := detectDir(workDir)
+
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		return info
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, ".") || skipDirs[name] {
+			continue
+		}
+		sub := detectDir(filepath.Join(workDir, name))
+		if len(sub.Languages) == 0 {
+			continue
+		}
+		info.Workspaces = append(info.Workspaces, Workspace{
+			Path:       name,
+			Languages:  sub.Languages,
+			Frameworks: sub.Frameworks,
+		})
+	}
+
+	return info
+}
+
+// detectDir inspects the manifests in a single directory.
+func detectDir(dir string) *Info {
+	info := &Info{}
+
+	for _, m := range markers {
+		if !fileExists(filepath.Join(dir, m.file)) {
+			continue
+		}
+
+		test, build, lint := m.test, m.build, m.lint
+		language := m.language
+
+		switch m.file {
+		case "Makefile":
+			test = makeTarget(dir, "test")
+			build = makeTarget(dir, "build")
+			lint = makeTarget(dir, "lint")
+		case "package.json":
+			scripts, deps := readPackageJSON(dir)
+			if scripts["build"] {
+				build = []string{"npm", "run", "build"}
+			}
+			if scripts["lint"] {
+				lint = []string{"npm", "run", "lint"}
+			}
+			if deps["typescript"] || fileExists(filepath.Join(dir, "tsconfig.json")) {
+				language = LangTypeScript
+			}
+			info.addFrameworks(jsFrameworks(deps)...)
+		case "pyproject.toml", "setup.py", "requirements.txt":
+			info.addFrameworks(pythonFrameworks(dir, m.file)...)
+		case "go.mod":
+			info.addFrameworks(goFrameworks(dir)...)
+		}
+
+		info.addLanguage(language)
+		if info.TestCommand == nil {
+			info.TestCommand = test
+		}
+		if info.BuildCommand == nil {
+			info.BuildCommand = build
+		}
+		if info.LintCommand == nil {
+			info.LintCommand = lint
+		}
+	}
+
+	// Terraform has no manifest, only .tf files
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tf")); len(matches) > 0 {
+		info.addLanguage(LangTerraform)
+	}
+
+	return info
+}
+
+// HasInfrastructure returns true if the project or one of its workspaces
+// is a Terraform or Pulumi project.
+func (i *Info) HasInfrastructure() bool {
+	return i.Uses(LangTerraform) || i.Uses(LangPulumi)
+}
+
+// Uses returns true if the project or one of its workspaces uses language.
+func (i *Info) Uses(language string) bool {
+	if contains(i.Languages, language) {
+		return true
+	}
+	for _, w := range i.Workspaces {
+		if contains(w.Languages, language) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Info) addLanguage(language string) {
+	if language != "" && !contains(i.Languages, language) {
+		i.Languages = append(i.Languages, language)
+	}
+}
+
+func (i *Info) addFrameworks(frameworks ...string) {
+	for _, f := range frameworks {
+		if !contains(i.Frameworks, f) {
+			i.Frameworks = append(i.Frameworks, f)
+		}
+	}
+}
+
+// Summary returns a short description, e.g. "Go module + React frontend".
+func (i *Info) Summary() string {
+	var parts []string
+	addParts := func(languages, frameworks []string) {
+		for _, p := range describe(languages, frameworks) {
+			if !contains(parts, p) {
+				parts = append(parts, p)
+			}
+		}
+	}
+
+	addParts(i.Languages, i.Frameworks)
+	for _, w := range i.Workspaces {
+		addParts(w.Languages, w.Frameworks)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	summary := strings.Join(parts, " + ")
+	if i.IsMonorepo() {
+		summary += " (monorepo)"
+	}
+	return summary
+}
+
+// describe names each language, using a frontend framework for JS/TS.
+func describe(languages, frameworks []string) []string {
+	frontend := ""
+	for _, f := range frameworks {
+		if frontendFrameworks[f] {
+			frontend = f
+			break
+		}
+	}
+
+	var parts []string
+	for _, lang := range languages {
+		switch lang {
+		case LangGo:
+			parts = append(parts, "Go module")
+		case LangRust:
+			parts = append(parts, "Rust crate")
+		case LangPython:
+			parts = append(parts, "Python package")
+		case LangTerraform, LangPulumi:
+			parts = append(parts, lang+" infrastructure")
+		case LangJavaScript, LangTypeScript:
+			if frontend != "" {
+				parts = append(parts, frontend+" frontend")
+			} else {
+				parts = append(parts, lang+" project")
+			}
+		default:
+			parts = append(parts, lang+" project")
+		}
+	}
+	return parts
+}
+
+// IsToolCommand returns true if a shell command runs one of the project's
+// detected test, build, or lint commands.
+func (i *Info) IsToolCommand(command string) bool {
+	command = strings.Join(strings.Fields(command), " ")
+	for _, c := range [][]string{i.TestCommand, i.BuildCommand, i.LintCommand} {
+		if c == nil {
+			continue
+		}
+		// Match on the command and first argument, e.g. "go test"
+		prefix := strings.Join(c[:min(2, len(c))], " ")
+		if strings.Contains(command, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTestCommand returns true if a shell command runs the project's
+// detected test command.
+func (i *Info) IsTestCommand(command string) bool {
+	if i.TestCommand == nil {
+		return false
+	}
+	command = strings.Join(strings.Fields(command), " ")
+	return strings.Contains(command, strings.Join(i.TestCommand[:min(2, len(i.TestCommand))], " "))
+}
+
+// HasMakeTarget checks if the Makefile in dir defines a target.
+func HasMakeTarget(dir, target string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, "Makefile"))
+	if err != nil {
+		return false
+	}
+
+	// Simple check for target definition
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, target+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func makeTarget(dir, target string) []string {
+	if HasMakeTarget(dir, target) {
+		return []string{"make", target}
+	}
+	return nil
+}
+
+// readPackageJSON returns the script names and dependency names.
+func readPackageJSON(dir string) (map[string]bool, map[string]bool) {
+	scripts := make(map[string]bool)
+	deps := make(map[string]bool)
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return scripts, deps
+	}
+
+	var pkg struct {
+		Scripts         map[string]string `json:"scripts"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return scripts, deps
+	}
+
+	for name := range pkg.Scripts {
+		scripts[name] = true
+	}
+	for name := range pkg.Dependencies {
+		deps[name] = true
+	}
+	for name := range pkg.DevDependencies {
+		deps[name] = true
+	}
+	return scripts, deps
+}
+
+func jsFrameworks(deps map[string]bool) []string {
+	checks := []struct{ dep, name string }{
+		{"next", "Next.js"},
+		{"react", "React"},
+		{"vue", "Vue"},
+		{"svelte", "Svelte"},
+		{"@angular/core", "Angular"},
+		{"express", "Express"},
+	}
+
+	var frameworks []string
+	for _, c := range checks {
+		if deps[c.dep] {
+			frameworks = append(frameworks, c.name)
+		}
+	}
+	return frameworks
+}
+
+func pythonFrameworks(dir, manifest string) []string {
+	content := strings.ToLower(readFile(filepath.Join(dir, manifest)))
+	checks := []struct{ dep, name string }{
+		{"django", "Django"},
+		{"flask", "Flask"},
+		{"fastapi", "FastAPI"},
+	}
+
+	var frameworks []string
+	for _, c := range checks {
+		if strings.Contains(content, c.dep) {
+			frameworks = append(frameworks, c.name)
+		}
+	}
+	return frameworks
+}
+
+func goFrameworks(dir string) []string {
+	content := readFile(filepath.Join(dir, "go.mod"))
+	checks := []struct{ module, name string }{
+		{"github.com/gin-gonic/gin", "Gin"},
+		{"github.com/labstack/echo", "Echo"},
+		{"github.com/gofiber/fiber", "Fiber"},
+	}
+
+	var frameworks []string
+	for _, c := range checks {
+		if strings.Contains(content, c.module) {
+			frameworks = append(frameworks, c.name)
+		}
+	}
+	return frameworks
+}
+
+func readFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantLangs   string
+		wantTest    string
+		wantBuild   string
+		wantSummary string
+	}{
+		{
+			name:        "go module",
+			files:       map[string]string{"go.mod": "module example.com/x\n\nrequire github.com/gin-gonic/gin v1.9.0\n"},
+			wantLangs:   "Go",
+			wantTest:    "go test ./...",
+			wantBuild:   "go build ./...",
+			wantSummary: "Go module",
+		},
+		{
+			name: "typescript react app",
+			files: map[string]string{
+				"package.json":  `{"scripts": {"build": "vite build"}, "dependencies": {"react": "18"}}`,
+				"tsconfig.json": "{}",
+			},
+			wantLangs:   "TypeScript",
+			wantTest:    "npm test -- --passWithNoTests",
+			wantBuild:   "npm run build",
+			wantSummary: "React frontend",
+		},
+		{
+			name:        "makefile targets",
+			files:       map[string]string{"Makefile": "build:\n\tcc main.c\ntest:\n\t./run-tests\n"},
+			wantLangs:   "",
+			wantTest:    "make test",
+			wantBuild:   "make build",
+			wantSummary: "",
+		},
+		{
+			name:        "python with django",
+			files:       map[string]string{"requirements.txt": "Django==4.2\n", "setup.py": ""},
+			wantLangs:   "Python",
+			wantTest:    "pytest -q",
+			wantSummary: "Python package",
+		},
+		{
+			name:        "terraform",
+			files:       map[string]string{"main.tf": "provider \"aws\" {}\n", "variables.tf": ""},
+			wantLangs:   "Terraform",
+			wantSummary: "Terraform infrastructure",
+		},
+		{
+			name:        "pulumi with typescript",
+			files:       map[string]string{"Pulumi.yaml": "name: api\nruntime: nodejs\n", "package.json": `{"dependencies": {"typescript": "5"}}`},
+			wantLangs:   "TypeScript,Pulumi",
+			wantTest:    "npm test -- --passWithNoTests",
+			wantSummary: "TypeScript project + Pulumi infrastructure",
+		},
+		{
+			name:        "empty",
+			files:       map[string]string{},
+			wantSummary: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir, err := os.MkdirTemp("", "project-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpDir)
+			writeFiles(t, tmpDir, tt.files)
+
+			info := Detect(tmpDir)
+			if got := strings.Join(info.Languages, ","); got != tt.wantLangs {
+				t.Errorf("Languages = %q, want %q", got, tt.wantLangs)
+			}
+			if got := strings.Join(info.TestCommand, " "); got != tt.wantTest {
+				t.Errorf("TestCommand = %q, want %q", got, tt.wantTest)
+			}
+			if got := strings.Join(info.BuildCommand, " "); got != tt.wantBuild {
+				t.Errorf("BuildCommand = %q, want %q", got, tt.wantBuild)
+			}
+			if got := info.Summary(); got != tt.wantSummary {
+				t.Errorf("Summary() = %q, want %q", got, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestDetectMonorepo(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "project-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFiles(t, tmpDir, map[string]string{
+		"go.mod":                    "module example.com/x\n",
+		"web/package.json":          `{"dependencies": {"react": "18"}}`,
+		"node_modules/package.json": `{}`,
+		".cache/go.mod":             "module cache\n",
+	})
+
+	info := Detect(tmpDir)
+	if !info.IsMonorepo() {
+		t.Fatal("IsMonorepo() = false, want true")
+	}
+	if len(info.Workspaces) != 1 || info.Workspaces[0].Path != "web" {
+		t.Errorf("Workspaces = %+v, want [web]", info.Workspaces)
+	}
+	if got := info.Summary(); got != "Go module + React frontend (monorepo)" {
+		t.Errorf("Summary() = %q, want %q", got, "Go module + React frontend (monorepo)")
+	}
+}
+
+func TestHasInfrastructure(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "project-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFiles(t, tmpDir, map[string]string{"go.mod": "module example.com/x\n"})
+	if Detect(tmpDir).HasInfrastructure() {
+		t.Error("HasInfrastructure() = true for a Go module")
+	}
+	writeFiles(t, tmpDir, map[string]string{"deploy/main.tf": ""})
+	if info := Detect(tmpDir); !info.HasInfrastructure() || info.Summary() != "Go module + Terraform infrastructure (monorepo)" {
+		t.Errorf("Detect() = %+v, want the Terraform workspace found", info)
+	}
+	if info := Detect(tmpDir); !info.Uses(LangGo) || info.Uses(LangPython) {
+		t.Errorf("Uses() = %v for Go and %v for Python, want only Go", info.Uses(LangGo), info.Uses(LangPython))
+	}
+}
+
+func TestIsToolCommand(t *testing.T) {
+	info := &Info{
+		TestCommand: []string{"go", "test", "./..."},
+		LintCommand: []string{"go", "vet", "./..."},
+	}
+
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"go test -run TestFoo ./internal/...", true},
+		{"cd sub &&  go   vet ./...", true},
+		{"go run ./cmd/tool", false},
+		{"ls -la", false},
+	}
+
+	for _, tt := range tests {
+		if got := info.IsToolCommand(tt.command); got != tt.want {
+			t.Errorf("IsToolCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestIsTestCommand(t *testing.T) {
+	info := &Info{
+		TestCommand: []string{"make", "test"},
+		LintCommand: []string{"go", "vet", "./..."},
+	}
+
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"make test", true},
+		{"make   test V=1", true},
+		{"go vet ./...", false},
+		{"make build", false},
+	}
+
+	for _, tt := range tests {
+		if got := info.IsTestCommand(tt.command); got != tt.want {
+			t.Errorf("IsTestCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+
+	if (&Info{}).IsTestCommand("make test") {
+		t.Error("IsTestCommand() without a test command = true, want false")
+	}
+}
@@ -7,74 +7,269 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+
+	sdk "github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk/protocol"
+)
+
+// The hook input and output types are the marketplace SDK's, so the
+// harness and other plugins read and write the same wire format. This
+// package adds the harness's output conventions: metadata with the hook and
+// event, dry-run and headless modes, and queued notices.
+type (
+	// HookInput represents the JSON input from Claude Code to hooks
+	HookInput = sdk.HookInput
+	// ToolResponse is a tool's result, a string or an object depending on
+	// the tool
+	ToolResponse = sdk.ToolResponse
+	// HookOutput represents the JSON output from hooks to Claude Code
+	HookOutput = sdk.HookOutput
+	// HookSpecificOutput contains hook-specific decisions; HookEventName
+	// defaults to the name given to SetHook
+	HookSpecificOutput = sdk.HookSpecificOutput
+	// Metadata is machine-readable detail about a hook's output, so tooling
+	// and tests can assert on behavior without parsing the message text.
+	// Every output written through this package carries "hook" (when set
+	// with SetHook) and "event".
+	Metadata = sdk.Metadata
 )
 
 // MaxInputSize limits stdin to 10MB to prevent DoS attacks
-const MaxInputSize = 10 * 1024 * 1024
+const MaxInputSize = sdk.MaxInputSize
+
+// PermissionDecision constants
+const (
+	PermissionAllow = sdk.PermissionAllow
+	PermissionDeny  = sdk.PermissionDeny
+	// PermissionAsk prompts the user to confirm the tool call
+	PermissionAsk = sdk.PermissionAsk
+)
+
+// Events describing hook output
+const (
+	// EventMessage is the default for informational messages
+	EventMessage = "message"
+	// EventBlocked is the default for denied operations
+	EventBlocked = "blocked"
+	// EventConfirm is the default when the user is asked to confirm
+	EventConfirm = "confirmation_requested"
+	// EventInputUpdated is the default when the tool input is rewritten
+	EventInputUpdated = "input_updated"
+	EventError        = "error"
+
+	EventCompactionRequired    = "compaction_required"
+	EventCompactionRecommended = "compaction_recommended"
+	EventContextWarning        = "context_warning"
+	EventContextStatus         = "context_status"
+	EventWarning               = "warning"
+	EventNotInitialized        = "not_initialized"
+	EventSessionContext        = "session_context"
+	EventPromptGuidance        = "prompt_guidance"
+	EventSubagentResult        = "subagent_result"
+	EventContextPreserved      = "context_preserved"
+	EventStopAllowed           = "stop_allowed"
+	EventStopBlocked           = "stop_blocked"
+	EventStopReminders         = "stop_reminders"
+	// EventNotice is a run whose only output is queued notices
+	EventNotice = "notice"
+)
+
+// hookName identifies the running hook in output metadata
+var hookName string
+
+// stdout receives hook output; replaced in tests
+var stdout io.Writer = os.Stdout
 
-// HookInput represents the JSON input from Claude Code to hooks
-type HookInput struct {
-	SessionID  string                 `json:"session_id"`
-	ToolName   string                 `json:"tool_name"`
-	ToolInput  map[string]interface{} `json:"tool_input"`
-	ToolResult string                 `json:"tool_result,omitempty"`
-	// UserPromptSubmit-specific fields
-	Prompt string `json:"prompt,omitempty"`
+// sink, when set, is handed hook output instead of stdout
+var sink func(*HookOutput) error
+
+// dryRun reports decisions instead of enforcing them
+var dryRun bool
+
+// headless withholds advisories written with WriteAdvice
+var headless bool
+
+// lastMetadata is the metadata of the last output written
+var lastMetadata Metadata
+
+// lastOutput is the last output written, for the hook log
+var lastOutput []byte
+
+// notices are shown ahead of the next output written
+var notices []string
+
+// SetHook names the running hook, e.g. "PostToolUse", for output metadata.
+func SetHook(name string) {
+	hookName = name
 }
 
-// HookOutput represents the JSON output from hooks to Claude Code
-type HookOutput struct {
-	SystemMessage      string                 `json:"systemMessage,omitempty"`
-	HookSpecificOutput *HookSpecificOutput    `json:"hookSpecificOutput,omitempty"`
-	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+// SetOutput redirects hook output to w.
+func SetOutput(w io.Writer) {
+	stdout = w
 }
 
-// HookSpecificOutput contains hook-specific decisions
-type HookSpecificOutput struct {
-	PermissionDecision string `json:"permissionDecision,omitempty"` // "allow" or "deny"
+// Output returns the writer hook output goes to.
+func Output() io.Writer {
+	return stdout
 }
 
-// PermissionDecision constants
-const (
-	PermissionAllow = "allow"
-	PermissionDeny  = "deny"
-)
+// SetSink hands hook output to write instead of writing it to the output,
+// or restores writing it when write is nil. The hook runner uses it to
+// send output through the SDK's hook context.
+func SetSink(write func(*HookOutput) error) {
+	sink = write
+}
 
-// ReadInput reads and parses JSON from stdin with size limiting
-func ReadInput() (*HookInput, error) {
-	reader := io.LimitReader(os.Stdin, MaxInputSize)
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read stdin: %w", err)
-	}
+// SetDryRun turns dry-run mode on or off. In dry-run mode denials,
+// confirmations, and input rewrites are described in the system message
+// instead of being sent as decisions, so nothing is blocked or changed.
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
 
-	// Handle empty input gracefully
-	if len(data) == 0 {
-		return &HookInput{}, nil
-	}
+// SetHeadless turns headless mode on or off. In headless mode nobody is
+// watching the session, so advisories written with WriteAdvice are
+// withheld and only kept in the output metadata, for the hook log.
+func SetHeadless(enabled bool) {
+	headless = enabled
+}
+
+// IsHeadless reports whether headless mode is on.
+func IsHeadless() bool {
+	return headless
+}
+
+// AddNotice queues a message to show the user with the next output,
+// whatever the hook writes. Use it for warnings raised below the hook,
+// such as a state file that had to be reset.
+func AddNotice(message string) {
+	notices = append(notices, message)
+}
+
+// TakeNotices returns the queued notices and clears them.
+func TakeNotices() []string {
+	taken := notices
+	notices = nil
+	return taken
+}
+
+// LastMetadata returns the metadata of the last output written, or nil if
+// it was empty.
+func LastMetadata() Metadata {
+	return lastMetadata
+}
 
-	var input HookInput
-	if err := json.Unmarshal(data, &input); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+// LastOutput returns the JSON of the last output written.
+func LastOutput() []byte {
+	return lastOutput
+}
+
+// merge combines metadata maps; later keys win.
+func merge(meta []Metadata) Metadata {
+	merged := Metadata{}
+	for _, m := range meta {
+		for k, v := range m {
+			merged[k] = v
+		}
 	}
+	return merged
+}
+
+// ReadInput reads and parses JSON from stdin with size limiting
+func ReadInput() (*HookInput, error) {
+	return ReadInputFrom(os.Stdin)
+}
 
-	return &input, nil
+// ReadInputFrom reads and parses JSON from r with size limiting
+func ReadInputFrom(r io.Reader) (*HookInput, error) {
+	return sdk.ReadInputFrom(r)
 }
 
-// WriteOutput writes JSON response to stdout
+// WriteOutput writes JSON response to stdout, filling in the hook name and
+// a default event in its metadata
 func WriteOutput(output *HookOutput) error {
+	if output.Metadata == nil {
+		output.Metadata = Metadata{}
+	}
+	if hookName != "" {
+		output.Metadata["hook"] = hookName
+	}
+	if _, ok := output.Metadata["event"]; !ok {
+		output.Metadata["event"] = EventMessage
+		if specific := output.HookSpecificOutput; specific != nil {
+			switch {
+			case specific.PermissionDecision == PermissionDeny:
+				output.Metadata["event"] = EventBlocked
+			case specific.PermissionDecision == PermissionAsk:
+				output.Metadata["event"] = EventConfirm
+			case specific.UpdatedInput != nil:
+				output.Metadata["event"] = EventInputUpdated
+			}
+		}
+	}
+	if specific := output.HookSpecificOutput; specific != nil && specific.HookEventName == "" {
+		specific.HookEventName = hookName
+	}
+	if dryRun && output.HookSpecificOutput != nil {
+		describeDryRun(output)
+	}
+	if pending := TakeNotices(); len(pending) > 0 {
+		output.Metadata["notices"] = len(pending)
+		if output.SystemMessage != "" {
+			pending = append(pending, output.SystemMessage)
+		}
+		output.SystemMessage = strings.Join(pending, "\n")
+	}
+	lastMetadata = output.Metadata
+
 	data, err := json.Marshal(output)
 	if err != nil {
 		return fmt.Errorf("failed to marshal output: %w", err)
 	}
 
-	_, err = os.Stdout.Write(data)
+	lastOutput = data
+	if sink != nil {
+		return sink(output)
+	}
+	_, err = stdout.Write(data)
 	return err
 }
 
-// WriteEmpty writes an empty JSON object {} to stdout
+// describeDryRun replaces a decision with a system message describing it.
+func describeDryRun(output *HookOutput) {
+	specific := output.HookSpecificOutput
+	var action string
+	switch {
+	case specific.UpdatedInput != nil:
+		action = "rewrite the tool input"
+	case specific.PermissionDecision == PermissionDeny:
+		action = "deny"
+	case specific.PermissionDecision == PermissionAsk:
+		action = "ask for confirmation"
+	default:
+		return
+	}
+	msg := "[Harness dry run] Would " + action + "; not enforced."
+	if output.SystemMessage != "" {
+		msg += "\n" + output.SystemMessage
+	}
+	output.SystemMessage = msg
+	output.HookSpecificOutput = nil
+	output.Metadata["dry_run"] = true
+}
+
+// WriteEmpty writes an empty JSON object {} to stdout, or the queued
+// notices if there are any
 func WriteEmpty() error {
-	_, err := os.Stdout.WriteString("{}")
+	if len(notices) > 0 {
+		return WriteOutput(&HookOutput{Metadata: Metadata{"event": EventNotice}})
+	}
+	lastMetadata = nil
+	lastOutput = []byte("{}")
+	if sink != nil {
+		return sink(nil)
+	}
+	_, err := io.WriteString(stdout, "{}")
 	return err
 }
 
@@ -83,129 +278,72 @@ func WriteError(format string, args ...interface{}) error {
 	msg := fmt.Sprintf(format, args...)
 	return WriteOutput(&HookOutput{
 		SystemMessage: fmt.Sprintf("[Harness] Hook error: %s", msg),
+		Metadata:      Metadata{"event": EventError, "error": msg},
 	})
 }
 
 // WriteDeny writes a permission denial response
-func WriteDeny(message string) error {
+func WriteDeny(message string, meta ...Metadata) error {
 	return WriteOutput(&HookOutput{
 		SystemMessage: message,
 		HookSpecificOutput: &HookSpecificOutput{
-			PermissionDecision: PermissionDeny,
+			PermissionDecision:       PermissionDeny,
+			PermissionDecisionReason: message,
 		},
+		Metadata: merge(meta),
 	})
 }
 
-// WriteMessage writes a system message (informational, not blocking)
-func WriteMessage(message string) error {
+// WriteAsk asks the user to confirm the tool call, showing message as the
+// reason
+func WriteAsk(message string, meta ...Metadata) error {
 	return WriteOutput(&HookOutput{
 		SystemMessage: message,
+		HookSpecificOutput: &HookSpecificOutput{
+			PermissionDecision:       PermissionAsk,
+			PermissionDecisionReason: message,
+		},
+		Metadata: merge(meta),
 	})
 }
 
-// GetFilePath extracts file_path from tool input, returns empty string if not present
-func (h *HookInput) GetFilePath() string {
-	if h.ToolInput == nil {
-		return ""
-	}
-	if path, ok := h.ToolInput["file_path"].(string); ok {
-		return path
-	}
-	return ""
-}
-
-// GetCommand extracts command from tool input (for Bash), returns empty string if not present
-func (h *HookInput) GetCommand() string {
-	if h.ToolInput == nil {
-		return ""
-	}
-	if cmd, ok := h.ToolInput["command"].(string); ok {
-		return cmd
-	}
-	return ""
-}
-
-// GetPrompt extracts prompt from input (for UserPromptSubmit), returns empty string if not present
-func (h *HookInput) GetPrompt() string {
-	// Check top-level prompt field first (UserPromptSubmit format)
-	if h.Prompt != "" {
-		return h.Prompt
-	}
-	// Fallback to tool_input for backwards compatibility
-	if h.ToolInput == nil {
-		return ""
-	}
-	if prompt, ok := h.ToolInput["prompt"].(string); ok {
-		return prompt
-	}
-	return ""
-}
-
-// GetSubagentType extracts subagent_type from tool input (for SubagentStop), returns empty string if not present
-func (h *HookInput) GetSubagentType() string {
-	if h.ToolInput == nil {
-		return ""
-	}
-	if t, ok := h.ToolInput["subagent_type"].(string); ok {
-		return t
-	}
-	return ""
-}
-
-// GetDescription extracts description from tool input (for SubagentStop), returns empty string if not present
-func (h *HookInput) GetDescription() string {
-	if h.ToolInput == nil {
-		return ""
-	}
-	if d, ok := h.ToolInput["description"].(string); ok {
-		return d
-	}
-	return ""
-}
-
-// GetOutput extracts output from tool input (for SubagentStop), returns empty string if not present
-func (h *HookInput) GetOutput() string {
-	if h.ToolInput == nil {
-		return ""
-	}
-	if o, ok := h.ToolInput["output"].(string); ok {
-		return o
-	}
-	return ""
+// WriteUpdatedInput replaces the tool input with updated, which must be the
+// complete tool input. decision is PermissionAllow to run the tool without
+// asking, or PermissionAsk to show the user the rewritten input. message
+// explains the change to the agent and may be empty
+func WriteUpdatedInput(message, decision string, updated map[string]interface{}, meta ...Metadata) error {
+	return WriteOutput(&HookOutput{
+		SystemMessage: message,
+		HookSpecificOutput: &HookSpecificOutput{
+			PermissionDecision: decision,
+			UpdatedInput:       updated,
+		},
+		Metadata: merge(meta),
+	})
 }
 
-// GetStopReason extracts stopReason or reason from tool input (for Stop), returns empty string if not present
-func (h *HookInput) GetStopReason() string {
-	if h.ToolInput == nil {
-		return ""
-	}
-	if r, ok := h.ToolInput["stopReason"].(string); ok {
-		return r
-	}
-	if r, ok := h.ToolInput["reason"].(string); ok {
-		return r
-	}
-	return ""
+// WriteMessage writes a system message (informational, not blocking)
+func WriteMessage(message string, meta ...Metadata) error {
+	return WriteOutput(&HookOutput{
+		SystemMessage: message,
+		Metadata:      merge(meta),
+	})
 }
 
-// GetTranscript extracts transcript or conversation_transcript from tool input
-func (h *HookInput) GetTranscript() string {
-	if h.ToolInput == nil {
-		return ""
-	}
-	if t, ok := h.ToolInput["transcript"].(string); ok {
-		return t
-	}
-	if t, ok := h.ToolInput["conversation_transcript"].(string); ok {
-		return t
-	}
-	if t, ok := h.ToolInput["transcript_path"].(string); ok {
-		return t
+// WriteAdvice writes a message meant for someone watching the session,
+// such as a compaction directive, a reminder, or a warning that blocks
+// nothing. In headless mode the message is withheld: it goes into the
+// metadata under "withheld" instead of the system message.
+func WriteAdvice(message string, meta ...Metadata) error {
+	if !headless {
+		return WriteMessage(message, meta...)
 	}
-	return ""
+	merged := merge(meta)
+	merged["withheld"] = message
+	return WriteMessage("", merged)
 }
 
 // WriteSystemMessage writes a system message response (alias for WriteMessage for clarity)
-func WriteSystemMessage(message string) error {
-	return WriteMessage(message)
+func WriteSystemMessage(message string, meta ...Metadata) error {
+	return WriteMessage(message, meta...)
 }
@@ -1,7 +1,10 @@
 package protocol
 
 import (
+	"bytes"
 	"encoding/json"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -43,6 +46,65 @@ func TestHookInputGetters(t *testing.T) {
 		}
 	})
 
+	t.Run("GetPattern", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input HookInput
+			want  string
+		}{
+			{
+				name:  "nil tool input",
+				input: HookInput{ToolInput: nil},
+				want:  "",
+			},
+			{
+				name:  "pattern not string",
+				input: HookInput{ToolInput: map[string]interface{}{"pattern": 42}},
+				want:  "",
+			},
+			{
+				name:  "valid pattern",
+				input: HookInput{ToolInput: map[string]interface{}{"pattern": "func main"}},
+				want:  "func main",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.input.GetPattern(); got != tt.want {
+					t.Errorf("GetPattern() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("Edit and Write inputs", func(t *testing.T) {
+		input := HookInput{ToolInput: map[string]interface{}{
+			"content":     "package main",
+			"old_string":  "foo",
+			"new_string":  "bar",
+			"replace_all": true,
+		}}
+
+		if got := input.GetContent(); got != "package main" {
+			t.Errorf("GetContent() = %v, want 'package main'", got)
+		}
+		if got := input.GetOldString(); got != "foo" {
+			t.Errorf("GetOldString() = %v, want 'foo'", got)
+		}
+		if got := input.GetNewString(); got != "bar" {
+			t.Errorf("GetNewString() = %v, want 'bar'", got)
+		}
+		if !input.GetReplaceAll() {
+			t.Error("GetReplaceAll() = false, want true")
+		}
+
+		empty := HookInput{}
+		if empty.GetContent() != "" || empty.GetOldString() != "" || empty.GetNewString() != "" || empty.GetReplaceAll() {
+			t.Error("getters on nil tool input should return zero values")
+		}
+	})
+
 	t.Run("GetCommand", func(t *testing.T) {
 		tests := []struct {
 			name  string
@@ -316,6 +378,137 @@ func TestHookInputJSONParsing(t *testing.T) {
 	})
 }
 
+func TestToolResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		wantText   string
+		wantCode   int
+		wantCodeOK bool
+		wantStderr string
+		wantFields bool
+		wantInterr bool
+	}{
+		{
+			name:     "string",
+			json:     `{"tool_response": "file contents"}`,
+			wantText: "file contents",
+		},
+		{
+			name:       "bash object",
+			json:       `{"tool_response": {"stdout": "ok", "stderr": "warn", "interrupted": false}}`,
+			wantText:   "ok\nwarn",
+			wantStderr: "warn",
+			wantFields: true,
+		},
+		{
+			name:       "exit code field",
+			json:       `{"tool_response": {"stdout": "FAIL", "exit_code": 1}}`,
+			wantText:   "FAIL",
+			wantCode:   1,
+			wantCodeOK: true,
+			wantFields: true,
+		},
+		{
+			name:       "exit code in string",
+			json:       `{"tool_response": "Error: Exit code 2\nboom"}`,
+			wantText:   "Error: Exit code 2\nboom",
+			wantCode:   2,
+			wantCodeOK: true,
+		},
+		{
+			name:       "interrupted",
+			json:       `{"tool_response": {"stdout": "", "interrupted": true}}`,
+			wantText:   `{"stdout": "", "interrupted": true}`,
+			wantFields: true,
+			wantInterr: true,
+		},
+		{
+			name:       "output field",
+			json:       `{"tool_response": {"output": "done", "exitCode": 0}}`,
+			wantText:   "done",
+			wantCodeOK: true,
+			wantFields: true,
+		},
+		{
+			name:       "legacy tool_result",
+			json:       `{"tool_result": "Exit code 3"}`,
+			wantText:   "Exit code 3",
+			wantCode:   3,
+			wantCodeOK: true,
+		},
+		{
+			name: "missing",
+			json: `{"tool_name": "Bash"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input HookInput
+			if err := json.Unmarshal([]byte(tt.json), &input); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got := input.GetToolResult(); got != tt.wantText {
+				t.Errorf("GetToolResult() = %q, want %q", got, tt.wantText)
+			}
+			code, ok := input.GetExitCode()
+			if code != tt.wantCode || ok != tt.wantCodeOK {
+				t.Errorf("GetExitCode() = %d, %v, want %d, %v", code, ok, tt.wantCode, tt.wantCodeOK)
+			}
+			if got := input.ToolResponse.Stderr(); got != tt.wantStderr {
+				t.Errorf("Stderr() = %q, want %q", got, tt.wantStderr)
+			}
+			if got := input.ToolResponse.Interrupted(); got != tt.wantInterr {
+				t.Errorf("Interrupted() = %v, want %v", got, tt.wantInterr)
+			}
+			if hasFields := input.ToolResponse != nil && input.ToolResponse.Fields != nil; hasFields != tt.wantFields {
+				t.Errorf("Fields set = %v, want %v", hasFields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestToolResponseRoundTrip(t *testing.T) {
+	for _, raw := range []string{`"text"`, `{"stdout":"ok"}`} {
+		var r ToolResponse
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", raw, err)
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if string(data) != raw {
+			t.Errorf("Marshal(Unmarshal(%s)) = %s", raw, data)
+		}
+	}
+}
+
+func TestToolResponseFiles(t *testing.T) {
+	var read, grep, text ToolResponse
+	json.Unmarshal([]byte(`{"type": "text", "file": {"filePath": "/p/a.go", "content": "package a"}}`), &read)
+	json.Unmarshal([]byte(`{"mode": "files_with_matches", "filenames": ["/p/a.go", "/p/b.go"], "numFiles": 2}`), &grep)
+	json.Unmarshal([]byte(`"package b"`), &text)
+
+	if got := read.FileContent(); got != "package a" {
+		t.Errorf("FileContent() = %q, want %q", got, "package a")
+	}
+	if got := text.FileContent(); got != "package b" {
+		t.Errorf("FileContent() of string = %q, want %q", got, "package b")
+	}
+	if got := grep.FileContent(); got != "" {
+		t.Errorf("FileContent() of Grep = %q, want empty", got)
+	}
+	if got := grep.Filenames(); len(got) != 2 || got[1] != "/p/b.go" {
+		t.Errorf("Filenames() = %v, want [/p/a.go /p/b.go]", got)
+	}
+	var none *ToolResponse
+	if none.FileContent() != "" || none.Filenames() != nil {
+		t.Error("nil ToolResponse should have no content or filenames")
+	}
+}
+
 func TestMaxInputSize(t *testing.T) {
 	// Verify the constant is set to a reasonable limit
 	if MaxInputSize != 10*1024*1024 {
@@ -330,4 +523,333 @@ func TestPermissionDecisionConstants(t *testing.T) {
 	if PermissionDeny != "deny" {
 		t.Errorf("PermissionDeny = %v, want 'deny'", PermissionDeny)
 	}
+	if PermissionAsk != "ask" {
+		t.Errorf("PermissionAsk = %v, want 'ask'", PermissionAsk)
+	}
+}
+
+func captureOutput(t *testing.T, write func() error) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() { stdout = os.Stdout }()
+
+	if err := write(); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("Failed to unmarshal %q: %v", buf.String(), err)
+	}
+	return parsed
+}
+
+func TestOutputMetadata(t *testing.T) {
+	SetHook("PostToolUse")
+	defer SetHook("")
+
+	tests := []struct {
+		name      string
+		write     func() error
+		wantEvent string
+		wantKeys  map[string]interface{}
+	}{
+		{
+			name:      "message defaults to message event",
+			write:     func() error { return WriteMessage("hello") },
+			wantEvent: EventMessage,
+		},
+		{
+			name:      "deny defaults to blocked event",
+			write:     func() error { return WriteDeny("no", Metadata{"check": "write_guard"}) },
+			wantEvent: EventBlocked,
+			wantKeys:  map[string]interface{}{"check": "write_guard"},
+		},
+		{
+			name: "explicit event and fields",
+			write: func() error {
+				return WriteSystemMessage("compact", Metadata{"event": EventCompactionRequired, "utilization": 0.72})
+			},
+			wantEvent: EventCompactionRequired,
+			wantKeys:  map[string]interface{}{"utilization": 0.72},
+		},
+		{
+			name:      "later metadata wins",
+			write:     func() error { return WriteMessage("x", Metadata{"a": "1"}, Metadata{"a": "2"}) },
+			wantEvent: EventMessage,
+			wantKeys:  map[string]interface{}{"a": "2"},
+		},
+		{
+			name:      "errors",
+			write:     func() error { return WriteError("boom %d", 1) },
+			wantEvent: EventError,
+			wantKeys:  map[string]interface{}{"error": "boom 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed := captureOutput(t, tt.write)
+			meta, ok := parsed["metadata"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("metadata not found in %v", parsed)
+			}
+			if meta["hook"] != "PostToolUse" {
+				t.Errorf("metadata hook = %v, want PostToolUse", meta["hook"])
+			}
+			if meta["event"] != tt.wantEvent {
+				t.Errorf("metadata event = %v, want %v", meta["event"], tt.wantEvent)
+			}
+			for k, want := range tt.wantKeys {
+				if meta[k] != want {
+					t.Errorf("metadata[%q] = %v, want %v", k, meta[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPermissionDecisions(t *testing.T) {
+	SetHook("PreToolUse")
+	defer SetHook("")
+
+	t.Run("ask", func(t *testing.T) {
+		parsed := captureOutput(t, func() error { return WriteAsk("Large write", Metadata{"check": "write_guard"}) })
+		specific, ok := parsed["hookSpecificOutput"].(map[string]interface{})
+		if !ok {
+			t.Fatal("hookSpecificOutput not found or wrong type")
+		}
+		if specific["permissionDecision"] != PermissionAsk {
+			t.Errorf("permissionDecision = %v, want %v", specific["permissionDecision"], PermissionAsk)
+		}
+		if specific["permissionDecisionReason"] != "Large write" {
+			t.Errorf("permissionDecisionReason = %v, want 'Large write'", specific["permissionDecisionReason"])
+		}
+		if specific["hookEventName"] != "PreToolUse" {
+			t.Errorf("hookEventName = %v, want PreToolUse", specific["hookEventName"])
+		}
+		if event := parsed["metadata"].(map[string]interface{})["event"]; event != EventConfirm {
+			t.Errorf("metadata event = %v, want %v", event, EventConfirm)
+		}
+	})
+
+	t.Run("updated input", func(t *testing.T) {
+		updated := map[string]interface{}{"file_path": "a.go", "content": "// header\n\npackage a\n"}
+		parsed := captureOutput(t, func() error { return WriteUpdatedInput("Added header", PermissionAllow, updated) })
+		specific := parsed["hookSpecificOutput"].(map[string]interface{})
+		if specific["permissionDecision"] != PermissionAllow {
+			t.Errorf("permissionDecision = %v, want %v", specific["permissionDecision"], PermissionAllow)
+		}
+		input, ok := specific["updatedInput"].(map[string]interface{})
+		if !ok || input["content"] != updated["content"] || input["file_path"] != "a.go" {
+			t.Errorf("updatedInput = %v, want %v", specific["updatedInput"], updated)
+		}
+		if event := parsed["metadata"].(map[string]interface{})["event"]; event != EventInputUpdated {
+			t.Errorf("metadata event = %v, want %v", event, EventInputUpdated)
+		}
+	})
+}
+
+func TestAutoApprovesEdits(t *testing.T) {
+	tests := []struct {
+		mode string
+		want bool
+	}{
+		{"", false},
+		{"default", false},
+		{"plan", false},
+		{"acceptEdits", true},
+		{"bypassPermissions", true},
+	}
+
+	for _, tt := range tests {
+		input := &HookInput{PermissionMode: tt.mode}
+		if got := input.AutoApprovesEdits(); got != tt.want {
+			t.Errorf("AutoApprovesEdits(%q) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestWriteEmptyHasNoMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() { stdout = os.Stdout }()
+
+	if err := WriteEmpty(); err != nil {
+		t.Fatalf("WriteEmpty() error = %v", err)
+	}
+	if buf.String() != "{}" {
+		t.Errorf("WriteEmpty() = %s, want {}", buf.String())
+	}
+}
+
+func TestSetSink(t *testing.T) {
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() { stdout = os.Stdout }()
+
+	var sent []*HookOutput
+	SetSink(func(output *HookOutput) error {
+		sent = append(sent, output)
+		return nil
+	})
+	defer SetSink(nil)
+
+	WriteMessage("hello")
+	WriteEmpty()
+	if buf.Len() != 0 {
+		t.Errorf("output = %s, want everything sent to the sink", buf.String())
+	}
+	if len(sent) != 2 || sent[0].SystemMessage != "hello" || sent[0].Metadata["event"] != EventMessage || sent[1] != nil {
+		t.Errorf("sink got %+v, want the message and then empty output", sent)
+	}
+	if string(LastOutput()) != "{}" {
+		t.Errorf("LastOutput() = %s, want {}", LastOutput())
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	SetDryRun(true)
+	defer SetDryRun(false)
+
+	parsed := captureOutput(t, func() error { return WriteDeny("Edit blocked", Metadata{"check": "phase_gate"}) })
+	if _, ok := parsed["hookSpecificOutput"]; ok {
+		t.Errorf("dry run output = %v, want no decision", parsed)
+	}
+	if msg, _ := parsed["systemMessage"].(string); !strings.HasPrefix(msg, "[Harness dry run] Would deny") || !strings.Contains(msg, "Edit blocked") {
+		t.Errorf("systemMessage = %q, want the denial described", msg)
+	}
+	meta := LastMetadata()
+	if meta["dry_run"] != true || meta["event"] != EventBlocked || meta["check"] != "phase_gate" {
+		t.Errorf("LastMetadata() = %v, want dry_run, blocked event, and check kept", meta)
+	}
+
+	parsed = captureOutput(t, func() error { return WriteMessage("note") })
+	if parsed["systemMessage"] != "note" {
+		t.Errorf("dry run message = %v, want it unchanged", parsed)
+	}
+
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	WriteEmpty()
+	if buf.String() != "{}" || LastMetadata() != nil {
+		t.Errorf("WriteEmpty() wrote %q with metadata %v, want {} and none", buf.String(), LastMetadata())
+	}
+}
+
+func TestWriteAdvice(t *testing.T) {
+	parsed := captureOutput(t, func() error { return WriteAdvice("compact now", Metadata{"event": EventCompactionRequired}) })
+	if parsed["systemMessage"] != "compact now" {
+		t.Errorf("WriteAdvice() = %v, want the message shown", parsed)
+	}
+
+	SetHeadless(true)
+	defer SetHeadless(false)
+	parsed = captureOutput(t, func() error { return WriteAdvice("compact now", Metadata{"event": EventCompactionRequired}) })
+	if _, ok := parsed["systemMessage"]; ok {
+		t.Errorf("headless WriteAdvice() = %v, want no system message", parsed)
+	}
+	if meta := LastMetadata(); meta["withheld"] != "compact now" || meta["event"] != EventCompactionRequired {
+		t.Errorf("LastMetadata() = %v, want the message withheld and the event kept", meta)
+	}
+
+	// Decisions are never withheld
+	parsed = captureOutput(t, func() error { return WriteDeny("Edit blocked") })
+	if parsed["systemMessage"] != "Edit blocked" {
+		t.Errorf("headless WriteDeny() = %v, want the denial shown", parsed)
+	}
+}
+
+func TestNotices(t *testing.T) {
+	AddNotice("state reset")
+	parsed := captureOutput(t, WriteEmpty)
+	if parsed["systemMessage"] != "state reset" {
+		t.Errorf("WriteEmpty() with a notice = %v, want the notice shown", parsed)
+	}
+	if meta := LastMetadata(); meta["event"] != EventNotice || meta["notices"] != 1 {
+		t.Errorf("LastMetadata() = %v, want notice event and count", meta)
+	}
+
+	// Shown once, ahead of the hook's own message
+	AddNotice("state reset")
+	parsed = captureOutput(t, func() error { return WriteMessage("context at 40%") })
+	if parsed["systemMessage"] != "state reset\ncontext at 40%" {
+		t.Errorf("systemMessage = %q, want the notice then the message", parsed["systemMessage"])
+	}
+	parsed = captureOutput(t, func() error { return WriteMessage("context at 40%") })
+	if parsed["systemMessage"] != "context at 40%" {
+		t.Errorf("systemMessage = %q, want the notice shown only once", parsed["systemMessage"])
+	}
+	if TakeNotices() != nil {
+		t.Error("TakeNotices() after output returned notices, want none")
+	}
+}
+
+func TestReadInputFrom(t *testing.T) {
+	input, err := ReadInputFrom(strings.NewReader(`{"session_id": "s1", "tool_name": "Read"}`))
+	if err != nil || input.SessionID != "s1" || input.ToolName != "Read" {
+		t.Errorf("ReadInputFrom() = %+v, %v, want session s1 and tool Read", input, err)
+	}
+	if input, err := ReadInputFrom(strings.NewReader("")); err != nil || input == nil {
+		t.Errorf("ReadInputFrom() of empty input = %v, %v, want empty input", input, err)
+	}
+	if _, err := ReadInputFrom(strings.NewReader("{")); err == nil {
+		t.Error("ReadInputFrom() of invalid JSON should fail")
+	}
+}
+
+func BenchmarkReadInputFrom(b *testing.B) {
+	input := `{"session_id": "s1", "tool_name": "Edit", "cwd": "/src/app", "permission_mode": "default",
+		"tool_input": {"file_path": "/src/app/main.go", "old_string": "` + strings.Repeat("x", 2000) + `", "new_string": "y"},
+		"tool_response": {"filePath": "/src/app/main.go", "success": true}}`
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadInputFrom(strings.NewReader(input)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func FuzzReadInputFrom(f *testing.F) {
+	f.Add(`{"session_id": "s1", "tool_name": "Read", "tool_input": {"file_path": "a.go"}}`)
+	f.Add(`{"tool_name": "Bash", "tool_input": {"command": "go test"}, "tool_response": {"stdout": "ok", "exit_code": 1}}`)
+	f.Add(`{"tool_name": "Read", "tool_response": {"type": "text", "file": {"filePath": "a.go", "content": "héllo"}}}`)
+	f.Add(`{"tool_name": "Glob", "tool_response": {"filenames": ["a.go", "日本.go"], "numFiles": 2}}`)
+	f.Add(`{"tool_name": "Grep", "tool_response": "a.go:1:x\nb.go:2:y"}`)
+	f.Add(`{"prompt": "fix 🐛", "subagent_type": "fic-researcher", "output": "Confidence: 90%"}`)
+	f.Add(`{"tool_response": [1, 2, 3], "tool_input": null}`)
+	f.Fuzz(func(t *testing.T, data string) {
+		input, err := ReadInputFrom(strings.NewReader(data))
+		if err != nil {
+			return
+		}
+		// Every getter must cope with whatever the JSON held
+		input.GetToolResult()
+		input.GetExitCode()
+		input.GetFilePath()
+		input.GetCommand()
+		input.GetContent()
+		input.GetOldString()
+		input.GetNewString()
+		input.GetReplaceAll()
+		input.GetPattern()
+		input.GetPrompt()
+		input.GetSubagentType()
+		input.GetDescription()
+		input.GetOutput()
+		input.GetStopReason()
+		input.GetTranscript()
+		if r := input.ToolResponse; r != nil {
+			r.Stdout()
+			r.Stderr()
+			r.Interrupted()
+			r.ExitCode()
+			r.FileContent()
+			r.Filenames()
+			if _, err := json.Marshal(r); err != nil {
+				t.Errorf("marshaling the tool response of %q: %v", data, err)
+			}
+		}
+	})
 }
@@ -0,0 +1,67 @@
+// Package quarantine recovers from state files that no longer parse.
+//
+// A corrupt state file used to make its loader fail, which silently turned
+// off whatever depended on it; a corrupt FIC state even let the phase gates
+// allow every edit. Instead, the loader hands the damaged contents to Save,
+// which keeps a copy in .claude/corrupt/ for inspection, and then carries on
+// from default state. Save queues a warning saying what was reset, shown
+// with the hook's next output; since the loader replaces the damaged file,
+// the warning appears once.
+package quarantine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/errcode"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/storage"
+)
+
+// DirName is the directory inside .claude holding quarantined files.
+const DirName = "corrupt"
+
+// Dir returns the quarantine directory for workDir.
+func Dir(workDir string) string {
+	return filepath.Join(workDir, ".claude", DirName)
+}
+
+// Save copies data, the contents of the state file name that failed to
+// parse with cause, into the quarantine directory, and queues a warning
+// that the state was reset. lost describes what resetting loses, e.g. "the
+// current phase and gate progress". It returns the copy's path. On error
+// nothing is queued, and the caller should keep the damaged file.
+func Save(workDir, name string, data []byte, cause error, lost string) (string, error) {
+	dir := Dir(workDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext) + "-" + time.Now().Format("20060102-150405")
+	path := filepath.Join(dir, base+ext)
+	for i := 2; exists(path); i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", base, i, ext))
+	}
+
+	// Sealed like any state file, so a quarantined copy is not left in
+	// plaintext when encryption is on
+	if err := storage.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+
+	// The notice tells the user; the code is for the hook log
+	errcode.Record(errcode.New(errcode.StateCorrupt, cause))
+	protocol.AddNotice(fmt.Sprintf(
+		"[Harness] Warning: %s was corrupt (%v) and has been reset to defaults; %s were lost. The damaged file was saved to %s.",
+		name, cause, lost, filepath.Join(".claude", DirName, filepath.Base(path))))
+	return path, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,65 @@
+package quarantine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/storage"
+)
+
+func TestSave(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "quarantine-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	protocol.TakeNotices()
+
+	cause := errors.New("unexpected end of JSON input")
+	first, err := Save(tmpDir, "fic-state.json", []byte(`{"phase":`), cause, "the workflow phase")
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	second, err := Save(tmpDir, "fic-state.json", []byte(`{`), cause, "the workflow phase")
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if first == second || filepath.Dir(first) != Dir(tmpDir) || filepath.Ext(second) != ".json" {
+		t.Errorf("Save() paths = %s and %s, want distinct .json files in %s", first, second, Dir(tmpDir))
+	}
+	if data, _ := storage.ReadFile(first); string(data) != `{"phase":` {
+		t.Errorf("quarantined copy = %q, want the damaged contents", data)
+	}
+
+	notices := protocol.TakeNotices()
+	if len(notices) != 2 {
+		t.Fatalf("notices = %q, want one per file", notices)
+	}
+	for _, want := range []string{"fic-state.json", cause.Error(), "the workflow phase", filepath.Join(".claude", DirName, filepath.Base(first))} {
+		if !strings.Contains(notices[0], want) {
+			t.Errorf("notice %q does not mention %q", notices[0], want)
+		}
+	}
+}
+
+func TestSaveFailure(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "quarantine-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	protocol.TakeNotices()
+
+	// A file where the .claude directory should be
+	os.WriteFile(filepath.Join(tmpDir, ".claude"), nil, 0600)
+	if _, err := Save(tmpDir, "fic-state.json", []byte("{"), errors.New("bad"), "the phase"); err == nil {
+		t.Error("Save() error = nil, want the directory error")
+	}
+	if notices := protocol.TakeNotices(); notices != nil {
+		t.Errorf("notices = %q, want none when nothing was saved", notices)
+	}
+}
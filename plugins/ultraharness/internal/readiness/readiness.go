@@ -0,0 +1,109 @@
+// Package readiness checks whether a branch is ready to leave the
+// developer's hands: passing features are verified, the project builds,
+// and no merge conflicts are left behind. The Stop hook runs these checks
+// before a session stops, and the prepush command before a push, so both
+// report the same problems in the same words.
+package readiness
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/build"
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+)
+
+// UnverifiedFeatures describes the features marked passing whose acceptance
+// criteria have not been verified since files last changed, or returns ""
+// if there are none.
+func UnverifiedFeatures(workDir string) string {
+	data, err := features.Load(workDir)
+	if err != nil {
+		return ""
+	}
+	unverified := data.UnverifiedPassing(features.TreeFingerprint(workDir))
+	if len(unverified) == 0 {
+		return ""
+	}
+
+	ids := make([]string, 0, len(unverified))
+	for _, f := range unverified {
+		ids = append(ids, f.ID)
+	}
+	return "Features marked passing without verification: " + strings.Join(ids, ", ") +
+		" - run verify_feature to check their acceptance criteria"
+}
+
+// BuildFailure builds the project, reusing the cached result for an
+// unchanged tree, and describes the failure with the build output, or
+// returns "" if it built or has no build command.
+func BuildFailure(workDir string, cfg *config.Config) string {
+	command := build.DetectCommand(workDir, cfg.BuildCommand)
+	timeout := time.Duration(cfg.GetBuildTimeoutSeconds()) * time.Second
+	result := build.Verify(workDir, command, timeout)
+	if result == nil || result.Success {
+		return ""
+	}
+
+	reason := "Project does not build: `" + result.Command + "` - " + result.Error
+	if result.Output != "" {
+		reason += "\n" + result.Output
+	}
+	return reason
+}
+
+// Conflicts returns the files with unresolved merge conflicts: those git
+// still lists as unmerged, and changed files that contain conflict markers.
+// Changed files are those differing from the branch's upstream, or the
+// uncommitted ones if it has none.
+func Conflicts(workDir string) []string {
+	conflicts := git.UnmergedFiles(workDir)
+	seen := make(map[string]bool, len(conflicts))
+	for _, f := range conflicts {
+		seen[f] = true
+	}
+
+	var changed []string
+	if upstream := git.Upstream(workDir); upstream != "" {
+		changed = git.ChangedFilesSince(workDir, upstream)
+	} else {
+		changed = git.ModifiedFiles(workDir)
+	}
+	for _, f := range changed {
+		if !seen[f] && hasConflictMarkers(filepath.Join(workDir, f)) {
+			seen[f] = true
+			conflicts = append(conflicts, f)
+		}
+	}
+	return conflicts
+}
+
+// hasConflictMarkers reports whether the file has both an opening and a
+// closing conflict marker at the start of a line. Deleted and unreadable
+// files have none.
+func hasConflictMarkers(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	opened := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "<<<<<<< "):
+			opened = true
+		case opened && strings.HasPrefix(line, ">>>>>>> "):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package readiness
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+)
+
+func createTestRepo(t *testing.T) string {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "readiness-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+	} {
+		exec.Command("git", append([]string{"-C", tmpDir}, args...)...).Run()
+	}
+	return tmpDir
+}
+
+func TestUnverifiedFeatures(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	if got := UnverifiedFeatures(tmpDir); got != "" {
+		t.Errorf("UnverifiedFeatures() without a checklist = %q, want empty", got)
+	}
+
+	data := &features.FeaturesData{Features: []features.Feature{
+		{ID: "F1", Status: features.StatusPassing, AcceptanceCriteria: []features.Criterion{{Command: "true"}}},
+		{ID: "F2", Status: features.StatusPending, AcceptanceCriteria: []features.Criterion{{Command: "true"}}},
+	}}
+	if err := features.Save(tmpDir, data); err != nil {
+		t.Fatal(err)
+	}
+	if got := UnverifiedFeatures(tmpDir); !strings.Contains(got, "without verification: F1 -") {
+		t.Errorf("UnverifiedFeatures() = %q, want F1 listed", got)
+	}
+}
+
+func TestBuildFailure(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	cfg := config.DefaultConfig()
+	cfg.BuildCommand = []string{"sh", "-c", "echo broken; exit 1"}
+	if got := BuildFailure(tmpDir, cfg); !strings.HasPrefix(got, "Project does not build: `sh -c echo broken; exit 1`") ||
+		!strings.HasSuffix(got, "\nbroken") {
+		t.Errorf("BuildFailure() = %q, want the command and its output", got)
+	}
+
+	cfg.BuildCommand = []string{"true"}
+	if got := BuildFailure(tmpDir, cfg); got != "" {
+		t.Errorf("BuildFailure() of a passing build = %q, want empty", got)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	tmpDir := createTestRepo(t)
+	defer os.RemoveAll(tmpDir)
+	git := func(args ...string) {
+		exec.Command("git", append([]string{"-C", tmpDir}, args...)...).Run()
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("base\n"), 0644)
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+	if got := Conflicts(tmpDir); got != nil {
+		t.Errorf("Conflicts() on a clean tree = %v, want none", got)
+	}
+
+	// Markers in a changed file count even after git add marks it resolved
+	marked := "<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic\n"
+	os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte(marked), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "doc.md"), []byte("Conflicts look like\n    <<<<<<< HEAD\n"), 0644)
+	git("add", ".")
+	if got := Conflicts(tmpDir); len(got) != 1 || got[0] != "a.txt" {
+		t.Errorf("Conflicts() = %v, want a.txt", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Package relevance ranks short texts against a query with TF-IDF, so
+// hooks can inject the few stored facts that matter for the task at hand
+// instead of all of them. It needs no external services or models.
+package relevance
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopWords are common words that carry no meaning for matching.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true,
+	"this": true, "from": true, "are": true, "was": true, "were": true,
+	"not": true, "but": true, "all": true, "any": true, "can": true,
+	"has": true, "have": true, "into": true, "its": true, "our": true,
+	"use": true, "when": true, "how": true, "what": true, "where": true,
+	"which": true, "who": true, "why": true, "add": true, "new": true,
+	"out": true, "via": true, "also": true, "should": true, "does": true,
+	"there": true, "then": true, "them": true, "they": true, "will": true,
+	"would": true, "could": true, "about": true, "make": true, "need": true,
+}
+
+// Tokenize splits text into lowercase terms of three or more letters or
+// digits, skipping stop words. Plural and verb endings are trimmed so
+// "tokens" matches "token" and "cached" matches "cache".
+func Tokenize(text string) []string {
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if stopWords[w] {
+			continue
+		}
+		if w = stem(w); len(w) >= 3 {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// stem trims the most common English suffixes. It is deliberately crude:
+// both sides of a comparison are stemmed the same way.
+func stem(w string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s", "e"} {
+		if len(w) > len(suffix)+3 && strings.HasSuffix(w, suffix) && !strings.HasSuffix(w, "ss") {
+			return strings.TrimSuffix(w, suffix)
+		}
+	}
+	return w
+}
+
+// Index holds the term statistics of a set of documents.
+type Index struct {
+	docs []map[string]int
+	// df counts the documents containing each term
+	df map[string]int
+}
+
+// NewIndex indexes docs; results refer to documents by their position.
+func NewIndex(docs []string) *Index {
+	ix := &Index{docs: make([]map[string]int, len(docs)), df: map[string]int{}}
+	for i, doc := range docs {
+		counts := map[string]int{}
+		for _, term := range Tokenize(doc) {
+			counts[term]++
+		}
+		for term := range counts {
+			ix.df[term]++
+		}
+		ix.docs[i] = counts
+	}
+	return ix
+}
+
+// idf weights a term by how rare it is among the documents. It stays
+// positive so a single document still matches its own terms.
+func (ix *Index) idf(term string) float64 {
+	return math.Log((float64(len(ix.docs)) + 1) / (float64(ix.df[term]) + 0.5))
+}
+
+// Score returns the TF-IDF relevance of document i to query: the sum over
+// distinct query terms of the term's damped frequency in the document
+// times its rarity. Zero means no terms in common.
+func (ix *Index) Score(i int, query string) float64 {
+	return ix.score(i, distinct(Tokenize(query)))
+}
+
+func (ix *Index) score(i int, terms []string) float64 {
+	score := 0.0
+	for _, term := range terms {
+		if n := ix.docs[i][term]; n > 0 {
+			score += (1 + math.Log(float64(n))) * ix.idf(term)
+		}
+	}
+	return score
+}
+
+// Match is a document's position and its relevance to a query.
+type Match struct {
+	Index int
+	Score float64
+}
+
+// Rank returns every document sharing a term with query, most relevant
+// first; equal scores keep document order.
+func (ix *Index) Rank(query string) []Match {
+	terms := distinct(Tokenize(query))
+	var matches []Match
+	for i := range ix.docs {
+		if score := ix.score(i, terms); score > 0 {
+			matches = append(matches, Match{Index: i, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		return matches[a].Score > matches[b].Score
+	})
+	return matches
+}
+
+// Top returns the k documents most relevant to query.
+func Top(docs []string, query string, k int) []Match {
+	matches := NewIndex(docs).Rank(query)
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+func distinct(terms []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	return result
+}
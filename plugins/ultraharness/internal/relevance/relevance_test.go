@@ -0,0 +1,65 @@
+package relevance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Where are the session files?", []string{"session", "file"}},
+		{"Cached tokens expire; caches refresh", []string{"cach", "token", "expir", "cach", "refresh"}},
+		{"process class go db", []string{"process", "class"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	docs := []string{
+		"The config loader merges defaults with the project file",
+		"Login tokens are refreshed by middleware",
+		"Session state is saved as JSON in the project directory",
+		"Token refresh fails when the session expired",
+	}
+	ix := NewIndex(docs)
+
+	got := ix.Rank("why does token refresh fail")
+	if len(got) != 2 {
+		t.Fatalf("Rank() = %v, want 2 matches", got)
+	}
+	if got[0].Index != 3 || got[1].Index != 1 {
+		t.Errorf("Rank() order = %v, want documents 3 then 1", got)
+	}
+
+	// A rare term outweighs a common one
+	got = ix.Rank("project loader")
+	if len(got) != 2 || got[0].Index != 0 {
+		t.Errorf("Rank(project loader) = %v, want document 0 first", got)
+	}
+
+	if got := ix.Rank("the and for"); len(got) != 0 {
+		t.Errorf("Rank() of stop words = %v, want none", got)
+	}
+	if ix.Score(2, "session") <= 0 || ix.Score(0, "session") != 0 {
+		t.Error("Score() should be positive only for documents containing the term")
+	}
+}
+
+func TestTop(t *testing.T) {
+	docs := []string{"alpha beta", "beta gamma", "beta delta"}
+	got := Top(docs, "beta", 2)
+	if len(got) != 2 || got[0].Index != 0 || got[1].Index != 1 {
+		t.Errorf("Top() = %v, want documents 0 and 1 in order", got)
+	}
+	if got := Top(nil, "beta", 2); len(got) != 0 {
+		t.Errorf("Top() of no documents = %v, want none", got)
+	}
+}
@@ -0,0 +1,242 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each remote request.
+const DefaultTimeout = 10 * time.Second
+
+// MaxObjectSize limits the size of downloaded objects.
+const MaxObjectSize = 10 << 20
+
+// ErrNotFound is returned by Get for objects that do not exist.
+var ErrNotFound = errors.New("remote object not found")
+
+// Client reads and writes objects under a base URL.
+type Client struct {
+	baseURL *url.URL
+	auth    authorizer
+	http    *http.Client
+}
+
+// authorizer adds credentials to a request for the given payload.
+type authorizer interface {
+	authorize(req *http.Request, payload []byte)
+}
+
+// NewHTTPClient returns a client that authenticates with a bearer token.
+// An empty token sends no Authorization header.
+func NewHTTPClient(baseURL, token string) (*Client, error) {
+	return newClient(baseURL, bearerAuth{token: token})
+}
+
+// NewS3Client returns a client for an S3-compatible store that signs
+// requests with AWS Signature Version 4, using path-style URLs such as
+// "https://s3.us-east-1.amazonaws.com/bucket/prefix". Credentials come from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN.
+func NewS3Client(baseURL, region string) (*Client, error) {
+	auth := s3Auth{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       region,
+	}
+	if auth.accessKey == "" || auth.secretKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for the s3 provider")
+	}
+	return newClient(baseURL, auth)
+}
+
+func newClient(baseURL string, auth authorizer) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid remote URL %q", baseURL)
+	}
+	// Credentials and state must not travel in cleartext except to a
+	// local test server
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname())) {
+		return nil, fmt.Errorf("remote URL must use https: %s", baseURL)
+	}
+
+	return &Client{
+		baseURL: u,
+		auth:    auth,
+		http:    &http.Client{Timeout: DefaultTimeout},
+	}, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Get returns the object stored under key, or ErrNotFound.
+func (c *Client) Get(key string) ([]byte, error) {
+	resp, err := c.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", key, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxObjectSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxObjectSize {
+		return nil, fmt.Errorf("GET %s: object exceeds %d bytes", key, MaxObjectSize)
+	}
+	return data, nil
+}
+
+// Put stores data under key.
+func (c *Client) Put(key string, data []byte) error {
+	resp, err := c.do(http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("PUT %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+func (c *Client) do(method, key string, payload []byte) (*http.Response, error) {
+	path := c.baseURL.EscapedPath()
+	for _, segment := range strings.Split(key, "/") {
+		path += "/" + url.PathEscape(segment)
+	}
+	u, err := c.baseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.auth.authorize(req, payload)
+
+	return c.http.Do(req)
+}
+
+// bearerAuth sends a static bearer token.
+type bearerAuth struct {
+	token string
+}
+
+func (a bearerAuth) authorize(req *http.Request, payload []byte) {
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+}
+
+// s3Auth signs requests with AWS Signature Version 4.
+type s3Auth struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+}
+
+func (a s3Auth) authorize(req *http.Request, payload []byte) {
+	a.sign(req, payload, time.Now().UTC())
+}
+
+func (a s3Auth) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if a.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + a.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+a.secretKey), day)
+	for _, part := range []string{a.region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(k []byte, data string) []byte {
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,485 @@
+// Package remote syncs FIC artifacts and the feature checklist with a
+// shared remote store, so sessions on the same repository and branch see
+// the same research and plan.
+//
+// Objects are stored under <url>/<repo>/<branch>/ next to a manifest that
+// records the hash, time, and author of each object's latest version.
+// Conflicting edits are resolved last-writer-wins and reported so the
+// next SessionStart can surface them.
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+	"ultraharness/internal/storage"
+)
+
+// StateKey is the storage key of the local sync state.
+const StateKey = "fic-remote-sync.json"
+
+// ManifestKey is the name of the remote manifest object.
+const ManifestKey = "manifest.json"
+
+// artifactPrefix is the storage key prefix of synced artifacts.
+const artifactPrefix = "fic-artifacts/"
+
+// Entry describes the latest version of a remote object.
+type Entry struct {
+	Hash      string    `json:"hash"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+}
+
+// Manifest lists the objects in a repo/branch namespace.
+type Manifest struct {
+	Objects map[string]Entry `json:"objects"`
+}
+
+// Conflict records an object changed both locally and remotely since the
+// last sync.
+type Conflict struct {
+	Key        string    `json:"key"`
+	Winner     string    `json:"winner"` // "local" or "remote"
+	RemoteBy   string    `json:"remote_by,omitempty"`
+	RemoteAt   time.Time `json:"remote_at"`
+	LocalAt    time.Time `json:"local_at"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// String describes the conflict for display.
+func (c Conflict) String() string {
+	by := c.RemoteBy
+	if by == "" {
+		by = "a teammate"
+	}
+	if c.Winner == "remote" {
+		return fmt.Sprintf("%s: kept remote version by %s (%s), local edit from %s overwritten",
+			c.Key, by, c.RemoteAt.Local().Format("2006-01-02 15:04"), c.LocalAt.Local().Format("2006-01-02 15:04"))
+	}
+	return fmt.Sprintf("%s: kept local version (%s), remote edit by %s from %s overwritten",
+		c.Key, c.LocalAt.Local().Format("2006-01-02 15:04"), by, c.RemoteAt.Local().Format("2006-01-02 15:04"))
+}
+
+// Result summarizes a sync.
+type Result struct {
+	Namespace string
+	Pulled    []string
+	Pushed    []string
+	Conflicts []Conflict
+}
+
+// State is the local record of the last sync.
+type State struct {
+	// Synced maps keys to the hash both sides agreed on at the last sync
+	Synced map[string]string `json:"synced"`
+	// Conflicts holds conflicts not yet shown at SessionStart
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	LastSync  time.Time  `json:"last_sync"`
+}
+
+// Syncer syncs one work directory with a remote namespace.
+type Syncer struct {
+	WorkDir   string
+	Client    *Client
+	Namespace string
+	Author    string
+}
+
+// New returns a Syncer for the project's remote sync config, or nil if
+// remote sync is disabled.
+func New(workDir string, cfg *config.Config) (*Syncer, error) {
+	rs := cfg.GetRemoteSync()
+	if !rs.Enabled {
+		return nil, nil
+	}
+	if rs.URL == "" {
+		return nil, errors.New("remote_sync.url is not set")
+	}
+
+	var client *Client
+	var err error
+	switch rs.Provider {
+	case "http":
+		client, err = NewHTTPClient(rs.URL, os.Getenv(rs.TokenEnv))
+	case "s3":
+		client, err = NewS3Client(rs.URL, rs.Region)
+	default:
+		err = fmt.Errorf("unknown remote_sync.provider %q (expected http or s3)", rs.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Syncer{
+		WorkDir:   workDir,
+		Client:    client,
+		Namespace: Namespace(workDir),
+		Author:    author(),
+	}, nil
+}
+
+var unsafeSegment = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Namespace returns "<repo>/<branch>" for workDir. The repo part is a hash
+// of the origin URL, so clones of the same repository share a namespace.
+func Namespace(workDir string) string {
+	origin := git.RemoteURL(workDir)
+	if origin == "" {
+		origin = filepath.Base(workDir)
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSuffix(origin, ".git")))
+	repo := hex.EncodeToString(sum[:])[:16]
+
+	branch := git.CurrentBranch(workDir)
+	if branch == "" {
+		branch = "detached"
+	}
+	return repo + "/" + unsafeSegment.ReplaceAllString(branch, "-")
+}
+
+func author() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		return name + "@" + host
+	}
+	return name
+}
+
+// LoadState loads the local sync state.
+func LoadState(workDir string) (*State, error) {
+	state := &State{Synced: make(map[string]string)}
+
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := backend.Get(StateKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Synced == nil {
+		state.Synced = make(map[string]string)
+	}
+	return state, nil
+}
+
+// SaveState saves the local sync state.
+func SaveState(workDir string, state *State) error {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return backend.Put(StateKey, data)
+}
+
+// TakeConflicts returns conflicts recorded by earlier syncs that have not
+// been shown yet, and clears them.
+func TakeConflicts(workDir string) []Conflict {
+	state, err := LoadState(workDir)
+	if err != nil || len(state.Conflicts) == 0 {
+		return nil
+	}
+	conflicts := state.Conflicts
+	state.Conflicts = nil
+	SaveState(workDir, state)
+	return conflicts
+}
+
+// Sync pulls remote changes and pushes local ones. Objects changed on
+// only one side are copied to the other; objects changed on both are
+// resolved in favor of the most recent write and recorded as conflicts.
+func (s *Syncer) Sync() (*Result, error) {
+	state, err := LoadState(s.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := s.fetchManifest()
+	if err != nil {
+		return nil, err
+	}
+	local, err := s.localObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Namespace: s.Namespace}
+	manifestChanged := false
+
+	for _, key := range unionKeys(local, manifest.Objects) {
+		localHash, hasLocal := local[key]
+		remote, hasRemote := manifest.Objects[key]
+		base := state.Synced[key]
+
+		switch {
+		case hasLocal && hasRemote && localHash == remote.Hash:
+			state.Synced[key] = localHash
+
+		case !hasRemote || (hasLocal && remote.Hash == base):
+			// Only the local side changed
+			if err := s.push(key, manifest); err != nil {
+				return nil, err
+			}
+			state.Synced[key] = manifest.Objects[key].Hash
+			result.Pushed = append(result.Pushed, key)
+			manifestChanged = true
+
+		case !hasLocal || localHash == base:
+			// Only the remote side changed
+			if err := s.pull(key); err != nil {
+				return nil, err
+			}
+			state.Synced[key] = remote.Hash
+			result.Pulled = append(result.Pulled, key)
+
+		default:
+			conflict := Conflict{
+				Key:        key,
+				RemoteBy:   remote.UpdatedBy,
+				RemoteAt:   remote.UpdatedAt,
+				LocalAt:    s.localModTime(key),
+				ResolvedAt: time.Now(),
+			}
+			if conflict.RemoteAt.After(conflict.LocalAt) {
+				conflict.Winner = "remote"
+				if err := s.pull(key); err != nil {
+					return nil, err
+				}
+				state.Synced[key] = remote.Hash
+				result.Pulled = append(result.Pulled, key)
+			} else {
+				conflict.Winner = "local"
+				if err := s.push(key, manifest); err != nil {
+					return nil, err
+				}
+				state.Synced[key] = manifest.Objects[key].Hash
+				result.Pushed = append(result.Pushed, key)
+				manifestChanged = true
+			}
+			result.Conflicts = append(result.Conflicts, conflict)
+		}
+	}
+
+	if manifestChanged {
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Client.Put(s.objectKey(ManifestKey), data); err != nil {
+			return nil, err
+		}
+	}
+
+	state.Conflicts = append(state.Conflicts, result.Conflicts...)
+	state.LastSync = time.Now()
+	if err := SaveState(s.WorkDir, state); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Syncer) objectKey(key string) string {
+	return s.Namespace + "/" + key
+}
+
+func (s *Syncer) fetchManifest() (*Manifest, error) {
+	manifest := &Manifest{Objects: make(map[string]Entry)}
+
+	data, err := s.Client.Get(s.objectKey(ManifestKey))
+	if errors.Is(err, ErrNotFound) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid remote manifest: %w", err)
+	}
+	if manifest.Objects == nil {
+		manifest.Objects = make(map[string]Entry)
+	}
+
+	for key := range manifest.Objects {
+		if !validKey(key) {
+			delete(manifest.Objects, key)
+		}
+	}
+	return manifest, nil
+}
+
+// validKey accepts the keys this package syncs and nothing else, so a
+// tampered manifest cannot write outside the synced state.
+func validKey(key string) bool {
+	if key == features.FeaturesFile {
+		return true
+	}
+	if !strings.HasPrefix(key, artifactPrefix) || !strings.HasSuffix(key, ".json") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// localObjects returns the hash of each synced local object.
+func (s *Syncer) localObjects() (map[string]string, error) {
+	objects := make(map[string]string)
+
+	backend, err := storage.Open(s.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := backend.List(artifactPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if !validKey(key) {
+			continue
+		}
+		data, err := backend.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		objects[key] = hash(data)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(s.WorkDir, features.FeaturesFile)); err == nil {
+		objects[features.FeaturesFile] = hash(data)
+	}
+	return objects, nil
+}
+
+func (s *Syncer) readLocal(key string) ([]byte, error) {
+	if key == features.FeaturesFile {
+		return os.ReadFile(filepath.Join(s.WorkDir, features.FeaturesFile))
+	}
+	backend, err := storage.Open(s.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Get(key)
+}
+
+func (s *Syncer) writeLocal(key string, data []byte) error {
+	if key == features.FeaturesFile {
+		return os.WriteFile(filepath.Join(s.WorkDir, features.FeaturesFile), data, 0644)
+	}
+	backend, err := storage.Open(s.WorkDir)
+	if err != nil {
+		return err
+	}
+	return backend.Put(key, data)
+}
+
+func (s *Syncer) localModTime(key string) time.Time {
+	path := filepath.Join(s.WorkDir, ".claude", filepath.FromSlash(key))
+	if key == features.FeaturesFile {
+		path = filepath.Join(s.WorkDir, features.FeaturesFile)
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Now()
+}
+
+// push uploads a local object and records it in the manifest. Payloads
+// are encrypted when state encryption is enabled.
+func (s *Syncer) push(key string, manifest *Manifest) error {
+	data, err := s.readLocal(key)
+	if err != nil {
+		return err
+	}
+	sealed, err := storage.Seal(data)
+	if err != nil {
+		return err
+	}
+	if err := s.Client.Put(s.objectKey(key), sealed); err != nil {
+		return err
+	}
+
+	manifest.Objects[key] = Entry{
+		Hash:      hash(data),
+		UpdatedAt: time.Now().UTC(),
+		UpdatedBy: s.Author,
+	}
+	return nil
+}
+
+// pull downloads a remote object and writes it locally.
+func (s *Syncer) pull(key string) error {
+	sealed, err := s.Client.Get(s.objectKey(key))
+	if err != nil {
+		return err
+	}
+	data, err := storage.Unseal(sealed)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return s.writeLocal(key, data)
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func unionKeys(local map[string]string, remote map[string]Entry) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for key := range local {
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	for key := range remote {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetResultString formats a sync result for display.
+func GetResultString(result *Result) string {
+	var parts []string
+	if len(result.Pulled) > 0 {
+		parts = append(parts, fmt.Sprintf("pulled %d", len(result.Pulled)))
+	}
+	if len(result.Pushed) > 0 {
+		parts = append(parts, fmt.Sprintf("pushed %d", len(result.Pushed)))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "up to date")
+	}
+	return fmt.Sprintf("Synced %s: %s", result.Namespace, strings.Join(parts, ", "))
+}
@@ -0,0 +1,316 @@
+// Package repair diagnoses harness state that has fallen out of step with
+// itself, such as gates that disagree with the artifacts or an
+// implementation artifact whose plan is gone, and fixes it without
+// deleting .claude. Every fix is written in one storage update, and
+// artifacts are put aside in a subdirectory rather than deleted.
+package repair
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/context"
+	"ultraharness/internal/gates"
+	"ultraharness/internal/storage"
+)
+
+// Phases lists the FIC state phases, in workflow order.
+var Phases = []string{"research", "planning", "implementation"}
+
+// StaleCompaction is how long a compaction may wait for the context to be
+// measured again before its pending result is considered stale.
+const StaleCompaction = 24 * time.Hour
+
+// Subdirectories artifacts are put aside in.
+const (
+	ClearedDir  = "cleared"
+	OrphanedDir = "orphaned"
+)
+
+// Options selects the fixes to make.
+type Options struct {
+	// ForcePhase sets the phase and the gates to match it
+	ForcePhase string
+	// ClearPlan puts the plan and implementation artifacts aside and
+	// closes the plan gate
+	ClearPlan bool
+	// Resync brings the gates in line with the artifacts, puts orphaned
+	// implementation artifacts aside, and clears stale context tracking
+	Resync bool
+}
+
+// State is the harness state as loaded for repair.
+type State struct {
+	FIC      *gates.FICState
+	Research *artifacts.Research
+	Plan     *artifacts.Plan
+	// PlanKeys and ImplementationKeys are the saved artifacts, oldest first
+	PlanKeys           []string
+	ImplementationKeys []string
+	// Orphans are the implementation artifacts that do not belong to the
+	// latest plan, oldest first
+	Orphans []string
+	// Context is the context state, or nil if there is none
+	Context *context.ContextState
+
+	backend storage.Backend
+	now     time.Time
+}
+
+// Load reads the state of the project in workDir.
+func Load(workDir string) (*State, error) {
+	backend, err := storage.Open(workDir)
+	if err != nil {
+		return nil, err
+	}
+	s := &State{backend: backend, now: time.Now()}
+
+	if s.FIC, err = gates.LoadFICState(workDir); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", gates.FICStateFileName, err)
+	}
+	if a, err := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactResearch); err == nil && a != nil {
+		s.Research, _ = a.(*artifacts.Research)
+	}
+	if a, err := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan); err == nil && a != nil {
+		s.Plan, _ = a.(*artifacts.Plan)
+	}
+	if s.PlanKeys, err = artifacts.Keys(backend, artifacts.ArtifactPlan); err != nil {
+		return nil, err
+	}
+	if s.ImplementationKeys, err = artifacts.Keys(backend, artifacts.ArtifactImplementation); err != nil {
+		return nil, err
+	}
+	for _, key := range s.ImplementationKeys {
+		a, err := artifacts.Get(backend, key, artifacts.ArtifactImplementation)
+		if err != nil {
+			// An unreadable implementation artifact cannot belong to the plan
+			s.Orphans = append(s.Orphans, key)
+			continue
+		}
+		if impl, _ := a.(*artifacts.Implementation); impl != nil && !s.belongsToPlan(impl) {
+			s.Orphans = append(s.Orphans, key)
+		}
+	}
+
+	data, err := backend.Get(context.ContextStateFileName)
+	if err == nil {
+		var ctx context.ContextState
+		if err := context.ContextStateSchema.Unmarshal(data, &ctx); err == nil {
+			s.Context = &ctx
+		}
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// belongsToPlan reports whether impl implements the latest plan. An
+// implementation that names no plan is taken to belong to it.
+func (s *State) belongsToPlan(impl *artifacts.Implementation) bool {
+	return s.Plan != nil && (impl.PlanArtifactID == "" || impl.PlanArtifactID == s.Plan.ID)
+}
+
+// derived returns which gates the artifacts call for open, given the
+// latest plan.
+func (s *State) derived(plan *artifacts.Plan) (researchDone, planDone bool) {
+	switch {
+	case plan != nil && plan.IsActionable():
+		return true, true
+	case plan != nil:
+		return true, false
+	case s.Research != nil && len(gates.ResearchProblems(s.Research)) == 0:
+		return true, false
+	}
+	return false, false
+}
+
+// phaseFor returns the phase matching the gates.
+func phaseFor(researchDone, planDone bool) string {
+	switch {
+	case researchDone && planDone:
+		return "implementation"
+	case researchDone:
+		return "planning"
+	}
+	return "research"
+}
+
+func knownPhase(phase string) bool {
+	for _, p := range Phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnose describes the inconsistencies in the state, all of which
+// Options.Resync fixes.
+func (s *State) Diagnose() []string {
+	var issues []string
+	add := func(format string, args ...interface{}) {
+		issues = append(issues, fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Orphans) > 0 && s.Orphans[len(s.Orphans)-1] == lastKey(s.ImplementationKeys) {
+		if s.Plan == nil {
+			add("implementation artifact %s has no plan; the phase reads IMPLEMENTATION anyway", lastKey(s.ImplementationKeys))
+		} else {
+			add("implementation artifact %s does not belong to the latest plan %s", lastKey(s.ImplementationKeys), s.Plan.ID)
+		}
+	}
+
+	researchDone, planDone := s.derived(s.Plan)
+	fic := s.FIC
+	switch {
+	case researchDone && !fic.ResearchComplete:
+		add("research is done (%s) but the research gate is closed", s.researchEvidence())
+	case !researchDone && fic.ResearchComplete:
+		add("the research gate is open but research is not done: %s", strings.Join(gates.ResearchProblems(s.Research), "; "))
+	}
+	switch {
+	case planDone && !fic.PlanValidated:
+		add("plan %s was validated (PROCEED) but the plan gate is closed, so edits are still gated", s.Plan.ID)
+	case !planDone && fic.PlanValidated:
+		add("the plan gate is open but the plan is not validated: %s", strings.Join(gates.PlanProblems(s.Plan), "; "))
+	}
+
+	if !knownPhase(fic.Phase) {
+		add("%s has an unknown phase %q", gates.FICStateFileName, fic.Phase)
+	} else if want := phaseFor(fic.ResearchComplete, fic.PlanValidated); fic.Phase != want {
+		add("the phase is %s but the gates say %s", fic.Phase, want)
+	}
+
+	if ctx := s.Context; ctx != nil {
+		if p := ctx.PendingCompaction; p != nil && s.now.Sub(p.At) > StaleCompaction {
+			add("the compaction at %s was never measured", p.At.Format("2006-01-02 15:04"))
+		}
+		if want := utilization(ctx); math.Abs(ctx.UtilizationPercent-want) > 0.01 {
+			add("context utilization is %.0f%% but the token estimate gives %.0f%%", ctx.UtilizationPercent*100, want*100)
+		}
+	}
+	return issues
+}
+
+// researchEvidence says why the artifacts show research as done.
+func (s *State) researchEvidence() string {
+	if s.Plan != nil {
+		return "plan " + s.Plan.ID + " exists"
+	}
+	return fmt.Sprintf("%s is %.0f%% confident with no blocking questions", s.Research.ID, s.Research.ConfidenceScore*100)
+}
+
+func utilization(ctx *context.ContextState) float64 {
+	return float64(ctx.TotalTokenEstimate) / float64(context.MaxContextTokens)
+}
+
+func lastKey(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[len(keys)-1]
+}
+
+// Repair makes the fixes opts selects and describes each change. With
+// dryRun it only describes them.
+func (s *State) Repair(opts Options, dryRun bool) ([]string, error) {
+	if opts.ForcePhase != "" && !knownPhase(opts.ForcePhase) {
+		return nil, fmt.Errorf("unknown phase %q (want one of %s)", opts.ForcePhase, strings.Join(Phases, ", "))
+	}
+	if opts.ForcePhase != "" && opts.Resync {
+		return nil, fmt.Errorf("-force-phase and -resync both set the gates; use one")
+	}
+
+	var changes []string
+	state := *s.FIC
+	aside := make(map[string]string)
+	plan := s.Plan
+
+	if opts.ClearPlan {
+		for _, key := range append(append([]string{}, s.PlanKeys...), s.ImplementationKeys...) {
+			aside[key] = ClearedDir
+		}
+		if len(aside) > 0 {
+			changes = append(changes, fmt.Sprintf("put aside %d plan and %d implementation artifact(s) in %s/",
+				len(s.PlanKeys), len(s.ImplementationKeys), ClearedDir))
+		}
+		plan = nil
+		state.PlanValidated = false
+		if state.Phase == "implementation" {
+			state.Phase = "planning"
+		}
+	}
+
+	var ctx *context.ContextState
+	if opts.Resync {
+		if !opts.ClearPlan && len(s.Orphans) > 0 {
+			for _, key := range s.Orphans {
+				aside[key] = OrphanedDir
+			}
+			changes = append(changes, fmt.Sprintf("put aside %d orphaned implementation artifact(s) in %s/", len(s.Orphans), OrphanedDir))
+		}
+		state.ResearchComplete, state.PlanValidated = s.derived(plan)
+		state.Phase = phaseFor(state.ResearchComplete, state.PlanValidated)
+
+		if s.Context != nil {
+			c := *s.Context
+			if p := c.PendingCompaction; p != nil && s.now.Sub(p.At) > StaleCompaction {
+				c.PendingCompaction = nil
+				changes = append(changes, "clear the unmeasured compaction from the context state")
+				ctx = &c
+			}
+			if want := utilization(&c); math.Abs(c.UtilizationPercent-want) > 0.01 {
+				c.UtilizationPercent = want
+				changes = append(changes, fmt.Sprintf("recompute context utilization as %.0f%%", want*100))
+				ctx = &c
+			}
+		}
+	}
+
+	if opts.ForcePhase != "" {
+		state.Phase = opts.ForcePhase
+		state.ResearchComplete = opts.ForcePhase != "research"
+		state.PlanValidated = opts.ForcePhase == "implementation"
+	}
+
+	stateChanged := state.Phase != s.FIC.Phase || state.ResearchComplete != s.FIC.ResearchComplete || state.PlanValidated != s.FIC.PlanValidated
+	if stateChanged {
+		changes = append(changes, fmt.Sprintf("set %s to phase %s (research gate %s, plan gate %s)",
+			gates.FICStateFileName, state.Phase, gateName(state.ResearchComplete), gateName(state.PlanValidated)))
+	}
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	err := s.backend.Update(func(tx storage.Tx) error {
+		for key, dir := range aside {
+			if err := artifacts.PutAside(tx, s.backend, key, dir); err != nil {
+				return err
+			}
+		}
+		if ctx != nil {
+			if err := ctx.Put(tx); err != nil {
+				return err
+			}
+		}
+		if stateChanged {
+			return gates.PutFICState(tx, &state)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func gateName(open bool) string {
+	if open {
+		return "open"
+	}
+	return "closed"
+}
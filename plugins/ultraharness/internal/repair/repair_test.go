@@ -0,0 +1,213 @@
+package repair
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/context"
+	"ultraharness/internal/gates"
+)
+
+func setupProject(t *testing.T, state *gates.FICState) string {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "repair-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+	if err := gates.SaveFICState(tmpDir, state); err != nil {
+		t.Fatal(err)
+	}
+	return tmpDir
+}
+
+func save(t *testing.T, workDir string, artifactType artifacts.ArtifactType, artifact interface{}) {
+	t.Helper()
+	if err := artifacts.SaveArtifact(workDir, artifactType, artifact); err != nil {
+		t.Fatal(err)
+	}
+	// Artifact names have one-second timestamps
+	time.Sleep(1100 * time.Millisecond)
+}
+
+func load(t *testing.T, workDir string) *State {
+	t.Helper()
+	s, err := Load(workDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return s
+}
+
+func wantIssues(t *testing.T, s *State, want ...string) {
+	t.Helper()
+	issues := s.Diagnose()
+	if len(issues) != len(want) {
+		t.Fatalf("Diagnose() = %q, want %d issue(s)", issues, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(issues[i], w) {
+			t.Errorf("issue %d = %q, want it to mention %q", i, issues[i], w)
+		}
+	}
+}
+
+var validated = &artifacts.ValidationResult{Recommendation: "PROCEED"}
+
+func TestDiagnose(t *testing.T) {
+	t.Run("new project", func(t *testing.T) {
+		wantIssues(t, load(t, setupProject(t, &gates.FICState{Phase: "research"})))
+	})
+
+	t.Run("consistent planning", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "planning", ResearchComplete: true})
+		save(t, tmpDir, artifacts.ArtifactPlan, &artifacts.Plan{ID: "p1"})
+		wantIssues(t, load(t, tmpDir))
+	})
+
+	t.Run("validated plan behind closed gates", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "research"})
+		save(t, tmpDir, artifacts.ArtifactPlan, &artifacts.Plan{ID: "p1", ValidationResult: validated})
+		wantIssues(t, load(t, tmpDir), "research gate is closed", "plan p1 was validated")
+	})
+
+	t.Run("gates open without artifacts", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "implementation", ResearchComplete: true, PlanValidated: true})
+		wantIssues(t, load(t, tmpDir), "no research artifact", "no plan artifact")
+	})
+
+	t.Run("phase disagrees with the gates", func(t *testing.T) {
+		wantIssues(t, load(t, setupProject(t, &gates.FICState{Phase: "implementation"})), "gates say research")
+		wantIssues(t, load(t, setupProject(t, &gates.FICState{Phase: "coding"})), "unknown phase")
+	})
+
+	t.Run("orphaned implementation", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "research"})
+		save(t, tmpDir, artifacts.ArtifactImplementation, &artifacts.Implementation{ID: "i1", PlanArtifactID: "p0"})
+		wantIssues(t, load(t, tmpDir), "has no plan")
+	})
+
+	t.Run("stale context tracking", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "research"})
+		ctx := &context.ContextState{
+			TotalTokenEstimate: 100000,
+			UtilizationPercent: 0.9,
+			PendingCompaction:  &context.CompactionResult{At: time.Now().Add(-48 * time.Hour)},
+		}
+		if err := ctx.Save(tmpDir); err != nil {
+			t.Fatal(err)
+		}
+		wantIssues(t, load(t, tmpDir), "never measured", "utilization is 90%")
+	})
+}
+
+func TestRepair(t *testing.T) {
+	t.Run("resync", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "research"})
+		save(t, tmpDir, artifacts.ArtifactImplementation, &artifacts.Implementation{ID: "i0", PlanArtifactID: "p0"})
+		save(t, tmpDir, artifacts.ArtifactPlan, &artifacts.Plan{ID: "p1", ValidationResult: validated})
+		save(t, tmpDir, artifacts.ArtifactImplementation, &artifacts.Implementation{ID: "i1", PlanArtifactID: "p1"})
+
+		s := load(t, tmpDir)
+		changes, err := s.Repair(Options{Resync: true}, true)
+		if err != nil || len(changes) != 2 {
+			t.Fatalf("Repair(dry run) = %q, %v, want the orphan and the state", changes, err)
+		}
+		if state, _ := gates.LoadFICState(tmpDir); state.ResearchComplete {
+			t.Fatal("a dry run changed the state")
+		}
+
+		if _, err := s.Repair(Options{Resync: true}, false); err != nil {
+			t.Fatalf("Repair() error = %v", err)
+		}
+		state, _ := gates.LoadFICState(tmpDir)
+		if state.Phase != "implementation" || !state.ResearchComplete || !state.PlanValidated {
+			t.Errorf("state = %+v, want the gates open for the validated plan", state)
+		}
+		if result := gates.CheckGate(gates.GateAllowEdit, tmpDir, "strict"); result.Action != gates.ActionAllow {
+			t.Errorf("CheckGate() = %v, want edits allowed", result.Action)
+		}
+		orphaned, _ := os.ReadDir(filepath.Join(artifacts.GetArtifactDir(tmpDir, artifacts.ArtifactImplementation), OrphanedDir))
+		if len(orphaned) != 1 {
+			t.Errorf("put aside %d implementation artifact(s), want the one for p0", len(orphaned))
+		}
+		if latest, _ := artifacts.GetLatestArtifact(tmpDir, artifacts.ArtifactImplementation); latest.(*artifacts.Implementation).ID != "i1" {
+			t.Errorf("latest implementation = %+v, want i1 kept", latest)
+		}
+		wantIssues(t, load(t, tmpDir))
+	})
+
+	t.Run("resync context", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "research"})
+		ctx := &context.ContextState{
+			SessionID:          "s1",
+			TotalTokenEstimate: 100000,
+			UtilizationPercent: 0.9,
+			PendingCompaction:  &context.CompactionResult{At: time.Now().Add(-48 * time.Hour)},
+		}
+		if err := ctx.Save(tmpDir); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := load(t, tmpDir).Repair(Options{Resync: true}, false); err != nil {
+			t.Fatal(err)
+		}
+		s := load(t, tmpDir)
+		if s.Context.PendingCompaction != nil || s.Context.UtilizationPercent != 0.5 || s.Context.SessionID != "s1" {
+			t.Errorf("context = %+v, want the compaction cleared and utilization recomputed", s.Context)
+		}
+	})
+
+	t.Run("clear plan", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "implementation", ResearchComplete: true, PlanValidated: true})
+		save(t, tmpDir, artifacts.ArtifactPlan, &artifacts.Plan{ID: "p1", ValidationResult: validated})
+		save(t, tmpDir, artifacts.ArtifactImplementation, &artifacts.Implementation{ID: "i1", PlanArtifactID: "p1"})
+
+		if _, err := load(t, tmpDir).Repair(Options{ClearPlan: true}, false); err != nil {
+			t.Fatal(err)
+		}
+		state, _ := gates.LoadFICState(tmpDir)
+		if state.Phase != "planning" || !state.ResearchComplete || state.PlanValidated {
+			t.Errorf("state = %+v, want planning with the plan gate closed", state)
+		}
+		if phase := artifacts.GetCurrentPhase(tmpDir); phase != "NEW_SESSION" {
+			t.Errorf("GetCurrentPhase() = %s, want no plan or implementation left", phase)
+		}
+		cleared, _ := os.ReadDir(filepath.Join(artifacts.GetArtifactDir(tmpDir, artifacts.ArtifactPlan), ClearedDir))
+		if len(cleared) != 1 {
+			t.Errorf("put aside %d plan(s), want 1", len(cleared))
+		}
+	})
+
+	t.Run("force phase", func(t *testing.T) {
+		tmpDir := setupProject(t, &gates.FICState{Phase: "research"})
+		changes, err := load(t, tmpDir).Repair(Options{ForcePhase: "planning"}, false)
+		if err != nil || len(changes) != 1 {
+			t.Fatalf("Repair() = %q, %v", changes, err)
+		}
+		state, _ := gates.LoadFICState(tmpDir)
+		if state.Phase != "planning" || !state.ResearchComplete || state.PlanValidated {
+			t.Errorf("state = %+v, want planning with the research gate open", state)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		s := load(t, setupProject(t, &gates.FICState{Phase: "research"}))
+		if _, err := s.Repair(Options{ForcePhase: "coding"}, true); err == nil {
+			t.Error("Repair() with an unknown phase succeeded")
+		}
+		if _, err := s.Repair(Options{ForcePhase: "planning", Resync: true}, true); err == nil {
+			t.Error("Repair() with -force-phase and -resync succeeded")
+		}
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		changes, err := load(t, setupProject(t, &gates.FICState{Phase: "research"})).Repair(Options{Resync: true}, false)
+		if err != nil || len(changes) != 0 {
+			t.Errorf("Repair() = %q, %v, want no changes", changes, err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+// Package researchqueue holds research questions deferred during
+// implementation, so exploration does not derail the current step and the
+// questions are picked up at the next phase boundary.
+//
+// The queue is a plain JSON file in .claude, like the approval queue.
+package researchqueue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the research queue file inside .claude.
+const FileName = "research-queue.json"
+
+// FilePermission is the permission for the research queue
+const FilePermission = 0600
+
+// DirPermission is the permission for the queue directory
+const DirPermission = 0700
+
+// Item statuses.
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+)
+
+// Item is one deferred research question.
+type Item struct {
+	ID       int       `json:"id"`
+	Prompt   string    `json:"prompt"`
+	Status   string    `json:"status"`
+	QueuedAt time.Time `json:"queued_at"`
+	// Phase is the FIC phase the question arrived in
+	Phase     string     `json:"phase,omitempty"`
+	SessionID string     `json:"session_id,omitempty"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+}
+
+// Queue is the list of deferred research questions, oldest first.
+type Queue struct {
+	Items []Item `json:"items"`
+}
+
+// GetPath returns the path to the research queue file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the research queue. A missing file is an empty queue.
+func Load(workDir string) (*Queue, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Queue{}, nil
+		}
+		return nil, err
+	}
+
+	var q Queue
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// Save writes the research queue.
+func (q *Queue) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// Add queues a research question. A question already pending, ignoring
+// case and surrounding whitespace, is not queued again. Returns the item
+// and whether it was added.
+func (q *Queue) Add(prompt, phase, sessionID string) (*Item, bool) {
+	prompt = strings.TrimSpace(prompt)
+	for i := range q.Items {
+		if q.Items[i].Status == StatusPending && strings.EqualFold(q.Items[i].Prompt, prompt) {
+			return &q.Items[i], false
+		}
+	}
+
+	id := 1
+	for _, item := range q.Items {
+		if item.ID >= id {
+			id = item.ID + 1
+		}
+	}
+	q.Items = append(q.Items, Item{
+		ID:        id,
+		Prompt:    prompt,
+		Status:    StatusPending,
+		QueuedAt:  time.Now(),
+		Phase:     phase,
+		SessionID: sessionID,
+	})
+	return &q.Items[len(q.Items)-1], true
+}
+
+// Pending returns the questions not yet researched, oldest first.
+func (q *Queue) Pending() []Item {
+	var pending []Item
+	for _, item := range q.Items {
+		if item.Status != StatusDone {
+			pending = append(pending, item)
+		}
+	}
+	return pending
+}
+
+// Resolve marks the pending items with the given IDs as done and returns
+// the IDs it changed.
+func (q *Queue) Resolve(ids ...int) []int {
+	now := time.Now()
+	var resolved []int
+	for _, id := range ids {
+		for i := range q.Items {
+			if q.Items[i].ID == id && q.Items[i].Status != StatusDone {
+				q.Items[i].Status = StatusDone
+				q.Items[i].DoneAt = &now
+				resolved = append(resolved, id)
+			}
+		}
+	}
+	return resolved
+}
+
+// Prune removes resolved items and returns how many it removed.
+func (q *Queue) Prune() int {
+	kept := q.Items[:0]
+	for _, item := range q.Items {
+		if item.Status != StatusDone {
+			kept = append(kept, item)
+		}
+	}
+	removed := len(q.Items) - len(kept)
+	q.Items = kept
+	return removed
+}
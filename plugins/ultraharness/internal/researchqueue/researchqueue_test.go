@@ -0,0 +1,63 @@
+package researchqueue
+
+import (
+	"os"
+	"testing"
+)
+
+func TestQueueLifecycle(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "researchqueue-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	q, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() missing file error = %v", err)
+	}
+
+	first, added := q.Add("How does the cache invalidate entries?", "IMPLEMENTATION", "s1")
+	if !added || first.ID != 1 || first.Status != StatusPending {
+		t.Fatalf("Add() = %+v, %v, want pending item 1", first, added)
+	}
+	if item, added := q.Add("  how does the cache invalidate entries?\n", "IMPLEMENTATION", "s1"); added || item.ID != 1 {
+		t.Errorf("Add() of a pending question = %+v, %v, want item 1 not added", item, added)
+	}
+	if second, _ := q.Add("Where is retry configured?", "IMPLEMENTATION", "s1"); second.ID != 2 {
+		t.Errorf("Add() ID = %d, want 2", second.ID)
+	}
+	if err := q.Save(tmpDir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := loaded.Pending(); len(got) != 2 || got[0].Prompt != "How does the cache invalidate entries?" {
+		t.Fatalf("Pending() = %+v, want both questions, oldest first", got)
+	}
+
+	if resolved := loaded.Resolve(1, 7); len(resolved) != 1 || resolved[0] != 1 {
+		t.Errorf("Resolve() = %v, want [1]", resolved)
+	}
+	if resolved := loaded.Resolve(1); len(resolved) != 0 {
+		t.Errorf("Resolve() of a done item = %v, want none", resolved)
+	}
+	if got := loaded.Pending(); len(got) != 1 || got[0].ID != 2 {
+		t.Errorf("Pending() after Resolve = %+v, want item 2", got)
+	}
+
+	// A resolved question may be asked again
+	if _, added := loaded.Add("How does the cache invalidate entries?", "RESEARCH", "s2"); !added {
+		t.Error("Add() of a resolved question = false, want true")
+	}
+
+	if removed := loaded.Prune(); removed != 1 || len(loaded.Items) != 2 {
+		t.Errorf("Prune() = %d leaving %d items, want 1 leaving 2", removed, len(loaded.Items))
+	}
+	if item, _ := loaded.Add("Why is the build slow?", "RESEARCH", "s2"); item.ID != 4 {
+		t.Errorf("Add() after Prune ID = %d, want 4", item.ID)
+	}
+}
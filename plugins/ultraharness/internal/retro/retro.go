@@ -0,0 +1,302 @@
+// Package retro writes a retrospective when a feature in the checklist
+// starts passing: planned versus completed plan steps, deviations from the
+// plan, time spent in each FIC phase, test runs, and files touched. Each
+// retrospective's summary also goes to the knowledge base, so over time a
+// project collects data on how its tasks actually went.
+//
+// Retrospectives, and the feature statuses and test counts they are built
+// from, are stored in .claude/retrospectives.json.
+package retro
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+	"ultraharness/internal/testrunner"
+)
+
+// FileName is the retrospectives file inside .claude.
+const FileName = "retrospectives.json"
+
+// FilePermission is the permission for the retrospectives file
+const FilePermission = 0600
+
+// DirPermission is the permission for the retrospectives directory
+const DirPermission = 0700
+
+// MaxRetrospectives caps the log; the oldest retrospectives are dropped
+// first.
+const MaxRetrospectives = 100
+
+// PhaseTime is the time spent in one FIC phase.
+type PhaseTime struct {
+	Phase   string `json:"phase"`
+	Minutes int    `json:"minutes"`
+}
+
+// Retrospective describes how the work on a feature went.
+type Retrospective struct {
+	FeatureID   string    `json:"feature_id"`
+	Feature     string    `json:"feature"`
+	CompletedAt time.Time `json:"completed_at"`
+	// Goal is the plan's goal, if a plan was saved for the feature
+	Goal           string `json:"goal,omitempty"`
+	PlannedSteps   int    `json:"planned_steps"`
+	CompletedSteps int    `json:"completed_steps"`
+	// SkippedSteps are planned steps that were never completed
+	SkippedSteps []string `json:"skipped_steps,omitempty"`
+	// UnplannedSteps were completed without being in the plan
+	UnplannedSteps []string    `json:"unplanned_steps,omitempty"`
+	Deviations     []string    `json:"deviations,omitempty"`
+	Phases         []PhaseTime `json:"phases,omitempty"`
+	TestRuns       int         `json:"test_runs"`
+	TestFailures   int         `json:"test_failures"`
+	FilesTouched   []string    `json:"files_touched,omitempty"`
+	// TestFilesTouched counts the test files among FilesTouched
+	TestFilesTouched int `json:"test_files_touched"`
+}
+
+// Log is the project's retrospectives, oldest first, and the state the
+// next one is built from.
+type Log struct {
+	// Statuses are the feature statuses at the last check
+	Statuses map[string]string `json:"statuses"`
+	// Since starts the window the next retrospective covers: the last
+	// retrospective, or the first check
+	Since time.Time `json:"since"`
+	// Head is the commit checked out when the window started
+	Head           string          `json:"head,omitempty"`
+	TestRuns       int             `json:"test_runs"`
+	TestFailures   int             `json:"test_failures"`
+	Retrospectives []Retrospective `json:"retrospectives,omitempty"`
+}
+
+// GetPath returns the path to the retrospectives file.
+func GetPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", FileName)
+}
+
+// Load reads the retrospectives. A missing file is an empty log.
+func Load(workDir string) (*Log, error) {
+	data, err := os.ReadFile(GetPath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Log{}, nil
+		}
+		return nil, err
+	}
+
+	var l Log
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Save writes the retrospectives.
+func (l *Log) Save(workDir string) error {
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), DirPermission); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetPath(workDir), append(data, '\n'), FilePermission)
+}
+
+// RecordTestRun counts a test run toward the next retrospective.
+func (l *Log) RecordTestRun(passed bool) {
+	l.TestRuns++
+	if !passed {
+		l.TestFailures++
+	}
+}
+
+// Check compares the checklist with the statuses at the last check and
+// writes a retrospective for each known feature that now passes. The
+// first check only records the statuses. Returns the new retrospectives.
+func (l *Log) Check(workDir string, data *features.FeaturesData) []Retrospective {
+	now := time.Now()
+	first := l.Statuses == nil
+
+	var done []Retrospective
+	statuses := make(map[string]string, len(data.Features))
+	for _, f := range data.Features {
+		statuses[f.ID] = f.Status
+		previous, known := l.Statuses[f.ID]
+		if known && previous != features.StatusPassing && f.Status == features.StatusPassing {
+			done = append(done, l.build(workDir, f, now))
+		}
+	}
+	l.Statuses = statuses
+
+	if first || len(done) > 0 {
+		l.Since, l.Head = now, git.Head(workDir)
+		l.TestRuns, l.TestFailures = 0, 0
+	}
+	l.Retrospectives = append(l.Retrospectives, done...)
+	if len(l.Retrospectives) > MaxRetrospectives {
+		l.Retrospectives = l.Retrospectives[len(l.Retrospectives)-MaxRetrospectives:]
+	}
+	return done
+}
+
+// build writes the retrospective of a feature completed at now, from the
+// plan and implementation saved since the window started.
+func (l *Log) build(workDir string, f features.Feature, now time.Time) Retrospective {
+	r := Retrospective{
+		FeatureID:    f.ID,
+		Feature:      f.Name,
+		CompletedAt:  now,
+		Phases:       phaseTimes(workDir, l.Since, now),
+		TestRuns:     l.TestRuns,
+		TestFailures: l.TestFailures,
+	}
+
+	var impl *artifacts.Implementation
+	if savedSince(workDir, artifacts.ArtifactImplementation, l.Since) {
+		latest, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactImplementation)
+		impl, _ = latest.(*artifacts.Implementation)
+	}
+	done := map[string]bool{}
+	if impl != nil {
+		for _, id := range impl.StepsCompleted {
+			done[id] = true
+		}
+		r.Deviations = impl.PlanDeviations
+	}
+
+	if savedSince(workDir, artifacts.ArtifactPlan, l.Since) {
+		latest, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan)
+		if plan, ok := latest.(*artifacts.Plan); ok && plan != nil {
+			r.Goal = plan.Goal
+			r.PlannedSteps = len(plan.Steps)
+			for _, step := range plan.Steps {
+				if step.Completed || done[step.ID] {
+					r.CompletedSteps++
+				} else {
+					r.SkippedSteps = append(r.SkippedSteps, step.Description)
+				}
+				delete(done, step.ID)
+			}
+		}
+	}
+	for id := range done {
+		r.UnplannedSteps = append(r.UnplannedSteps, id)
+		r.CompletedSteps++
+	}
+	sort.Strings(r.UnplannedSteps)
+
+	r.FilesTouched = filesTouched(workDir, l.Head)
+	for _, file := range r.FilesTouched {
+		if testrunner.IsTestFile(file) {
+			r.TestFilesTouched++
+		}
+	}
+	return r
+}
+
+// savedSince reports whether the latest artifact of the given type was
+// saved after since, rather than for an earlier task.
+func savedSince(workDir string, artifactType artifacts.ArtifactType, since time.Time) bool {
+	times := artifacts.SavedTimes(workDir, artifactType)
+	return len(times) > 0 && !times[len(times)-1].Before(since.Truncate(time.Second))
+}
+
+// phaseTimes splits the window from since to now into the research,
+// planning, and implementation phases, each starting when its first
+// artifact was saved. Phases without an artifact are left out.
+func phaseTimes(workDir string, since, now time.Time) []PhaseTime {
+	phases := []struct {
+		name         string
+		artifactType artifacts.ArtifactType
+	}{
+		{"research", artifacts.ArtifactResearch},
+		{"planning", artifacts.ArtifactPlan},
+		{"implementation", artifacts.ArtifactImplementation},
+	}
+
+	var names []string
+	var starts []time.Time
+	for _, p := range phases {
+		for _, t := range artifacts.SavedTimes(workDir, p.artifactType) {
+			if !t.Before(since.Truncate(time.Second)) {
+				names = append(names, p.name)
+				starts = append(starts, t)
+				break
+			}
+		}
+	}
+
+	times := make([]PhaseTime, len(names))
+	for i, name := range names {
+		end := now
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		times[i] = PhaseTime{Phase: name, Minutes: int(end.Sub(starts[i]).Minutes())}
+	}
+	return times
+}
+
+// filesTouched returns the files changed since head, committed or not,
+// and the untracked files, leaving out the harness's own state.
+func filesTouched(workDir, head string) []string {
+	if !git.IsRepo(workDir) {
+		return nil
+	}
+	var changed []string
+	if head != "" {
+		changed = git.ChangedFilesSince(workDir, head)
+	}
+	changed = append(changed, git.ModifiedFiles(workDir)...)
+
+	seen := map[string]bool{}
+	var files []string
+	for _, file := range changed {
+		if seen[file] || strings.HasPrefix(file, ".claude/") {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Summary renders the retrospective in one line.
+func (r Retrospective) Summary() string {
+	parts := []string{fmt.Sprintf("%d/%d planned steps completed", r.CompletedSteps-len(r.UnplannedSteps), r.PlannedSteps)}
+	if n := len(r.UnplannedSteps); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d unplanned", n))
+	}
+	if n := len(r.Deviations); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d deviations", n))
+	}
+	if len(r.Phases) > 0 {
+		var phases []string
+		for _, p := range r.Phases {
+			phases = append(phases, p.Phase+" "+formatMinutes(p.Minutes))
+		}
+		parts = append(parts, strings.Join(phases, ", "))
+	}
+	parts = append(parts, fmt.Sprintf("%d test runs (%d failing)", r.TestRuns, r.TestFailures),
+		fmt.Sprintf("%d files touched (%d tests)", len(r.FilesTouched), r.TestFilesTouched))
+	return fmt.Sprintf("Retrospective for %s (%s): %s", r.FeatureID, r.Feature, strings.Join(parts, "; "))
+}
+
+func formatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
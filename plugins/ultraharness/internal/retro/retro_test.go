@@ -0,0 +1,152 @@
+package retro
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/features"
+)
+
+func checklist(statuses ...string) *features.FeaturesData {
+	data := &features.FeaturesData{}
+	for i, status := range statuses {
+		id := string(rune('A' + i))
+		data.Features = append(data.Features, features.Feature{ID: id, Name: "Feature " + id, Status: status})
+	}
+	return data
+}
+
+func TestCheck(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "retro-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	log := &Log{}
+	if done := log.Check(tmpDir, checklist(features.StatusPassing, features.StatusPending)); done != nil {
+		t.Fatalf("first Check() = %+v, want only the statuses recorded", done)
+	}
+
+	log.RecordTestRun(false)
+	log.RecordTestRun(true)
+	done := log.Check(tmpDir, checklist(features.StatusPassing, features.StatusPassing, features.StatusPassing))
+	if len(done) != 1 || done[0].FeatureID != "B" {
+		t.Fatalf("Check() = %+v, want a retrospective for B only", done)
+	}
+	if done[0].TestRuns != 2 || done[0].TestFailures != 1 {
+		t.Errorf("TestRuns, TestFailures = %d, %d, want 2, 1", done[0].TestRuns, done[0].TestFailures)
+	}
+	if log.TestRuns != 0 || len(log.Retrospectives) != 1 {
+		t.Errorf("after Check(): TestRuns = %d, %d retrospectives, want 0 and 1", log.TestRuns, len(log.Retrospectives))
+	}
+
+	if done := log.Check(tmpDir, checklist(features.StatusPassing, features.StatusPassing, features.StatusPassing)); done != nil {
+		t.Errorf("Check() without changes = %+v, want none", done)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "retro-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	exec.Command("git", "init", "-q", tmpDir).Run()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte("package app\n"), 0644)
+	git("add", ".")
+	git("commit", "-qm", "initial")
+
+	log := &Log{}
+	log.Check(tmpDir, checklist(features.StatusInProgress))
+
+	artifacts.SaveArtifact(tmpDir, artifacts.ArtifactPlan, &artifacts.Plan{
+		Goal: "Add login",
+		Steps: []artifacts.PlanStep{
+			{ID: "1", Description: "Add model", Completed: true},
+			{ID: "2", Description: "Add handler"},
+			{ID: "3", Description: "Add docs"},
+		},
+	})
+	artifacts.SaveArtifact(tmpDir, artifacts.ArtifactImplementation, &artifacts.Implementation{
+		StepsCompleted: []string{"2", "2b"},
+		PlanDeviations: []string{"Split the handler"},
+	})
+	os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte("package app\n\nfunc Login() {}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "app_test.go"), []byte("package app\n"), 0644)
+
+	done := log.Check(tmpDir, checklist(features.StatusPassing))
+	if len(done) != 1 {
+		t.Fatalf("Check() = %+v, want one retrospective", done)
+	}
+	r := done[0]
+	if r.Goal != "Add login" || r.PlannedSteps != 3 || r.CompletedSteps != 3 {
+		t.Errorf("Goal, PlannedSteps, CompletedSteps = %q, %d, %d, want Add login, 3, 3", r.Goal, r.PlannedSteps, r.CompletedSteps)
+	}
+	if !reflect.DeepEqual(r.SkippedSteps, []string{"Add docs"}) || !reflect.DeepEqual(r.UnplannedSteps, []string{"2b"}) {
+		t.Errorf("SkippedSteps, UnplannedSteps = %v, %v, want [Add docs], [2b]", r.SkippedSteps, r.UnplannedSteps)
+	}
+	if !reflect.DeepEqual(r.Deviations, []string{"Split the handler"}) {
+		t.Errorf("Deviations = %v, want the implementation's deviation", r.Deviations)
+	}
+	if len(r.Phases) != 2 || r.Phases[0].Phase != "planning" || r.Phases[1].Phase != "implementation" {
+		t.Errorf("Phases = %+v, want planning then implementation", r.Phases)
+	}
+	if !reflect.DeepEqual(r.FilesTouched, []string{"app.go", "app_test.go"}) || r.TestFilesTouched != 1 {
+		t.Errorf("FilesTouched = %v (%d tests), want app.go and app_test.go (1 test)", r.FilesTouched, r.TestFilesTouched)
+	}
+
+	summary := r.Summary()
+	for _, want := range []string{"Retrospective for A (Feature A)", "2/3 planned steps completed", "1 unplanned", "1 deviations", "2 files touched (1 tests)"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Summary() = %q, want it to contain %q", summary, want)
+		}
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "retro-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	log, err := Load(tmpDir)
+	if err != nil || log.Statuses != nil {
+		t.Fatalf("Load() without a file = %+v, %v, want an empty log", log, err)
+	}
+	log.Check(tmpDir, checklist(features.StatusPending))
+	log.RecordTestRun(false)
+	if err := log.Save(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Statuses["A"] != features.StatusPending || loaded.TestFailures != 1 {
+		t.Errorf("Load() = %+v, want the saved statuses and test counts", loaded)
+	}
+}
+
+func TestFormatMinutes(t *testing.T) {
+	tests := map[int]string{0: "0m", 45: "45m", 60: "1h00m", 135: "2h15m"}
+	for minutes, want := range tests {
+		if got := formatMinutes(minutes); got != want {
+			t.Errorf("formatMinutes(%d) = %q, want %q", minutes, got, want)
+		}
+	}
+}
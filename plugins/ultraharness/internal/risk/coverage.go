@@ -0,0 +1,118 @@
+package risk
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CoverageFiles are the coverage profiles looked for when none is
+// configured, relative to the project root.
+var CoverageFiles = []string{
+	"coverage.out", "cover.out", "coverage.txt", "c.out",
+	"coverage/lcov.info", "lcov.info",
+}
+
+// Coverage records, for each file in a coverage profile, whether any of
+// its lines ran.
+type Coverage map[string]bool
+
+// LoadCoverage reads the configured coverage profile, or the first of
+// CoverageFiles that exists. Go cover profiles and LCOV are understood. It
+// returns the profile's path relative to workDir, or "" if there is none.
+func LoadCoverage(workDir, configured string) (string, Coverage) {
+	candidates := CoverageFiles
+	if configured != "" {
+		candidates = []string{configured}
+	}
+	for _, name := range candidates {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, name)
+		}
+		if coverage, ok := parseCoverage(workDir, path); ok {
+			return filepath.ToSlash(name), coverage
+		}
+	}
+	return "", nil
+}
+
+// Covers reports whether any line of path ran. Profile entries match by
+// path suffix, since Go profiles name files by import path and LCOV often
+// by absolute path.
+func (c Coverage) Covers(path string) bool {
+	path = filepath.ToSlash(path)
+	for file, covered := range c {
+		if covered && (file == path || strings.HasSuffix(file, "/"+path) || strings.HasSuffix(path, "/"+file)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCoverage reads a Go cover profile, whose first line is the mode, or
+// an LCOV tracefile.
+func parseCoverage(workDir, path string) (Coverage, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	coverage := Coverage{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return nil, false
+	}
+	first := scanner.Text()
+
+	if strings.HasPrefix(first, "mode:") {
+		// name.go:line.col,line.col statements count
+		for scanner.Scan() {
+			line := scanner.Text()
+			colon := strings.LastIndex(line, ":")
+			fields := strings.Fields(line)
+			if colon < 0 || len(fields) != 3 {
+				continue
+			}
+			file := line[:colon]
+			count, _ := strconv.Atoi(fields[2])
+			coverage[file] = coverage[file] || count > 0
+		}
+		return coverage, true
+	}
+
+	// SF:<file>, DA:<line>,<hits> records, end_of_record
+	var file string
+	record := func(line string) {
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = filepath.ToSlash(strings.TrimPrefix(line, "SF:"))
+			if filepath.IsAbs(file) {
+				if rel, err := filepath.Rel(workDir, file); err == nil {
+					file = filepath.ToSlash(rel)
+				}
+			}
+			coverage[file] = coverage[file]
+		case strings.HasPrefix(line, "DA:") && file != "":
+			parts := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(parts) >= 2 {
+				hits, _ := strconv.Atoi(parts[1])
+				coverage[file] = coverage[file] || hits > 0
+			}
+		case line == "end_of_record":
+			file = ""
+		}
+	}
+	record(first)
+	for scanner.Scan() {
+		record(scanner.Text())
+	}
+	if len(coverage) == 0 {
+		return nil, false
+	}
+	return coverage, true
+}
@@ -0,0 +1,159 @@
+// Package risk scores how risky an edit to a file is, from how critical
+// the file is, how large its uncommitted diff has grown, whether tests
+// cover it, and how often it needed fixing recently. PostToolUse surfaces
+// the score after each edit, and PreToolUse requires a checkpoint commit
+// before HIGH risk edits in strict mode.
+package risk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/git"
+	"ultraharness/internal/glob"
+	"ultraharness/internal/testrunner"
+)
+
+// Risk levels
+const (
+	Low    = "LOW"
+	Medium = "MEDIUM"
+	High   = "HIGH"
+)
+
+// Scores at which an edit becomes MEDIUM and HIGH risk
+const (
+	MediumScore = 2
+	HighScore   = 4
+)
+
+// Assessment is the risk of an edit to one file.
+type Assessment struct {
+	Path  string
+	Level string
+	Score int
+	// Factors describe what contributed to the score
+	Factors []string
+}
+
+// incidentPattern matches commit subjects that fixed or undid a problem
+var incidentPattern = regexp.MustCompile(`(?i)\b(fix(es|ed)?|hotfix|revert|rollback|incident|outage)\b`)
+
+// Assess scores an edit to path, whose uncommitted diff will be lines
+// lines after the edit:
+//
+//   - matching a critical path glob: +3
+//   - a diff of at least large_diff_lines: +2, or a quarter of that: +1
+//   - a code file the coverage profile shows uncovered: +2, or with no
+//     coverage profile and no test file next to it: +1
+//   - fix or revert commits in the incident window: +1, or +2 for three
+//     or more
+//
+// A score of 4 is HIGH and 2 is MEDIUM. isCode reports whether a path,
+// relative to workDir, is a code file.
+func Assess(workDir string, settings config.RiskConfig, path string, lines int, isCode func(string) bool) Assessment {
+	rel := relPath(workDir, path)
+	a := Assessment{Path: rel}
+	add := func(points int, factor string) {
+		a.Score += points
+		a.Factors = append(a.Factors, factor)
+	}
+
+	for _, pattern := range settings.CriticalPaths {
+		if glob.Match(pattern, rel) {
+			add(3, fmt.Sprintf("critical path (%s)", pattern))
+			break
+		}
+	}
+
+	switch {
+	case lines >= settings.LargeDiffLines:
+		add(2, fmt.Sprintf("%d uncommitted lines", lines))
+	case lines >= settings.LargeDiffLines/4:
+		add(1, fmt.Sprintf("%d uncommitted lines", lines))
+	}
+
+	if isCode(rel) && !testrunner.IsTestFile(rel) {
+		if profile, covered := LoadCoverage(workDir, settings.CoverageFile); profile != "" {
+			if !covered.Covers(rel) {
+				add(2, "not covered by tests in "+profile)
+			}
+		} else if !HasTestFile(workDir, rel) {
+			add(1, "no test file found")
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -settings.IncidentWindowDays)
+	incidents := 0
+	for _, subject := range git.SubjectsSince(workDir, since, rel) {
+		if incidentPattern.MatchString(subject) {
+			incidents++
+		}
+	}
+	switch {
+	case incidents >= 3:
+		add(2, fmt.Sprintf("%d fix or revert commits in the last %d days", incidents, settings.IncidentWindowDays))
+	case incidents > 0:
+		add(1, fmt.Sprintf("%d fix or revert commit(s) in the last %d days", incidents, settings.IncidentWindowDays))
+	}
+
+	a.Level = Level(a.Score)
+	return a
+}
+
+// Level returns the risk level of a score.
+func Level(score int) string {
+	switch {
+	case score >= HighScore:
+		return High
+	case score >= MediumScore:
+		return Medium
+	default:
+		return Low
+	}
+}
+
+// Summary describes the assessment in one line.
+func (a Assessment) Summary() string {
+	if len(a.Factors) == 0 {
+		return fmt.Sprintf("%s risk edit to %s", a.Level, a.Path)
+	}
+	return fmt.Sprintf("%s risk edit to %s: %s", a.Level, a.Path, strings.Join(a.Factors, ", "))
+}
+
+// relPath returns path relative to workDir with forward slashes.
+func relPath(workDir, path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(workDir, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// HasTestFile reports whether a conventional test file exists for path:
+// name_test, name.test, name.spec, name_spec, test_name, or nameTest, next
+// to it or in a tests, test, spec, or __tests__ directory beside it.
+func HasTestFile(workDir, path string) bool {
+	dir := filepath.Join(workDir, filepath.Dir(path))
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext)
+
+	candidates := []string{
+		name + "_test" + ext, name + ".test" + ext, name + ".spec" + ext,
+		name + "_spec" + ext, "test_" + name + ext, name + "Test" + ext,
+	}
+	for _, sub := range []string{"", "tests", "test", "spec", "__tests__"} {
+		for _, c := range candidates {
+			if _, err := os.Stat(filepath.Join(dir, sub, c)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package risk
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/git"
+)
+
+// isCode is the built-in code file rule, without code_files config.
+func isCode(path string) bool {
+	return git.IsCodeFile(path, nil, nil)
+}
+
+func createRepo(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "risk-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test User"},
+	} {
+		exec.Command("git", append([]string{"-C", dir}, args...)...).Run()
+	}
+	return dir
+}
+
+func commit(dir, file, content, subject string) {
+	os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755)
+	os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)
+	exec.Command("git", "-C", dir, "add", ".").Run()
+	exec.Command("git", "-C", dir, "commit", "-q", "-m", subject).Run()
+}
+
+func TestAssess(t *testing.T) {
+	dir := createRepo(t)
+	defer os.RemoveAll(dir)
+
+	commit(dir, "auth/login.go", "package auth\n", "add login")
+	commit(dir, "util/strings.go", "package util\n", "add strings")
+	commit(dir, "util/strings_test.go", "package util\n", "test strings")
+
+	settings := (&config.Config{Risk: &config.RiskConfig{CriticalPaths: []string{"auth/**"}}}).GetRisk()
+
+	t.Run("tested file with a small diff is low risk", func(t *testing.T) {
+		a := Assess(dir, settings, filepath.Join(dir, "util/strings.go"), 3, isCode)
+		if a.Level != Low || a.Score != 0 || a.Path != "util/strings.go" {
+			t.Errorf("Assess() = %+v, want LOW with no factors", a)
+		}
+	})
+
+	t.Run("untested critical file is high risk", func(t *testing.T) {
+		a := Assess(dir, settings, "auth/login.go", 3, isCode)
+		if a.Level != High || a.Score != 4 {
+			t.Errorf("Assess() = %+v, want HIGH with score 4", a)
+		}
+		if !strings.Contains(a.Summary(), "critical path (auth/**)") || !strings.Contains(a.Summary(), "no test file found") {
+			t.Errorf("Summary() = %q, want the critical path and missing tests", a.Summary())
+		}
+	})
+
+	t.Run("large diff", func(t *testing.T) {
+		if a := Assess(dir, settings, "util/strings.go", 60, isCode); a.Score != 1 {
+			t.Errorf("Assess() with 60 lines = %+v, want score 1", a)
+		}
+		if a := Assess(dir, settings, "util/strings.go", 250, isCode); a.Level != Medium || a.Score != 2 {
+			t.Errorf("Assess() with 250 lines = %+v, want MEDIUM with score 2", a)
+		}
+	})
+
+	t.Run("recent fixes", func(t *testing.T) {
+		commit(dir, "util/strings.go", "package util\n\n", "Fix panic on empty input")
+		if a := Assess(dir, settings, "util/strings.go", 0, isCode); a.Score != 1 {
+			t.Errorf("Assess() after one fix = %+v, want score 1", a)
+		}
+		commit(dir, "util/strings.go", "package util\n", "Revert \"Fix panic on empty input\"")
+		commit(dir, "util/strings.go", "package util\n\n", "hotfix: empty input")
+		if a := Assess(dir, settings, "util/strings.go", 0, isCode); a.Level != Medium || a.Score != 2 {
+			t.Errorf("Assess() after three fixes = %+v, want MEDIUM with score 2", a)
+		}
+	})
+
+	t.Run("coverage profile", func(t *testing.T) {
+		os.WriteFile(filepath.Join(dir, "coverage.out"), []byte("mode: set\n"+
+			"example.com/app/auth/login.go:3.1,5.2 2 1\n"+
+			"example.com/app/util/strings.go:3.1,5.2 2 0\n"), 0644)
+		defer os.Remove(filepath.Join(dir, "coverage.out"))
+
+		if a := Assess(dir, settings, "auth/login.go", 0, isCode); a.Score != 3 {
+			t.Errorf("Assess() of a covered file = %+v, want only the critical path", a)
+		}
+		a := Assess(dir, settings, "util/strings.go", 0, isCode)
+		if !strings.Contains(a.Summary(), "not covered by tests in coverage.out") {
+			t.Errorf("Assess() of an uncovered file = %+v, want it reported uncovered", a)
+		}
+	})
+}
+
+func TestLoadCoverage(t *testing.T) {
+	dir, err := os.MkdirTemp("", "risk-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if profile, _ := LoadCoverage(dir, ""); profile != "" {
+		t.Errorf("LoadCoverage() without a profile = %q, want none", profile)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "coverage"), 0755)
+	lcov := "TN:\nSF:" + filepath.Join(dir, "src/app.js") + "\nDA:1,0\nDA:2,4\nend_of_record\n" +
+		"SF:src/unused.js\nDA:1,0\nend_of_record\n"
+	os.WriteFile(filepath.Join(dir, "coverage", "lcov.info"), []byte(lcov), 0644)
+
+	profile, coverage := LoadCoverage(dir, "")
+	if profile != "coverage/lcov.info" {
+		t.Fatalf("LoadCoverage() = %q, want coverage/lcov.info", profile)
+	}
+	if !coverage.Covers("src/app.js") {
+		t.Error("Covers(src/app.js) = false, want true")
+	}
+	if coverage.Covers("src/unused.js") || coverage.Covers("src/other.js") {
+		t.Error("Covers() = true for a file with no hits or no entry, want false")
+	}
+
+	if profile, _ := LoadCoverage(dir, "missing.out"); profile != "" {
+		t.Errorf("LoadCoverage() with a missing configured file = %q, want none", profile)
+	}
+}
+
+func TestHasTestFile(t *testing.T) {
+	dir, err := os.MkdirTemp("", "risk-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"pkg/foo_test.go", "src/__tests__/app.test.js", "lib/tests/test_util.py"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), 0755)
+		os.WriteFile(filepath.Join(dir, f), nil, 0644)
+	}
+
+	tests := map[string]bool{
+		"pkg/foo.go":   true,
+		"src/app.js":   true,
+		"lib/util.py":  true,
+		"pkg/bar.go":   false,
+		"src/other.js": false,
+	}
+	for path, want := range tests {
+		if got := HasTestFile(dir, path); got != want {
+			t.Errorf("HasTestFile(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	for score, want := range map[int]string{0: Low, 1: Low, 2: Medium, 3: Medium, 4: High, 7: High} {
+		if got := Level(score); got != want {
+			t.Errorf("Level(%d) = %s, want %s", score, got, want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Package scaffold generates a new plugin for the marketplace: a manifest,
+// hook registrations, and a Go module whose hooks run through the
+// marketplace's hooks SDK, plus a test per hook. The result passes
+// plugincheck and builds and tests as generated.
+//
+// The files are rendered from the text/template files embedded from
+// skeleton/.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"ultraharness/internal/plugincheck"
+)
+
+//go:embed skeleton
+var skeleton embed.FS
+
+// DefaultEvents are the hooks a plugin gets when none are chosen.
+var DefaultEvents = []string{"PreToolUse", "PostToolUse", "SessionStart"}
+
+// SDKModule is the module path of the marketplace's hooks SDK.
+const SDKModule = "github.com/praneethpuligundla/claude-plugins-marketplace/plugins/sdk"
+
+// DefaultTimeout is the timeout, in seconds, of each hook registration.
+const DefaultTimeout = 10
+
+// namePattern matches plugin names that are also valid Go module paths.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// ErrExists reports that the plugin directory already has files.
+var ErrExists = errors.New("directory already exists and is not empty")
+
+// Options describe the plugin to generate.
+type Options struct {
+	// Name is the plugin name, which is also its Go module path and binary
+	Name        string
+	Description string
+	// Author is optional
+	Author string
+	// SDKPath is the SDK's directory, relative to the plugin directory or
+	// absolute, which the plugin's go.mod replaces SDKModule with
+	SDKPath string
+	// Events are the Claude Code hook events to generate hooks for;
+	// DefaultEvents if empty
+	Events []string
+}
+
+// hook is the template data for one generated hook.
+type hook struct {
+	Plugin string
+	Event  string
+	// Key is the name run-hook takes, e.g. "pre_tool_use"
+	Key string
+	// Package is the Go package, e.g. "pretooluse"
+	Package string
+	// When completes "Hook runs ..."
+	When        string
+	AddsContext bool
+	// SampleInput is the input the generated test runs the hook with
+	SampleInput string
+}
+
+// events describes each hook event for the generated code.
+var events = map[string]struct {
+	when        string
+	addsContext bool
+	sampleInput string
+}{
+	"PreToolUse": {"before each tool call, and can block it", false,
+		`{"session_id": "test", "hook_event_name": "PreToolUse", "tool_name": "Bash", "tool_input": {"command": "ls"}}`},
+	"PostToolUse": {"after each tool call", true,
+		`{"session_id": "test", "hook_event_name": "PostToolUse", "tool_name": "Bash", "tool_input": {"command": "ls"}, "tool_response": {"stdout": "", "stderr": ""}}`},
+	"UserPromptSubmit": {"when the user submits a prompt", true,
+		`{"session_id": "test", "hook_event_name": "UserPromptSubmit", "prompt": "hello"}`},
+	"Notification": {"when Claude Code sends a notification", false, `{"session_id": "test", "hook_event_name": "Notification"}`},
+	"Stop":         {"when the agent finishes its turn", false, `{"session_id": "test", "hook_event_name": "Stop"}`},
+	"SubagentStop": {"when a subagent finishes", false, `{"session_id": "test", "hook_event_name": "SubagentStop"}`},
+	"PreCompact":   {"before the conversation is compacted", false, `{"session_id": "test", "hook_event_name": "PreCompact"}`},
+	"SessionStart": {"when a session starts", true, `{"session_id": "test", "hook_event_name": "SessionStart"}`},
+	"SessionEnd":   {"when a session ends", false, `{"session_id": "test", "hook_event_name": "SessionEnd"}`},
+}
+
+// Validate checks the options, filling in the default events.
+func (o *Options) Validate() error {
+	if !namePattern.MatchString(o.Name) {
+		return fmt.Errorf("plugin name %q must be kebab-case, starting with a letter", o.Name)
+	}
+	if strings.TrimSpace(o.Description) == "" {
+		return errors.New("a description is required")
+	}
+	if o.SDKPath == "" {
+		return errors.New("the SDK path is required")
+	}
+	if len(o.Events) == 0 {
+		o.Events = DefaultEvents
+	}
+	seen := map[string]bool{}
+	for _, e := range o.Events {
+		if _, ok := events[e]; !ok {
+			return fmt.Errorf("unknown hook event %q (want one of %s)", e, strings.Join(plugincheck.Events, ", "))
+		}
+		if seen[e] {
+			return fmt.Errorf("hook event %q given twice", e)
+		}
+		seen[e] = true
+	}
+	return nil
+}
+
+// File is a generated file.
+type File struct {
+	// Path is relative to the plugin directory
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+}
+
+// Files renders the plugin's files, sorted by path.
+func Files(o Options) ([]File, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	hooks := make([]hook, 0, len(o.Events))
+	for _, e := range o.Events {
+		key := snakeCase(e)
+		hooks = append(hooks, hook{
+			Plugin:      o.Name,
+			Event:       e,
+			Key:         key,
+			Package:     strings.ReplaceAll(key, "_", ""),
+			When:        events[e].when,
+			AddsContext: events[e].addsContext,
+			SampleInput: events[e].sampleInput,
+		})
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].Key < hooks[j].Key })
+	data := struct {
+		Options
+		Hooks []hook
+	}{o, hooks}
+
+	var files []File
+	add := func(tmpl, path string, mode os.FileMode, data interface{}) error {
+		content, err := render(tmpl, data)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		files = append(files, File{Path: path, Content: content, Mode: mode})
+		return nil
+	}
+	for _, f := range []struct{ tmpl, path string }{
+		{"plugin.json", plugincheck.ManifestFile},
+		{"go.mod", "go.mod"},
+		{"Makefile", "Makefile"},
+		{"gitignore", ".gitignore"},
+		{"README.md", "README.md"},
+		{"main.go", filepath.Join("cmd", o.Name, "main.go")},
+		{"hooks.go", "internal/hooks/hooks.go"},
+	} {
+		if err := add(f.tmpl, f.path, 0644, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := add("run-hook", "bin/run-hook", 0755, data); err != nil {
+		return nil, err
+	}
+	for _, h := range hooks {
+		dir := filepath.Join("internal/hooks", h.Package)
+		if err := add("hook.go", filepath.Join(dir, h.Package+".go"), 0644, h); err != nil {
+			return nil, err
+		}
+		if err := add("hook_test.go", filepath.Join(dir, h.Package+"_test.go"), 0644, h); err != nil {
+			return nil, err
+		}
+	}
+
+	registrations, err := hooksJSON(o, hooks)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, File{Path: plugincheck.HooksFile, Content: registrations, Mode: 0644})
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Generate writes the plugin into dir, which must not exist or be empty,
+// and returns the paths written.
+func Generate(dir string, o Options) ([]string, error) {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("%s: %w", dir, ErrExists)
+	}
+	files, err := Files(o)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		path := filepath.Join(dir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return paths, err
+		}
+		if err := os.WriteFile(path, f.Content, f.Mode); err != nil {
+			return paths, err
+		}
+		paths = append(paths, f.Path)
+	}
+	return paths, nil
+}
+
+func render(name string, data interface{}) ([]byte, error) {
+	text, err := skeleton.ReadFile("skeleton/" + name + ".tmpl")
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"sdk": func() string { return SDKModule },
+		"json": func(s string) (string, error) {
+			b, err := json.Marshal(s)
+			return string(b), err
+		},
+	}).Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hooksJSON renders hooks/hooks.json, registering each hook to run
+// through bin/run-hook.
+func hooksJSON(o Options, hooks []hook) ([]byte, error) {
+	type command struct {
+		Type    string `json:"type"`
+		Command string `json:"command"`
+		Timeout int    `json:"timeout"`
+	}
+	type matcher struct {
+		Matcher string    `json:"matcher"`
+		Hooks   []command `json:"hooks"`
+	}
+	registrations := map[string][]matcher{}
+	for _, h := range hooks {
+		registrations[h.Event] = []matcher{{
+			Matcher: "*",
+			Hooks: []command{{
+				Type:    "command",
+				Command: plugincheck.PluginRootVar + "/bin/run-hook " + h.Key,
+				Timeout: DefaultTimeout,
+			}},
+		}}
+	}
+	data, err := json.MarshalIndent(struct {
+		Description string               `json:"description"`
+		Hooks       map[string][]matcher `json:"hooks"`
+	}{o.Description, registrations}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// snakeCase turns an event name into a hook key: "PreToolUse" becomes
+// "pre_tool_use".
+func snakeCase(event string) string {
+	var b strings.Builder
+	for i, r := range event {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package scaffold
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/plugincheck"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"valid", Options{Name: "my-plugin", Description: "Mine", SDKPath: "../sdk"}, ""},
+		{"uppercase name", Options{Name: "MyPlugin", Description: "Mine", SDKPath: "../sdk"}, "kebab-case"},
+		{"leading digit", Options{Name: "1plugin", Description: "Mine", SDKPath: "../sdk"}, "kebab-case"},
+		{"no description", Options{Name: "my-plugin", SDKPath: "../sdk"}, "description"},
+		{"no SDK", Options{Name: "my-plugin", Description: "Mine"}, "SDK path"},
+		{"unknown event", Options{Name: "my-plugin", Description: "Mine", SDKPath: "../sdk", Events: []string{"BeforeEdit"}}, "unknown hook event"},
+		{"duplicate event", Options{Name: "my-plugin", Description: "Mine", SDKPath: "../sdk", Events: []string{"Stop", "Stop"}}, "twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+				if len(tt.opts.Events) != len(DefaultEvents) {
+					t.Errorf("Events = %v, want the defaults", tt.opts.Events)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for event, want := range map[string]string{"PreToolUse": "pre_tool_use", "Stop": "stop", "SessionEnd": "session_end"} {
+		if got := snakeCase(event); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", event, got, want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "scaffold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// The generated module uses the SDK in this repository
+	sdkDir, err := filepath.Abs(filepath.Join("..", "..", "..", "sdk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(tmpDir, "my-plugin")
+	opts := Options{
+		Name:        "my-plugin",
+		Description: `Guards "risky" commands`,
+		Author:      "Jane Doe",
+		Events:      []string{"PreToolUse", "UserPromptSubmit", "Stop"},
+		SDKPath:     sdkDir,
+	}
+	paths, err := Generate(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		".claude-plugin/plugin.json",
+		"hooks/hooks.json",
+		"bin/run-hook",
+		"go.mod",
+		"cmd/my-plugin/main.go",
+		"internal/hooks/hooks.go",
+		"internal/hooks/pretooluse/pretooluse.go",
+		"internal/hooks/pretooluse/pretooluse_test.go",
+		"internal/hooks/userpromptsubmit/userpromptsubmit.go",
+		"internal/hooks/stop/stop_test.go",
+	} {
+		found := false
+		for _, p := range paths {
+			found = found || filepath.ToSlash(p) == want
+		}
+		if !found {
+			t.Errorf("Generate() did not write %s", want)
+		}
+	}
+
+	gomod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil || !strings.Contains(string(gomod), "replace "+SDKModule+" => "+sdkDir) {
+		t.Errorf("go.mod = %s, want the SDK replaced with %s", gomod, sdkDir)
+	}
+
+	t.Run("passes plugincheck", func(t *testing.T) {
+		r := plugincheck.Plugin(dir)
+		if len(r.Problems) > 0 {
+			t.Errorf("plugincheck.Plugin() = %v, want no problems", r.Problems)
+		}
+	})
+
+	t.Run("refuses to overwrite", func(t *testing.T) {
+		if _, err := Generate(dir, opts); !errors.Is(err, ErrExists) {
+			t.Errorf("Generate() into an existing plugin = %v, want ErrExists", err)
+		}
+	})
+
+	t.Run("builds and tests", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("runs go test on the generated module")
+		}
+		goBin, err := exec.LookPath("go")
+		if err != nil {
+			t.Skip("go not on PATH")
+		}
+		for _, args := range [][]string{{"vet", "./..."}, {"test", "./..."}} {
+			cmd := exec.Command(goBin, args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+			}
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type doc struct {
+	SchemaVersion int    `json:"schema_version"`
+	Phase         string `json:"phase"`
+	Steps         int    `json:"steps"`
+	Count         int64  `json:"count"`
+}
+
+// testFormat renames "stage" to "phase" in version 2, and counts steps in
+// version 3
+var testFormat = &Format{
+	Name: "state.json",
+	Migrations: []Migration{
+		Unversioned,
+		func(d map[string]interface{}) error {
+			if stage, ok := d["stage"]; ok {
+				d["phase"] = stage
+				delete(d, "stage")
+			}
+			return nil
+		},
+		func(d map[string]interface{}) error {
+			list, _ := d["step_list"].([]interface{})
+			d["steps"] = len(list)
+			delete(d, "step_list")
+			return nil
+		},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want doc
+	}{
+		{"unversioned", `{"stage": "plan", "step_list": [1, 2], "count": 9007199254740993}`, doc{3, "plan", 2, 9007199254740993}},
+		{"version 2", `{"schema_version": 2, "phase": "plan", "step_list": [1]}`, doc{3, "plan", 1, 0}},
+		{"current", `{"schema_version": 3, "phase": "research", "steps": 4}`, doc{3, "research", 4, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got doc
+			if err := testFormat.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var got doc
+	err := testFormat.Unmarshal([]byte(`{"schema_version": 4, "phase": "plan"}`), &got)
+	if !errors.Is(err, ErrNewer) || !strings.Contains(err.Error(), "state.json") {
+		t.Errorf("Unmarshal() of a newer file error = %v, want ErrNewer naming the file", err)
+	}
+	if got.Phase != "" {
+		t.Errorf("Unmarshal() of a newer file decoded %+v, want nothing", got)
+	}
+
+	var syntax *json.SyntaxError
+	if err := testFormat.Unmarshal([]byte(`{"phase": `), &got); !errors.As(err, &syntax) {
+		t.Errorf("Unmarshal() of malformed JSON error = %v, want the syntax error", err)
+	}
+	if err := testFormat.Unmarshal([]byte(`{"schema_version": -1}`), &got); err == nil || errors.Is(err, ErrNewer) {
+		t.Errorf("Unmarshal() of a negative version error = %v, want an invalid version error", err)
+	}
+
+	failing := &Format{Name: "state.json", Migrations: []Migration{func(map[string]interface{}) error {
+		return errors.New("no phase")
+	}}}
+	if err := failing.Unmarshal([]byte(`{}`), &got); err == nil || !strings.Contains(err.Error(), "from schema version 0") {
+		t.Errorf("Unmarshal() with a failing migration error = %v, want it wrapped with the version", err)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	data, err := testFormat.Upgrade([]byte(`null`), 0)
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if string(data) != `{"schema_version":3,"steps":0}` {
+		t.Errorf("Upgrade(null) = %s, want an empty current document", data)
+	}
+}
@@ -0,0 +1,335 @@
+// Package search finds where something was recorded in the harness state:
+// every saved version of the FIC artifacts, the decision log, the
+// knowledge base, the progress log, and the context preserved at the last
+// compaction. It
+// answers questions like "where did we decide how auth tokens are
+// refreshed?" with ranked snippets naming the artifact and when it was
+// written.
+package search
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/decisions"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/relevance"
+	"ultraharness/internal/storage"
+	"ultraharness/internal/tags"
+	"ultraharness/internal/text"
+)
+
+// Sources of documents.
+const (
+	SourceResearch       = "research"
+	SourcePlan           = "plan"
+	SourceImplementation = "implementation"
+	SourceDecision       = "decision"
+	SourceKnowledge      = "knowledge"
+	SourceProgress       = "progress"
+	SourceContext        = "context"
+)
+
+// Sources lists every document source, in the order they are collected.
+var Sources = []string{SourceResearch, SourcePlan, SourceImplementation, SourceDecision, SourceKnowledge, SourceProgress, SourceContext}
+
+// preservedContextKey is the storage key PreCompact writes the preserved
+// context to.
+const preservedContextKey = "fic-preserved-context.json"
+
+// SnippetLength is the number of characters of a document shown around
+// the first matching word.
+const SnippetLength = 200
+
+// Document is one searchable piece of recorded text.
+type Document struct {
+	// Source is where the text was recorded, one of Sources
+	Source string
+	// ID identifies the record: the artifact ID, the decision number, the
+	// knowledge fact's source, the progress entry's author, or the
+	// compacted session
+	ID string
+	// Field names the part of the record, e.g. "discovery" or "step 3"
+	Field string
+	// Time is when the text was recorded; zero if unknown
+	Time time.Time
+	Text string
+	// Tags are the record's tags, including the hashtags in Text
+	Tags []string
+}
+
+// Result is a document matching a query.
+type Result struct {
+	Document
+	Score float64
+	// Snippet is the part of the text around the first matching word
+	Snippet string
+}
+
+// Collect reads every searchable document in workDir, skipping sources
+// that cannot be read.
+func Collect(workDir string) []Document {
+	var docs []Document
+	if backend, err := storage.Open(workDir); err == nil {
+		docs = append(docs, artifactDocuments(backend)...)
+		docs = append(docs, contextDocuments(backend)...)
+	}
+	if log, err := decisions.Load(workDir); err == nil {
+		for _, d := range log.Decisions {
+			docs = append(docs, decisionDocuments(d)...)
+		}
+	}
+	if base, err := knowledge.Load(workDir); err == nil {
+		for _, f := range base.Facts {
+			docs = append(docs, Document{Source: SourceKnowledge, ID: f.Source, Field: "fact", Time: f.AddedAt, Text: f.Text, Tags: tags.Of(f.Tags, f.Text)})
+		}
+	}
+	if entries, err := progress.ReadEntries(workDir); err == nil {
+		for _, e := range entries {
+			docs = append(docs, Document{Source: SourceProgress, ID: string(e.Source), Field: "entry", Time: e.Time, Text: e.Message, Tags: e.Tags()})
+		}
+	}
+	return docs
+}
+
+// Tagged returns the docs carrying any of the given tags.
+func Tagged(docs []Document, labels []string) []Document {
+	var tagged []Document
+	for _, d := range docs {
+		if tags.Any(d.Tags, labels) {
+			tagged = append(tagged, d)
+		}
+	}
+	return tagged
+}
+
+// Search ranks docs by relevance to query and returns at most limit
+// results. Equal scores rank newer documents first. A query without
+// search words matches every document, newest first, for listing
+// documents already narrowed down by Tagged.
+func Search(docs []Document, query string, limit int) []Result {
+	if len(relevance.Tokenize(query)) == 0 {
+		var results []Result
+		for _, d := range docs {
+			results = append(results, Result{Document: d, Snippet: Snippet(d.Text, "", SnippetLength)})
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Time.After(results[j].Time) })
+		return limitResults(results, limit)
+	}
+
+	texts := make([]string, len(docs))
+	for i, d := range docs {
+		texts[i] = d.Text
+	}
+
+	var results []Result
+	for _, m := range relevance.NewIndex(texts).Rank(query) {
+		d := docs[m.Index]
+		results = append(results, Result{Document: d, Score: m.Score, Snippet: Snippet(d.Text, query, SnippetLength)})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Time.After(results[j].Time)
+	})
+	return limitResults(results, limit)
+}
+
+// limitResults returns the first limit results; all of them if limit is
+// not positive.
+func limitResults(results []Result, limit int) []Result {
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Snippet returns about n characters of s starting shortly before the
+// first word matching query, marking cut ends with text.Ellipsis.
+func Snippet(s, query string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	start := firstMatch(s, query)
+	// Lead in with a little context, from the start of a word
+	if start > 0 {
+		lead := text.Suffix(s[:start], n/4)
+		if i := strings.Index(lead, " "); i >= 0 && len(lead) < start {
+			lead = lead[i+1:]
+		}
+		start -= len(lead)
+	}
+	snippet := text.Truncate(s[start:], n)
+	if start > 0 {
+		snippet = text.Ellipsis + snippet
+	}
+	return snippet
+}
+
+// firstMatch returns the byte offset of the first word of s sharing a
+// term with query, or 0 if there is none.
+func firstMatch(s, query string) int {
+	terms := map[string]bool{}
+	for _, t := range relevance.Tokenize(query) {
+		terms[t] = true
+	}
+	offset := 0
+	for _, word := range strings.Split(s, " ") {
+		for _, t := range relevance.Tokenize(word) {
+			if terms[t] {
+				return offset
+			}
+		}
+		offset += len(word) + 1
+	}
+	return 0
+}
+
+// artifactDocuments returns the text of every saved artifact version. Text
+// repeated in later versions is kept only from the version that first
+// recorded it, so results show when something was decided.
+func artifactDocuments(backend storage.Backend) []Document {
+	var docs []Document
+	for _, t := range []artifacts.ArtifactType{artifacts.ArtifactResearch, artifacts.ArtifactPlan, artifacts.ArtifactImplementation} {
+		keys, err := artifacts.Keys(backend, t)
+		if err != nil {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, key := range keys {
+			a, err := artifacts.Get(backend, key, t)
+			if err != nil {
+				continue
+			}
+			savedAt, _ := artifacts.SavedAt(key)
+			for _, d := range artifactFields(a) {
+				d.Tags = tags.Merge(d.Tags, tags.Parse(d.Text))
+				if d.Text == "" || seen[d.Field+"\x00"+d.Text] {
+					continue
+				}
+				seen[d.Field+"\x00"+d.Text] = true
+				if d.ID == "" {
+					d.ID = strings.TrimSuffix(path.Base(key), ".json")
+				}
+				d.Time = savedAt
+				docs = append(docs, d)
+			}
+		}
+	}
+	return docs
+}
+
+// artifactFields returns the searchable fields of an artifact.
+func artifactFields(a interface{}) []Document {
+	var docs []Document
+	switch a := a.(type) {
+	case *artifacts.Research:
+		doc := func(field, text string, labels []string) {
+			docs = append(docs, Document{Source: SourceResearch, ID: a.ID, Field: field, Text: text, Tags: labels})
+		}
+		doc("task", a.FeatureOrTask, nil)
+		doc("initial context", a.InitialContext, nil)
+		for _, d := range a.Discoveries {
+			doc("discovery", d.Summary, d.Tags)
+		}
+		for _, q := range a.OpenQuestions {
+			doc("open question", q.Question, nil)
+		}
+	case *artifacts.Plan:
+		doc := func(field, text string, labels []string) {
+			docs = append(docs, Document{Source: SourcePlan, ID: a.ID, Field: field, Text: text, Tags: labels})
+		}
+		doc("goal", a.Goal, nil)
+		for _, s := range a.Steps {
+			doc("step "+s.ID, s.Description, s.Tags)
+		}
+	case *artifacts.Implementation:
+		for _, d := range a.PlanDeviations {
+			docs = append(docs, Document{Source: SourceImplementation, ID: a.ID, Field: "deviation", Text: d})
+		}
+	}
+	return docs
+}
+
+// decisionDocuments returns the searchable fields of a decision.
+func decisionDocuments(d decisions.Decision) []Document {
+	var docs []Document
+	for _, field := range []struct{ name, text string }{
+		{"decision", d.Title + ": " + d.Decision},
+		{"context", d.Context},
+		{"options", strings.Join(d.Options, "; ")},
+		{"consequences", d.Consequences},
+	} {
+		if field.text != "" {
+			docs = append(docs, Document{Source: SourceDecision, ID: d.Number(), Field: field.name, Time: d.RecordedAt, Text: field.text, Tags: d.Tags})
+		}
+	}
+	return docs
+}
+
+// contextDocuments returns the text preserved at the last compaction, one
+// document per top-level field.
+func contextDocuments(backend storage.Backend) []Document {
+	data, err := backend.Get(preservedContextKey)
+	if err != nil {
+		return nil
+	}
+	var ctx map[string]interface{}
+	if err := json.Unmarshal(data, &ctx); err != nil {
+		return nil
+	}
+
+	var at time.Time
+	if s, ok := ctx["timestamp"].(string); ok {
+		at, _ = time.Parse(time.RFC3339, s)
+	}
+	id, _ := ctx["session_id"].(string)
+
+	var fields []string
+	for field := range ctx {
+		switch field {
+		case "timestamp", "session_id":
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var docs []Document
+	for _, field := range fields {
+		var parts []string
+		collectStrings(ctx[field], &parts)
+		if len(parts) > 0 {
+			joined := strings.Join(parts, "; ")
+			docs = append(docs, Document{Source: SourceContext, ID: id, Field: field, Time: at, Text: joined, Tags: tags.Parse(joined)})
+		}
+	}
+	return docs
+}
+
+// collectStrings appends the strings in a decoded JSON value to parts.
+func collectStrings(v interface{}, parts *[]string) {
+	switch v := v.(type) {
+	case string:
+		if v != "" {
+			*parts = append(*parts, v)
+		}
+	case []interface{}:
+		for _, e := range v {
+			collectStrings(e, parts)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			collectStrings(v[k], parts)
+		}
+	}
+}
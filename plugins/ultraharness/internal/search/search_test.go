@@ -0,0 +1,146 @@
+package search
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/decisions"
+	"ultraharness/internal/knowledge"
+	"ultraharness/internal/progress"
+	"ultraharness/internal/storage"
+)
+
+func TestCollect(t *testing.T) {
+	tmpDir := t.TempDir()
+	backend, err := storage.Open(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(key string, v interface{}) {
+		data, _ := json.Marshal(v)
+		if err := backend.Put(key, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan := &artifacts.Plan{ID: "plan-1", Goal: "Session handling", Steps: []artifacts.PlanStep{
+		{ID: "1", Description: "Refresh auth tokens in the middleware before they expire"},
+	}}
+	put("fic-artifacts/plan/20261001-090000.json", plan)
+	plan.Steps = append(plan.Steps, artifacts.PlanStep{ID: "2", Description: "Log out on refresh failure"})
+	put("fic-artifacts/plan/20261002-090000.json", plan)
+	put("fic-artifacts/plan/orphaned/20260901-090000.json", &artifacts.Plan{ID: "old", Goal: "put aside"})
+	put("fic-artifacts/research/20261001-080000.json", &artifacts.Research{
+		FeatureOrTask: "Token refresh",
+		Discoveries:   []artifacts.Discovery{{Summary: "tokens expire after 15 minutes", Tags: []string{"auth"}}},
+		OpenQuestions: []artifacts.OpenQuestion{{Question: "Who owns the signing key?"}},
+	})
+	put(preservedContextKey, map[string]interface{}{
+		"timestamp":             "2026-10-03T10:00:00Z",
+		"session_id":            "sess-1",
+		"phase":                 "implementation",
+		"essential_discoveries": []string{"refresh happens in auth/refresh.go", "keys rotate weekly"},
+	})
+
+	log := &decisions.Log{}
+	log.Add(decisions.Decision{Decision: "Refresh tokens in middleware", Options: []string{"per handler", "middleware"}, Tags: []string{"auth"}})
+	log.Save(tmpDir)
+
+	base := &knowledge.Base{}
+	base.Add("The API gateway caches tokens for 60 seconds", "research: gateway", false)
+	base.Save(tmpDir)
+	progress.Append(progress.SourceHuman, "Decided to refresh tokens eagerly #auth", tmpDir)
+
+	docs := Collect(tmpDir)
+	var got []string
+	for _, d := range docs {
+		got = append(got, d.Source+" "+d.ID+" "+d.Field+": "+d.Text)
+	}
+	want := []string{
+		"research 20261001-080000 task: Token refresh",
+		"research 20261001-080000 discovery: tokens expire after 15 minutes",
+		"research 20261001-080000 open question: Who owns the signing key?",
+		"plan plan-1 goal: Session handling",
+		"plan plan-1 step 1: Refresh auth tokens in the middleware before they expire",
+		"plan plan-1 step 2: Log out on refresh failure",
+		"context sess-1 essential_discoveries: refresh happens in auth/refresh.go; keys rotate weekly",
+		"context sess-1 phase: implementation",
+		"decision ADR-1 decision: Refresh tokens in middleware: Refresh tokens in middleware",
+		"decision ADR-1 options: per handler; middleware",
+		"knowledge research: gateway fact: The API gateway caches tokens for 60 seconds",
+		"progress human entry: Decided to refresh tokens eagerly #auth",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Collect() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Steps carried into later versions keep the time they were first saved
+	if docs[4].Time.Day() != 1 || docs[5].Time.Day() != 2 {
+		t.Errorf("step times = %v, %v, want the version that added each", docs[4].Time, docs[5].Time)
+	}
+	if docs[6].Time.IsZero() {
+		t.Error("preserved context has no time")
+	}
+
+	var tagged []string
+	for _, d := range Tagged(docs, []string{"#auth"}) {
+		tagged = append(tagged, d.Source+": "+d.Text)
+	}
+	if got := strings.Join(tagged, "\n"); got != "research: tokens expire after 15 minutes\ndecision: Refresh tokens in middleware: Refresh tokens in middleware\ndecision: per handler; middleware\nprogress: Decided to refresh tokens eagerly #auth" {
+		t.Errorf("Tagged(auth) =\n%s", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	old := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	docs := []Document{
+		{Source: SourceKnowledge, Text: "Billing runs nightly", Time: old},
+		{Source: SourcePlan, ID: "old", Text: "Refresh auth tokens", Time: old},
+		{Source: SourcePlan, ID: "new", Text: "Refresh auth tokens", Time: old.AddDate(0, 0, 1)},
+		{Source: SourceProgress, Text: "Tokens are refreshed by the auth middleware when they expire", Time: old},
+	}
+
+	results := Search(docs, "how are auth tokens refreshed", 10)
+	if len(results) != 3 {
+		t.Fatalf("Search() returned %d results, want 3", len(results))
+	}
+	if results[0].ID != "new" || results[1].ID != "old" {
+		t.Errorf("Search() = %+v, want the newer of equal matches first", results)
+	}
+	if results[2].Snippet != docs[3].Text {
+		t.Errorf("Search() snippet = %q", results[2].Snippet)
+	}
+
+	if got := Search(docs, "auth", 1); len(got) != 1 {
+		t.Errorf("Search() with limit 1 returned %d results", len(got))
+	}
+	if got := Search(docs, "deployment", 10); len(got) != 0 {
+		t.Errorf("Search() without a match = %+v", got)
+	}
+	if got := Search(docs, "", 2); len(got) != 2 || got[0].ID != "new" {
+		t.Errorf("Search() without search words = %+v, want the newest documents", got)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+		n     int
+		want  string
+	}{
+		{"Refresh tokens early", "tokens", 40, "Refresh tokens early"},
+		{"one two three four five six seven eight tokens nine ten", "tokens", 40, "...eight tokens nine ten"},
+		{"one two three four five six seven eight tokens nine ten", "tokens", 16, "...tokens nine ten"},
+		{"no match here at all", "tokens", 8, "no match..."},
+		{"multi\n  line   text", "line", 40, "multi line text"},
+	}
+	for _, tt := range tests {
+		if got := Snippet(tt.text, tt.query, tt.n); got != tt.want {
+			t.Errorf("Snippet(%q, %q, %d) = %q, want %q", tt.text, tt.query, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+// Package session tracks per-session activity: tool calls, wall time, and files modified.
+//
+// Unlike context state, which persists across sessions until compaction,
+// session state starts fresh whenever the session ID changes.
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/filecache"
+	"ultraharness/internal/quarantine"
+	"ultraharness/internal/validation"
+)
+
+// SessionStateFileName is the name of the session state file
+const SessionStateFileName = "fic-session-state.json"
+
+// FilePermission is the permission for state files
+const FilePermission = 0600
+
+// DirPermission is the permission for state directories
+const DirPermission = 0700
+
+// SubagentTimeout is how long a started Task subagent counts as running
+// without PostToolUse reporting it finished. A Task call denied after
+// PreToolUse allowed it, by the user or another hook, never reports back.
+const SubagentTimeout = 30 * time.Minute
+
+// State tracks activity within a single session
+type State struct {
+	SessionID     string         `json:"session_id"`
+	StartedAt     time.Time      `json:"started_at"`
+	LastActivity  time.Time      `json:"last_activity"`
+	ToolCalls     int            `json:"tool_calls"`
+	ToolCounts    map[string]int `json:"tool_counts,omitempty"`
+	FilesModified []string       `json:"files_modified,omitempty"`
+	LinesChanged  int            `json:"lines_changed,omitempty"`
+
+	// Token estimates for cost reporting
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+
+	// Outcomes for cross-project stats
+	Compactions int `json:"compactions,omitempty"`
+	GateBlocks  int `json:"gate_blocks,omitempty"`
+	TestRuns    int `json:"test_runs,omitempty"`
+	TestPasses  int `json:"test_passes,omitempty"`
+	// LastTestPassed is the outcome of the last of the TestRuns
+	LastTestPassed bool `json:"last_test_passed,omitempty"`
+
+	// Feature checklist statuses by ID when the session started, to tell
+	// which features it advanced
+	FeatureStatuses map[string]string `json:"feature_statuses,omitempty"`
+
+	// Tokens freed by the compactions measured after they ran
+	CompactionsMeasured int `json:"compactions_measured,omitempty"`
+	TokensFreed         int `json:"tokens_freed,omitempty"`
+
+	// Task subagents started this session, and the start times of those
+	// still running, oldest first
+	SubagentsStarted int         `json:"subagents_started,omitempty"`
+	SubagentStarts   []time.Time `json:"subagent_starts,omitempty"`
+
+	// Hashes of the most recent user prompts, oldest first
+	RecentPrompts []string `json:"recent_prompts,omitempty"`
+
+	// FIC phase seen by the last user prompt, to deliver project guidance
+	// once per phase change
+	PromptPhase string `json:"prompt_phase,omitempty"`
+
+	// Highest edit risk score of each file edited this session
+	EditRisks map[string]int `json:"edit_risks,omitempty"`
+
+	// Files edited since the last test run whose affected tests were
+	// already suggested
+	TestsSuggested map[string]bool `json:"tests_suggested,omitempty"`
+
+	// HEAD when a HIGH risk edit was last allowed in strict mode; edits
+	// after that checkpoint need no new one
+	RiskCheckpoint string `json:"risk_checkpoint,omitempty"`
+
+	// Most recent edits and commands, oldest first, for the watchdog
+	RecentActions []Action `json:"recent_actions,omitempty"`
+	// Cycles of edits followed by a failing test run since tests last
+	// passed, and whether there were edits since the last test run
+	FailCycles       int  `json:"fail_cycles,omitempty"`
+	EditedSinceTests bool `json:"edited_since_tests,omitempty"`
+	// Loops the watchdog reported this session, to escalate its directive
+	LoopWarnings int `json:"loop_warnings,omitempty"`
+	// Set while the watchdog holds a looping session in strict mode
+	LoopHold *LoopHold `json:"loop_hold,omitempty"`
+
+	// On-disk versions of the files read or written this session, to spot
+	// changes made outside the session
+	TrackedFiles map[string]FileStamp `json:"tracked_files,omitempty"`
+}
+
+// Action is an edit or command remembered by the watchdog
+type Action struct {
+	// Kind is "edit" or "bash"
+	Kind string `json:"kind"`
+	// Hash identifies identical edits or commands
+	Hash string `json:"hash"`
+	// Label names the edited file or the command
+	Label string `json:"label"`
+}
+
+// LoopHold stops a session the watchdog caught looping until the plan is
+// revised
+type LoopHold struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+	// Plan is the version of the latest plan artifact when the hold began
+	Plan string `json:"plan,omitempty"`
+}
+
+// FileStamp identifies a version of a file on disk
+type FileStamp struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+}
+
+// MaxTrackedFiles caps the files tracked per session; later reads of
+// untracked files are ignored
+const MaxTrackedFiles = 200
+
+// MaxRecentPrompts is the number of prompt hashes kept per session
+const MaxRecentPrompts = 10
+
+// MaxRecentActions is the number of edits and commands kept per session for
+// the watchdog
+const MaxRecentActions = 20
+
+// NewState returns an empty state for the given session
+func NewState(sessionID string) *State {
+	now := time.Now()
+	return &State{
+		SessionID:    sessionID,
+		StartedAt:    now,
+		LastActivity: now,
+	}
+}
+
+// ResolveID returns the session ID to use for state tracking,
+// falling back to "default" for empty or unsafe IDs
+func ResolveID(sessionID string) string {
+	if sessionID == "" {
+		return "default"
+	}
+	if err := validation.ValidateSessionID(sessionID); err != nil {
+		return "default"
+	}
+	return sessionID
+}
+
+// GetStatePath returns the path to the session state file
+func GetStatePath(workDir string) string {
+	return filepath.Join(workDir, ".claude", SessionStateFileName)
+}
+
+// Load reads the session state from the working directory.
+// Returns a fresh state if the file doesn't exist or belongs to another session.
+func Load(sessionID, workDir string) (*State, error) {
+	data, err := filecache.ReadFile(GetStatePath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewState(sessionID), nil
+		}
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		if err := quarantineState(workDir, data, err); err != nil {
+			return nil, err
+		}
+		return NewState(sessionID), nil
+	}
+
+	if state.SessionID != sessionID {
+		return NewState(sessionID), nil
+	}
+
+	return &state, nil
+}
+
+// LoadLatest reads the most recently recorded session state, regardless of session ID.
+// Returns nil if no session has been recorded.
+func LoadLatest(workDir string) (*State, error) {
+	data, err := filecache.ReadFile(GetStatePath(workDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		if err := quarantineState(workDir, data, err); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// quarantineState keeps a corrupt session state for inspection and removes
+// it, so the session starts fresh. If the state cannot be kept, cause is
+// returned and the file is left alone.
+func quarantineState(workDir string, data []byte, cause error) error {
+	if _, err := quarantine.Save(workDir, SessionStateFileName, data, cause, "this session's tool counts, token estimates, and modified files"); err != nil {
+		return cause
+	}
+	defer filecache.Invalidate(GetStatePath(workDir))
+	return os.Remove(GetStatePath(workDir))
+}
+
+// Save writes the session state to disk
+func (s *State) Save(workDir string) error {
+	stateDir := filepath.Join(workDir, ".claude")
+	if err := os.MkdirAll(stateDir, DirPermission); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	defer filecache.Invalidate(GetStatePath(workDir))
+	return os.WriteFile(GetStatePath(workDir), data, FilePermission)
+}
+
+// RecordToolCall counts a tool call and, for Edit/Write, the file it modified
+func (s *State) RecordToolCall(toolName, filePath string) {
+	s.ToolCalls++
+	s.LastActivity = time.Now()
+	if toolName != "" {
+		if s.ToolCounts == nil {
+			s.ToolCounts = map[string]int{}
+		}
+		s.ToolCounts[toolName]++
+	}
+
+	if (toolName == "Edit" || toolName == "Write") && filePath != "" {
+		s.addModifiedFile(filePath)
+	}
+}
+
+// RecordLinesChanged adds lines added plus removed by an Edit or Write
+func (s *State) RecordLinesChanged(lines int) {
+	s.LinesChanged += lines
+}
+
+// RecordTokens adds estimated input and output tokens for one tool call
+func (s *State) RecordTokens(input, output int) {
+	s.InputTokens += int64(input)
+	s.OutputTokens += int64(output)
+}
+
+// RecordCompaction counts a context compaction
+func (s *State) RecordCompaction() {
+	s.Compactions++
+}
+
+// RecordCompactionFreed adds the tokens a compaction freed, once measured
+func (s *State) RecordCompactionFreed(tokens int) {
+	s.CompactionsMeasured++
+	s.TokensFreed += tokens
+}
+
+// RecordPrompt remembers a user prompt, returning true if the same prompt
+// was among the recent ones. Prompts are compared ignoring case and
+// surrounding whitespace.
+func (s *State) RecordPrompt(prompt string) bool {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(prompt))))
+	hash := hex.EncodeToString(sum[:8])
+
+	seen := false
+	for _, h := range s.RecentPrompts {
+		if h == hash {
+			seen = true
+			break
+		}
+	}
+	s.RecentPrompts = append(s.RecentPrompts, hash)
+	if len(s.RecentPrompts) > MaxRecentPrompts {
+		s.RecentPrompts = s.RecentPrompts[len(s.RecentPrompts)-MaxRecentPrompts:]
+	}
+	return seen
+}
+
+// EnterPhase records the FIC phase seen by a user prompt, returning true
+// if it changed since the previous prompt. The first prompt of a session
+// reports no change, since SessionStart has just described its phase.
+func (s *State) EnterPhase(phase string) bool {
+	changed := s.PromptPhase != "" && s.PromptPhase != phase
+	s.PromptPhase = phase
+	return changed
+}
+
+// RecordRisk records the risk score of an edit to path, keeping the
+// file's highest score
+func (s *State) RecordRisk(path string, score int) {
+	if s.EditRisks == nil {
+		s.EditRisks = map[string]int{}
+	}
+	if current, ok := s.EditRisks[path]; !ok || score > current {
+		s.EditRisks[path] = score
+	}
+}
+
+// RecordGateBlock counts an operation denied by PreToolUse
+func (s *State) RecordGateBlock() {
+	s.GateBlocks++
+}
+
+// RecordSubagentStart counts a Task subagent about to start
+func (s *State) RecordSubagentStart() {
+	s.SubagentsStarted++
+	s.SubagentStarts = append(s.expireSubagents(), time.Now())
+}
+
+// RecordSubagentDone counts a Task subagent that finished, the oldest one
+// still running
+func (s *State) RecordSubagentDone() {
+	if running := s.expireSubagents(); len(running) > 0 {
+		s.SubagentStarts = running[1:]
+	}
+}
+
+// SubagentsRunning returns the number of Task subagents started less than
+// SubagentTimeout ago that have not finished
+func (s *State) SubagentsRunning() int {
+	return len(s.expireSubagents())
+}
+
+// expireSubagents drops the subagents started SubagentTimeout ago or
+// earlier, which are presumed never to have run, and returns the rest
+func (s *State) expireSubagents() []time.Time {
+	cutoff := time.Now().Add(-SubagentTimeout)
+	i := 0
+	for i < len(s.SubagentStarts) && !s.SubagentStarts[i].After(cutoff) {
+		i++
+	}
+	s.SubagentStarts = s.SubagentStarts[i:]
+	return s.SubagentStarts
+}
+
+// SuggestTests records that the tests affected by an edit to path were
+// suggested, returning false if they already were since the last test run
+func (s *State) SuggestTests(path string) bool {
+	if s.TestsSuggested[path] {
+		return false
+	}
+	if s.TestsSuggested == nil {
+		s.TestsSuggested = map[string]bool{}
+	}
+	s.TestsSuggested[path] = true
+	return true
+}
+
+// RecordAction remembers an edit or command for the watchdog, keeping the
+// most recent MaxRecentActions. Edits start a new edit and test cycle.
+func (s *State) RecordAction(action Action) {
+	if action.Kind == "edit" {
+		s.EditedSinceTests = true
+	}
+	s.RecentActions = append(s.RecentActions, action)
+	if len(s.RecentActions) > MaxRecentActions {
+		s.RecentActions = s.RecentActions[len(s.RecentActions)-MaxRecentActions:]
+	}
+}
+
+// RecordTestRun counts a test command and whether it passed, and forgets
+// the test suggestions made before it. A failing run after edits completes
+// an edit and test-fail cycle; a passing run ends the streak.
+func (s *State) RecordTestRun(passed bool) {
+	switch {
+	case passed:
+		s.FailCycles = 0
+	case s.EditedSinceTests:
+		s.FailCycles++
+	}
+	s.EditedSinceTests = false
+	s.TestsSuggested = nil
+	s.TestRuns++
+	s.LastTestPassed = passed
+	if passed {
+		s.TestPasses++
+	}
+}
+
+// StampFile returns the current on-disk version of a file, or false if it
+// cannot be read
+func StampFile(filePath string) (FileStamp, bool) {
+	info, err := os.Stat(filePath)
+	if err != nil || info.IsDir() {
+		return FileStamp{}, false
+	}
+	return FileStamp{ModTime: info.ModTime(), Size: info.Size()}, true
+}
+
+// TrackFile records the current on-disk version of a file the session has
+// just read or written
+func (s *State) TrackFile(filePath string) {
+	if filePath == "" {
+		return
+	}
+	stamp, ok := StampFile(filePath)
+	if !ok {
+		return
+	}
+	if _, tracked := s.TrackedFiles[filePath]; !tracked && len(s.TrackedFiles) >= MaxTrackedFiles {
+		return
+	}
+	if s.TrackedFiles == nil {
+		s.TrackedFiles = map[string]FileStamp{}
+	}
+	s.TrackedFiles[filePath] = stamp
+}
+
+// ChangedFiles returns the tracked files whose on-disk version changed
+// since the session last read or wrote them, sorted. Each change is
+// reported once: changed files take their new version, deleted files are
+// no longer tracked.
+func (s *State) ChangedFiles() []string {
+	var changed []string
+	for filePath, stamp := range s.TrackedFiles {
+		current, ok := StampFile(filePath)
+		if ok && current.Size == stamp.Size && current.ModTime.Equal(stamp.ModTime) {
+			continue
+		}
+		changed = append(changed, filePath)
+		if ok {
+			s.TrackedFiles[filePath] = current
+		} else {
+			delete(s.TrackedFiles, filePath)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func (s *State) addModifiedFile(filePath string) {
+	for _, f := range s.FilesModified {
+		if f == filePath {
+			return
+		}
+	}
+	s.FilesModified = append(s.FilesModified, filePath)
+}
+
+// Elapsed returns the wall-clock time since the session started
+func (s *State) Elapsed() time.Duration {
+	if s.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.StartedAt)
+}
@@ -0,0 +1,372 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/quarantine"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("non-existent state returns new state", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "session-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		state, err := Load("session-1", tmpDir)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if state.SessionID != "session-1" {
+			t.Errorf("SessionID = %v, want 'session-1'", state.SessionID)
+		}
+		if state.StartedAt.IsZero() {
+			t.Error("StartedAt should be set")
+		}
+	})
+
+	t.Run("same session loads saved state", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "session-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		state := NewState("session-1")
+		state.RecordToolCall("Edit", "main.go")
+		if err := state.Save(tmpDir); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+
+		loaded, err := Load("session-1", tmpDir)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if loaded.ToolCalls != 1 {
+			t.Errorf("ToolCalls = %v, want 1", loaded.ToolCalls)
+		}
+		if len(loaded.FilesModified) != 1 {
+			t.Errorf("FilesModified = %v, want 1 entry", loaded.FilesModified)
+		}
+	})
+
+	t.Run("different session starts fresh", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "session-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		state := NewState("old-session")
+		state.ToolCalls = 40
+		state.Save(tmpDir)
+
+		loaded, err := Load("new-session", tmpDir)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if loaded.ToolCalls != 0 {
+			t.Errorf("ToolCalls = %v, want 0 for new session", loaded.ToolCalls)
+		}
+	})
+
+	t.Run("corrupt state is quarantined", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "session-test")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		os.MkdirAll(filepath.Join(tmpDir, ".claude"), 0755)
+		os.WriteFile(GetStatePath(tmpDir), []byte("not json"), 0644)
+
+		state, err := Load("session-1", tmpDir)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if state.SessionID != "session-1" || state.ToolCalls != 0 {
+			t.Errorf("Load() = %+v, want a fresh state", state)
+		}
+		if len(protocol.TakeNotices()) != 1 {
+			t.Error("Load() of corrupt state queued no warning")
+		}
+		if _, err := os.Stat(GetStatePath(tmpDir)); !os.IsNotExist(err) {
+			t.Errorf("corrupt state file still present (stat error %v)", err)
+		}
+		if backups, _ := os.ReadDir(quarantine.Dir(tmpDir)); len(backups) != 1 {
+			t.Errorf("quarantined %d files, want 1", len(backups))
+		}
+	})
+}
+
+func TestRecordToolCall(t *testing.T) {
+	state := NewState("test")
+
+	state.RecordToolCall("Edit", "a.go")
+	state.RecordToolCall("Edit", "a.go")
+	state.RecordToolCall("Write", "b.go")
+	state.RecordToolCall("Read", "c.go")
+
+	if state.ToolCalls != 4 {
+		t.Errorf("ToolCalls = %v, want 4", state.ToolCalls)
+	}
+	if len(state.FilesModified) != 2 {
+		t.Errorf("FilesModified = %v, want [a.go b.go]", state.FilesModified)
+	}
+	if state.ToolCounts["Edit"] != 2 || state.ToolCounts["Read"] != 1 {
+		t.Errorf("ToolCounts = %v, want Edit:2 Read:1 Write:1", state.ToolCounts)
+	}
+}
+
+func TestRecordPrompt(t *testing.T) {
+	state := NewState("test")
+
+	if state.RecordPrompt("What is the current branch?") {
+		t.Error("RecordPrompt() of a new prompt = true, want false")
+	}
+	if !state.RecordPrompt("  what is the current branch?\n") {
+		t.Error("RecordPrompt() of a repeated prompt = false, want true")
+	}
+
+	for i := 0; i < MaxRecentPrompts; i++ {
+		state.RecordPrompt(fmt.Sprintf("prompt %d", i))
+	}
+	if len(state.RecentPrompts) != MaxRecentPrompts {
+		t.Errorf("len(RecentPrompts) = %d, want %d", len(state.RecentPrompts), MaxRecentPrompts)
+	}
+	if state.RecordPrompt("What is the current branch?") {
+		t.Error("RecordPrompt() of a prompt older than MaxRecentPrompts = true, want false")
+	}
+}
+
+func TestEnterPhase(t *testing.T) {
+	state := NewState("test")
+
+	steps := []struct {
+		phase string
+		want  bool
+	}{
+		{"RESEARCH", false},
+		{"RESEARCH", false},
+		{"PLANNING_READY", true},
+		{"PLANNING_READY", false},
+	}
+	for _, step := range steps {
+		if got := state.EnterPhase(step.phase); got != step.want {
+			t.Errorf("EnterPhase(%s) = %v, want %v", step.phase, got, step.want)
+		}
+	}
+}
+
+func TestRecordRisk(t *testing.T) {
+	state := NewState("test")
+
+	state.RecordRisk("a.go", 3)
+	state.RecordRisk("a.go", 1)
+	state.RecordRisk("b.go", 0)
+	if got := state.EditRisks["a.go"]; got != 3 {
+		t.Errorf("EditRisks[a.go] = %d, want the highest score 3", got)
+	}
+	if got, ok := state.EditRisks["b.go"]; !ok || got != 0 {
+		t.Errorf("EditRisks[b.go] = %d, %v, want 0, true", got, ok)
+	}
+}
+
+func TestSuggestTests(t *testing.T) {
+	state := NewState("test")
+
+	if !state.SuggestTests("a.go") {
+		t.Error("SuggestTests(a.go) = false on the first edit, want true")
+	}
+	if state.SuggestTests("a.go") {
+		t.Error("SuggestTests(a.go) = true on the second edit, want false")
+	}
+	state.RecordTestRun(true)
+	if !state.SuggestTests("a.go") {
+		t.Error("SuggestTests(a.go) = false after a test run, want true")
+	}
+}
+
+func TestRecordActionAndFailCycles(t *testing.T) {
+	state := NewState("test")
+
+	for i := 0; i < MaxRecentActions+5; i++ {
+		state.RecordAction(Action{Kind: "bash", Hash: "h", Label: "ls"})
+	}
+	if len(state.RecentActions) != MaxRecentActions {
+		t.Errorf("RecentActions has %d entries, want %d", len(state.RecentActions), MaxRecentActions)
+	}
+
+	state.RecordTestRun(false)
+	if state.FailCycles != 0 {
+		t.Errorf("FailCycles = %d after a failing run without edits, want 0", state.FailCycles)
+	}
+	for i := 0; i < 2; i++ {
+		state.RecordAction(Action{Kind: "edit", Hash: "e", Label: "a.go"})
+		state.RecordTestRun(false)
+	}
+	state.RecordTestRun(false)
+	if state.FailCycles != 2 {
+		t.Errorf("FailCycles = %d after two edit and fail cycles, want 2", state.FailCycles)
+	}
+	state.RecordTestRun(true)
+	if state.FailCycles != 0 {
+		t.Errorf("FailCycles = %d after a passing run, want 0", state.FailCycles)
+	}
+}
+
+func TestRecordOutcomes(t *testing.T) {
+	state := NewState("test")
+
+	state.RecordCompaction()
+	state.RecordCompactionFreed(85000)
+	state.RecordGateBlock()
+	state.RecordGateBlock()
+	state.RecordTestRun(true)
+	state.RecordTestRun(false)
+	state.RecordTestRun(true)
+
+	if state.Compactions != 1 {
+		t.Errorf("Compactions = %v, want 1", state.Compactions)
+	}
+	if state.CompactionsMeasured != 1 || state.TokensFreed != 85000 {
+		t.Errorf("CompactionsMeasured, TokensFreed = %v, %v, want 1, 85000", state.CompactionsMeasured, state.TokensFreed)
+	}
+	if state.GateBlocks != 2 {
+		t.Errorf("GateBlocks = %v, want 2", state.GateBlocks)
+	}
+	if state.TestRuns != 3 || state.TestPasses != 2 || !state.LastTestPassed {
+		t.Errorf("TestRuns, TestPasses, LastTestPassed = %v, %v, %v, want 3, 2, true", state.TestRuns, state.TestPasses, state.LastTestPassed)
+	}
+
+	state.RecordSubagentStart()
+	state.RecordSubagentStart()
+	state.RecordSubagentDone()
+	state.RecordSubagentDone()
+	state.RecordSubagentDone()
+	if state.SubagentsStarted != 2 || state.SubagentsRunning() != 0 {
+		t.Errorf("SubagentsStarted, SubagentsRunning() = %v, %v, want 2, 0", state.SubagentsStarted, state.SubagentsRunning())
+	}
+
+	// A subagent that never reported finishing stops counting as running
+	state.RecordSubagentStart()
+	state.SubagentStarts[0] = time.Now().Add(-SubagentTimeout)
+	state.RecordSubagentStart()
+	if state.SubagentsStarted != 4 || state.SubagentsRunning() != 1 {
+		t.Errorf("SubagentsStarted, SubagentsRunning() = %v, %v, want 4, 1 after one expired", state.SubagentsStarted, state.SubagentsRunning())
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "session-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	read := filepath.Join(tmpDir, "read.go")
+	gone := filepath.Join(tmpDir, "gone.go")
+	same := filepath.Join(tmpDir, "same.go")
+	for _, f := range []string{read, gone, same} {
+		os.WriteFile(f, []byte("package main\n"), 0644)
+	}
+
+	state := NewState("test")
+	state.TrackFile(read)
+	state.TrackFile(gone)
+	state.TrackFile(same)
+	state.TrackFile(filepath.Join(tmpDir, "missing.go"))
+	if len(state.TrackedFiles) != 3 {
+		t.Fatalf("TrackedFiles = %v, want 3 files", state.TrackedFiles)
+	}
+	if got := state.ChangedFiles(); len(got) != 0 {
+		t.Errorf("ChangedFiles() before changes = %v, want none", got)
+	}
+
+	os.WriteFile(read, []byte("package main\n\nfunc main() {}\n"), 0644)
+	os.Remove(gone)
+	got := state.ChangedFiles()
+	if len(got) != 2 || got[0] != gone || got[1] != read {
+		t.Errorf("ChangedFiles() = %v, want [%s %s]", got, gone, read)
+	}
+
+	// Each change is reported once
+	if got := state.ChangedFiles(); len(got) != 0 {
+		t.Errorf("ChangedFiles() after reporting = %v, want none", got)
+	}
+	if _, ok := state.TrackedFiles[gone]; ok {
+		t.Errorf("deleted file %s is still tracked", gone)
+	}
+}
+
+func TestRecordTokens(t *testing.T) {
+	state := NewState("test")
+
+	state.RecordTokens(1500, 200)
+	state.RecordTokens(3000, 200)
+
+	if state.InputTokens != 4500 {
+		t.Errorf("InputTokens = %v, want 4500", state.InputTokens)
+	}
+	if state.OutputTokens != 400 {
+		t.Errorf("OutputTokens = %v, want 400", state.OutputTokens)
+	}
+}
+
+func TestLoadLatest(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "session-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	state, err := LoadLatest(tmpDir)
+	if err != nil || state != nil {
+		t.Fatalf("LoadLatest() = %v, %v; want nil, nil", state, err)
+	}
+
+	NewState("some-session").Save(tmpDir)
+
+	state, err = LoadLatest(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadLatest() error = %v", err)
+	}
+	if state.SessionID != "some-session" {
+		t.Errorf("SessionID = %v, want 'some-session'", state.SessionID)
+	}
+}
+
+func TestElapsed(t *testing.T) {
+	state := &State{StartedAt: time.Now().Add(-10 * time.Minute)}
+	if state.Elapsed() < 10*time.Minute {
+		t.Errorf("Elapsed() = %v, want >= 10m", state.Elapsed())
+	}
+
+	empty := &State{}
+	if empty.Elapsed() != 0 {
+		t.Errorf("Elapsed() = %v, want 0 for zero StartedAt", empty.Elapsed())
+	}
+}
+
+func TestResolveID(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "default"},
+		{"abc-123", "abc-123"},
+		{"../etc/passwd", "default"},
+	}
+
+	for _, tt := range tests {
+		if got := ResolveID(tt.input); got != tt.want {
+			t.Errorf("ResolveID(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Package snapshot saves and restores the complete harness state as
+// compressed tar archives.
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/validation"
+)
+
+// SnapshotsDir is where snapshots are stored, relative to the work directory.
+const SnapshotsDir = ".claude/snapshots"
+
+// Extension is the snapshot file extension.
+const Extension = ".tar.gz"
+
+// FilePermission for snapshot files.
+const FilePermission = 0600
+
+// DirPermission for the snapshots directory.
+const DirPermission = 0700
+
+// StatePaths are the harness state paths captured in a snapshot, as globs
+// relative to the work directory. Only the harness's own files under
+// .claude are listed: Claude Code's settings.json, settings.local.json,
+// commands/, and agents/ live there too, and a restore must leave them
+// alone. The hook log records what ran and is not state.
+var StatePaths = []string{
+	".claude/.claude-harness-initialized",
+	".claude/claude-harness.json",
+	".claude/fic-*", // FIC, session, and context state, caches, and fic-artifacts/
+	".claude/approvals.json",
+	".claude/baseline.json",
+	".claude/blockers.json",
+	".claude/checkpoint.json",
+	".claude/codebase-map.json",
+	".claude/corrupt",
+	".claude/daily-log.md",
+	".claude/decisions.json",
+	".claude/guidance.md",
+	".claude/handoffs",
+	".claude/infra-plans.json",
+	".claude/init.d",
+	".claude/knowledge.json",
+	".claude/research-queue.json",
+	".claude/retrospectives.json",
+	".claude/subagent-outputs",
+	".claude/templates",
+	"claude-features.json",
+	"claude-progress.txt",
+}
+
+// ErrNotFound is returned when a snapshot does not exist.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Info describes a stored snapshot.
+type Info struct {
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// DefaultName returns a timestamp-based snapshot name.
+func DefaultName() string {
+	return time.Now().Format("20060102-150405")
+}
+
+// GetPath returns the archive path for a snapshot name.
+func GetPath(workDir, name string) string {
+	return filepath.Join(workDir, SnapshotsDir, name+Extension)
+}
+
+// Create archives the harness state under name. An existing snapshot with
+// the same name is replaced.
+func Create(workDir, name string) (string, error) {
+	if err := validation.ValidateSessionID(name); err != nil {
+		return "", fmt.Errorf("invalid snapshot name %q: use letters, digits, '-' and '_'", name)
+	}
+
+	if err := os.MkdirAll(filepath.Join(workDir, SnapshotsDir), DirPermission); err != nil {
+		return "", err
+	}
+
+	path := GetPath(workDir, name)
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, FilePermission)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeArchive(f, workDir); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	return path, os.Rename(tmpPath, path)
+}
+
+func writeArchive(w io.Writer, workDir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	roots, err := statePaths(workDir)
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+
+			rel, err := filepath.Rel(workDir, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			// Only directories and regular files; symlinks are not followed
+			if !d.IsDir() && !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = rel
+			if d.IsDir() {
+				header.Name += "/"
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			src, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			_, err = io.Copy(tw, src)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// List returns stored snapshots, newest first.
+func List(workDir string) ([]Info, error) {
+	entries, err := os.ReadDir(filepath.Join(workDir, SnapshotsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []Info
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), Extension) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Info{
+			Name:      strings.TrimSuffix(e.Name(), Extension),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Remove deletes a stored snapshot.
+func Remove(workDir, name string) error {
+	if err := validation.ValidateSessionID(name); err != nil {
+		return ErrNotFound
+	}
+	if err := os.Remove(GetPath(workDir, name)); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Restore replaces the current harness state with a snapshot. The current
+// state is first saved as a "pre-restore-<timestamp>" snapshot, whose name
+// is returned so the restore itself can be undone.
+func Restore(workDir, name string) (string, error) {
+	if err := validation.ValidateSessionID(name); err != nil {
+		return "", ErrNotFound
+	}
+
+	path := GetPath(workDir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+
+	// Validate the archive fully before touching the current state
+	if err := readArchive(path, workDir, false); err != nil {
+		return "", fmt.Errorf("snapshot %s is invalid: %w", name, err)
+	}
+
+	backup := "pre-restore-" + DefaultName()
+	if _, err := Create(workDir, backup); err != nil {
+		return "", fmt.Errorf("failed to back up current state: %w", err)
+	}
+
+	if err := clearState(workDir); err != nil {
+		return backup, err
+	}
+	return backup, readArchive(path, workDir, true)
+}
+
+// statePaths returns the existing files and directories that match
+// StatePaths.
+func statePaths(workDir string) ([]string, error) {
+	var paths []string
+	for _, pattern := range StatePaths {
+		matches, err := filepath.Glob(filepath.Join(workDir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// clearState removes the current harness state. Snapshots and anything
+// else under .claude that StatePaths does not list are kept.
+func clearState(workDir string) error {
+	paths, err := statePaths(workDir)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readArchive validates every entry and, if extract is set, writes it out.
+func readArchive(path, workDir string, extract bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !IsStatePath(header.Name) {
+			// Snapshots taken before StatePaths was narrowed hold all of
+			// .claude; files the harness does not own are not restored
+			if name := strings.TrimSuffix(header.Name, "/"); name == ".claude" || strings.HasPrefix(name, ".claude/") {
+				continue
+			}
+			return fmt.Errorf("unexpected entry %q", header.Name)
+		}
+		target, err := validation.ValidatePath(filepath.FromSlash(header.Name), workDir)
+		if err != nil {
+			return fmt.Errorf("unsafe entry %q: %w", header.Name, err)
+		}
+		if !extract {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, DirPermission); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), DirPermission); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fs.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// IsStatePath reports whether name, a slash-separated path relative to
+// the work directory, is or is inside one of StatePaths.
+func IsStatePath(name string) bool {
+	parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	for _, pattern := range StatePaths {
+		n := strings.Count(pattern, "/") + 1
+		if len(parts) < n {
+			continue
+		}
+		if ok, _ := path.Match(pattern, strings.Join(parts[:n], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeState(t *testing.T, workDir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(workDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeState(t, tmpDir, map[string]string{
+		".claude/claude-harness.json":              `{"strictness": "strict"}`,
+		".claude/fic-artifacts/plan/20240101.json": `{"goal": "original"}`,
+		"claude-features.json":                     `{"features": []}`,
+		"claude-progress.txt":                      "started\n",
+		"main.go":                                  "package main\n",
+	})
+
+	if _, err := Create(tmpDir, "good"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Corrupt the state
+	writeState(t, tmpDir, map[string]string{
+		".claude/fic-artifacts/plan/20240101.json": `{"goal": "corrupted"}`,
+		".claude/fic-artifacts/plan/20240102.json": `{"goal": "bogus"}`,
+		"main.go": "package main // edited\n",
+	})
+	os.Remove(filepath.Join(tmpDir, "claude-progress.txt"))
+
+	backup, err := Restore(tmpDir, "good")
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(tmpDir, ".claude/fic-artifacts/plan/20240101.json")); got != `{"goal": "original"}` {
+		t.Errorf("plan after restore = %q, want original", got)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".claude/fic-artifacts/plan/20240102.json")); !os.IsNotExist(err) {
+		t.Error("artifact created after snapshot should be removed by restore")
+	}
+	if got := readFile(t, filepath.Join(tmpDir, "claude-progress.txt")); got != "started\n" {
+		t.Errorf("progress after restore = %q, want %q", got, "started\n")
+	}
+	if got := readFile(t, filepath.Join(tmpDir, "main.go")); got != "package main // edited\n" {
+		t.Errorf("main.go = %q, project files must not be touched", got)
+	}
+
+	snapshots, _ := List(tmpDir)
+	if len(snapshots) != 2 {
+		t.Errorf("len(List()) = %d, want 2 (snapshot + pre-restore backup)", len(snapshots))
+	}
+	if _, err := os.Stat(GetPath(tmpDir, backup)); err != nil {
+		t.Errorf("pre-restore backup %s missing: %v", backup, err)
+	}
+}
+
+func TestRestoreKeepsClaudeCodeFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeState(t, tmpDir, map[string]string{
+		".claude/fic-state.json":   `{"phase": "RESEARCH"}`,
+		".claude/settings.json":    `{"model": "original"}`,
+		".claude/commands/ship.md": "ship it\n",
+	})
+	if _, err := Create(tmpDir, "good"); err != nil {
+		t.Fatal(err)
+	}
+
+	writeState(t, tmpDir, map[string]string{
+		".claude/fic-state.json":      `{"phase": "PLANNING"}`,
+		".claude/settings.json":       `{"model": "changed"}`,
+		".claude/settings.local.json": `{"permissions": {}}`,
+		".claude/agents/reviewer.md":  "review\n",
+	})
+	if _, err := Restore(tmpDir, "good"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(tmpDir, ".claude/fic-state.json")); got != `{"phase": "RESEARCH"}` {
+		t.Errorf("fic-state.json after restore = %q, want the snapshot's", got)
+	}
+	for name, want := range map[string]string{
+		".claude/settings.json":       `{"model": "changed"}`,
+		".claude/settings.local.json": `{"permissions": {}}`,
+		".claude/commands/ship.md":    "ship it\n",
+		".claude/agents/reviewer.md":  "review\n",
+	} {
+		if got := readFile(t, filepath.Join(tmpDir, name)); got != want {
+			t.Errorf("%s after restore = %q, want %q: files the harness does not own must be left alone", name, got, want)
+		}
+	}
+}
+
+func TestIsStatePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{".claude/claude-harness.json", true},
+		{".claude/fic-session-state.json", true},
+		{".claude/fic-artifacts/plan/20240101.json", true},
+		{".claude/handoffs/", true},
+		{"claude-progress.txt", true},
+		{".claude", false},
+		{".claude/settings.json", false},
+		{".claude/commands/ship.md", false},
+		{".claude/snapshots/good.tar.gz", false},
+		{".claude/hook-log.jsonl", false},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		if got := IsStatePath(tt.name); got != tt.want {
+			t.Errorf("IsStatePath(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateInvalidName(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"../escape", "a/b", "", "with space"} {
+		if _, err := Create(tmpDir, name); err == nil {
+			t.Errorf("Create(%q) error = nil, want error", name)
+		}
+	}
+}
+
+func TestRestoreRejectsUnsafeArchive(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeState(t, tmpDir, map[string]string{"claude-progress.txt": "keep\n"})
+	os.MkdirAll(filepath.Join(tmpDir, SnapshotsDir), 0700)
+
+	f, _ := os.Create(GetPath(tmpDir, "evil"))
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "main.go", Mode: 0600, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("x"))
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	if _, err := Restore(tmpDir, "evil"); err == nil {
+		t.Error("Restore() error = nil, want error for entry outside harness state")
+	}
+	if got := readFile(t, filepath.Join(tmpDir, "claude-progress.txt")); got != "keep\n" {
+		t.Errorf("state modified by rejected restore: progress = %q", got)
+	}
+}
+
+func TestRestoreNotFound(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := Restore(tmpDir, "missing"); err != ErrNotFound {
+		t.Errorf("Restore() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeState(t, tmpDir, map[string]string{"claude-progress.txt": "log\n"})
+	if _, err := Create(tmpDir, "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(tmpDir, "old"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if list, _ := List(tmpDir); len(list) != 0 {
+		t.Errorf("List() after Remove() = %v, want none", list)
+	}
+	if err := Remove(tmpDir, "old"); err != ErrNotFound {
+		t.Errorf("Remove() of a missing snapshot error = %v, want ErrNotFound", err)
+	}
+	if err := Remove(tmpDir, "../old"); err != ErrNotFound {
+		t.Errorf("Remove() of an invalid name error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,271 @@
+// Package stats keeps a user-level record of every session across all
+// projects, so harness behavior can be compared over time: which projects
+// compact most often, how long sessions run, how often tests pass.
+//
+// Records are appended to ~/.ultraharness/stats.jsonl, one JSON object per
+// session. Collection is opt-in through the global_stats setting.
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/session"
+)
+
+// HomeEnvVar overrides the directory holding the stats store.
+const HomeEnvVar = "ULTRAHARNESS_HOME"
+
+// FileName is the name of the stats store.
+const FileName = "stats.jsonl"
+
+// Record summarizes one session.
+type Record struct {
+	Project       string         `json:"project"`
+	SessionID     string         `json:"session_id"`
+	StartedAt     time.Time      `json:"started_at"`
+	EndedAt       time.Time      `json:"ended_at"`
+	ToolCalls     int            `json:"tool_calls"`
+	ToolCounts    map[string]int `json:"tool_counts,omitempty"`
+	FilesModified int            `json:"files_modified"`
+	Compactions   int            `json:"compactions"`
+	// TokensFreed is the estimate freed by the CompactionsMeasured
+	// compactions that were measured afterwards
+	CompactionsMeasured int `json:"compactions_measured,omitempty"`
+	TokensFreed         int `json:"tokens_freed,omitempty"`
+	GateBlocks          int `json:"gate_blocks"`
+	TestRuns            int `json:"test_runs"`
+	TestPasses          int `json:"test_passes"`
+}
+
+// Duration returns the session's wall-clock length.
+func (r Record) Duration() time.Duration {
+	if r.StartedAt.IsZero() || r.EndedAt.Before(r.StartedAt) {
+		return 0
+	}
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// FromSession builds the record for a session in project.
+func FromSession(project string, state *session.State, endedAt time.Time) Record {
+	return Record{
+		Project:             project,
+		SessionID:           state.SessionID,
+		StartedAt:           state.StartedAt,
+		EndedAt:             endedAt,
+		ToolCalls:           state.ToolCalls,
+		ToolCounts:          state.ToolCounts,
+		FilesModified:       len(state.FilesModified),
+		Compactions:         state.Compactions,
+		CompactionsMeasured: state.CompactionsMeasured,
+		TokensFreed:         state.TokensFreed,
+		GateBlocks:          state.GateBlocks,
+		TestRuns:            state.TestRuns,
+		TestPasses:          state.TestPasses,
+	}
+}
+
+// DefaultPath returns the stats store path: $ULTRAHARNESS_HOME/stats.jsonl,
+// or ~/.ultraharness/stats.jsonl.
+func DefaultPath() (string, error) {
+	if dir := os.Getenv(HomeEnvVar); dir != "" {
+		return filepath.Join(dir, FileName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ultraharness", FileName), nil
+}
+
+// Append adds a record to the store at path.
+func Append(path string, r Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), session.DirPermission); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, session.FilePermission)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads every record in the store at path. A missing store has no
+// records; lines that do not parse are skipped.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err == nil {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// Filter returns the records that ended at or after since and whose
+// project path contains project. Zero values match everything.
+func Filter(records []Record, since time.Time, project string) []Record {
+	var kept []Record
+	for _, r := range records {
+		if !since.IsZero() && r.EndedAt.Before(since) {
+			continue
+		}
+		if project != "" && !strings.Contains(r.Project, project) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// Totals aggregates a group of sessions.
+type Totals struct {
+	Sessions    int
+	ToolCalls   int
+	Compactions int
+	// CompactionsMeasured of the Compactions freed TokensFreed in total
+	CompactionsMeasured int
+	TokensFreed         int
+	GateBlocks          int
+	TestRuns            int
+	TestPasses          int
+	Duration            time.Duration
+}
+
+func (t *Totals) add(r Record) {
+	t.Sessions++
+	t.ToolCalls += r.ToolCalls
+	t.Compactions += r.Compactions
+	t.CompactionsMeasured += r.CompactionsMeasured
+	t.TokensFreed += r.TokensFreed
+	t.GateBlocks += r.GateBlocks
+	t.TestRuns += r.TestRuns
+	t.TestPasses += r.TestPasses
+	t.Duration += r.Duration()
+}
+
+// AverageDuration returns the mean session length.
+func (t Totals) AverageDuration() time.Duration {
+	if t.Sessions == 0 {
+		return 0
+	}
+	return t.Duration / time.Duration(t.Sessions)
+}
+
+// AverageToolCalls returns the mean tool calls per session.
+func (t Totals) AverageToolCalls() float64 {
+	if t.Sessions == 0 {
+		return 0
+	}
+	return float64(t.ToolCalls) / float64(t.Sessions)
+}
+
+// AverageTokensFreed returns the mean tokens freed per measured
+// compaction, or -1 if none were measured.
+func (t Totals) AverageTokensFreed() int {
+	if t.CompactionsMeasured == 0 {
+		return -1
+	}
+	return t.TokensFreed / t.CompactionsMeasured
+}
+
+// TestPassRate returns the fraction of test runs that passed, or -1 if
+// no tests were run.
+func (t Totals) TestPassRate() float64 {
+	if t.TestRuns == 0 {
+		return -1
+	}
+	return float64(t.TestPasses) / float64(t.TestRuns)
+}
+
+// ProjectTotals are the totals for one project.
+type ProjectTotals struct {
+	Project string
+	Totals
+}
+
+// ToolCount is the number of calls to one tool.
+type ToolCount struct {
+	Tool  string
+	Calls int
+}
+
+// Summary aggregates records overall, per project, and per tool.
+type Summary struct {
+	Overall Totals
+	// Projects is ordered by compactions, then sessions, most first
+	Projects []ProjectTotals
+	// Tools is ordered by calls, most first
+	Tools []ToolCount
+}
+
+// Summarize aggregates the records.
+func Summarize(records []Record) Summary {
+	var s Summary
+	byProject := map[string]*ProjectTotals{}
+	tools := map[string]int{}
+	for _, r := range records {
+		s.Overall.add(r)
+		p, ok := byProject[r.Project]
+		if !ok {
+			p = &ProjectTotals{Project: r.Project}
+			byProject[r.Project] = p
+		}
+		p.add(r)
+		for tool, n := range r.ToolCounts {
+			tools[tool] += n
+		}
+	}
+
+	for _, p := range byProject {
+		s.Projects = append(s.Projects, *p)
+	}
+	sort.Slice(s.Projects, func(i, j int) bool {
+		a, b := s.Projects[i], s.Projects[j]
+		if a.Compactions != b.Compactions {
+			return a.Compactions > b.Compactions
+		}
+		if a.Sessions != b.Sessions {
+			return a.Sessions > b.Sessions
+		}
+		return a.Project < b.Project
+	})
+
+	for tool, n := range tools {
+		s.Tools = append(s.Tools, ToolCount{Tool: tool, Calls: n})
+	}
+	sort.Slice(s.Tools, func(i, j int) bool {
+		if s.Tools[i].Calls != s.Tools[j].Calls {
+			return s.Tools[i].Calls > s.Tools[j].Calls
+		}
+		return s.Tools[i].Tool < s.Tools[j].Tool
+	})
+	return s
+}
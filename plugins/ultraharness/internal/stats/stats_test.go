@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stats-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "nested", FileName)
+	if records, err := Load(path); err != nil || records != nil {
+		t.Fatalf("Load() of missing store = %v, %v, want nil, nil", records, err)
+	}
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	want := []Record{
+		{Project: "/src/a", SessionID: "s1", StartedAt: start, EndedAt: start.Add(time.Hour), ToolCalls: 40, Compactions: 2},
+		{Project: "/src/b", SessionID: "s2", StartedAt: start, EndedAt: start.Add(30 * time.Minute), ToolCalls: 10},
+	}
+	for _, r := range want {
+		if err := Append(path, r); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	// Corrupt lines are skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Load() returned %d records, want 2", len(got))
+	}
+	if got[0].SessionID != "s1" || got[0].Compactions != 2 || got[1].Duration() != 30*time.Minute {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv(HomeEnvVar, "/tmp/uh")
+	got, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() error = %v", err)
+	}
+	if want := filepath.Join("/tmp/uh", FileName); got != want {
+		t.Errorf("DefaultPath() = %v, want %v", got, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Project: "/src/a", StartedAt: start, EndedAt: start.Add(time.Hour), ToolCalls: 60, Compactions: 1,
+			TestRuns: 4, TestPasses: 3, ToolCounts: map[string]int{"Read": 40, "Edit": 20}},
+		{Project: "/src/b", StartedAt: start, EndedAt: start.Add(2 * time.Hour), ToolCalls: 100, Compactions: 3,
+			CompactionsMeasured: 2, TokensFreed: 170000, GateBlocks: 2, ToolCounts: map[string]int{"Edit": 30, "Bash": 70}},
+		{Project: "/src/a", StartedAt: start, EndedAt: start.Add(30 * time.Minute), ToolCalls: 20,
+			TestRuns: 4, TestPasses: 4, ToolCounts: map[string]int{"Read": 20}},
+	}
+
+	s := Summarize(records)
+	if s.Overall.Sessions != 3 || s.Overall.ToolCalls != 180 || s.Overall.Compactions != 4 {
+		t.Errorf("Overall = %+v, want 3 sessions, 180 tool calls, 4 compactions", s.Overall)
+	}
+	if got := s.Overall.AverageDuration(); got != 70*time.Minute {
+		t.Errorf("AverageDuration() = %v, want 1h10m", got)
+	}
+	if got := s.Overall.TestPassRate(); got != 7.0/8.0 {
+		t.Errorf("TestPassRate() = %v, want 0.875", got)
+	}
+
+	if len(s.Projects) != 2 || s.Projects[0].Project != "/src/b" {
+		t.Fatalf("Projects = %+v, want /src/b (most compactions) first", s.Projects)
+	}
+	if s.Projects[1].Sessions != 2 || s.Projects[1].TestPassRate() != 7.0/8.0 {
+		t.Errorf("Projects[1] = %+v, want 2 sessions with 7/8 tests passing", s.Projects[1])
+	}
+	if got := s.Projects[0].AverageTokensFreed(); got != 85000 {
+		t.Errorf("AverageTokensFreed() = %v, want 85000", got)
+	}
+	if got := s.Projects[1].AverageTokensFreed(); got != -1 {
+		t.Errorf("AverageTokensFreed() without measurements = %v, want -1", got)
+	}
+	if s.Projects[0].TestPassRate() != -1 {
+		t.Errorf("TestPassRate() without test runs = %v, want -1", s.Projects[0].TestPassRate())
+	}
+
+	wantTools := []ToolCount{{"Bash", 70}, {"Read", 60}, {"Edit", 50}}
+	if len(s.Tools) != len(wantTools) {
+		t.Fatalf("Tools = %v, want %v", s.Tools, wantTools)
+	}
+	for i, tc := range wantTools {
+		if s.Tools[i] != tc {
+			t.Errorf("Tools[%d] = %v, want %v", i, s.Tools[i], tc)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Project: "/src/api", EndedAt: now.Add(-48 * time.Hour)},
+		{Project: "/src/web", EndedAt: now.Add(-time.Hour)},
+		{Project: "/src/api", EndedAt: now.Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		name    string
+		since   time.Time
+		project string
+		want    int
+	}{
+		{"everything", time.Time{}, "", 3},
+		{"since", now.Add(-24 * time.Hour), "", 2},
+		{"project", time.Time{}, "api", 2},
+		{"both", now.Add(-24 * time.Hour), "api", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Filter(records, tt.since, tt.project); len(got) != tt.want {
+				t.Errorf("Filter() returned %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBackend is the backend used when none is configured.
+const DefaultBackend = "fs"
+
+// ErrNotFound is returned by Get for keys that do not exist.
+var ErrNotFound = errors.New("key not found")
+
+// ErrUnknownBackend is returned for a configured backend that is not
+// compiled into the binary.
+var ErrUnknownBackend = errors.New("storage backend is not available in this build")
+
+// Backend stores harness state entities under slash-separated keys such as
+// "fic-context-state.json" or "fic-artifacts/plan/20240101-120000.json".
+// Keys are relative to the project's .claude directory.
+type Backend interface {
+	// Get returns the value for key, or ErrNotFound.
+	Get(key string) ([]byte, error)
+	// Put stores value under key, replacing any existing value.
+	Put(key string, value []byte) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(key string) error
+	// List returns the keys starting with prefix, sorted ascending.
+	List(prefix string) ([]string, error)
+	// Update runs fn and applies its writes together. If fn returns an
+	// error, none of its writes are applied.
+	Update(fn func(tx Tx) error) error
+}
+
+// Tx collects writes for Backend.Update.
+type Tx interface {
+	Put(key string, value []byte)
+	Delete(key string)
+}
+
+// Opener creates a backend for a work directory.
+type Opener func(workDir string) (Backend, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Opener{}
+	selected   = DefaultBackend
+)
+
+// Register makes a backend available by name. Backends with external
+// dependencies (e.g. SQLite) register themselves from their own package.
+func Register(name string, open Opener) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = open
+}
+
+// Backends returns the names of the registered backends.
+func Backends() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectBackend sets the backend used by Open. Unknown names are rejected:
+// Open fails until a registered backend is selected, rather than putting
+// state somewhere the config did not ask for.
+func selectBackend(name string) error {
+	if name == "" {
+		name = DefaultBackend
+	}
+
+	registryMu.Lock()
+	selected = name
+	registryMu.Unlock()
+
+	return checkBackend(name)
+}
+
+// checkBackend returns an error if name is not a registered backend.
+func checkBackend(name string) error {
+	registryMu.RLock()
+	_, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %q (available: %s)", ErrUnknownBackend, name, strings.Join(Backends(), ", "))
+	}
+	return nil
+}
+
+// Open returns the configured backend for workDir.
+func Open(workDir string) (Backend, error) {
+	registryMu.RLock()
+	name := selected
+	open := registry[name]
+	registryMu.RUnlock()
+
+	if open == nil {
+		return nil, checkBackend(name)
+	}
+	return open(workDir)
+}
+
+// batch is a Tx that records writes for later application.
+type batch struct {
+	puts    map[string][]byte
+	deletes map[string]bool
+	order   []string
+}
+
+func newBatch() *batch {
+	return &batch{puts: make(map[string][]byte), deletes: make(map[string]bool)}
+}
+
+func (b *batch) Put(key string, value []byte) {
+	delete(b.deletes, key)
+	if _, ok := b.puts[key]; !ok {
+		b.order = append(b.order, key)
+	}
+	b.puts[key] = value
+}
+
+func (b *batch) Delete(key string) {
+	delete(b.puts, key)
+	b.deletes[key] = true
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/config"
+)
+
+func TestFSBackend(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "storage-backend-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	b := NewFS(tmpDir)
+
+	if _, err := b.Get("missing.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() missing key error = %v, want ErrNotFound", err)
+	}
+
+	if err := b.Put("fic-artifacts/plan/2.json", []byte("two")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	b.Put("fic-artifacts/plan/1.json", []byte("one"))
+	b.Put("fic-artifacts/research/1.json", []byte("r"))
+
+	got, err := b.Get("fic-artifacts/plan/2.json")
+	if err != nil || string(got) != "two" {
+		t.Errorf("Get() = %q, %v, want two", got, err)
+	}
+
+	keys, err := b.List("fic-artifacts/plan/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if strings.Join(keys, ",") != "fic-artifacts/plan/1.json,fic-artifacts/plan/2.json" {
+		t.Errorf("List() = %v, want sorted plan keys", keys)
+	}
+
+	if err := b.Delete("fic-artifacts/plan/1.json"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if err := b.Delete("fic-artifacts/plan/1.json"); err != nil {
+		t.Errorf("Delete() missing key error = %v, want nil", err)
+	}
+
+	for _, key := range []string{"../escape", "/abs", ""} {
+		if err := b.Put(key, []byte("x")); err == nil {
+			t.Errorf("Put(%q) error = nil, want invalid key error", key)
+		}
+	}
+}
+
+func TestFSBackendUpdate(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "storage-backend-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	b := NewFS(tmpDir)
+	b.Put("old.json", []byte("old"))
+
+	err = b.Update(func(tx Tx) error {
+		tx.Put("a.json", []byte("a"))
+		tx.Put("b.json", []byte("b"))
+		tx.Delete("old.json")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	keys, _ := b.List("")
+	if strings.Join(keys, ",") != "a.json,b.json" {
+		t.Errorf("keys after Update() = %v, want [a.json b.json]", keys)
+	}
+
+	failed := errors.New("abort")
+	err = b.Update(func(tx Tx) error {
+		tx.Put("c.json", []byte("c"))
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Update() error = %v, want abort", err)
+	}
+	if _, err := b.Get("c.json"); !errors.Is(err, ErrNotFound) {
+		t.Error("aborted Update() applied its writes")
+	}
+
+	// No temporary files are left behind
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("leftover temp files: %v", matches)
+	}
+}
+
+func TestFSBackendRecover(t *testing.T) {
+	tmpDir := t.TempDir()
+	b := NewFS(tmpDir)
+	b.Put("old.json", []byte("old"))
+	b.Put("a.json", []byte("a0"))
+
+	// An Update whose journal was written, interrupted after the first
+	// rename
+	tmpA, _ := b.writeTemp(filepath.Join(tmpDir, "a.json"), []byte("a1"))
+	tmpB, _ := b.writeTemp(filepath.Join(tmpDir, "b.json"), []byte("b1"))
+	if tmpA == tmpB {
+		t.Fatalf("writeTemp() reused %s", tmpA)
+	}
+	j := journal{Puts: map[string]string{"a.json": filepath.Base(tmpA), "b.json": filepath.Base(tmpB)}, Deletes: []string{"old.json"}}
+	if _, err := b.writeJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	os.Rename(tmpA, filepath.Join(tmpDir, "a.json"))
+
+	if err := b.Recover(); err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	for key, want := range map[string]string{"a.json": "a1", "b.json": "b1"} {
+		if got, _ := b.Get(key); string(got) != want {
+			t.Errorf("Get(%q) after Recover() = %q, want %q", key, got, want)
+		}
+	}
+	if _, err := b.Get("old.json"); !errors.Is(err, ErrNotFound) {
+		t.Error("Recover() did not apply the journal's delete")
+	}
+	leftover, _ := filepath.Glob(filepath.Join(tmpDir, "*"))
+	if len(leftover) != 2 {
+		t.Errorf("files after Recover() = %v, want only a.json and b.json", leftover)
+	}
+}
+
+func TestConfigureBackend(t *testing.T) {
+	defer selectBackend(DefaultBackend)
+
+	cfg := config.DefaultConfig()
+	cfg.StorageBackend = "sqlite"
+	if err := Configure(cfg); err == nil {
+		t.Error("Configure() with unavailable backend error = nil, want error")
+	}
+
+	// State is not put anywhere else instead
+	tmpDir, err := os.MkdirTemp("", "storage-backend-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := Open(tmpDir); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("Open() with unavailable backend error = %v, want ErrUnknownBackend", err)
+	}
+
+	cfg.StorageBackend = ""
+	if err := Configure(cfg); err != nil {
+		t.Fatalf("Configure() with default backend error = %v", err)
+	}
+	b, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, ok := b.(*FS); !ok {
+		t.Errorf("Open() = %T, want *FS", b)
+	}
+}
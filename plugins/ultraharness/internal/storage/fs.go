@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ultraharness/internal/filecache"
+)
+
+// FilePermission for state files.
+const FilePermission = 0600
+
+// DirPermission for state directories.
+const DirPermission = 0700
+
+func init() {
+	Register(DefaultBackend, func(workDir string) (Backend, error) {
+		b := NewFS(filepath.Join(workDir, ".claude"))
+		return b, b.Recover()
+	})
+}
+
+// journalSuffix ends the name of an Update journal in the root directory.
+const journalSuffix = ".journal"
+
+// FS stores each key as a file under a root directory, encrypting values
+// when encryption is enabled. Files are replaced atomically via rename, so
+// readers never see partial writes, and Update journals its renames so an
+// interrupted update is completed by the next Recover.
+type FS struct {
+	root string
+}
+
+// journal records the renames and deletes of an Update once all its
+// values are staged. Paths are relative to the root.
+type journal struct {
+	// Puts maps each key to the temporary file holding its new value
+	Puts    map[string]string `json:"puts"`
+	Deletes []string          `json:"deletes,omitempty"`
+}
+
+// NewFS returns a filesystem backend rooted at dir.
+func NewFS(dir string) *FS {
+	return &FS{root: dir}
+}
+
+func (b *FS) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "..") || strings.HasPrefix(key, "/") || strings.ContainsRune(key, 0) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(b.root, filepath.FromSlash(key)), nil
+}
+
+// Get returns the value for key.
+func (b *FS) Get(key string) ([]byte, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put stores value under key.
+func (b *FS) Put(key string, value []byte) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	tmp, err := b.writeTemp(path, value)
+	if err != nil {
+		return err
+	}
+	defer filecache.Invalidate(path)
+	return os.Rename(tmp, path)
+}
+
+// Delete removes key.
+func (b *FS) Delete(key string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	filecache.Invalidate(path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns keys starting with prefix.
+func (b *FS) List(prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(b.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".tmp") || strings.HasSuffix(path, journalSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(b.root, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+// Update stages all writes to temporary files, then commits them by
+// writing a journal of the renames and deletes before applying it. A crash
+// before the journal is written applies none of the writes; a crash after
+// leaves the journal for Recover to finish, so the update is applied in
+// full.
+func (b *FS) Update(fn func(tx Tx) error) error {
+	tx := newBatch()
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	j := journal{Puts: make(map[string]string)}
+	cleanup := func() {
+		for _, tmp := range j.Puts {
+			os.Remove(filepath.Join(b.root, tmp))
+		}
+	}
+
+	for _, key := range tx.order {
+		value, ok := tx.puts[key]
+		if !ok {
+			continue
+		}
+		path, err := b.path(key)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		tmp, err := b.writeTemp(path, value)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		rel, _ := filepath.Rel(b.root, tmp)
+		j.Puts[key] = filepath.ToSlash(rel)
+	}
+	for key := range tx.deletes {
+		if _, err := b.path(key); err != nil {
+			cleanup()
+			return err
+		}
+		j.Deletes = append(j.Deletes, key)
+	}
+	sort.Strings(j.Deletes)
+
+	journalPath, err := b.writeJournal(j)
+	if err != nil {
+		cleanup()
+		return err
+	}
+	if err := b.apply(j); err != nil {
+		return err
+	}
+	return os.Remove(journalPath)
+}
+
+// Recover finishes updates whose journal was written but not fully
+// applied, such as by a hook killed mid-update.
+func (b *FS) Recover() error {
+	paths, err := filepath.Glob(filepath.Join(b.root, "*"+journalSuffix))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Another process finished it
+				continue
+			}
+			return err
+		}
+		var j journal
+		if err := json.Unmarshal(data, &j); err != nil {
+			return fmt.Errorf("storage journal %s: %w", filepath.Base(path), err)
+		}
+		if err := b.apply(j); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply renames a journal's staged values into place and removes its
+// deleted keys. Staged files already renamed are skipped, so a journal
+// can be applied again.
+func (b *FS) apply(j journal) error {
+	keys := make([]string, 0, len(j.Puts))
+	for key := range j.Puts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		path, err := b.path(key)
+		if err != nil {
+			return err
+		}
+		tmp, err := b.path(j.Puts[key])
+		if err != nil {
+			return err
+		}
+		filecache.Invalidate(path)
+		if err := os.Rename(tmp, path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for _, key := range j.Deletes {
+		if err := b.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJournal atomically writes j to a new journal file and returns its
+// path.
+func (b *FS) writeJournal(j journal) (string, error) {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := b.writeTempFile(filepath.Join(b.root, "update"), func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSuffix(tmp, ".tmp") + journalSuffix
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return path, nil
+}
+
+// writeTemp writes value, sealed, to a new temporary file next to path.
+func (b *FS) writeTemp(path string, value []byte) (string, error) {
+	sealed, err := Seal(value)
+	if err != nil {
+		return "", err
+	}
+	return b.writeTempFile(path, func(f *os.File) error {
+		_, err := f.Write(sealed)
+		return err
+	})
+}
+
+// writeTempFile creates a uniquely named temporary file next to path, so
+// concurrent writers of the same key never share one, and fills it with
+// write. os.CreateTemp creates it with FilePermission.
+func (b *FS) writeTempFile(path string, write func(f *os.File) error) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), DirPermission); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// KeyEnvVar holds the state encryption key.
+const KeyEnvVar = "ULTRAHARNESS_STATE_KEY"
+
+// KeychainService is the OS keychain service name for the key.
+const KeychainService = "ultraharness"
+
+// Key sources.
+const (
+	KeySourceEnv      = "env"
+	KeySourceKeychain = "keychain"
+)
+
+// LoadKey loads the encryption key from the given source.
+func LoadKey(source string) ([]byte, error) {
+	switch source {
+	case "", KeySourceEnv:
+		secret := os.Getenv(KeyEnvVar)
+		if secret == "" {
+			return nil, fmt.Errorf("%w: set %s (context and artifacts will not be saved)", ErrKeyUnavailable, KeyEnvVar)
+		}
+		return ParseKey(secret)
+	case KeySourceKeychain:
+		secret, err := readKeychain()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrKeyUnavailable, err)
+		}
+		return ParseKey(secret)
+	default:
+		return nil, fmt.Errorf("unknown encryption key source %q", source)
+	}
+}
+
+// ParseKey decodes an AES-256 key from a base64 or hex encoded 32-byte
+// value, such as the output of "openssl rand -base64 32". Passphrases are
+// rejected: hashing one into a key without a salt or work factor would
+// make it cheap to guess.
+func ParseKey(secret string) ([]byte, error) {
+	secret = strings.TrimSpace(secret)
+	if k, err := base64.StdEncoding.DecodeString(secret); err == nil && len(k) == KeySize {
+		return k, nil
+	}
+	if k, err := hex.DecodeString(secret); err == nil && len(k) == KeySize {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: the key must be %d random bytes, base64 or hex encoded (e.g. openssl rand -base64 %d)",
+		ErrKeyUnavailable, KeySize, KeySize)
+}
+
+// readKeychain reads the secret from the macOS keychain or the Linux
+// Secret Service.
+func readKeychain() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", KeychainService, "-w")
+	case "linux":
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", KeychainService)
+	default:
+		return "", fmt.Errorf("keychain not supported on %s", runtime.GOOS)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain lookup failed: %v", err)
+	}
+	secret := strings.TrimSpace(string(output))
+	if secret == "" {
+		return "", fmt.Errorf("keychain entry %q is empty", KeychainService)
+	}
+	return secret, nil
+}
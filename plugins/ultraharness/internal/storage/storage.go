@@ -0,0 +1,178 @@
+// Package storage persists harness state through a pluggable Backend,
+// optionally encrypting it at rest with AES-256-GCM.
+//
+// Encryption is enabled per project through the "encryption" config
+// section. Encrypted files start with a short magic header, so plaintext
+// files written before encryption was enabled remain readable and are
+// encrypted on their next write.
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"ultraharness/internal/config"
+	"ultraharness/internal/filecache"
+)
+
+// KeySize is the AES-256 key size in bytes.
+const KeySize = 32
+
+// magic prefixes encrypted files.
+var magic = []byte("UHENC1\n")
+
+// Errors returned by the storage layer.
+var (
+	ErrKeyUnavailable = errors.New("encryption is enabled but no key is available")
+	ErrDecrypt        = errors.New("failed to decrypt state file (wrong key?)")
+)
+
+var (
+	mu       sync.RWMutex
+	key      []byte
+	required bool
+)
+
+// Configure selects the storage backend and enables encryption if the
+// config requests it. When encryption is enabled but no key can be loaded,
+// writes fail with ErrKeyUnavailable rather than falling back to plaintext,
+// and the load error is returned.
+func Configure(cfg *config.Config) error {
+	if err := selectBackend(cfg.StorageBackend); err != nil {
+		configureEncryption(cfg)
+		return err
+	}
+	return configureEncryption(cfg)
+}
+
+func configureEncryption(cfg *config.Config) error {
+	enc := cfg.GetEncryption()
+	if !enc.Enabled {
+		SetKey(nil, false)
+		return nil
+	}
+
+	k, err := LoadKey(enc.KeySource)
+	if err != nil {
+		SetKey(nil, true)
+		return err
+	}
+	SetKey(k, true)
+	return nil
+}
+
+// SetKey sets the encryption key. If enabled is false, files are written
+// in plaintext; encrypted files can still be read when a key is set.
+func SetKey(k []byte, enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	key = k
+	required = enabled
+}
+
+// IsEncrypted reports whether data has the encrypted file header.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// ReadFile reads a state file, decrypting it if needed.
+func ReadFile(path string) ([]byte, error) {
+	data, err := filecache.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Unseal(data)
+}
+
+// WriteFile writes a state file, encrypting it when encryption is enabled.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := Seal(data)
+	if err != nil {
+		return err
+	}
+	defer filecache.Invalidate(path)
+	return os.WriteFile(path, sealed, perm)
+}
+
+// Seal encrypts data with the configured key when encryption is enabled,
+// and returns it unchanged otherwise.
+func Seal(data []byte) ([]byte, error) {
+	mu.RLock()
+	k, enabled := key, required
+	mu.RUnlock()
+
+	if !enabled {
+		return data, nil
+	}
+	if k == nil {
+		return nil, ErrKeyUnavailable
+	}
+	return Encrypt(k, data)
+}
+
+// Unseal decrypts data produced by Seal. Plaintext is returned unchanged.
+func Unseal(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	mu.RLock()
+	k := key
+	mu.RUnlock()
+	if k == nil {
+		return nil, ErrKeyUnavailable
+	}
+	return Decrypt(k, data)
+}
+
+// Encrypt seals plaintext with AES-256-GCM. The output is the magic
+// header, a random nonce, and the ciphertext.
+func Encrypt(k, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(k)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte{}, magic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, magic), nil
+}
+
+// Decrypt opens data produced by Encrypt.
+func Decrypt(k, data []byte) ([]byte, error) {
+	gcm, err := newGCM(k)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimPrefix(data, magic)
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, magic)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(k []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
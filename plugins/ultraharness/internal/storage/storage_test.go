@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ultraharness/internal/config"
+)
+
+// testKey returns a key of KeySize bytes, all b.
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := testKey(1)
+	plaintext := []byte(`{"summary": "secret code excerpt"}`)
+
+	sealed, err := Encrypt(key, plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Error("IsEncrypted() = false for sealed data")
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Error("sealed data contains plaintext")
+	}
+
+	opened, err := Decrypt(key, sealed)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Decrypt() = %q, want %q", opened, plaintext)
+	}
+
+	if _, err := Decrypt(testKey(2), sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Decrypt() with wrong key error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	defer SetKey(nil, false)
+
+	path := filepath.Join(tmpDir, "state.json")
+	data := []byte(`{"a": 1}`)
+
+	t.Run("plaintext when disabled", func(t *testing.T) {
+		SetKey(nil, false)
+		if err := WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := os.ReadFile(path)
+		if !bytes.Equal(raw, data) {
+			t.Errorf("file = %q, want plaintext", raw)
+		}
+	})
+
+	t.Run("plaintext readable after enabling", func(t *testing.T) {
+		SetKey(testKey(1), true)
+		got, err := ReadFile(path)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("ReadFile() = %q, %v, want %q", got, err, data)
+		}
+	})
+
+	t.Run("encrypted when enabled", func(t *testing.T) {
+		SetKey(testKey(1), true)
+		if err := WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := os.ReadFile(path)
+		if !IsEncrypted(raw) {
+			t.Error("file not encrypted")
+		}
+		got, err := ReadFile(path)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("ReadFile() = %q, %v, want %q", got, err, data)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		SetKey(nil, true)
+		if _, err := ReadFile(path); !errors.Is(err, ErrKeyUnavailable) {
+			t.Errorf("ReadFile() error = %v, want ErrKeyUnavailable", err)
+		}
+		if err := WriteFile(path, data, 0600); !errors.Is(err, ErrKeyUnavailable) {
+			t.Errorf("WriteFile() error = %v, want ErrKeyUnavailable", err)
+		}
+	})
+}
+
+func TestParseKey(t *testing.T) {
+	raw := testKey(7)
+
+	tests := []struct {
+		name   string
+		secret string
+		want   []byte
+	}{
+		{"base64 key", "BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc=", raw},
+		{"hex key", "0707070707070707070707070707070707070707070707070707070707070707", raw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParseKey(tt.secret); err != nil || !bytes.Equal(got, tt.want) {
+				t.Errorf("ParseKey() = %x, %v, want %x", got, err, tt.want)
+			}
+		})
+	}
+
+	for _, secret := range []string{"correct horse battery staple", "BwcHBwcHBwc=", "0707"} {
+		if _, err := ParseKey(secret); !errors.Is(err, ErrKeyUnavailable) {
+			t.Errorf("ParseKey(%q) error = %v, want ErrKeyUnavailable", secret, err)
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer SetKey(nil, false)
+
+	cfg := config.DefaultConfig()
+	if err := Configure(cfg); err != nil {
+		t.Errorf("Configure() with encryption disabled error = %v", err)
+	}
+
+	cfg.Encryption = &config.EncryptionConfig{Enabled: true}
+	t.Setenv(KeyEnvVar, "")
+	if err := Configure(cfg); !errors.Is(err, ErrKeyUnavailable) {
+		t.Errorf("Configure() without key error = %v, want ErrKeyUnavailable", err)
+	}
+
+	t.Setenv(KeyEnvVar, "s3cret")
+	if err := Configure(cfg); !errors.Is(err, ErrKeyUnavailable) {
+		t.Errorf("Configure() with a passphrase error = %v, want ErrKeyUnavailable", err)
+	}
+
+	t.Setenv(KeyEnvVar, "0707070707070707070707070707070707070707070707070707070707070707")
+	if err := Configure(cfg); err != nil {
+		t.Errorf("Configure() with key error = %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package syntax runs fast per-language syntax checks on a single file so
+// errors surface right after an edit instead of at the next test run.
+package syntax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/text"
+)
+
+// DefaultTimeout bounds each check.
+const DefaultTimeout = 5 * time.Second
+
+// MaxOutputLength limits the reported checker output.
+const MaxOutputLength = 800
+
+// Checker validates the syntax of files with the given extensions.
+type Checker struct {
+	Name       string
+	Extensions []string
+	// Command returns the command line checking path, or nil for checks
+	// done in-process by Validate
+	Command func(path string) []string
+	// Validate checks file content in-process
+	Validate func(content []byte) error
+}
+
+// Checkers lists the built-in syntax checkers.
+var Checkers = []Checker{
+	{
+		Name:       "gofmt",
+		Extensions: []string{".go"},
+		Command:    func(path string) []string { return []string{"gofmt", "-e", "-l", path} },
+	},
+	{
+		Name:       "node --check",
+		Extensions: []string{".js", ".mjs", ".cjs"},
+		Command:    func(path string) []string { return []string{"node", "--check", path} },
+	},
+	{
+		// Compiles like py_compile without writing __pycache__
+		Name:       "python compile",
+		Extensions: []string{".py"},
+		Command: func(path string) []string {
+			return []string{"python3", "-c",
+				"import sys; compile(open(sys.argv[1], 'rb').read(), sys.argv[1], 'exec')", path}
+		},
+	},
+	{
+		Name:       "bash -n",
+		Extensions: []string{".sh", ".bash"},
+		Command:    func(path string) []string { return []string{"bash", "-n", path} },
+	},
+	{
+		Name:       "json",
+		Extensions: []string{".json"},
+		Validate:   validateJSON,
+	},
+}
+
+// Result is the outcome of a syntax check.
+type Result struct {
+	Checker string
+	OK      bool
+	Output  string
+}
+
+// CheckerFor returns the checker for path, or nil if none applies.
+func CheckerFor(path string) *Checker {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".json" && isJSONC(path) {
+		return nil
+	}
+	for i := range Checkers {
+		for _, e := range Checkers[i].Extensions {
+			if e == ext {
+				return &Checkers[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Check runs the syntax checker for path. Returns nil if no checker
+// applies, its tool is not installed, or it did not finish in time; a
+// check that cannot run is not a syntax error.
+func Check(workDir, path string, timeout time.Duration) *Result {
+	checker := CheckerFor(path)
+	if checker == nil {
+		return nil
+	}
+
+	if checker.Validate != nil {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		result := &Result{Checker: checker.Name, OK: true}
+		if err := checker.Validate(content); err != nil {
+			result.OK = false
+			result.Output = err.Error()
+		}
+		return result
+	}
+
+	command := checker.Command(path)
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = workDir
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil
+	}
+
+	result := &Result{Checker: checker.Name, OK: true}
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil
+		}
+		result.OK = false
+		result.Output = formatOutput(string(output), workDir)
+	}
+	return result
+}
+
+// formatOutput shortens absolute paths and keeps the start of the output,
+// where checkers report the first error.
+func formatOutput(output, workDir string) string {
+	output = strings.TrimSpace(output)
+	if workDir != "" {
+		output = strings.ReplaceAll(output, strings.TrimSuffix(workDir, "/")+"/", "")
+	}
+	if len(output) > MaxOutputLength {
+		output = text.Prefix(output, MaxOutputLength) + "...[truncated]"
+	}
+	return output
+}
+
+// isJSONC reports whether a .json file is conventionally JSON with
+// comments, which the strict JSON check would reject.
+func isJSONC(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return strings.HasPrefix(base, "tsconfig") || strings.HasPrefix(base, "jsconfig") ||
+		strings.HasPrefix(base, ".eslintrc") || base == "devcontainer.json" ||
+		filepath.Base(filepath.Dir(path)) == ".vscode"
+}
+
+func validateJSON(content []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(content, &v); err != nil {
+		if se, ok := err.(*json.SyntaxError); ok {
+			return fmt.Errorf("line %d: %w", 1+strings.Count(string(content[:se.Offset]), "\n"), err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package syntax
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckerFor(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "gofmt"},
+		{"web/app.MJS", "node --check"},
+		{"tool.py", "python compile"},
+		{"data.json", "json"},
+		{"tsconfig.json", ""},
+		{".vscode/settings.json", ""},
+		{"README.md", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if c := CheckerFor(tt.path); c != nil {
+			got = c.Name
+		}
+		if got != tt.want {
+			t.Errorf("CheckerFor(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckJSON(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "syntax-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	valid := filepath.Join(tmpDir, "valid.json")
+	os.WriteFile(valid, []byte(`{"a": [1, 2]}`), 0644)
+	if r := Check(tmpDir, valid, 0); r == nil || !r.OK {
+		t.Errorf("Check(valid.json) = %+v, want OK", r)
+	}
+
+	invalid := filepath.Join(tmpDir, "invalid.json")
+	os.WriteFile(invalid, []byte("{\n  \"a\": [1, 2\n}"), 0644)
+	r := Check(tmpDir, invalid, 0)
+	if r == nil || r.OK {
+		t.Fatalf("Check(invalid.json) = %+v, want syntax error", r)
+	}
+	if !strings.HasPrefix(r.Output, "line 3:") {
+		t.Errorf("Check(invalid.json).Output = %q, want line 3 error", r.Output)
+	}
+}
+
+func TestCheckGo(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("gofmt not installed")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "syntax-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Unformatted but valid code is not a syntax error
+	valid := filepath.Join(tmpDir, "valid.go")
+	os.WriteFile(valid, []byte("package main\nfunc main() {  }\n"), 0644)
+	if r := Check(tmpDir, valid, 0); r == nil || !r.OK {
+		t.Errorf("Check(valid.go) = %+v, want OK", r)
+	}
+
+	invalid := filepath.Join(tmpDir, "invalid.go")
+	os.WriteFile(invalid, []byte("package main\nfunc main() {\n"), 0644)
+	r := Check(tmpDir, invalid, 0)
+	if r == nil || r.OK {
+		t.Fatalf("Check(invalid.go) = %+v, want syntax error", r)
+	}
+	if strings.Contains(r.Output, tmpDir) || !strings.Contains(r.Output, "invalid.go") {
+		t.Errorf("Check(invalid.go).Output = %q, want path relative to workDir", r.Output)
+	}
+}
+
+func TestCheckMissingTool(t *testing.T) {
+	t.Setenv("PATH", "")
+	if r := Check("", "main.py", 0); r != nil {
+		t.Errorf("Check() without python3 = %+v, want nil", r)
+	}
+}
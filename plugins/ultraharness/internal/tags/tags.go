@@ -0,0 +1,102 @@
+// Package tags reads the labels, such as #auth, #perf, or #tech-debt,
+// that discoveries, plan steps, knowledge facts, and progress entries
+// carry. A tag is written inline as a hashtag in the text or listed in a
+// record's tags field; either way it is compared without the "#" and
+// ignoring case.
+package tags
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Normalize returns tag without a leading "#", lowercased and trimmed.
+func Normalize(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// Parse returns the hashtags in text, normalized, in order of first use.
+// A hashtag starts with a letter after a "#" that does not follow a word
+// character, so "#123", "C#", and "page#anchor" are not tags; it runs
+// through letters, digits, "-", and "_".
+func Parse(text string) []string {
+	var found []string
+	prev := ' '
+	for i, r := range text {
+		if r == '#' && !isWordRune(prev) {
+			if tag := hashtag(text[i+1:]); tag != "" {
+				found = append(found, tag)
+			}
+		}
+		prev = r
+	}
+	return Merge(found)
+}
+
+// hashtag returns the tag at the start of s, or "" if s does not start
+// with a letter.
+func hashtag(s string) string {
+	first, _ := utf8.DecodeRuneInString(s)
+	if !unicode.IsLetter(first) {
+		return ""
+	}
+	end := strings.IndexFunc(s, func(r rune) bool { return !isTagRune(r) })
+	if end < 0 {
+		end = len(s)
+	}
+	// A trailing "-" or "_" is punctuation, as in "#auth-"
+	return Normalize(strings.TrimRight(s[:end], "-_"))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '/'
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_'
+}
+
+// Of returns a record's tags: those listed explicitly and the hashtags in
+// its text.
+func Of(listed []string, text string) []string {
+	return Merge(listed, Parse(text))
+}
+
+// Merge returns the distinct normalized tags of lists, in order of first
+// appearance. Empty tags are dropped.
+func Merge(lists ...[]string) []string {
+	var merged []string
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, tag := range list {
+			if tag = Normalize(tag); tag != "" && !seen[tag] {
+				seen[tag] = true
+				merged = append(merged, tag)
+			}
+		}
+	}
+	return merged
+}
+
+// Split parses a comma- or space-separated list of tags, such as a
+// command-line flag value "auth,#perf".
+func Split(list string) []string {
+	return Merge(strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}))
+}
+
+// Any reports whether have includes any of want. Both are compared
+// normalized.
+func Any(have, want []string) bool {
+	for _, w := range want {
+		w = Normalize(w)
+		for _, h := range have {
+			if Normalize(h) == w {
+				return true
+			}
+		}
+	}
+	return false
+}
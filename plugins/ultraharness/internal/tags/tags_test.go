@@ -0,0 +1,47 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Tokens refresh in middleware #auth #perf", "auth perf"},
+		{"#Auth first, then #auth again", "auth"},
+		{"Mark as #tech-debt.", "tech-debt"},
+		{"(#auth) and #db_pool, #auth-", "auth db_pool"},
+		{"Fixes #123 in C# per docs/page#anchor", ""},
+		{"#", ""},
+		{"résumé #café", "café"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(Parse(tt.text), " "); got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestOfAndMerge(t *testing.T) {
+	if got := strings.Join(Of([]string{"#Perf", "auth"}, "Cache keys #auth #cache"), " "); got != "perf auth cache" {
+		t.Errorf("Of() = %q", got)
+	}
+	if got := strings.Join(Merge([]string{"", " #a "}, nil, []string{"A", "b"}), " "); got != "a b" {
+		t.Errorf("Merge() = %q", got)
+	}
+	if got := strings.Join(Split("auth, #perf  tech-debt,,"), " "); got != "auth perf tech-debt" {
+		t.Errorf("Split() = %q", got)
+	}
+}
+
+func TestAny(t *testing.T) {
+	if !Any([]string{"auth", "perf"}, []string{"#Perf"}) {
+		t.Error("Any() should match ignoring case and the #")
+	}
+	if Any([]string{"auth"}, []string{"perf"}) || Any(nil, []string{"auth"}) || Any([]string{"auth"}, nil) {
+		t.Error("Any() matched without a shared tag")
+	}
+}
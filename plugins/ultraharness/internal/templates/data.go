@@ -0,0 +1,106 @@
+package templates
+
+// Phase is the data of phase_guidance.
+type Phase struct {
+	// Phase is the current FIC phase, e.g. "RESEARCH"
+	Phase string
+}
+
+// Prompt is the data of research_directive and planning_directive.
+type Prompt struct {
+	Phase string
+	// Prompt is the user's request, cut to 100 bytes
+	Prompt      string
+	HasResearch bool
+}
+
+// Deferral is the data of research_deferred.
+type Deferral struct {
+	// Added is false if the question was already queued
+	Added   bool
+	ID      int
+	Pending int
+	File    string
+}
+
+// Context is the data of the compaction directives (auto_compact_directive,
+// compaction_directive, prompt_compaction_directive, tool_count_directive)
+// and context_warning.
+type Context struct {
+	// Reason is "utilization" or "tool_count"
+	Reason string
+	// Utilization and Threshold are 0-1 fractions; format them with percent
+	Utilization   float64
+	Threshold     float64
+	TokenEstimate int
+	ToolCalls     int
+	ToolLimit     int
+	// Remaining is the number of tool calls left before ToolLimit
+	Remaining int
+	// Redundant counts repeated reads and searches
+	Redundant   int
+	Summary     string
+	Compactions int
+	// Quiet is set with output_verbosity "quiet"
+	Quiet bool
+}
+
+// Gate is the data of gate_message.
+type Gate struct {
+	// Action is "warn" or "block"
+	Action string
+	// Code identifies the gate for translations: "research_incomplete" or
+	// "plan_incomplete"
+	Code        string
+	Reason      string
+	Suggestions []string
+}
+
+// Digest is the data of digest, the stakeholder summary printed by
+// report -digest.
+type Digest struct {
+	Project string
+	// From and To bound the period, formatted as dates
+	From string
+	To   string
+	// Period names the period in a sentence: "this week" or "in the last
+	// N days"
+	Period string
+	// Done and Total count the passing and all features
+	Done  int
+	Total int
+	// Completed are the features that started passing in the period
+	Completed  []DigestFeature
+	InProgress []DigestFeature
+	// Next are the highest-priority features ready to start
+	Next []DigestFeature
+	// Risks describe failing features, work started before its
+	// prerequisites, and unresolved errors, in plain language
+	Risks []string
+}
+
+// ADR is the data of adr, a decision rendered as a markdown record by
+// decision -export.
+type ADR struct {
+	// Number is the record number, e.g. "ADR-3"
+	Number string
+	Title  string
+	// Date is when the decision was recorded, as YYYY-MM-DD
+	Date    string
+	Status  string
+	Context string
+	// Options are the alternatives considered
+	Options      []string
+	Decision     string
+	Consequences string
+	// Source names who recorded it, e.g. "agent" or "subagent: explore"
+	Source string
+	Tags   []string
+}
+
+// DigestFeature is a feature listed in a digest.
+type DigestFeature struct {
+	ID          string
+	Name        string
+	Description string
+}
@@ -0,0 +1,176 @@
+// Package templates renders the text the harness injects into the
+// conversation, such as compaction directives, phase guidance, and gate
+// messages, from Go text/template files.
+//
+// Each message has a default template embedded in the binary. A project
+// overrides one by putting a file with the same name in .claude/templates/,
+// e.g. .claude/templates/phase_guidance.tmpl, so teams can rebrand,
+// translate, or slim down what the harness says without rebuilding it. An
+// override that fails to parse or execute falls back to the default and
+// queues a notice naming the file, so a typo never silences a directive.
+//
+// Defaults are bundled per locale under defaults/<locale>/. English has
+// every template; another locale may translate only some, and the rest
+// fall back to English, so a partial translation is still usable.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"ultraharness/internal/protocol"
+)
+
+// DirName is the directory inside .claude holding template overrides.
+const DirName = "templates"
+
+// Ext is the file extension of templates.
+const Ext = ".tmpl"
+
+// DefaultLocale is the locale that has a default template for every
+// message; other locales fall back to it.
+const DefaultLocale = "en"
+
+//go:embed defaults
+var defaults embed.FS
+
+// locale is the locale messages are rendered in
+var locale = DefaultLocale
+
+// funcs are available to every template.
+var funcs = template.FuncMap{
+	// percent formats a 0-1 fraction as a whole percentage, without the
+	// sign: {{percent .Utilization}}%
+	"percent": func(fraction float64) string {
+		return fmt.Sprintf("%.0f", fraction*100)
+	},
+}
+
+// SetLocale selects the locale messages are rendered in, e.g. "es" or
+// "pt-BR". Hooks set it from the locale setting before running.
+func SetLocale(l string) {
+	locale = l
+}
+
+// Dir returns the template override directory for workDir.
+func Dir(workDir string) string {
+	return filepath.Join(workDir, ".claude", DirName)
+}
+
+// Names returns the names of the default templates, sorted.
+func Names() []string {
+	return bundled(DefaultLocale)
+}
+
+// Locales returns the locales with bundled templates, sorted.
+func Locales() []string {
+	entries, _ := defaults.ReadDir("defaults")
+	locales := make([]string, 0, len(entries))
+	for _, e := range entries {
+		locales = append(locales, e.Name())
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// bundled returns the names of the templates bundled for locale l, sorted.
+func bundled(l string) []string {
+	entries, _ := defaults.ReadDir("defaults/" + l)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), Ext))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the source of the template name bundled for locale l,
+// or "" if there is none. It does not fall back to other locales.
+func Default(l, name string) string {
+	data, err := defaults.ReadFile("defaults/" + l + "/" + name + Ext)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// fallbacks returns the locales to look in for l, most specific first and
+// ending with the default locale: "pt_BR" gives pt-br, pt, en.
+func fallbacks(l string) []string {
+	l = strings.ToLower(strings.ReplaceAll(l, "_", "-"))
+	var chain []string
+	for l != "" && l != DefaultLocale {
+		chain = append(chain, l)
+		i := strings.LastIndex(l, "-")
+		if i < 0 {
+			break
+		}
+		l = l[:i]
+	}
+	return append(chain, DefaultLocale)
+}
+
+// Render executes the template name with data in the current locale. The
+// first template found is used, in this order: the project's localized
+// overrides in .claude/templates/<locale>/, its override for every locale
+// in .claude/templates/, then the bundled templates of the locale and its
+// fallbacks down to English. It returns "" if name has no default
+// template.
+func Render(workDir, name string, data interface{}) string {
+	chain := fallbacks(locale)
+
+	if workDir != "" {
+		overrides := make([]string, 0, len(chain)+1)
+		for _, l := range chain {
+			overrides = append(overrides, filepath.Join(l, name+Ext))
+		}
+		overrides = append(overrides, name+Ext)
+
+		for _, rel := range overrides {
+			source, err := os.ReadFile(filepath.Join(Dir(workDir), rel))
+			if err != nil {
+				continue
+			}
+			text, err := execute(name, string(source), data)
+			if err == nil {
+				return text
+			}
+			protocol.AddNotice(fmt.Sprintf(
+				"[Harness] Warning: template %s is invalid (%v); using the default.",
+				filepath.Join(".claude", DirName, rel), err))
+			break
+		}
+	}
+
+	// The bundled templates are tested to execute with the data their
+	// callers pass
+	for _, l := range chain {
+		if source := Default(l, name); source != "" {
+			text, _ := execute(name, source, data)
+			return text
+		}
+	}
+	return ""
+}
+
+// execute parses and executes source. The final newline of the file is
+// dropped, so templates can end with one like any text file.
+func execute(name, source string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").
+		Parse(strings.TrimSuffix(source, "\n"))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,164 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/protocol"
+)
+
+// samples holds the data each default template is rendered with
+var samples = map[string]interface{}{
+	"auto_compact_directive":      Context{Reason: "tool_count", ToolCalls: 61, ToolLimit: 60, Summary: "61 tools"},
+	"compaction_directive":        Context{Reason: "utilization", Utilization: 0.91, Threshold: 0.85, Summary: "91% util"},
+	"context_warning":             Context{Utilization: 0.6, ToolCalls: 40, ToolLimit: 60, Remaining: 20, Redundant: 3},
+	"adr":                         ADR{Number: "ADR-3", Title: "Refresh tokens in middleware", Date: "2026-10-15", Status: "accepted", Options: []string{"Per handler", "Middleware"}, Decision: "Use middleware", Tags: []string{"auth"}},
+	"digest":                      Digest{Project: "shop", From: "Oct 8", To: "Oct 15", Period: "this week", Done: 3, Total: 5, Completed: []DigestFeature{{Name: "Checkout"}}, Risks: []string{"Search is failing its checks"}},
+	"gate_message":                Gate{Action: "warn", Reason: "Research phase not complete", Suggestions: []string{"Research first"}},
+	"phase_guidance":              Phase{Phase: "RESEARCH"},
+	"planning_directive":          Prompt{Phase: "PLANNING", Prompt: "add caching"},
+	"prompt_compaction_directive": Context{Utilization: 0.91, Threshold: 0.85, TokenEstimate: 150000},
+	"research_deferred":           Deferral{Added: true, ID: 2, Pending: 1, File: "research-queue.json"},
+	"research_directive":          Prompt{Phase: "RESEARCH", Prompt: "how does auth work"},
+	"tool_count_directive":        Context{ToolCalls: 61, ToolLimit: 60, Summary: "61 tools"},
+}
+
+func TestDefaults(t *testing.T) {
+	english := Names()
+	for _, l := range Locales() {
+		for _, name := range bundled(l) {
+			t.Run(l+"/"+name, func(t *testing.T) {
+				if !contains(english, name) {
+					t.Fatalf("%s has no English default to fall back to", name)
+				}
+				data, ok := samples[name]
+				if !ok {
+					t.Fatalf("no sample data for template %s", name)
+				}
+				for _, quiet := range []bool{false, true} {
+					if c, ok := data.(Context); ok {
+						c.Quiet = quiet
+						data = c
+					}
+					text, err := execute(name, Default(l, name), data)
+					if err != nil || strings.TrimSpace(text) == "" {
+						t.Errorf("default %s (quiet %v) = %q, %v; want text", name, quiet, text, err)
+					}
+					if strings.HasSuffix(text, "\n") {
+						t.Errorf("default %s (quiet %v) ends with a newline", name, quiet)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	tests := map[string]string{
+		"":           "en",
+		"en":         "en",
+		"es":         "es en",
+		"pt_BR":      "pt-br pt en",
+		"zh-Hant-TW": "zh-hant-tw zh-hant zh en",
+	}
+	for l, want := range tests {
+		if got := strings.Join(fallbacks(l), " "); got != want {
+			t.Errorf("fallbacks(%q) = %s, want %s", l, got, want)
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "templates-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	defer SetLocale(DefaultLocale)
+
+	SetLocale("es-MX")
+	if got := Render(tmpDir, "phase_guidance", Phase{Phase: "PLANNING"}); !strings.HasPrefix(got, "IMPORTANTE:") {
+		t.Errorf("Render() in es-MX = %q, want the Spanish guidance", got)
+	}
+	gate := Render(tmpDir, "gate_message", Gate{Action: "block", Code: "plan_incomplete", Reason: "Planning phase not complete"})
+	if !strings.Contains(gate, "planificación") || strings.Contains(gate, "Planning phase") {
+		t.Errorf("Render() of a known gate in es = %q, want it explained in Spanish", gate)
+	}
+	if got := Render(tmpDir, "tool_count_directive", Context{ToolCalls: 61, ToolLimit: 60, Quiet: true}); !strings.HasPrefix(got, "[FIC] CRITICAL: 61 tool calls") {
+		t.Errorf("Render() of an untranslated template = %q, want the English default", got)
+	}
+
+	// A localized override beats the project-wide one, which beats the
+	// bundled translation
+	os.MkdirAll(filepath.Join(Dir(tmpDir), "es"), 0755)
+	os.WriteFile(filepath.Join(Dir(tmpDir), "phase_guidance.tmpl"), []byte("all: {{.Phase}}"), 0644)
+	if got := Render(tmpDir, "phase_guidance", Phase{Phase: "PLANNING"}); got != "all: PLANNING" {
+		t.Errorf("Render() with a project-wide override = %q, want it used", got)
+	}
+	os.WriteFile(filepath.Join(Dir(tmpDir), "es", "phase_guidance.tmpl"), []byte("es: {{.Phase}}"), 0644)
+	if got := Render(tmpDir, "phase_guidance", Phase{Phase: "PLANNING"}); got != "es: PLANNING" {
+		t.Errorf("Render() with a localized override = %q, want it used", got)
+	}
+
+	SetLocale("fr")
+	if got := Render(tmpDir, "research_directive", Prompt{Phase: "RESEARCH", Prompt: "q"}); !strings.HasPrefix(got, "[FIC] Research request detected.") {
+		t.Errorf("Render() in an unbundled locale = %q, want English", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRender(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "templates-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	protocol.TakeNotices()
+
+	want := "IMPORTANT: Continue PLANNING. Validate the plan before implementation."
+	if got := Render(tmpDir, "phase_guidance", Phase{Phase: "PLANNING"}); got != want {
+		t.Errorf("Render() without an override = %q, want %q", got, want)
+	}
+	if got := Render(tmpDir, "no_such_message", nil); got != "" {
+		t.Errorf("Render() of an unknown template = %q, want empty", got)
+	}
+
+	override := func(source string) {
+		os.MkdirAll(Dir(tmpDir), 0755)
+		os.WriteFile(filepath.Join(Dir(tmpDir), "phase_guidance.tmpl"), []byte(source), 0644)
+	}
+
+	override("Phase: {{.Phase | printf \"%.4s\"}}\n")
+	if got := Render(tmpDir, "phase_guidance", Phase{Phase: "PLANNING"}); got != "Phase: PLAN" {
+		t.Errorf("Render() with an override = %q, want it used without its final newline", got)
+	}
+	if notices := protocol.TakeNotices(); len(notices) != 0 {
+		t.Errorf("valid override queued notices %v", notices)
+	}
+
+	for name, source := range map[string]string{
+		"parse error":   "{{if .Phase}}unterminated",
+		"unknown field": "{{.Stage}}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			override(source)
+			if got := Render(tmpDir, "phase_guidance", Phase{Phase: "PLANNING"}); got != want {
+				t.Errorf("Render() with an invalid override = %q, want the default", got)
+			}
+			notices := protocol.TakeNotices()
+			if len(notices) != 1 || !strings.Contains(notices[0], filepath.Join(".claude", "templates", "phase_guidance.tmpl")) {
+				t.Errorf("Render() with an invalid override queued %v, want a notice naming the file", notices)
+			}
+		})
+	}
+}
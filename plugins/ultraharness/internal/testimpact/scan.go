@@ -0,0 +1,107 @@
+package testimpact
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Limits on the naive import scan, so large repositories stay fast
+const (
+	maxScannedFiles = 5000
+	maxScannedBytes = 256 * 1024
+)
+
+// families groups the extensions whose files import each other
+var families = map[string]string{
+	".js": "js", ".jsx": "js", ".mjs": "js", ".cjs": "js",
+	".ts": "js", ".tsx": "js", ".vue": "js", ".svelte": "js",
+	".py": "py",
+}
+
+var (
+	// import ... from './x', export ... from './x', import('./x'),
+	// import './x', and require('./x')
+	jsImportPattern = regexp.MustCompile(`(?:from|import|require)\s*\(?\s*['"](\.{1,2}(?:/[^'"]*)?)['"]`)
+	// from pkg.mod import a, b, from . import a, and from pkg import (a, b)
+	pyFromPattern = regexp.MustCompile(`(?m)^\s*from\s+(\.*)([\w.]*)\s+import\s+(\([^)]*\)|[^\n]+)`)
+	// import pkg.mod, other as o
+	pyImportPattern = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)`)
+)
+
+// moduleKey identifies a file by its path without extension, with index
+// and __init__ files standing for their directory, as imports name them.
+func moduleKey(file string) string {
+	key := strings.TrimSuffix(filepath.ToSlash(file), filepath.Ext(file))
+	for _, index := range []string{"/index", "/__init__"} {
+		key = strings.TrimSuffix(key, index)
+	}
+	return key
+}
+
+// parseImports returns the module keys a file imports. Only relative
+// imports are followed in JavaScript and TypeScript, since package imports
+// name dependencies rather than project files.
+func parseImports(workDir, file, family string) []string {
+	source := readSource(workDir, file)
+	if source == "" {
+		return nil
+	}
+	dir := path.Dir(filepath.ToSlash(file))
+
+	var targets []string
+	if family == "js" {
+		for _, m := range jsImportPattern.FindAllStringSubmatch(source, -1) {
+			spec := m[1]
+			if families[strings.ToLower(path.Ext(spec))] == "js" {
+				spec = strings.TrimSuffix(spec, path.Ext(spec))
+			}
+			targets = append(targets, moduleKey(path.Join(dir, spec)))
+		}
+		return targets
+	}
+
+	for _, m := range pyFromPattern.FindAllStringSubmatch(source, -1) {
+		base := strings.ReplaceAll(m[2], ".", "/")
+		if dots := len(m[1]); dots > 0 {
+			// Relative to the importing file's package
+			pkg := dir
+			for i := 1; i < dots; i++ {
+				pkg = path.Dir(pkg)
+			}
+			base = strings.TrimSuffix(path.Join(pkg, base), "/")
+		}
+		if base != "" && base != "." {
+			targets = append(targets, base)
+		}
+		for _, name := range strings.Split(m[3], ",") {
+			fields := strings.Fields(strings.Trim(name, " ()\\"))
+			if len(fields) > 0 && fields[0] != "*" {
+				targets = append(targets, path.Join(base, fields[0]))
+			}
+		}
+	}
+	for _, m := range pyImportPattern.FindAllStringSubmatch(source, -1) {
+		for _, name := range strings.Split(m[1], ",") {
+			if fields := strings.Fields(name); len(fields) > 0 {
+				targets = append(targets, strings.ReplaceAll(fields[0], ".", "/"))
+			}
+		}
+	}
+	return targets
+}
+
+// resolve returns the key of the project file a target names. Python
+// packages are also looked up under src/.
+func resolve(sources map[string]string, target, family string) (string, bool) {
+	if _, ok := sources[target]; ok {
+		return target, true
+	}
+	if family == "py" {
+		if _, ok := sources["src/"+target]; ok {
+			return "src/" + target, true
+		}
+	}
+	return "", false
+}
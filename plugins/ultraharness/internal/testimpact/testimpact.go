@@ -0,0 +1,315 @@
+// Package testimpact finds the tests likely affected by a change to a
+// file, so the agent can run those instead of the full suite or nothing.
+// Go packages are related with go list; JavaScript, TypeScript, and Python
+// files with a naive scan of their imports.
+package testimpact
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ultraharness/internal/git"
+	"ultraharness/internal/imports"
+	"ultraharness/internal/testrunner"
+)
+
+// MaxDepth is the number of import hops followed from the changed file
+const MaxDepth = 2
+
+// Impact lists the tests likely affected by a change, closest first.
+type Impact struct {
+	// Files are test files relative to workDir
+	Files []string
+	// Command runs them, for languages whose runner selects tests by
+	// package
+	Command string
+}
+
+// Find returns up to limit test files likely affected by a change to path:
+// tests of the file itself, then tests of the code that imports it, up to
+// MaxDepth hops away.
+func Find(workDir, path string, limit int, timeout time.Duration) Impact {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		return findGo(workDir, path, limit, timeout)
+	}
+	if family, ok := families[ext]; ok {
+		return findScripts(workDir, path, family, limit)
+	}
+	return Impact{}
+}
+
+// Supported reports whether Find can relate path to its tests: a Go,
+// JavaScript, TypeScript, or Python file.
+func Supported(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	_, ok := families[ext]
+	return ok || ext == ".go"
+}
+
+// findGo returns the tests of the file's package, then those of the
+// packages that import it.
+func findGo(workDir, path string, limit int, timeout time.Duration) Impact {
+	mod := imports.FindModule(path)
+	if mod == nil {
+		return Impact{}
+	}
+	pkg, err := filepath.Rel(mod.Root, filepath.Dir(path))
+	if err != nil {
+		return Impact{}
+	}
+	pkgs := []string{filepath.ToSlash(pkg)}
+	if graph, err := imports.LoadGraph(mod, timeout); err == nil {
+		pkgs = append(pkgs, graph.Dependents(pkgs[0], MaxDepth)...)
+	}
+
+	var impact Impact
+	var tested []string
+	for _, p := range pkgs {
+		matches, _ := filepath.Glob(filepath.Join(mod.Root, filepath.FromSlash(p), "*_test.go"))
+		if len(matches) == 0 {
+			continue
+		}
+		sort.Strings(matches)
+		tested = append(tested, "./"+p)
+		for _, m := range matches {
+			if len(impact.Files) == limit {
+				break
+			}
+			impact.Files = append(impact.Files, relPath(workDir, m))
+		}
+		if len(impact.Files) == limit {
+			break
+		}
+	}
+	if len(tested) == 0 {
+		return Impact{}
+	}
+
+	impact.Command = "go test " + strings.Join(tested, " ")
+	if modDir := relPath(workDir, mod.Root); modDir != "." {
+		impact.Command = "cd " + modDir + " && " + impact.Command
+	}
+	return impact
+}
+
+// findScripts returns the tests named after the file, then the tests
+// that import it, directly or through other files of the same language.
+func findScripts(workDir, path, family string, limit int) Impact {
+	rel := relPath(workDir, path)
+	if testrunner.IsTestFile(rel) {
+		return Impact{Files: []string{rel}}
+	}
+	files, ok := git.ListFiles(workDir)
+	if !ok {
+		return Impact{}
+	}
+
+	// Files of the same language by module key
+	sources := map[string]string{}
+	for _, f := range files {
+		if families[strings.ToLower(filepath.Ext(f))] == family {
+			sources[moduleKey(f)] = f
+		}
+		if len(sources) == maxScannedFiles {
+			break
+		}
+	}
+
+	importers := map[string][]string{}
+	for key, f := range sources {
+		for _, target := range parseImports(workDir, f, family) {
+			if target, ok := resolve(sources, target, family); ok && target != key {
+				importers[target] = append(importers[target], key)
+			}
+		}
+	}
+
+	var impact Impact
+	add := func(tests []string) bool {
+		sort.Strings(tests)
+		for _, t := range tests {
+			if len(impact.Files) == limit {
+				return false
+			}
+			impact.Files = append(impact.Files, t)
+		}
+		return true
+	}
+
+	// Tests named after the file, beside it or in a test directory
+	seen := map[string]bool{}
+	var named []string
+	for _, f := range sources {
+		if testrunner.IsTestFile(f) && isTestOf(f, rel) {
+			named = append(named, f)
+			seen[moduleKey(f)] = true
+		}
+	}
+	if !add(named) {
+		return impact
+	}
+
+	start := moduleKey(rel)
+	seen[start] = true
+	frontier := []string{start}
+	for hop := 0; hop < MaxDepth && len(frontier) > 0; hop++ {
+		var next, tests []string
+		for _, key := range frontier {
+			for _, from := range importers[key] {
+				if seen[from] {
+					continue
+				}
+				seen[from] = true
+				next = append(next, from)
+				if testrunner.IsTestFile(sources[from]) {
+					tests = append(tests, sources[from])
+				}
+			}
+		}
+		if !add(tests) {
+			break
+		}
+		frontier = next
+	}
+	return impact
+}
+
+// isTestOf reports whether test is named after file and sits beside it or
+// in a tests, test, spec, or __tests__ directory beside it.
+func isTestOf(test, file string) bool {
+	dir := filepath.Dir(test)
+	if base := filepath.Base(dir); base == "tests" || base == "test" || base == "spec" || base == "__tests__" {
+		if dir != filepath.Dir(file) {
+			dir = filepath.Dir(dir)
+		}
+	}
+	if dir != filepath.Dir(file) {
+		return false
+	}
+	return testSubject(test) == strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+// testSubject strips the test markers from a test file's name.
+func testSubject(test string) string {
+	name := strings.TrimSuffix(filepath.Base(test), filepath.Ext(test))
+	for _, suffix := range []string{"_test", "_spec", ".test", ".spec", "Test"} {
+		if trimmed := strings.TrimSuffix(name, suffix); trimmed != name {
+			return trimmed
+		}
+	}
+	return strings.TrimPrefix(name, "test_")
+}
+
+// relPath returns path relative to workDir with forward slashes.
+func relPath(workDir, path string) string {
+	if rel, err := filepath.Rel(workDir, path); err == nil {
+		path = rel
+	}
+	return filepath.ToSlash(path)
+}
+
+// readSource reads a file to scan its imports, skipping large files.
+func readSource(workDir, file string) string {
+	path := filepath.Join(workDir, file)
+	if info, err := os.Stat(path); err != nil || info.Size() > maxScannedBytes {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// GoPackages returns the Go packages with tests that changes to files,
+// relative to workDir, may affect: the packages containing changed .go
+// files and every package that imports them, directly or not, or every
+// package of a module whose go.mod or go.sum changed. With all set, it
+// returns every package with tests in the modules of files instead, or in
+// the module at workDir if files is empty. Packages are directories
+// relative to workDir, like "./internal/git", sorted; each module is
+// listed with go list within timeout.
+func GoPackages(workDir string, files []string, all bool, timeout time.Duration) []string {
+	type changes struct {
+		mod  *imports.Module
+		pkgs []string
+		all  bool
+	}
+	modules := map[string]*changes{}
+	var order []string
+	add := func(path string) *changes {
+		mod := imports.FindModule(path)
+		if mod == nil {
+			return nil
+		}
+		c, ok := modules[mod.Root]
+		if !ok {
+			c = &changes{mod: mod, all: all}
+			modules[mod.Root] = c
+			order = append(order, mod.Root)
+		}
+		return c
+	}
+
+	if all && len(files) == 0 {
+		add(filepath.Join(workDir, "go.mod"))
+	}
+	for _, f := range files {
+		path := filepath.Join(workDir, f)
+		base := filepath.Base(f)
+		if base != "go.mod" && base != "go.sum" && filepath.Ext(f) != ".go" {
+			continue
+		}
+		c := add(path)
+		if c == nil {
+			continue
+		}
+		if base == "go.mod" || base == "go.sum" {
+			c.all = true
+		} else if pkg, err := filepath.Rel(c.mod.Root, filepath.Dir(path)); err == nil {
+			c.pkgs = append(c.pkgs, filepath.ToSlash(pkg))
+		}
+	}
+
+	seen := map[string]bool{}
+	var dirs []string
+	for _, root := range order {
+		c := modules[root]
+		graph, err := imports.LoadGraph(c.mod, timeout)
+		pkgs := c.pkgs
+		switch {
+		case err != nil:
+		case c.all:
+			pkgs = nil
+			for pkg := range graph {
+				pkgs = append(pkgs, pkg)
+			}
+		default:
+			for _, pkg := range c.pkgs {
+				pkgs = append(pkgs, graph.Dependents(pkg, len(graph))...)
+			}
+		}
+		for _, pkg := range pkgs {
+			dir := relPath(workDir, filepath.Join(root, filepath.FromSlash(pkg)))
+			if dir != "." && !strings.HasPrefix(dir, "../") {
+				dir = "./" + dir
+			}
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			if tests, _ := filepath.Glob(filepath.Join(workDir, filepath.FromSlash(dir), "*_test.go")); len(tests) > 0 {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
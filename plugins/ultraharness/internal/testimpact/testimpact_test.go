@@ -0,0 +1,175 @@
+package testimpact
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeTree creates files under a new git repository.
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := os.MkdirTemp("", "testimpact-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec.Command("git", "init", "-q", dir).Run()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFindGo(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	dir := writeTree(t, map[string]string{
+		"go.mod":                      "module example.com/app\n\ngo 1.21\n",
+		"store/store.go":              "package store\n\nfunc Get() int { return 1 }\n",
+		"store/store_test.go":         "package store\n",
+		"api/api.go":                  "package api\n\nimport \"example.com/app/store\"\n\nvar V = store.Get()\n",
+		"api/api_test.go":             "package api\n",
+		"api/handler_test.go":         "package api\n",
+		"cmd/server/main.go":          "package main\n\nimport _ \"example.com/app/api\"\n\nfunc main() {}\n",
+		"unrelated/unrelated.go":      "package unrelated\n",
+		"unrelated/unrelated_test.go": "package unrelated\n",
+	})
+	defer os.RemoveAll(dir)
+
+	impact := Find(dir, "store/store.go", 3, 30*time.Second)
+	want := []string{"store/store_test.go", "api/api_test.go", "api/handler_test.go"}
+	if !reflect.DeepEqual(impact.Files, want) {
+		t.Errorf("Find().Files = %v, want %v", impact.Files, want)
+	}
+	if impact.Command != "go test ./store ./api" {
+		t.Errorf("Find().Command = %q, want go test ./store ./api", impact.Command)
+	}
+
+	impact = Find(filepath.Dir(dir), filepath.Join(dir, "store/store.go"), 1, 30*time.Second)
+	if want := "cd " + filepath.Base(dir) + " && go test ./store"; impact.Command != want {
+		t.Errorf("Find() from outside the module: Command = %q, want %q", impact.Command, want)
+	}
+
+	if impact := Find(dir, "cmd/server/main.go", 3, 30*time.Second); impact.Files != nil || impact.Command != "" {
+		t.Errorf("Find() for an untested package = %+v, want none", impact)
+	}
+}
+
+func TestGoPackages(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	dir := writeTree(t, map[string]string{
+		"go.mod":                      "module example.com/app\n\ngo 1.21\n",
+		"store/store.go":              "package store\n\nfunc Get() int { return 1 }\n",
+		"store/store_test.go":         "package store\n",
+		"api/api.go":                  "package api\n\nimport \"example.com/app/store\"\n\nvar V = store.Get()\n",
+		"api/api_test.go":             "package api\n",
+		"web/web.go":                  "package web\n\nimport _ \"example.com/app/api\"\n",
+		"web/web_test.go":             "package web\n",
+		"unrelated/unrelated.go":      "package unrelated\n",
+		"unrelated/unrelated_test.go": "package unrelated\n",
+		"tools/go.mod":                "module example.com/tools\n\ngo 1.21\n",
+		"tools/gen/gen.go":            "package gen\n",
+		"tools/gen/gen_test.go":       "package gen\n",
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name  string
+		files []string
+		all   bool
+		want  []string
+	}{
+		{"transitive importers", []string{"store/store.go"}, false, []string{"./api", "./store", "./web"}},
+		{"leaf package", []string{"web/web.go", "README.md"}, false, []string{"./web"}},
+		{"nested module", []string{"tools/gen/gen.go"}, false, []string{"./tools/gen"}},
+		{"go.mod changed", []string{"go.mod"}, false, []string{"./api", "./store", "./unrelated", "./web"}},
+		{"all", nil, true, []string{"./api", "./store", "./unrelated", "./web"}},
+		{"no Go files", []string{"docs/index.md"}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GoPackages(dir, tt.files, tt.all, 30*time.Second); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GoPackages(%v) = %v, want %v", tt.files, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindScripts(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"src/util/format.ts":                "export const f = 1\n",
+		"src/util/format.test.ts":           "import { f } from './format'\n",
+		"src/components/Price.tsx":          "import { f } from '../util/format'\n",
+		"src/components/Price.spec.tsx":     "import Price from './Price.tsx'\n",
+		"src/pages/index.ts":                "export * from '../components/Price'\n",
+		"src/pages/__tests__/index.test.ts": "import page from '..'\n",
+		"src/other.test.ts":                 "import lodash from 'lodash'\n",
+	})
+	defer os.RemoveAll(dir)
+
+	impact := Find(dir, filepath.Join(dir, "src/util/format.ts"), 5, 0)
+	want := []string{"src/util/format.test.ts", "src/components/Price.spec.tsx"}
+	if !reflect.DeepEqual(impact.Files, want) {
+		t.Errorf("Find().Files = %v, want %v", impact.Files, want)
+	}
+
+	impact = Find(dir, "src/components/Price.tsx", 5, 0)
+	want = []string{"src/components/Price.spec.tsx", "src/pages/__tests__/index.test.ts"}
+	if !reflect.DeepEqual(impact.Files, want) {
+		t.Errorf("Find().Files = %v, want %v", impact.Files, want)
+	}
+
+	if impact := Find(dir, "src/util/format.ts", 1, 0); len(impact.Files) != 1 {
+		t.Errorf("Find() with limit 1 = %v, want one file", impact.Files)
+	}
+	if impact := Find(dir, "src/other.test.ts", 5, 0); !reflect.DeepEqual(impact.Files, []string{"src/other.test.ts"}) {
+		t.Errorf("Find() for a test file = %v, want the file itself", impact.Files)
+	}
+}
+
+func TestFindPython(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"src/shop/__init__.py":      "",
+		"src/shop/cart.py":          "from .pricing import total\n",
+		"src/shop/pricing.py":       "TAX = 0.2\n",
+		"tests/test_pricing.py":     "from shop.pricing import TAX\n",
+		"tests/test_cart.py":        "import shop.cart as cart\n",
+		"tests/test_everything.py":  "from shop import (\n    cart,\n)\n",
+		"src/shop/tests/test_db.py": "import sqlite3\n",
+	})
+	defer os.RemoveAll(dir)
+
+	impact := Find(dir, "src/shop/pricing.py", 5, 0)
+	want := []string{"tests/test_pricing.py", "tests/test_cart.py", "tests/test_everything.py"}
+	if !reflect.DeepEqual(impact.Files, want) {
+		t.Errorf("Find().Files = %v, want %v", impact.Files, want)
+	}
+}
+
+func TestIsTestOf(t *testing.T) {
+	tests := []struct {
+		test, file string
+		want       bool
+	}{
+		{"src/app.test.js", "src/app.js", true},
+		{"src/__tests__/app.test.js", "src/app.js", true},
+		{"lib/tests/test_util.py", "lib/util.py", true},
+		{"src/UserTest.java", "src/User.java", true},
+		{"other/app.test.js", "src/app.js", false},
+		{"src/apple.test.js", "src/app.js", false},
+	}
+	for _, tt := range tests {
+		if got := isTestOf(tt.test, tt.file); got != tt.want {
+			t.Errorf("isTestOf(%s, %s) = %v, want %v", tt.test, tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package testrunner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ultraharness/internal/git"
+	"ultraharness/internal/text"
+)
+
+// CacheFileName is the name of the test result cache.
+const CacheFileName = "fic-test-cache.json"
+
+// MaxCachedOutput limits the test output kept in the cache; the end of the
+// output, where runners report failures, is kept.
+const MaxCachedOutput = 2000
+
+// cachedRun is a test run recorded against the working tree state.
+type cachedRun struct {
+	Fingerprint string    `json:"fingerprint"`
+	Result      Result    `json:"result"`
+	Output      string    `json:"output,omitempty"`
+	Passed      int       `json:"passed"`
+	Failed      int       `json:"failed"`
+	Skipped     int       `json:"skipped"`
+	Total       int       `json:"total"`
+	RanAt       time.Time `json:"ran_at"`
+}
+
+// GetCachePath returns the path to the test result cache file.
+func GetCachePath(workDir string) string {
+	return filepath.Join(workDir, ".claude", CacheFileName)
+}
+
+// RunCached runs the tests unless the last passing or failing run was made
+// on the same working tree, in which case its result is returned and cached
+// is true. Runs that time out or find no test command are not cached.
+func RunCached(workDir string, timeout time.Duration) (summary *Summary, cached bool) {
+	fingerprint := git.StateFingerprint(workDir)
+
+	if data, err := os.ReadFile(GetCachePath(workDir)); err == nil {
+		var run cachedRun
+		if json.Unmarshal(data, &run) == nil && run.Fingerprint == fingerprint {
+			return &Summary{
+				Result:    run.Result,
+				RawOutput: run.Output,
+				Passed:    run.Passed,
+				Failed:    run.Failed,
+				Skipped:   run.Skipped,
+				Total:     run.Total,
+			}, true
+		}
+	}
+
+	summary = Run(workDir, timeout)
+	if summary.Result != Passed && summary.Result != Failed {
+		return summary, false
+	}
+
+	output := summary.RawOutput
+	if len(output) > MaxCachedOutput {
+		output = "...[truncated]" + text.Suffix(output, MaxCachedOutput)
+	}
+	data, err := json.MarshalIndent(cachedRun{
+		Fingerprint: fingerprint,
+		Result:      summary.Result,
+		Output:      output,
+		Passed:      summary.Passed,
+		Failed:      summary.Failed,
+		Skipped:     summary.Skipped,
+		Total:       summary.Total,
+		RanAt:       time.Now(),
+	}, "", "  ")
+	if err == nil && os.MkdirAll(filepath.Dir(GetCachePath(workDir)), 0700) == nil {
+		os.WriteFile(GetCachePath(workDir), data, 0600)
+	}
+	return summary, false
+}
@@ -0,0 +1,45 @@
+package testrunner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCached(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "testrunner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	exec.Command("git", "-C", tmpDir, "init", "-q").Run()
+
+	counter := filepath.Join(tmpDir, ".claude", "runs")
+	os.MkdirAll(filepath.Dir(counter), 0700)
+	os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte("test:\n\t@echo run >> "+counter+"\n"), 0644)
+	runs := func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+
+	first, cached := RunCached(tmpDir, 0)
+	if first.Result != Passed || cached {
+		t.Fatalf("RunCached() = %v, cached %v, want a fresh pass", first.Result, cached)
+	}
+
+	second, cached := RunCached(tmpDir, 0)
+	if second.Result != Passed || !cached || runs() != 1 {
+		t.Errorf("RunCached() on unchanged tree = %v, cached %v, runs %d, want the cached pass", second.Result, cached, runs())
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte("test:\n\t@echo run >> "+counter+"\n\t@exit 1\n"), 0644)
+	third, cached := RunCached(tmpDir, 0)
+	if third.Result != Failed || cached || runs() != 2 {
+		t.Errorf("RunCached() after change = %v, cached %v, runs %d, want a fresh failure", third.Result, cached, runs())
+	}
+	if _, cached := RunCached(tmpDir, 0); !cached {
+		t.Error("RunCached() after a failure was not cached")
+	}
+}
@@ -0,0 +1,102 @@
+package testrunner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryFileName is the record of how long test suite runs took.
+const HistoryFileName = "fic-test-durations.json"
+
+// MaxHistory is the number of runs remembered per test command
+const MaxHistory = 10
+
+// MaxTimeoutFactor caps how far history may raise a timeout, as a multiple
+// of the timeout asked for
+const MaxTimeoutFactor = 4
+
+// suiteRun is one run of a test command.
+type suiteRun struct {
+	Command string  `json:"command"`
+	Seconds float64 `json:"seconds"`
+	// TimedOut runs were still writing output when they were killed, so
+	// the suite takes at least Seconds
+	TimedOut bool      `json:"timed_out,omitempty"`
+	RanAt    time.Time `json:"ran_at"`
+}
+
+// GetHistoryPath returns the path to the test duration history.
+func GetHistoryPath(workDir string) string {
+	return filepath.Join(workDir, ".claude", HistoryFileName)
+}
+
+func loadHistory(workDir string) []suiteRun {
+	var runs []suiteRun
+	if data, err := os.ReadFile(GetHistoryPath(workDir)); err == nil {
+		json.Unmarshal(data, &runs)
+	}
+	return runs
+}
+
+// EffectiveTimeout returns the timeout to run command with: timeout, or
+// half as long again as the slowest of its recent runs if that is longer,
+// up to MaxTimeoutFactor times timeout. A suite that legitimately takes
+// longer than its timeout thus gets the time it needs, while one that hung
+// with no output is not counted and keeps the timeout asked for.
+func EffectiveTimeout(workDir string, command []string, timeout time.Duration) time.Duration {
+	key := strings.Join(command, " ")
+	var slowest time.Duration
+	for _, run := range loadHistory(workDir) {
+		if d := time.Duration(run.Seconds * float64(time.Second)); run.Command == key && d > slowest {
+			slowest = d
+		}
+	}
+
+	want := (slowest * 3 / 2).Round(time.Second)
+	if want <= timeout {
+		return timeout
+	}
+	if limit := timeout * MaxTimeoutFactor; want > limit {
+		return limit
+	}
+	return want
+}
+
+// recordRun adds a run of command to the history. Runs that did not
+// complete are recorded only if they timed out while still making
+// progress.
+func recordRun(workDir string, command []string, summary *Summary) {
+	switch {
+	case summary.Result == Passed || summary.Result == Failed:
+	case summary.TimedOut && !summary.Stalled:
+	default:
+		return
+	}
+
+	key := strings.Join(command, " ")
+	var kept []suiteRun
+	count := 0
+	runs := loadHistory(workDir)
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].Command == key {
+			if count++; count >= MaxHistory {
+				continue
+			}
+		}
+		kept = append([]suiteRun{runs[i]}, kept...)
+	}
+	kept = append(kept, suiteRun{
+		Command:  key,
+		Seconds:  summary.Duration.Round(time.Millisecond).Seconds(),
+		TimedOut: summary.TimedOut,
+		RanAt:    time.Now(),
+	})
+
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err == nil && os.MkdirAll(filepath.Dir(GetHistoryPath(workDir)), 0700) == nil {
+		os.WriteFile(GetHistoryPath(workDir), append(data, '\n'), 0600)
+	}
+}
@@ -0,0 +1,42 @@
+package testrunner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffectiveTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	command := []string{"go", "test", "./..."}
+	if got := EffectiveTimeout(tmpDir, command, time.Minute); got != time.Minute {
+		t.Errorf("EffectiveTimeout() without history = %v, want 1m0s", got)
+	}
+
+	recordRun(tmpDir, command, &Summary{Result: Passed, Duration: 50 * time.Second})
+	recordRun(tmpDir, command, &Summary{Result: Error, TimedOut: true, Stalled: true, Duration: 10 * time.Minute})
+	recordRun(tmpDir, []string{"npm", "test"}, &Summary{Result: Passed, Duration: time.Hour})
+	if got := EffectiveTimeout(tmpDir, command, time.Minute); got != 75*time.Second {
+		t.Errorf("EffectiveTimeout() = %v, want 1m15s from the 50s run, ignoring the stalled one", got)
+	}
+
+	recordRun(tmpDir, command, &Summary{Result: Error, TimedOut: true, Duration: 75 * time.Second})
+	if got := EffectiveTimeout(tmpDir, command, time.Minute); got != 113*time.Second {
+		t.Errorf("EffectiveTimeout() after a progressing timeout = %v, want 1m53s", got)
+	}
+	if got := EffectiveTimeout(tmpDir, []string{"npm", "test"}, time.Minute); got != 4*time.Minute {
+		t.Errorf("EffectiveTimeout() = %v, want the 4m0s cap", got)
+	}
+	if got := EffectiveTimeout(tmpDir, command, 5*time.Minute); got != 5*time.Minute {
+		t.Errorf("EffectiveTimeout() = %v, never below the timeout asked for", got)
+	}
+
+	for i := 0; i < MaxHistory; i++ {
+		recordRun(tmpDir, command, &Summary{Result: Passed, Duration: 10 * time.Second})
+	}
+	if got := EffectiveTimeout(tmpDir, command, time.Minute); got != time.Minute {
+		t.Errorf("EffectiveTimeout() after %d fast runs = %v, want the old slow runs forgotten", MaxHistory, got)
+	}
+	if runs := loadHistory(tmpDir); len(runs) != MaxHistory+1 {
+		t.Errorf("history has %d runs, want %d for go test and 1 for npm test", len(runs), MaxHistory+1)
+	}
+}
@@ -0,0 +1,110 @@
+package testrunner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PackageResult is the outcome of one Go package's tests.
+type PackageResult struct {
+	// Dir is the package directory relative to the project, e.g.
+	// "./internal/git"
+	Dir      string
+	Result   Result
+	Output   string
+	Duration time.Duration
+}
+
+// RunGoPackages runs `go test` in each package directory, at most workers
+// at a time, each with its own timeout, so one slow or hanging package
+// does not hold up or fail the rest. Packages are reported in Packages,
+// sorted by directory; Passed and Failed count packages, and a package
+// that timed out counts as failed. RawOutput reports each package in go
+// test's "ok"/"FAIL" format, followed by the output of those that failed.
+func RunGoPackages(workDir string, dirs []string, workers int, timeout time.Duration) *Summary {
+	summary := &Summary{Result: NotRun}
+	if len(dirs) == 0 {
+		return summary
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]PackageResult, len(dirs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < workers && w < len(dirs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run := runCommand(filepath.Join(workDir, dirs[i]), []string{"go", "test", "."}, timeout)
+				results[i] = PackageResult{Dir: dirs[i], Result: run.Result, Output: run.RawOutput, Duration: run.Duration}
+			}
+		}()
+	}
+	for i := range dirs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	summary.Duration = time.Since(start)
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Dir < results[j].Dir })
+	summary.Packages = results
+	summary.Result = Passed
+	for _, r := range results {
+		switch r.Result {
+		case Passed:
+			summary.Passed++
+		case Failed:
+			summary.Failed++
+			summary.Result = Failed
+		default:
+			summary.Failed++
+			if summary.Result == Passed {
+				summary.Result = Error
+			}
+		}
+	}
+	summary.Total = summary.Passed + summary.Failed
+	summary.RawOutput = FormatPackages(results)
+	return summary
+}
+
+// FormatPackages reports package results one per line, like go test:
+// "ok  \t./internal/git\t1.2s" or "FAIL\t./internal/cli\t0.4s", then the
+// output of each failed package, and of each timed out one that wrote
+// some.
+func FormatPackages(results []PackageResult) string {
+	var b strings.Builder
+	var failed []PackageResult
+	for _, r := range results {
+		switch r.Result {
+		case Passed:
+			fmt.Fprintf(&b, "ok  \t%s\t%s\n", r.Dir, r.Duration.Round(10*time.Millisecond))
+		case Failed:
+			fmt.Fprintf(&b, "FAIL\t%s\t%s\n", r.Dir, r.Duration.Round(10*time.Millisecond))
+			failed = append(failed, r)
+		default:
+			// The error, then any partial output of a timed out package
+			reason, _, partial := strings.Cut(strings.TrimSpace(r.Output), "\n")
+			fmt.Fprintf(&b, "FAIL\t%s\t(%s)\n", r.Dir, reason)
+			if partial {
+				failed = append(failed, r)
+			}
+		}
+	}
+	for _, r := range failed {
+		fmt.Fprintf(&b, "\n=== %s\n%s\n", r.Dir, strings.TrimRight(r.Output, "\n"))
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package testrunner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ultraharness/internal/text"
+)
+
+// Heartbeats, when set, receives a progress line every HeartbeatInterval
+// while a test command runs, so a long suite is visibly alive. Commands
+// run from a terminal set it to os.Stderr; hooks leave it nil, since their
+// output is the hook response.
+var Heartbeats io.Writer
+
+// HeartbeatInterval is how often a running test command reports progress.
+// A command that writes no output for this long is considered stalled.
+var HeartbeatInterval = 30 * time.Second
+
+// MaxPartialOutput limits the output kept from a run that timed out; the
+// end of the output, nearest the test that hung, is kept.
+const MaxPartialOutput = 4000
+
+// stream collects a command's output as it is written, so the output is
+// still there if the command is killed at its timeout.
+type stream struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	lastAt time.Time
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastAt = time.Now()
+	return s.buf.Write(p)
+}
+
+// snapshot returns the output so far and when it was last written to.
+func (s *stream) snapshot() (string, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String(), s.lastAt
+}
+
+// heartbeat reports on a running command every HeartbeatInterval until
+// done is closed.
+func heartbeat(out *stream, command string, start time.Time, done <-chan struct{}) {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			output, lastAt := out.snapshot()
+			line := fmt.Sprintf("[tests] %s still running after %s", command, now.Sub(start).Round(time.Second))
+			if test := RunningTest(output); test != "" {
+				line += ", running " + test
+			}
+			if now.Sub(lastAt) >= HeartbeatInterval {
+				line += fmt.Sprintf(" (no output for %s)", now.Sub(lastAt).Round(time.Second))
+			}
+			fmt.Fprintln(Heartbeats, line)
+		}
+	}
+}
+
+var (
+	// go test's own timeout panic lists the tests still running
+	goPanicRunningPattern = regexp.MustCompile(`(?m)^running tests:\n\s+(\S+)`)
+	goRunPattern          = regexp.MustCompile(`^=== (?:RUN|CONT)\s+(\S+)`)
+	goDonePattern         = regexp.MustCompile(`^\s*--- (?:PASS|FAIL|SKIP): (\S+)`)
+	// pytest -v writes the test id before its outcome
+	pytestStartedPattern = regexp.MustCompile(`^(\S+::\S+)\s*$`)
+	// jest in a terminal, and cargo's slow test warning
+	runsPattern      = regexp.MustCompile(`^\s*RUNS\s+(\S+)`)
+	cargoSlowPattern = regexp.MustCompile(`^test (\S+) has been running for over`)
+)
+
+// RunningTest returns the test that was running at the end of output, for
+// a run cut short by a timeout, or "" if the output does not tell. It
+// understands go test -v and its timeout panic, pytest -v, jest, and
+// cargo test.
+func RunningTest(output string) string {
+	if m := goPanicRunningPattern.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	var started []string
+	running := ""
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if m := goRunPattern.FindStringSubmatch(line); m != nil {
+			started = append(started, m[1])
+			continue
+		}
+		if m := goDonePattern.FindStringSubmatch(line); m != nil {
+			for i := len(started) - 1; i >= 0; i-- {
+				if started[i] == m[1] {
+					started = append(started[:i], started[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+		if m := runsPattern.FindStringSubmatch(line); m != nil {
+			running = m[1]
+		} else if m := cargoSlowPattern.FindStringSubmatch(line); m != nil {
+			running = m[1]
+		}
+	}
+	if len(started) > 0 {
+		return started[len(started)-1]
+	}
+	if m := pytestStartedPattern.FindStringSubmatch(strings.TrimRight(lines[len(lines)-1], "\r")); m != nil {
+		return m[1]
+	}
+	return running
+}
+
+// timeoutReport describes a run killed at its timeout, followed by the
+// end of its output.
+func timeoutReport(timeout time.Duration, summary *Summary, output string) string {
+	var b strings.Builder
+	b.WriteString("Test execution timed out after " + timeout.String())
+	if summary.RunningTest != "" {
+		b.WriteString(" while running " + summary.RunningTest)
+	}
+	if summary.Stalled {
+		b.WriteString(", with no output for the last " + HeartbeatInterval.String())
+	}
+	if summary.Passed+summary.Failed > 0 {
+		fmt.Fprintf(&b, " (%d passed, %d failed before the timeout)", summary.Passed, summary.Failed)
+	}
+
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return b.String()
+	}
+	if len(output) > MaxPartialOutput {
+		output = "...[truncated]" + text.Suffix(output, MaxPartialOutput)
+	}
+	b.WriteString("\n\nPartial output:\n" + output)
+	return b.String()
+}
@@ -3,12 +3,14 @@ package testrunner
 
 import (
 	"context"
-	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"ultraharness/internal/project"
 )
 
 // Result represents the outcome of running tests.
@@ -34,21 +36,65 @@ type Summary struct {
 	Skipped   int
 	Total     int
 	Duration  time.Duration
+	// Packages are the per-package results of RunGoPackages
+	Packages []PackageResult
+	// TimedOut reports a run killed at its timeout. Counts are then those
+	// reported before the timeout, and RawOutput describes the timeout
+	// followed by the end of the output up to it.
+	TimedOut bool
+	// RunningTest is the test that was running when the run timed out, if
+	// the output tells
+	RunningTest string
+	// Stalled reports that a run that timed out had written no output for
+	// a HeartbeatInterval, so it was likely hung rather than slow
+	Stalled bool
 }
 
 // DefaultTimeout is the default test timeout.
 const DefaultTimeout = 120 * time.Second
 
-// Run executes tests in the given directory.
+// Run executes tests in the given directory. The timeout is raised when
+// past runs show the suite takes longer, see EffectiveTimeout, and each
+// run is added to that history.
 func Run(workDir string, timeout time.Duration) *Summary {
 	if timeout == 0 {
 		timeout = DefaultTimeout
 	}
 
-	summary := &Summary{Result: NotRun}
-
-	// Detect test command based on project type
 	testCmd := detectTestCommand(workDir)
+	if testCmd == nil {
+		return &Summary{Result: NotRun}
+	}
+	summary := runCommand(workDir, testCmd, EffectiveTimeout(workDir, testCmd, timeout))
+	recordRun(workDir, testCmd, summary)
+	return summary
+}
+
+// RunFiltered executes only the tests matching filter, using the
+// project's native filter flag. Returns NotRun if the project's test
+// command does not support filtering.
+func RunFiltered(workDir, filter string, timeout time.Duration) *Summary {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return runCommand(workDir, filterCommand(detectTestCommand(workDir), filter), timeout)
+}
+
+// RunFiles executes only the given test files, for runners that select
+// tests by path (npm, yarn, and pnpm scripts, npx jest or vitest, and
+// pytest). Returns NotRun for other test commands.
+func RunFiles(workDir string, files []string, timeout time.Duration) *Summary {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return runCommand(workDir, filesCommand(detectTestCommand(workDir), files), timeout)
+}
+
+// runCommand runs a test command and summarizes its result.
+func runCommand(workDir string, testCmd []string, timeout time.Duration) *Summary {
+	summary := &Summary{Result: NotRun}
 	if testCmd == nil {
 		return summary
 	}
@@ -56,17 +102,34 @@ func Run(workDir string, timeout time.Duration) *Summary {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	// Output is streamed rather than collected at exit, so a run killed at
+	// its timeout still reports how far it got
+	out := &stream{}
 	cmd := exec.CommandContext(ctx, testCmd[0], testCmd[1:]...)
 	cmd.Dir = workDir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = time.Second
 
 	start := time.Now()
-	output, err := cmd.CombinedOutput()
+	out.lastAt = start
+	done := make(chan struct{})
+	if Heartbeats != nil {
+		go heartbeat(out, strings.Join(testCmd, " "), start, done)
+	}
+	err := cmd.Run()
+	close(done)
 	summary.Duration = time.Since(start)
-	summary.RawOutput = string(output)
+	output, lastAt := out.snapshot()
+	summary.RawOutput = output
 
 	if ctx.Err() == context.DeadlineExceeded {
 		summary.Result = Error
-		summary.RawOutput = "Test execution timed out after " + timeout.String()
+		summary.TimedOut = true
+		summary.Stalled = time.Since(lastAt) >= HeartbeatInterval
+		summary.RunningTest = RunningTest(output)
+		parseTestCounts(summary)
+		summary.RawOutput = timeoutReport(timeout, summary, output)
 		return summary
 	}
 
@@ -85,53 +148,95 @@ func Run(workDir string, timeout time.Duration) *Summary {
 
 // detectTestCommand determines the appropriate test command.
 func detectTestCommand(workDir string) []string {
-	// Check for various project types
-	checks := []struct {
-		file string
-		cmd  []string
-	}{
-		{"package.json", []string{"npm", "test", "--", "--passWithNoTests"}},
-		{"Cargo.toml", []string{"cargo", "test"}},
-		{"go.mod", []string{"go", "test", "./..."}},
-		{"pyproject.toml", []string{"pytest", "-q"}},
-		{"setup.py", []string{"pytest", "-q"}},
-		{"Makefile", nil}, // Check for test target
-		{"pom.xml", []string{"mvn", "test", "-q"}},
-		{"build.gradle", []string{"./gradlew", "test"}},
-	}
-
-	for _, check := range checks {
-		if _, err := os.Stat(filepath.Join(workDir, check.file)); err == nil {
-			if check.file == "Makefile" {
-				// Check if Makefile has a test target
-				if hasTarget, _ := makefileHasTarget(workDir, "test"); hasTarget {
-					return []string{"make", "test"}
-				}
-				continue
-			}
-			return check.cmd
-		}
+	return project.Detect(workDir).TestCommand
+}
+
+// filterCommand adds a test name filter to a detected test command.
+func filterCommand(testCmd []string, filter string) []string {
+	if testCmd == nil {
+		return nil
+	}
+
+	args := append([]string{}, testCmd...)
+	switch args[0] {
+	case "go":
+		return append(args, "-run", filter)
+	case "pytest":
+		return append(args, "-k", filter)
+	case "npm":
+		return append(args, "-t", filter)
+	case "cargo":
+		return append(args, filter)
+	case "mvn":
+		return append(args, "-Dtest="+filter)
+	case "./gradlew":
+		return append(args, "--tests", filter)
 	}
 
+	// make and other runners have no portable filter flag
 	return nil
 }
 
-// makefileHasTarget checks if Makefile has a specific target.
-func makefileHasTarget(workDir, target string) (bool, error) {
-	makefilePath := filepath.Join(workDir, "Makefile")
-	content, err := os.ReadFile(makefilePath)
-	if err != nil {
-		return false, err
+// filesCommand adds test file paths to a detected test command.
+func filesCommand(testCmd []string, files []string) []string {
+	if testCmd == nil || len(files) == 0 {
+		return nil
 	}
 
-	// Simple check for target definition
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, target+":") {
-			return true, nil
+	args := append([]string{}, testCmd...)
+	switch args[0] {
+	case "npm":
+		// Arguments after -- reach the test script
+		found := false
+		for _, a := range args {
+			found = found || a == "--"
 		}
+		if !found {
+			args = append(args, "--")
+		}
+		return append(args, files...)
+	case "yarn", "pnpm", "npx", "pytest":
+		return append(args, files...)
 	}
-	return false, nil
+	return nil
+}
+
+// failurePatterns capture a failing test's name from runner output
+var failurePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\s*--- FAIL: (\S+)`),               // go test
+	regexp.MustCompile(`^FAILED (\S+?)(?: - .*)?$`),         // pytest summary
+	regexp.MustCompile(`^(?:FAIL|ERROR): (\S+ \([\w.]+\))`), // unittest
+	regexp.MustCompile(`^test (\S+) \.\.\. FAILED$`),        // cargo test
+	regexp.MustCompile(`^\s*[✕×] (.+?)(?: \(\d+ ?m?s\))?$`), // jest, vitest
+	regexp.MustCompile(`^rspec (\./\S+)`),                   // rspec
+}
+
+// ParseOutput summarizes test runner output, counting passed and failed
+// tests where the runner reports them.
+func ParseOutput(output string) *Summary {
+	summary := &Summary{RawOutput: output}
+	parseTestCounts(summary)
+	return summary
+}
+
+// FailingTests returns the names of failing tests found in runner output,
+// in order and without duplicates.
+func FailingTests(output string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		for _, pattern := range failurePatterns {
+			m := pattern.FindStringSubmatch(line)
+			if m == nil || seen[m[1]] {
+				continue
+			}
+			seen[m[1]] = true
+			names = append(names, m[1])
+			break
+		}
+	}
+	return names
 }
 
 // parseTestCounts extracts test counts from output (basic parsing).
@@ -151,14 +256,14 @@ func parseTestCounts(summary *Summary) {
 			summary.Skipped = countInLine(line, "skipped")
 		}
 
-		// pytest style: "X passed, Y failed"
-		if strings.HasSuffix(line, "passed") || strings.Contains(line, "passed,") {
+		// pytest style: "X passed, Y failed" or "X passed in 0.12s"
+		if strings.HasSuffix(line, "passed") || strings.Contains(line, "passed,") || strings.Contains(line, "passed in ") {
 			summary.Passed = countInLine(line, "passed")
 			summary.Failed = countInLine(line, "failed")
 		}
 
 		// Go style: "ok" or "FAIL"
-		if strings.HasPrefix(line, "ok ") || strings.HasPrefix(line, "FAIL ") {
+		if strings.HasPrefix(line, "ok ") || strings.HasPrefix(line, "FAIL ") || strings.HasPrefix(line, "FAIL\t") {
 			if strings.HasPrefix(line, "ok ") {
 				summary.Passed++
 			} else {
@@ -180,19 +285,27 @@ func countInLine(line, keyword string) int {
 	}
 
 	// Walk backwards to find the number
-	numStr := ""
+	end := -1
+	start := 0
 	for i := idx - 1; i >= 0; i-- {
 		c := line[i]
 		if c >= '0' && c <= '9' {
-			numStr = string(c) + numStr
-		} else if numStr != "" {
+			if end < 0 {
+				end = i + 1
+			}
+		} else if end >= 0 {
+			start = i + 1
 			break
 		}
 	}
+	if end < 0 {
+		return 0
+	}
 
-	var count int
-	for _, c := range numStr {
-		count = count*10 + int(c-'0')
+	// A count too large for an int is not a real count
+	count, err := strconv.Atoi(line[start:end])
+	if err != nil {
+		return 0
 	}
 	return count
 }
@@ -224,6 +337,47 @@ func GetSummaryString(summary *Summary) string {
 	return strings.Join(parts, ", ")
 }
 
+// testCommands are shell commands that run a test suite
+var testCommands = []string{
+	"go test", "npm test", "npm run test", "yarn test", "pnpm test", "npx jest", "npx vitest",
+	"pytest", "python -m pytest", "python3 -m pytest", "python -m unittest", "tox",
+	"cargo test", "cargo nextest", "make test", "make check", "mvn test", "./gradlew test",
+	"gradle test", "bundle exec rspec", "rspec", "phpunit", "dotnet test", "mix test",
+	"test_affected",
+}
+
+// IsTestCommand reports whether a shell command runs a test suite.
+func IsTestCommand(command string) bool {
+	command = " " + strings.Join(strings.Fields(command), " ") + " "
+	for _, c := range testCommands {
+		// Match whole words, anywhere in a compound command
+		for _, sep := range []string{" ", ";", "&", "|", "("} {
+			if strings.Contains(command, sep+c+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsTestFile reports whether path is itself a test file.
+func IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	switch {
+	case strings.HasSuffix(name, "_test"), strings.HasSuffix(name, "_spec"),
+		strings.HasSuffix(name, ".test"), strings.HasSuffix(name, ".spec"),
+		strings.HasPrefix(name, "test_"), strings.HasSuffix(name, "Test"):
+		return true
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if dir == "__tests__" {
+			return true
+		}
+	}
+	return false
+}
+
 // DidTestsRun checks if tests were run in the current session.
 // This is a simplified check - looks for test-related output in a transcript.
 func DidTestsRun(transcript string) bool {
@@ -0,0 +1,280 @@
+package testrunner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsTestFile(t *testing.T) {
+	tests := map[string]bool{
+		"pkg/foo_test.go":        true,
+		"src/app.test.ts":        true,
+		"src/app.spec.js":        true,
+		"tests/test_app.py":      true,
+		"spec/user_spec.rb":      true,
+		"src/UserTest.java":      true,
+		"src/__tests__/app.js":   true,
+		"pkg/foo.go":             false,
+		"src/contest.py":         false,
+		"src/latest.java":        false,
+		"src/testing/helpers.go": false,
+	}
+	for path, want := range tests {
+		if got := IsTestFile(path); got != want {
+			t.Errorf("IsTestFile(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIsTestCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"go test ./...", true},
+		{"cd api && go test -run TestX ./...", true},
+		{"npm test", true},
+		{"python -m pytest -x tests/", true},
+		{"cargo test --all", true},
+		{`"${CLAUDE_PLUGIN_ROOT}/bin/run-hook" test_affected -workers 4`, true},
+		{"go build ./...", false},
+		{"cat pytest.ini", false},
+		{"git commit -m 'go test fixes'", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTestCommand(tt.command); got != tt.want {
+			t.Errorf("IsTestCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestFilesCommand(t *testing.T) {
+	files := []string{"src/a.test.ts", "src/b.test.ts"}
+	tests := []struct {
+		testCmd []string
+		want    []string
+	}{
+		{[]string{"npm", "test", "--", "--passWithNoTests"}, []string{"npm", "test", "--", "--passWithNoTests", "src/a.test.ts", "src/b.test.ts"}},
+		{[]string{"npm", "test"}, []string{"npm", "test", "--", "src/a.test.ts", "src/b.test.ts"}},
+		{[]string{"npx", "vitest", "run"}, []string{"npx", "vitest", "run", "src/a.test.ts", "src/b.test.ts"}},
+		{[]string{"pytest", "-q"}, []string{"pytest", "-q", "src/a.test.ts", "src/b.test.ts"}},
+		{[]string{"go", "test", "./..."}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := filesCommand(tt.testCmd, files); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filesCommand(%q) = %q, want %q", tt.testCmd, got, tt.want)
+		}
+	}
+}
+
+func TestFailingTests(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "go",
+			output: "=== RUN   TestA\n--- FAIL: TestA (0.00s)\n    --- FAIL: TestA/sub (0.00s)\nFAIL\nFAIL\tpkg\t0.01s\n",
+			want:   []string{"TestA", "TestA/sub"},
+		},
+		{
+			name:   "pytest",
+			output: "=== short test summary info ===\nFAILED tests/test_a.py::test_one - assert 1 == 2\nFAILED tests/test_a.py::test_two\n",
+			want:   []string{"tests/test_a.py::test_one", "tests/test_a.py::test_two"},
+		},
+		{
+			name:   "cargo",
+			output: "test parser::tests::empty ... ok\ntest parser::tests::nested ... FAILED\n",
+			want:   []string{"parser::tests::nested"},
+		},
+		{
+			name:   "jest",
+			output: "  ✓ adds (2 ms)\n  ✕ subtracts (3 ms)\n",
+			want:   []string{"subtracts"},
+		},
+		{
+			name:   "unittest",
+			output: "FAIL: test_sum (test_math.TestMath)\n",
+			want:   []string{"test_sum (test_math.TestMath)"},
+		},
+		{
+			name:   "passing",
+			output: "ok  \tpkg\t0.01s\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := FailingTests(tt.output); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FailingTests(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantPassed int
+		wantFailed int
+	}{
+		{"pytest", "===== 3 passed in 0.12s =====", 3, 0},
+		{"pytest with failures", "===== 1 failed, 2 passed in 0.40s =====", 2, 1},
+		{"no tests", "===== 0 passed in 0.01s =====", 0, 0},
+		{"jest", "Tests:       1 failed, 4 passed, 5 total", 4, 1},
+		{"go", "ok  \ta\t0.1s\nFAIL\tb\t0.2s", 1, 1},
+	}
+
+	for _, tt := range tests {
+		summary := ParseOutput(tt.output)
+		if summary.Passed != tt.wantPassed || summary.Failed != tt.wantFailed {
+			t.Errorf("ParseOutput(%s) = %d passed, %d failed, want %d, %d",
+				tt.name, summary.Passed, summary.Failed, tt.wantPassed, tt.wantFailed)
+		}
+	}
+}
+
+func TestRunGoPackages(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	dir, err := os.MkdirTemp("", "testrunner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":            "module example.com/app\n\ngo 1.21\n",
+		"good/good_test.go": "package good\n\nimport \"testing\"\n\nfunc TestGood(t *testing.T) {}\n",
+		"bad/bad_test.go":   "package bad\n\nimport \"testing\"\n\nfunc TestBad(t *testing.T) { t.Fatal(\"boom\") }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary := RunGoPackages(dir, []string{"./good", "./bad"}, 2, time.Minute)
+	if summary.Result != Failed || summary.Passed != 1 || summary.Failed != 1 || summary.Total != 2 {
+		t.Errorf("RunGoPackages() = %v, %d passed, %d failed, %d total", summary.Result, summary.Passed, summary.Failed, summary.Total)
+	}
+	if len(summary.Packages) != 2 || summary.Packages[0].Dir != "./bad" || summary.Packages[1].Result != Passed {
+		t.Errorf("Packages = %+v, want ./bad failed and ./good passed", summary.Packages)
+	}
+	if got := FailingTests(summary.RawOutput); !reflect.DeepEqual(got, []string{"TestBad"}) {
+		t.Errorf("FailingTests(RawOutput) = %v, want [TestBad]\n%s", got, summary.RawOutput)
+	}
+
+	if summary := RunGoPackages(dir, []string{"./good"}, 4, time.Minute); summary.Result != Passed {
+		t.Errorf("RunGoPackages(./good) = %v\n%s", summary.Result, summary.RawOutput)
+	}
+	if summary := RunGoPackages(dir, nil, 4, time.Minute); summary.Result != NotRun {
+		t.Errorf("RunGoPackages() without packages = %v, want NotRun", summary.Result)
+	}
+}
+
+func TestFormatPackages(t *testing.T) {
+	got := FormatPackages([]PackageResult{
+		{Dir: "./api", Result: Passed, Duration: 1234 * time.Millisecond},
+		{Dir: "./db", Result: Failed, Output: "--- FAIL: TestQuery (0.00s)\nFAIL\n", Duration: 400 * time.Millisecond},
+		{Dir: "./slow", Result: Error, Output: "Test execution timed out after 1m0s"},
+	})
+	want := "ok  \t./api\t1.23s\n" +
+		"FAIL\t./db\t400ms\n" +
+		"FAIL\t./slow\t(Test execution timed out after 1m0s)\n" +
+		"\n=== ./db\n--- FAIL: TestQuery (0.00s)\nFAIL\n"
+	if got != want {
+		t.Errorf("FormatPackages() =\n%q\nwant\n%q", got, want)
+	}
+	if s := ParseOutput(got); s.Passed != 1 || s.Failed != 2 {
+		t.Errorf("ParseOutput(FormatPackages()) counted %d passed, %d failed, want 1 and 2", s.Passed, s.Failed)
+	}
+}
+
+func TestRunningTest(t *testing.T) {
+	tests := []struct {
+		name, output, want string
+	}{
+		{"go -v", "=== RUN   TestA\n--- PASS: TestA (0.00s)\n=== RUN   TestB\n=== RUN   TestB/slow\n", "TestB/slow"},
+		{"go -v subtest done", "=== RUN   TestB\n=== RUN   TestB/fast\n    --- PASS: TestB/fast (0.00s)\n", "TestB"},
+		{"go timeout panic", "panic: test timed out after 10m0s\nrunning tests:\n\tTestSlow (10m0s)\n", "TestSlow"},
+		{"pytest -v", "tests/test_a.py::test_ok PASSED  [ 50%]\ntests/test_a.py::test_slow ", "tests/test_a.py::test_slow"},
+		{"cargo", "test parse::ok ... ok\ntest net::fetch has been running for over 60 seconds\n", "net::fetch"},
+		{"finished", "=== RUN   TestA\n--- PASS: TestA (0.00s)\nPASS\n", ""},
+		{"quiet", "....\n", ""},
+	}
+	for _, tt := range tests {
+		if got := RunningTest(tt.output); got != tt.want {
+			t.Errorf("RunningTest(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	script := "echo 'ok  \ta\t0.1s'; echo '=== RUN   TestSlow'; sleep 5"
+	summary := runCommand(t.TempDir(), []string{"sh", "-c", script}, 300*time.Millisecond)
+	if summary.Result != Error || !summary.TimedOut || summary.Stalled {
+		t.Fatalf("runCommand() = %+v, want a timeout that was not stalled", summary)
+	}
+	if summary.RunningTest != "TestSlow" || summary.Passed != 1 {
+		t.Errorf("RunningTest = %q, Passed = %d, want TestSlow and 1", summary.RunningTest, summary.Passed)
+	}
+	want := "Test execution timed out after 300ms while running TestSlow (1 passed, 0 failed before the timeout)\n\nPartial output:\nok  \ta\t0.1s\n=== RUN   TestSlow"
+	if summary.RawOutput != want {
+		t.Errorf("RawOutput =\n%q\nwant\n%q", summary.RawOutput, want)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	interval := HeartbeatInterval
+	HeartbeatInterval = 50 * time.Millisecond
+	defer func() { HeartbeatInterval = interval }()
+
+	out := &stream{lastAt: time.Now()}
+	out.Write([]byte("=== RUN   TestSlow\n"))
+	var beats strings.Builder
+	Heartbeats = &beats
+	defer func() { Heartbeats = nil }()
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		heartbeat(out, "go test ./...", time.Now(), done)
+		close(stopped)
+	}()
+	time.Sleep(130 * time.Millisecond)
+	close(done)
+	<-stopped
+
+	if !strings.Contains(beats.String(), "[tests] go test ./... still running after ") || !strings.Contains(beats.String(), ", running TestSlow") {
+		t.Errorf("heartbeats = %q", beats.String())
+	}
+}
+
+func FuzzParseOutput(f *testing.F) {
+	f.Add("===== 1 failed, 2 passed in 0.40s =====")
+	f.Add("Tests:       1 failed, 4 passed, 2 skipped, 7 total")
+	f.Add("ok  \ta\t0.1s\nFAIL\tb\t0.2s")
+	f.Add("--- FAIL: TestRetry (0.00s)\nFAIL\n")
+	f.Add("99999999999999999999 passed")
+	f.Add("✕ renders the 日本 header (12 ms)\n× fails")
+	f.Fuzz(func(t *testing.T, output string) {
+		s := ParseOutput(output)
+		if s.Total < 0 || s.Passed < 0 || s.Failed < 0 || s.Skipped < 0 {
+			t.Errorf("ParseOutput(%q) = %d total, %d passed, %d failed, %d skipped, want no negative counts",
+				output, s.Total, s.Passed, s.Failed, s.Skipped)
+		}
+		FailingTests(output)
+	})
+}
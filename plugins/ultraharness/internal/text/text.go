@@ -0,0 +1,62 @@
+// Package text shortens strings for display and storage without cutting a
+// multi-byte character in two. Much of what the hooks quote (prompts,
+// commands, subagent output) is model-generated and may hold emoji or CJK
+// text anywhere, so a plain s[:n] can leave invalid UTF-8 behind.
+package text
+
+import "unicode/utf8"
+
+// Ellipsis marks a string shortened by Truncate.
+const Ellipsis = "..."
+
+// Truncate returns s if it has at most n characters, and otherwise its
+// first n characters followed by Ellipsis.
+func Truncate(s string, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	if len(s) <= n {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i] + Ellipsis
+		}
+		count++
+	}
+	return s
+}
+
+// Prefix returns the longest prefix of s that is at most n bytes and does
+// not end inside a character, for byte limits on stored or parsed text.
+func Prefix(s string, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	if len(s) <= n {
+		return s
+	}
+	// A character is at most utf8.UTFMax bytes; any further back is not
+	// valid UTF-8 to begin with
+	for back := 0; back < utf8.UTFMax-1 && n > 0 && !utf8.RuneStart(s[n]); back++ {
+		n--
+	}
+	return s[:n]
+}
+
+// Suffix returns the longest suffix of s that is at most n bytes and does
+// not start inside a character, for keeping the tail of long output.
+func Suffix(s string, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	if len(s) <= n {
+		return s
+	}
+	i := len(s) - n
+	for ahead := 0; ahead < utf8.UTFMax-1 && i < len(s) && !utf8.RuneStart(s[i]); ahead++ {
+		i++
+	}
+	return s[i:]
+}
@@ -0,0 +1,111 @@
+package text
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"hello world", 5, "hello..."},
+		{"", 3, ""},
+		{"abc", 0, "..."},
+		{"abc", -1, "..."},
+		{"日本語のテキスト", 3, "日本語..."},
+		{"日本語", 3, "日本語"},
+		{"🚀🚀🚀🚀", 2, "🚀🚀..."},
+		{"fix 🐛 now", 5, "fix 🐛..."},
+		// Combining marks count as characters of their own
+		{"cafe\u0301s", 5, "cafe\u0301..."},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"日本語", 4, "日"},
+		{"日本語", 6, "日本"},
+		{"a🚀", 4, "a"},
+		{"a🚀", 5, "a🚀"},
+		{"日本", 0, ""},
+		{"日本", -2, ""},
+	}
+	for _, tt := range tests {
+		if got := Prefix(tt.s, tt.n); got != tt.want {
+			t.Errorf("Prefix(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSuffix(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "llo"},
+		{"日本語", 4, "語"},
+		{"日本語", 6, "本語"},
+		{"🚀a", 4, "a"},
+		{"日本", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Suffix(tt.s, tt.n); got != tt.want {
+			t.Errorf("Suffix(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	// Stray continuation bytes are cut through rather than backed out of
+	s := strings.Repeat("\x80", 20)
+	if got := Prefix(s, 10); len(got) != 7 {
+		t.Errorf("Prefix() of continuation bytes = %d bytes, want 7", len(got))
+	}
+	if got := Suffix(s, 10); len(got) != 7 {
+		t.Errorf("Suffix() of continuation bytes = %d bytes, want 7", len(got))
+	}
+}
+
+func FuzzTruncate(f *testing.F) {
+	f.Add("hello world", 5)
+	f.Add("日本語のテキスト", 4)
+	f.Add("🚀🚀🚀", 1)
+	f.Fuzz(func(t *testing.T, s string, n int) {
+		if n > 1<<16 {
+			n = 1 << 16
+		}
+		valid := utf8.ValidString(s)
+		if got := Truncate(s, n); valid && !utf8.ValidString(got) {
+			t.Errorf("Truncate(%q, %d) = %q, not valid UTF-8", s, n, got)
+		} else if n >= 0 && utf8.RuneCountInString(got) > n+len(Ellipsis) {
+			t.Errorf("Truncate(%q, %d) = %q, longer than %d characters and the ellipsis", s, n, got, n)
+		}
+		for _, got := range []string{Prefix(s, n), Suffix(s, n)} {
+			if valid && !utf8.ValidString(got) {
+				t.Errorf("cutting %q to %d bytes gave %q, not valid UTF-8", s, n, got)
+			}
+			if n >= 0 && len(got) > n {
+				t.Errorf("cutting %q to %d bytes gave %d bytes", s, n, len(got))
+			}
+		}
+	})
+}
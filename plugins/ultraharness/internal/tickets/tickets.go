@@ -0,0 +1,63 @@
+// Package tickets checks that a session's work references an issue-tracker
+// ticket. Teams that tie every change to a ticket set ticket_pattern to a
+// regular expression for their IDs, such as `PROJ-\d+`; a ticket counts as
+// referenced if it appears in a progress entry or a commit message written
+// during the session.
+package tickets
+
+import (
+	"regexp"
+	"time"
+
+	"ultraharness/internal/git"
+	"ultraharness/internal/progress"
+)
+
+// Compile compiles a ticket pattern. Reports false for an empty or invalid
+// pattern, which turns the check off.
+func Compile(pattern string) (*regexp.Regexp, bool) {
+	if pattern == "" {
+		return nil, false
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false
+	}
+	return re, true
+}
+
+// Referenced returns the first ticket ID in the progress entries and
+// commit messages written since since, or "" if there is none.
+func Referenced(workDir string, re *regexp.Regexp, since time.Time) string {
+	// Entry timestamps have whole seconds
+	start := since.Truncate(time.Second)
+	if entries, err := progress.ReadEntries(workDir); err == nil {
+		for _, e := range entries {
+			if e.Time.Before(start) {
+				continue
+			}
+			if id := re.FindString(e.Message); id != "" {
+				return id
+			}
+		}
+	}
+	for _, message := range git.MessagesSince(workDir, since) {
+		if id := re.FindString(message); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// CodeCommitted reports whether a commit made since since changed a file
+// isCode accepts.
+func CodeCommitted(workDir string, since time.Time, isCode func(string) bool) bool {
+	for _, c := range git.CommitsSince(workDir, since) {
+		for _, file := range c.Files {
+			if isCode(file) {
+				return true
+			}
+		}
+	}
+	return false
+}
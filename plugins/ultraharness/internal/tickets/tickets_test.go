@@ -0,0 +1,79 @@
+package tickets
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ultraharness/internal/git"
+	"ultraharness/internal/progress"
+)
+
+func TestCompile(t *testing.T) {
+	if _, ok := Compile(""); ok {
+		t.Error("Compile(\"\") should turn the check off")
+	}
+	if _, ok := Compile(`PROJ-(\d+`); ok {
+		t.Error("Compile() accepted an invalid pattern")
+	}
+	re, ok := Compile(`PROJ-\d+`)
+	if !ok || re.FindString("fix login (PROJ-42)") != "PROJ-42" {
+		t.Error("Compile() should match ticket IDs")
+	}
+}
+
+func TestReferenced(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(file string, message ...string) {
+		if err := os.WriteFile(filepath.Join(tmpDir, file), []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", ".")
+		args := []string{"commit", "-q"}
+		for _, m := range message {
+			args = append(args, "-m", m)
+		}
+		run(args...)
+	}
+	isCode := func(path string) bool { return git.IsCodeFile(path, nil, nil) }
+	re, _ := Compile(`PROJ-\d+`)
+
+	run("init", "-q")
+	since := time.Now().Add(-time.Minute)
+	if CodeCommitted(tmpDir, since, isCode) {
+		t.Error("CodeCommitted() without commits = true")
+	}
+
+	commit("notes.txt", "notes for PROJ-1")
+	if CodeCommitted(tmpDir, since, isCode) {
+		t.Error("CodeCommitted() with only a text file = true")
+	}
+	if got := Referenced(tmpDir, re, since); got != "PROJ-1" {
+		t.Errorf("Referenced() = %q, want the ID in the commit subject", got)
+	}
+	if got := Referenced(tmpDir, re, time.Now().Add(time.Hour)); got != "" {
+		t.Errorf("Referenced() after the commit = %q, want none", got)
+	}
+
+	commit("main.go", "fix login", "Refs PROJ-7")
+	if !CodeCommitted(tmpDir, since, isCode) {
+		t.Error("CodeCommitted() after committing code = false")
+	}
+	other, _ := Compile(`OPS-\d+`)
+	if got := Referenced(tmpDir, other, since); got != "" {
+		t.Errorf("Referenced() of another project = %q, want none", got)
+	}
+
+	progress.Append(progress.SourceAgent, "Fixed the OPS-3 outage", tmpDir)
+	if got := Referenced(tmpDir, other, since); got != "OPS-3" {
+		t.Errorf("Referenced() = %q, want the ID in the progress entry", got)
+	}
+}
@@ -0,0 +1,151 @@
+// Package todos scans tracked code for TODO/FIXME/HACK comments and turns
+// them into pending feature checklist items.
+package todos
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+	"ultraharness/internal/text"
+)
+
+// MaxTextLength limits the comment text kept per item.
+const MaxTextLength = 120
+
+// grepPattern finds candidate lines; markerPattern extracts tag and text
+// from tags that open a comment (//, #, /*, *, or --), so tags in string
+// literals and prose are not taken for code debt.
+var (
+	grepPattern   = `(TODO|FIXME|HACK)`
+	markerPattern = regexp.MustCompile(`(?://|#|/\*|\*|--)\s*\b(TODO|FIXME|HACK)\b(?:\([^)]*\))?:?\s*(.*)`)
+)
+
+// Item is a single code debt comment.
+type Item struct {
+	File string
+	Line int
+	Tag  string
+	Text string
+}
+
+// Location returns the file:line reference for the item.
+func (i Item) Location() string {
+	return fmt.Sprintf("%s:%d", i.File, i.Line)
+}
+
+// Scan returns TODO/FIXME/HACK comments in tracked code files. isCode
+// reports whether a path, relative to workDir, is a code file.
+func Scan(workDir string, isCode func(string) bool) []Item {
+	var items []Item
+	for _, match := range git.Grep(workDir, grepPattern) {
+		if item, ok := parseMatch(match, isCode); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseMatch parses a "path:line:text" grep match in a code file.
+func parseMatch(match string, isCode func(string) bool) (Item, bool) {
+	parts := strings.SplitN(match, ":", 3)
+	if len(parts) != 3 {
+		return Item{}, false
+	}
+
+	file := parts[0]
+	if !isCode(file) {
+		return Item{}, false
+	}
+
+	line, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Item{}, false
+	}
+
+	m := markerPattern.FindStringSubmatch(parts[2])
+	if m == nil {
+		return Item{}, false
+	}
+
+	comment := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(m[2]), "*/-#>"))
+	if comment == "" {
+		return Item{}, false
+	}
+
+	return Item{File: file, Line: line, Tag: m[1], Text: text.Truncate(comment, MaxTextLength)}, true
+}
+
+// Untracked returns items not already represented in the feature checklist.
+// An item is tracked if a feature's name or description contains its text.
+func Untracked(items []Item, data *features.FeaturesData) []Item {
+	if data == nil {
+		return items
+	}
+
+	var existing []string
+	for _, f := range data.Features {
+		existing = append(existing, normalize(f.Name+" "+f.Description))
+	}
+
+	var untracked []Item
+	seen := make(map[string]bool)
+	for _, item := range items {
+		text := normalize(item.Text)
+		if seen[text] {
+			continue
+		}
+		seen[text] = true
+
+		tracked := false
+		for _, e := range existing {
+			if strings.Contains(e, text) {
+				tracked = true
+				break
+			}
+		}
+		if !tracked {
+			untracked = append(untracked, item)
+		}
+	}
+	return untracked
+}
+
+// AddAsFeatures appends items to the checklist as pending features and
+// returns the features that were added.
+func AddAsFeatures(items []Item, data *features.FeaturesData) []features.Feature {
+	next := nextFeatureNumber(data)
+
+	var added []features.Feature
+	for _, item := range items {
+		f := features.Feature{
+			ID:          fmt.Sprintf("F%d", next),
+			Name:        item.Text,
+			Description: fmt.Sprintf("%s at %s: %s", item.Tag, item.Location(), item.Text),
+			Status:      features.StatusPending,
+			Category:    "code-debt",
+		}
+		data.Features = append(data.Features, f)
+		added = append(added, f)
+		next++
+	}
+	return added
+}
+
+// nextFeatureNumber returns one past the highest numeric "F<n>" feature ID.
+func nextFeatureNumber(data *features.FeaturesData) int {
+	highest := 0
+	for _, f := range data.Features {
+		if n, err := strconv.Atoi(strings.TrimPrefix(f.ID, "F")); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
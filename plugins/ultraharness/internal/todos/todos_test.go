@@ -0,0 +1,90 @@
+package todos
+
+import (
+	"testing"
+
+	"ultraharness/internal/features"
+	"ultraharness/internal/git"
+)
+
+// isCode is the built-in code file rule, with code_files adding SQL.
+func isCode(path string) bool {
+	return git.IsCodeFile(path, []string{".sql"}, nil)
+}
+
+func TestParseMatch(t *testing.T) {
+	tests := []struct {
+		match    string
+		wantOK   bool
+		wantTag  string
+		wantText string
+	}{
+		{"main.go:12:\t// TODO: handle timeouts", true, "TODO", "handle timeouts"},
+		{"app.py:3:# FIXME(bob) broken on windows", true, "FIXME", "broken on windows"},
+		{"lib.ts:40:  /* HACK: skip cache */", true, "HACK", "skip cache"},
+		{"README.md:1:TODO: write docs", false, "", ""},
+		{"main.go:5:// TODOS are tracked elsewhere", false, "", ""},
+		{"main.go:7:// TODO:", false, "", ""},
+		{"main.go:x:// TODO: bad line", false, "", ""},
+		{"schema.sql:2:-- TODO add an index", true, "TODO", "add an index"},
+		{"lib.js:9: * FIXME: flaky on CI", true, "FIXME", "flaky on CI"},
+		{`cli.go:55:	{"scan_todos", "List untracked TODO comments", ScanTodos},`, false, "", ""},
+		{`status.py:4:    icon = "[TODO]"  # shown for pending work`, false, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.match, func(t *testing.T) {
+			item, ok := parseMatch(tt.match, isCode)
+			if ok != tt.wantOK {
+				t.Fatalf("parseMatch() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if item.Tag != tt.wantTag || item.Text != tt.wantText {
+				t.Errorf("parseMatch() = %s %q, want %s %q", item.Tag, item.Text, tt.wantTag, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestUntracked(t *testing.T) {
+	data := &features.FeaturesData{
+		Features: []features.Feature{
+			{ID: "F1", Name: "Login", Description: "TODO at auth.go:3: Handle   expired tokens"},
+		},
+	}
+	items := []Item{
+		{File: "auth.go", Line: 9, Tag: "TODO", Text: "handle expired tokens"},
+		{File: "db.go", Line: 1, Tag: "FIXME", Text: "close rows"},
+		{File: "db.go", Line: 8, Tag: "FIXME", Text: "close rows"},
+	}
+
+	untracked := Untracked(items, data)
+	if len(untracked) != 1 || untracked[0].Text != "close rows" {
+		t.Errorf("Untracked() = %v, want [close rows]", untracked)
+	}
+}
+
+func TestAddAsFeatures(t *testing.T) {
+	data := &features.FeaturesData{
+		Features: []features.Feature{{ID: "F1"}, {ID: "F7"}, {ID: "setup"}},
+	}
+
+	added := AddAsFeatures([]Item{{File: "db.go", Line: 8, Tag: "FIXME", Text: "close rows"}}, data)
+	if len(added) != 1 {
+		t.Fatalf("len(added) = %d, want 1", len(added))
+	}
+	if added[0].ID != "F8" {
+		t.Errorf("ID = %q, want %q", added[0].ID, "F8")
+	}
+	if added[0].Status != features.StatusPending {
+		t.Errorf("Status = %q, want %q", added[0].Status, features.StatusPending)
+	}
+	if added[0].Description != "FIXME at db.go:8: close rows" {
+		t.Errorf("Description = %q", added[0].Description)
+	}
+	if len(data.Features) != 4 {
+		t.Errorf("len(Features) = %d, want 4", len(data.Features))
+	}
+}
@@ -0,0 +1,183 @@
+// Package tree summarizes a project's directory structure: a depth-limited
+// tree of directories annotated with file counts and languages, so an agent
+// starting to explore gets oriented without spending tool calls on ls and
+// Glob.
+//
+// In a git repository the files come from git, so .gitignore is honored;
+// elsewhere the tree is walked, skipping hidden and dependency directories.
+package tree
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ultraharness/internal/git"
+)
+
+// MaxWalkFiles caps the files counted when walking a tree outside git.
+const MaxWalkFiles = 20000
+
+// maxLanguages is the number of languages listed per directory.
+const maxLanguages = 3
+
+// maxChildren is the number of subdirectories listed per directory.
+const maxChildren = 15
+
+// skipDirs are dependency and build directories skipped outside git.
+var skipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "__pycache__": true, "venv": true, "bin": true, "obj": true,
+}
+
+// languages maps file extensions to language names.
+var languages = map[string]string{
+	".go": "Go", ".py": "Python", ".rb": "Ruby", ".rs": "Rust", ".java": "Java",
+	".kt": "Kotlin", ".swift": "Swift", ".php": "PHP", ".cs": "C#",
+	".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++",
+	".sh": "Shell", ".bash": "Shell", ".sql": "SQL", ".proto": "Protobuf", ".tf": "Terraform",
+	".html": "HTML", ".css": "CSS", ".scss": "CSS", ".vue": "Vue", ".svelte": "Svelte",
+	".md": "Markdown", ".json": "JSON", ".yaml": "YAML", ".yml": "YAML", ".toml": "TOML",
+}
+
+// Dir is a directory with the files below it.
+type Dir struct {
+	Name string
+	// Files counts the files in this directory and below
+	Files int
+	// Languages counts those files by language
+	Languages map[string]int
+	Dirs      map[string]*Dir
+}
+
+func newDir(name string) *Dir {
+	return &Dir{Name: name, Languages: map[string]int{}, Dirs: map[string]*Dir{}}
+}
+
+// Build makes a tree named name from slash-separated file paths.
+func Build(name string, files []string) *Dir {
+	root := newDir(name)
+	for _, f := range files {
+		lang := languages[strings.ToLower(path.Ext(f))]
+		parts := strings.Split(path.Clean(f), "/")
+		d := root
+		for i := 0; ; i++ {
+			d.Files++
+			if lang != "" {
+				d.Languages[lang]++
+			}
+			if i == len(parts)-1 {
+				break
+			}
+			child, ok := d.Dirs[parts[i]]
+			if !ok {
+				child = newDir(parts[i])
+				d.Dirs[parts[i]] = child
+			}
+			d = child
+		}
+	}
+	return root
+}
+
+// Summarize builds the tree of workDir.
+func Summarize(workDir string) (*Dir, error) {
+	name := filepath.Base(workDir)
+	if files, ok := git.ListFiles(workDir); ok {
+		return Build(name, files), nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(workDir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if p != workDir && (strings.HasPrefix(entry.Name(), ".") || skipDirs[entry.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(files) == MaxWalkFiles {
+			return filepath.SkipAll
+		}
+		if rel, err := filepath.Rel(workDir, p); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Build(name, files), nil
+}
+
+// Format renders the tree to depth levels below the root, one line per
+// directory, in at most maxLines lines.
+func (d *Dir) Format(depth, maxLines int) []string {
+	var lines []string
+	truncated := false
+	var walk func(dir *Dir, level int)
+	walk = func(dir *Dir, level int) {
+		if len(lines) == maxLines {
+			truncated = true
+			return
+		}
+		lines = append(lines, strings.Repeat("  ", level)+dir.describe())
+		if level == depth {
+			return
+		}
+		names := make([]string, 0, len(dir.Dirs))
+		for name := range dir.Dirs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			if i == maxChildren {
+				lines = append(lines, fmt.Sprintf("%s... and %d more directories", strings.Repeat("  ", level+1), len(names)-i))
+				break
+			}
+			walk(dir.Dirs[name], level+1)
+		}
+	}
+	walk(d, 0)
+	if truncated {
+		lines = append(lines, "... (truncated)")
+	}
+	return lines
+}
+
+// describe renders "name/ (N files: Go 12, Markdown 2)".
+func (d *Dir) describe() string {
+	noun := "files"
+	if d.Files == 1 {
+		noun = "file"
+	}
+	desc := fmt.Sprintf("%s/ (%d %s", d.Name, d.Files, noun)
+
+	langs := make([]string, 0, len(d.Languages))
+	for lang := range d.Languages {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if d.Languages[langs[i]] != d.Languages[langs[j]] {
+			return d.Languages[langs[i]] > d.Languages[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if len(langs) > maxLanguages {
+		langs = langs[:maxLanguages]
+	}
+	for i, lang := range langs {
+		langs[i] = fmt.Sprintf("%s %d", lang, d.Languages[lang])
+	}
+	if len(langs) > 0 {
+		desc += ": " + strings.Join(langs, ", ")
+	}
+	return desc + ")"
+}
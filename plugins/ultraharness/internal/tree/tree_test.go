@@ -0,0 +1,79 @@
+package tree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildAndFormat(t *testing.T) {
+	root := Build("app", []string{
+		"go.mod",
+		"README.md",
+		"cmd/server/main.go",
+		"internal/api/api.go",
+		"internal/api/api_test.go",
+		"internal/db/db.go",
+		"internal/db/schema.sql",
+		"web/index.ts",
+	})
+
+	want := []string{
+		"app/ (8 files: Go 4, Markdown 1, SQL 1)",
+		"  cmd/ (1 file: Go 1)",
+		"  internal/ (4 files: Go 3, SQL 1)",
+		"  web/ (1 file: TypeScript 1)",
+	}
+	if got := root.Format(1, 50); !reflect.DeepEqual(got, want) {
+		t.Errorf("Format(1) = %q, want %q", got, want)
+	}
+
+	got := root.Format(2, 50)
+	if len(got) != 7 || got[4] != "    api/ (2 files: Go 2)" {
+		t.Errorf("Format(2) = %q, want internal/api/ at depth 2", got)
+	}
+
+	got = root.Format(2, 3)
+	if len(got) != 4 || got[3] != "... (truncated)" {
+		t.Errorf("Format() over maxLines = %q, want 3 lines and a truncation note", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tree-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, f := range []string{"main.go", "pkg/a.go", "node_modules/x/index.js", ".cache/blob"} {
+		os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(f)), 0755)
+		os.WriteFile(filepath.Join(tmpDir, f), []byte("x"), 0644)
+	}
+
+	// Outside git, hidden and dependency directories are skipped
+	root, err := Summarize(tmpDir)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if root.Files != 2 || root.Dirs["node_modules"] != nil {
+		t.Errorf("Summarize() counted %d files in %v, want 2 without node_modules", root.Files, root.Format(1, 10))
+	}
+
+	// In git, .gitignore decides
+	if err := exec.Command("git", "-C", tmpDir, "init", "-q").Run(); err != nil {
+		t.Skip("git not available")
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("pkg/\n"), 0644)
+	root, err = Summarize(tmpDir)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	got := strings.Join(root.Format(1, 10), "\n")
+	if root.Dirs["pkg"] != nil || root.Dirs["node_modules"] == nil {
+		t.Errorf("Summarize() in git = %q, want pkg/ ignored and node_modules/ listed", got)
+	}
+}
@@ -0,0 +1,180 @@
+// Package watchdog catches runaway repetition: the same edit applied over
+// and over, the same command run again and again, and rounds of edits that
+// keep failing the tests. PostToolUse reports each loop with a directive
+// that escalates as loops recur, and in strict mode PreToolUse holds the
+// session until the plan is revised.
+package watchdog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/config"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/session"
+	"ultraharness/internal/text"
+)
+
+// Action kinds
+const (
+	KindEdit = "edit"
+	KindBash = "bash"
+)
+
+// Loop kinds
+const (
+	RepeatedEdit    = "repeated_edit"
+	RepeatedCommand = "repeated_command"
+	FailCycles      = "fail_cycles"
+)
+
+// maxLabel caps the command quoted in a loop report
+const maxLabel = 80
+
+// Loop is runaway repetition found in a session.
+type Loop struct {
+	Kind   string
+	Detail string
+}
+
+// ActionFor returns the action the watchdog remembers for a tool call:
+// Edit and Write by file and change, Bash by command. Other tools are
+// ignored.
+func ActionFor(input *protocol.HookInput) (session.Action, bool) {
+	switch input.ToolName {
+	case "Edit":
+		return session.Action{Kind: KindEdit, Label: input.GetFilePath(), Hash: hash(input.GetFilePath(),
+			input.GetOldString(), input.GetNewString(), fmt.Sprint(input.GetReplaceAll()))}, true
+	case "Write":
+		return session.Action{Kind: KindEdit, Label: input.GetFilePath(),
+			Hash: hash(input.GetFilePath(), input.GetContent())}, true
+	case "Bash":
+		command := strings.Join(strings.Fields(input.GetCommand()), " ")
+		if command == "" {
+			return session.Action{}, false
+		}
+		return session.Action{Kind: KindBash, Label: command, Hash: hash(command)}, true
+	}
+	return session.Action{}, false
+}
+
+func hash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Detect returns the loop in the session's recent activity, or nil if
+// there is none.
+func Detect(state *session.State, settings config.WatchdogConfig) *Loop {
+	counts := map[string]int{}
+	var edit, command session.Action
+	for _, a := range state.RecentActions {
+		counts[a.Hash]++
+		switch {
+		case a.Kind == KindEdit && counts[a.Hash] > counts[edit.Hash]:
+			edit = a
+		case a.Kind == KindBash && counts[a.Hash] > counts[command.Hash]:
+			command = a
+		}
+	}
+
+	if n := counts[edit.Hash]; edit.Hash != "" && n >= settings.RepeatedEdits {
+		return &Loop{Kind: RepeatedEdit,
+			Detail: fmt.Sprintf("the same edit to %s was applied %d times", filepath.Base(edit.Label), n)}
+	}
+	if state.FailCycles >= settings.FailCycles {
+		return &Loop{Kind: FailCycles,
+			Detail: fmt.Sprintf("%d rounds of edits in a row each ended with failing tests", state.FailCycles)}
+	}
+	if n := counts[command.Hash]; command.Hash != "" && n >= settings.RepeatedCommands {
+		label := text.Truncate(command.Label, maxLabel)
+		return &Loop{Kind: RepeatedCommand,
+			Detail: fmt.Sprintf("`%s` was run %d times in the last %d edits and commands", label, n, len(state.RecentActions))}
+	}
+	return nil
+}
+
+// Report counts a reported loop for escalation and clears the evidence for
+// it, so the next report needs fresh repetition.
+func Report(state *session.State) {
+	state.LoopWarnings++
+	state.RecentActions = nil
+	state.FailCycles = 0
+}
+
+// Directive tells the agent to step back from a loop. The first report
+// asks for a different approach; later ones demand a re-plan of the
+// current plan step. held adds that the session is blocked until the plan
+// is revised.
+func Directive(workDir string, loop *Loop, warnings int, held bool) string {
+	var msg string
+	if warnings <= 1 {
+		msg = fmt.Sprintf("[Harness] Watchdog: %s. Repeating the same approach is not converging.\n"+
+			"Step back before the next change: re-read the latest error, question the assumption behind this approach, and try a different one.",
+			loop.Detail)
+	} else {
+		msg = fmt.Sprintf("[Harness] WATCHDOG (loop %d this session): %s. You are still looping.\n"+
+			"STOP and re-plan %s: write down why the previous attempts failed and what you will do differently before changing anything else.",
+			warnings, loop.Detail, currentStep(workDir))
+	}
+	if held {
+		msg += fmt.Sprintf("\nIn strict mode, Edit, Write, and Bash are blocked until a revised plan is saved in %s/%s/.",
+			artifacts.ArtifactsDir, artifacts.ArtifactPlan)
+	}
+	return msg
+}
+
+// currentStep names the first unfinished step of the latest plan.
+func currentStep(workDir string) string {
+	latest, _ := artifacts.GetLatestArtifact(workDir, artifacts.ArtifactPlan)
+	plan, ok := latest.(*artifacts.Plan)
+	if !ok || plan == nil {
+		return "your approach"
+	}
+	for i, step := range plan.Steps {
+		if !step.Completed {
+			return fmt.Sprintf("step %d (%q)", i+1, step.Description)
+		}
+	}
+	return "your approach"
+}
+
+// Hold stops the session until the plan is revised.
+func Hold(workDir string, state *session.State, loop *Loop) {
+	state.LoopHold = &session.LoopHold{
+		Reason: loop.Detail,
+		Since:  time.Now(),
+		Plan:   artifacts.LatestVersion(workDir, artifacts.ArtifactPlan),
+	}
+}
+
+// Release lifts the hold once a plan artifact was saved or rewritten since
+// it began, returning true if the session is not held.
+func Release(workDir string, state *session.State) bool {
+	if state.LoopHold == nil {
+		return true
+	}
+	if artifacts.LatestVersion(workDir, artifacts.ArtifactPlan) == state.LoopHold.Plan {
+		return false
+	}
+	state.LoopHold = nil
+	return true
+}
+
+// IsPlanArtifact reports whether path is in the plan artifact directory,
+// which stays writable while the session is held.
+func IsPlanArtifact(workDir, path string) bool {
+	if path == "" {
+		return false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	rel, err := filepath.Rel(artifacts.GetArtifactDir(workDir, artifacts.ArtifactPlan), filepath.Clean(path))
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
@@ -0,0 +1,150 @@
+package watchdog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ultraharness/internal/artifacts"
+	"ultraharness/internal/config"
+	"ultraharness/internal/protocol"
+	"ultraharness/internal/session"
+)
+
+func input(tool string, fields map[string]interface{}) *protocol.HookInput {
+	return &protocol.HookInput{ToolName: tool, ToolInput: fields}
+}
+
+func TestActionFor(t *testing.T) {
+	edit := input("Edit", map[string]interface{}{"file_path": "/p/a.go", "old_string": "x", "new_string": "y"})
+	same := input("Edit", map[string]interface{}{"file_path": "/p/a.go", "old_string": "x", "new_string": "y"})
+	other := input("Edit", map[string]interface{}{"file_path": "/p/a.go", "old_string": "x", "new_string": "z"})
+
+	a, ok := ActionFor(edit)
+	if !ok || a.Kind != KindEdit || a.Label != "/p/a.go" {
+		t.Fatalf("ActionFor(Edit) = %+v, %v, want an edit of /p/a.go", a, ok)
+	}
+	if b, _ := ActionFor(same); b.Hash != a.Hash {
+		t.Error("ActionFor() hashes identical edits differently")
+	}
+	if c, _ := ActionFor(other); c.Hash == a.Hash {
+		t.Error("ActionFor() hashes different edits the same")
+	}
+
+	cmd, ok := ActionFor(input("Bash", map[string]interface{}{"command": "go  test ./..."}))
+	if !ok || cmd.Kind != KindBash || cmd.Label != "go test ./..." {
+		t.Errorf("ActionFor(Bash) = %+v, %v, want the normalized command", cmd, ok)
+	}
+	if _, ok := ActionFor(input("Read", map[string]interface{}{"file_path": "/p/a.go"})); ok {
+		t.Error("ActionFor(Read) = true, want Read ignored")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	settings := (&config.Config{}).GetWatchdog()
+
+	t.Run("repeated edit", func(t *testing.T) {
+		state := session.NewState("test")
+		for i := 0; i < settings.RepeatedEdits; i++ {
+			state.RecordAction(session.Action{Kind: KindEdit, Hash: "e1", Label: "/p/app.go"})
+			state.RecordAction(session.Action{Kind: KindEdit, Hash: "e2", Label: "/p/app.go"})
+		}
+		loop := Detect(state, settings)
+		if loop == nil || loop.Kind != RepeatedEdit || !strings.Contains(loop.Detail, "app.go was applied 3 times") {
+			t.Errorf("Detect() = %+v, want a repeated edit to app.go", loop)
+		}
+	})
+
+	t.Run("repeated command", func(t *testing.T) {
+		state := session.NewState("test")
+		for i := 0; i < settings.RepeatedCommands-1; i++ {
+			state.RecordAction(session.Action{Kind: KindBash, Hash: "b", Label: "make"})
+		}
+		if loop := Detect(state, settings); loop != nil {
+			t.Errorf("Detect() below the threshold = %+v, want nil", loop)
+		}
+		state.RecordAction(session.Action{Kind: KindBash, Hash: "b", Label: "make"})
+		loop := Detect(state, settings)
+		if loop == nil || loop.Kind != RepeatedCommand || !strings.Contains(loop.Detail, "`make` was run 5 times") {
+			t.Errorf("Detect() = %+v, want a repeated make", loop)
+		}
+	})
+
+	t.Run("edit and test-fail cycles", func(t *testing.T) {
+		state := session.NewState("test")
+		for i := 0; i < settings.FailCycles; i++ {
+			state.RecordAction(session.Action{Kind: KindEdit, Hash: string(rune('a' + i)), Label: "/p/app.go"})
+			state.RecordTestRun(false)
+		}
+		loop := Detect(state, settings)
+		if loop == nil || loop.Kind != FailCycles {
+			t.Fatalf("Detect() = %+v, want fail cycles", loop)
+		}
+
+		Report(state)
+		if state.LoopWarnings != 1 || Detect(state, settings) != nil {
+			t.Errorf("after Report(): LoopWarnings = %d, Detect() = %+v, want 1 and nil",
+				state.LoopWarnings, Detect(state, settings))
+		}
+	})
+}
+
+func TestDirective(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "watchdog-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	loop := &Loop{Kind: RepeatedCommand, Detail: "`make` was run 5 times"}
+	first := Directive(tmpDir, loop, 1, false)
+	if !strings.Contains(first, "Step back") || strings.Contains(first, "blocked") {
+		t.Errorf("Directive() for the first loop = %q, want a step-back directive", first)
+	}
+
+	artifacts.SaveArtifact(tmpDir, artifacts.ArtifactPlan, &artifacts.Plan{Steps: []artifacts.PlanStep{
+		{ID: "1", Description: "Add model", Completed: true},
+		{ID: "2", Description: "Wire handler"},
+	}})
+	second := Directive(tmpDir, loop, 2, true)
+	if !strings.Contains(second, `re-plan step 2 ("Wire handler")`) || !strings.Contains(second, "blocked until a revised plan") {
+		t.Errorf("Directive() for a repeat loop = %q, want a re-plan of step 2 and the hold", second)
+	}
+}
+
+func TestHoldAndRelease(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "watchdog-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	state := session.NewState("test")
+	Hold(tmpDir, state, &Loop{Detail: "looping"})
+	if Release(tmpDir, state) || state.LoopHold == nil {
+		t.Fatal("Release() = true without a new plan, want the session held")
+	}
+
+	artifacts.SaveArtifact(tmpDir, artifacts.ArtifactPlan, &artifacts.Plan{Goal: "revised"})
+	if !Release(tmpDir, state) || state.LoopHold != nil {
+		t.Error("Release() = false after a plan was saved, want the hold lifted")
+	}
+}
+
+func TestIsPlanArtifact(t *testing.T) {
+	workDir := "/project"
+	tests := map[string]bool{
+		filepath.Join(workDir, ".claude/fic-artifacts/plan/20250101-120000.json"): true,
+		".claude/fic-artifacts/plan/revised.json":                                 true,
+		filepath.Join(workDir, ".claude/fic-artifacts/plan"):                      false,
+		filepath.Join(workDir, ".claude/fic-artifacts/research/r.json"):           false,
+		filepath.Join(workDir, "src/plan/main.go"):                                false,
+		"": false,
+	}
+	for path, want := range tests {
+		if got := IsPlanArtifact(workDir, path); got != want {
+			t.Errorf("IsPlanArtifact(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package writeguard inspects Write content for oversized files and binary
+// or base64-encoded blobs, which usually mean the agent is dumping
+// generated assets into the repository.
+package writeguard
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SampleSize is how much content is inspected for binary data.
+const SampleSize = 8 * 1024
+
+// MinBlobLength is the shortest base64 run reported as a blob.
+const MinBlobLength = 4096
+
+// minBlobLineLength is the shortest line counted as part of a wrapped
+// base64 blob; shorter lines are more likely to be identifiers.
+const minBlobLineLength = 60
+
+// Check returns the reasons content written to path looks like a
+// generated asset. maxBytes of zero disables the size check.
+func Check(path, content string, maxBytes int) []string {
+	var findings []string
+
+	if maxBytes > 0 && len(content) > maxBytes {
+		findings = append(findings, fmt.Sprintf(
+			"%s is %s, over the %s write limit", path, formatSize(len(content)), formatSize(maxBytes)))
+	}
+
+	if IsBinary(content) {
+		findings = append(findings, fmt.Sprintf(
+			"%s content looks like binary data (%s)", path, formatSize(len(content))))
+	} else if n := LongestBase64Run(content); n >= MinBlobLength {
+		findings = append(findings, fmt.Sprintf(
+			"%s contains a %s base64-encoded blob", path, formatSize(n)))
+	}
+
+	return findings
+}
+
+// IsBinary reports whether content looks like binary data: it contains NUL
+// bytes, or more than a tenth of it is invalid UTF-8 or control characters.
+func IsBinary(content string) bool {
+	sample := content
+	if len(sample) > SampleSize {
+		sample = sample[:SampleSize]
+	}
+	if sample == "" {
+		return false
+	}
+	if strings.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	suspicious := 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRuneInString(sample[i:])
+		// A rune cut off by the sample boundary is not evidence of binary data
+		if r == utf8.RuneError && size == 1 && len(sample)-i >= utf8.UTFMax {
+			suspicious++
+		} else if r < 0x20 && r != '\n' && r != '\r' && r != '\t' && r != '\f' {
+			suspicious++
+		}
+		i += size
+	}
+	return suspicious*10 > len(sample)
+}
+
+// LongestBase64Run returns the length of the longest base64 blob in
+// content: a single long token of base64 characters, or consecutive long
+// lines made only of base64 characters (wrapped encodings).
+func LongestBase64Run(content string) int {
+	longest, run := 0, 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// Long tokens embedded in a line, such as data: URIs
+		for _, token := range strings.FieldsFunc(line, func(r rune) bool { return !isBase64Char(r) }) {
+			if len(token) > longest && hasBase64Mix(token) {
+				longest = len(token)
+			}
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) >= minBlobLineLength && isBase64Line(trimmed) {
+			run += len(trimmed)
+		} else {
+			run = 0
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+func isBase64Char(r rune) bool {
+	return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' ||
+		r == '+' || r == '/' || r == '=' || r == '-' || r == '_'
+}
+
+func isBase64Line(line string) bool {
+	for _, r := range line {
+		if !isBase64Char(r) {
+			return false
+		}
+	}
+	return hasBase64Mix(line)
+}
+
+// hasBase64Mix requires upper case, lower case, and digits, so long runs of
+// a single character class (separators, hex digests, words) are not blobs.
+func hasBase64Mix(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+func formatSize(n int) string {
+	if n >= 1024 {
+		return fmt.Sprintf("%d KB", n/1024)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,49 @@
+package writeguard
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	blob := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x89PNG\r\n\x1a\n binary asset data ", 400)))
+
+	// Wrapped at 76 columns like MIME or PEM encodings
+	var wrapped strings.Builder
+	for i := 0; i < len(blob); i += 76 {
+		end := i + 76
+		if end > len(blob) {
+			end = len(blob)
+		}
+		wrapped.WriteString(blob[i:end] + "\n")
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		maxBytes int
+		want     string
+	}{
+		{"source code", strings.Repeat("func main() {\n\tfmt.Println(\"hello\")\n}\n", 50), 1024 * 1024, ""},
+		{"oversized", strings.Repeat("x", 2048), 1024, "over the 1 KB write limit"},
+		{"size check disabled", strings.Repeat("x", 2048), 0, ""},
+		{"nul bytes", "PK\x03\x04\x00\x00data", 0, "binary data"},
+		{"inline base64", `const logo = "data:image/png;base64,` + blob + `"`, 0, "base64-encoded blob"},
+		{"wrapped base64", wrapped.String(), 0, "base64-encoded blob"},
+		{"hex digests", strings.Repeat("3f786850e387550fdab836ed7e6dc881de23001b\n", 200), 0, ""},
+		{"utf-8 text", strings.Repeat("héllo wörld — ünïcode ✓\n", 100), 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(Check("asset.txt", tt.content, tt.maxBytes), "; ")
+			if tt.want == "" && got != "" {
+				t.Errorf("Check() = %q, want no findings", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("Check() = %q, want finding containing %q", got, tt.want)
+			}
+		})
+	}
+}